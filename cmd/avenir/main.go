@@ -1,14 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"avenir/internal/dap"
 	"avenir/internal/ir"
 	"avenir/internal/modules"
 	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
 	"avenir/internal/types"
 	"avenir/internal/vm"
 )
@@ -26,6 +33,10 @@ func main() {
 	switch cmd {
 	case "run":
 		if err := cmdRun(os.Args[2:]); err != nil {
+			var exitErr *builtins.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Code)
+			}
 			fmt.Fprintln(os.Stderr, "error:", err)
 			os.Exit(1)
 		}
@@ -34,6 +45,21 @@ func main() {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			os.Exit(1)
 		}
+	case "disasm":
+		if err := cmdDisasm(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	case "debug":
+		if err := cmdDebug(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	case "verify":
+		if err := cmdVerify(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
 	case "help", "-h", "--help":
 		usage()
 	case "version", "-v", "--version":
@@ -49,73 +75,270 @@ func usage() {
 	fmt.Println(`Avenir language CLI
 
 Usage:
-  avenir run <file.av|file.avc>
-  avenir build <file.av> [-o out.avc] [-target=bytecode|native]
+  avenir run [flags] <file.av|file.avc> [-- args...]
+  avenir build <file.av> [-o out.avc] [-O] [-target=bytecode|native]
+  avenir verify <file.avc>
+  avenir disasm [-json] [-O] <file.av|file.avc>
+  avenir debug [-listen addr]
 
 Commands:
   version  Avenir Language version
-  run      Compile+run .av source or run .avc bytecode
+  run      Compile+run .av source or run .avc bytecode; args after the file go to os.args()
   build    Compile .av source into .avc file
+  verify   Check that an .avc file is well-formed bytecode
+  disasm   Print the bytecode of a .av or .avc file
+  debug    Serve the Debug Adapter Protocol on stdin/stdout for editors
+
+Flags (run):
+  --allow-read[=paths]     Allow reading the listed files and directories (all if no list)
+  --allow-write[=paths]    Allow writing the listed files and directories (all if no list)
+  --allow-net[=addrs]      Allow connecting and listening on host:port, host or *:port
+  --allow-connect[=addrs]  Allow outgoing connections only
+  --allow-listen[=addrs]   Allow listening only
+  --allow-sql              Allow SQL connections
+  --allow-run              Allow running subprocesses
+  --allow-env              Allow reading and changing environment variables
+  --sandbox                Deny everything not allowed by the flags above
+  Any --allow-* flag implies --sandbox. Relative paths are resolved against
+  the script's directory; comma-separate multiple entries.
+  --cpuprofile=file        Write a CPU profile of Avenir functions in pprof format
+  --memprofile=file        Write lists, dicts, structs, strings and closures allocated per call site
+  --builtinprofile=file    Write the calls and time spent per builtin
 
 Flags (build):
   -o       Output file name (default: <input>.avc)
-  -target  Build target: "bytecode" (default) or "native" (native not implemented yet)`)
+  -O       Optimize the bytecode: fold constants, thread jumps, drop dead code, fuse instructions
+  -target  Build target: "bytecode" (default) or "native" (native not implemented yet)
+
+Flags (disasm):
+  -json    Print the listing as JSON
+  -O       Optimize the bytecode first, as build -O does
+
+Flags (debug):
+  -listen  Serve one client on a TCP address instead, e.g. localhost:4711`)
 }
 
 // -------------- RUN --------------
 
 func cmdRun(args []string) error {
-	if len(args) < 1 {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var perms permFlags
+	perms.register(fs)
+	var prof profileFlags
+	prof.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
 		return fmt.Errorf("run: missing input file")
 	}
-	input := args[0]
+	input := fs.Arg(0)
+	progArgs := fs.Args()[1:]
+	if len(progArgs) > 0 && progArgs[0] == "--" {
+		progArgs = progArgs[1:]
+	}
 	ext := filepath.Ext(input)
 
+	var mod *ir.Module
 	switch ext {
 	case ".av":
 		// компиляция в памяти → VM
-		mod, err := compileSourceFile(input)
+		var err error
+		mod, err = compileSourceFile(input)
 		if err != nil {
 			return err
 		}
-		env := runtime.DefaultEnv()
-		absInput, err := filepath.Abs(input)
-		if err == nil {
-			env.SetExecRoot(filepath.Dir(absInput))
-		}
-		m := vm.NewVM(mod, env)
-		_, err = m.RunMain()
-		return err
 	case ".avc":
 		// запуск байткода
-		mod, err := ir.ReadModuleFromFile(input)
+		var err error
+		mod, err = ir.ReadModuleFromFile(input)
 		if err != nil {
 			return fmt.Errorf("failed to read bytecode: %w", err)
 		}
-		env := runtime.DefaultEnv()
-		absInput, err := filepath.Abs(input)
-		if err == nil {
-			env.SetExecRoot(filepath.Dir(absInput))
-		}
-		m := vm.NewVM(mod, env)
-		_, err = m.RunMain()
-		return err
 	default:
 		return fmt.Errorf("run: unsupported file extension %q (use .av or .avc)", ext)
 	}
+
+	env := runtime.DefaultEnv()
+	absInput, err := filepath.Abs(input)
+	if err == nil {
+		env.SetExecRoot(filepath.Dir(absInput))
+	}
+	env.SetArgs(progArgs)
+	if perms.enabled() {
+		if err := env.SetPermissions(perms.permissions()); err != nil {
+			return fmt.Errorf("run: %w", err)
+		}
+	}
+	m := vm.NewVM(mod, env)
+	profiler := prof.start(m)
+	_, err = m.RunMain()
+	if profiler != nil {
+		if werr := prof.write(profiler); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// profileFlags holds the profiling flags of `avenir run`.
+type profileFlags struct {
+	cpu, mem, builtins string
+}
+
+func (p *profileFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.cpu, "cpuprofile", "", "write a CPU profile of the Avenir call stack to this file")
+	fs.StringVar(&p.mem, "memprofile", "", "write the values allocated per call site to this file")
+	fs.StringVar(&p.builtins, "builtinprofile", "", "write the calls and time per builtin to this file")
+}
+
+// start attaches a profiler to m if any profile was asked for.
+func (p *profileFlags) start(m *vm.VM) *vm.Profiler {
+	if p.cpu == "" && p.mem == "" && p.builtins == "" {
+		return nil
+	}
+	profiler := vm.NewProfiler()
+	m.SetProfiler(profiler)
+	if p.cpu != "" {
+		profiler.Start()
+	}
+	return profiler
+}
+
+// write stops profiler and writes the profiles asked for.
+func (p *profileFlags) write(profiler *vm.Profiler) error {
+	profiler.Stop()
+	for _, prof := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{p.cpu, profiler.WriteCPUProfile},
+		{p.mem, profiler.WriteAllocProfile},
+		{p.builtins, profiler.WriteBuiltinProfile},
+	} {
+		if prof.path == "" {
+			continue
+		}
+		f, err := os.Create(prof.path)
+		if err != nil {
+			return fmt.Errorf("run: %w", err)
+		}
+		err = prof.write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("run: writing %s: %w", prof.path, err)
+		}
+	}
+	return nil
+}
+
+// -------------- PERMISSIONS --------------
+
+// listFlag collects comma-separated values from repeated flags. Given
+// without a value it stands for "everything".
+type listFlag struct {
+	set   bool
+	all   bool
+	items []string
+}
+
+func (l *listFlag) String() string { return strings.Join(l.items, ",") }
+
+func (l *listFlag) IsBoolFlag() bool { return true }
+
+func (l *listFlag) Set(v string) error {
+	l.set = true
+	if v == "true" {
+		l.all = true
+		return nil
+	}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			l.items = append(l.items, item)
+		}
+	}
+	return nil
+}
+
+// permFlags holds the --allow-* flags of `avenir run`.
+type permFlags struct {
+	read, write, net, connect, listen listFlag
+	sql, run, env, sandbox            bool
+}
+
+func (p *permFlags) register(fs *flag.FlagSet) {
+	fs.Var(&p.read, "allow-read", "allow reading these paths")
+	fs.Var(&p.write, "allow-write", "allow writing these paths")
+	fs.Var(&p.net, "allow-net", "allow connecting to and listening on these addresses")
+	fs.Var(&p.connect, "allow-connect", "allow connecting to these addresses")
+	fs.Var(&p.listen, "allow-listen", "allow listening on these addresses")
+	fs.BoolVar(&p.sql, "allow-sql", false, "allow SQL connections")
+	fs.BoolVar(&p.run, "allow-run", false, "allow running subprocesses")
+	fs.BoolVar(&p.env, "allow-env", false, "allow environment access")
+	fs.BoolVar(&p.sandbox, "sandbox", false, "deny everything not explicitly allowed")
+}
+
+func (p *permFlags) enabled() bool {
+	return p.sandbox || p.sql || p.run || p.env ||
+		p.read.set || p.write.set || p.net.set || p.connect.set || p.listen.set
+}
+
+func (p *permFlags) permissions() *runtime.Permissions {
+	paths := func(l listFlag) []string {
+		if l.all {
+			return []string{string(filepath.Separator)}
+		}
+		return l.items
+	}
+	addrs := func(lists ...listFlag) []string {
+		var out []string
+		for _, l := range lists {
+			if l.all {
+				return []string{"*"}
+			}
+			out = append(out, l.items...)
+		}
+		return out
+	}
+	return &runtime.Permissions{
+		Read:    paths(p.read),
+		Write:   paths(p.write),
+		Connect: addrs(p.net, p.connect),
+		Listen:  addrs(p.net, p.listen),
+		SQL:     p.sql,
+		Process: p.run,
+		Env:     p.env,
+	}
 }
 
 // -------------- BUILD --------------
 
+// optimizeModule runs the bytecode optimizer and checks that its output
+// still verifies, so that build -O never writes a file the loader rejects.
+func optimizeModule(mod *ir.Module) error {
+	ir.Optimize(mod)
+	if err := ir.Verify(mod); err != nil {
+		return fmt.Errorf("optimized bytecode does not verify: %w", err)
+	}
+	return nil
+}
+
 func cmdBuild(args []string) error {
 	fs := flag.NewFlagSet("build", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
 	var out string
 	var target string
+	var optimize bool
 
 	fs.StringVar(&out, "o", "", "output file (default: <input>.avc)")
 	fs.StringVar(&target, "target", "bytecode", "build target: bytecode|native")
+	fs.BoolVar(&optimize, "O", false, "optimize the bytecode")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -146,6 +369,11 @@ func cmdBuild(args []string) error {
 	if err != nil {
 		return err
 	}
+	if optimize {
+		if err := optimizeModule(mod); err != nil {
+			return err
+		}
+	}
 
 	if err := ir.WriteModuleToFile(out, mod); err != nil {
 		return fmt.Errorf("failed to write bytecode: %w", err)
@@ -154,6 +382,156 @@ func cmdBuild(args []string) error {
 	return nil
 }
 
+// -------------- VERIFY --------------
+
+// cmdVerify reads a bytecode file, which runs the verifier, and lists the
+// problems it found.
+func cmdVerify(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("verify: expected one .avc file")
+	}
+	input := args[0]
+	if filepath.Ext(input) != ".avc" {
+		return fmt.Errorf("verify: input must be .avc bytecode file")
+	}
+	if _, err := ir.ReadModuleFromFile(input); err != nil {
+		var problems interface{ Unwrap() []error }
+		if !errors.As(err, &problems) {
+			return fmt.Errorf("failed to read bytecode: %w", err)
+		}
+		for _, p := range problems.Unwrap() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input, p)
+		}
+		return fmt.Errorf("verify: %d problems in %s", len(problems.Unwrap()), input)
+	}
+	fmt.Printf("%s: ok\n", input)
+	return nil
+}
+
+// -------------- DEBUG --------------
+
+// cmdDebug serves one debugging session of the Debug Adapter Protocol. The
+// client names the program in its launch request.
+func cmdDebug(args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var listen string
+	fs.StringVar(&listen, "listen", "", "serve one client on this TCP address")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("debug: unexpected arguments; the client names the program to launch")
+	}
+
+	if listen == "" {
+		return dap.NewServer(os.Stdin, os.Stdout, loadProgram).Serve()
+	}
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("debug: %w", err)
+	}
+	defer ln.Close()
+	fmt.Fprintf(os.Stderr, "debug: listening on %s\n", ln.Addr())
+	conn, err := ln.Accept()
+	if err != nil {
+		return fmt.Errorf("debug: %w", err)
+	}
+	defer conn.Close()
+	return dap.NewServer(conn, conn, loadProgram).Serve()
+}
+
+// loadProgram compiles a .av file or reads a .avc file.
+func loadProgram(path string) (*ir.Module, error) {
+	switch filepath.Ext(path) {
+	case ".av":
+		return compileSourceFile(path)
+	case ".avc":
+		mod, err := ir.ReadModuleFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bytecode: %w", err)
+		}
+		return mod, nil
+	}
+	return nil, fmt.Errorf("unsupported file extension %q (use .av or .avc)", filepath.Ext(path))
+}
+
+// -------------- DISASM --------------
+
+func cmdDisasm(args []string) error {
+	fs := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var asJSON bool
+	var optimize bool
+	fs.BoolVar(&asJSON, "json", false, "print the listing as JSON")
+	fs.BoolVar(&optimize, "O", false, "optimize the bytecode first, as avenir build -O does")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("disasm: expected one .av or .avc file")
+	}
+	input := fs.Arg(0)
+
+	var mod *ir.Module
+	var err error
+	switch filepath.Ext(input) {
+	case ".av":
+		mod, err = compileSourceFile(input)
+		if err != nil {
+			return err
+		}
+	case ".avc":
+		mod, err = ir.ReadModuleFromFile(input)
+		if err != nil {
+			return fmt.Errorf("failed to read bytecode: %w", err)
+		}
+	default:
+		return fmt.Errorf("disasm: unsupported file extension %q (use .av or .avc)", filepath.Ext(input))
+	}
+
+	if optimize {
+		if err := optimizeModule(mod); err != nil {
+			return err
+		}
+	}
+
+	listing := ir.Disassemble(mod)
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing)
+	}
+	return listing.WriteText(os.Stdout, sourceLines())
+}
+
+// sourceLines returns an ir.SourceFunc that reads source files from disk,
+// caching them. Missing files are skipped.
+func sourceLines() ir.SourceFunc {
+	cache := make(map[string][]string)
+	return func(file string, n int) (string, bool) {
+		if file == "" {
+			return "", false
+		}
+		lines, ok := cache[file]
+		if !ok {
+			data, err := os.ReadFile(file)
+			if err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+			cache[file] = lines
+		}
+		if n < 1 || n > len(lines) {
+			return "", false
+		}
+		return lines[n-1], true
+	}
+}
+
 // -------------- Unified compilation pipeline: .av -> *ir.Module --------------
 
 // compileSourceFile compiles a source file using the unified module-based pipeline.
@@ -184,6 +562,7 @@ func compileSourceFile(path string) (*ir.Module, error) {
 			Name:  modName,
 			Prog:  modAST.Prog,
 			Scope: nil, // Will be set by CheckWorldWithBindings
+			File:  modAST.FilePath,
 		}
 	}
 
@@ -4,7 +4,10 @@ go 1.24.0
 
 require golang.org/x/crypto v0.24.0
 
-require github.com/lib/pq v1.11.2
+require (
+	github.com/lib/pq v1.11.2
+	golang.org/x/text v0.16.0
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -15,7 +18,6 @@ require (
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
 	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	golang.org/x/text v0.16.0 // indirect
 	modernc.org/libc v1.67.6 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
@@ -211,6 +211,14 @@ type DictType struct {
 func (t *DictType) Pos() token.Position { return t.DictPos }
 func (t *DictType) typeNode()           {}
 
+type SetType struct {
+	SetPos   token.Position
+	ElemType TypeNode
+}
+
+func (t *SetType) Pos() token.Position { return t.SetPos }
+func (t *SetType) typeNode()           {}
+
 type FuncType struct {
 	FunPos     token.Position
 	ParamTypes []TypeNode
@@ -412,8 +420,13 @@ type ForStmt struct {
 func (s *ForStmt) Pos() token.Position { return s.ForPos }
 func (s *ForStmt) stmtNode()           {}
 
+// ForEachStmt is `for (v in x)` or `for (k, v in x)`. Over a list, KeyName
+// is the index and VarName the element; over a dict, a single VarName is
+// the key, and with KeyName it is the value.
 type ForEachStmt struct {
 	ForPos   token.Position
+	KeyName  string // "" without a key variable
+	KeyPos   token.Position
 	VarName  string
 	VarPos   token.Position
 	ListExpr Expr
@@ -527,10 +540,14 @@ type ListLiteral struct {
 func (e *ListLiteral) Pos() token.Position { return e.LBracket }
 func (e *ListLiteral) exprNode()           {}
 
+// DictEntry is one entry of a dict literal. A bare name or string literal
+// before the colon is a string key in Key; any other key is an expression
+// in KeyExpr.
 type DictEntry struct {
-	Key    string
-	KeyPos token.Position
-	Value  Expr
+	Key     string
+	KeyExpr Expr // nil for string keys
+	KeyPos  token.Position
+	Value   Expr
 }
 
 func (e *DictEntry) Pos() token.Position { return e.KeyPos }
@@ -544,6 +561,18 @@ type DictLiteral struct {
 func (e *DictLiteral) Pos() token.Position { return e.LBrace }
 func (e *DictLiteral) exprNode()           {}
 
+// SetLiteral is set{a, b} or, with an explicit element type, set<T>{a, b}.
+type SetLiteral struct {
+	SetPos   token.Position
+	ElemType TypeNode // nil when inferred from the elements
+	LBrace   token.Position
+	Elements []Expr
+	RBrace   token.Position
+}
+
+func (e *SetLiteral) Pos() token.Position { return e.SetPos }
+func (e *SetLiteral) exprNode()           {}
+
 type StructLiteral struct {
 	TypeName    string
 	TypeNamePos token.Position
@@ -571,6 +600,7 @@ type FuncLiteral struct {
 	Return        TypeNode
 	Throws        []TypeNode // error types after "!"
 	Body          *BlockStmt
+	IsAsync       bool // async fun(...) literal; calling it returns Future<Return>
 }
 
 func (e *FuncLiteral) Pos() token.Position { return e.FunPos }
@@ -661,6 +691,16 @@ type AwaitExpr struct {
 func (e *AwaitExpr) Pos() token.Position { return e.AwaitPos }
 func (e *AwaitExpr) exprNode()           {}
 
+// SpawnExpr runs a call as a separate async task: spawn f(args).
+// The call is evaluated on the scheduler and the expression yields Future<T>.
+type SpawnExpr struct {
+	SpawnPos token.Position
+	Call     *CallExpr
+}
+
+func (e *SpawnExpr) Pos() token.Position { return e.SpawnPos }
+func (e *SpawnExpr) exprNode()           {}
+
 // ValuePackExpansion represents a variadic value pack expansion in expression position: args...
 type ValuePackExpansion struct {
 	Name    string
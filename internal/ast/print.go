@@ -127,6 +127,10 @@ func fprintNode(w io.Writer, n Node, indent int) {
 		fmt.Fprintf(w, "%sDictType\n", ind)
 		fprintNode(w, n.ValueType, indent+1)
 
+	case *SetType:
+		fmt.Fprintf(w, "%sSetType\n", ind)
+		fprintNode(w, n.ElemType, indent+1)
+
 	case *UnionType:
 		fmt.Fprintf(w, "%sUnionType\n", ind)
 		for _, t := range n.Variants {
@@ -240,7 +244,11 @@ func fprintNode(w io.Writer, n Node, indent int) {
 		fprintNode(w, n.Body, indent+2)
 
 	case *ForEachStmt:
-		fmt.Fprintf(w, "%sForEachStmt var=%s\n", ind, n.VarName)
+		if n.KeyName != "" {
+			fmt.Fprintf(w, "%sForEachStmt key=%s var=%s\n", ind, n.KeyName, n.VarName)
+		} else {
+			fmt.Fprintf(w, "%sForEachStmt var=%s\n", ind, n.VarName)
+		}
 		fmt.Fprintf(w, "%s  ListExpr:\n", ind)
 		fprintNode(w, n.ListExpr, indent+2)
 		fmt.Fprintf(w, "%s  Body:\n", ind)
@@ -308,10 +316,25 @@ func fprintNode(w io.Writer, n Node, indent int) {
 			fprintNode(w, el, indent+1)
 		}
 
+	case *SetLiteral:
+		fmt.Fprintf(w, "%sSetLiteral\n", ind)
+		if n.ElemType != nil {
+			fprintNode(w, n.ElemType, indent+1)
+		}
+		for _, el := range n.Elements {
+			fprintNode(w, el, indent+1)
+		}
+
 	case *DictLiteral:
 		fmt.Fprintf(w, "%sDictLiteral\n", ind)
 		for _, entry := range n.Entries {
-			fmt.Fprintf(w, "%s  Key %q:\n", ind, entry.Key)
+			if entry.KeyExpr != nil {
+				fmt.Fprintf(w, "%s  Key:\n", ind)
+				fprintNode(w, entry.KeyExpr, indent+2)
+				fmt.Fprintf(w, "%s  Value:\n", ind)
+			} else {
+				fmt.Fprintf(w, "%s  Key %q:\n", ind, entry.Key)
+			}
 			fprintNode(w, entry.Value, indent+2)
 		}
 
@@ -355,7 +378,11 @@ func fprintNode(w io.Writer, n Node, indent int) {
 		fprintNode(w, n.Value, indent+1)
 
 	case *FuncLiteral:
-		fmt.Fprintf(w, "%sFuncLiteral\n", ind)
+		if n.IsAsync {
+			fmt.Fprintf(w, "%sFuncLiteral async\n", ind)
+		} else {
+			fmt.Fprintf(w, "%sFuncLiteral\n", ind)
+		}
 		if len(n.Params) > 0 {
 			fmt.Fprintf(w, "%s  Params:\n", ind)
 			for _, p := range n.Params {
@@ -371,6 +398,10 @@ func fprintNode(w io.Writer, n Node, indent int) {
 		fmt.Fprintf(w, "%sAwaitExpr\n", ind)
 		fprintNode(w, n.Expr, indent+1)
 
+	case *SpawnExpr:
+		fmt.Fprintf(w, "%sSpawnExpr\n", ind)
+		fprintNode(w, n.Call, indent+1)
+
 	default:
 		fmt.Fprintf(w, "%s<unknown node %T>\n", ind, n)
 	}
@@ -0,0 +1,415 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"avenir/internal/ir"
+	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/vm"
+)
+
+// Server debugs one program for a client connected over r and w. The
+// program runs on its own goroutine once the client finishes configuring
+// breakpoints; what it prints is sent to the client as output events.
+type Server struct {
+	// Compile loads the program named by the launch request.
+	Compile func(program string) (*ir.Module, error)
+
+	r   *bufio.Reader
+	w   io.Writer
+	wmu sync.Mutex
+	seq int
+
+	mod      *ir.Module
+	machine  *vm.VM
+	debugger *vm.Debugger
+	noDebug  bool // run without stopping, as asked by the launch request
+	running  bool
+	exited   chan struct{} // closed when the program has ended
+
+	// frames maps the frame IDs given out since the program stopped to
+	// their task and depth.
+	frames []frameRef
+}
+
+type frameRef struct {
+	thread, depth int
+}
+
+// NewServer returns a server reading requests from r and writing responses
+// and events to w.
+func NewServer(r io.Reader, w io.Writer, compile func(program string) (*ir.Module, error)) *Server {
+	return &Server{Compile: compile, r: bufio.NewReader(r), w: w, exited: make(chan struct{})}
+}
+
+// Serve handles requests until the client disconnects or closes the
+// connection. A program still running is terminated.
+func (s *Server) Serve() error {
+	defer s.shutdown()
+	for {
+		data, err := readMessage(s.r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		body, err := s.handle(&req)
+		resp := response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		if err := s.send(&resp); err != nil {
+			return err
+		}
+		if err == nil {
+			s.after(&req)
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// shutdown terminates a running program and waits briefly for it to end;
+// a program waiting for timers or I/O ends when it runs again.
+func (s *Server) shutdown() {
+	if s.running {
+		s.debugger.Terminate()
+		select {
+		case <-s.exited:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// send writes a response or an event, numbering it.
+func (s *Server) send(msg any) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.seq++
+	switch m := msg.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+	return writeMessage(s.w, msg)
+}
+
+func (s *Server) event(name string, body any) {
+	s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) handle(req *request) (any, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(&args)
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(&args), nil
+	case "setExceptionBreakpoints":
+		return nil, nil
+	case "configurationDone":
+		if s.machine == nil {
+			return nil, errors.New("no program launched")
+		}
+		return nil, nil
+	case "threads":
+		threads := []thread{}
+		if s.debugger != nil {
+			for _, t := range s.debugger.Threads() {
+				threads = append(threads, thread{ID: t.ID, Name: t.Name})
+			}
+		}
+		return map[string]any{"threads": threads}, nil
+	case "stackTrace":
+		var args stackTraceArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		return s.stackTrace(&args)
+	case "scopes":
+		var args frameArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args variablesArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		if s.debugger == nil {
+			return nil, vm.ErrNotStopped
+		}
+		vars, err := s.debugger.Variables(args.VariablesReference)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]variable, len(vars))
+		for i, v := range vars {
+			list[i] = variable{Name: v.Name, Value: v.Value, Type: v.Type, VariablesReference: v.Ref}
+		}
+		return map[string]any{"variables": list}, nil
+	case "evaluate":
+		var args evaluateArguments
+		if err := unmarshal(req, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(&args)
+	case "continue", "next", "stepIn", "stepOut":
+		if s.debugger == nil {
+			return nil, vm.ErrNotStopped
+		}
+		if req.Command == "continue" {
+			return map[string]any{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	case "pause":
+		if s.debugger != nil {
+			s.debugger.Pause()
+		}
+		return nil, nil
+	case "terminate", "disconnect":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request %q", req.Command)
+}
+
+// after acts on a request once it has been answered, so that events it
+// causes follow the response.
+func (s *Server) after(req *request) {
+	switch req.Command {
+	case "launch":
+		s.event("initialized", nil)
+	case "configurationDone":
+		s.start()
+	case "continue", "next", "stepIn", "stepOut":
+		s.frames = nil
+		var err error
+		switch req.Command {
+		case "continue":
+			err = s.debugger.Continue()
+		case "next":
+			err = s.debugger.StepOver()
+		case "stepIn":
+			err = s.debugger.StepIn()
+		case "stepOut":
+			err = s.debugger.StepOut()
+		}
+		if err != nil {
+			s.output("console", err.Error()+"\n")
+		}
+	case "terminate":
+		if s.debugger != nil {
+			s.debugger.Terminate()
+		}
+	}
+}
+
+func unmarshal(req *request, args any) error {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Arguments, args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) launch(args *launchArguments) error {
+	if s.machine != nil {
+		return errors.New("a program is already launched")
+	}
+	if args.Program == "" {
+		return errors.New("launch: missing program")
+	}
+	mod, err := s.Compile(args.Program)
+	if err != nil {
+		return err
+	}
+	env := runtime.DefaultEnv()
+	if abs, err := filepath.Abs(args.Program); err == nil {
+		env.SetExecRoot(filepath.Dir(abs))
+	}
+	env.SetArgs(args.Args)
+	env.SetIO(outputIO{s})
+
+	s.mod = mod
+	s.noDebug = args.NoDebug
+	s.debugger = vm.NewDebugger(args.StopOnEntry && !args.NoDebug)
+	s.debugger.OnStop = func(ev vm.StopEvent) {
+		s.event("stopped", map[string]any{
+			"reason":            string(ev.Reason),
+			"threadId":          ev.Thread,
+			"allThreadsStopped": true,
+		})
+	}
+	s.machine = vm.NewVM(mod, env)
+	s.machine.SetDebugger(s.debugger)
+	return nil
+}
+
+// start runs the program, reporting its end with exited and terminated
+// events.
+func (s *Server) start() {
+	if s.running {
+		return
+	}
+	s.running = true
+	go func() {
+		defer close(s.exited)
+		code := 0
+		_, err := s.machine.RunMain()
+		var exitErr *builtins.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			code = exitErr.Code
+		case errors.Is(err, vm.ErrTerminated):
+			code = 1
+		case err != nil:
+			s.output("stderr", "error: "+err.Error()+"\n")
+			code = 1
+		}
+		s.event("exited", map[string]any{"exitCode": code})
+		s.event("terminated", nil)
+	}()
+}
+
+func (s *Server) output(category, text string) {
+	s.event("output", map[string]any{"category": category, "output": text})
+}
+
+// outputIO sends what the program prints to the client. The program
+// cannot read input, as the connection belongs to the protocol.
+type outputIO struct{ s *Server }
+
+func (o outputIO) Println(line string) { o.s.output("stdout", line+"\n") }
+
+func (o outputIO) ReadLine() (string, error) {
+	return "", errors.New("input is not available while debugging")
+}
+
+func (s *Server) setBreakpoints(args *setBreakpointsArguments) any {
+	lines := make([]int, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line
+	}
+	breakpoints := make([]breakpoint, len(lines))
+	if s.debugger == nil || s.noDebug {
+		for i := range breakpoints {
+			breakpoints[i] = breakpoint{Line: lines[i], Message: "breakpoints need a program launched for debugging"}
+		}
+		return map[string]any{"breakpoints": breakpoints}
+	}
+	for i, line := range s.debugger.SetBreakpoints(s.mod, args.Source.Path, lines) {
+		if line == 0 {
+			breakpoints[i] = breakpoint{Line: lines[i], Message: "no code at or after this line"}
+			continue
+		}
+		breakpoints[i] = breakpoint{Verified: true, Line: line}
+	}
+	return map[string]any{"breakpoints": breakpoints}
+}
+
+func (s *Server) stackTrace(args *stackTraceArguments) (any, error) {
+	if s.debugger == nil {
+		return nil, vm.ErrNotStopped
+	}
+	trace, err := s.debugger.StackTrace(args.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	total := len(trace)
+	start := min(max(args.StartFrame, 0), total)
+	end := total
+	if args.Levels > 0 {
+		end = min(start+args.Levels, total)
+	}
+	frames := []stackFrame{}
+	for depth := start; depth < end; depth++ {
+		fr := trace[depth]
+		s.frames = append(s.frames, frameRef{thread: args.ThreadID, depth: depth})
+		sf := stackFrame{ID: len(s.frames), Name: fr.Function, Line: fr.Line, Column: 1}
+		if fr.File != "" {
+			path := fr.File
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			sf.Source = &source{Name: filepath.Base(path), Path: path}
+		}
+		frames = append(frames, sf)
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": total}, nil
+}
+
+func (s *Server) frame(id int) (frameRef, error) {
+	if id < 1 || id > len(s.frames) {
+		return frameRef{}, fmt.Errorf("unknown frame %d", id)
+	}
+	return s.frames[id-1], nil
+}
+
+func (s *Server) scopes(frameID int) (any, error) {
+	ref, err := s.frame(frameID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.debugger.Scopes(ref.thread, ref.depth)
+	if err != nil {
+		return nil, err
+	}
+	scopes := make([]scope, len(list))
+	for i, sc := range list {
+		scopes[i] = scope{Name: sc.Name, VariablesReference: sc.Ref, Expensive: sc.Name == "Globals"}
+	}
+	return map[string]any{"scopes": scopes}, nil
+}
+
+func (s *Server) evaluate(args *evaluateArguments) (any, error) {
+	if s.debugger == nil {
+		return nil, vm.ErrNotStopped
+	}
+	// Without a frame, evaluate in the innermost frame of the main task.
+	ref := frameRef{thread: 1}
+	if args.FrameID != 0 {
+		var err error
+		if ref, err = s.frame(args.FrameID); err != nil {
+			return nil, err
+		}
+	}
+	v, err := s.debugger.Evaluate(ref.thread, ref.depth, args.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.Ref}, nil
+}
@@ -0,0 +1,232 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"avenir/internal/ir"
+)
+
+// debugModule is main.main of prog.av:
+//
+//	2  var x | int = 41;
+//	3  x = x + 1;
+//	4  print("hi");
+func debugModule(file string) *ir.Module {
+	fn := &ir.Function{Name: "main.main", File: file}
+	c := &fn.Chunk
+	c.SetLine(2)
+	c.Emit(ir.OpConst, c.AddConstInt(41), 0)
+	c.Emit(ir.OpStoreLocal, 0, 0)
+	c.Emit(ir.OpPop, 0, 0)
+	c.SetLine(3)
+	c.Emit(ir.OpLoadLocal, 0, 0)
+	c.Emit(ir.OpConst, c.AddConstInt(1), 0)
+	c.Emit(ir.OpAdd, 0, 0)
+	c.Emit(ir.OpStoreLocal, 0, 0)
+	c.Emit(ir.OpPop, 0, 0)
+	c.SetLine(4)
+	c.Emit(ir.OpConst, c.AddConstString("hi"), 0)
+	c.Emit(ir.OpCallBuiltin, 0, 1)
+	c.Emit(ir.OpPop, 0, 0)
+	c.Emit(ir.OpReturn, 0, 0)
+	c.NumLocals = 1
+	fn.Locals = []ir.LocalInfo{{Name: "x", Slot: 0, Start: 0, End: len(c.Code)}}
+	return &ir.Module{Functions: []*ir.Function{fn}, MainIndex: 0, InitIndex: -1, Builtins: []string{"print"}}
+}
+
+// client drives a Server over pipes.
+type client struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	seq int
+}
+
+func (c *client) request(command string, args any) {
+	c.t.Helper()
+	c.seq++
+	req := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	if err := writeMessage(c.w, req); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// message is a response or an event as the client sees it.
+type message struct {
+	Type       string         `json:"type"`
+	Command    string         `json:"command"`
+	Event      string         `json:"event"`
+	RequestSeq int            `json:"request_seq"`
+	Success    bool           `json:"success"`
+	Message    string         `json:"message"`
+	Body       map[string]any `json:"body"`
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := readMessage(c.r)
+		done <- result{data, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			c.t.Fatal(res.err)
+		}
+		var msg message
+		if err := json.Unmarshal(res.data, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for a message")
+	}
+	return message{}
+}
+
+// call sends a request and returns the body of its successful response.
+func (c *client) call(command string, args any) map[string]any {
+	c.t.Helper()
+	c.request(command, args)
+	msg := c.read()
+	if msg.Type != "response" || msg.Command != command || msg.RequestSeq != c.seq {
+		c.t.Fatalf("got %+v, want the response to %s", msg, command)
+	}
+	if !msg.Success {
+		c.t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+	return msg.Body
+}
+
+func (c *client) event(name string) map[string]any {
+	c.t.Helper()
+	msg := c.read()
+	if msg.Type != "event" || msg.Event != name {
+		c.t.Fatalf("got %+v, want a %s event", msg, name)
+	}
+	return msg.Body
+}
+
+// first returns the first element of a list in a body, as an object.
+func first(t *testing.T, body map[string]any, key string) map[string]any {
+	t.Helper()
+	list, ok := body[key].([]any)
+	if !ok || len(list) == 0 {
+		t.Fatalf("%s = %v, want a non-empty list", key, body[key])
+	}
+	return list[0].(map[string]any)
+}
+
+func TestServer_Session(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prog.av")
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+	srv := NewServer(toServer, fromServer, func(program string) (*ir.Module, error) {
+		return debugModule(program), nil
+	})
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve() }()
+	c := &client{t: t, w: fromClient, r: bufio.NewReader(toClient)}
+
+	if body := c.call("initialize", map[string]any{"adapterID": "avenir"}); body["supportsConfigurationDoneRequest"] != true {
+		t.Fatalf("capabilities = %v", body)
+	}
+	c.call("launch", map[string]any{"program": file})
+	c.event("initialized")
+	bp := first(t, c.call("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": file},
+		"breakpoints": []any{map[string]any{"line": 3}},
+	}), "breakpoints")
+	if bp["verified"] != true || bp["line"] != 3.0 {
+		t.Fatalf("breakpoint = %v", bp)
+	}
+	c.call("configurationDone", nil)
+
+	stopped := c.event("stopped")
+	if stopped["reason"] != "breakpoint" || stopped["threadId"] != 1.0 {
+		t.Fatalf("stopped = %v", stopped)
+	}
+	if th := first(t, c.call("threads", nil), "threads"); th["id"] != 1.0 || th["name"] != "main" {
+		t.Fatalf("thread = %v", th)
+	}
+	frame := first(t, c.call("stackTrace", map[string]any{"threadId": 1}), "stackFrames")
+	if frame["name"] != "main.main" || frame["line"] != 3.0 || frame["source"].(map[string]any)["path"] != file {
+		t.Fatalf("frame = %v", frame)
+	}
+	sc := first(t, c.call("scopes", map[string]any{"frameId": frame["id"]}), "scopes")
+	if sc["name"] != "Locals" {
+		t.Fatalf("scope = %v", sc)
+	}
+	v := first(t, c.call("variables", map[string]any{"variablesReference": sc["variablesReference"]}), "variables")
+	if v["name"] != "x" || v["value"] != "41" || v["type"] != "int" {
+		t.Fatalf("variable = %v", v)
+	}
+	if res := c.call("evaluate", map[string]any{"expression": "x * 2", "frameId": frame["id"]}); res["result"] != "82" {
+		t.Fatalf("evaluate = %v", res)
+	}
+
+	c.request("evaluate", map[string]any{"expression": "y", "frameId": frame["id"]})
+	if msg := c.read(); msg.Success || msg.Message != `unknown name "y"` {
+		t.Fatalf("evaluate of an unknown name = %+v", msg)
+	}
+
+	c.call("next", map[string]any{"threadId": 1})
+	if stopped := c.event("stopped"); stopped["reason"] != "step" {
+		t.Fatalf("stopped = %v", stopped)
+	}
+	c.call("continue", map[string]any{"threadId": 1})
+	if out := c.event("output"); out["category"] != "stdout" || out["output"] != "hi\n" {
+		t.Fatalf("output = %v", out)
+	}
+	if exited := c.event("exited"); exited["exitCode"] != 0.0 {
+		t.Fatalf("exited = %v", exited)
+	}
+	c.event("terminated")
+
+	c.call("disconnect", nil)
+	if err := <-served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
+
+func TestServer_DisconnectTerminatesProgram(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prog.av")
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+	srv := NewServer(toServer, fromServer, func(program string) (*ir.Module, error) {
+		return debugModule(program), nil
+	})
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve() }()
+	c := &client{t: t, w: fromClient, r: bufio.NewReader(toClient)}
+
+	c.call("initialize", nil)
+	c.call("launch", map[string]any{"program": file, "stopOnEntry": true})
+	c.event("initialized")
+	c.call("configurationDone", nil)
+	if stopped := c.event("stopped"); stopped["reason"] != "entry" {
+		t.Fatalf("stopped = %v", stopped)
+	}
+
+	c.call("disconnect", nil)
+	if exited := c.event("exited"); exited["exitCode"] != 1.0 {
+		t.Fatalf("exited = %v", exited)
+	}
+	c.event("terminated")
+	if err := <-served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+// Package decimal implements the decimal numbers of Avenir. A decimal is an
+// arbitrary-precision integer coefficient and a scale, the number of digits
+// after the decimal point: its value is coef / 10^scale. Decimals keep
+// their scale, so 1.50 and 1.5 are equal but print differently.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxScale bounds the scales that Parse and Rescale accept, so that a
+// scale from untrusted input cannot make a decimal arbitrarily large.
+const MaxScale = 10000
+
+// ErrDivisionByZero is returned by Quo for a zero divisor.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Decimal is an immutable decimal number. The zero Decimal is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+// RoundingMode says how to round a result that has more digits than its
+// scale allows.
+type RoundingMode int
+
+const (
+	HalfEven RoundingMode = iota // to nearest, ties to even
+	HalfUp                       // to nearest, ties away from zero
+	HalfDown                     // to nearest, ties toward zero
+	Up                           // away from zero
+	Down                         // toward zero
+	Ceiling                      // toward +∞
+	Floor                        // toward −∞
+)
+
+var modeNames = []string{"halfEven", "halfUp", "halfDown", "up", "down", "ceiling", "floor"}
+
+// String returns the name of the mode, as ParseRoundingMode accepts it.
+func (m RoundingMode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return fmt.Sprintf("RoundingMode(%d)", int(m))
+	}
+	return modeNames[m]
+}
+
+// ParseRoundingMode returns the mode named name, e.g. "halfUp".
+func ParseRoundingMode(name string) (RoundingMode, bool) {
+	for i, n := range modeNames {
+		if n == name {
+			return RoundingMode(i), true
+		}
+	}
+	return 0, false
+}
+
+// New returns coef / 10^scale. scale must not be negative.
+func New(coef *big.Int, scale int) Decimal {
+	if scale < 0 {
+		panic("decimal: negative scale")
+	}
+	return Decimal{coef: new(big.Int).Set(coef), scale: scale}
+}
+
+// FromInt returns n with scale 0.
+func FromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+// Parse parses a decimal in plain or scientific notation, such as "12.50",
+// "-3" or "1.5e3". The scale is the number of digits after the point, less
+// the exponent, and at least 0.
+func Parse(s string) (Decimal, error) {
+	mant, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mant = s[:i]
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > MaxScale || e < -MaxScale {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		exp = e
+	}
+	digits := strings.TrimLeft(mant, "+-")
+	if len(mant)-len(digits) > 1 {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	intPart, frac, _ := strings.Cut(digits, ".")
+	if intPart+frac == "" || !allDigits(intPart) || !allDigits(frac) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	coef, _ := new(big.Int).SetString(intPart+frac, 10)
+	if strings.HasPrefix(mant, "-") {
+		coef.Neg(coef)
+	}
+	scale := len(frac) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	if scale > MaxScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: scale above %d", s, MaxScale)
+	}
+	return Decimal{coef: coef, scale: scale}, nil
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// c returns the coefficient, which is nil in the zero Decimal.
+func (d Decimal) c() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int { return d.scale }
+
+// BitLen returns the length of the coefficient's absolute value in bits.
+func (d Decimal) BitLen() int { return d.c().BitLen() }
+
+// Sign returns -1, 0 or 1 as d is negative, zero or positive.
+func (d Decimal) Sign() int { return d.c().Sign() }
+
+// String formats d in plain notation with exactly Scale digits after the
+// point.
+func (d Decimal) String() string {
+	c := d.c()
+	digits := new(big.Int).Abs(c).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if c.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 returns the float nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Int returns d truncated toward zero.
+func (d Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.c(), pow10(d.scale))
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.c()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.c()), scale: d.scale}
+}
+
+// align returns the coefficients of d and e at their larger scale.
+func align(d, e Decimal) (x, y *big.Int, scale int) {
+	x, y, scale = d.c(), e.c(), d.scale
+	switch {
+	case d.scale < e.scale:
+		x, scale = new(big.Int).Mul(x, pow10(e.scale-d.scale)), e.scale
+	case d.scale > e.scale:
+		y = new(big.Int).Mul(y, pow10(d.scale-e.scale))
+	}
+	return x, y, scale
+}
+
+// Cmp compares d and e by value, returning -1, 0 or 1.
+func (d Decimal) Cmp(e Decimal) int {
+	x, y, _ := align(d, e)
+	return x.Cmp(y)
+}
+
+// Add returns d + e, at the larger scale of the two.
+func (d Decimal) Add(e Decimal) Decimal {
+	x, y, scale := align(d, e)
+	return Decimal{coef: new(big.Int).Add(x, y), scale: scale}
+}
+
+// Sub returns d - e, at the larger scale of the two.
+func (d Decimal) Sub(e Decimal) Decimal {
+	x, y, scale := align(d, e)
+	return Decimal{coef: new(big.Int).Sub(x, y), scale: scale}
+}
+
+// Mul returns d * e, at the sum of their scales.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.c(), e.c()), scale: d.scale + e.scale}
+}
+
+// Quo returns d / e rounded to scale digits after the point.
+func (d Decimal) Quo(e Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if e.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	if err := checkScale(scale); err != nil {
+		return Decimal{}, err
+	}
+	// d / e * 10^scale = d.coef * 10^(e.scale+scale) / (e.coef * 10^d.scale)
+	num := new(big.Int).Mul(d.c(), pow10(e.scale+scale))
+	den := new(big.Int).Mul(e.c(), pow10(d.scale))
+	return Decimal{coef: roundQuo(num, den, mode), scale: scale}, nil
+}
+
+// Rescale returns d with scale digits after the point, rounding if the
+// scale is smaller than d's.
+func (d Decimal) Rescale(scale int, mode RoundingMode) (Decimal, error) {
+	if err := checkScale(scale); err != nil {
+		return Decimal{}, err
+	}
+	if scale >= d.scale {
+		return Decimal{coef: new(big.Int).Mul(d.c(), pow10(scale-d.scale)), scale: scale}, nil
+	}
+	return Decimal{coef: roundQuo(d.c(), pow10(d.scale-scale), mode), scale: scale}, nil
+}
+
+func checkScale(scale int) error {
+	if scale < 0 || scale > MaxScale {
+		return fmt.Errorf("scale %d out of range [0, %d]", scale, MaxScale)
+	}
+	return nil
+}
+
+// roundQuo returns num / den rounded by mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	sign := num.Sign() * den.Sign()
+	// half compares the remainder with half the divisor.
+	twice := new(big.Int).Abs(r)
+	half := twice.Lsh(twice, 1).Cmp(new(big.Int).Abs(den))
+	var away bool
+	switch mode {
+	case HalfEven:
+		away = half > 0 || half == 0 && q.Bit(0) == 1
+	case HalfUp:
+		away = half >= 0
+	case HalfDown:
+		away = half > 0
+	case Up:
+		away = true
+	case Down:
+		away = false
+	case Ceiling:
+		away = sign > 0
+	case Floor:
+		away = sign < 0
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,129 @@
+package decimal
+
+import (
+	"errors"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		scale int
+	}{
+		{"0", "0", 0},
+		{"12.50", "12.50", 2},
+		{"-0.05", "-0.05", 2},
+		{"+7", "7", 0},
+		{".5", "0.5", 1},
+		{"1.5e3", "1500", 0},
+		{"1.5e-3", "0.0015", 4},
+		{"123456789012345678901234567890.1", "123456789012345678901234567890.1", 1},
+	}
+	for _, tt := range tests {
+		d := mustParse(t, tt.in)
+		if d.String() != tt.want || d.Scale() != tt.scale {
+			t.Errorf("Parse(%q) = %s (scale %d), want %s (scale %d)", tt.in, d, d.Scale(), tt.want, tt.scale)
+		}
+	}
+	for _, in := range []string{"", "-", ".", "1.2.3", "1e", "abc", "--1", "1e99999", "0x10"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) expected error", in)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := mustParse(t, "19.99"), mustParse(t, "0.5")
+	if got := a.Add(b).String(); got != "20.49" {
+		t.Errorf("Add = %s", got)
+	}
+	if got := b.Sub(a).String(); got != "-19.49" {
+		t.Errorf("Sub = %s", got)
+	}
+	if got := a.Mul(b).String(); got != "9.995" {
+		t.Errorf("Mul = %s", got)
+	}
+	if a.Cmp(b) != 1 || mustParse(t, "1.50").Cmp(mustParse(t, "1.5")) != 0 {
+		t.Errorf("Cmp gave wrong order")
+	}
+	if got := mustParse(t, "-2.75").Int().String(); got != "-2" {
+		t.Errorf("Int = %s", got)
+	}
+	var zero Decimal
+	if zero.String() != "0" || zero.Add(b).String() != "0.5" {
+		t.Errorf("zero Decimal = %s", zero)
+	}
+}
+
+func TestQuo(t *testing.T) {
+	ten := mustParse(t, "10.00")
+	got, err := ten.Quo(FromInt(3), 2, HalfEven)
+	if err != nil || got.String() != "3.33" {
+		t.Errorf("10.00 / 3 = %s, %v", got, err)
+	}
+	got, err = ten.Quo(FromInt(-3), 4, Up)
+	if err != nil || got.String() != "-3.3334" {
+		t.Errorf("10.00 / -3 up = %s, %v", got, err)
+	}
+	if _, err := ten.Quo(Decimal{}, 2, HalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("division by zero error = %v", err)
+	}
+}
+
+func TestRescaleModes(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"2.5", HalfEven, "2"},
+		{"3.5", HalfEven, "4"},
+		{"-2.5", HalfEven, "-2"},
+		{"2.5", HalfUp, "3"},
+		{"-2.5", HalfUp, "-3"},
+		{"2.5", HalfDown, "2"},
+		{"2.51", HalfDown, "3"},
+		{"2.1", Up, "3"},
+		{"-2.1", Up, "-3"},
+		{"2.9", Down, "2"},
+		{"-2.1", Ceiling, "-2"},
+		{"2.1", Ceiling, "3"},
+		{"-2.1", Floor, "-3"},
+		{"2.9", Floor, "2"},
+	}
+	for _, tt := range tests {
+		got, err := mustParse(t, tt.in).Rescale(0, tt.mode)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Rescale(%s, 0, %s) = %s, %v, want %s", tt.in, tt.mode, got, err, tt.want)
+		}
+	}
+	got, err := mustParse(t, "1.5").Rescale(3, HalfEven)
+	if err != nil || got.String() != "1.500" {
+		t.Errorf("Rescale up = %s, %v", got, err)
+	}
+	if _, err := FromInt(1).Rescale(-1, HalfEven); err == nil {
+		t.Errorf("negative scale expected error")
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, name := range modeNames {
+		m, ok := ParseRoundingMode(name)
+		if !ok || m.String() != name {
+			t.Errorf("ParseRoundingMode(%q) = %v, %v", name, m, ok)
+		}
+	}
+	if _, ok := ParseRoundingMode("nearest"); ok {
+		t.Errorf("unknown mode accepted")
+	}
+}
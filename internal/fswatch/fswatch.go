@@ -0,0 +1,185 @@
+// Package fswatch reports file system changes under a set of paths. It uses
+// inotify on Linux and falls back to polling elsewhere or when inotify is
+// unavailable. Events are coalesced per path and delivered in batches once
+// the paths have been quiet for the debounce interval.
+package fswatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event operations.
+const (
+	Create = "create"
+	Modify = "modify"
+	Remove = "remove"
+	Rename = "rename" // the path was moved away; the new name is reported as Create
+)
+
+const (
+	defaultDebounce     = 100 * time.Millisecond
+	defaultPollInterval = 500 * time.Millisecond
+)
+
+// Event is a change to a single path.
+type Event struct {
+	Op   string
+	Path string
+}
+
+// Options configures a Watcher. Zero durations select the defaults.
+type Options struct {
+	Recursive    bool
+	Debounce     time.Duration
+	Poll         bool // force the polling backend
+	PollInterval time.Duration
+}
+
+type backend interface {
+	close() error
+}
+
+// Watcher delivers batches of events until it is closed.
+type Watcher struct {
+	raw     chan Event
+	events  chan []Event
+	done    chan struct{}
+	once    sync.Once
+	backend backend
+	polling bool
+}
+
+// New starts watching paths. Directories are watched for changes to their
+// entries (and, with Recursive, everything below them); files are watched
+// through their parent directory so that replacing a file is still seen.
+func New(paths []string, opts Options) (*Watcher, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to watch")
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	abs := make([]string, len(paths))
+	for i, p := range paths {
+		a, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(a); err != nil {
+			return nil, err
+		}
+		abs[i] = a
+	}
+
+	w := &Watcher{
+		raw:    make(chan Event, 256),
+		events: make(chan []Event, 16),
+		done:   make(chan struct{}),
+	}
+	var err error
+	if !opts.Poll {
+		w.backend, err = newNative(abs, opts.Recursive, w.emit)
+	}
+	if opts.Poll || err != nil {
+		w.backend = newPoller(abs, opts.Recursive, opts.PollInterval, w.emit)
+		w.polling = true
+	}
+	go w.debounce(opts.Debounce)
+	return w, nil
+}
+
+// Events returns the channel of event batches. It is closed by Close.
+func (w *Watcher) Events() <-chan []Event {
+	return w.events
+}
+
+// Polling reports whether the watcher fell back to polling.
+func (w *Watcher) Polling() bool {
+	return w.polling
+}
+
+// Close stops the watcher. It is safe to call more than once.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+	})
+	return err
+}
+
+func (w *Watcher) emit(ev Event) {
+	select {
+	case w.raw <- ev:
+	case <-w.done:
+	}
+}
+
+// debounce merges raw events per path and flushes them as one batch after
+// no new event has arrived for d.
+func (w *Watcher) debounce(d time.Duration) {
+	defer close(w.events)
+	var pending []Event
+	index := make(map[string]int)
+	timer := time.NewTimer(d)
+	timer.Stop()
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case ev := <-w.raw:
+			if i, ok := index[ev.Path]; ok {
+				pending[i].Op = mergeOp(pending[i].Op, ev.Op)
+			} else {
+				index[ev.Path] = len(pending)
+				pending = append(pending, ev)
+			}
+			timer.Reset(d)
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			batch := make([]Event, 0, len(pending))
+			for _, ev := range pending {
+				if ev.Op != "" {
+					batch = append(batch, ev)
+				}
+			}
+			pending = nil
+			index = make(map[string]int)
+			if len(batch) == 0 {
+				continue
+			}
+			select {
+			case w.events <- batch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// mergeOp combines two events for the same path within one batch. An empty
+// result means the events cancel out.
+func mergeOp(prev, next string) string {
+	switch {
+	case prev == Create && next == Modify:
+		return Create
+	case prev == Create && (next == Remove || next == Rename):
+		return ""
+	case prev == "" && next == Modify:
+		return Create
+	case (prev == Remove || prev == Rename) && next == Create:
+		return Modify
+	}
+	return next
+}
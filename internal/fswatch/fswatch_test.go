@@ -0,0 +1,141 @@
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// collect gathers events until one for want arrives or the timeout expires.
+func collect(t *testing.T, w *Watcher, want Event) []Event {
+	t.Helper()
+	var got []Event
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case batch, ok := <-w.Events():
+			if !ok {
+				t.Fatalf("events closed before %v, got %v", want, got)
+			}
+			got = append(got, batch...)
+			for _, ev := range batch {
+				if ev == want {
+					return got
+				}
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %v, got %v", want, got)
+		}
+	}
+}
+
+func testWatch(t *testing.T, opts Options) {
+	dir := t.TempDir()
+	opts.Recursive = true
+	opts.Debounce = 20 * time.Millisecond
+	opts.PollInterval = 20 * time.Millisecond
+	w, err := New([]string{dir}, opts)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer w.Close()
+	if runtime.GOOS == "linux" && w.Polling() != opts.Poll {
+		t.Fatalf("expected polling=%v on linux", opts.Poll)
+	}
+
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, w, Event{Op: Create, Path: file})
+
+	if err := os.WriteFile(file, []byte("two, longer"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, w, Event{Op: Modify, Path: file})
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, w, Event{Op: Create, Path: sub})
+	nested := filepath.Join(sub, "b.txt")
+	if err := os.WriteFile(nested, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, w, Event{Op: Create, Path: nested})
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, w, Event{Op: Remove, Path: file})
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	for range w.Events() {
+	}
+}
+
+func TestWatchNative(t *testing.T) {
+	testWatch(t, Options{})
+}
+
+func TestWatchPolling(t *testing.T) {
+	testWatch(t, Options{Poll: true})
+}
+
+func TestWatchSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "config.json")
+	other := filepath.Join(dir, "other.json")
+	if err := os.WriteFile(target, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New([]string{target}, Options{Debounce: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(other, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Replace the target the way editors do: write elsewhere, rename over.
+	tmp := filepath.Join(dir, ".config.json.tmp")
+	if err := os.WriteFile(tmp, []byte(`{"a": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		t.Fatal(err)
+	}
+	got := collect(t, w, Event{Op: Create, Path: target})
+	for _, ev := range got {
+		if ev.Path != target {
+			t.Fatalf("unexpected event for unwatched path: %v", ev)
+		}
+	}
+}
+
+func TestMergeOp(t *testing.T) {
+	cases := []struct{ prev, next, want string }{
+		{Create, Modify, Create},
+		{Create, Remove, ""},
+		{Remove, Create, Modify},
+		{Modify, Remove, Remove},
+		{Modify, Modify, Modify},
+	}
+	for _, c := range cases {
+		if got := mergeOp(c.prev, c.next); got != c.want {
+			t.Errorf("mergeOp(%q, %q) = %q, want %q", c.prev, c.next, got, c.want)
+		}
+	}
+}
+
+func TestWatchMissingPath(t *testing.T) {
+	if _, err := New([]string{filepath.Join(t.TempDir(), "missing")}, Options{}); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
@@ -0,0 +1,190 @@
+//go:build linux
+
+package fswatch
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watchDir is one inotify watch descriptor.
+type watchDir struct {
+	path      string
+	names     map[string]bool // nil: report every entry; otherwise only these file targets
+	recursive bool
+	root      bool
+}
+
+type inotify struct {
+	file *os.File
+	fd   int
+	emit func(Event)
+
+	mu    sync.Mutex
+	watch map[int]*watchDir
+}
+
+func newNative(paths []string, recursive bool, emit func(Event)) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// A non-blocking descriptor goes through the runtime poller, so Close
+	// interrupts a pending Read.
+	in := &inotify{
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		emit:  emit,
+		watch: make(map[int]*watchDir),
+	}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err == nil && info.IsDir() {
+			err = in.addTree(p, recursive, true, nil)
+		} else if err == nil {
+			err = in.addFile(p)
+		}
+		if err != nil {
+			in.file.Close()
+			return nil, err
+		}
+	}
+	go in.readLoop()
+	return in, nil
+}
+
+func (in *inotify) close() error {
+	return in.file.Close()
+}
+
+func (in *inotify) add(dir string, name string, recursive, root bool) error {
+	wd, err := syscall.InotifyAddWatch(in.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	w := in.watch[wd]
+	if w == nil {
+		w = &watchDir{path: dir}
+		if name != "" {
+			w.names = make(map[string]bool)
+		}
+		in.watch[wd] = w
+	}
+	if name == "" {
+		w.names = nil
+	} else if w.names != nil {
+		w.names[name] = true
+	}
+	w.recursive = w.recursive || recursive
+	w.root = w.root || root
+	return nil
+}
+
+// addFile watches a single file through its parent directory.
+func (in *inotify) addFile(path string) error {
+	return in.add(filepath.Dir(path), filepath.Base(path), false, false)
+}
+
+// addTree watches dir and, when recursive, every directory below it. Entries
+// found in directories that appeared after watching started are passed to
+// found so that their creation is not lost.
+func (in *inotify) addTree(dir string, recursive, root bool, found func(string)) error {
+	if !recursive {
+		return in.add(dir, "", false, root)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if path != dir && found != nil {
+			found(path)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := in.add(path, "", true, root && path == dir); err != nil && path == dir {
+			return err
+		}
+		return nil
+	})
+}
+
+func (in *inotify) readLoop() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			if errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+			offset += syscall.SizeofInotifyEvent + int(raw.Len)
+			in.handle(int(raw.Wd), raw.Mask, string(bytes.TrimRight(nameBytes, "\x00")))
+		}
+	}
+}
+
+func (in *inotify) handle(wd int, mask uint32, name string) {
+	in.mu.Lock()
+	w := in.watch[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(in.watch, wd)
+	}
+	in.mu.Unlock()
+	if w == nil || mask&syscall.IN_Q_OVERFLOW != 0 {
+		return
+	}
+
+	if name == "" {
+		// Events on the watched directory itself; only roots are reported,
+		// subdirectories are already reported by their parent.
+		if !w.root {
+			return
+		}
+		switch {
+		case mask&syscall.IN_DELETE_SELF != 0:
+			in.emit(Event{Op: Remove, Path: w.path})
+		case mask&syscall.IN_MOVE_SELF != 0:
+			in.emit(Event{Op: Rename, Path: w.path})
+		}
+		return
+	}
+	if w.names != nil && !w.names[name] {
+		return
+	}
+
+	path := filepath.Join(w.path, name)
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		in.emit(Event{Op: Create, Path: path})
+		if mask&syscall.IN_ISDIR != 0 && w.recursive {
+			in.addTree(path, true, false, func(p string) {
+				in.emit(Event{Op: Create, Path: p})
+			})
+		}
+	case mask&syscall.IN_MODIFY != 0:
+		in.emit(Event{Op: Modify, Path: path})
+	case mask&syscall.IN_DELETE != 0:
+		in.emit(Event{Op: Remove, Path: path})
+	case mask&syscall.IN_MOVED_FROM != 0:
+		in.emit(Event{Op: Rename, Path: path})
+	}
+}
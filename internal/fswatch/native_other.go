@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fswatch
+
+import "errors"
+
+// newNative has no native backend outside Linux; New falls back to polling.
+func newNative(paths []string, recursive bool, emit func(Event)) (backend, error) {
+	return nil, errors.New("native file watching is not supported on this platform")
+}
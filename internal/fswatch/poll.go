@@ -0,0 +1,110 @@
+package fswatch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+// poller compares snapshots of the watched paths. Renames show up as a
+// remove of the old path and a create of the new one.
+type poller struct {
+	paths     []string
+	recursive bool
+	done      chan struct{}
+}
+
+func newPoller(paths []string, recursive bool, interval time.Duration, emit func(Event)) *poller {
+	p := &poller{paths: paths, recursive: recursive, done: make(chan struct{})}
+	prev := p.scan()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+			}
+			next := p.scan()
+			diff(prev, next, emit)
+			prev = next
+		}
+	}()
+	return p
+}
+
+func (p *poller) close() error {
+	close(p.done)
+	return nil
+}
+
+func (p *poller) scan() map[string]fileState {
+	snap := make(map[string]fileState)
+	for _, root := range p.paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			continue
+		}
+		snap[root] = stateOf(info)
+		if !info.IsDir() {
+			continue
+		}
+		if !p.recursive {
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if info, err := e.Info(); err == nil {
+					snap[filepath.Join(root, e.Name())] = stateOf(info)
+				}
+			}
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || path == root {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				snap[path] = stateOf(info)
+			}
+			return nil
+		})
+	}
+	return snap
+}
+
+func stateOf(info fs.FileInfo) fileState {
+	return fileState{modTime: info.ModTime(), size: info.Size(), isDir: info.IsDir()}
+}
+
+func diff(prev, next map[string]fileState, emit func(Event)) {
+	var events []Event
+	for path, st := range next {
+		old, ok := prev[path]
+		switch {
+		case !ok:
+			events = append(events, Event{Op: Create, Path: path})
+		case !st.isDir && (!st.modTime.Equal(old.modTime) || st.size != old.size):
+			events = append(events, Event{Op: Modify, Path: path})
+		}
+	}
+	for path := range prev {
+		if _, ok := next[path]; !ok {
+			events = append(events, Event{Op: Remove, Path: path})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	for _, ev := range events {
+		emit(ev)
+	}
+}
@@ -0,0 +1,43 @@
+// Package intmath implements the arithmetic of Avenir ints, which are 64-bit
+// two's complement. Each operation reports false when its exact result does
+// not fit, leaving the caller to fail, wrap or saturate.
+package intmath
+
+import "math"
+
+// Add returns a + b.
+func Add(a, b int64) (int64, bool) {
+	r := a + b
+	return r, (a >= 0) != (b >= 0) || (r >= 0) == (a >= 0)
+}
+
+// Sub returns a - b.
+func Sub(a, b int64) (int64, bool) {
+	r := a - b
+	return r, (a >= 0) == (b >= 0) || (r >= 0) == (a >= 0)
+}
+
+// Mul returns a * b.
+func Mul(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || r/b != a {
+		return r, false
+	}
+	return r, true
+}
+
+// Div returns a / b truncated toward zero. b must not be 0.
+func Div(a, b int64) (int64, bool) {
+	if a == math.MinInt64 && b == -1 {
+		return a, false
+	}
+	return a / b, true
+}
+
+// Neg returns -a.
+func Neg(a int64) (int64, bool) {
+	return -a, a != math.MinInt64
+}
@@ -0,0 +1,48 @@
+package intmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOps(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(a, b int64) (int64, bool)
+		a, b int64
+		want int64
+		ok   bool
+	}{
+		{"add", Add, 2, 3, 5, true},
+		{"add max", Add, math.MaxInt64, 1, math.MinInt64, false},
+		{"add min", Add, math.MinInt64, -1, math.MaxInt64, false},
+		{"add mixed signs", Add, math.MaxInt64, math.MinInt64, -1, true},
+		{"sub", Sub, 2, 3, -1, true},
+		{"sub min", Sub, math.MinInt64, 1, math.MaxInt64, false},
+		{"sub max", Sub, 0, math.MinInt64, math.MinInt64, false},
+		{"sub same signs", Sub, -1, math.MinInt64, math.MaxInt64, true},
+		{"mul", Mul, -4, 5, -20, true},
+		{"mul zero", Mul, 0, math.MinInt64, 0, true},
+		{"mul overflow", Mul, math.MaxInt64, 2, -2, false},
+		{"mul min by -1", Mul, math.MinInt64, -1, math.MinInt64, false},
+		{"mul -1 by min", Mul, -1, math.MinInt64, math.MinInt64, false},
+		{"mul min by 1", Mul, math.MinInt64, 1, math.MinInt64, true},
+		{"div truncates", Div, -7, 2, -3, true},
+		{"div min by -1", Div, math.MinInt64, -1, math.MinInt64, false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.op(tt.a, tt.b)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s(%d, %d) = %d, %v, want %d, %v", tt.name, tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNeg(t *testing.T) {
+	if got, ok := Neg(5); got != -5 || !ok {
+		t.Errorf("Neg(5) = %d, %v", got, ok)
+	}
+	if _, ok := Neg(math.MinInt64); ok {
+		t.Errorf("Neg(MinInt64) reported no overflow")
+	}
+}
@@ -2,6 +2,7 @@ package ir
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"avenir/internal/ast"
@@ -15,6 +16,9 @@ import (
 type StructTypeInfo struct {
 	Name   string
 	Fields []types.Field
+	// Methods maps the name of each instance method to its function
+	// index, for calls through interfaces.
+	Methods map[string]int
 }
 
 // Compiler compiles an AST program into an IR module.
@@ -42,9 +46,25 @@ type Compiler struct {
 
 	world *types.World // Store world for method lookup
 
+	registry     *builtins.Registry // builtins available to the program
+	builtinIndex map[string]int     // builtin symbol -> index in mod.Builtins
+
 	errors []error
 }
 
+// builtinRef returns the index of b in the module's builtin symbol table,
+// adding it on first use.
+func (c *Compiler) builtinRef(b *builtins.Builtin) int {
+	sym := b.Meta.Symbol()
+	if idx, ok := c.builtinIndex[sym]; ok {
+		return idx
+	}
+	idx := len(c.mod.Builtins)
+	c.mod.Builtins = append(c.mod.Builtins, sym)
+	c.builtinIndex[sym] = idx
+	return idx
+}
+
 // Compile compiles a single program. This is a convenience wrapper that
 // builds a minimal world and calls CompileWorld.
 func Compile(prog *ast.Program) (*Module, []error) {
@@ -106,6 +126,7 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 
 	// Collect all functions from all modules
 	for modName, modInfo := range world.Modules {
+		firstFunc := len(mod.Functions)
 		for _, fn := range modInfo.Prog.Funcs {
 			// Skip uninstantiated generic functions
 			if len(fn.TypeParams) > 0 {
@@ -120,6 +141,7 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 					upvalues[i] = UpvalueInfo{
 						IsLocal: uv.IsLocal,
 						Index:   uv.Index,
+						Name:    uv.Name,
 					}
 				}
 			}
@@ -135,6 +157,7 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 				Chunk:     Chunk{},
 				Upvalues:  upvalues,
 				IsAsync:   fn.IsAsync,
+				IsPublic:  fn.IsPublic,
 			}
 			mod.Functions = append(mod.Functions, irFn)
 			funcIndexByDecl[fn] = idx
@@ -143,6 +166,9 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 
 		// Collect function literals from this module
 		collectFuncLiteralsFromProg(modInfo.Prog, modName, mod, funcIndexByLiteral, &allFuncNodes, allFuncInfos)
+		for _, irFn := range mod.Functions[firstFunc:] {
+			irFn.File = modInfo.File
+		}
 	}
 
 	// Collect monomorphized generic functions from bindings
@@ -153,6 +179,7 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 			NumParams: len(monoDecl.Params),
 			Chunk:     Chunk{},
 			IsAsync:   monoDecl.IsAsync,
+			IsPublic:  monoDecl.IsPublic,
 		}
 		mod.Functions = append(mod.Functions, irFn)
 		funcIndexByDecl[monoDecl] = idx
@@ -233,6 +260,12 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 				}
 				if idx, ok3 := funcIndexByDecl[fnDecl]; ok3 {
 					methodIndex[receiverTypeName][fnDecl.Name] = idx
+					if info := structTypes[receiverTypeName]; info != nil && fnDecl.Receiver.Kind == ast.ReceiverInstance {
+						if info.Methods == nil {
+							info.Methods = make(map[string]int)
+						}
+						info.Methods[fnDecl.Name] = idx
+					}
 				}
 			}
 		}
@@ -276,6 +309,8 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 		methodIndex:      methodIndex,
 		globalIndex:      globalIdx,
 		world:            world,
+		registry:         world.BuiltinRegistry(),
+		builtinIndex:     make(map[string]int),
 		errors:           []error{},
 	}
 
@@ -299,8 +334,12 @@ func CompileWorld(world *types.World, entryMod *types.ModuleInfo, bindings *type
 		irFn.Chunk.NumLocals = fc.nextLocal
 		code := irFn.Chunk.Code
 		if len(code) == 0 || code[len(code)-1].Op != OpReturn {
+			if body := fc.body(); body != nil {
+				irFn.Chunk.SetLine(body.RBrace.Line)
+			}
 			irFn.Chunk.Emit(OpReturn, 0, 0)
 		}
+		fc.endLocals()
 	}
 
 	// Generate __init__ function for decorator application
@@ -336,6 +375,7 @@ func collectFuncLiteralsInNode(node ast.Node, modName string, mod *Module, funcI
 				upvalues[i] = UpvalueInfo{
 					IsLocal: uv.IsLocal,
 					Index:   uv.Index,
+					Name:    uv.Name,
 				}
 			}
 		}
@@ -344,6 +384,7 @@ func collectFuncLiteralsInNode(node ast.Node, modName string, mod *Module, funcI
 			NumParams: len(n.Params),
 			Chunk:     Chunk{},
 			Upvalues:  upvalues,
+			IsAsync:   n.IsAsync,
 		}
 		mod.Functions = append(mod.Functions, irFn)
 		funcIndexByLiteral[n] = idx
@@ -448,6 +489,17 @@ func collectFuncLiteralsInNode(node ast.Node, modName string, mod *Module, funcI
 		for _, el := range n.Elements {
 			collectFuncLiteralsInNode(el, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			collectFuncLiteralsInNode(el, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if entry.KeyExpr != nil {
+				collectFuncLiteralsInNode(entry.KeyExpr, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
+			}
+			collectFuncLiteralsInNode(entry.Value, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
+		}
 	case *ast.MemberExpr:
 		collectFuncLiteralsInNode(n.X, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
 	case *ast.OptionalMemberExpr:
@@ -463,6 +515,8 @@ func collectFuncLiteralsInNode(node ast.Node, modName string, mod *Module, funcI
 		}
 	case *ast.AwaitExpr:
 		collectFuncLiteralsInNode(n.Expr, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
+	case *ast.SpawnExpr:
+		collectFuncLiteralsInNode(n.Call, modName, mod, funcIndexByLiteral, allFuncNodes, allFuncInfos)
 	}
 }
 
@@ -589,6 +643,18 @@ func findFuncLiteralInNode(node ast.Node, target *ast.FuncLiteral) bool {
 				return true
 			}
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			if findFuncLiteralInNode(el, target) {
+				return true
+			}
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if (entry.KeyExpr != nil && findFuncLiteralInNode(entry.KeyExpr, target)) || findFuncLiteralInNode(entry.Value, target) {
+				return true
+			}
+		}
 	case *ast.MemberExpr:
 		if findFuncLiteralInNode(n.X, target) {
 			return true
@@ -614,6 +680,10 @@ func findFuncLiteralInNode(node ast.Node, target *ast.FuncLiteral) bool {
 		if findFuncLiteralInNode(n.Expr, target) {
 			return true
 		}
+	case *ast.SpawnExpr:
+		if findFuncLiteralInNode(n.Call, target) {
+			return true
+		}
 	}
 	return false
 }
@@ -728,6 +798,7 @@ func (c *Compiler) generateInitFunc(bindings *types.Bindings, funcIndexByDecl ma
 
 	fc.chunk.Emit(OpReturn, 0, 0)
 	initFn.Chunk.NumLocals = fc.nextLocal
+	fc.endLocals()
 	c.mod.InitIndex = initIdx
 }
 
@@ -736,6 +807,7 @@ func (c *Compiler) generateInitFunc(bindings *types.Bindings, funcIndexByDecl ma
 type localScope struct {
 	parent *localScope
 	slots  map[string]int // name -> slot index
+	locals []int          // indexes into Function.Locals of the scope's locals
 }
 
 func newLocalScope(parent *localScope) *localScope {
@@ -808,12 +880,14 @@ func newFuncCompiler(c *Compiler, fnNode ast.Node, irFn *Function, fnInfo *resol
 		// Only instance methods have a receiver variable
 		fc.scope.slots[receiver.Name] = 0
 		fc.nextLocal++
+		fc.debugLocal(receiver.Name, 0)
 		slotOffset = 1
 	}
 	// Parameters occupy slots starting from slotOffset
 	for i, p := range params {
 		fc.scope.slots[p.Name] = slotOffset + i
 		fc.nextLocal++
+		fc.debugLocal(p.Name, slotOffset+i)
 	}
 	return fc
 }
@@ -846,6 +920,10 @@ func (fc *funcCompiler) resolveTypeNode(tn ast.TypeNode) types.Type {
 			return types.ErrorType
 		case "bytes":
 			return types.Bytes
+		case "bigint":
+			return types.BigInt
+		case "decimal":
+			return types.Decimal
 		default:
 			if st, ok := fc.c.structTypes[t.Name]; ok {
 				return &types.Struct{Name: st.Name}
@@ -883,9 +961,39 @@ func (fc *funcCompiler) allocLocal(name string, node ast.Node) int {
 	slot := fc.nextLocal
 	fc.nextLocal++
 	fc.scope.slots[name] = slot
+	fc.debugLocal(name, slot)
 	return slot
 }
 
+// debugLocal records that name lives in slot from the next instruction
+// until its scope closes. Compiler temporaries, named with a "__" prefix,
+// are left out.
+func (fc *funcCompiler) debugLocal(name string, slot int) {
+	if strings.HasPrefix(name, "__") {
+		return
+	}
+	fc.scope.locals = append(fc.scope.locals, len(fc.fn.Locals))
+	fc.fn.Locals = append(fc.fn.Locals, LocalInfo{Name: name, Slot: slot, Start: len(fc.chunk.Code), End: -1})
+}
+
+// closeScope makes prev the current scope again, ending the debug ranges of
+// the locals declared in the closed one.
+func (fc *funcCompiler) closeScope(prev *localScope) {
+	for _, i := range fc.scope.locals {
+		fc.fn.Locals[i].End = len(fc.chunk.Code)
+	}
+	fc.scope = prev
+}
+
+// endLocals ends the debug ranges still open at the end of the function.
+func (fc *funcCompiler) endLocals() {
+	for i := range fc.fn.Locals {
+		if fc.fn.Locals[i].End < 0 {
+			fc.fn.Locals[i].End = len(fc.chunk.Code)
+		}
+	}
+}
+
 func (fc *funcCompiler) lookupLocal(name string) (int, bool) {
 	if fc.scope == nil {
 		return 0, false
@@ -986,6 +1094,17 @@ func (fc *funcCompiler) compile() {
 	}
 }
 
+// body returns the body of the function being compiled.
+func (fc *funcCompiler) body() *ast.BlockStmt {
+	if fc.fnAst != nil {
+		return fc.fnAst.Body
+	}
+	if fc.fnLit != nil {
+		return fc.fnLit.Body
+	}
+	return nil
+}
+
 func (fc *funcCompiler) compileBlock(b *ast.BlockStmt) {
 	// Create a new scope for the block
 	prev := fc.scope
@@ -993,10 +1112,23 @@ func (fc *funcCompiler) compileBlock(b *ast.BlockStmt) {
 	for _, st := range b.Stmts {
 		fc.compileStmt(st)
 	}
-	fc.scope = prev
+	fc.closeScope(prev)
+}
+
+// markLine records node's source line for the instructions emitted until
+// the returned function is called, which restores the previous line.
+func (fc *funcCompiler) markLine(node ast.Node) func() {
+	prev := fc.chunk.line
+	if node != nil {
+		if line := node.Pos().Line; line > 0 {
+			fc.chunk.SetLine(line)
+		}
+	}
+	return func() { fc.chunk.SetLine(prev) }
 }
 
 func (fc *funcCompiler) compileStmt(s ast.Stmt) {
+	defer fc.markLine(s)()
 	switch st := s.(type) {
 	case *ast.BlockStmt:
 		fc.compileBlock(st)
@@ -1216,20 +1348,79 @@ func (fc *funcCompiler) compileFor(s *ast.ForStmt) {
 }
 
 func (fc *funcCompiler) compileForEach(s *ast.ForEachStmt) {
-	// Evaluate list expression once and store in a temporary local
-	listSlot := fc.allocLocal("_foreach_list", s)
+	if call, ok := s.ListExpr.(*ast.CallExpr); ok {
+		if ident, ok := call.Callee.(*ast.IdentExpr); ok && ident.Name == "range" {
+			if rangeBuiltin := fc.c.registry.LookupByName("range"); rangeBuiltin != nil {
+				fc.compileRangeLoop(s, call, rangeBuiltin)
+				return
+			}
+		}
+	}
+
+	lenBuiltin := fc.c.registry.LookupByName("len")
+	if lenBuiltin == nil {
+		fc.addError(s, "for-in loops need the len builtin")
+		return
+	}
+
+	// The hidden slots and the loop variable live in their own scope, as the
+	// type checker scopes the loop variable, so sibling loops do not clash.
+	prev := fc.scope
+	fc.scope = newLocalScope(prev)
+	defer fc.closeScope(prev)
+
+	// Evaluate list expression once and store in a temporary local. A dict
+	// is iterated over a snapshot of its keys, or of its [key, value]
+	// entries when the loop binds both, and a set over a snapshot of its
+	// elements.
+	listSlot := fc.allocLocal("__foreach_list", s)
 	fc.compileExpr(s.ListExpr)
+	isDict, isSet := false, false
+	if fc.c.bindings != nil {
+		switch fc.c.bindings.ExprTypes[s.ListExpr].(type) {
+		case *types.Dict:
+			isDict = true
+		case *types.Set:
+			isSet = true
+		}
+	}
+	if isDict {
+		method := "keys"
+		if s.KeyName != "" {
+			method = "entries"
+		}
+		snapshot := fc.c.registry.LookupMethod(builtins.TypeDict, method)
+		if snapshot == nil {
+			fc.addError(s, "for-in loops over dicts need the dict.%s builtin", method)
+			return
+		}
+		fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(snapshot), 1)
+	} else if isSet {
+		snapshot := fc.c.registry.LookupMethod(builtins.TypeSet, "toList")
+		if snapshot == nil {
+			fc.addError(s, "for-in loops over sets need the set.toList builtin")
+			return
+		}
+		fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(snapshot), 1)
+	}
 	fc.chunk.Emit(OpStoreLocal, listSlot, 0)
 	fc.chunk.Emit(OpPop, 0, 0)
 
 	// Allocate index variable
-	indexSlot := fc.allocLocal("_foreach_index", s)
+	indexSlot := fc.allocLocal("__foreach_index", s)
 	zeroIdx := fc.chunk.AddConstInt(0)
 	fc.chunk.Emit(OpConst, zeroIdx, 0)
 	fc.chunk.Emit(OpStoreLocal, indexSlot, 0)
 	fc.chunk.Emit(OpPop, 0, 0)
 
-	// Allocate loop variable
+	// Allocate loop variables
+	keySlot, entrySlot := -1, -1
+	if s.KeyName != "" {
+		keySlot = fc.allocLocal(s.KeyName, s)
+		if isDict {
+			entrySlot = fc.allocLocal("__foreach_entry", s)
+		}
+	}
 	varSlot := fc.allocLocal(s.VarName, s)
 
 	// Start a new loop context
@@ -1243,7 +1434,7 @@ func (fc *funcCompiler) compileForEach(s *ast.ForEachStmt) {
 	fc.chunk.Emit(OpLoadLocal, indexSlot, 0)
 	// Load list and call len builtin
 	fc.chunk.Emit(OpLoadLocal, listSlot, 0)
-	fc.chunk.Emit(OpCallBuiltin, int(builtins.Len), 1)
+	fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(lenBuiltin), 1)
 	// Compare: index < len (OpLt pops len then index, does index < len)
 	fc.chunk.Emit(OpLt, 0, 0)
 	// If index < len is false (i.e., index >= len), jump out
@@ -1253,8 +1444,28 @@ func (fc *funcCompiler) compileForEach(s *ast.ForEachStmt) {
 	fc.chunk.Emit(OpLoadLocal, listSlot, 0)
 	fc.chunk.Emit(OpLoadLocal, indexSlot, 0)
 	fc.chunk.Emit(OpIndex, 0, 0)
-	fc.chunk.Emit(OpStoreLocal, varSlot, 0)
-	fc.chunk.Emit(OpPop, 0, 0)
+	switch {
+	case isDict && keySlot >= 0:
+		// Split the [key, value] entry
+		fc.chunk.Emit(OpStoreLocal, entrySlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+		for i, slot := range []int{keySlot, varSlot} {
+			fc.chunk.Emit(OpLoadLocal, entrySlot, 0)
+			fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(int64(i)), 0)
+			fc.chunk.Emit(OpIndex, 0, 0)
+			fc.chunk.Emit(OpStoreLocal, slot, 0)
+			fc.chunk.Emit(OpPop, 0, 0)
+		}
+	case keySlot >= 0:
+		fc.chunk.Emit(OpStoreLocal, varSlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+		fc.chunk.Emit(OpLoadLocal, indexSlot, 0)
+		fc.chunk.Emit(OpStoreLocal, keySlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	default:
+		fc.chunk.Emit(OpStoreLocal, varSlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	}
 
 	// Compile body
 	fc.compileBlock(s.Body)
@@ -1281,6 +1492,189 @@ func (fc *funcCompiler) compileForEach(s *ast.ForEachStmt) {
 	fc.popLoop(afterLoop)
 }
 
+// compileRangeLoop compiles for (i in range(start, end, step)) as a counting
+// loop, without building the list that range returns elsewhere.
+func (fc *funcCompiler) compileRangeLoop(s *ast.ForEachStmt, call *ast.CallExpr, rangeBuiltin *builtins.Builtin) {
+	args, provided := fc.reorderCallArgs(call, rangeBuiltin.Meta.ParamNames, "range")
+	for i, prov := range provided[:len(provided)-rangeBuiltin.Meta.Optional] {
+		if !prov {
+			fc.addError(call, "missing argument for required parameter %q", rangeBuiltin.Meta.ParamNames[i])
+			return
+		}
+	}
+
+	prev := fc.scope
+	fc.scope = newLocalScope(prev)
+	defer fc.closeScope(prev)
+
+	// The bounds are evaluated once, in order. The counter is kept apart
+	// from the loop variable, which the body may assign.
+	curSlot := fc.allocLocal("__range_current", s)
+	endSlot := fc.allocLocal("__range_end", s)
+	for i, slot := range []int{curSlot, endSlot} {
+		fc.compileExpr(args[i])
+		fc.chunk.Emit(OpStoreLocal, slot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	}
+
+	// A constant step fixes the loop condition. Any other step is stored,
+	// checked for 0 like range does, and its sign tested on each iteration.
+	step, stepSlot := int64(1), -1
+	if args[2] != nil {
+		if n, ok := constStep(args[2]); ok && n != 0 {
+			step = n
+		} else {
+			stepSlot = fc.allocLocal("__range_step", s)
+			fc.compileExpr(args[2])
+			fc.chunk.Emit(OpStoreLocal, stepSlot, 0)
+			fc.chunk.Emit(OpPop, 0, 0)
+
+			errorBuiltin := fc.c.registry.LookupByName("error")
+			if errorBuiltin == nil {
+				fc.addError(s, "for-in loops over range need the error builtin")
+				return
+			}
+			fc.chunk.Emit(OpLoadLocal, stepSlot, 0)
+			fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(0), 0)
+			fc.chunk.Emit(OpEq, 0, 0)
+			jumpIfNonZero := fc.chunk.Emit(OpJumpIfFalse, 0, 0)
+			fc.chunk.Emit(OpConst, fc.chunk.AddConstString("range: step must not be 0"), 0)
+			fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(errorBuiltin), 1)
+			fc.chunk.Emit(OpThrow, 0, 0)
+			fc.chunk.Code[jumpIfNonZero].A = len(fc.chunk.Code)
+		}
+	}
+
+	// The key variable counts the iterations.
+	indexSlot, keySlot := -1, -1
+	if s.KeyName != "" {
+		indexSlot = fc.allocLocal("__range_index", s)
+		fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(0), 0)
+		fc.chunk.Emit(OpStoreLocal, indexSlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+		keySlot = fc.allocLocal(s.KeyName, s)
+	}
+	varSlot := fc.allocLocal(s.VarName, s)
+
+	// bySign emits pos for a positive step and neg for a negative one,
+	// testing the sign on each pass when the step is not a constant.
+	bySign := func(pos, neg func()) {
+		switch {
+		case stepSlot >= 0:
+			fc.chunk.Emit(OpLoadLocal, stepSlot, 0)
+			fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(0), 0)
+			fc.chunk.Emit(OpGt, 0, 0)
+			jumpIfNegative := fc.chunk.Emit(OpJumpIfFalse, 0, 0)
+			pos()
+			jumpOver := fc.chunk.Emit(OpJump, 0, 0)
+			fc.chunk.Code[jumpIfNegative].A = len(fc.chunk.Code)
+			neg()
+			fc.chunk.Code[jumpOver].A = len(fc.chunk.Code)
+		case step > 0:
+			pos()
+		default:
+			neg()
+		}
+	}
+	emitStep := func() {
+		if stepSlot >= 0 {
+			fc.chunk.Emit(OpLoadLocal, stepSlot, 0)
+		} else {
+			fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(step), 0)
+		}
+	}
+
+	// The counter stops once it reaches limit = end - step, before adding
+	// the step would carry it past the end, so a range ending near the int
+	// bounds does not overflow. When end - step itself is out of range,
+	// every value is the last one and limit is clamped to the bound.
+	limitSlot := fc.allocLocal("__range_limit", s)
+	emitLimit := func(bound int64, past OpCode) {
+		fc.chunk.Emit(OpLoadLocal, endSlot, 0)
+		fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(bound), 0)
+		emitStep()
+		fc.chunk.Emit(OpAdd, 0, 0)
+		fc.chunk.Emit(past, 0, 0)
+		jumpIfInRange := fc.chunk.Emit(OpJumpIfFalse, 0, 0)
+		fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(bound), 0)
+		jumpToStore := fc.chunk.Emit(OpJump, 0, 0)
+		fc.chunk.Code[jumpIfInRange].A = len(fc.chunk.Code)
+		fc.chunk.Emit(OpLoadLocal, endSlot, 0)
+		emitStep()
+		fc.chunk.Emit(OpSub, 0, 0)
+		fc.chunk.Code[jumpToStore].A = len(fc.chunk.Code)
+		fc.chunk.Emit(OpStoreLocal, limitSlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	}
+	bySign(func() { emitLimit(math.MinInt64, OpLt) }, func() { emitLimit(math.MaxInt64, OpGt) })
+
+	// Loop while current < end for a positive step, or current > end for a
+	// negative one; both checks jump past the loop when they fail.
+	var exits []int
+	emitCompare := func(slot int, op OpCode) func() {
+		return func() {
+			fc.chunk.Emit(OpLoadLocal, curSlot, 0)
+			fc.chunk.Emit(OpLoadLocal, slot, 0)
+			fc.chunk.Emit(op, 0, 0)
+			exits = append(exits, fc.chunk.Emit(OpJumpIfFalse, 0, 0))
+		}
+	}
+	bySign(emitCompare(endSlot, OpLt), emitCompare(endSlot, OpGt))
+
+	fc.pushLoop(-1)
+	loopStart := len(fc.chunk.Code)
+
+	fc.chunk.Emit(OpLoadLocal, curSlot, 0)
+	fc.chunk.Emit(OpStoreLocal, varSlot, 0)
+	fc.chunk.Emit(OpPop, 0, 0)
+	if keySlot >= 0 {
+		fc.chunk.Emit(OpLoadLocal, indexSlot, 0)
+		fc.chunk.Emit(OpStoreLocal, keySlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	}
+
+	fc.compileBlock(s.Body)
+
+	fc.setLoopContinueTarget(len(fc.chunk.Code))
+
+	// Stop at the last value, then advance the counter and the iteration
+	// index
+	bySign(emitCompare(limitSlot, OpLt), emitCompare(limitSlot, OpGt))
+	fc.chunk.Emit(OpLoadLocal, curSlot, 0)
+	emitStep()
+	fc.chunk.Emit(OpAdd, 0, 0)
+	fc.chunk.Emit(OpStoreLocal, curSlot, 0)
+	fc.chunk.Emit(OpPop, 0, 0)
+	if indexSlot >= 0 {
+		fc.chunk.Emit(OpLoadLocal, indexSlot, 0)
+		fc.chunk.Emit(OpConst, fc.chunk.AddConstInt(1), 0)
+		fc.chunk.Emit(OpAdd, 0, 0)
+		fc.chunk.Emit(OpStoreLocal, indexSlot, 0)
+		fc.chunk.Emit(OpPop, 0, 0)
+	}
+
+	fc.chunk.Emit(OpJump, loopStart, 0)
+
+	afterLoop := len(fc.chunk.Code)
+	for _, idx := range exits {
+		fc.chunk.Code[idx].A = afterLoop
+	}
+	fc.popLoop(afterLoop)
+}
+
+// constStep returns the value of an int literal step, possibly negated.
+func constStep(e ast.Expr) (int64, bool) {
+	switch ex := e.(type) {
+	case *ast.IntLiteral:
+		return ex.Value, true
+	case *ast.UnaryExpr:
+		if lit, ok := ex.X.(*ast.IntLiteral); ok && ex.Op == token.Minus {
+			return -lit.Value, true
+		}
+	}
+	return 0, false
+}
+
 func (fc *funcCompiler) compileSwitch(s *ast.SwitchStmt) {
 	tmpName := fmt.Sprintf("__switch_%d", len(fc.chunk.Code))
 	tmpSlot := fc.allocLocal(tmpName, s)
@@ -1326,13 +1720,17 @@ func (fc *funcCompiler) compileTry(s *ast.TryStmt) {
 	handlerIP := len(fc.chunk.Code)
 	fc.chunk.Code[beginIdx].A = handlerIP
 
-	if len(s.Catches) > 1 {
+	if len(s.Catches) > 0 {
 		var jumpToEnds []int
 
 		for _, clause := range s.Catches {
 			typeName := ""
-			if st, ok := clause.Type.(*ast.SimpleType); ok {
-				typeName = st.Name
+			switch t := clause.Type.(type) {
+			case *ast.SimpleType:
+				typeName = t.Name
+			case *ast.QualifiedType:
+				// Struct types are indexed by their unqualified name.
+				typeName = t.Path[len(t.Path)-1]
 			}
 
 			prevScope := fc.scope
@@ -1343,13 +1741,13 @@ func (fc *funcCompiler) compileTry(s *ast.TryStmt) {
 				fc.chunk.Emit(OpStoreLocal, slot, 0)
 				fc.chunk.Emit(OpPop, 0, 0)
 				fc.compileBlock(clause.Body)
-				fc.scope = prevScope
+				fc.closeScope(prevScope)
 				jumpToEnds = append(jumpToEnds, fc.chunk.Emit(OpJump, 0, 0))
 			} else {
 				structIdx, found := fc.c.structIndex[typeName]
 				if !found {
 					fc.addError(s, "unknown struct type %q in catch clause", typeName)
-					fc.scope = prevScope
+					fc.closeScope(prevScope)
 					continue
 				}
 
@@ -1360,7 +1758,7 @@ func (fc *funcCompiler) compileTry(s *ast.TryStmt) {
 				fc.chunk.Emit(OpStoreLocal, slot, 0)
 				fc.chunk.Emit(OpPop, 0, 0)
 				fc.compileBlock(clause.Body)
-				fc.scope = prevScope
+				fc.closeScope(prevScope)
 				jumpToEnds = append(jumpToEnds, fc.chunk.Emit(OpJump, 0, 0))
 
 				nextClauseIP := len(fc.chunk.Code)
@@ -1417,6 +1815,7 @@ func (fc *funcCompiler) compileIf(s *ast.IfStmt) {
 // ---------- Expressions ----------
 
 func (fc *funcCompiler) compileExpr(e ast.Expr) {
+	defer fc.markLine(e)()
 	switch ex := e.(type) {
 	case *ast.IntLiteral:
 		idx := fc.chunk.AddConstInt(ex.Value)
@@ -1525,8 +1924,15 @@ func (fc *funcCompiler) compileExpr(e ast.Expr) {
 		if info != nil {
 			numUpvalues = len(info.Upvalues)
 			// Push values for non-local upvalues (parent's upvalues)
-			for _, uv := range info.Upvalues {
-				if !uv.IsLocal {
+			for i, uv := range info.Upvalues {
+				if uv.IsLocal {
+					// The resolver numbers locals by declaration order and does not
+					// know about hidden slots (foreach list/index, switch temps), so
+					// point the upvalue at the slot this compiler actually allocated.
+					if slot, ok := fc.lookupLocal(uv.Name); ok {
+						fc.c.mod.Functions[fnIndex].Upvalues[i].Index = slot
+					}
+				} else {
 					// Capture from parent function's upvalue - load and push the value
 					parentUpvalueIdx, ok := fc.lookupUpvalue(uv.Name)
 					if !ok {
@@ -1573,6 +1979,8 @@ func (fc *funcCompiler) compileExpr(e ast.Expr) {
 
 	case *ast.DictLiteral:
 		fc.compileDictLiteral(ex)
+	case *ast.SetLiteral:
+		fc.compileSetLiteral(ex)
 
 	case *ast.StructLiteral:
 		fc.compileStructLiteral(ex)
@@ -1885,6 +2293,9 @@ func (fc *funcCompiler) compileExpr(e ast.Expr) {
 		fc.compileExpr(ex.Expr)
 		fc.chunk.Emit(OpAwait, 0, 0)
 
+	case *ast.SpawnExpr:
+		fc.compileCallExpr(ex.Call, true)
+
 	default:
 		fc.addError(e, "unsupported expression of type %T", e)
 	}
@@ -1904,14 +2315,26 @@ func (fc *funcCompiler) compileDictLiteral(lit *ast.DictLiteral) {
 	// Compile entries in order: key1, value1, key2, value2, ...
 	count := len(lit.Entries)
 	for _, entry := range lit.Entries {
-		keyIdx := fc.chunk.AddConstString(entry.Key)
-		fc.chunk.Emit(OpConst, keyIdx, 0)
+		if entry.KeyExpr != nil {
+			fc.compileExpr(entry.KeyExpr)
+		} else {
+			keyIdx := fc.chunk.AddConstString(entry.Key)
+			fc.chunk.Emit(OpConst, keyIdx, 0)
+		}
 		fc.compileExpr(entry.Value)
 	}
 	// OpMakeDict pops 2*count values and creates a dict
 	fc.chunk.Emit(OpMakeDict, count, 0)
 }
 
+func (fc *funcCompiler) compileSetLiteral(lit *ast.SetLiteral) {
+	for _, el := range lit.Elements {
+		fc.compileExpr(el)
+	}
+	// OpMakeSet pops n values and creates a set, dropping duplicates
+	fc.chunk.Emit(OpMakeSet, len(lit.Elements), 0)
+}
+
 func (fc *funcCompiler) compileStructLiteral(lit *ast.StructLiteral) {
 	// Resolve struct name: for generic structs, use the monomorphized name
 	structName := lit.TypeName
@@ -2190,16 +2613,37 @@ func (fc *funcCompiler) reorderCallArgs(call *ast.CallExpr, paramNames []string,
 }
 
 func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
+	fc.compileCallExpr(call, false)
+}
+
+// compileBuiltinArg compiles an argument of a builtin call, or none for an
+// omitted optional parameter.
+func (fc *funcCompiler) compileBuiltinArg(arg ast.Expr) {
+	if arg == nil {
+		fc.chunk.Emit(OpConst, fc.chunk.AddConstNone(), 0)
+		return
+	}
+	fc.compileExpr(arg)
+}
+
+// compileCallExpr compiles a call. With spawn set (spawn f(args)), the callee runs
+// as a separate task and a Future is left on the stack even for sync functions.
+func (fc *funcCompiler) compileCallExpr(call *ast.CallExpr, spawn bool) {
 	// Builtins by simple name
 	if ident, ok := call.Callee.(*ast.IdentExpr); ok {
-		if builtin := builtins.LookupByName(ident.Name); builtin != nil {
+		if builtin := fc.c.registry.LookupByName(ident.Name); builtin != nil {
+			if spawn {
+				fc.addError(call, "cannot spawn builtin function %q", ident.Name)
+				return
+			}
 			// builtin call with named argument support
 			reorderedArgs, provided := fc.reorderCallArgs(call, builtin.Meta.ParamNames, builtin.Meta.Name)
 
-			// Builtins don't have defaults - check that all parameters are provided
+			// Builtins don't have defaults - check that all parameters are
+			// provided, except optional ones, which are passed as none
 			allProvided := true
 			for i, prov := range provided {
-				if !prov {
+				if !prov && i < len(provided)-builtin.Meta.Optional {
 					fc.addError(call, "missing argument for required parameter %q", builtin.Meta.ParamNames[i])
 					allProvided = false
 				}
@@ -2219,13 +2663,13 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 
 			// Compile arguments in parameter order
 			for _, arg := range reorderedArgs {
-				fc.compileExpr(arg)
+				fc.compileBuiltinArg(arg)
 			}
 
-			if builtins.IsAsyncBuiltin(builtin.Meta.ID) {
-				fc.chunk.Emit(OpCallBuiltinAsync, int(builtin.Meta.ID), len(reorderedArgs))
+			if builtin.CallAsync != nil {
+				fc.chunk.Emit(OpCallBuiltinAsync, fc.c.builtinRef(builtin), len(reorderedArgs))
 			} else {
-				fc.chunk.Emit(OpCallBuiltin, int(builtin.Meta.ID), len(reorderedArgs))
+				fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(builtin), len(reorderedArgs))
 			}
 			return
 		}
@@ -2276,10 +2720,16 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 					typeKind, found = builtins.TypeList, true
 				case *types.Dict:
 					typeKind, found = builtins.TypeDict, true
+				case *types.Set:
+					typeKind, found = builtins.TypeSet, true
 				}
 
 				if found {
-					if methodBuiltin := builtins.LookupMethod(typeKind, cal.Name); methodBuiltin != nil {
+					if methodBuiltin := fc.c.registry.LookupMethod(typeKind, cal.Name); methodBuiltin != nil {
+						if spawn {
+							fc.addError(call, "cannot spawn built-in method %q", cal.Name)
+							return
+						}
 						// Compile built-in method call with named argument support
 						// Build effective arguments: receiver + call.Args
 						effectiveArgs := append([]ast.Expr{cal.X}, call.Args...)
@@ -2288,7 +2738,7 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 						// Check that all required parameters are provided
 						allProvided := true
 						for i, prov := range provided {
-							if !prov {
+							if !prov && i < len(provided)-methodBuiltin.Meta.Optional {
 								fc.addError(call, "missing argument for required parameter %q", methodBuiltin.Meta.ParamNames[i])
 								allProvided = false
 							}
@@ -2304,14 +2754,12 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 
 						// Compile arguments in parameter order (receiver first)
 						for _, arg := range reorderedArgs {
-							if arg != nil {
-								fc.compileExpr(arg)
-							}
+							fc.compileBuiltinArg(arg)
 						}
 
 						// Emit OpCallBuiltin with receiver + arguments
 						// Arity for methods includes the receiver
-						fc.chunk.Emit(OpCallBuiltin, int(methodBuiltin.Meta.ID), methodBuiltin.Meta.Arity)
+						fc.chunk.Emit(OpCallBuiltin, fc.c.builtinRef(methodBuiltin), methodBuiltin.Meta.Arity)
 						return
 					}
 				}
@@ -2423,6 +2871,36 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 		}
 	}
 
+	// Interface method call: the target depends on the receiver's runtime
+	// type, so the VM resolves the call site and caches the result.
+	if cal, ok := call.Callee.(*ast.MemberExpr); ok && !hasFn && fc.c.bindings != nil {
+		var xType types.Type
+		if ident, ok2 := cal.X.(*ast.IdentExpr); ok2 {
+			if xSym, ok3 := fc.c.bindings.Idents[ident]; ok3 && xSym.Kind != types.SymType {
+				xType = xSym.Type
+			}
+		} else {
+			xType = fc.c.bindings.ExprTypes[cal.X]
+		}
+		if _, ok2 := xType.(*types.Interface); ok2 {
+			if spawn {
+				fc.addError(call, "cannot spawn interface method %q", cal.Name)
+				return
+			}
+			fc.compileExpr(cal.X)
+			for _, arg := range call.Args {
+				if _, ok3 := arg.(*ast.NamedArg); ok3 {
+					fc.addError(call, "named arguments are not allowed in interface method calls")
+				}
+				fc.compileExpr(arg)
+			}
+			site := len(fc.c.mod.MethodCalls)
+			fc.c.mod.MethodCalls = append(fc.c.mod.MethodCalls, cal.Name)
+			fc.chunk.Emit(OpCallMethod, site, 1+len(call.Args))
+			return
+		}
+	}
+
 	// If we know fnDecl, handle named/default args and emit OpCall
 	if hasFn && fnDecl != nil {
 		// For methods, prepend receiver to parameter names and arguments
@@ -2490,7 +2968,7 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 		}
 
 		// Direct call by index with the number of parameters (including receiver for methods)
-		if fnDecl.IsAsync {
+		if fnDecl.IsAsync || spawn {
 			fc.chunk.Emit(OpSpawn, fnIndex, nParams)
 		} else {
 			fc.chunk.Emit(OpCall, fnIndex, nParams)
@@ -2509,7 +2987,9 @@ func (fc *funcCompiler) compileCall(call *ast.CallExpr) {
 	fc.compileExpr(call.Callee)
 	// If callee's type returns Future, set B=1 so the VM spawns it as a child task.
 	spawnFlag := 0
-	if fc.c.bindings != nil {
+	if spawn {
+		spawnFlag = 1
+	} else if fc.c.bindings != nil {
 		if calleeType, ok := fc.c.bindings.ExprTypes[call.Callee]; ok {
 			if ft, ok2 := calleeType.(*types.Func); ok2 {
 				if _, isFut := ft.Result.(*types.Future); isFut {
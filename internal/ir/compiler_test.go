@@ -2,8 +2,13 @@ package ir_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +17,7 @@ import (
 	"avenir/internal/modules"
 	"avenir/internal/parser"
 	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
 	"avenir/internal/types"
 	"avenir/internal/value"
 	"avenir/internal/vm"
@@ -147,8 +153,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 7 {
-		t.Fatalf("expected 7, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 7 {
+		t.Fatalf("expected 7, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -194,8 +200,8 @@ fun hello_or_bye(a | int) | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "small" {
-		t.Fatalf("expected \"small\", got %q (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "small" {
+		t.Fatalf("expected \"small\", got %q (%s)", val.Str(), val.String())
 	}
 }
 
@@ -324,8 +330,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -362,8 +368,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "hello world!" {
-		t.Fatalf("expected \"hello world!\", got %q (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "hello world!" {
+		t.Fatalf("expected \"hello world!\", got %q (%s)", val.Str(), val.String())
 	}
 }
 
@@ -451,8 +457,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -489,8 +495,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 2 {
-		t.Fatalf("expected 2, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 2 {
+		t.Fatalf("expected 2, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -535,8 +541,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
-		t.Fatalf("expected 42, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 42 {
+		t.Fatalf("expected 42, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -576,8 +582,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -618,8 +624,8 @@ fun main() | int {
 	}
 
 	// sum = 0 + 1 + 2 + 3 + 4 = 10
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -661,8 +667,8 @@ fun main() | int {
 	}
 
 	// sum = 1 + 2 + 3 + 4 + 5 = 15
-	if val.Kind != value.KindInt || val.Int != 15 {
-		t.Fatalf("expected 15, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 15 {
+		t.Fatalf("expected 15, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -704,8 +710,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -746,8 +752,8 @@ fun main() | int {
 	}
 
 	// sum(5) with default b=0 should return 5
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -788,8 +794,8 @@ fun main() | int {
 	}
 
 	// sum(b=5, a=1) should return 6
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -866,8 +872,8 @@ fun main() | int {
 			t.Fatalf("RunMain error: %v", err)
 		}
 
-		if val.Kind != value.KindInt || val.Int != 3 {
-			t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+		if val.Kind != value.KindInt || val.Int() != 3 {
+			t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 		}
 	})
 
@@ -906,9 +912,9 @@ fun main() | error {
 		if val.Kind != value.KindError {
 			t.Fatalf("expected error value, got %v (%s)", val, val.String())
 		}
-		msg := val.Str
-		if val.Error != nil && val.Error.Message != "" {
-			msg = val.Error.Message
+		msg := val.Str()
+		if val.Error() != nil && val.Error().Message != "" {
+			msg = val.Error().Message
 		}
 		if msg != "oops" {
 			t.Fatalf("expected error 'oops', got %q (%s)", msg, val.String())
@@ -948,7 +954,7 @@ fun main() | string {
 			t.Fatalf("RunMain error: %v", err)
 		}
 
-		if val.Kind != value.KindString || val.Str != "fail" {
+		if val.Kind != value.KindString || val.Str() != "fail" {
 			t.Fatalf("expected 'fail', got %v (%s)", val, val.String())
 		}
 	})
@@ -1646,6 +1652,34 @@ fun main() | void {
 	}
 }
 
+func TestCompileWorld_ClosureAfterForEach(t *testing.T) {
+	// foreach allocates hidden slots the resolver does not number; captures
+	// declared after a loop must still point at the right local. Sibling
+	// loops may reuse the loop variable name.
+	output := runWorldWithStd(t, `pckg main;
+
+fun twice(f | fun(string) | void) | void {
+    f("a");
+    f("b");
+}
+
+fun main() | void {
+    for (x in [1, 2]) {
+        print(x);
+    }
+    for (x in [3]) {
+        print(x);
+    }
+    var seen | list<string> = [];
+    twice(fun(s | string) | void {
+        seen = seen.append(s);
+    });
+    print(seen);
+}
+`)
+	expectOutput(t, output, []string{"1", "2", "3", "[a, b]"})
+}
+
 func TestCompile_ClosureNoCapture(t *testing.T) {
 	src := `
 pckg main;
@@ -1843,8 +1877,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -1888,8 +1922,8 @@ fun main() | int {
 	}
 
 	// sum = 0 + 1 + 2 = 3
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -1934,8 +1968,8 @@ fun main() | int {
 	}
 
 	// sum = 1 + 2 + 3 = 6
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -1978,8 +2012,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 4 {
-		t.Fatalf("expected 4, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 4 {
+		t.Fatalf("expected 4, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2025,8 +2059,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 20 {
-		t.Fatalf("expected 20, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 20 {
+		t.Fatalf("expected 20, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2082,8 +2116,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 1 {
-		t.Fatalf("expected 1, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 1 {
+		t.Fatalf("expected 1, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2173,8 +2207,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2217,8 +2251,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2261,8 +2295,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 9000 {
-		t.Fatalf("expected 9000, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 9000 {
+		t.Fatalf("expected 9000, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2306,8 +2340,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 15 {
-		t.Fatalf("expected 15, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 15 {
+		t.Fatalf("expected 15, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2350,8 +2384,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 20 {
-		t.Fatalf("expected 20, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 20 {
+		t.Fatalf("expected 20, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2393,8 +2427,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 1 {
-		t.Fatalf("expected 1, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 1 {
+		t.Fatalf("expected 1, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2440,8 +2474,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2488,8 +2522,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 15 {
-		t.Fatalf("expected 15, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 15 {
+		t.Fatalf("expected 15, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2544,8 +2578,8 @@ fun main() | int {
 	}
 
 	// 3*3 + 4*4 = 9 + 16 = 25
-	if val.Kind != value.KindInt || val.Int != 25 {
-		t.Fatalf("expected 25, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 25 {
+		t.Fatalf("expected 25, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2593,8 +2627,8 @@ fun main() | int {
 	}
 
 	// 10 + 5 + 2 = 17
-	if val.Kind != value.KindInt || val.Int != 17 {
-		t.Fatalf("expected 17, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 17 {
+		t.Fatalf("expected 17, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2644,8 +2678,8 @@ fun main() | int {
 	}
 
 	// (3-0)^2 + (4-0)^2 = 9 + 16 = 25
-	if val.Kind != value.KindInt || val.Int != 25 {
-		t.Fatalf("expected 25, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 25 {
+		t.Fatalf("expected 25, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2691,8 +2725,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2738,8 +2772,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2789,8 +2823,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2847,8 +2881,182 @@ fun main() | int {
 	}
 
 	// 0 + 5 + 10 = 15
-	if val.Kind != value.KindInt || val.Int != 15 {
-		t.Fatalf("expected 15, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 15 {
+		t.Fatalf("expected 15, got %v (%s)", val.Int(), val.String())
+	}
+}
+
+func TestCompile_InterfaceMethodCall(t *testing.T) {
+	src := `
+pckg main;
+
+interface Shape {
+    fun area() | int
+    fun scaled(k | int) | int
+}
+
+struct Rect {
+    w | int
+    h | int
+}
+
+struct Square {
+    s | int
+}
+
+fun (r | Rect).area() | int {
+    return r.w * r.h;
+}
+
+fun (r | Rect).scaled(k | int) | int {
+    return r.area() * k;
+}
+
+fun Square.unit() | Square {
+    return Square{s = 1};
+}
+
+fun (q | Square).area() | int {
+    return q.s * q.s;
+}
+
+fun (q | Square).scaled(k | int) | int {
+    return q.area() * k * 10;
+}
+
+fun main() | int {
+    var shapes | list<Shape> = [Rect{w = 2, h = 3}, Square{s = 4}, Square.unit()];
+    var total | int = 0;
+    for (s in shapes) {
+        total = total + s.area() + s.scaled(2);
+    }
+    return total;
+}
+`
+	l := lexer.New(src)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+	if got := mod.MethodCalls; !reflect.DeepEqual(got, []string{"area", "scaled"}) {
+		t.Fatalf("method calls = %v", got)
+	}
+	for _, st := range mod.StructTypes {
+		if len(st.Methods) != 2 {
+			t.Errorf("%s methods = %v, want area and scaled", st.Name, st.Methods)
+		}
+	}
+
+	// Tables survive serialization.
+	var buf bytes.Buffer
+	if err := ir.WriteModule(&buf, mod); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ir.ReadModule(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.MethodCalls, mod.MethodCalls) {
+		t.Fatalf("method calls %v, want %v", loaded.MethodCalls, mod.MethodCalls)
+	}
+	for i, st := range loaded.StructTypes {
+		if !reflect.DeepEqual(st.Methods, mod.StructTypes[i].Methods) {
+			t.Fatalf("%s methods %v, want %v", st.Name, st.Methods, mod.StructTypes[i].Methods)
+		}
+	}
+
+	for _, m := range []*ir.Module{mod, loaded} {
+		val, err := vm.NewVM(m, runtime.DefaultEnv()).RunMain()
+		if err != nil {
+			t.Fatalf("RunMain error: %v", err)
+		}
+		// 6 + 12, 16 + 320, 1 + 20
+		if val.Kind != value.KindInt || val.Int() != 375 {
+			t.Fatalf("expected 375, got %s", val.String())
+		}
+	}
+}
+
+func TestCompile_InterfaceMethodBuiltinReceiver(t *testing.T) {
+	src := `
+pckg main;
+
+interface Length {
+    fun length() | int
+}
+
+struct Pair {
+    a | int
+    b | int
+}
+
+fun (p | Pair).length() | int {
+    return 2;
+}
+
+fun main() | int {
+    var items | list<Length> = ["hello", [1, 2, 3], Pair{a = 1, b = 2}, "!"];
+    var total | int = 0;
+    for (item in items) {
+        total = total * 10 + item.length();
+    }
+    return total;
+}
+`
+	l := lexer.New(src)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+	val, err := vm.NewVM(mod, runtime.DefaultEnv()).RunMain()
+	if err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	if val.Kind != value.KindInt || val.Int() != 5321 {
+		t.Fatalf("expected 5321, got %s", val.String())
+	}
+}
+
+func TestCompile_InterfaceMethodSpawn(t *testing.T) {
+	src := `
+pckg main;
+
+interface Named {
+    fun name() | string
+}
+
+struct User {
+    id | int
+}
+
+fun (u | User).name() | string {
+    return "u${u.id}";
+}
+
+fun main() | void {
+    var n | Named = User{id = 1};
+    spawn n.name();
+}
+`
+	l := lexer.New(src)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	_, errs := ir.Compile(prog)
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), "cannot spawn interface method") {
+		t.Fatalf("expected spawn error, got %v", errs)
 	}
 }
 
@@ -2885,8 +3093,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2924,8 +3132,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 4 {
-		t.Fatalf("expected 4, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 4 {
+		t.Fatalf("expected 4, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -2963,8 +3171,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3002,8 +3210,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3041,8 +3249,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3080,8 +3288,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3118,8 +3326,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -3156,8 +3364,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 20 {
-		t.Fatalf("expected 20, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 20 {
+		t.Fatalf("expected 20, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3194,8 +3402,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -3232,8 +3440,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 1 {
-		t.Fatalf("expected 1, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 1 {
+		t.Fatalf("expected 1, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3271,8 +3479,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3310,8 +3518,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3349,8 +3557,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3390,8 +3598,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3431,8 +3639,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 2 {
-		t.Fatalf("expected 2, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 2 {
+		t.Fatalf("expected 2, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3472,8 +3680,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3513,8 +3721,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3554,8 +3762,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3595,8 +3803,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
-		t.Fatalf("expected 42, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 42 {
+		t.Fatalf("expected 42, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3634,8 +3842,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3674,8 +3882,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 10 {
-		t.Fatalf("expected 10, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 10 {
+		t.Fatalf("expected 10, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3713,8 +3921,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3752,8 +3960,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3792,8 +4000,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3831,8 +4039,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 0 {
-		t.Fatalf("expected 0, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 0 {
+		t.Fatalf("expected 0, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3869,8 +4077,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -3907,8 +4115,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "HELLO" {
-		t.Fatalf("expected \"HELLO\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "HELLO" {
+		t.Fatalf("expected \"HELLO\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -3945,8 +4153,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "hello" {
-		t.Fatalf("expected \"hello\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "hello" {
+		t.Fatalf("expected \"hello\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -3983,8 +4191,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "hello" {
-		t.Fatalf("expected \"hello\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "hello" {
+		t.Fatalf("expected \"hello\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -4021,8 +4229,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -4059,8 +4267,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -4097,8 +4305,8 @@ fun main() | bool {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindBool || val.Bool != true {
-		t.Fatalf("expected true, got %v (%s)", val.Bool, val.String())
+	if val.Kind != value.KindBool || val.Bool() != true {
+		t.Fatalf("expected true, got %v (%s)", val.Bool(), val.String())
 	}
 }
 
@@ -4135,8 +4343,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "hello avenir" {
-		t.Fatalf("expected \"hello avenir\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "hello avenir" {
+		t.Fatalf("expected \"hello avenir\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -4174,8 +4382,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4212,8 +4420,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 6 {
-		t.Fatalf("expected 6, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 6 {
+		t.Fatalf("expected 6, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4250,8 +4458,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 12 {
-		t.Fatalf("expected 12, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 12 {
+		t.Fatalf("expected 12, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4288,8 +4496,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "HELLO WORLD" {
-		t.Fatalf("expected \"HELLO WORLD\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "HELLO WORLD" {
+		t.Fatalf("expected \"HELLO WORLD\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -4326,8 +4534,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 5 {
-		t.Fatalf("expected 5, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 5 {
+		t.Fatalf("expected 5, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4367,8 +4575,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
-		t.Fatalf("expected 42, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 42 {
+		t.Fatalf("expected 42, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4408,8 +4616,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "hello" {
-		t.Fatalf("expected \"hello\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "hello" {
+		t.Fatalf("expected \"hello\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -4450,8 +4658,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 99 {
-		t.Fatalf("expected 99, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 99 {
+		t.Fatalf("expected 99, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4491,8 +4699,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 7 {
-		t.Fatalf("expected 7, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 7 {
+		t.Fatalf("expected 7, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4536,8 +4744,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 123 {
-		t.Fatalf("expected 123, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 123 {
+		t.Fatalf("expected 123, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4581,8 +4789,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 11 {
-		t.Fatalf("expected 11, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 11 {
+		t.Fatalf("expected 11, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4620,8 +4828,8 @@ fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "HELLO" {
-		t.Fatalf("expected \"HELLO\", got %v (%s)", val.Str, val.String())
+	if val.Kind != value.KindString || val.Str() != "HELLO" {
+		t.Fatalf("expected \"HELLO\", got %v (%s)", val.Str(), val.String())
 	}
 }
 
@@ -4662,8 +4870,8 @@ async fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 30 {
-		t.Fatalf("expected 30, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 30 {
+		t.Fatalf("expected 30, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4745,8 +4953,8 @@ async fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 20 {
-		t.Fatalf("expected 20, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 20 {
+		t.Fatalf("expected 20, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -4829,7 +5037,7 @@ async fun main() | int {
 		t.Fatalf("expected int result, got %v", val.Kind)
 	}
 
-	elapsedNanos := val.Int
+	elapsedNanos := val.Int()
 	if elapsedNanos < 40000000 {
 		t.Fatalf("sleep was too short: %d ns (expected >= 40ms)", elapsedNanos)
 	}
@@ -4872,7 +5080,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "caught" {
+	if val.Kind != value.KindString || val.Str() != "caught" {
 		t.Fatalf("expected 'caught', got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -4919,7 +5127,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "async hello" {
+	if val.Kind != value.KindString || val.Str() != "async hello" {
 		t.Fatalf("expected 'async hello', got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -4968,7 +5176,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "ok" {
+	if val.Kind != value.KindString || val.Str() != "ok" {
 		t.Fatalf("expected 'ok', got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -5012,7 +5220,7 @@ async fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
+	if val.Kind != value.KindInt || val.Int() != 42 {
 		t.Fatalf("expected 42, got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -5063,7 +5271,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "ok" {
+	if val.Kind != value.KindString || val.Str() != "ok" {
 		t.Fatalf("expected 'ok', got %v (%s)", val.Kind, val.String())
 	}
 
@@ -5111,8 +5319,8 @@ async fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
-		t.Fatalf("expected 42, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 42 {
+		t.Fatalf("expected 42, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5159,7 +5367,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "timeout" {
+	if val.Kind != value.KindString || val.Str() != "timeout" {
 		t.Fatalf("expected 'timeout', got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -5208,8 +5416,8 @@ fun main() | int {
 	}
 
 	// add(3, 4) = 7, doubler wraps it so result = 7 * 2 = 14
-	if val.Kind != value.KindInt || val.Int != 14 {
-		t.Fatalf("expected 14, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 14 {
+		t.Fatalf("expected 14, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5259,8 +5467,8 @@ fun main() | int {
 	}
 
 	// square(5) = 25, multiplier(3) wraps it so result = 25 * 3 = 75
-	if val.Kind != value.KindInt || val.Int != 75 {
-		t.Fatalf("expected 75, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 75 {
+		t.Fatalf("expected 75, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5316,8 +5524,8 @@ fun main() | int {
 
 	// add(3,4)=7, doubler wraps: 7*2=14, negator wraps: -14
 	// @negator @doubler means negator(doubler(add)), so result = -(2*(3+4)) = -14
-	if val.Kind != value.KindInt || val.Int != -14 {
-		t.Fatalf("expected -14, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != -14 {
+		t.Fatalf("expected -14, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5364,7 +5572,7 @@ async fun main() | string {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindString || val.Str != "caught" {
+	if val.Kind != value.KindString || val.Str() != "caught" {
 		t.Fatalf("expected 'caught', got %v (%s)", val.Kind, val.String())
 	}
 }
@@ -5405,8 +5613,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 42 {
-		t.Fatalf("expected 42, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 42 {
+		t.Fatalf("expected 42, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5446,8 +5654,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 2 {
-		t.Fatalf("expected 2, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 2 {
+		t.Fatalf("expected 2, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5498,8 +5706,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 14 {
-		t.Fatalf("expected 14 (doubler decorator), got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 14 {
+		t.Fatalf("expected 14 (doubler decorator), got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5539,8 +5747,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 30 {
-		t.Fatalf("expected 30, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 30 {
+		t.Fatalf("expected 30, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5582,8 +5790,8 @@ fun main() | int {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if val.Kind != value.KindInt || val.Int != 21 {
-		t.Fatalf("expected 21, got %v (%s)", val.Int, val.String())
+	if val.Kind != value.KindInt || val.Int() != 21 {
+		t.Fatalf("expected 21, got %v (%s)", val.Int(), val.String())
 	}
 }
 
@@ -5916,155 +6124,680 @@ fun main() | void {
 	}
 }
 
-func TestCompileWorld_HTMLBuilder(t *testing.T) {
-	tmpDir := t.TempDir()
+func TestCompile_DictOrder(t *testing.T) {
+	src := `
+pckg main;
 
-	realStd, err := filepath.Abs("../../std")
-	if err != nil {
-		t.Fatalf("failed to resolve std path: %v", err)
+fun main() | void {
+    var d | dict<string, int> = {zeta: 1, alpha: 2, "mid": 3};
+    d.set("beta", 4);
+    d.set("zeta", 5);
+    print(d);
+    d.remove("alpha");
+    d.set("alpha", 6);
+    print(d.keys());
+    print(d.values());
+    print(d.entries());
+    for (k, v in d) {
+        print("${k}=${v}");
+        d.set(k + "!", v);
+    }
+    for (k in d) {
+        print(k);
+    }
+    for (i, s in ["a", "b"]) {
+        print("${i}:${s}");
+    }
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
 	}
-	if _, err := os.Stat(realStd); os.IsNotExist(err) {
-		t.Skip("std/ directory not found, skipping HTML builder test")
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
 	}
-	if err := os.Symlink(realStd, filepath.Join(tmpDir, "std")); err != nil {
-		t.Fatalf("failed to symlink std: %v", err)
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
 	}
+	expectOutput(t, output, []string{
+		"{zeta: 5, alpha: 2, mid: 3, beta: 4}",
+		"[zeta, mid, beta, alpha]",
+		"[5, 3, 4, 6]",
+		"[[zeta, 5], [mid, 3], [beta, 4], [alpha, 6]]",
+		// The loop runs over the entries when it started.
+		"zeta=5", "mid=3", "beta=4", "alpha=6",
+		"zeta", "mid", "beta", "alpha", "zeta!", "mid!", "beta!", "alpha!",
+		"0:a", "1:b",
+	})
+}
 
-	mainFile := filepath.Join(tmpDir, "main.av")
-	mainContent := `pckg main;
+func TestCompile_DictKeyKinds(t *testing.T) {
+	src := `
+pckg main;
 
-import std.web.html as html;
+struct Point {
+    x | int
+    y | int
+}
 
 fun main() | void {
-    var result | string = html.build(fun(h | html.Builder) | void {
-        h.doctype();
-        h.html(fun() | void {
-            h.head(fun() | void {
-                h.title("Test Page");
-                h.meta({charset: "utf-8"});
-            });
-            h.body(fun() | void {
-                h.div({class: "container"}, fun() | void {
-                    h.h1("Hello World");
-                    h.p("This is a test");
-                });
-                h.ul(fun() | void {
-                    h.li("Item 1");
-                    h.li("Item 2");
-                });
-                h.br();
-                h.img({src: "/logo.png", alt: "Logo"});
-            });
-        });
-    });
-    print(result);
-
-    var escaped | string = html.escape("<script>alert('xss')</script>");
-    print(escaped);
+    var base | int = 10;
+    var ids | dict<int, string> = {1: "one", base + 1: "eleven"};
+    print(ids[11]);
+    print(ids.has(1));
+    var flags | dict<bool, string> = {true: "yes", false: "no"};
+    print(flags[false]);
+    var grid | dict<Point, string> = {};
+    grid.set(Point{x = 1, y = 2}, "a");
+    grid.set(Point{x = 2, y = 1}, "b");
+    grid.set(Point{x = 1, y = 2}, "c");
+    print(grid.length());
+    print(grid[Point{x = 1, y = 2}]);
+    var raw | dict<bytes, int> = {b"ab": 1};
+    print(raw.get(b"ab"));
+    for (p, label in grid) {
+        print("${p.x},${p.y}=${label}");
+    }
 }
 `
-	if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
-		t.Fatalf("failed to write main.av: %v", err)
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
 	}
-
-	world, errs := modules.LoadWorld(mainFile)
+	mod, errs := ir.Compile(prog)
 	if len(errs) > 0 {
-		for _, e := range errs {
-			t.Logf("module loading error: %s", e)
-		}
-		t.Fatalf("failed to load world: %d errors", len(errs))
+		t.Fatalf("compile errors: %v", errs)
 	}
 
-	typeWorld := &types.World{
-		Modules: make(map[string]*types.ModuleInfo),
-		Entry:   world.Entry,
-	}
-	for modName, modAST := range world.Modules {
-		typeWorld.Modules[modName] = &types.ModuleInfo{
-			Name:  modName,
-			Prog:  modAST.Prog,
-			Scope: nil,
-		}
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
 	}
+	expectOutput(t, output, []string{"eleven", "true", "no", "2", "c", "some(1)", "1,2=c", "2,1=b"})
+}
 
-	bindings, typeErrs := types.CheckWorldWithBindings(typeWorld)
-	if len(typeErrs) > 0 {
-		for _, e := range typeErrs {
-			t.Logf("type error: %s", e)
-		}
-		t.Fatalf("type checking failed: %d errors", len(typeErrs))
-	}
+func TestCompile_DictMutableStructKey(t *testing.T) {
+	src := `
+pckg main;
 
-	entryModInfo := typeWorld.Modules[world.Entry]
-	mod, compileErrs := ir.CompileWorld(typeWorld, entryModInfo, bindings)
-	if len(compileErrs) > 0 {
-		for _, e := range compileErrs {
-			t.Logf("compile error: %s", e)
-		}
-		t.Fatalf("compilation failed: %d errors", len(compileErrs))
+mut struct Cell {
+    x | int
+}
+
+fun main() | void {
+    var c = Cell{x = 1};
+    var d | dict<Cell, string> = {};
+    d.set(c, "one");
+    c.x = 2;
+    d.set(c, "two");
+    print(d.length());
+    print(d.has(Cell{x = 1}));
+    print(d[Cell{x = 2}]);
+    for (k, v in d) {
+        k.x = 9;
+    }
+    print(d.keys());
+    var s = set<Cell>{};
+    s.add(c);
+    c.x = 3;
+    print(s.has(Cell{x = 2}));
+    print(s.has(c));
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
 	}
 
 	var output []string
-	env := runtime.NewEnv(&testOutputWriter{output: &output})
-	machine := vm.NewVM(mod, env)
-	_, runErr := machine.RunMain()
-	if runErr != nil {
-		t.Fatalf("RunMain error: %v", runErr)
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
 	}
+	// Keys are copied on the way in and out, so mutating the struct afterwards
+	// changes neither the stored keys nor their lookups.
+	expectOutput(t, output, []string{"2", "true", "two", "[{1}, {2}]", "true", "false"})
+}
 
-	if len(output) != 2 {
-		t.Fatalf("expected 2 outputs, got %d: %v", len(output), output)
-	}
+func TestCompile_DictUnhashableKey(t *testing.T) {
+	src := `
+pckg main;
 
-	expectedHTML := `<!DOCTYPE html><html><head><title>Test Page</title><meta charset="utf-8"></head><body><div class="container"><h1>Hello World</h1><p>This is a test</p></div><ul><li>Item 1</li><li>Item 2</li></ul><br><img alt="Logo" src="/logo.png"></body></html>`
-	if output[0] != expectedHTML {
-		t.Fatalf("HTML output mismatch.\nExpected: %s\nGot:      %s", expectedHTML, output[0])
+fun main() | void {
+    var key | any = 1.5;
+    var d | dict<any, int> = {};
+    d.set(key, 1);
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
 	}
-
-	expectedEscaped := "&lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;"
-	if output[1] != expectedEscaped {
-		t.Fatalf("escape output mismatch.\nExpected: %s\nGot:      %s", expectedEscaped, output[1])
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+	_, err := vm.NewVM(mod, runtime.DefaultEnv()).RunMain()
+	if err == nil || !strings.Contains(err.Error(), "a float cannot be a dict key") {
+		t.Fatalf("RunMain error = %v", err)
 	}
 }
 
-func TestCompileWorld_HTMLTemplateEngine(t *testing.T) {
-	tmpDir := t.TempDir()
+func TestCompile_Set(t *testing.T) {
+	src := `
+pckg main;
 
-	realStd, err := filepath.Abs("../../std")
-	if err != nil {
-		t.Fatalf("failed to resolve std path: %v", err)
+struct Point {
+    x | int
+    y | int
+}
+
+fun main() | void {
+    var s | set<int> = set{3, 1, 3, 2};
+    print(s);
+    print(s.length());
+    print(len(s));
+    print(s.add(4));
+    print(s.add(1));
+    print(s.has(2));
+    print(s.remove(3));
+    print(s.remove(3));
+    print(s);
+    var other = set<int>{2, 4, 6};
+    print(s.union(other));
+    print(s.intersection(other));
+    print(s.difference(other));
+    print(set{2}.isSubsetOf(other));
+    print(other.isSupersetOf(set{2, 5}));
+    print(set{1, 2} == set{2, 1});
+    for (x in other) {
+        print(x);
+        other.add(x * 10);
+    }
+    for (i, x in set{"a", "b"}) {
+        print("${i}:${x}");
+    }
+    var xs | list<int> = s.toList();
+    print(xs);
+    print([1, 1, 2].toSet());
+    var points = set{Point{x = 1, y = 2}, Point{x = 1, y = 2}};
+    print(points.length());
+    var names | set<string> = set{};
+    names.add("x");
+    print(names);
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
 	}
-	if _, err := os.Stat(realStd); os.IsNotExist(err) {
-		t.Skip("std/ directory not found, skipping HTML template test")
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
 	}
-	if err := os.Symlink(realStd, filepath.Join(tmpDir, "std")); err != nil {
-		t.Fatalf("failed to symlink std: %v", err)
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
 	}
+	expectOutput(t, output, []string{
+		"set{3, 1, 2}", "3", "3",
+		"true", "false", "true", "true", "false",
+		"set{1, 2, 4}",
+		"set{1, 2, 4, 6}", "set{2, 4}", "set{1}",
+		"true", "false", "true",
+		// The loop runs over the elements when it started.
+		"2", "4", "6",
+		"0:a", "1:b",
+		"[1, 2, 4]",
+		"set{1, 2}",
+		"1",
+		"set{x}",
+	})
+}
 
-	tplDir := filepath.Join(tmpDir, "templates")
-	if err := os.Mkdir(tplDir, 0755); err != nil {
-		t.Fatalf("failed to create templates dir: %v", err)
+func TestCompile_SetUnhashableElement(t *testing.T) {
+	src := `
+pckg main;
+
+fun main() | void {
+    var xs | list<any> = [1, [2]];
+    var s = xs.toSet();
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
 	}
+	_, err := vm.NewVM(mod, runtime.DefaultEnv()).RunMain()
+	if err == nil || !strings.Contains(err.Error(), "a list cannot be a set element") {
+		t.Fatalf("RunMain error = %v", err)
+	}
+}
 
-	baseTpl := `<!DOCTYPE html>
-<html>
-<head><title>{% block title %}Default{% endblock %}</title></head>
-<body>{% block content %}{% endblock %}</body>
-</html>`
-	if err := os.WriteFile(filepath.Join(tplDir, "base.html"), []byte(baseTpl), 0644); err != nil {
-		t.Fatalf("failed to write base.html: %v", err)
+func TestCompile_ListMethods(t *testing.T) {
+	src := `
+pckg main;
+
+struct Person {
+    name | string
+    age | int
+}
+
+fun main() | void {
+    var xs | list<int> = [3, 1, 2, 3, 5, 1];
+    print(xs.sort());
+    print(xs.sort(fun(a | int, b | int) | int { return b - a; }));
+    print(xs);
+    var people | list<Person> = [
+        Person{name = "bo", age = 30},
+        Person{name = "al", age = 25},
+        Person{name = "cy", age = 30}
+    ];
+    for (p in people.sortBy(fun(p | Person) | int { return p.age; })) {
+        print(p.name);
+    }
+    var found | int? = xs.find(fun(x | int) | bool { return x > 2; });
+    print(found);
+    print(xs.find(fun(x | int) | bool { return x > 9; }));
+    print(xs.findIndex(fun(x | int) | bool { return x == 5; }));
+    print(xs.findIndex(fun(x | int) | bool { return x == 9; }));
+    print(xs.any(fun(x | int) | bool { return x > 4; }));
+    print(xs.all(fun(x | int) | bool { return x > 1; }));
+    print(xs.count(fun(x | int) | bool { return x == 3; }));
+    print(xs.zip(["a", "b"]));
+    print([1, 2].flatMap(fun(x | int) | list<int> { return [x, x * 10]; }));
+    var byParity | dict<bool, list<int>> = xs.groupBy(fun(x | int) | bool { return x % 2 == 0; });
+    print(byParity);
+    print(xs.chunk(4));
+    print(xs.unique());
+    print([1.5, 1.5, 2.0].unique());
+    print(["a", "b", "c"].join(", "));
+    var lo | int = xs.min();
+    print(lo);
+    print(["pear", "apple"].max());
+    var total | int = xs.sum();
+    print(total);
+    print([1, 2.5].sum());
+    print([].sum());
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
 	}
 
-	pageTpl := `{% extends "base.html" %}
-{% block title %}{{ title }}{% endblock %}
-{% block content %}<h1>{{ name }}</h1>{% if show %}<p>visible</p>{% endif %}{% endblock %}`
-	if err := os.WriteFile(filepath.Join(tplDir, "page.html"), []byte(pageTpl), 0644); err != nil {
-		t.Fatalf("failed to write page.html: %v", err)
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
 	}
+	expectOutput(t, output, []string{
+		"[1, 1, 2, 3, 3, 5]",
+		"[5, 3, 3, 2, 1, 1]",
+		"[3, 1, 2, 3, 5, 1]",
+		// sortBy is stable
+		"al", "bo", "cy",
+		"some(3)", "none",
+		"4", "-1",
+		"true", "false", "2",
+		"[[3, a], [1, b]]",
+		"[1, 10, 2, 20]",
+		"{false: [3, 1, 3, 5, 1], true: [2]}",
+		"[[3, 1, 2, 3], [5, 1]]",
+		"[3, 1, 2, 5]",
+		"[1.5, 2]",
+		"a, b, c",
+		"1", "pear",
+		"15", "3.5", "0",
+	})
+}
 
-	loopTpl := `<ul>{% for item in items %}<li>{{ item }}</li>{% endfor %}</ul>`
-	if err := os.WriteFile(filepath.Join(tplDir, "loop.html"), []byte(loopTpl), 0644); err != nil {
-		t.Fatalf("failed to write loop.html: %v", err)
+func TestCompile_ListMethodErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"min of empty list", `var xs | list<int> = []; print(xs.min());`, "list.min: empty list"},
+		{"sort mixed", `var xs | list<any> = [1, "a"]; print(xs.sort());`, "cannot compare string and int"},
+		{"chunk size", `print([1].chunk(0));`, "list.chunk: size must be positive, got 0"},
+		{"range step", `print(range(0, 3, 0));`, "range: step must not be 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(lexer.New("pckg main;\n\nfun main() | void {\n    " + tt.body + "\n}\n"))
+			prog := p.ParseProgram()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("parser errors: %v", errs)
+			}
+			mod, errs := ir.Compile(prog)
+			if len(errs) > 0 {
+				t.Fatalf("compile errors: %v", errs)
+			}
+			_, err := vm.NewVM(mod, runtime.DefaultEnv()).RunMain()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("RunMain error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_RangeLoop(t *testing.T) {
+	src := `
+pckg main;
+
+fun main() | void {
+    for (i in range(0, 3)) {
+        print(i);
+    }
+    for (k, i in range(10, 0, -4)) {
+        print("${k}:${i}");
+    }
+    var step = -2;
+    for (i in range(5, 0, step)) {
+        print(i);
+    }
+    for (i in range(start = 2, end = 9, step = 3)) {
+        i = i * 100;
+        print(i);
+    }
+    for (i in range(0, 10)) {
+        if (i == 1) {
+            continue;
+        }
+        if (i == 3) {
+            break;
+        }
+        print(i);
+    }
+    for (i in range(3, 3)) {
+        print("never");
+    }
+    print(range(0, 10, 3));
+    var zero = 0;
+    try {
+        for (i in range(0, 3, zero)) {
+            print(i);
+        }
+    } catch (e | error) {
+        print(e);
+    }
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	expectOutput(t, output, []string{
+		"0", "1", "2",
+		"0:10", "1:6", "2:2",
+		"5", "3", "1",
+		"200", "500", "800",
+		"0", "2",
+		"[0, 3, 6, 9]",
+		"error(range: step must not be 0)",
+	})
+}
+
+func TestCompile_IntArithmetic(t *testing.T) {
+	src := `
+pckg main;
+
+fun main() | void {
+    var max = 9223372036854775807;
+    var zero = 0;
+    print(7 / 2);
+    print(-7 / 2);
+    print(-7 % 3);
+    print(7.0 / 2);
+    print(max - 1 < max);
+    try {
+        print(max + 1);
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(-max - 2);
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(max * 2);
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(-(-max - 1));
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(1 / zero);
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(1 % zero);
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(1.5 % 2);
+    } catch (e | error) {
+        print(e);
+    }
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	expectOutput(t, output, []string{
+		"3", "-3", "-1", "3.5", "true",
+		"error(integer overflow: 9223372036854775807 + 1)",
+		"error(integer overflow: -9223372036854775807 - 2)",
+		"error(integer overflow: 9223372036854775807 * 2)",
+		"error(integer overflow: -(-9223372036854775808))",
+		"error(division by zero)",
+		"error(modulo by zero)",
+		"error(operator % expects ints, got float and int)",
+	})
+}
+
+func TestCompile_RangeLoopIntBounds(t *testing.T) {
+	// The counter must stop at the last value instead of stepping past an
+	// end near the int bounds.
+	src := `
+pckg main;
+
+fun main() | void {
+    var max = 9223372036854775807;
+    var min = -max - 1;
+    var up = 5;
+    var down = -2;
+    for (i in range(9223372036854775800, max, 5)) {
+        print(i);
+    }
+    for (i in range(max - 7, max, up)) {
+        print(i);
+    }
+    for (i in range(-9223372036854775805, min, -2)) {
+        print(i);
+    }
+    for (i in range(min + 3, min, down)) {
+        print(i);
+    }
+    for (i in range(min, max, max)) {
+        print(i);
+    }
+    for (i in range(min, min + 2, 5)) {
+        print(i);
+    }
+    for (i in range(max, max - 2, -5)) {
+        print(i);
+    }
+    print(range(9223372036854775800, max, 5));
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	expectOutput(t, output, []string{
+		"9223372036854775800", "9223372036854775805",
+		"9223372036854775800", "9223372036854775805",
+		"-9223372036854775805", "-9223372036854775807",
+		"-9223372036854775805", "-9223372036854775807",
+		"-9223372036854775808", "-1", "9223372036854775806",
+		"-9223372036854775808",
+		"9223372036854775807",
+		"[9223372036854775800, 9223372036854775805]",
+	})
+}
+
+func TestCompile_BigIntDecimal(t *testing.T) {
+	src := `
+pckg main;
+
+fun main() | void {
+    var big | bigint = bigint("9223372036854775807") + 1;
+    print(big);
+    print(bigint(2).pow(100));
+    print(big * 2 > big);
+    print(-big);
+    print(bigint(-7) / 2);
+    print(bigint(-7) % 3);
+    var price | decimal = decimal("19.99");
+    print(price * 3);
+    print(price + 1);
+    print(decimal("60.00") / 7);
+    print(decimal(60, 2).div(7, 4, "up"));
+    print(decimal("2.345").setScale(2, "halfUp"));
+    print(decimal("2.345").setScale(2));
+    print(decimal("1.50") == decimal("1.5"));
+    print([decimal("2.5"), decimal("-1"), decimal("0.25")].sort());
+    print(decimal("-12.75").toBigint());
+    try {
+        print(big.toInt());
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(price / decimal("0"));
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(decimal("x"));
+    } catch (e | error) {
+        print(e);
+    }
+}
+`
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	expectOutput(t, output, []string{
+		"9223372036854775808",
+		"1267650600228229401496703205376",
+		"true",
+		"-9223372036854775808",
+		"-3", "-1",
+		"59.97", "20.99", "8.57", "8.5715",
+		"2.35", "2.34",
+		"true",
+		"[-1, 0.25, 2.5]",
+		"-12",
+		"error(bigint.toInt: 9223372036854775808 is out of int range)",
+		"error(division by zero)",
+		"error(decimal: invalid decimal \"x\")",
+	})
+}
+
+func TestCompileWorld_HTMLBuilder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	realStd, err := filepath.Abs("../../std")
+	if err != nil {
+		t.Fatalf("failed to resolve std path: %v", err)
+	}
+	if _, err := os.Stat(realStd); os.IsNotExist(err) {
+		t.Skip("std/ directory not found, skipping HTML builder test")
+	}
+	if err := os.Symlink(realStd, filepath.Join(tmpDir, "std")); err != nil {
+		t.Fatalf("failed to symlink std: %v", err)
 	}
 
 	mainFile := filepath.Join(tmpDir, "main.av")
@@ -6073,19 +6806,31 @@ func TestCompileWorld_HTMLTemplateEngine(t *testing.T) {
 import std.web.html as html;
 
 fun main() | void {
-    var engine | html.TemplateEngine = html.newEngine("templates/");
-
-    var result1 | string = engine.render("page.html", {
-        "title": "Test",
-        "name": "Alice",
-        "show": true
+    var result | string = html.build(fun(h | html.Builder) | void {
+        h.doctype();
+        h.html(fun() | void {
+            h.head(fun() | void {
+                h.title("Test Page");
+                h.meta({charset: "utf-8"});
+            });
+            h.body(fun() | void {
+                h.div({class: "container"}, fun() | void {
+                    h.h1("Hello World");
+                    h.p("This is a test");
+                });
+                h.ul(fun() | void {
+                    h.li("Item 1");
+                    h.li("Item 2");
+                });
+                h.br();
+                h.img({src: "/logo.png", alt: "Logo"});
+            });
+        });
     });
-    print(result1);
+    print(result);
 
-    var result2 | string = engine.render("loop.html", {
-        "items": ["a", "b", "c"]
-    });
-    print(result2);
+    var escaped | string = html.escape("<script>alert('xss')</script>");
+    print(escaped);
 }
 `
 	if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
@@ -6131,7 +6876,132 @@ fun main() | void {
 
 	var output []string
 	env := runtime.NewEnv(&testOutputWriter{output: &output})
-	env.SetExecRoot(tmpDir)
+	machine := vm.NewVM(mod, env)
+	_, runErr := machine.RunMain()
+	if runErr != nil {
+		t.Fatalf("RunMain error: %v", runErr)
+	}
+
+	if len(output) != 2 {
+		t.Fatalf("expected 2 outputs, got %d: %v", len(output), output)
+	}
+
+	expectedHTML := `<!DOCTYPE html><html><head><title>Test Page</title><meta charset="utf-8"></head><body><div class="container"><h1>Hello World</h1><p>This is a test</p></div><ul><li>Item 1</li><li>Item 2</li></ul><br><img alt="Logo" src="/logo.png"></body></html>`
+	if output[0] != expectedHTML {
+		t.Fatalf("HTML output mismatch.\nExpected: %s\nGot:      %s", expectedHTML, output[0])
+	}
+
+	expectedEscaped := "&lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;"
+	if output[1] != expectedEscaped {
+		t.Fatalf("escape output mismatch.\nExpected: %s\nGot:      %s", expectedEscaped, output[1])
+	}
+}
+
+func TestCompileWorld_HTMLTemplateEngine(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	realStd, err := filepath.Abs("../../std")
+	if err != nil {
+		t.Fatalf("failed to resolve std path: %v", err)
+	}
+	if _, err := os.Stat(realStd); os.IsNotExist(err) {
+		t.Skip("std/ directory not found, skipping HTML template test")
+	}
+	if err := os.Symlink(realStd, filepath.Join(tmpDir, "std")); err != nil {
+		t.Fatalf("failed to symlink std: %v", err)
+	}
+
+	tplDir := filepath.Join(tmpDir, "templates")
+	if err := os.Mkdir(tplDir, 0755); err != nil {
+		t.Fatalf("failed to create templates dir: %v", err)
+	}
+
+	baseTpl := `<!DOCTYPE html>
+<html>
+<head><title>{% block title %}Default{% endblock %}</title></head>
+<body>{% block content %}{% endblock %}</body>
+</html>`
+	if err := os.WriteFile(filepath.Join(tplDir, "base.html"), []byte(baseTpl), 0644); err != nil {
+		t.Fatalf("failed to write base.html: %v", err)
+	}
+
+	pageTpl := `{% extends "base.html" %}
+{% block title %}{{ title }}{% endblock %}
+{% block content %}<h1>{{ name }}</h1>{% if show %}<p>visible</p>{% endif %}{% endblock %}`
+	if err := os.WriteFile(filepath.Join(tplDir, "page.html"), []byte(pageTpl), 0644); err != nil {
+		t.Fatalf("failed to write page.html: %v", err)
+	}
+
+	loopTpl := `<ul>{% for item in items %}<li>{{ item }}</li>{% endfor %}</ul>`
+	if err := os.WriteFile(filepath.Join(tplDir, "loop.html"), []byte(loopTpl), 0644); err != nil {
+		t.Fatalf("failed to write loop.html: %v", err)
+	}
+
+	mainFile := filepath.Join(tmpDir, "main.av")
+	mainContent := `pckg main;
+
+import std.web.html as html;
+
+fun main() | void {
+    var engine | html.TemplateEngine = html.newEngine("templates/");
+
+    var result1 | string = engine.render("page.html", {
+        "title": "Test",
+        "name": "Alice",
+        "show": true
+    });
+    print(result1);
+
+    var result2 | string = engine.render("loop.html", {
+        "items": ["a", "b", "c"]
+    });
+    print(result2);
+}
+`
+	if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
+		t.Fatalf("failed to write main.av: %v", err)
+	}
+
+	world, errs := modules.LoadWorld(mainFile)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			t.Logf("module loading error: %s", e)
+		}
+		t.Fatalf("failed to load world: %d errors", len(errs))
+	}
+
+	typeWorld := &types.World{
+		Modules: make(map[string]*types.ModuleInfo),
+		Entry:   world.Entry,
+	}
+	for modName, modAST := range world.Modules {
+		typeWorld.Modules[modName] = &types.ModuleInfo{
+			Name:  modName,
+			Prog:  modAST.Prog,
+			Scope: nil,
+		}
+	}
+
+	bindings, typeErrs := types.CheckWorldWithBindings(typeWorld)
+	if len(typeErrs) > 0 {
+		for _, e := range typeErrs {
+			t.Logf("type error: %s", e)
+		}
+		t.Fatalf("type checking failed: %d errors", len(typeErrs))
+	}
+
+	entryModInfo := typeWorld.Modules[world.Entry]
+	mod, compileErrs := ir.CompileWorld(typeWorld, entryModInfo, bindings)
+	if len(compileErrs) > 0 {
+		for _, e := range compileErrs {
+			t.Logf("compile error: %s", e)
+		}
+		t.Fatalf("compilation failed: %d errors", len(compileErrs))
+	}
+
+	var output []string
+	env := runtime.NewEnv(&testOutputWriter{output: &output})
+	env.SetExecRoot(tmpDir)
 	machine := vm.NewVM(mod, env)
 	_, runErr := machine.RunMain()
 	if runErr != nil {
@@ -6916,3 +7786,1115 @@ fun main() | int {
 	t.Logf("Lumina compiled: %d functions, %d globals, main=%d, init=%d",
 		len(mod.Functions), len(mod.Globals), mod.MainIndex, mod.InitIndex)
 }
+
+// runWorldWithStd compiles mainContent as main.av next to a symlink to the real
+// std/ directory, runs it and returns the printed lines.
+func runWorldWithStd(t *testing.T, mainContent string) []string {
+	t.Helper()
+	output, err := runWorldWithStdErr(t, mainContent)
+	if err != nil {
+		t.Fatalf("runtime error: %v", err)
+	}
+	return output
+}
+
+// runWorldWithStdErr is runWorldWithStd for programs expected to fail at
+// runtime; it returns the output printed so far along with the error.
+func runWorldWithStdErr(t *testing.T, mainContent string) ([]string, error) {
+	t.Helper()
+	return runWorldWithStdEnv(t, mainContent, nil)
+}
+
+// runWorldWithStdEnv is runWorldWithStdErr with a hook to configure the
+// runtime Env, e.g. its permissions, before the program starts.
+func runWorldWithStdEnv(t *testing.T, mainContent string, setup func(env *runtime.Env)) ([]string, error) {
+	t.Helper()
+	mod := compileWorldWithStd(t, mainContent)
+	var output []string
+	env := runtime.NewEnv(&testOutputWriter{output: &output})
+	if setup != nil {
+		setup(env)
+	}
+	machine := vm.NewVM(mod, env)
+	_, err := machine.RunMain()
+	return output, err
+}
+
+// runWorldWithLimits runs a program under the given VM resource limits.
+func runWorldWithLimits(t *testing.T, mainContent string, limits vm.Limits) ([]string, error) {
+	t.Helper()
+	mod := compileWorldWithStd(t, mainContent)
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	machine.SetLimits(limits)
+	_, err := machine.RunMain()
+	return output, err
+}
+
+// compileWorldWithStd compiles mainContent as main.av next to the real std
+// modules.
+func compileWorldWithStd(t *testing.T, mainContent string) *ir.Module {
+	t.Helper()
+	return compileWorldWithRegistry(t, mainContent, nil)
+}
+
+// compileWorldWithRegistry is compileWorldWithStd with a builtin registry
+// other than the default one.
+func compileWorldWithRegistry(t *testing.T, mainContent string, registry *builtins.Registry) *ir.Module {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	realStd, err := filepath.Abs("../../std")
+	if err != nil {
+		t.Fatalf("failed to resolve std path: %v", err)
+	}
+	if _, err := os.Stat(realStd); os.IsNotExist(err) {
+		t.Skip("std/ directory not found")
+	}
+	if err := os.Symlink(realStd, filepath.Join(tmpDir, "std")); err != nil {
+		t.Fatalf("failed to symlink std: %v", err)
+	}
+
+	mainFile := filepath.Join(tmpDir, "main.av")
+	if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
+		t.Fatalf("failed to write main.av: %v", err)
+	}
+
+	world, errs := modules.LoadWorld(mainFile)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			t.Logf("module loading error: %s", e)
+		}
+		t.Fatalf("failed to load world: %d errors", len(errs))
+	}
+
+	typeWorld := &types.World{
+		Modules:  make(map[string]*types.ModuleInfo),
+		Entry:    world.Entry,
+		Builtins: registry,
+	}
+	for modName, modAST := range world.Modules {
+		typeWorld.Modules[modName] = &types.ModuleInfo{
+			Name: modName,
+			Prog: modAST.Prog,
+			File: modAST.FilePath,
+		}
+	}
+
+	bindings, typeErrs := types.CheckWorldWithBindings(typeWorld)
+	if len(typeErrs) > 0 {
+		for _, e := range typeErrs {
+			t.Logf("type error: %s", e)
+		}
+		t.Fatalf("type checking failed: %d errors", len(typeErrs))
+	}
+
+	mod, compileErrs := ir.CompileWorld(typeWorld, typeWorld.Modules[world.Entry], bindings)
+	if len(compileErrs) > 0 {
+		for _, e := range compileErrs {
+			t.Logf("compile error: %s", e)
+		}
+		t.Fatalf("compilation failed: %d errors", len(compileErrs))
+	}
+	if err := ir.Verify(mod); err != nil {
+		t.Fatalf("compiled module does not verify: %v", err)
+	}
+	return mod
+}
+
+func expectOutput(t *testing.T, output []string, expected []string) {
+	t.Helper()
+	if len(output) != len(expected) {
+		t.Fatalf("expected %d output lines, got %d: %v", len(expected), len(output), output)
+	}
+	for i, exp := range expected {
+		if output[i] != exp {
+			t.Fatalf("output[%d] = %q, expected %q", i, output[i], exp)
+		}
+	}
+}
+
+func TestCompile_AsyncFuncLiteral_CapturesUpvalue(t *testing.T) {
+	src := `
+pckg main;
+
+async fun main() | int {
+    var base | int = 10;
+    var add | fun(int) | Future<int> = async fun(x | int) | int {
+        return x + base;
+    };
+    var f | Future<int> = add(5);
+    base = 100;
+    return await f;
+}
+`
+	l := lexer.New(src)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected compile errors: %v", errs)
+	}
+
+	machine := vm.NewVM(mod, runtime.DefaultEnv())
+	val, err := machine.RunMain()
+	if err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	// The task runs after main suspends, so it sees the updated capture.
+	if val.Kind != value.KindInt || val.Int() != 105 {
+		t.Fatalf("expected 105, got %s", val.String())
+	}
+}
+
+func TestCompile_SpawnSyncCalls(t *testing.T) {
+	src := `
+pckg main;
+
+fun square(x | int) | int {
+    return x * x;
+}
+
+fun makeCounter() | fun() | int {
+    var n | int = 0;
+    return fun() | int {
+        n = n + 1;
+        return n;
+    };
+}
+
+async fun main() | void {
+    var a | Future<int> = spawn square(7);
+    var sq | fun(int) | int = square;
+    var b | Future<int> = spawn sq(8);
+    var lifted | fun(int) | Future<int> = square;
+    print(await a);
+    print(await b);
+    print(await lifted(9));
+
+    var next | fun() | int = makeCounter();
+    var c1 | Future<int> = spawn next();
+    var c2 | Future<int> = spawn next();
+    print(await c1 + await c2);
+    print(next());
+}
+`
+	l := lexer.New(src)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected compile errors: %v", errs)
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	expectOutput(t, output, []string{"49", "64", "81", "3", "3"})
+}
+
+func TestCompileWorld_StdMath(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.math;
+
+fun main() | void {
+    print(math.sqrt(2.25));
+    print(math.pow(2, 10));
+    print(math.roundEven(2.5));
+    print(math.isNaN(math.nan()));
+    print(math.isFinite(math.inf(1)));
+    print(math.roundToInt(-2.5));
+    print(math.floorToInt(-2.5));
+    print(math.parseFloat("1.5e3"));
+    print(math.checkedAdd(math.maxIntValue(), 1));
+    print(math.checkedMul(6, 7));
+    print(math.wrappingAdd(math.maxIntValue(), 1) == math.minIntValue());
+    print(math.saturatingSub(math.minIntValue(), 1) == math.minIntValue());
+    print(math.clampInt(12, 0, 10));
+    try {
+        print(math.truncToInt(1e19));
+    } catch (e | error) {
+        print(e);
+    }
+}
+`)
+	expectOutput(t, output, []string{
+		"1.5", "1024", "2", "true", "false", "-3", "-3", "1500",
+		"none", "some(42)", "true", "true", "10",
+		"error(math: 1e+19 is out of int range)",
+	})
+}
+
+func TestCompileWorld_StdRegex(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.regex;
+import std.coolweb as cw;
+
+fun handler(ctx | cw.Context) | cw.Response {
+    return ctx.text("ok");
+}
+
+fun main() | void {
+    var re = regex.compile("(?P<user>[a-z.]+)@(?P<host>[a-z]+\\.[a-z]+)");
+    print(re.matches("mail bob@example.com now"));
+    var m = re.find("mail bob@example.com now");
+    print(m != none);
+    print(re.find("nothing here") == none);
+    for (x in re.findAll("a@b.co, c.d@e.org")) {
+        print("${x.start}-${x.end} ${x.groups} ${x.named}");
+    }
+    print(re.replace("bob@example.com", "$host:$1"));
+    print(re.replaceFunc("bob@example.com, al@x.io", fun(m | regex.Match) | string {
+        return m.named["user"].toUpperCase();
+    }));
+    print(regex.compile("\\s*,\\s*").split("a , b,c ,d", 3));
+    print(regex.escape("1.5+2"));
+    try {
+        regex.compile("(");
+    } catch (e | error) {
+        print(e);
+    }
+
+    var rt = cw.compileRoute("GET", "/users/{id:[0-9]+}/posts/{slug}", handler);
+    print(rt.segments);
+    print(cw.matchRoute(rt, "GET", ["users", "42", "posts", "hi"]));
+    print(cw.matchRoute(rt, "GET", ["users", "bob", "posts", "hi"]));
+    try {
+        cw.compileRoute("GET", "/a/{bad name}", handler);
+    } catch (e | error) {
+        print(e);
+    }
+}
+`)
+	expectOutput(t, output, []string{
+		"true", "true", "true",
+		"0-6 [a@b.co, a, b.co] {user: a, host: b.co}",
+		"8-17 [c.d@e.org, c.d, e.org] {user: c.d, host: e.org}",
+		"example.com:bob",
+		"BOB, AL",
+		"[a, b, c ,d]",
+		"1\\.5\\+2",
+		"error(regex: invalid pattern \"(\": missing closing ))",
+		"[users, :id, posts, :slug]",
+		"{matched: true, params: {id: 42, slug: hi}}",
+		"{matched: false}",
+		"error(coolweb: invalid route parameter {bad name} in /a/{bad name})",
+	})
+}
+
+func TestCompileWorld_StdStrings(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.strings;
+
+fun fill(sb | strings.StringBuilder, n | int) | void {
+    for (i in range(0, n)) {
+        sb.write("${i}");
+    }
+}
+
+fun main() | void {
+    print(strings.format("[{:>8.2f}] {:05d} {:,}", [3.14159, 42, 1234567]));
+    print(strings.format("{:<6}|{:^7}|{1}", ["ab", "mid"]));
+    var sb = strings.newBuilder();
+    fill(sb, 5);
+    sb.writeFormat("|{:x}", [255]);
+    sb.writeLine("!");
+    print(sb.toString());
+    print(sb.length());
+    sb.reset();
+    print(sb.length());
+    print("7".padLeft(3, "0") + "|" + "ab".padRight(4) + "|" + "-".repeat(3));
+    var word = "héllo";
+    print("${word.length()} ${word.runeCount()} ${word.chars()} ${word.runes()}");
+    print("Straße".foldCase());
+    print("Go".equalFold("GO"));
+    print("é".normalize() == "é");
+    print(toFloat("2.5") + toFloat(1));
+    print(parseInt("ff", 16) + parseInt("-0b11", 0));
+    try {
+        print(strings.format("{:d}", ["x"]));
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print("x".repeat(-1));
+    } catch (e | error) {
+        print(e);
+    }
+    try {
+        print(parseInt("z", 10));
+    } catch (e | error) {
+        print(e);
+    }
+}
+`)
+	expectOutput(t, output, []string{
+		"[    3.14] 00042 1,234,567",
+		"ab    |  mid  |mid",
+		"01234|ff!\n",
+		"10",
+		"0",
+		"007|ab  |---",
+		"6 5 [h, é, l, l, o] [104, 233, 108, 108, 111]",
+		"strasse",
+		"true",
+		"true",
+		"3.5",
+		"252",
+		"error(strings.format: {:d}: 'd' needs an int, got string)",
+		"error(string.repeat: negative count -1)",
+		"error(parseInt: invalid base 10 integer \"z\")",
+	})
+}
+
+func TestCompileWorld_TaskMap(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.task;
+import std.time;
+
+var active | int = 0;
+var peak | int = 0;
+
+async fun slowDouble(x | int) | int {
+    active = active + 1;
+    if (active > peak) {
+        peak = active;
+    }
+    await time.asyncSleep(time.fromMillis(1));
+    active = active - 1;
+    return x * 2;
+}
+
+fun inc(x | int) | int {
+    return x + 1;
+}
+
+async fun main() | void {
+    print(await task.map([1, 2, 3, 4, 5], slowDouble, 2));
+    print(peak);
+    print(await task.map([1, 2, 3], inc, 0));
+    var fs | list<Future<int>> = [slowDouble(10), slowDouble(20)];
+    print(await task.all(fs));
+    var sum | int = 0;
+    await task.forEach([1, 2, 3], async fun(x | int) | void {
+        sum = sum + x;
+    }, 1);
+    print(sum);
+}
+`)
+	expectOutput(t, output, []string{"[2, 4, 6, 8, 10]", "2", "[2, 3, 4]", "[20, 40]", "6"})
+}
+
+func TestCompileWorld_TimeEveryTickerCron(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.time;
+
+async fun main() | void {
+    var n | int = 0;
+    var iv | time.Timer = time.every(time.fromMillis(5), fun() | void {
+        n = n + 1;
+    });
+    await time.after(time.fromMillis(28));
+    iv.stop();
+    print(n >= 3 && n <= 6);
+    print(iv.stopped());
+
+    var tk | time.Ticker = time.newTicker(time.fromMillis(2));
+    var ticks | int = 0;
+    while (await tk.tick()) {
+        ticks = ticks + 1;
+        if (ticks == 3) {
+            tk.stop();
+        }
+    }
+    print(ticks);
+
+    var base | time.DateTime = time.parseISO8601("2024-01-01T10:03:00Z");
+    print(time.formatISO8601(time.cronNext("*/5 * * * *", base)));
+
+    try {
+        time.cron("61 * * * *", fun() | void {});
+    } catch (e | error) {
+        print("invalid cron");
+    }
+    var job | time.Timer = time.cronWithJitter("* * * * *", time.fromSeconds(1), fun() | void {
+        print("never");
+    });
+    job.stop();
+}
+`)
+	expectOutput(t, output, []string{"true", "true", "3", "2024-01-01T10:05:00Z", "invalid cron"})
+}
+
+func TestCompileWorld_TimeEveryStopsOnError(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.time;
+
+async fun main() | void {
+    var n | int = 0;
+    var iv | time.Timer = time.every(time.fromMillis(2), fun() | void {
+        n = n + 1;
+        if (n == 2) {
+            throw error("job failed");
+        }
+    });
+    print(iv.err() == none);
+    await time.after(time.fromMillis(40));
+    print(n);
+    print(iv.stopped());
+    print(iv.err());
+
+    var job | time.Timer = time.cron("* * * * *", fun() | void {});
+    job.stop();
+    print(job.err() == none);
+}
+`)
+	expectOutput(t, output, []string{"true", "2", "true", "some(error(job failed))", "true"})
+}
+
+func TestCompileWorld_OSExitRunsDefers(t *testing.T) {
+	output, err := runWorldWithStdErr(t, `pckg main;
+
+import std.os;
+
+fun note(msg | string) | void {
+    print(msg);
+}
+
+fun inner() | void {
+    defer note("inner defer");
+    try {
+        os.exit(3);
+    } catch (e | error) {
+        print("caught");
+    }
+    print("after exit");
+}
+
+fun main() | void {
+    defer note("main defer");
+    os.setenv("AVENIR_OS_TEST", "on");
+    print(os.getenv("AVENIR_OS_TEST"));
+    os.unsetenv("AVENIR_OS_TEST");
+    print(os.getenv("AVENIR_OS_TEST", "off"));
+    print(len(os.args()));
+    inner();
+}
+`)
+	var exitErr *builtins.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	expectOutput(t, output, []string{"on", "off", "0", "inner defer", "main defer"})
+}
+
+func TestCompileWorld_OSExitFromTask(t *testing.T) {
+	output, err := runWorldWithStdErr(t, `pckg main;
+
+import std.os;
+import std.time;
+
+async fun worker() | void {
+    await time.asyncSleep(time.fromMillis(1));
+    os.exit(4);
+}
+
+async fun main() | void {
+    var f | Future<void> = worker();
+    await time.asyncSleep(time.fromSeconds(5));
+    print("not reached");
+}
+`)
+	var exitErr *builtins.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 4 {
+		t.Fatalf("expected exit status 4, got %v", err)
+	}
+	expectOutput(t, output, []string{})
+}
+
+func TestCompileWorld_OSExitFromTaskRunsAllDefers(t *testing.T) {
+	// The calling task unwinds first, then the other unfinished tasks,
+	// newest first.
+	output, err := runWorldWithStdErr(t, `pckg main;
+
+import std.os;
+import std.time;
+
+fun note(msg | string) | void {
+    print(msg);
+}
+
+async fun sleeper() | void {
+    defer note("sleeper defer");
+    await time.asyncSleep(time.fromSeconds(5));
+    print("sleeper not reached");
+}
+
+async fun worker() | void {
+    defer note("worker defer");
+    await time.asyncSleep(time.fromMillis(20));
+    os.exit(5);
+}
+
+async fun main() | void {
+    defer note("main defer");
+    var s | Future<void> = sleeper();
+    var w | Future<void> = worker();
+    await time.asyncSleep(time.fromSeconds(5));
+    print("main not reached");
+}
+`)
+	var exitErr *builtins.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 5 {
+		t.Fatalf("expected exit status 5, got %v", err)
+	}
+	expectOutput(t, output, []string{"worker defer", "sleeper defer", "main defer"})
+}
+
+func TestCompileWorld_TimeZonesAndCalendar(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.time;
+
+fun main() | void {
+    var dt | time.DateTime = time.parseISO8601("2024-03-10T06:30:00Z");
+    var ny | time.DateTime = dt.inZone("America/New_York");
+    print(ny.format("YYYY-MM-DD HH:mm:ssZ"));
+    print(ny.addDays(1).format("YYYY-MM-DD HH:mm:ssZ"));
+    print(time.parseISO8601("2024-01-31T12:00:00Z").addMonths(1).format("YYYY-MM-DD"));
+    print(dt.weekday());
+    print(dt.isoWeek());
+    print(dt.startOfWeek().format("YYYY-MM-DD HH:mm"));
+    print(dt.toUnixMillis());
+    print(dt.equal(ny));
+
+    var d | time.Date = time.newDate(2024, 2, 28);
+    print(d.addDays(2).toString());
+    print(d.atTime(time.newTime(9, 5, 0), "Europe/Kyiv").format("YYYY-MM-DDTHH:mm:ssZ"));
+    try {
+        time.newDate(2023, 2, 29);
+    } catch (e | error) {
+        print(e);
+    }
+
+    var start | time.Instant = time.instant();
+    print(start.elapsed().nanos >= 0);
+}
+`)
+	expectOutput(t, output, []string{
+		"2024-03-10 01:30:00-05:00",
+		"2024-03-11 01:30:00-04:00",
+		"2024-02-29",
+		"7",
+		"10",
+		"2024-03-04 00:00",
+		"1710052200000",
+		"true",
+		"2024-03-01",
+		"2024-02-28T09:05:00+02:00",
+		"error(time: invalid date)",
+		"true",
+	})
+}
+
+func TestCompileWorld_Process(t *testing.T) {
+	output := runWorldWithStd(t, `pckg main;
+
+import std.process;
+
+async fun main() | void {
+    var r | process.Result = process.run("sh", ["-c", "echo $NAME; echo warn >&2; exit 2"], {"env": {"NAME": "avenir"}});
+    print(r.code);
+    print(r.stdout);
+    print(r.stderr);
+    print(r.ok());
+
+    var cat | process.Process = process.start("cat");
+    var upper | process.Process = process.pipe(cat, "tr", ["a-z", "A-Z"]);
+    await cat.asyncWriteString("streamed\n");
+    cat.closeStdin();
+    print(await upper.asyncReadString());
+    print((await upper.asyncWait()).ok());
+    print(cat.wait().code);
+
+    var slow | process.Result = await process.asyncRun("sleep", ["5"], {"timeoutMs": 20});
+    print(slow.timedOut);
+}
+`)
+	expectOutput(t, output, []string{"2", "avenir\n", "warn\n", "false", "STREAMED\n", "true", "0", "true"})
+}
+
+func TestCompileWorld_FSMetadataAndWalk(t *testing.T) {
+	root := t.TempDir()
+	src := strings.ReplaceAll(`pckg main;
+
+import std.fs;
+import std.os;
+
+fun main() | void {
+    os.chdir("ROOT");
+    fs.mkdirAll("tree/src/vendor");
+    fs.mkdirAll("tree/docs");
+    var f | fs.File = fs.open("tree/src/main.av", "w+");
+    f.writeString("hello world");
+    print(f.seek(-5, "end"));
+    print(f.readString());
+    f.truncate(5);
+    print(f.tell());
+    f.close();
+    fs.copy("tree/src/main.av", "tree/docs/copy.txt");
+    fs.rename("tree/docs/copy.txt", "tree/docs/readme.txt");
+    fs.chmod("tree/docs/readme.txt", 384);
+    fs.symlink("readme.txt", "tree/docs/link.txt");
+
+    var info | fs.FileInfo = fs.stat("tree/docs/link.txt");
+    print("${info.size} ${info.mode} ${info.isDir} ${info.isSymlink}");
+    print(fs.lstat("tree/docs/link.txt").isSymlink);
+    print(fs.readlink("tree/docs/link.txt"));
+    print(fs.glob("tree/docs/*.txt"));
+
+    var visited | list<string> = [];
+    fs.walk("tree", fun(path | string, entry | fs.FileInfo) | bool {
+        visited = visited.append(path);
+        return entry.name != "src";
+    });
+    print(visited);
+
+    fs.removeAll("tree");
+    print(fs.exists("tree"));
+}
+`, "ROOT", root)
+	output := runWorldWithStd(t, src)
+	expectOutput(t, output, []string{
+		"6",
+		"world",
+		"11",
+		"5 384 false false",
+		"true",
+		"readme.txt",
+		"[tree/docs/link.txt, tree/docs/readme.txt]",
+		"[tree, tree/docs, tree/docs/link.txt, tree/docs/readme.txt, tree/src]",
+		"false",
+	})
+}
+
+func TestCompileWorld_FSWatch(t *testing.T) {
+	root := t.TempDir()
+	src := strings.ReplaceAll(`pckg main;
+
+import std.fs;
+import std.time;
+
+async fun edit() | void {
+    await time.asyncSleep(time.fromMillis(30));
+    var f | fs.File = fs.open("ROOT/notes.txt", "w");
+    f.writeString("draft");
+    f.close();
+}
+
+async fun main() | void {
+    var w | fs.Watcher = fs.watch(["ROOT"], {"debounceMs": 20});
+    var job | Future<void> = edit();
+    var events | list<fs.WatchEvent> = await w.next();
+    await job;
+    for (ev in events) {
+        print("${ev.op} ${fs.basename(ev.path)}");
+    }
+    w.close();
+    print(len(await w.next()));
+}
+`, "ROOT", root)
+	output := runWorldWithStd(t, src)
+	expectOutput(t, output, []string{"create notes.txt", "0"})
+}
+
+func TestCompileWorld_TemplateDevModeReload(t *testing.T) {
+	root := t.TempDir()
+	page := filepath.Join(root, "page.html")
+	if err := os.WriteFile(page, []byte("v1 {{ name }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := strings.ReplaceAll(`pckg main;
+
+import std.fs;
+import std.time;
+import std.web.html;
+
+async fun main() | void {
+    var engine | html.TemplateEngine = html.newEngine("ROOT", {"devMode": true});
+    print(engine.render("page.html", {"name": "a"}));
+    var f | fs.File = fs.open("ROOT/page.html", "w");
+    f.writeString("v2 {{ name }}");
+    f.close();
+    await time.asyncSleep(time.fromMillis(300));
+    print(engine.render("page.html", {"name": "b"}));
+}
+`, "ROOT", root)
+	output := runWorldWithStd(t, src)
+	expectOutput(t, output, []string{"v1 a", "v2 b"})
+}
+
+func TestCompileWorld_PermissionDenied(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := strings.ReplaceAll(`pckg main;
+
+import std.fs;
+import std.os;
+
+fun main() | void {
+    os.chdir("ROOT");
+    var f | fs.File = fs.open("data/notes.txt", "w");
+    f.writeString("ok");
+    f.close();
+    try {
+        fs.open("secret.txt", "w");
+    } catch (e | os.PermissionDenied) {
+        print(e.operation);
+        print(e.target == "ROOT/secret.txt");
+    }
+    try {
+        os.getenv("HOME");
+    } catch (e | error) {
+        print(errorMessage(e));
+    }
+    try {
+        fs.stat("/");
+    } catch (e | fs.FileInfo) {
+        print("wrong clause");
+    } catch (e | os.PermissionDenied) {
+        print(e.message);
+    }
+    fs.remove("data/notes.txt");
+    fs.mkdir("other");
+}
+`, "ROOT", root)
+	output, err := runWorldWithStdEnv(t, src, func(env *runtime.Env) {
+		env.SetExecRoot(root)
+		if err := env.SetPermissions(&runtime.Permissions{Read: []string{"."}, Write: []string{"data"}}); err != nil {
+			t.Fatalf("set permissions: %v", err)
+		}
+	})
+	if err == nil || !strings.Contains(err.Error(), "permission denied: write") {
+		t.Fatalf("expected uncaught permission error, got %v", err)
+	}
+	expectOutput(t, output, []string{"write", "true", "permission denied: env HOME", "permission denied: read /"})
+}
+
+func TestCompileWorld_InstructionLimit(t *testing.T) {
+	src := `pckg main;
+
+fun main() | void {
+    print("start");
+    var i | int = 0;
+    while (true) {
+        i = i + 1;
+    }
+}
+`
+	output, err := runWorldWithLimits(t, src, vm.Limits{MaxInstructions: 10000})
+	if !errors.Is(err, vm.ErrInstructionLimit) {
+		t.Fatalf("expected instruction limit error, got %v", err)
+	}
+	expectOutput(t, output, []string{"start"})
+}
+
+func TestCompileWorld_CallDepthLimitIsCatchable(t *testing.T) {
+	src := `pckg main;
+
+fun depth(n | int) | int {
+    return depth(n + 1);
+}
+
+fun main() | void {
+    try {
+        depth(0);
+    } catch (e | error) {
+        print(errorMessage(e));
+    }
+    print("after");
+}
+`
+	output, err := runWorldWithLimits(t, src, vm.Limits{MaxCallDepth: 50})
+	if err != nil {
+		t.Fatalf("runtime error: %v", err)
+	}
+	expectOutput(t, output, []string{"call depth limit exceeded (max 50)", "after"})
+}
+
+func TestCompileWorld_MemoryLimit(t *testing.T) {
+	src := `pckg main;
+
+fun main() | void {
+    var s | string = "x";
+    while (true) {
+        try {
+            s = s + s;
+        } catch (e | error) {
+            print("caught");
+        }
+    }
+}
+`
+	_, err := runWorldWithLimits(t, src, vm.Limits{MaxMemory: 1 << 20})
+	var limitErr *vm.LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, vm.ErrMemoryLimit) {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+}
+
+func TestCompileWorld_ContextDeadline(t *testing.T) {
+	src := `pckg main;
+
+fun main() | void {
+    while (true) {
+    }
+}
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := runWorldWithLimits(t, src, vm.Limits{Context: ctx})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	asyncSrc := `pckg main;
+
+import std.time;
+
+async fun main() | void {
+    print("waiting");
+    await time.asyncSleep(time.fromSeconds(5));
+    print("done");
+}
+`
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	output, err := runWorldWithLimits(t, asyncSrc, vm.Limits{Context: ctx})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("event loop did not stop at the deadline, took %v", elapsed)
+	}
+	expectOutput(t, output, []string{"waiting"})
+}
+
+func TestCompileWorld_CustomBuiltinRegistry(t *testing.T) {
+	registry := builtins.Default().Clone()
+	registry.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AutoID,
+			Name:         "__builtin_test_double",
+			Arity:        1,
+			ParamNames:   []string{"n"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			return value.Int(args[0].(value.Value).Int() * 2), nil
+		},
+	})
+	src := `pckg main;
+
+fun main() | void {
+    print(__builtin_test_double(21));
+}
+`
+	mod := compileWorldWithRegistry(t, src, registry)
+	if builtins.LookupByName("__builtin_test_double") != nil {
+		t.Fatal("custom builtin leaked into the default registry")
+	}
+
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	machine.SetBuiltins(registry)
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("runtime error: %v", err)
+	}
+	expectOutput(t, output, []string{"42"})
+
+	// A VM without the registry fails to bind the builtin.
+	machine = vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err == nil || !strings.Contains(err.Error(), "__builtin_test_double") {
+		t.Fatalf("expected unknown builtin error, got %v", err)
+	}
+}
+
+func TestSerialize_RoundTrip(t *testing.T) {
+	src := `pckg main;
+
+var greeting | string = "hi";
+
+fun main() | void {
+    var p | dict<any> = {"x": 1, "y": 2.5};
+    var data | bytes = b"ab";
+    print("${greeting} ${p["x"]} ${p["y"]} ${len(data)} ${typeOf(greeting)}");
+}
+`
+	mod := compileWorldWithStd(t, src)
+	var buf bytes.Buffer
+	if err := ir.WriteModule(&buf, mod); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("AVC4")) {
+		t.Fatalf("unexpected header %q", buf.Bytes()[:4])
+	}
+	loaded, err := ir.ReadModule(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(loaded.Builtins) == 0 || loaded.InitIndex != mod.InitIndex {
+		t.Fatalf("builtins %v, init index %d", loaded.Builtins, loaded.InitIndex)
+	}
+	mainIdx, _ := loaded.FunctionIndex("main.main")
+	mainFn := loaded.Functions[mainIdx]
+	if !strings.HasSuffix(mainFn.File, "main.av") || len(mainFn.Chunk.Lines) != len(mainFn.Chunk.Code) || mainFn.Chunk.Lines[0] != 6 {
+		t.Fatalf("debug info not preserved: file %q, lines %v", mainFn.File, mainFn.Chunk.Lines)
+	}
+	origIdx, _ := mod.FunctionIndex("main.main")
+	if len(mainFn.Locals) != 2 || !reflect.DeepEqual(mainFn.Locals, mod.Functions[origIdx].Locals) {
+		t.Fatalf("local names not preserved: %+v", mainFn.Locals)
+	}
+
+	// Files without the debug section, or written before local names were
+	// recorded, still load.
+	var again bytes.Buffer
+	if err := ir.WriteModule(&again, mod); err != nil {
+		t.Fatal(err)
+	}
+	data := again.Bytes()
+	stripped, err := ir.ReadModule(bytes.NewReader(data[:bytes.LastIndex(data, []byte("DBG1"))]))
+	if err != nil {
+		t.Fatalf("read without debug info: %v", err)
+	}
+	if fn := stripped.Functions[mainIdx]; fn.File != "" || fn.Chunk.Lines != nil {
+		t.Fatalf("unexpected debug info: %q %v", fn.File, fn.Chunk.Lines)
+	}
+	linesOnly, err := ir.ReadModule(bytes.NewReader(data[:bytes.LastIndex(data, []byte("VAR1"))]))
+	if err != nil {
+		t.Fatalf("read without local names: %v", err)
+	}
+	if fn := linesOnly.Functions[mainIdx]; fn.Chunk.Lines == nil || fn.Locals != nil {
+		t.Fatalf("unexpected debug info: %v %+v", fn.Chunk.Lines, fn.Locals)
+	}
+
+	var output []string
+	machine := vm.NewVM(loaded, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("runtime error: %v", err)
+	}
+	expectOutput(t, output, []string{"hi 1 2.5 2 string"})
+}
+
+func TestCompile_LocalDebugInfo(t *testing.T) {
+	src := `pckg main;
+
+fun main() | void {
+    var x | int = 1;
+    if (x > 0) {
+        var x | string = "inner";
+        print(x);
+    }
+    for (item in [1, 2]) {
+        print(item);
+    }
+    var f | fun() | int = fun() | int {
+        return x;
+    };
+    print(f());
+}
+`
+	mod := compileWorldWithStd(t, src)
+	mainIdx, _ := mod.FunctionIndex("main.main")
+	mainFn := mod.Functions[mainIdx]
+	var names []string
+	for _, l := range mainFn.Locals {
+		names = append(names, l.Name)
+		if l.Start > l.End || l.End > len(mainFn.Chunk.Code) {
+			t.Errorf("local %q: invalid range [%d, %d)", l.Name, l.Start, l.End)
+		}
+	}
+	// Compiler temporaries such as the for-in list and index are left out.
+	if !reflect.DeepEqual(names, []string{"x", "x", "item", "f"}) {
+		t.Fatalf("locals = %v", names)
+	}
+	outer, inner := mainFn.Locals[0], mainFn.Locals[1]
+	if outer.Slot == inner.Slot || inner.Start < outer.Start || inner.End >= outer.End {
+		t.Fatalf("inner x %+v should be scoped within outer x %+v", inner, outer)
+	}
+
+	var lambda *ir.Function
+	for _, fn := range mod.Functions {
+		if strings.HasPrefix(fn.Name, "main.<lambda_") {
+			lambda = fn
+		}
+	}
+	if lambda == nil || len(lambda.Upvalues) != 1 || lambda.Upvalues[0].Name != "x" {
+		t.Fatalf("lambda upvalues = %+v", lambda)
+	}
+}
+
+func TestSerialize_ReadsLegacyModule(t *testing.T) {
+	// An AVC2 module with main.main calling print("old"), as written by
+	// earlier releases: builtin operands are numeric IDs.
+	var buf bytes.Buffer
+	w := func(v any) { binary.Write(&buf, binary.LittleEndian, v) }
+	buf.WriteString("AVC2")
+	w(uint32(1))
+	w(uint16(len("main.main")))
+	buf.WriteString("main.main")
+	w(uint32(0)) // params
+	w(uint32(0)) // locals
+	w(uint32(1)) // consts
+	w(uint8(ir.ConstString))
+	w(uint32(3))
+	buf.WriteString("old")
+	code := []ir.Instruction{
+		{Op: ir.OpConst, A: 0},
+		{Op: ir.OpCallBuiltin, A: int(builtins.Print), B: 1},
+		{Op: ir.OpReturn},
+	}
+	w(uint32(len(code)))
+	for _, inst := range code {
+		w(uint8(inst.Op))
+		w(int32(inst.A))
+		w(int32(inst.B))
+	}
+	w(uint32(0)) // structs
+	w(int32(0))  // main
+
+	mod, err := ir.ReadModule(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(mod.Builtins) != 1 || mod.Builtins[0] != "print" {
+		t.Fatalf("builtins = %v, want [print]", mod.Builtins)
+	}
+	var output []string
+	machine := vm.NewVM(mod, runtime.NewEnv(&testOutputWriter{output: &output}))
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatalf("runtime error: %v", err)
+	}
+	expectOutput(t, output, []string{"old"})
+}
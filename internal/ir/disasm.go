@@ -0,0 +1,335 @@
+package ir
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Listing is a disassembled module. Disassemble builds it, WriteText prints
+// it for people, and it marshals to JSON for tools.
+type Listing struct {
+	Main      string           `json:"main,omitempty"`
+	Init      string           `json:"init,omitempty"`
+	Structs   []ListedStruct   `json:"structs"`
+	Globals   []string         `json:"globals"`
+	Builtins  []string         `json:"builtins"`
+	Functions []ListedFunction `json:"functions"`
+}
+
+// ListedStruct is a struct type of a Listing.
+type ListedStruct struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// ListedFunction is a function of a Listing.
+type ListedFunction struct {
+	Index     int                 `json:"index"`
+	Name      string              `json:"name"`
+	File      string              `json:"file,omitempty"`
+	Params    int                 `json:"params"`
+	Locals    int                 `json:"locals"`
+	Async     bool                `json:"async"`
+	Upvalues  []ListedUpvalue     `json:"upvalues"`
+	Constants []ListedConstant    `json:"constants"`
+	Code      []ListedInstruction `json:"code"`
+}
+
+// ListedUpvalue describes what a closure of the function captures: a local
+// of the enclosing function, or one of its upvalues.
+type ListedUpvalue struct {
+	Local bool `json:"local"`
+	Index int  `json:"index"`
+}
+
+// ListedConstant is an entry of a function's constant table.
+type ListedConstant struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"` // Avenir literal syntax
+}
+
+// ListedInstruction is one instruction with its operands resolved.
+type ListedInstruction struct {
+	IP      int    `json:"ip"`
+	Label   string `json:"label,omitempty"` // set when a jump or handler targets the instruction
+	Op      string `json:"op"`
+	A       int    `json:"a"`
+	B       int    `json:"b"`
+	Operand string `json:"operand,omitempty"` // symbolic form of A and B
+	Line    int    `json:"line,omitempty"`
+}
+
+// Disassemble lists the functions of mod with symbolic operands. Operands
+// out of range are listed as such, so unverified modules can be inspected.
+func Disassemble(mod *Module) *Listing {
+	l := &Listing{
+		Structs:   make([]ListedStruct, 0, len(mod.StructTypes)),
+		Globals:   make([]string, 0, len(mod.Globals)),
+		Builtins:  append([]string{}, mod.Builtins...),
+		Functions: make([]ListedFunction, 0, len(mod.Functions)),
+	}
+	if mod.MainIndex >= 0 {
+		l.Main = functionName(mod, mod.MainIndex)
+	}
+	if mod.InitIndex >= 0 {
+		l.Init = functionName(mod, mod.InitIndex)
+	}
+	for _, st := range mod.StructTypes {
+		ls := ListedStruct{Name: st.Name, Fields: []string{}}
+		for _, f := range st.Fields {
+			ls.Fields = append(ls.Fields, f.Name)
+		}
+		l.Structs = append(l.Structs, ls)
+	}
+	for _, g := range mod.Globals {
+		l.Globals = append(l.Globals, g.Name)
+	}
+	for i, fn := range mod.Functions {
+		if fn != nil {
+			l.Functions = append(l.Functions, listFunction(mod, i, fn))
+		}
+	}
+	return l
+}
+
+func listFunction(mod *Module, index int, fn *Function) ListedFunction {
+	lf := ListedFunction{
+		Index:     index,
+		Name:      fn.Name,
+		File:      fn.File,
+		Params:    fn.NumParams,
+		Locals:    fn.Chunk.NumLocals,
+		Async:     fn.IsAsync,
+		Upvalues:  make([]ListedUpvalue, 0, len(fn.Upvalues)),
+		Constants: make([]ListedConstant, 0, len(fn.Chunk.Consts)),
+		Code:      make([]ListedInstruction, 0, len(fn.Chunk.Code)),
+	}
+	for _, uv := range fn.Upvalues {
+		lf.Upvalues = append(lf.Upvalues, ListedUpvalue{Local: uv.IsLocal, Index: uv.Index})
+	}
+	for _, c := range fn.Chunk.Consts {
+		lf.Constants = append(lf.Constants, ListedConstant{Kind: c.Kind.String(), Value: c.literal()})
+	}
+
+	// Number labels in code order.
+	var targets []int
+	seen := make(map[int]bool)
+	for _, inst := range fn.Chunk.Code {
+		if isJump(inst.Op) && !seen[inst.A] {
+			seen[inst.A] = true
+			targets = append(targets, inst.A)
+		}
+	}
+	sort.Ints(targets)
+	labels := make(map[int]string, len(targets))
+	for i, ip := range targets {
+		labels[ip] = "L" + strconv.Itoa(i)
+	}
+
+	for ip, inst := range fn.Chunk.Code {
+		li := ListedInstruction{
+			IP:      ip,
+			Label:   labels[ip],
+			Op:      inst.Op.String(),
+			A:       inst.A,
+			B:       inst.B,
+			Operand: operand(mod, fn, inst, labels),
+		}
+		if ip < len(fn.Chunk.Lines) {
+			li.Line = fn.Chunk.Lines[ip]
+		}
+		lf.Code = append(lf.Code, li)
+	}
+	return lf
+}
+
+func isJump(op OpCode) bool {
+	return op == OpJump || op == OpJumpIfFalse || op == OpJumpIfNone || op == OpBeginTry || op == OpCmpJumpIfFalse
+}
+
+// operand describes the operands of inst in terms of the module.
+func operand(mod *Module, fn *Function, inst Instruction, labels map[int]string) string {
+	plural := func(n int, word string) string {
+		if n == 1 {
+			return "1 " + word
+		}
+		return fmt.Sprintf("%d %ss", n, word)
+	}
+	switch inst.Op {
+	case OpConst:
+		if inst.A >= 0 && inst.A < len(fn.Chunk.Consts) {
+			return fn.Chunk.Consts[inst.A].literal()
+		}
+		return fmt.Sprintf("<constant %d>", inst.A)
+	case OpLoadLocal, OpStoreLocal:
+		return "local " + strconv.Itoa(inst.A)
+	case OpAddLocalConst:
+		if inst.B >= 0 && inst.B < len(fn.Chunk.Consts) {
+			return "local " + strconv.Itoa(inst.A) + ", " + fn.Chunk.Consts[inst.B].literal()
+		}
+		return fmt.Sprintf("local %d, <constant %d>", inst.A, inst.B)
+	case OpLoadUpvalue, OpStoreUpvalue:
+		return "upvalue " + strconv.Itoa(inst.A)
+	case OpLoadGlobal, OpStoreGlobal:
+		if inst.A >= 0 && inst.A < len(mod.Globals) {
+			return mod.Globals[inst.A].Name
+		}
+		return fmt.Sprintf("<global %d>", inst.A)
+	case OpJump, OpJumpIfFalse, OpJumpIfNone, OpBeginTry:
+		if label, ok := labels[inst.A]; ok && inst.A >= 0 && inst.A < len(fn.Chunk.Code) {
+			return label
+		}
+		return fmt.Sprintf("<ip %d>", inst.A)
+	case OpCmpJumpIfFalse:
+		target := fmt.Sprintf("<ip %d>", inst.A)
+		if label, ok := labels[inst.A]; ok && inst.A >= 0 && inst.A < len(fn.Chunk.Code) {
+			target = label
+		}
+		return OpCode(inst.B).String() + ", " + target
+	case OpCall, OpSpawn:
+		return functionName(mod, inst.A) + ", " + plural(inst.B, "arg")
+	case OpClosure:
+		return functionName(mod, inst.A) + ", " + plural(inst.B, "upvalue")
+	case OpSetFunc:
+		return functionName(mod, inst.A)
+	case OpCallValue:
+		if inst.B == 1 {
+			return plural(inst.A, "arg") + ", spawn"
+		}
+		return plural(inst.A, "arg")
+	case OpCallBuiltin, OpCallBuiltinAsync:
+		name := fmt.Sprintf("<builtin %d>", inst.A)
+		if inst.A >= 0 && inst.A < len(mod.Builtins) {
+			name = mod.Builtins[inst.A]
+		}
+		return name + ", " + plural(inst.B, "arg")
+	case OpCallMethod:
+		name := fmt.Sprintf("<method call %d>", inst.A)
+		if inst.A >= 0 && inst.A < len(mod.MethodCalls) {
+			name = mod.MethodCalls[inst.A]
+		}
+		return name + ", " + plural(inst.B, "arg")
+	case OpPushDefer:
+		return plural(inst.A, "arg")
+	case OpReturn:
+		if inst.B == 1 {
+			return "value"
+		}
+		return ""
+	case OpMakeList, OpMakeSet:
+		return plural(inst.A, "element")
+	case OpMakeDict:
+		return plural(inst.A, "entry")
+	case OpMakeStruct:
+		return structName(mod, inst.A) + ", " + plural(inst.B, "field")
+	case OpIsStructType:
+		return structName(mod, inst.A)
+	case OpLoadField, OpStoreField:
+		return "field " + strconv.Itoa(inst.A)
+	}
+	return ""
+}
+
+func functionName(mod *Module, idx int) string {
+	if idx >= 0 && idx < len(mod.Functions) && mod.Functions[idx] != nil {
+		return mod.Functions[idx].Name
+	}
+	return fmt.Sprintf("<function %d>", idx)
+}
+
+func structName(mod *Module, idx int) string {
+	if idx >= 0 && idx < len(mod.StructTypes) {
+		return mod.StructTypes[idx].Name
+	}
+	return fmt.Sprintf("<struct %d>", idx)
+}
+
+// literal returns the constant in Avenir literal syntax.
+func (c Constant) literal() string {
+	switch c.Kind {
+	case ConstInt:
+		return strconv.FormatInt(c.Int, 10)
+	case ConstFloat:
+		return strconv.FormatFloat(c.Float, 'g', -1, 64)
+	case ConstString:
+		return strconv.Quote(c.String)
+	case ConstBool:
+		return strconv.FormatBool(c.Bool)
+	case ConstBytes:
+		return "b" + strconv.Quote(string(c.Bytes))
+	case ConstNone:
+		return "none"
+	}
+	return "?"
+}
+
+// SourceFunc returns line n (1-based) of a source file, for WriteText.
+type SourceFunc func(file string, n int) (string, bool)
+
+// WriteText prints the listing. When source is not nil and an instruction
+// starts a new source line, the line is printed above it.
+func (l *Listing) WriteText(w io.Writer, source SourceFunc) error {
+	var b strings.Builder
+	if l.Main != "" {
+		fmt.Fprintf(&b, "main: %s\n", l.Main)
+	}
+	if l.Init != "" {
+		fmt.Fprintf(&b, "init: %s\n", l.Init)
+	}
+	for i, st := range l.Structs {
+		fmt.Fprintf(&b, "struct %d: %s {%s}\n", i, st.Name, strings.Join(st.Fields, ", "))
+	}
+	for i, g := range l.Globals {
+		fmt.Fprintf(&b, "global %d: %s\n", i, g)
+	}
+	for i, sym := range l.Builtins {
+		fmt.Fprintf(&b, "builtin %d: %s\n", i, sym)
+	}
+	for _, fn := range l.Functions {
+		b.WriteString("\n")
+		fn.writeText(&b, source)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (fn *ListedFunction) writeText(b *strings.Builder, source SourceFunc) {
+	async := ""
+	if fn.Async {
+		async = ", async"
+	}
+	fmt.Fprintf(b, "function %d: %s (params %d, locals %d%s)\n", fn.Index, fn.Name, fn.Params, fn.Locals, async)
+	if fn.File != "" {
+		fmt.Fprintf(b, "  file: %s\n", fn.File)
+	}
+	for i, uv := range fn.Upvalues {
+		kind := "upvalue"
+		if uv.Local {
+			kind = "local"
+		}
+		fmt.Fprintf(b, "  upvalue %d: %s %d\n", i, kind, uv.Index)
+	}
+	for i, c := range fn.Constants {
+		fmt.Fprintf(b, "  const %d: %s %s\n", i, c.Kind, c.Value)
+	}
+	line := 0
+	for _, inst := range fn.Code {
+		if inst.Label != "" {
+			fmt.Fprintf(b, "%s:\n", inst.Label)
+		}
+		if source != nil && inst.Line > 0 && inst.Line != line {
+			if text, ok := source(fn.File, inst.Line); ok {
+				fmt.Fprintf(b, "  ; %d: %s\n", inst.Line, strings.TrimSpace(text))
+			}
+		}
+		line = inst.Line
+		if inst.Operand != "" {
+			fmt.Fprintf(b, "  %04d  %-16s %s\n", inst.IP, inst.Op, inst.Operand)
+		} else {
+			fmt.Fprintf(b, "  %04d  %s\n", inst.IP, inst.Op)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ir_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"avenir/internal/ir"
+)
+
+func TestDisassemble_Text(t *testing.T) {
+	mod := verifyModule()
+	mod.StructTypes = []ir.StructTypeInfo{{Name: "Point"}}
+	mod.Builtins = []string{"print"}
+	main := mod.Functions[1]
+	main.File = "main.av"
+	main.Chunk.Lines = []int{3, 4, 4, 4, 4, 5, 5, 6, 7, 8, 8}
+	main.Chunk.Code[8] = ir.Instruction{Op: ir.OpCallBuiltin, A: 0, B: 1}
+
+	source := []string{"", "", "try {", "    x = add(1, 2);", "} catch (e | error) {", "    x = e;", "}", "return x;"}
+	var out bytes.Buffer
+	err := ir.Disassemble(mod).WriteText(&out, func(file string, n int) (string, bool) {
+		if file != "main.av" || n > len(source) {
+			return "", false
+		}
+		return source[n-1], true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"main: main.main\n",
+		"struct 0: Point {}\n",
+		"builtin 0: print\n",
+		"function 1: main.main (params 0, locals 1)\n  file: main.av\n",
+		"  const 1: int 2\n",
+		"  ; 3: try {\n  0000  BeginTry         L0\n",
+		"  0003  Call             main.add, 2 args\n",
+		"  0006  Jump             L1\n",
+		"L0:\n  ; 6: x = e;\n  0007  StoreLocal       local 0\n",
+		"L1:\n  ; 7: }\n  0008  CallBuiltin      print, 1 arg\n",
+		"  0010  Return           value\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("listing does not contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestDisassemble_JSON(t *testing.T) {
+	mod := verifyModule()
+	mod.Functions[0].Upvalues = []ir.UpvalueInfo{{IsLocal: true, Index: 0}}
+	mod.Functions[1].Chunk.Code[9] = ir.Instruction{Op: ir.OpClosure, A: 0, B: 1}
+
+	data, err := json.Marshal(ir.Disassemble(mod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listing struct {
+		Main      string
+		Functions []struct {
+			Name     string
+			Upvalues []struct {
+				Local bool
+				Index int
+			}
+			Code []struct {
+				IP      int
+				Label   string
+				Op      string
+				Operand string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &listing); err != nil {
+		t.Fatal(err)
+	}
+	if listing.Main != "main.main" || len(listing.Functions) != 2 {
+		t.Fatalf("unexpected listing: %s", data)
+	}
+	if uv := listing.Functions[0].Upvalues; len(uv) != 1 || !uv[0].Local {
+		t.Fatalf("upvalues = %+v", uv)
+	}
+	code := listing.Functions[1].Code
+	if c := code[7]; c.Label != "L0" || c.Op != "StoreLocal" {
+		t.Fatalf("code[7] = %+v", c)
+	}
+	if c := code[9]; c.Op != "Closure" || c.Operand != "main.add, 1 upvalue" {
+		t.Fatalf("code[9] = %+v", c)
+	}
+}
+
+func TestDisassemble_OutOfRangeOperands(t *testing.T) {
+	mod := verifyModule()
+	mod.Functions[1].Chunk.Code[3].A = 7
+	mod.Functions[1].Chunk.Code[6].A = 99
+	var out bytes.Buffer
+	if err := ir.Disassemble(mod).WriteText(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<function 7>, 2 args", "Jump             <ip 99>"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("listing does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package ir
 
+import "fmt"
+
 // OpCode is an opcode for Avenir VM bytecode
 type OpCode byte
 
@@ -35,7 +37,7 @@ const (
 	// Calls / returns
 	OpCall        // A = function index, B = number of arguments
 	OpCallValue   // A = number of arguments, callee = value on stack
-	OpCallBuiltin // A = builtin id, B = number of arguments
+	OpCallBuiltin // A = index in Module.Builtins, B = number of arguments
 	OpPushDefer   // A = number of arguments captured for deferred call
 	OpReturn      // B = 0 (without result) or 1 (with result returning)
 
@@ -69,13 +71,49 @@ const (
 	// Async
 	OpSpawn            // A = function index, B = number of arguments; create task + future, schedule, push future
 	OpAwait            // pop future; if ready push result; else suspend current task
-	OpCallBuiltinAsync // A = builtin id, B = number of arguments; call async builtin, create future, push future
+	OpCallBuiltinAsync // A = index in Module.Builtins, B = number of arguments; call async builtin, create future, push future
 
 	// Module-level variables
 	OpLoadGlobal  // A = global index; push globals[A]
 	OpStoreGlobal // A = global index; globals[A] = top (no pop)
+
+	// Superinstructions, emitted by Optimize
+	OpAddLocalConst  // A = local variable index, B = constant index; push local[A] + const[B]
+	OpCmpJumpIfFalse // A = absolute ip, B = comparison opcode; pop two values, jump if the comparison is false
+
+	// Interface dispatch
+	OpCallMethod // A = index in Module.MethodCalls, B = number of arguments including the receiver
+
+	// Sets
+	OpMakeSet // A = number of elements; pop A values, create set, push set
 )
 
+var opNames = map[OpCode]string{
+	OpHalt: "Halt", OpConst: "Const", OpLoadLocal: "LoadLocal", OpStoreLocal: "StoreLocal", OpPop: "Pop",
+	OpAdd: "Add", OpSub: "Sub", OpMul: "Mul", OpDiv: "Div", OpMod: "Mod", OpNegate: "Negate",
+	OpEq: "Eq", OpNeq: "Neq", OpLt: "Lt", OpLte: "Lte", OpGt: "Gt", OpGte: "Gte",
+	OpJump: "Jump", OpJumpIfFalse: "JumpIfFalse", OpJumpIfNone: "JumpIfNone",
+	OpCall: "Call", OpCallValue: "CallValue", OpCallBuiltin: "CallBuiltin", OpPushDefer: "PushDefer", OpReturn: "Return",
+	OpMakeList: "MakeList", OpMakeDict: "MakeDict", OpIndex: "Index", OpMakeSome: "MakeSome",
+	OpMakeStruct: "MakeStruct", OpLoadField: "LoadField", OpStoreField: "StoreField",
+	OpStringify: "Stringify", OpConcatString: "ConcatString",
+	OpBeginTry: "BeginTry", OpEndTry: "EndTry", OpThrow: "Throw", OpIsStructType: "IsStructType",
+	OpClosure: "Closure", OpLoadUpvalue: "LoadUpvalue", OpStoreUpvalue: "StoreUpvalue", OpSetFunc: "SetFunc",
+	OpSpawn: "Spawn", OpAwait: "Await", OpCallBuiltinAsync: "CallBuiltinAsync",
+	OpLoadGlobal: "LoadGlobal", OpStoreGlobal: "StoreGlobal",
+	OpAddLocalConst: "AddLocalConst", OpCmpJumpIfFalse: "CmpJumpIfFalse",
+	OpCallMethod: "CallMethod",
+	OpMakeSet:    "MakeSet",
+}
+
+// String returns the opcode's name without the Op prefix.
+func (op OpCode) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
 // Instruction is one bytecode instruction
 // A and B are operands (semantics depend on Op)
 type Instruction struct {
@@ -95,6 +133,25 @@ const (
 	ConstNone
 )
 
+// String returns the constant kind's name.
+func (k ConstKind) String() string {
+	switch k {
+	case ConstInt:
+		return "int"
+	case ConstFloat:
+		return "float"
+	case ConstString:
+		return "string"
+	case ConstBool:
+		return "bool"
+	case ConstBytes:
+		return "bytes"
+	case ConstNone:
+		return "none"
+	}
+	return fmt.Sprintf("ConstKind(%d)", int(k))
+}
+
 // Constant is written to the module's constant table
 type Constant struct {
 	Kind   ConstKind
@@ -110,12 +167,26 @@ type Chunk struct {
 	Code      []Instruction
 	Consts    []Constant
 	NumLocals int // Number of local slots, including parameters
+	// Lines is debug info: the source line of each instruction, 0 where
+	// unknown. It is nil for modules read from files without debug info.
+	Lines []int
+	line  int // line recorded for emitted instructions, see SetLine
 }
 
 // UpvalueInfo describes a captured variable (upvalue).
 type UpvalueInfo struct {
-	IsLocal bool // true: captures a local from immediately enclosing function
-	Index   int  // slot index in enclosing function's locals OR "upvalue index" of enclosing function
+	IsLocal bool   // true: captures a local from immediately enclosing function
+	Index   int    // slot index in enclosing function's locals OR "upvalue index" of enclosing function
+	Name    string // debug info: name of the captured variable, if known
+}
+
+// LocalInfo is debug info naming a local slot while the instructions
+// [Start, End) of its function run. Compiler temporaries are not listed.
+type LocalInfo struct {
+	Name  string
+	Slot  int
+	Start int
+	End   int
 }
 
 // Function represents a single function in a module.
@@ -125,6 +196,9 @@ type Function struct {
 	Chunk     Chunk
 	Upvalues  []UpvalueInfo // NEW: upvalues for closures
 	IsAsync   bool
+	IsPublic  bool        // declared with pub; only these can be called by a host
+	File      string      // debug info: source file of the function, if known
+	Locals    []LocalInfo // debug info: named locals in declaration order
 }
 
 // GlobalInfo describes a module-level variable.
@@ -139,6 +213,23 @@ type Module struct {
 	Globals     []GlobalInfo // module-level variable metadata
 	MainIndex   int          // Index of the main function in the Functions array
 	InitIndex   int          // Index of the __init__ function (-1 if none)
+	// Builtins is the symbol table of the builtins called by the module
+	// (see builtins.Meta.Symbol); the VM resolves them by name at run time.
+	Builtins []string
+	// MethodCalls holds the method name of each OpCallMethod site. The VM
+	// resolves a site against the receiver's type and caches the result.
+	MethodCalls []string
+}
+
+// FunctionIndex returns the index of the function with the given qualified
+// name, e.g. "main.add".
+func (m *Module) FunctionIndex(name string) (int, bool) {
+	for i, fn := range m.Functions {
+		if fn.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
 }
 
 // AddConstInt adds an integer constant and returns its index.
@@ -194,8 +285,16 @@ func (c *Chunk) AddConstNone() int {
 	return len(c.Consts) - 1
 }
 
+// SetLine sets the source line recorded for the instructions emitted next.
+func (c *Chunk) SetLine(line int) {
+	c.line = line
+}
+
 // Emit appends an instruction to the end of the chunk.
 func (c *Chunk) Emit(op OpCode, a, b int) int {
+	if len(c.Lines) == len(c.Code) {
+		c.Lines = append(c.Lines, c.line)
+	}
 	c.Code = append(c.Code, Instruction{
 		Op: op,
 		A:  a,
@@ -0,0 +1,537 @@
+package ir
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+
+	"avenir/internal/intmath"
+)
+
+// Optimize rewrites the code of every function of mod in place. It folds
+// constant arithmetic, comparisons and string concatenation, threads jumps,
+// removes unreachable code and redundant stores, and fuses common pairs into
+// superinstructions (OpAddLocalConst, OpCmpJumpIfFalse). The optimized module
+// behaves like the original one, except that it runs fewer instructions.
+//
+// Rewrites never span an instruction that a jump or exception handler
+// targets. Line tables and the instruction ranges of locals are remapped.
+func Optimize(mod *Module) {
+	for _, fn := range mod.Functions {
+		if fn != nil && len(fn.Chunk.Code) > 0 {
+			optimizeFunction(fn)
+		}
+	}
+}
+
+func optimizeFunction(fn *Function) {
+	o := &optimizer{fn: fn}
+	for {
+		o.start()
+		changed := o.fold()
+		changed = o.threadJumps() || changed
+		changed = o.removeUnreachable() || changed
+		o.compact()
+		if !changed {
+			break
+		}
+	}
+	o.start()
+	o.fuse()
+	o.compact()
+	o.compactConsts()
+}
+
+// optimizer holds the state of one function's optimization. Rewrites mark
+// the instructions they drop as dead; compact removes them.
+type optimizer struct {
+	fn      *Function
+	dead    []bool
+	targets []bool // instructions that jumps or handlers land on
+}
+
+func (o *optimizer) start() {
+	code := o.fn.Chunk.Code
+	o.dead = make([]bool, len(code))
+	o.targets = make([]bool, len(code))
+	for _, inst := range code {
+		if isJump(inst.Op) && inst.A >= 0 && inst.A < len(code) {
+			o.targets[inst.A] = true
+		}
+	}
+}
+
+// next returns the first live instruction after ip, or len(code).
+func (o *optimizer) next(ip int) int {
+	return o.live(ip + 1)
+}
+
+// live returns the first live instruction at or after ip, or len(code).
+func (o *optimizer) live(ip int) int {
+	for ip < len(o.dead) && o.dead[ip] {
+		ip++
+	}
+	return ip
+}
+
+// window returns the live instructions starting at ip, if there are n of
+// them and only the first may be a jump target.
+func (o *optimizer) window(ip, n int) ([]int, bool) {
+	ips := []int{ip}
+	for len(ips) < n {
+		ip = o.next(ip)
+		if ip >= len(o.dead) || o.targets[ip] {
+			return nil, false
+		}
+		ips = append(ips, ip)
+	}
+	return ips, true
+}
+
+// replace rewrites the first instruction of a window and drops the others.
+func (o *optimizer) replace(ips []int, inst Instruction) {
+	o.fn.Chunk.Code[ips[0]] = inst
+	for _, ip := range ips[1:] {
+		o.dead[ip] = true
+	}
+}
+
+// constAt returns the constant loaded by the instruction at ip, if any.
+func (o *optimizer) constAt(ip int) (Constant, bool) {
+	inst := o.fn.Chunk.Code[ip]
+	if inst.Op != OpConst || inst.A < 0 || inst.A >= len(o.fn.Chunk.Consts) {
+		return Constant{}, false
+	}
+	return o.fn.Chunk.Consts[inst.A], true
+}
+
+func (o *optimizer) addConst(c Constant) Instruction {
+	o.fn.Chunk.Consts = append(o.fn.Chunk.Consts, c)
+	return Instruction{Op: OpConst, A: len(o.fn.Chunk.Consts) - 1}
+}
+
+// fold applies the constant folding and store/load rules once over the
+// code, retrying each position after a rewrite so that folds chain.
+func (o *optimizer) fold() bool {
+	code := o.fn.Chunk.Code
+	changed := false
+	for ip := 0; ip < len(code); {
+		if o.dead[ip] || !o.foldAt(ip) {
+			ip++
+			continue
+		}
+		changed = true
+	}
+	return changed
+}
+
+func (o *optimizer) foldAt(ip int) bool {
+	code := o.fn.Chunk.Code
+	if a, ok := o.constAt(ip); ok {
+		if w, ok := o.window(ip, 2); ok {
+			switch code[w[1]].Op {
+			case OpNegate:
+				if r, ok := foldNegate(a); ok {
+					o.replace(w, o.addConst(r))
+					return true
+				}
+			case OpStringify:
+				if r, ok := foldStringify(a); ok {
+					o.replace(w, o.addConst(r))
+					return true
+				}
+			case OpJumpIfFalse:
+				if a.Kind != ConstBool {
+					return false
+				}
+				if a.Bool {
+					o.dead[w[0]], o.dead[w[1]] = true, true
+				} else {
+					o.replace(w, Instruction{Op: OpJump, A: code[w[1]].A})
+				}
+				return true
+			case OpPop:
+				o.dead[w[0]], o.dead[w[1]] = true, true
+				return true
+			}
+		}
+		if w, ok := o.window(ip, 3); ok {
+			if b, ok := o.constAt(w[1]); ok {
+				if r, ok := foldBinary(code[w[2]].Op, a, b); ok {
+					o.replace(w, o.addConst(r))
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if code[ip].Op == OpStoreLocal {
+		if w, ok := o.window(ip, 3); ok && code[w[1]].Op == OpPop &&
+			code[w[2]].Op == OpLoadLocal && code[w[2]].A == code[ip].A {
+			o.replace(w, code[ip])
+			return true
+		}
+	}
+	return false
+}
+
+// threadJumps points jumps that land on an unconditional jump at its
+// target, and drops jumps to the next instruction.
+func (o *optimizer) threadJumps() bool {
+	code := o.fn.Chunk.Code
+	changed := false
+	for ip := range code {
+		if o.dead[ip] || !isJump(code[ip].Op) {
+			continue
+		}
+		target := o.live(code[ip].A)
+		for hops := 0; target < len(code) && code[target].Op == OpJump && hops < len(code); hops++ {
+			target = o.live(code[target].A)
+		}
+		if target >= len(code) {
+			continue
+		}
+		if target != code[ip].A {
+			code[ip].A = target
+			changed = true
+		}
+		if target != o.next(ip) {
+			continue
+		}
+		switch code[ip].Op {
+		case OpJump:
+			o.dead[ip] = true
+			changed = true
+		case OpJumpIfFalse:
+			// Both ways lead to the same place; only the pop remains.
+			code[ip] = Instruction{Op: OpPop}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// removeUnreachable drops the instructions no path from the entry reaches,
+// such as the code after a return or throw.
+func (o *optimizer) removeUnreachable() bool {
+	code := o.fn.Chunk.Code
+	reached := make([]bool, len(code))
+	work := []int{o.live(0)}
+	for len(work) > 0 {
+		ip := work[len(work)-1]
+		work = work[:len(work)-1]
+		if ip >= len(code) || reached[ip] {
+			continue
+		}
+		reached[ip] = true
+		inst := code[ip]
+		switch inst.Op {
+		case OpHalt, OpReturn, OpThrow:
+		case OpJump:
+			work = append(work, o.live(inst.A))
+		case OpJumpIfFalse, OpJumpIfNone, OpBeginTry, OpCmpJumpIfFalse:
+			work = append(work, o.live(inst.A), o.next(ip))
+		default:
+			work = append(work, o.next(ip))
+		}
+	}
+	changed := false
+	for ip := range code {
+		if !o.dead[ip] && !reached[ip] {
+			o.dead[ip] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// fuse replaces common instruction sequences with superinstructions.
+func (o *optimizer) fuse() {
+	code := o.fn.Chunk.Code
+	for ip := range code {
+		if o.dead[ip] {
+			continue
+		}
+		switch code[ip].Op {
+		case OpLoadLocal:
+			w, ok := o.window(ip, 3)
+			if !ok || code[w[2]].Op != OpAdd {
+				continue
+			}
+			if c, ok := o.constAt(w[1]); ok && (c.Kind == ConstInt || c.Kind == ConstFloat) {
+				o.replace(w, Instruction{Op: OpAddLocalConst, A: code[ip].A, B: code[w[1]].A})
+			}
+		case OpLt, OpLte, OpGt, OpGte, OpEq, OpNeq:
+			if w, ok := o.window(ip, 2); ok && code[w[1]].Op == OpJumpIfFalse {
+				o.replace(w, Instruction{Op: OpCmpJumpIfFalse, A: code[w[1]].A, B: int(code[ip].Op)})
+			}
+		}
+	}
+}
+
+// compact removes the dead instructions, remapping jump targets, lines
+// and local ranges. A target that was dropped maps to the next live
+// instruction, which is where control would have gone.
+func (o *optimizer) compact() {
+	chunk := &o.fn.Chunk
+	remap := make([]int, len(chunk.Code)+1)
+	n := 0
+	for ip := range chunk.Code {
+		remap[ip] = n
+		if !o.dead[ip] {
+			n++
+		}
+	}
+	remap[len(chunk.Code)] = n
+	if n == len(chunk.Code) {
+		return
+	}
+
+	code := make([]Instruction, 0, n)
+	var lines []int
+	if chunk.Lines != nil {
+		lines = make([]int, 0, n)
+	}
+	for ip, inst := range chunk.Code {
+		if o.dead[ip] {
+			continue
+		}
+		if isJump(inst.Op) && inst.A >= 0 && inst.A <= len(chunk.Code) {
+			inst.A = remap[inst.A]
+		}
+		code = append(code, inst)
+		if lines != nil && ip < len(chunk.Lines) {
+			lines = append(lines, chunk.Lines[ip])
+		}
+	}
+	chunk.Code = code
+	chunk.Lines = lines
+	for i := range o.fn.Locals {
+		l := &o.fn.Locals[i]
+		if l.Start >= 0 && l.End <= len(remap)-1 && l.Start <= l.End {
+			l.Start, l.End = remap[l.Start], remap[l.End]
+		}
+	}
+	o.dead = make([]bool, len(code))
+}
+
+// compactConsts drops the constants no instruction loads any more and
+// merges duplicates.
+func (o *optimizer) compactConsts() {
+	chunk := &o.fn.Chunk
+	index := make(map[string]int)
+	var consts []Constant
+	remap := func(idx int) int {
+		if idx < 0 || idx >= len(chunk.Consts) {
+			return idx
+		}
+		c := chunk.Consts[idx]
+		key := constKey(c)
+		n, ok := index[key]
+		if !ok {
+			n = len(consts)
+			consts = append(consts, c)
+			index[key] = n
+		}
+		return n
+	}
+	for i, inst := range chunk.Code {
+		switch inst.Op {
+		case OpConst:
+			chunk.Code[i].A = remap(inst.A)
+		case OpAddLocalConst:
+			chunk.Code[i].B = remap(inst.B)
+		}
+	}
+	chunk.Consts = consts
+}
+
+// constKey identifies a constant by kind and value. Floats are compared
+// by their bits so that 0 and -0 stay apart.
+func constKey(c Constant) string {
+	switch c.Kind {
+	case ConstInt:
+		return fmt.Sprintf("i%d", c.Int)
+	case ConstFloat:
+		return fmt.Sprintf("f%x", math.Float64bits(c.Float))
+	case ConstString:
+		return "s" + c.String
+	case ConstBool:
+		return fmt.Sprintf("b%t", c.Bool)
+	case ConstBytes:
+		return "y" + string(c.Bytes)
+	}
+	return fmt.Sprintf("k%d", c.Kind)
+}
+
+// The folding rules below compute what the VM would at run time, and give
+// up on anything that would raise an error there.
+
+func numeric(c Constant) (float64, bool) {
+	switch c.Kind {
+	case ConstInt:
+		return float64(c.Int), true
+	case ConstFloat:
+		return c.Float, true
+	}
+	return 0, false
+}
+
+func foldBinary(op OpCode, a, b Constant) (Constant, bool) {
+	switch op {
+	case OpAdd, OpSub, OpMul, OpDiv:
+		return foldArith(op, a, b)
+	case OpMod:
+		if a.Kind != ConstInt || b.Kind != ConstInt || b.Int == 0 {
+			return Constant{}, false
+		}
+		return Constant{Kind: ConstInt, Int: a.Int % b.Int}, true
+	case OpLt, OpLte, OpGt, OpGte:
+		if a.Kind == ConstInt && b.Kind == ConstInt {
+			// Ints compare exactly, as in the VM.
+			c := cmp.Compare(a.Int, b.Int)
+			var r bool
+			switch op {
+			case OpLt:
+				r = c < 0
+			case OpLte:
+				r = c <= 0
+			case OpGt:
+				r = c > 0
+			default:
+				r = c >= 0
+			}
+			return Constant{Kind: ConstBool, Bool: r}, true
+		}
+		x, ok1 := numeric(a)
+		y, ok2 := numeric(b)
+		if !ok1 || !ok2 {
+			return Constant{}, false
+		}
+		var r bool
+		switch op {
+		case OpLt:
+			r = x < y
+		case OpLte:
+			r = x <= y
+		case OpGt:
+			r = x > y
+		default:
+			r = x >= y
+		}
+		return Constant{Kind: ConstBool, Bool: r}, true
+	case OpEq, OpNeq:
+		eq, ok := constEqual(a, b)
+		if !ok {
+			return Constant{}, false
+		}
+		return Constant{Kind: ConstBool, Bool: eq == (op == OpEq)}, true
+	case OpConcatString:
+		if a.Kind != ConstString || b.Kind != ConstString {
+			return Constant{}, false
+		}
+		return Constant{Kind: ConstString, String: a.String + b.String}, true
+	}
+	return Constant{}, false
+}
+
+// foldArith mirrors the VM's numeric operations: ints compute exactly, and
+// an int with a float computes in float64.
+func foldArith(op OpCode, a, b Constant) (Constant, bool) {
+	if a.Kind == ConstInt && b.Kind == ConstInt {
+		var r int64
+		ok := true
+		switch op {
+		case OpAdd:
+			r, ok = intmath.Add(a.Int, b.Int)
+		case OpSub:
+			r, ok = intmath.Sub(a.Int, b.Int)
+		case OpMul:
+			r, ok = intmath.Mul(a.Int, b.Int)
+		default:
+			if b.Int == 0 {
+				return Constant{}, false
+			}
+			r, ok = intmath.Div(a.Int, b.Int)
+		}
+		// Overflow is left to the VM, which reports it.
+		if !ok {
+			return Constant{}, false
+		}
+		return Constant{Kind: ConstInt, Int: r}, true
+	}
+	x, ok1 := numeric(a)
+	y, ok2 := numeric(b)
+	if !ok1 || !ok2 {
+		return Constant{}, false
+	}
+	var r float64
+	switch op {
+	case OpAdd:
+		r = x + y
+	case OpSub:
+		r = x - y
+	case OpMul:
+		r = x * y
+	default:
+		if y == 0 {
+			return Constant{}, false
+		}
+		r = x / y
+	}
+	return Constant{Kind: ConstFloat, Float: r}, true
+}
+
+// constEqual compares constants like the VM's == does: values of different
+// kinds are never equal.
+func constEqual(a, b Constant) (bool, bool) {
+	for _, c := range []Constant{a, b} {
+		switch c.Kind {
+		case ConstInt, ConstFloat, ConstString, ConstBool:
+		default:
+			return false, false
+		}
+	}
+	if a.Kind != b.Kind {
+		return false, true
+	}
+	switch a.Kind {
+	case ConstInt:
+		return a.Int == b.Int, true
+	case ConstFloat:
+		return a.Float == b.Float, true
+	case ConstString:
+		return a.String == b.String, true
+	default:
+		return a.Bool == b.Bool, true
+	}
+}
+
+func foldNegate(a Constant) (Constant, bool) {
+	switch a.Kind {
+	case ConstInt:
+		r, ok := intmath.Neg(a.Int)
+		return Constant{Kind: ConstInt, Int: r}, ok
+	case ConstFloat:
+		return Constant{Kind: ConstFloat, Float: -a.Float}, true
+	}
+	return Constant{}, false
+}
+
+// foldStringify formats a constant like value.Value.String.
+func foldStringify(a Constant) (Constant, bool) {
+	var s string
+	switch a.Kind {
+	case ConstInt:
+		s = fmt.Sprintf("%d", a.Int)
+	case ConstFloat:
+		s = fmt.Sprintf("%g", a.Float)
+	case ConstString:
+		s = a.String
+	case ConstBool:
+		s = fmt.Sprintf("%t", a.Bool)
+	default:
+		return Constant{}, false
+	}
+	return Constant{Kind: ConstString, String: s}, true
+}
@@ -0,0 +1,379 @@
+package ir_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"avenir/internal/ir"
+	"avenir/internal/lexer"
+	"avenir/internal/parser"
+	"avenir/internal/runtime"
+	"avenir/internal/vm"
+)
+
+// compileBoth compiles src and returns its module and an optimized copy.
+func compileBoth(t *testing.T, src string) (plain, optimized *ir.Module) {
+	t.Helper()
+	for _, mod := range []**ir.Module{&plain, &optimized} {
+		p := parser.New(lexer.New(src))
+		prog := p.ParseProgram()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("parser errors: %v", errs)
+		}
+		var errs []error
+		*mod, errs = ir.Compile(prog)
+		if len(errs) > 0 {
+			t.Fatalf("compile errors: %v", errs)
+		}
+	}
+	ir.Optimize(optimized)
+	if err := ir.Verify(optimized); err != nil {
+		t.Fatalf("optimized module does not verify: %v", err)
+	}
+	return plain, optimized
+}
+
+// printed runs mod and returns what it printed.
+func printed(t *testing.T, mod *ir.Module) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := vm.NewVM(mod, runtime.NewEnv(&testIO{output: &buf})).RunMain(); err != nil {
+		t.Fatalf("RunMain error: %v", err)
+	}
+	return buf.String()
+}
+
+// ops lists the opcodes of the named function.
+func ops(t *testing.T, mod *ir.Module, name string) []ir.OpCode {
+	t.Helper()
+	idx, ok := mod.FunctionIndex(name)
+	if !ok {
+		t.Fatalf("no function %s", name)
+	}
+	var ops []ir.OpCode
+	for _, inst := range mod.Functions[idx].Chunk.Code {
+		ops = append(ops, inst.Op)
+	}
+	return ops
+}
+
+func TestOptimize_SameOutput(t *testing.T) {
+	// Each program must print the same with and without the optimizer.
+	tests := []struct {
+		name, src, want string
+	}{
+		{"loops", `pckg main;
+
+fun main() | void {
+    var sum | int = 0;
+    for (var i | int = 0; i < 10; i = i + 1) {
+        if (i == 7) {
+            break;
+        }
+        if (i % 2 == 0) {
+            continue;
+        }
+        sum = sum + i;
+    }
+    var n | int = 3;
+    while (n > 0) {
+        n = n - 1;
+        sum = sum * 2;
+    }
+    for (x in [1, 2, 3]) {
+        sum = sum + x;
+    }
+    print(sum);
+}
+`, "78"},
+		{"closures", `pckg main;
+
+fun apply(f | fun(int) | int, x | int) | int {
+    return f(x);
+}
+
+fun main() | void {
+    var base | int = 10;
+    var add = fun(x | int) | int {
+        return x + base;
+    };
+    base = base + 1;
+    print(apply(add, 1 + 2));
+}
+`, "14"},
+		{"errors", `pckg main;
+
+fun check(x | int) | int {
+    if (x > 2) {
+        throw error("too big");
+    }
+    return x * 3;
+}
+
+fun main() | void {
+    for (x in [1, 3]) {
+        try {
+            print(check(x));
+        } catch (e | error) {
+            print("caught ${e}");
+        }
+    }
+    try {
+        print(1 / 0);
+    } catch (e | error) {
+        print("div");
+    }
+}
+`, "3caught error(too big)div"},
+		{"strings", `pckg main;
+
+fun main() | void {
+    var name | string = "av" + "enir";
+    var s | string = "${name}:${1 + 2}:${2.5 * 2}:${1 < 2}";
+    print(s);
+    print(s.length());
+}
+`, "avenir:3:5:true15"},
+		{"switch", `pckg main;
+
+fun label(v | int) | string {
+    switch v {
+        case 1:
+            return "one";
+        case 2:
+            return "two";
+        default:
+            return "many";
+    }
+}
+
+fun main() | void {
+    print(label(1) + label(2) + label(2 + 1));
+}
+`, "onetwomany"},
+		{"structs and defer", `pckg main;
+
+struct Point {
+    x | int
+    y | int
+}
+
+fun (p | Point).sum() | int {
+    return p.x + p.y;
+}
+
+fun note(msg | string) | void {
+    print(msg);
+}
+
+fun main() | void {
+    defer note("done");
+    var p | Point = Point{x = 1 + 1, y = 3};
+    if (false) {
+        print("unreachable");
+    }
+    print(p.sum());
+}
+`, "5done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, optimized := compileBoth(t, tt.src)
+			want := printed(t, plain)
+			if want != tt.want {
+				t.Fatalf("program printed %q, want %q", want, tt.want)
+			}
+			if got := printed(t, optimized); got != want {
+				t.Fatalf("optimized program printed %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestOptimize_FoldsConstants(t *testing.T) {
+	plain, optimized := compileBoth(t, `pckg main;
+
+fun main() | void {
+    var a | int = 1 + 2 * 3 - 4 % 3;
+    var b | float = 7 / 2 + 0.5;
+    var c | int = 9007199254740993 + 0;
+    var d | string = "a" + "b" + "${1 + 1}${-2.5}${true}";
+    var e | bool = 1 < 2.5 && 2 == 3;
+    print("${a} ${b} ${c} ${d} ${e}");
+}
+`)
+	want := printed(t, plain)
+	if got := printed(t, optimized); got != want {
+		t.Fatalf("optimized program printed %q, want %q", got, want)
+	}
+	if want != "6 3.5 9007199254740993 ab2-2.5true false" {
+		t.Fatalf("unexpected output %q", want)
+	}
+	for _, op := range ops(t, optimized, "main.main") {
+		switch op {
+		case ir.OpAdd, ir.OpSub, ir.OpMul, ir.OpDiv, ir.OpMod, ir.OpNegate, ir.OpLt, ir.OpEq:
+			t.Errorf("%s left in folded code: %v", op, ops(t, optimized, "main.main"))
+		}
+	}
+}
+
+func TestOptimize_KeepsRuntimeErrors(t *testing.T) {
+	_, optimized := compileBoth(t, `pckg main;
+
+fun main() | int {
+    return 1 / 0;
+}
+`)
+	if !slices.Contains(ops(t, optimized, "main.main"), ir.OpDiv) {
+		t.Fatalf("division by zero was folded: %v", ops(t, optimized, "main.main"))
+	}
+	if _, err := vm.NewVM(optimized, runtime.DefaultEnv()).RunMain(); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("RunMain error = %v, want division by zero", err)
+	}
+}
+
+func TestOptimize_KeepsIntOverflow(t *testing.T) {
+	_, optimized := compileBoth(t, `pckg main;
+
+fun main() | int {
+    return 9223372036854775807 + 1;
+}
+`)
+	if !slices.Contains(ops(t, optimized, "main.main"), ir.OpAdd) {
+		t.Fatalf("overflowing addition was folded: %v", ops(t, optimized, "main.main"))
+	}
+	if _, err := vm.NewVM(optimized, runtime.DefaultEnv()).RunMain(); err == nil || !strings.Contains(err.Error(), "integer overflow") {
+		t.Fatalf("RunMain error = %v, want integer overflow", err)
+	}
+}
+
+func TestOptimize_DeadCodeAndJumps(t *testing.T) {
+	plain, optimized := compileBoth(t, `pckg main;
+
+fun pick(x | int) | string {
+    if (false) {
+        return "never";
+    }
+    if (x > 0) {
+        return "pos";
+    } else {
+        return "neg";
+    }
+    return "unreachable";
+}
+
+fun main() | void {
+    print(pick(1) + pick(-1));
+}
+`)
+	if got, want := printed(t, optimized), printed(t, plain); got != want || want != "posneg" {
+		t.Fatalf("optimized program printed %q, plain %q", got, want)
+	}
+	idx, _ := optimized.FunctionIndex("main.pick")
+	fn := optimized.Functions[idx]
+	for ip, inst := range fn.Chunk.Code {
+		if inst.Op == ir.OpConst && fn.Chunk.Consts[inst.A].Kind == ir.ConstString {
+			if s := fn.Chunk.Consts[inst.A].String; s == "never" || s == "unreachable" {
+				t.Errorf("dead code loading %q kept at %d", s, ip)
+			}
+		}
+		if inst.Op == ir.OpJump && inst.A == ip+1 {
+			t.Errorf("jump to the next instruction kept at %d", ip)
+		}
+	}
+	if len(fn.Chunk.Consts) != 3 {
+		t.Errorf("constants %v, want 0, \"pos\" and \"neg\"", fn.Chunk.Consts)
+	}
+}
+
+func TestOptimize_Superinstructions(t *testing.T) {
+	plain, optimized := compileBoth(t, `pckg main;
+
+fun main() | void {
+    var s | int = 0;
+    for (var i | int = 0; i < 10; i = i + 1) {
+        s = s + i;
+    }
+    var f | float = 0.5;
+    while (f != 4.5) {
+        f = f + 1;
+    }
+    print("${s} ${f}");
+}
+`)
+	if got, want := printed(t, optimized), printed(t, plain); got != want || want != "45 4.5" {
+		t.Fatalf("optimized program printed %q, plain %q", got, want)
+	}
+	got := ops(t, optimized, "main.main")
+	for _, op := range []ir.OpCode{ir.OpAddLocalConst, ir.OpCmpJumpIfFalse} {
+		if !slices.Contains(got, op) {
+			t.Errorf("no %s in %v", op, got)
+		}
+	}
+	if slices.Contains(got, ir.OpJumpIfFalse) {
+		t.Errorf("JumpIfFalse left after a comparison: %v", got)
+	}
+
+	// Locals still cover the code that uses them.
+	idx, _ := optimized.FunctionIndex("main.main")
+	fn := optimized.Functions[idx]
+	for _, l := range fn.Locals {
+		for ip, inst := range fn.Chunk.Code {
+			if (inst.Op == ir.OpLoadLocal || inst.Op == ir.OpAddLocalConst) && inst.A == l.Slot && l.Name == "i" && (ip < l.Start || ip >= l.End) {
+				t.Errorf("local i used at %d outside [%d, %d)", ip, l.Start, l.End)
+			}
+		}
+	}
+}
+
+func TestOptimize_StoreLoad(t *testing.T) {
+	mod := &ir.Module{
+		Functions: []*ir.Function{{
+			Name: "main.main",
+			Chunk: ir.Chunk{
+				NumLocals: 1,
+				Consts:    []ir.Constant{{Kind: ir.ConstInt, Int: 1}, {Kind: ir.ConstInt, Int: 2}},
+				Code: []ir.Instruction{
+					{Op: ir.OpConst, A: 0},
+					{Op: ir.OpStoreLocal, A: 0},
+					{Op: ir.OpPop},
+					{Op: ir.OpLoadLocal, A: 0},
+					{Op: ir.OpConst, A: 1},
+					{Op: ir.OpJump, A: 6},
+					{Op: ir.OpAdd},
+					{Op: ir.OpReturn, B: 1},
+				},
+				Lines: []int{1, 1, 1, 2, 2, 2, 3, 3},
+			},
+			Locals: []ir.LocalInfo{{Name: "x", Slot: 0, Start: 2, End: 8}},
+		}},
+		InitIndex: -1,
+	}
+	ir.Optimize(mod)
+	if err := ir.Verify(mod); err != nil {
+		t.Fatal(err)
+	}
+	fn := mod.Functions[0]
+	want := []ir.Instruction{
+		{Op: ir.OpConst, A: 0},
+		{Op: ir.OpStoreLocal, A: 0},
+		{Op: ir.OpConst, A: 1},
+		{Op: ir.OpAdd},
+		{Op: ir.OpReturn, B: 1},
+	}
+	if !slices.Equal(fn.Chunk.Code, want) {
+		t.Fatalf("code = %v, want %v", fn.Chunk.Code, want)
+	}
+	if !slices.Equal(fn.Chunk.Lines, []int{1, 1, 2, 3, 3}) {
+		t.Errorf("lines = %v", fn.Chunk.Lines)
+	}
+	if l := fn.Locals[0]; l.Start != 2 || l.End != 5 {
+		t.Errorf("local x covers [%d, %d), want [2, 5)", l.Start, l.End)
+	}
+	val, err := vm.NewVM(mod, runtime.DefaultEnv()).RunMain()
+	if err != nil || val.Int() != 3 {
+		t.Fatalf("RunMain = %v, %v; want 3", val.Int(), err)
+	}
+}
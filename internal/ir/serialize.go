@@ -1,15 +1,43 @@
 package ir
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"sort"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/types"
 )
 
 var magicV1 = [4]byte{'A', 'V', 'C', '1'}
 var magicV2 = [4]byte{'A', 'V', 'C', '2'}
 
+// magicV3 files refer to builtins by name through Module.Builtins and also
+// store upvalues, function flags, globals and the init function.
+var magicV3 = [4]byte{'A', 'V', 'C', '3'}
+
+// magicV4 files are AVC3 files that also store the instance methods of each
+// struct type and the method names of OpCallMethod sites.
+var magicV4 = [4]byte{'A', 'V', 'C', '4'}
+
+// Function flags of AVC3 and AVC4 files.
+const (
+	fnAsync uint8 = 1 << iota
+	fnPublic
+)
+
+// debugSection tags the optional debug info at the end of AVC3 and AVC4
+// files: the source file and run-length encoded line table of each function.
+var debugSection = [4]byte{'D', 'B', 'G', '1'}
+
+// localsSection follows debugSection with the names of each function's
+// locals (Function.Locals) and upvalues.
+var localsSection = [4]byte{'V', 'A', 'R', '1'}
+
 func WriteModuleToFile(filename string, m *Module) error {
 	f, err := os.Create(filename)
 	if err != nil {
@@ -28,253 +56,503 @@ func ReadModuleFromFile(filename string) (*Module, error) {
 	return ReadModule(f)
 }
 
-func WriteModule(w io.Writer, m *Module) error {
-	// magic
-	if _, err := w.Write(magicV2[:]); err != nil {
-		return err
+// moduleWriter writes little-endian values and keeps the first error.
+type moduleWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (mw *moduleWriter) write(v any) {
+	if mw.err == nil {
+		mw.err = binary.Write(mw.w, binary.LittleEndian, v)
 	}
+}
 
-	// num functions
-	if err := binary.Write(w, binary.LittleEndian, uint32(len(m.Functions))); err != nil {
-		return err
+func (mw *moduleWriter) bytes(b []byte) {
+	mw.write(uint32(len(b)))
+	if mw.err == nil {
+		_, mw.err = mw.w.Write(b)
 	}
+}
 
-	for _, fn := range m.Functions {
-		nameBytes := []byte(fn.Name)
-		if len(nameBytes) > 0xFFFF {
-			return fmt.Errorf("function name too long: %s", fn.Name)
+// lines writes a line table as runs of (count, line) pairs.
+func (mw *moduleWriter) lines(lines []int) {
+	type run struct{ count, line uint32 }
+	var runs []run
+	for _, line := range lines {
+		if n := len(runs); n > 0 && runs[n-1].line == uint32(line) {
+			runs[n-1].count++
+		} else {
+			runs = append(runs, run{1, uint32(line)})
 		}
-		// name len (uint16) + name bytes
-		if err := binary.Write(w, binary.LittleEndian, uint16(len(nameBytes))); err != nil {
-			return err
-		}
-		if _, err := w.Write(nameBytes); err != nil {
-			return err
+	}
+	mw.write(uint32(len(runs)))
+	for _, r := range runs {
+		mw.write(r.count)
+		mw.write(r.line)
+	}
+}
+
+// name writes a string with a uint16 length, as used for identifiers.
+func (mw *moduleWriter) name(s, what string) {
+	if len(s) > 0xFFFF {
+		if mw.err == nil {
+			mw.err = fmt.Errorf("%s name too long: %s", what, s)
 		}
+		return
+	}
+	mw.write(uint16(len(s)))
+	if mw.err == nil {
+		_, mw.err = mw.w.WriteString(s)
+	}
+}
+
+func WriteModule(w io.Writer, m *Module) error {
+	mw := &moduleWriter{w: bufio.NewWriter(w)}
+	mw.write(magicV4)
 
-		// num params, num locals
-		if err := binary.Write(w, binary.LittleEndian, uint32(fn.NumParams)); err != nil {
-			return err
+	mw.write(uint32(len(m.Functions)))
+	for _, fn := range m.Functions {
+		mw.name(fn.Name, "function")
+		mw.write(uint32(fn.NumParams))
+		mw.write(uint32(fn.Chunk.NumLocals))
+		var flags uint8
+		if fn.IsAsync {
+			flags |= fnAsync
 		}
-		if err := binary.Write(w, binary.LittleEndian, uint32(fn.Chunk.NumLocals)); err != nil {
-			return err
+		if fn.IsPublic {
+			flags |= fnPublic
 		}
+		mw.write(flags)
 
-		// consts
-		if err := binary.Write(w, binary.LittleEndian, uint32(len(fn.Chunk.Consts))); err != nil {
-			return err
+		mw.write(uint32(len(fn.Upvalues)))
+		for _, uv := range fn.Upvalues {
+			var isLocal uint8
+			if uv.IsLocal {
+				isLocal = 1
+			}
+			mw.write(isLocal)
+			mw.write(uint32(uv.Index))
 		}
+
+		mw.write(uint32(len(fn.Chunk.Consts)))
 		for _, c := range fn.Chunk.Consts {
-			if err := binary.Write(w, binary.LittleEndian, uint8(c.Kind)); err != nil {
-				return err
-			}
+			mw.write(uint8(c.Kind))
 			switch c.Kind {
 			case ConstInt:
-				if err := binary.Write(w, binary.LittleEndian, c.Int); err != nil {
-					return err
-				}
+				mw.write(c.Int)
+			case ConstFloat:
+				mw.write(math.Float64bits(c.Float))
 			case ConstBool:
-				var b byte
+				var b uint8
 				if c.Bool {
 					b = 1
 				}
-				if err := binary.Write(w, binary.LittleEndian, b); err != nil {
-					return err
-				}
+				mw.write(b)
 			case ConstString:
-				bs := []byte(c.String)
-				if err := binary.Write(w, binary.LittleEndian, uint32(len(bs))); err != nil {
-					return err
-				}
-				if _, err := w.Write(bs); err != nil {
-					return err
-				}
+				mw.bytes([]byte(c.String))
+			case ConstBytes:
+				mw.bytes(c.Bytes)
+			case ConstNone:
 			default:
 				return fmt.Errorf("unknown const kind %d", c.Kind)
 			}
 		}
 
-		// code
-		if err := binary.Write(w, binary.LittleEndian, uint32(len(fn.Chunk.Code))); err != nil {
-			return err
-		}
+		mw.write(uint32(len(fn.Chunk.Code)))
 		for _, inst := range fn.Chunk.Code {
-			if err := binary.Write(w, binary.LittleEndian, uint8(inst.Op)); err != nil {
-				return err
-			}
-			if err := binary.Write(w, binary.LittleEndian, int32(inst.A)); err != nil {
-				return err
-			}
-			if err := binary.Write(w, binary.LittleEndian, int32(inst.B)); err != nil {
-				return err
-			}
+			mw.write(uint8(inst.Op))
+			mw.write(int32(inst.A))
+			mw.write(int32(inst.B))
 		}
 	}
 
-	// struct types
-	if err := binary.Write(w, binary.LittleEndian, uint32(len(m.StructTypes))); err != nil {
-		return err
-	}
+	mw.write(uint32(len(m.StructTypes)))
 	for _, st := range m.StructTypes {
-		nameBytes := []byte(st.Name)
-		if len(nameBytes) > 0xFFFF {
-			return fmt.Errorf("struct name too long: %s", st.Name)
+		mw.name(st.Name, "struct")
+		mw.write(uint32(len(st.Fields)))
+		for _, f := range st.Fields {
+			mw.name(f.Name, "field")
 		}
-		if err := binary.Write(w, binary.LittleEndian, uint16(len(nameBytes))); err != nil {
-			return err
+		methods := make([]string, 0, len(st.Methods))
+		for name := range st.Methods {
+			methods = append(methods, name)
 		}
-		if _, err := w.Write(nameBytes); err != nil {
-			return err
+		sort.Strings(methods)
+		mw.write(uint32(len(methods)))
+		for _, name := range methods {
+			mw.name(name, "method")
+			mw.write(uint32(st.Methods[name]))
 		}
 	}
 
-	// main index
-	if err := binary.Write(w, binary.LittleEndian, int32(m.MainIndex)); err != nil {
-		return err
+	mw.write(uint32(len(m.Globals)))
+	for _, g := range m.Globals {
+		mw.name(g.Name, "global")
 	}
 
-	return nil
-}
+	mw.write(uint32(len(m.Builtins)))
+	for _, sym := range m.Builtins {
+		mw.name(sym, "builtin")
+	}
 
-func ReadModule(r io.Reader) (*Module, error) {
-	var hdr [4]byte
-	if _, err := io.ReadFull(r, hdr[:]); err != nil {
-		return nil, err
+	mw.write(uint32(len(m.MethodCalls)))
+	for _, name := range m.MethodCalls {
+		mw.name(name, "method")
 	}
-	if hdr != magicV1 && hdr != magicV2 {
-		return nil, fmt.Errorf("invalid magic header: %q", string(hdr[:]))
+
+	mw.write(int32(m.MainIndex))
+	mw.write(int32(m.InitIndex))
+
+	mw.write(debugSection)
+	for _, fn := range m.Functions {
+		mw.name(fn.File, "file")
+		mw.lines(fn.Chunk.Lines)
 	}
 
-	var numFuncs uint32
-	if err := binary.Read(r, binary.LittleEndian, &numFuncs); err != nil {
-		return nil, err
+	mw.write(localsSection)
+	for _, fn := range m.Functions {
+		mw.write(uint32(len(fn.Locals)))
+		for _, l := range fn.Locals {
+			mw.name(l.Name, "local")
+			mw.write(uint32(l.Slot))
+			mw.write(uint32(l.Start))
+			mw.write(uint32(l.End))
+		}
+		for _, uv := range fn.Upvalues {
+			mw.name(uv.Name, "upvalue")
+		}
 	}
 
-	mod := &Module{
-		Functions: make([]*Function, 0, numFuncs),
-		MainIndex: -1,
+	if mw.err != nil {
+		return mw.err
 	}
+	return mw.w.Flush()
+}
 
-	for i := uint32(0); i < numFuncs; i++ {
-		var nameLen uint16
-		if err := binary.Read(r, binary.LittleEndian, &nameLen); err != nil {
-			return nil, err
-		}
+// moduleReader reads little-endian values and keeps the first error.
+type moduleReader struct {
+	r   io.Reader
+	err error
+}
 
-		nameBytes := make([]byte, nameLen)
-		if _, err := io.ReadFull(r, nameBytes); err != nil {
-			return nil, err
+func (mr *moduleReader) read(v any) {
+	if mr.err == nil {
+		mr.err = binary.Read(mr.r, binary.LittleEndian, v)
+	}
+}
+
+func (mr *moduleReader) u32() uint32 {
+	var v uint32
+	mr.read(&v)
+	return v
+}
+
+func (mr *moduleReader) bytes() []byte {
+	n := mr.u32()
+	if mr.err != nil {
+		return nil
+	}
+	b := make([]byte, 0, min(n, 1<<20))
+	for remaining := n; remaining > 0 && mr.err == nil; {
+		chunk := make([]byte, min(remaining, 1<<20))
+		_, mr.err = io.ReadFull(mr.r, chunk)
+		b = append(b, chunk...)
+		remaining -= uint32(len(chunk))
+	}
+	return b
+}
+
+// lines reads a line table written by moduleWriter.lines for a chunk of
+// numInstr instructions.
+func (mr *moduleReader) lines(numInstr int) []int {
+	numRuns := mr.u32()
+	var lines []int
+	for i := uint32(0); i < numRuns && mr.err == nil; i++ {
+		count := mr.u32()
+		line := int(mr.u32())
+		if uint64(len(lines))+uint64(count) > uint64(numInstr) {
+			mr.err = fmt.Errorf("line table longer than code")
+			return nil
+		}
+		for j := uint32(0); j < count; j++ {
+			lines = append(lines, line)
 		}
-		name := string(nameBytes)
+	}
+	return lines
+}
 
-		var numParamsU32 uint32
-		if err := binary.Read(r, binary.LittleEndian, &numParamsU32); err != nil {
+func (mr *moduleReader) name() string {
+	var n uint16
+	mr.read(&n)
+	if mr.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, mr.err = io.ReadFull(mr.r, b)
+	return string(b)
+}
+
+// ReadModule reads a module written by WriteModule or by an earlier
+// release, and verifies it before returning it.
+func ReadModule(r io.Reader) (*Module, error) {
+	mod, err := readModule(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(mod); err != nil {
+		return nil, fmt.Errorf("invalid bytecode: %w", err)
+	}
+	return mod, nil
+}
+
+func readModule(r io.Reader) (*Module, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	switch hdr {
+	case magicV3, magicV4:
+		return readModuleV3(r, hdr == magicV4)
+	case magicV1, magicV2:
+		mod, err := readModuleLegacy(r, hdr)
+		if err != nil {
 			return nil, err
 		}
-		var numLocalsU32 uint32
-		if err := binary.Read(r, binary.LittleEndian, &numLocalsU32); err != nil {
+		if err := bindLegacyBuiltins(mod); err != nil {
 			return nil, err
 		}
+		return mod, nil
+	}
+	return nil, fmt.Errorf("invalid magic header: %q", string(hdr[:]))
+}
 
-		fn := &Function{
-			Name:      name,
-			NumParams: int(numParamsU32),
-			Chunk: Chunk{
-				NumLocals: int(numLocalsU32),
-			},
-		}
+// readModuleV3 reads AVC3 files, and AVC4 files when v4 is set.
+func readModuleV3(r io.Reader, v4 bool) (*Module, error) {
+	mr := &moduleReader{r: r}
+	mod := &Module{MainIndex: -1, InitIndex: -1}
 
-		// consts
-		var numConsts uint32
-		if err := binary.Read(r, binary.LittleEndian, &numConsts); err != nil {
-			return nil, err
+	numFuncs := mr.u32()
+	for i := uint32(0); i < numFuncs && mr.err == nil; i++ {
+		fn := &Function{Name: mr.name()}
+		fn.NumParams = int(mr.u32())
+		fn.Chunk.NumLocals = int(mr.u32())
+		var flags uint8
+		mr.read(&flags)
+		fn.IsAsync = flags&fnAsync != 0
+		fn.IsPublic = flags&fnPublic != 0
+
+		numUpvalues := mr.u32()
+		for j := uint32(0); j < numUpvalues && mr.err == nil; j++ {
+			var isLocal uint8
+			mr.read(&isLocal)
+			fn.Upvalues = append(fn.Upvalues, UpvalueInfo{IsLocal: isLocal != 0, Index: int(mr.u32())})
 		}
-		fn.Chunk.Consts = make([]Constant, numConsts)
-		for ci := uint32(0); ci < numConsts; ci++ {
-			var kindU8 uint8
-			if err := binary.Read(r, binary.LittleEndian, &kindU8); err != nil {
-				return nil, err
-			}
-			c := Constant{Kind: ConstKind(kindU8)}
+
+		numConsts := mr.u32()
+		for j := uint32(0); j < numConsts && mr.err == nil; j++ {
+			var kind uint8
+			mr.read(&kind)
+			c := Constant{Kind: ConstKind(kind)}
 			switch c.Kind {
 			case ConstInt:
-				if err := binary.Read(r, binary.LittleEndian, &c.Int); err != nil {
-					return nil, err
-				}
+				mr.read(&c.Int)
+			case ConstFloat:
+				var bits uint64
+				mr.read(&bits)
+				c.Float = math.Float64frombits(bits)
 			case ConstBool:
-				var b byte
-				if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
-					return nil, err
-				}
+				var b uint8
+				mr.read(&b)
 				c.Bool = b != 0
 			case ConstString:
-				var slen uint32
-				if err := binary.Read(r, binary.LittleEndian, &slen); err != nil {
-					return nil, err
-				}
-				sb := make([]byte, slen)
-				if _, err := io.ReadFull(r, sb); err != nil {
-					return nil, err
-				}
-				c.String = string(sb)
+				c.String = string(mr.bytes())
+			case ConstBytes:
+				c.Bytes = mr.bytes()
+			case ConstNone:
 			default:
 				return nil, fmt.Errorf("unknown const kind %d", c.Kind)
 			}
-			fn.Chunk.Consts[ci] = c
+			fn.Chunk.Consts = append(fn.Chunk.Consts, c)
+		}
+
+		numInstr := mr.u32()
+		for j := uint32(0); j < numInstr && mr.err == nil; j++ {
+			var op uint8
+			var a, b int32
+			mr.read(&op)
+			mr.read(&a)
+			mr.read(&b)
+			fn.Chunk.Code = append(fn.Chunk.Code, Instruction{Op: OpCode(op), A: int(a), B: int(b)})
 		}
+		mod.Functions = append(mod.Functions, fn)
+	}
 
-		// code
-		var numInstr uint32
-		if err := binary.Read(r, binary.LittleEndian, &numInstr); err != nil {
+	numStructs := mr.u32()
+	for i := uint32(0); i < numStructs && mr.err == nil; i++ {
+		st := StructTypeInfo{Name: mr.name()}
+		numFields := mr.u32()
+		for j := uint32(0); j < numFields && mr.err == nil; j++ {
+			st.Fields = append(st.Fields, types.Field{Name: mr.name()})
+		}
+		if v4 {
+			numMethods := mr.u32()
+			for j := uint32(0); j < numMethods && mr.err == nil; j++ {
+				if st.Methods == nil {
+					st.Methods = make(map[string]int)
+				}
+				name := mr.name()
+				st.Methods[name] = int(mr.u32())
+			}
+		}
+		mod.StructTypes = append(mod.StructTypes, st)
+	}
+
+	numGlobals := mr.u32()
+	for i := uint32(0); i < numGlobals && mr.err == nil; i++ {
+		mod.Globals = append(mod.Globals, GlobalInfo{Name: mr.name()})
+	}
+
+	numBuiltins := mr.u32()
+	for i := uint32(0); i < numBuiltins && mr.err == nil; i++ {
+		mod.Builtins = append(mod.Builtins, mr.name())
+	}
+
+	if v4 {
+		numCalls := mr.u32()
+		for i := uint32(0); i < numCalls && mr.err == nil; i++ {
+			mod.MethodCalls = append(mod.MethodCalls, mr.name())
+		}
+	}
+
+	var mainIdx, initIdx int32
+	mr.read(&mainIdx)
+	mr.read(&initIdx)
+	if mr.err != nil {
+		return nil, mr.err
+	}
+	mod.MainIndex = int(mainIdx)
+	mod.InitIndex = int(initIdx)
+
+	// Files written without debug info end here, and files written before
+	// local names were recorded end after the line tables.
+	for _, want := range [][4]byte{debugSection, localsSection} {
+		var section [4]byte
+		if _, err := io.ReadFull(r, section[:]); err == io.EOF {
+			return mod, nil
+		} else if err != nil {
 			return nil, err
 		}
-		fn.Chunk.Code = make([]Instruction, numInstr)
-		for pi := uint32(0); pi < numInstr; pi++ {
-			var opU8 uint8
-			if err := binary.Read(r, binary.LittleEndian, &opU8); err != nil {
-				return nil, err
+		if section != want {
+			return nil, fmt.Errorf("unknown section %q", string(section[:]))
+		}
+		for _, fn := range mod.Functions {
+			if section == debugSection {
+				fn.File = mr.name()
+				fn.Chunk.Lines = mr.lines(len(fn.Chunk.Code))
+				continue
 			}
-			var a, b int32
-			if err := binary.Read(r, binary.LittleEndian, &a); err != nil {
-				return nil, err
+			numLocals := mr.u32()
+			for j := uint32(0); j < numLocals && mr.err == nil; j++ {
+				l := LocalInfo{Name: mr.name()}
+				l.Slot = int(mr.u32())
+				l.Start = int(mr.u32())
+				l.End = int(mr.u32())
+				fn.Locals = append(fn.Locals, l)
 			}
-			if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
-				return nil, err
+			for j := range fn.Upvalues {
+				fn.Upvalues[j].Name = mr.name()
 			}
-			fn.Chunk.Code[pi] = Instruction{
-				Op: OpCode(opU8),
-				A:  int(a),
-				B:  int(b),
+		}
+		if mr.err != nil {
+			return nil, mr.err
+		}
+	}
+	return mod, nil
+}
+
+// readModuleLegacy reads AVC1 and AVC2 files, whose builtin operands are
+// numeric builtin IDs.
+func readModuleLegacy(r io.Reader, hdr [4]byte) (*Module, error) {
+	mr := &moduleReader{r: r}
+	mod := &Module{MainIndex: -1, InitIndex: -1}
+
+	numFuncs := mr.u32()
+	for i := uint32(0); i < numFuncs && mr.err == nil; i++ {
+		fn := &Function{Name: mr.name()}
+		fn.NumParams = int(mr.u32())
+		fn.Chunk.NumLocals = int(mr.u32())
+
+		numConsts := mr.u32()
+		for j := uint32(0); j < numConsts && mr.err == nil; j++ {
+			var kind uint8
+			mr.read(&kind)
+			c := Constant{Kind: ConstKind(kind)}
+			switch c.Kind {
+			case ConstInt:
+				mr.read(&c.Int)
+			case ConstBool:
+				var b uint8
+				mr.read(&b)
+				c.Bool = b != 0
+			case ConstString:
+				c.String = string(mr.bytes())
+			default:
+				return nil, fmt.Errorf("unknown const kind %d", c.Kind)
 			}
+			fn.Chunk.Consts = append(fn.Chunk.Consts, c)
 		}
 
+		numInstr := mr.u32()
+		for j := uint32(0); j < numInstr && mr.err == nil; j++ {
+			var op uint8
+			var a, b int32
+			mr.read(&op)
+			mr.read(&a)
+			mr.read(&b)
+			fn.Chunk.Code = append(fn.Chunk.Code, Instruction{Op: OpCode(op), A: int(a), B: int(b)})
+		}
 		mod.Functions = append(mod.Functions, fn)
 	}
 
 	if hdr == magicV2 {
-		var numStructs uint32
-		if err := binary.Read(r, binary.LittleEndian, &numStructs); err != nil {
-			return nil, err
-		}
-		mod.StructTypes = make([]StructTypeInfo, numStructs)
-		for i := uint32(0); i < numStructs; i++ {
-			var nameLen uint16
-			if err := binary.Read(r, binary.LittleEndian, &nameLen); err != nil {
-				return nil, err
-			}
-			nameBytes := make([]byte, nameLen)
-			if _, err := io.ReadFull(r, nameBytes); err != nil {
-				return nil, err
-			}
-			mod.StructTypes[i] = StructTypeInfo{Name: string(nameBytes)}
+		numStructs := mr.u32()
+		for i := uint32(0); i < numStructs && mr.err == nil; i++ {
+			mod.StructTypes = append(mod.StructTypes, StructTypeInfo{Name: mr.name()})
 		}
 	}
 
 	var mainIdx int32
-	if err := binary.Read(r, binary.LittleEndian, &mainIdx); err != nil {
-		return nil, err
+	mr.read(&mainIdx)
+	if mr.err != nil {
+		return nil, mr.err
 	}
 	mod.MainIndex = int(mainIdx)
-
 	return mod, nil
 }
+
+// bindLegacyBuiltins rewrites the numeric builtin IDs of an AVC1/AVC2 module
+// into indexes of a symbol table, using the IDs of the default registry.
+func bindLegacyBuiltins(mod *Module) error {
+	index := make(map[string]int)
+	for _, fn := range mod.Functions {
+		for i, inst := range fn.Chunk.Code {
+			if inst.Op != OpCallBuiltin && inst.Op != OpCallBuiltinAsync {
+				continue
+			}
+			b := builtins.LookupByID(builtins.ID(inst.A))
+			if b == nil {
+				return fmt.Errorf("%s: unknown builtin id %d", fn.Name, inst.A)
+			}
+			sym := b.Meta.Symbol()
+			idx, ok := index[sym]
+			if !ok {
+				idx = len(mod.Builtins)
+				mod.Builtins = append(mod.Builtins, sym)
+				index[sym] = idx
+			}
+			fn.Chunk.Code[i].A = idx
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package ir
+
+import (
+	"errors"
+	"fmt"
+)
+
+// VerifyError describes one problem found by Verify.
+type VerifyError struct {
+	Function string // qualified function name; empty for module-level problems
+	IP       int    // instruction index, or -1 for function-level problems
+	Msg      string
+}
+
+func (e *VerifyError) Error() string {
+	switch {
+	case e.Function == "":
+		return e.Msg
+	case e.IP < 0:
+		return fmt.Sprintf("%s: %s", e.Function, e.Msg)
+	default:
+		return fmt.Sprintf("%s@%d: %s", e.Function, e.IP, e.Msg)
+	}
+}
+
+// Verify checks that a module is safe to hand to the VM: every operand is in
+// range, jumps land inside their function, no path falls off the end of the
+// code, and the operand stack has the same height whenever control flow
+// reaches an instruction, never dropping below the function's locals.
+// ReadModule runs it on every module it loads, so bytecode from an untrusted
+// source cannot crash or corrupt the VM.
+//
+// The result joins one *VerifyError per problem; checking a function stops
+// at its first stack problem to avoid cascades.
+func Verify(mod *Module) error {
+	v := &verifier{mod: mod}
+	v.module()
+	for _, fn := range mod.Functions {
+		if fn == nil {
+			v.errorf(nil, -1, "nil function")
+			continue
+		}
+		v.function(fn)
+	}
+	return errors.Join(v.errs...)
+}
+
+// maxLocals bounds the local slots of a function, which the VM allocates on
+// every call.
+const maxLocals = 1 << 16
+
+type verifier struct {
+	mod  *Module
+	errs []error
+}
+
+func (v *verifier) errorf(fn *Function, ip int, format string, args ...any) {
+	e := &VerifyError{IP: ip, Msg: fmt.Sprintf(format, args...)}
+	if fn != nil {
+		e.Function = fn.Name
+	}
+	v.errs = append(v.errs, e)
+}
+
+func (v *verifier) module() {
+	n := len(v.mod.Functions)
+	if v.mod.MainIndex < -1 || v.mod.MainIndex >= n {
+		v.errorf(nil, -1, "main index %d out of range (%d functions)", v.mod.MainIndex, n)
+	}
+	if v.mod.InitIndex < -1 || v.mod.InitIndex >= n {
+		v.errorf(nil, -1, "init index %d out of range (%d functions)", v.mod.InitIndex, n)
+	}
+	for i, sym := range v.mod.Builtins {
+		if sym == "" {
+			v.errorf(nil, -1, "builtin %d has an empty name", i)
+		}
+	}
+	for i, name := range v.mod.MethodCalls {
+		if name == "" {
+			v.errorf(nil, -1, "method call %d has an empty name", i)
+		}
+	}
+	for _, st := range v.mod.StructTypes {
+		for name, idx := range st.Methods {
+			if idx < 0 || idx >= n {
+				v.errorf(nil, -1, "method %s.%s: function %d out of range (%d functions)", st.Name, name, idx, n)
+			} else if fn := v.mod.Functions[idx]; fn != nil && fn.NumParams < 1 {
+				v.errorf(nil, -1, "method %s.%s: %s takes no receiver", st.Name, name, fn.Name)
+			}
+		}
+	}
+}
+
+func (v *verifier) function(fn *Function) {
+	if fn.NumParams < 0 || fn.Chunk.NumLocals < fn.NumParams || fn.Chunk.NumLocals > maxLocals {
+		v.errorf(fn, -1, "invalid frame: %d params, %d locals", fn.NumParams, fn.Chunk.NumLocals)
+		return
+	}
+	for i, c := range fn.Chunk.Consts {
+		if c.Kind < ConstInt || c.Kind > ConstNone {
+			v.errorf(fn, -1, "constant %d has unknown kind %d", i, c.Kind)
+		}
+	}
+	if len(fn.Chunk.Code) == 0 {
+		v.errorf(fn, -1, "empty code")
+		return
+	}
+	if fn.Chunk.Lines != nil && len(fn.Chunk.Lines) != len(fn.Chunk.Code) {
+		v.errorf(fn, -1, "line table has %d entries for %d instructions", len(fn.Chunk.Lines), len(fn.Chunk.Code))
+	}
+	for _, l := range fn.Locals {
+		if l.Slot < 0 || l.Slot >= fn.Chunk.NumLocals || l.Start < 0 || l.Start > l.End || l.End > len(fn.Chunk.Code) {
+			v.errorf(fn, -1, "local %q: slot %d, instructions [%d, %d) out of range", l.Name, l.Slot, l.Start, l.End)
+		}
+	}
+	ok := true
+	for ip, inst := range fn.Chunk.Code {
+		if err := v.operands(fn, inst); err != "" {
+			v.errorf(fn, ip, "%s: %s", inst.Op, err)
+			ok = false
+		}
+	}
+	if ok {
+		v.stack(fn)
+	}
+}
+
+// operands checks the operands of one instruction against the module and
+// its function, returning a description of the problem if any.
+func (v *verifier) operands(fn *Function, inst Instruction) string {
+	chunk := &fn.Chunk
+	inRange := func(what string, idx, n int) string {
+		if idx < 0 || idx >= n {
+			return fmt.Sprintf("%s %d out of range (%d)", what, idx, n)
+		}
+		return ""
+	}
+	count := func(what string, n int) string {
+		if n < 0 {
+			return fmt.Sprintf("negative %s %d", what, n)
+		}
+		return ""
+	}
+	switch inst.Op {
+	case OpHalt, OpPop, OpAdd, OpSub, OpMul, OpDiv, OpMod, OpNegate,
+		OpEq, OpNeq, OpLt, OpLte, OpGt, OpGte,
+		OpIndex, OpMakeSome, OpStringify, OpConcatString,
+		OpEndTry, OpThrow, OpAwait:
+		return ""
+	case OpConst:
+		return inRange("constant", inst.A, len(chunk.Consts))
+	case OpLoadLocal, OpStoreLocal:
+		return inRange("local", inst.A, chunk.NumLocals)
+	case OpJump, OpJumpIfFalse, OpJumpIfNone, OpBeginTry:
+		return inRange("target", inst.A, len(chunk.Code))
+	case OpCall, OpSpawn:
+		if err := inRange("function", inst.A, len(v.mod.Functions)); err != "" {
+			return err
+		}
+		if callee := v.mod.Functions[inst.A]; callee != nil && inst.B != callee.NumParams {
+			return fmt.Sprintf("%s expects %d arguments, got %d", callee.Name, callee.NumParams, inst.B)
+		}
+		return ""
+	case OpCallValue:
+		if inst.B != 0 && inst.B != 1 {
+			return fmt.Sprintf("invalid spawn flag %d", inst.B)
+		}
+		return count("argument count", inst.A)
+	case OpCallBuiltin, OpCallBuiltinAsync:
+		if err := inRange("builtin", inst.A, len(v.mod.Builtins)); err != "" {
+			return err
+		}
+		return count("argument count", inst.B)
+	case OpCallMethod:
+		if err := inRange("method call", inst.A, len(v.mod.MethodCalls)); err != "" {
+			return err
+		}
+		if inst.B < 1 {
+			return fmt.Sprintf("%d arguments, want the receiver and its arguments", inst.B)
+		}
+		return ""
+	case OpPushDefer:
+		return count("argument count", inst.A)
+	case OpReturn:
+		if inst.B != 0 && inst.B != 1 {
+			return fmt.Sprintf("invalid result flag %d", inst.B)
+		}
+		return ""
+	case OpMakeList, OpMakeDict, OpMakeSet:
+		return count("element count", inst.A)
+	case OpMakeStruct:
+		if err := inRange("struct type", inst.A, len(v.mod.StructTypes)); err != "" {
+			return err
+		}
+		// Modules from AVC1/AVC2 files carry no field names.
+		if fields := v.mod.StructTypes[inst.A].Fields; len(fields) > 0 && inst.B != len(fields) {
+			return fmt.Sprintf("%d fields, %s has %d", inst.B, v.mod.StructTypes[inst.A].Name, len(fields))
+		}
+		return count("field count", inst.B)
+	case OpLoadField, OpStoreField:
+		return count("field index", inst.A)
+	case OpIsStructType:
+		return inRange("struct type", inst.A, len(v.mod.StructTypes))
+	case OpClosure:
+		if err := inRange("function", inst.A, len(v.mod.Functions)); err != "" {
+			return err
+		}
+		callee := v.mod.Functions[inst.A]
+		if callee == nil {
+			return ""
+		}
+		if inst.B < 0 || inst.B > len(callee.Upvalues) {
+			return fmt.Sprintf("%d upvalues, %s has %d", inst.B, callee.Name, len(callee.Upvalues))
+		}
+		for i, uv := range callee.Upvalues[:inst.B] {
+			n := len(fn.Upvalues)
+			if uv.IsLocal {
+				n = chunk.NumLocals
+			}
+			if uv.Index < 0 || uv.Index >= n {
+				return fmt.Sprintf("upvalue %d of %s: index %d out of range (%d)", i, callee.Name, uv.Index, n)
+			}
+		}
+		return ""
+	case OpLoadUpvalue, OpStoreUpvalue:
+		return inRange("upvalue", inst.A, len(fn.Upvalues))
+	case OpSetFunc:
+		return inRange("function", inst.A, len(v.mod.Functions))
+	case OpLoadGlobal, OpStoreGlobal:
+		return inRange("global", inst.A, len(v.mod.Globals))
+	case OpAddLocalConst:
+		if err := inRange("local", inst.A, chunk.NumLocals); err != "" {
+			return err
+		}
+		return inRange("constant", inst.B, len(chunk.Consts))
+	case OpCmpJumpIfFalse:
+		switch OpCode(inst.B) {
+		case OpLt, OpLte, OpGt, OpGte, OpEq, OpNeq:
+		default:
+			return fmt.Sprintf("invalid comparison %d", inst.B)
+		}
+		return inRange("target", inst.A, len(chunk.Code))
+	default:
+		return "unknown opcode"
+	}
+}
+
+// stackEffect returns how many values an instruction pops and pushes. Its
+// operands must have been checked.
+func (v *verifier) stackEffect(inst Instruction) (pops, pushes int) {
+	switch inst.Op {
+	case OpConst, OpLoadLocal, OpLoadUpvalue, OpLoadGlobal, OpAddLocalConst:
+		return 0, 1
+	case OpStoreLocal, OpStoreUpvalue, OpStoreGlobal, OpJumpIfNone:
+		return 1, 1
+	case OpPop, OpJumpIfFalse, OpThrow, OpSetFunc:
+		return 1, 0
+	case OpCmpJumpIfFalse:
+		return 2, 0
+	case OpAdd, OpSub, OpMul, OpDiv, OpMod, OpEq, OpNeq, OpLt, OpLte, OpGt, OpGte,
+		OpIndex, OpConcatString, OpStoreField:
+		return 2, 1
+	case OpNegate, OpMakeSome, OpStringify, OpLoadField, OpAwait:
+		return 1, 1
+	case OpIsStructType:
+		return 1, 2
+	case OpCall, OpSpawn, OpCallBuiltin, OpCallBuiltinAsync, OpCallMethod, OpMakeStruct:
+		return inst.B, 1
+	case OpCallValue:
+		return inst.A + 1, 1
+	case OpPushDefer:
+		return inst.A + 1, 0
+	case OpReturn:
+		return inst.B, 0
+	case OpMakeList, OpMakeSet:
+		return inst.A, 1
+	case OpMakeDict:
+		return 2 * inst.A, 1
+	case OpClosure:
+		callee := v.mod.Functions[inst.A]
+		if callee != nil {
+			for _, uv := range callee.Upvalues[:inst.B] {
+				if !uv.IsLocal {
+					pops++
+				}
+			}
+		}
+		return pops, 1
+	default: // OpHalt, OpJump, OpBeginTry, OpEndTry
+		return 0, 0
+	}
+}
+
+// stack checks that every instruction of fn is reached with one operand
+// stack height, not counting locals, and that no instruction underflows.
+func (v *verifier) stack(fn *Function) {
+	code := fn.Chunk.Code
+	heights := make([]int, len(code))
+	for i := range heights {
+		heights[i] = -1
+	}
+	work := []int{0}
+	heights[0] = 0
+	// reach records that control flow arrives at target with height h.
+	reach := func(from, target, h int) bool {
+		if target >= len(code) {
+			v.errorf(fn, from, "control flow falls off the end of the code")
+			return false
+		}
+		switch heights[target] {
+		case -1:
+			heights[target] = h
+			work = append(work, target)
+		case h:
+		default:
+			v.errorf(fn, target, "stack height %d from instruction %d, %d from another path", h, from, heights[target])
+			return false
+		}
+		return true
+	}
+	for len(work) > 0 {
+		ip := work[len(work)-1]
+		work = work[:len(work)-1]
+		inst := code[ip]
+		h := heights[ip]
+		pops, pushes := v.stackEffect(inst)
+		if h < pops {
+			v.errorf(fn, ip, "%s pops %d values, stack has %d", inst.Op, pops, h)
+			return
+		}
+		next := h - pops + pushes
+		var ok bool
+		switch inst.Op {
+		case OpHalt, OpReturn, OpThrow:
+			continue
+		case OpJump:
+			ok = reach(ip, inst.A, next)
+		case OpJumpIfFalse, OpJumpIfNone, OpCmpJumpIfFalse:
+			ok = reach(ip, inst.A, next) && reach(ip, ip+1, next)
+		case OpBeginTry:
+			// The handler starts with the thrown value on the stack.
+			ok = reach(ip, inst.A, h+1) && reach(ip, ip+1, next)
+		default:
+			ok = reach(ip, ip+1, next)
+		}
+		if !ok {
+			return
+		}
+	}
+}
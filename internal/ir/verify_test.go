@@ -0,0 +1,172 @@
+package ir_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"avenir/internal/ir"
+)
+
+// verifyModule returns a small valid module: main.main calls main.add(1, 2)
+// inside a try block and returns the result.
+func verifyModule() *ir.Module {
+	add := &ir.Function{Name: "main.add", NumParams: 2}
+	add.Chunk.NumLocals = 2
+	add.Chunk.Emit(ir.OpLoadLocal, 0, 0)
+	add.Chunk.Emit(ir.OpLoadLocal, 1, 0)
+	add.Chunk.Emit(ir.OpAdd, 0, 0)
+	add.Chunk.Emit(ir.OpReturn, 0, 1)
+
+	main := &ir.Function{Name: "main.main"}
+	main.Chunk.NumLocals = 1
+	one := main.Chunk.AddConstInt(1)
+	two := main.Chunk.AddConstInt(2)
+	main.Chunk.Emit(ir.OpBeginTry, 7, 0)   // 0
+	main.Chunk.Emit(ir.OpConst, one, 0)    // 1
+	main.Chunk.Emit(ir.OpConst, two, 0)    // 2
+	main.Chunk.Emit(ir.OpCall, 0, 2)       // 3
+	main.Chunk.Emit(ir.OpStoreLocal, 0, 0) // 4
+	main.Chunk.Emit(ir.OpEndTry, 0, 0)     // 5
+	main.Chunk.Emit(ir.OpJump, 8, 0)       // 6
+	main.Chunk.Emit(ir.OpStoreLocal, 0, 0) // 7: handler
+	main.Chunk.Emit(ir.OpPop, 0, 0)        // 8
+	main.Chunk.Emit(ir.OpLoadLocal, 0, 0)  // 9
+	main.Chunk.Emit(ir.OpReturn, 0, 1)     // 10
+
+	return &ir.Module{
+		Functions: []*ir.Function{add, main},
+		MainIndex: 1,
+		InitIndex: -1,
+	}
+}
+
+func TestVerify_AcceptsValidModules(t *testing.T) {
+	if err := ir.Verify(verifyModule()); err != nil {
+		t.Fatalf("hand-written module: %v", err)
+	}
+	// compileWorldWithStd verifies what it compiles.
+	compileWorldWithStd(t, `pckg main;
+
+import std.json;
+
+fun main() | void {
+    var xs | list<int> = [1, 2, 3];
+    for (x in xs) {
+        try {
+            print(json.stringify({"x": x}));
+        } catch (e | error) {
+            print(errorMessage(e));
+        }
+    }
+    var f | fun(int) | int = fun(n | int) | int { return n + xs[0]; };
+    print(f(1));
+}
+`)
+}
+
+func TestVerify_RejectsInvalidModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(m *ir.Module)
+		want    string
+	}{
+		{"local out of range", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[9].A = 5
+		}, "main.main@9: LoadLocal: local 5 out of range"},
+		{"jump out of range", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[6].A = 100
+		}, "Jump: target 100 out of range"},
+		{"function index", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[3].A = 9
+		}, "Call: function 9 out of range"},
+		{"argument count", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[3].B = 1
+		}, "main.add expects 2 arguments, got 1"},
+		{"constant index", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[1].A = -1
+		}, "Const: constant -1 out of range"},
+		{"constant kind", func(m *ir.Module) {
+			m.Functions[1].Chunk.Consts[0].Kind = 42
+		}, "constant 0 has unknown kind 42"},
+		{"builtin index", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[2] = ir.Instruction{Op: ir.OpCallBuiltin, A: 0, B: 2}
+		}, "CallBuiltin: builtin 0 out of range"},
+		{"struct type", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[2] = ir.Instruction{Op: ir.OpMakeStruct, A: 3, B: 2}
+		}, "MakeStruct: struct type 3 out of range"},
+		{"upvalue", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[0] = ir.Instruction{Op: ir.OpLoadUpvalue, A: 0}
+		}, "LoadUpvalue: upvalue 0 out of range"},
+		{"closure upvalue descriptor", func(m *ir.Module) {
+			m.Functions[0].Upvalues = []ir.UpvalueInfo{{IsLocal: true, Index: 3}}
+			m.Functions[1].Chunk.Code[9] = ir.Instruction{Op: ir.OpClosure, A: 0, B: 1}
+		}, "upvalue 0 of main.add: index 3 out of range (1)"},
+		{"superinstruction constant", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[9] = ir.Instruction{Op: ir.OpAddLocalConst, A: 0, B: 7}
+		}, "AddLocalConst: constant 7 out of range"},
+		{"superinstruction comparison", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[6] = ir.Instruction{Op: ir.OpCmpJumpIfFalse, A: 9, B: int(ir.OpAdd)}
+		}, "CmpJumpIfFalse: invalid comparison"},
+		{"method call", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[2] = ir.Instruction{Op: ir.OpCallMethod, A: 0, B: 2}
+		}, "CallMethod: method call 0 out of range"},
+		{"struct method", func(m *ir.Module) {
+			m.StructTypes = []ir.StructTypeInfo{{Name: "Point", Methods: map[string]int{"norm": 4}}}
+		}, "method Point.norm: function 4 out of range"},
+		{"unknown opcode", func(m *ir.Module) {
+			m.Functions[1].Chunk.Code[8].Op = 200
+		}, "Op(200): unknown opcode"},
+		{"stack underflow", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[1] = ir.Instruction{Op: ir.OpPop}
+		}, "main.add@2: Add pops 2 values, stack has 0"},
+		{"inconsistent stack height", func(m *ir.Module) {
+			// The handler path skips the pop at 8 and reaches 9 with the
+			// thrown value still on the stack.
+			m.Functions[1].Chunk.Code[7] = ir.Instruction{Op: ir.OpJump, A: 9}
+		}, "main.main@9: stack height"},
+		{"falls off the end", func(m *ir.Module) {
+			m.Functions[0].Chunk.Code[3] = ir.Instruction{Op: ir.OpPop}
+		}, "control flow falls off the end"},
+		{"main index", func(m *ir.Module) {
+			m.MainIndex = 2
+		}, "main index 2 out of range"},
+		{"frame", func(m *ir.Module) {
+			m.Functions[0].Chunk.NumLocals = 1
+		}, "main.add: invalid frame"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod := verifyModule()
+			tt.corrupt(mod)
+			err := ir.Verify(mod)
+			if err == nil {
+				t.Fatal("expected verification error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadModule_VerifiesBytecode(t *testing.T) {
+	mod := verifyModule()
+	var buf bytes.Buffer
+	if err := ir.WriteModule(&buf, mod); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ir.ReadModule(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("read valid module: %v", err)
+	}
+
+	mod.Functions[1].Chunk.Code[6].A = -3
+	buf.Reset()
+	if err := ir.WriteModule(&buf, mod); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ir.ReadModule(&buf)
+	if err == nil || !strings.Contains(err.Error(), "invalid bytecode") {
+		t.Fatalf("expected invalid bytecode error, got %v", err)
+	}
+}
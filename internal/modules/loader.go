@@ -2,7 +2,9 @@ package modules
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -27,16 +29,39 @@ type World struct {
 
 // LoadWorld loads the entry file and all its dependencies recursively.
 func LoadWorld(entryFile string) (*World, []error) {
-	w := &World{
-		Modules: make(map[string]*ModuleAST),
-	}
-
 	// Determine project root (directory of entry file)
 	entryAbs, err := filepath.Abs(entryFile)
 	if err != nil {
 		return nil, []error{fmt.Errorf("cannot resolve entry file path: %v", err)}
 	}
-	projectRoot := filepath.Dir(entryAbs)
+	l := &loader{files: hostFS{}, projectRoot: filepath.Dir(entryAbs), repoFallback: true}
+	return l.load(entryFile)
+}
+
+// LoadWorldFS is LoadWorld for sources in fsys, e.g. an embed.FS. entry and
+// module paths are slash-separated and relative to the root of fsys, and std
+// modules are looked up in its "std" directory.
+func LoadWorldFS(fsys fs.FS, entry string) (*World, []error) {
+	if !fs.ValidPath(entry) {
+		return nil, []error{fmt.Errorf("invalid entry path %q", entry)}
+	}
+	l := &loader{files: ioFS{fsys}, projectRoot: "."}
+	return l.load(entry)
+}
+
+// loader resolves and parses modules from one file system.
+type loader struct {
+	files       fileSystem
+	projectRoot string
+	// repoFallback also looks for std modules in the repository root above
+	// the project.
+	repoFallback bool
+}
+
+func (l *loader) load(entryFile string) (*World, []error) {
+	w := &World{
+		Modules: make(map[string]*ModuleAST),
+	}
 
 	// Track visited modules for cycle detection
 	visited := make(map[string]bool)
@@ -44,7 +69,7 @@ func LoadWorld(entryFile string) (*World, []error) {
 	var errors []error
 
 	// Load entry module
-	entryMod, errs := loadModule(entryFile, projectRoot, visited, visiting, w)
+	entryMod, errs := l.loadModule(entryFile, visited, visiting, w)
 	if len(errs) > 0 {
 		errors = append(errors, errs...)
 	}
@@ -57,8 +82,8 @@ func LoadWorld(entryFile string) (*World, []error) {
 }
 
 // loadModule loads a single module and recursively loads its dependencies.
-func loadModule(filePath string, projectRoot string, visited map[string]bool, visiting map[string]bool, world *World) (*ModuleAST, []error) {
-	moduleFiles, err := moduleFilesForEntry(filePath)
+func (l *loader) loadModule(filePath string, visited map[string]bool, visiting map[string]bool, world *World) (*ModuleAST, []error) {
+	moduleFiles, err := l.moduleFilesForEntry(filePath)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -68,14 +93,14 @@ func loadModule(filePath string, projectRoot string, visited map[string]bool, vi
 	var moduleName string
 
 	for _, path := range moduleFiles {
-		content, err := os.ReadFile(path)
+		content, err := l.files.ReadFile(path)
 		if err != nil {
 			parseErrors = append(parseErrors, fmt.Errorf("cannot read file %s: %v", path, err))
 			continue
 		}
 
-		l := lexer.New(string(content))
-		p := parser.New(l)
+		lex := lexer.New(string(content))
+		p := parser.New(lex)
 		prog := p.ParseProgram()
 
 		if errs := p.Errors(); len(errs) > 0 {
@@ -97,7 +122,7 @@ func loadModule(filePath string, projectRoot string, visited map[string]bool, vi
 			continue
 		}
 
-		if err := validateFileStructMapping(path, prog); err != nil {
+		if err := validateFileStructMapping(l.files.Base(path), path, prog); err != nil {
 			parseErrors = append(parseErrors, err)
 			continue
 		}
@@ -144,14 +169,14 @@ func loadModule(filePath string, projectRoot string, visited map[string]bool, vi
 		importFQN := strings.Join(imp.Path, ".")
 
 		// Find the file for this import
-		importFile, err := findModuleFile(importFQN, projectRoot)
+		importFile, err := l.findModuleFile(importFQN)
 		if err != nil {
 			allErrors = append(allErrors, fmt.Errorf("%s:%d:%d: %v", filePath, imp.ImportPos.Line, imp.ImportPos.Column, err))
 			continue
 		}
 
 		// Recursively load the imported module
-		_, errs := loadModule(importFile, projectRoot, visited, visiting, world)
+		_, errs := l.loadModule(importFile, visited, visiting, world)
 		if len(errs) > 0 {
 			allErrors = append(allErrors, errs...)
 		}
@@ -166,7 +191,8 @@ func loadModule(filePath string, projectRoot string, visited map[string]bool, vi
 
 // findModuleFile locates the .av file for a given module FQN.
 // Supports both flat files (module.av) and folder-based imports (module/module.av).
-func findModuleFile(moduleFQN string, projectRoot string) (string, error) {
+func (l *loader) findModuleFile(moduleFQN string) (string, error) {
+	files := l.files
 	// Check if it's a std module
 	if strings.HasPrefix(moduleFQN, "std.") {
 		// std.io -> try std/io/io.av (folder) then std/io.av (flat)
@@ -175,30 +201,31 @@ func findModuleFile(moduleFQN string, projectRoot string) (string, error) {
 		lastPart := parts[len(parts)-1]
 
 		// Try folder-based: std/io/io.av
-		folderPath := filepath.Join(projectRoot, "std", filepath.Join(parts...))
-		folderFile := filepath.Join(folderPath, lastPart+".av")
-		if _, err := os.Stat(folderFile); err == nil {
+		folderPath := files.Join(l.projectRoot, "std", files.Join(parts...))
+		folderFile := files.Join(folderPath, lastPart+".av")
+		if l.exists(folderFile) {
 			return folderFile, nil
 		}
 
 		// Try flat file: std/io.av
-		flatFile := filepath.Join(projectRoot, "std", path+".av")
-		if _, err := os.Stat(flatFile); err == nil {
+		flatFile := files.Join(l.projectRoot, "std", path+".av")
+		if l.exists(flatFile) {
 			return flatFile, nil
 		}
 
 		// Fallback: try repo root std/
-		repoRoot := findRepoRoot(projectRoot)
-		if repoRoot != "" {
-			// Try folder-based in repo root
-			folderFile = filepath.Join(repoRoot, "std", filepath.Join(parts...), lastPart+".av")
-			if _, err := os.Stat(folderFile); err == nil {
-				return folderFile, nil
-			}
-			// Try flat file in repo root
-			flatFile = filepath.Join(repoRoot, "std", path+".av")
-			if _, err := os.Stat(flatFile); err == nil {
-				return flatFile, nil
+		if l.repoFallback {
+			if repoRoot := findRepoRoot(l.projectRoot); repoRoot != "" {
+				// Try folder-based in repo root
+				folderFile = files.Join(repoRoot, "std", files.Join(parts...), lastPart+".av")
+				if l.exists(folderFile) {
+					return folderFile, nil
+				}
+				// Try flat file in repo root
+				flatFile = files.Join(repoRoot, "std", path+".av")
+				if l.exists(flatFile) {
+					return flatFile, nil
+				}
 			}
 		}
 		return "", fmt.Errorf("cannot find module %q (looked for folder %s/%s.av and file %s)", moduleFQN, folderPath, lastPart, flatFile)
@@ -209,26 +236,31 @@ func findModuleFile(moduleFQN string, projectRoot string) (string, error) {
 	lastPart := parts[len(parts)-1]
 
 	// Try folder-based: app/utils/utils.av
-	folderPath := filepath.Join(projectRoot, filepath.Join(parts...))
-	folderFile := filepath.Join(folderPath, lastPart+".av")
-	if _, err := os.Stat(folderFile); err == nil {
+	folderPath := files.Join(l.projectRoot, files.Join(parts...))
+	folderFile := files.Join(folderPath, lastPart+".av")
+	if l.exists(folderFile) {
 		return folderFile, nil
 	}
 
 	// Try flat file: app/utils.av
-	flatFile := filepath.Join(projectRoot, filepath.Join(parts...)+".av")
-	if _, err := os.Stat(flatFile); err == nil {
+	flatFile := files.Join(l.projectRoot, files.Join(parts...)+".av")
+	if l.exists(flatFile) {
 		return flatFile, nil
 	}
 
 	// Check if folder exists but file is missing
-	if info, err := os.Stat(folderPath); err == nil && info.IsDir() {
+	if info, err := files.Stat(folderPath); err == nil && info.IsDir() {
 		return "", fmt.Errorf("folder %q exists but does not contain required file %q. Expected: %s", folderPath, lastPart+".av", folderFile)
 	}
 
 	return "", fmt.Errorf("cannot find module %q (looked for folder %s/%s.av and file %s)", moduleFQN, folderPath, lastPart, flatFile)
 }
 
+func (l *loader) exists(path string) bool {
+	_, err := l.files.Stat(path)
+	return err == nil
+}
+
 // findRepoRoot tries to find the repository root by looking for go.mod or .git
 func findRepoRoot(startDir string) string {
 	dir := startDir
@@ -248,29 +280,30 @@ func findRepoRoot(startDir string) string {
 	return ""
 }
 
-func moduleFilesForEntry(filePath string) ([]string, error) {
-	dir := filepath.Dir(filePath)
-	base := strings.TrimSuffix(filepath.Base(filePath), ".av")
-	if filepath.Base(dir) != base {
+func (l *loader) moduleFilesForEntry(filePath string) ([]string, error) {
+	files := l.files
+	dir := files.Dir(filePath)
+	base := strings.TrimSuffix(files.Base(filePath), ".av")
+	if files.Base(dir) != base {
 		return []string{filePath}, nil
 	}
 
-	entries, err := os.ReadDir(dir)
+	entries, err := files.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read module directory %s: %v", dir, err)
 	}
 
-	var files []string
+	var paths []string
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 		if strings.HasSuffix(entry.Name(), ".av") {
-			files = append(files, filepath.Join(dir, entry.Name()))
+			paths = append(paths, files.Join(dir, entry.Name()))
 		}
 	}
-	sort.Strings(files)
-	return files, nil
+	sort.Strings(paths)
+	return paths, nil
 }
 
 func mergePrograms(programs []*ast.Program) *ast.Program {
@@ -291,8 +324,7 @@ func mergePrograms(programs []*ast.Program) *ast.Program {
 	return merged
 }
 
-func validateFileStructMapping(filePath string, prog *ast.Program) error {
-	fileName := filepath.Base(filePath)
+func validateFileStructMapping(fileName, filePath string, prog *ast.Program) error {
 	fileNameWithoutExt := strings.TrimSuffix(fileName, ".av")
 
 	var foundMatchingStruct bool
@@ -310,3 +342,36 @@ func validateFileStructMapping(filePath string, prog *ast.Program) error {
 	}
 	return nil
 }
+
+// fileSystem abstracts where module sources are read from, so that worlds
+// can be loaded from the host file system or from an fs.FS.
+type fileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Join(elem ...string) string
+	Dir(name string) string
+	Base(name string) string
+}
+
+// hostFS reads modules from the operating system's file system.
+type hostFS struct{}
+
+func (hostFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (hostFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (hostFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (hostFS) Join(elem ...string) string                 { return filepath.Join(elem...) }
+func (hostFS) Dir(name string) string                     { return filepath.Dir(name) }
+func (hostFS) Base(name string) string                    { return filepath.Base(name) }
+
+// ioFS reads modules from an fs.FS using slash-separated paths.
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f ioFS) ReadFile(name string) ([]byte, error)       { return fs.ReadFile(f.fsys, name) }
+func (f ioFS) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(f.fsys, name) }
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(f.fsys, name) }
+func (ioFS) Join(elem ...string) string                   { return path.Join(elem...) }
+func (ioFS) Dir(name string) string                       { return path.Dir(name) }
+func (ioFS) Base(name string) string                      { return path.Base(name) }
@@ -698,8 +698,19 @@ func (p *Parser) parseFunDecl() *ast.FunDecl {
 }
 
 func (p *Parser) parseFuncLiteral() ast.Expr {
+	// Optional 'async' keyword: async fun(...) | T { ... }
+	isAsync := false
+	asyncPos := p.cur.Pos
+	if p.cur.Kind == token.Async {
+		isAsync = true
+		p.nextToken()
+	}
+
 	funTok := p.cur
 	p.nextToken()
+	if isAsync {
+		funTok.Pos = asyncPos
+	}
 
 	p.expect(token.LParen)
 
@@ -778,6 +789,7 @@ func (p *Parser) parseFuncLiteral() ast.Expr {
 		Return:        res,
 		Throws:        throws,
 		Body:          body,
+		IsAsync:       isAsync,
 	}
 }
 
@@ -989,7 +1001,12 @@ func (p *Parser) parseType() ast.TypeNode {
 				NamePos: nameTok.Pos,
 			}
 		}
-		typ := p.parseQualifiedType()
+		var typ ast.TypeNode
+		if p.cur.Lexeme == "set" && p.peek.Kind == token.Lt {
+			typ = p.parseSetType()
+		} else {
+			typ = p.parseQualifiedType()
+		}
 		if p.cur.Kind == token.Question {
 			qPos := p.cur.Pos
 			p.nextToken()
@@ -1010,6 +1027,24 @@ func (p *Parser) parseType() ast.TypeNode {
 	}
 }
 
+// parseSetType parses set<T>. set is not a keyword, so that it stays usable
+// as a name, e.g. for the dict method d.set(k, v).
+func (p *Parser) parseSetType() ast.TypeNode {
+	setTok := p.cur
+	p.nextToken() // consume 'set'
+	p.nextToken() // consume '<'
+	elem := p.parseType()
+	if p.cur.Kind != token.Gt {
+		p.errorf(p.cur.Pos, "expected '>' at end of set type")
+	} else {
+		p.nextToken()
+	}
+	return &ast.SetType{
+		SetPos:   setTok.Pos,
+		ElemType: elem,
+	}
+}
+
 func (p *Parser) parseTypeArgs() []ast.TypeNode {
 	p.nextToken() // consume '<'
 	var args []ast.TypeNode
@@ -1172,7 +1207,7 @@ func (p *Parser) parseStatement() ast.Stmt {
 				switch p.cur.Kind {
 				case token.Dot:
 					p.nextToken() // consume dot
-					if p.cur.Kind != token.Ident {
+					if !p.atMemberName() {
 						p.errorf(p.cur.Pos, "expected identifier after '.'")
 						return &ast.ExprStmt{Expression: expr}
 					}
@@ -1383,22 +1418,24 @@ func (p *Parser) parseForStmt() ast.Stmt {
 	p.nextToken()
 	p.expect(token.LParen)
 
-	// Check if this is a foreach loop: `for (ident in expr)`
-	if p.cur.Kind == token.Ident && p.peek.Kind == token.In {
-		varNameTok := p.cur
-		p.nextToken() // consume ident
-		p.nextToken() // consume 'in'
-		listExpr := p.parseExpr()
+	// Check if this is a foreach loop: `for (ident in expr)` or
+	// `for (key, ident in expr)`
+	if p.cur.Kind == token.Ident && (p.peek.Kind == token.In || p.peek.Kind == token.Comma) {
+		stmt := &ast.ForEachStmt{ForPos: forTok.Pos}
+		if p.peek.Kind == token.Comma {
+			stmt.KeyName = p.cur.Lexeme
+			stmt.KeyPos = p.cur.Pos
+			p.nextToken() // consume key ident
+			p.nextToken() // consume ','
+		}
+		varNameTok := p.expect(token.Ident)
+		stmt.VarName = varNameTok.Lexeme
+		stmt.VarPos = varNameTok.Pos
+		p.expect(token.In)
+		stmt.ListExpr = p.parseExpr()
 		p.expect(token.RParen)
-		body := p.parseBlock()
-
-		return &ast.ForEachStmt{
-			ForPos:   forTok.Pos,
-			VarName:  varNameTok.Lexeme,
-			VarPos:   varNameTok.Pos,
-			ListExpr: listExpr,
-			Body:     body,
-		}
+		stmt.Body = p.parseBlock()
+		return stmt
 	}
 
 	// C-style for loop: `for (init; cond; post)`
@@ -1646,6 +1683,19 @@ func (p *Parser) parseTryStmt() ast.Stmt {
 
 // ---------- Expressions (with priorities) ----------
 
+// ParseExpression parses input holding a single expression, such as one
+// typed into a debugger. Check Errors afterwards.
+func (p *Parser) ParseExpression() ast.Expr {
+	expr := p.parseExpr()
+	if p.cur.Kind == token.Semicolon {
+		p.nextToken()
+	}
+	if p.cur.Kind != token.EOF {
+		p.errorf(p.cur.Pos, "unexpected %s (%q) after expression", p.cur.Kind, p.cur.Lexeme)
+	}
+	return expr
+}
+
 func (p *Parser) parseExpr() ast.Expr {
 	return p.parseOr()
 }
@@ -1767,9 +1817,29 @@ func (p *Parser) parseUnary() ast.Expr {
 			Expr:     expr,
 		}
 	}
+	if p.cur.Kind == token.Spawn {
+		spawnTok := p.cur
+		p.nextToken()
+		expr := p.parsePostfix()
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			p.errorf(spawnTok.Pos, "spawn expects a function call")
+			return expr
+		}
+		return &ast.SpawnExpr{
+			SpawnPos: spawnTok.Pos,
+			Call:     call,
+		}
+	}
 	return p.parsePostfix()
 }
 
+// atMemberName reports whether the current token can name a member after
+// '.': an identifier, or a keyword such as any in list.any(fn).
+func (p *Parser) atMemberName() bool {
+	return p.cur.Kind == token.Ident || token.IsKeyword(p.cur.Kind)
+}
+
 func (p *Parser) parsePostfix() ast.Expr {
 	expr := p.parsePrimary()
 
@@ -1778,7 +1848,7 @@ func (p *Parser) parsePostfix() ast.Expr {
 		case token.Dot:
 			// Member access: expr.name
 			p.nextToken()
-			if p.cur.Kind != token.Ident {
+			if !p.atMemberName() {
 				p.errorf(p.cur.Pos, "expected identifier after '.'")
 				return expr
 			}
@@ -1791,7 +1861,7 @@ func (p *Parser) parsePostfix() ast.Expr {
 			}
 		case token.QuestionDot:
 			p.nextToken()
-			if p.cur.Kind == token.Ident {
+			if p.atMemberName() {
 				nameTok := p.cur
 				p.nextToken()
 				if p.cur.Kind == token.LParen {
@@ -2002,6 +2072,9 @@ func (p *Parser) parseGenericExpr() ast.Expr {
 	}
 
 	if p.cur.Kind == token.LBrace {
+		if nameTok.Lexeme == "set" && len(typeArgs) == 1 {
+			return p.parseSetLiteral(nameTok, typeArgs[0])
+		}
 		return p.parseGenericStructLiteral(nameTok, typeArgs)
 	}
 
@@ -2091,15 +2164,60 @@ func (p *Parser) parseGenericStructLiteral(nameTok token.Token, typeArgs []ast.T
 	}
 }
 
+// parseSetLiteral parses the braces of set{...} or set<T>{...}; the current
+// token is the '{'.
+func (p *Parser) parseSetLiteral(setTok token.Token, elemType ast.TypeNode) ast.Expr {
+	lbrace := p.expect(token.LBrace)
+
+	var elems []ast.Expr
+	if p.cur.Kind != token.RBrace {
+		for {
+			elems = append(elems, p.parseExpr())
+			if p.cur.Kind == token.Comma {
+				p.nextToken()
+				continue
+			}
+			break
+		}
+	}
+
+	rbrace := p.expect(token.RBrace)
+
+	return &ast.SetLiteral{
+		SetPos:   setTok.Pos,
+		ElemType: elemType,
+		LBrace:   lbrace.Pos,
+		Elements: elems,
+		RBrace:   rbrace.Pos,
+	}
+}
+
 func (p *Parser) parsePrimary() ast.Expr {
 	switch p.cur.Kind {
 	case token.Fun:
 		return p.parseFuncLiteral()
+	case token.Async:
+		if p.peek.Kind != token.Fun {
+			tok := p.cur
+			p.errorf(tok.Pos, "expected 'fun' after 'async' in expression")
+			p.nextToken()
+			return &ast.IntLiteral{
+				Value:  0,
+				LitPos: tok.Pos,
+				Raw:    "0",
+			}
+		}
+		return p.parseFuncLiteral()
 	case token.Ident, token.ErrorType:
 		// Allow error as identifier in expression contexts (for builtin function)
 		// Could be a struct literal: TypeName{field = value, ...}
 		// or a generic struct literal: TypeName<T>{field = value, ...}
 		if p.peek.Kind == token.LBrace {
+			if p.cur.Lexeme == "set" {
+				setTok := p.cur
+				p.nextToken() // consume 'set'
+				return p.parseSetLiteral(setTok, nil)
+			}
 			return p.parseStructLiteral()
 		}
 		if p.peek.Kind == token.Lt && p.isGenericStart() {
@@ -2279,24 +2397,21 @@ func (p *Parser) parseDictLiteral() ast.Expr {
 	var entries []*ast.DictEntry
 	if p.cur.Kind != token.RBrace {
 		for {
-			var keyTok token.Token
-			switch p.cur.Kind {
-			case token.Ident, token.String:
-				keyTok = p.cur
+			entry := &ast.DictEntry{KeyPos: p.cur.Pos}
+			switch {
+			case (p.cur.Kind == token.Ident || p.cur.Kind == token.String) && p.peek.Kind == token.Colon:
+				entry.Key = p.cur.Lexeme
 				p.nextToken()
-			default:
+			case p.cur.Kind == token.Colon || p.cur.Kind == token.Comma || p.cur.Kind == token.EOF:
 				p.errorf(p.cur.Pos, "expected dict key")
 				return &ast.DictLiteral{LBrace: lbrace.Pos, Entries: entries, RBrace: lbrace.Pos}
+			default:
+				entry.KeyExpr = p.parseExpr()
 			}
 
 			p.expect(token.Colon)
-			value := p.parseExpr()
-
-			entries = append(entries, &ast.DictEntry{
-				Key:    keyTok.Lexeme,
-				KeyPos: keyTok.Pos,
-				Value:  value,
-			})
+			entry.Value = p.parseExpr()
+			entries = append(entries, entry)
 
 			if p.cur.Kind == token.Comma {
 				p.nextToken()
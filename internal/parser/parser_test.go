@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"fmt"
 	"testing"
 
 	"avenir/internal/ast"
@@ -507,6 +508,121 @@ fun main() | void {
 	}
 }
 
+func TestParseDictLiteralExprKeys(t *testing.T) {
+	input := `pckg main;
+
+fun main() | void {
+    var d = {name: 1, 2: "two", (key): 3, Point{x = 1}: 4, "a" + "b": 5};
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("expected no parser errors, got %v", errs)
+	}
+
+	dictLit := prog.Funcs[0].Body.Stmts[0].(*ast.VarDeclStmt).Value.(*ast.DictLiteral)
+	if len(dictLit.Entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(dictLit.Entries))
+	}
+	if e := dictLit.Entries[0]; e.Key != "name" || e.KeyExpr != nil {
+		t.Fatalf("entry 0: expected string key %q, got %+v", "name", e)
+	}
+	wantKeys := []string{"*ast.IntLiteral", "*ast.IdentExpr", "*ast.StructLiteral", "*ast.BinaryExpr"}
+	for i, want := range wantKeys {
+		if got := fmt.Sprintf("%T", dictLit.Entries[i+1].KeyExpr); got != want {
+			t.Fatalf("entry %d: expected key %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestParseForEachKeyValue(t *testing.T) {
+	input := `pckg main;
+
+fun main() | void {
+    for (k, v in d) {
+        print(k);
+    }
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("expected no parser errors, got %v", errs)
+	}
+
+	loop, ok := prog.Funcs[0].Body.Stmts[0].(*ast.ForEachStmt)
+	if !ok {
+		t.Fatalf("expected ForEachStmt, got %T", prog.Funcs[0].Body.Stmts[0])
+	}
+	if loop.KeyName != "k" || loop.VarName != "v" {
+		t.Fatalf("expected loop variables k, v, got %q, %q", loop.KeyName, loop.VarName)
+	}
+}
+
+func TestParseSetLiteralAndType(t *testing.T) {
+	input := `pckg main;
+
+fun main() | void {
+    var a | set<int> = set{1, 2};
+    var b = set<string>{};
+    var set = 1;
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("expected no parser errors, got %v", errs)
+	}
+
+	stmts := prog.Funcs[0].Body.Stmts
+	a := stmts[0].(*ast.VarDeclStmt)
+	if st, ok := a.Type.(*ast.SetType); !ok || st.ElemType.(*ast.SimpleType).Name != "int" {
+		t.Fatalf("expected set<int> type, got %#v", a.Type)
+	}
+	if lit, ok := a.Value.(*ast.SetLiteral); !ok || lit.ElemType != nil || len(lit.Elements) != 2 {
+		t.Fatalf("expected set literal with 2 elements, got %#v", a.Value)
+	}
+	b := stmts[1].(*ast.VarDeclStmt)
+	if lit, ok := b.Value.(*ast.SetLiteral); !ok || lit.ElemType == nil || len(lit.Elements) != 0 {
+		t.Fatalf("expected empty set<string> literal, got %#v", b.Value)
+	}
+	if name := stmts[2].(*ast.VarDeclStmt).Name; name != "set" {
+		t.Fatalf("expected variable named set, got %q", name)
+	}
+}
+
+func TestParseKeywordMemberName(t *testing.T) {
+	input := `pckg main;
+
+fun main() | void {
+    var ok = xs.any(f);
+    xs.any(f);
+    var maybe = xs?.any(f);
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("expected no parser errors, got %v", errs)
+	}
+
+	stmts := prog.Funcs[0].Body.Stmts
+	call, ok := stmts[0].(*ast.VarDeclStmt).Value.(*ast.CallExpr)
+	if !ok {
+		t.Fatalf("expected call, got %#v", stmts[0].(*ast.VarDeclStmt).Value)
+	}
+	if m, ok := call.Callee.(*ast.MemberExpr); !ok || m.Name != "any" {
+		t.Fatalf("expected member any, got %#v", call.Callee)
+	}
+	if _, ok := stmts[1].(*ast.ExprStmt); !ok {
+		t.Fatalf("expected expression statement, got %#v", stmts[1])
+	}
+	if _, ok := stmts[2].(*ast.VarDeclStmt).Value.(*ast.OptionalCallExpr); !ok {
+		t.Fatalf("expected optional call, got %#v", stmts[2].(*ast.VarDeclStmt).Value)
+	}
+}
+
 func TestParseImport(t *testing.T) {
 	input := `pckg main;
 
@@ -1079,3 +1195,89 @@ fun main() | void {
 		t.Fatalf("expected expansion name 'Args', got %q", expansion.Name)
 	}
 }
+
+func TestParseAsyncFuncLiteralAndSpawn(t *testing.T) {
+	input := `pckg main;
+
+async fun main() | void {
+	var f | fun(int) | Future<int> = async fun(x | int) | int {
+		return x;
+	};
+	var g | Future<int> = spawn f(1);
+}
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		for _, e := range errs {
+			t.Logf("parser error: %s", e)
+		}
+		t.Fatalf("expected no parser errors, got %d", len(errs))
+	}
+
+	body := prog.Funcs[0].Body.Stmts
+	if len(body) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(body))
+	}
+
+	litDecl := body[0].(*ast.VarDeclStmt)
+	lit, ok := litDecl.Value.(*ast.FuncLiteral)
+	if !ok {
+		t.Fatalf("expected FuncLiteral, got %T", litDecl.Value)
+	}
+	if !lit.IsAsync {
+		t.Fatalf("expected async function literal")
+	}
+
+	spawnDecl := body[1].(*ast.VarDeclStmt)
+	spawn, ok := spawnDecl.Value.(*ast.SpawnExpr)
+	if !ok {
+		t.Fatalf("expected SpawnExpr, got %T", spawnDecl.Value)
+	}
+	if callee, ok := spawn.Call.Callee.(*ast.IdentExpr); !ok || callee.Name != "f" {
+		t.Fatalf("expected spawn of call to f, got %T", spawn.Call.Callee)
+	}
+}
+
+func TestParseSpawnRequiresCall(t *testing.T) {
+	input := `pckg main;
+
+fun main() | void {
+	var f | int = spawn 42;
+}
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected parser error for spawn without a call")
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	p := parser.New(lexer.New(`user.tags[0] + "!"`))
+	expr := p.ParseExpression()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	bin, ok := expr.(*ast.BinaryExpr)
+	if !ok {
+		t.Fatalf("expected BinaryExpr, got %T", expr)
+	}
+	idx, ok := bin.Left.(*ast.IndexExpr)
+	if !ok {
+		t.Fatalf("expected IndexExpr, got %T", bin.Left)
+	}
+	if member, ok := idx.X.(*ast.MemberExpr); !ok || member.Name != "tags" {
+		t.Fatalf("expected user.tags, got %T", idx.X)
+	}
+
+	p = parser.New(lexer.New(`x + 1 y`))
+	p.ParseExpression()
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected an error for trailing tokens")
+	}
+}
@@ -159,16 +159,21 @@ func (r *Resolver) collectLocalsAndNestedFunctions(block *ast.BlockStmt, current
 			}
 
 		case *ast.ForEachStmt:
-			// Loop variable is a local
-			found := false
-			for _, name := range currentFunc.Locals {
-				if name == s.VarName {
-					found = true
-					break
+			// Loop variables are locals
+			for _, loopVar := range []string{s.KeyName, s.VarName} {
+				if loopVar == "" {
+					continue
+				}
+				found := false
+				for _, name := range currentFunc.Locals {
+					if name == loopVar {
+						found = true
+						break
+					}
+				}
+				if !found {
+					currentFunc.Locals = append(currentFunc.Locals, loopVar)
 				}
-			}
-			if !found {
-				currentFunc.Locals = append(currentFunc.Locals, s.VarName)
 			}
 			if s.Body != nil {
 				r.collectLocalsAndNestedFunctions(s.Body, currentFunc, parentFunc)
@@ -282,6 +287,17 @@ func (r *Resolver) findFunctionLiteralsInExpr(node ast.Node, currentFunc *Functi
 		for _, el := range n.Elements {
 			r.findFunctionLiteralsInExpr(el, currentFunc, parentFunc)
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			r.findFunctionLiteralsInExpr(el, currentFunc, parentFunc)
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if entry.KeyExpr != nil {
+				r.findFunctionLiteralsInExpr(entry.KeyExpr, currentFunc, parentFunc)
+			}
+			r.findFunctionLiteralsInExpr(entry.Value, currentFunc, parentFunc)
+		}
 
 	case *ast.MemberExpr:
 		r.findFunctionLiteralsInExpr(n.X, currentFunc, parentFunc)
@@ -298,6 +314,12 @@ func (r *Resolver) findFunctionLiteralsInExpr(node ast.Node, currentFunc *Functi
 				r.findFunctionLiteralsInExpr(arg, currentFunc, parentFunc)
 			}
 		}
+
+	case *ast.AwaitExpr:
+		r.findFunctionLiteralsInExpr(n.Expr, currentFunc, parentFunc)
+
+	case *ast.SpawnExpr:
+		r.findFunctionLiteralsInExpr(n.Call, currentFunc, parentFunc)
 	}
 }
 
@@ -601,6 +623,17 @@ func (r *Resolver) findNestedFunctionLiteralsAndPropagate(node ast.Node, current
 		for _, el := range n.Elements {
 			r.findNestedFunctionLiteralsAndPropagate(el, currentFunc, parentFunc)
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			r.findNestedFunctionLiteralsAndPropagate(el, currentFunc, parentFunc)
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if entry.KeyExpr != nil {
+				r.findNestedFunctionLiteralsAndPropagate(entry.KeyExpr, currentFunc, parentFunc)
+			}
+			r.findNestedFunctionLiteralsAndPropagate(entry.Value, currentFunc, parentFunc)
+		}
 
 	case *ast.IfStmt:
 		if n.Then != nil {
@@ -666,6 +699,12 @@ func (r *Resolver) findNestedFunctionLiteralsAndPropagate(node ast.Node, current
 				r.findNestedFunctionLiteralsAndPropagate(arg, currentFunc, parentFunc)
 			}
 		}
+
+	case *ast.AwaitExpr:
+		r.findNestedFunctionLiteralsAndPropagate(n.Expr, currentFunc, parentFunc)
+
+	case *ast.SpawnExpr:
+		r.findNestedFunctionLiteralsAndPropagate(n.Call, currentFunc, parentFunc)
 	}
 }
 
@@ -786,6 +825,17 @@ func (r *Resolver) collectUsedIdentifiers(node ast.Node, used map[string]bool) {
 		for _, el := range n.Elements {
 			r.collectUsedIdentifiers(el, used)
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			r.collectUsedIdentifiers(el, used)
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if entry.KeyExpr != nil {
+				r.collectUsedIdentifiers(entry.KeyExpr, used)
+			}
+			r.collectUsedIdentifiers(entry.Value, used)
+		}
 
 	case *ast.MemberExpr:
 		r.collectUsedIdentifiers(n.X, used)
@@ -803,6 +853,12 @@ func (r *Resolver) collectUsedIdentifiers(node ast.Node, used map[string]bool) {
 			}
 		}
 
+	case *ast.AwaitExpr:
+		r.collectUsedIdentifiers(n.Expr, used)
+
+	case *ast.SpawnExpr:
+		r.collectUsedIdentifiers(n.Call, used)
+
 	case *ast.FuncLiteral:
 		// Don't process function literal body here - nested functions are processed
 		// separately in identifyUpvaluesForFunction. We only collect identifiers
@@ -871,6 +927,17 @@ func (r *Resolver) findAndProcessFunctionLiterals(node ast.Node, currentFunc *Fu
 		for _, el := range n.Elements {
 			r.findAndProcessFunctionLiterals(el, currentFunc, parentFunc)
 		}
+	case *ast.SetLiteral:
+		for _, el := range n.Elements {
+			r.findAndProcessFunctionLiterals(el, currentFunc, parentFunc)
+		}
+	case *ast.DictLiteral:
+		for _, entry := range n.Entries {
+			if entry.KeyExpr != nil {
+				r.findAndProcessFunctionLiterals(entry.KeyExpr, currentFunc, parentFunc)
+			}
+			r.findAndProcessFunctionLiterals(entry.Value, currentFunc, parentFunc)
+		}
 
 	case *ast.BlockStmt:
 		for _, stmt := range n.Stmts {
@@ -941,5 +1008,11 @@ func (r *Resolver) findAndProcessFunctionLiterals(node ast.Node, currentFunc *Fu
 				r.findAndProcessFunctionLiterals(arg, currentFunc, parentFunc)
 			}
 		}
+
+	case *ast.AwaitExpr:
+		r.findAndProcessFunctionLiterals(n.Expr, currentFunc, parentFunc)
+
+	case *ast.SpawnExpr:
+		r.findAndProcessFunctionLiterals(n.Call, currentFunc, parentFunc)
 	}
 }
@@ -16,7 +16,7 @@ type AsyncHandle struct {
 	ready      bool
 	result     value.Value
 	err        error
-	onComplete func()
+	onComplete []func()
 }
 
 // NewAsyncHandle creates a new unresolved AsyncHandle.
@@ -35,11 +35,12 @@ func (h *AsyncHandle) Resolve(v value.Value) {
 	}
 	h.ready = true
 	h.result = v
-	cb := h.onComplete
+	cbs := h.onComplete
+	h.onComplete = nil
 	h.mu.Unlock()
 
 	close(h.done)
-	if cb != nil {
+	for _, cb := range cbs {
 		cb()
 	}
 }
@@ -53,11 +54,12 @@ func (h *AsyncHandle) Reject(err error) {
 	}
 	h.ready = true
 	h.err = err
-	cb := h.onComplete
+	cbs := h.onComplete
+	h.onComplete = nil
 	h.mu.Unlock()
 
 	close(h.done)
-	if cb != nil {
+	for _, cb := range cbs {
 		cb()
 	}
 }
@@ -79,7 +81,8 @@ func (h *AsyncHandle) Wait() {
 }
 
 // OnComplete registers a callback that fires when the handle completes.
-// If the handle is already complete, the callback fires immediately.
+// Callbacks fire in the order they were registered. If the handle is
+// already complete, the callback fires immediately.
 func (h *AsyncHandle) OnComplete(fn func()) {
 	h.mu.Lock()
 	if h.ready {
@@ -87,7 +90,7 @@ func (h *AsyncHandle) OnComplete(fn func()) {
 		fn()
 		return
 	}
-	h.onComplete = fn
+	h.onComplete = append(h.onComplete, fn)
 	h.mu.Unlock()
 }
 
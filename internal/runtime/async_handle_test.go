@@ -26,7 +26,7 @@ func TestAsyncHandleResolve(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if res.Kind != value.KindInt || res.Int != 42 {
+	if res.Kind != value.KindInt || res.Int() != 42 {
 		t.Fatalf("expected Int(42), got %v", res)
 	}
 }
@@ -50,8 +50,8 @@ func TestAsyncHandleDoubleResolve(t *testing.T) {
 	ah.Resolve(value.Int(2))
 
 	res, _, _ := ah.Poll()
-	if res.Int != 1 {
-		t.Fatalf("expected first resolve value 1, got %d", res.Int)
+	if res.Int() != 1 {
+		t.Fatalf("expected first resolve value 1, got %d", res.Int())
 	}
 }
 
@@ -70,8 +70,8 @@ func TestAsyncHandleWait(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if res.Str != "done" {
-		t.Fatalf("expected 'done', got %q", res.Str)
+	if res.Str() != "done" {
+		t.Fatalf("expected 'done', got %q", res.Str())
 	}
 }
 
@@ -111,6 +111,20 @@ func TestAsyncHandleOnCompleteAlreadyReady(t *testing.T) {
 	}
 }
 
+func TestAsyncHandleOnCompleteMultiple(t *testing.T) {
+	ah := NewAsyncHandle()
+
+	var order []int
+	ah.OnComplete(func() { order = append(order, 1) })
+	ah.OnComplete(func() { order = append(order, 2) })
+	ah.Resolve(value.Int(1))
+	ah.OnComplete(func() { order = append(order, 3) })
+
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Fatalf("callbacks fired in order %v, want [1 2 3]", order)
+	}
+}
+
 func TestWireToFutureResolve(t *testing.T) {
 	ah := NewAsyncHandle()
 	fut := NewFuture()
@@ -125,8 +139,8 @@ func TestWireToFutureResolve(t *testing.T) {
 	if fut.Err != nil {
 		t.Fatalf("unexpected error: %v", fut.Err)
 	}
-	if fut.Result.Int != 99 {
-		t.Fatalf("expected 99, got %d", fut.Result.Int)
+	if fut.Result.Int() != 99 {
+		t.Fatalf("expected 99, got %d", fut.Result.Int())
 	}
 }
 
@@ -160,8 +174,8 @@ func TestRunAsync(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if res.Str != "hello" {
-		t.Fatalf("expected 'hello', got %q", res.Str)
+	if res.Str() != "hello" {
+		t.Fatalf("expected 'hello', got %q", res.Str())
 	}
 }
 
@@ -264,7 +278,7 @@ func TestEventLoopWithAsyncIO(t *testing.T) {
 	if mainFut.Err != nil {
 		t.Fatalf("unexpected error: %v", mainFut.Err)
 	}
-	if mainFut.Result.Str != "async result" {
-		t.Fatalf("expected 'async result', got %q", mainFut.Result.Str)
+	if mainFut.Result.Str() != "async result" {
+		t.Fatalf("expected 'async result', got %q", mainFut.Result.Str())
 	}
 }
@@ -0,0 +1,240 @@
+package bignum
+
+import (
+	"fmt"
+	"math/big"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerBigIntNew()
+	registerBigIntToInt()
+	registerBigIntToFloat()
+	registerToString(builtins.BigIntToString, bigintRef)
+	registerBigIntAbs()
+	registerSign(builtins.BigIntSign, bigintRef)
+	registerBigIntPow()
+}
+
+var bigintRef = builtins.TypeRef{Kind: builtins.TypeBigInt}
+
+// maxPowBits bounds the size of bigint.pow results, which are computed
+// before the memory limit can see them.
+const maxPowBits = 1 << 24
+
+func registerBigIntNew() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.BigIntNew,
+			Name:       "bigint",
+			Arity:      1,
+			ParamNames: []string{"value"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeUnion, Elem: []builtins.TypeRef{{Kind: builtins.TypeInt}, {Kind: builtins.TypeString}}},
+			},
+			Result:       bigintRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("bigint expects 1 argument, got %d", len(args))
+			}
+			v := args[0].(value.Value)
+			switch v.Kind {
+			case value.KindInt:
+				return value.BigInt(big.NewInt(v.Int())), nil
+			case value.KindString:
+				b, ok := new(big.Int).SetString(v.Str(), 10)
+				if !ok {
+					return value.Value{}, fmt.Errorf("bigint: invalid integer %q", v.Str())
+				}
+				return value.BigInt(b), nil
+			}
+			return value.Value{}, fmt.Errorf("bigint expects an int or a string, got %s", typeName(v))
+		},
+	})
+}
+
+func registerBigIntToInt() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.BigIntToInt,
+			Name:         "toInt",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{bigintRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeBigInt,
+			MethodName:   "toInt",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBigInt(args, "bigint.toInt")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if !b.IsInt64() {
+				return value.Value{}, fmt.Errorf("bigint.toInt: %s is out of int range", b)
+			}
+			return value.Int(b.Int64()), nil
+		},
+	})
+}
+
+func registerBigIntToFloat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.BigIntToFloat,
+			Name:         "toFloat",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{bigintRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeFloat},
+			ReceiverType: builtins.TypeBigInt,
+			MethodName:   "toFloat",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBigInt(args, "bigint.toFloat")
+			if err != nil {
+				return value.Value{}, err
+			}
+			f, _ := new(big.Float).SetInt(b).Float64()
+			return value.Float(f), nil
+		},
+	})
+}
+
+func registerBigIntAbs() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.BigIntAbs,
+			Name:         "abs",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{bigintRef},
+			Result:       bigintRef,
+			ReceiverType: builtins.TypeBigInt,
+			MethodName:   "abs",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBigInt(args, "bigint.abs")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.BigInt(new(big.Int).Abs(b)), nil
+		},
+	})
+}
+
+func registerBigIntPow() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.BigIntPow,
+			Name:         "pow",
+			Arity:        2,
+			ParamNames:   []string{"self", "exp"},
+			Params:       []builtins.TypeRef{bigintRef, {Kind: builtins.TypeInt}},
+			Result:       bigintRef,
+			ReceiverType: builtins.TypeBigInt,
+			MethodName:   "pow",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBigInt(args, "bigint.pow")
+			if err != nil {
+				return value.Value{}, err
+			}
+			exp := args[1].(value.Value)
+			if exp.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("bigint.pow expects exp as int, got %s", typeName(exp))
+			}
+			if exp.Int() < 0 {
+				return value.Value{}, fmt.Errorf("bigint.pow: negative exponent %d", exp.Int())
+			}
+			if b.BitLen() > 1 && exp.Int() > maxPowBits/int64(b.BitLen()-1) {
+				return value.Value{}, fmt.Errorf("bigint.pow: result too large")
+			}
+			return value.BigInt(new(big.Int).Exp(b, big.NewInt(exp.Int()), nil)), nil
+		},
+	})
+}
+
+// registerToString registers toString for the bigint or decimal receiver.
+func registerToString(id builtins.ID, recv builtins.TypeRef) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         "toString",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{recv},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: recv.Kind,
+			MethodName:   "toString",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			v := args[0].(value.Value)
+			if v.Kind != value.KindBigInt && v.Kind != value.KindDecimal {
+				return value.Value{}, fmt.Errorf("%s.toString called on %s", recv.Kind, typeName(v))
+			}
+			return value.Str(v.String()), nil
+		},
+	})
+}
+
+// registerSign registers sign for the bigint or decimal receiver.
+func registerSign(id builtins.ID, recv builtins.TypeRef) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         "sign",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{recv},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: recv.Kind,
+			MethodName:   "sign",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			v := args[0].(value.Value)
+			switch v.Kind {
+			case value.KindBigInt:
+				return value.Int(int64(v.BigInt().Sign())), nil
+			case value.KindDecimal:
+				return value.Int(int64(v.Decimal().Sign())), nil
+			}
+			return value.Value{}, fmt.Errorf("%s.sign called on %s", recv.Kind, typeName(v))
+		},
+	})
+}
+
+func requireBigInt(args []interface{}, name string) (*big.Int, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s expects a receiver", name)
+	}
+	v := args[0].(value.Value)
+	if v.Kind != value.KindBigInt {
+		return nil, fmt.Errorf("%s called on non-bigint type %s", name, typeName(v))
+	}
+	return v.BigInt(), nil
+}
+
+// typeName names the type of v in errors.
+func typeName(v value.Value) string {
+	switch v.Kind {
+	case value.KindInt:
+		return "int"
+	case value.KindFloat:
+		return "float"
+	case value.KindBigInt:
+		return "bigint"
+	case value.KindDecimal:
+		return "decimal"
+	case value.KindString:
+		return "string"
+	case value.KindOptional:
+		return "optional"
+	}
+	return "value"
+}
@@ -0,0 +1,269 @@
+package bignum
+
+import (
+	"fmt"
+
+	"avenir/internal/decimal"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerDecimalNew()
+	registerDecimalScale()
+	registerDecimalSetScale()
+	registerDecimalDiv()
+	registerDecimalToFloat()
+	registerToString(builtins.DecimalToString, decimalRef)
+	registerDecimalToBigInt()
+	registerDecimalAbs()
+	registerSign(builtins.DecimalSign, decimalRef)
+}
+
+var decimalRef = builtins.TypeRef{Kind: builtins.TypeDecimal}
+
+// integerOrDecimal is the type of decimal operands, which may also be ints
+// and bigints.
+var integerOrDecimal = builtins.TypeRef{Kind: builtins.TypeUnion, Elem: []builtins.TypeRef{
+	{Kind: builtins.TypeInt},
+	bigintRef,
+	decimalRef,
+}}
+
+func registerDecimalNew() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.DecimalNew,
+			Name:       "decimal",
+			Arity:      2,
+			ParamNames: []string{"value", "scale"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeUnion, Elem: []builtins.TypeRef{
+					{Kind: builtins.TypeInt},
+					{Kind: builtins.TypeString},
+					bigintRef,
+				}},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       decimalRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("decimal expects 2 arguments, got %d", len(args))
+			}
+			v := args[0].(value.Value)
+			var d decimal.Decimal
+			switch v.Kind {
+			case value.KindString:
+				var err error
+				if d, err = decimal.Parse(v.Str()); err != nil {
+					return value.Value{}, fmt.Errorf("decimal: %w", err)
+				}
+			case value.KindInt, value.KindBigInt:
+				d, _ = value.ToDecimal(v)
+			default:
+				return value.Value{}, fmt.Errorf("decimal expects an int, bigint or string, got %s", typeName(v))
+			}
+			if scale := args[1].(value.Value); scale.Kind == value.KindInt {
+				var err error
+				if d, err = d.Rescale(int(scale.Int()), decimal.HalfEven); err != nil {
+					return value.Value{}, fmt.Errorf("decimal: %w", err)
+				}
+			}
+			return value.Decimal(d), nil
+		},
+	})
+}
+
+func registerDecimalScale() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.DecimalScale,
+			Name:         "scale",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{decimalRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "scale",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.scale")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(d.Scale())), nil
+		},
+	})
+}
+
+func registerDecimalSetScale() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.DecimalSetScale,
+			Name:       "setScale",
+			Arity:      3,
+			ParamNames: []string{"self", "scale", "mode"},
+			Params: []builtins.TypeRef{
+				decimalRef,
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+			},
+			Result:       decimalRef,
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "setScale",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.setScale")
+			if err != nil {
+				return value.Value{}, err
+			}
+			scale, mode, err := scaleAndMode(args[1].(value.Value), args[2].(value.Value), "decimal.setScale")
+			if err != nil {
+				return value.Value{}, err
+			}
+			r, err := d.Rescale(scale, mode)
+			if err != nil {
+				return value.Value{}, fmt.Errorf("decimal.setScale: %w", err)
+			}
+			return value.Decimal(r), nil
+		},
+	})
+}
+
+func registerDecimalDiv() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.DecimalDiv,
+			Name:       "div",
+			Arity:      4,
+			ParamNames: []string{"self", "divisor", "scale", "mode"},
+			Params: []builtins.TypeRef{
+				decimalRef,
+				integerOrDecimal,
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+			},
+			Result:       decimalRef,
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "div",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.div")
+			if err != nil {
+				return value.Value{}, err
+			}
+			divisor, ok := value.ToDecimal(args[1].(value.Value))
+			if !ok {
+				return value.Value{}, fmt.Errorf("decimal.div expects a number divisor, got %s", typeName(args[1].(value.Value)))
+			}
+			scale, mode, err := scaleAndMode(args[2].(value.Value), args[3].(value.Value), "decimal.div")
+			if err != nil {
+				return value.Value{}, err
+			}
+			r, err := d.Quo(divisor, scale, mode)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Decimal(r), nil
+		},
+	})
+}
+
+func registerDecimalToFloat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.DecimalToFloat,
+			Name:         "toFloat",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{decimalRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeFloat},
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "toFloat",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.toFloat")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Float(d.Float64()), nil
+		},
+	})
+}
+
+func registerDecimalToBigInt() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.DecimalToBigInt,
+			Name:         "toBigint",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{decimalRef},
+			Result:       bigintRef,
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "toBigint",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.toBigint")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.BigInt(d.Int()), nil
+		},
+	})
+}
+
+func registerDecimalAbs() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.DecimalAbs,
+			Name:         "abs",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{decimalRef},
+			Result:       decimalRef,
+			ReceiverType: builtins.TypeDecimal,
+			MethodName:   "abs",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			d, err := requireDecimal(args, "decimal.abs")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Decimal(d.Abs()), nil
+		},
+	})
+}
+
+// scaleAndMode reads the scale and the optional rounding mode arguments of
+// method name; the mode defaults to half-even.
+func scaleAndMode(scale, mode value.Value, name string) (int, decimal.RoundingMode, error) {
+	if scale.Kind != value.KindInt {
+		return 0, 0, fmt.Errorf("%s expects scale as int, got %s", name, typeName(scale))
+	}
+	if mode.Kind == value.KindOptional {
+		return int(scale.Int()), decimal.HalfEven, nil
+	}
+	m, ok := decimal.ParseRoundingMode(mode.Str())
+	if mode.Kind != value.KindString || !ok {
+		return 0, 0, fmt.Errorf("%s: unknown rounding mode %q", name, mode.Str())
+	}
+	return int(scale.Int()), m, nil
+}
+
+func requireDecimal(args []interface{}, name string) (decimal.Decimal, error) {
+	if len(args) == 0 {
+		return decimal.Decimal{}, fmt.Errorf("%s expects a receiver", name)
+	}
+	v := args[0].(value.Value)
+	if v.Kind != value.KindDecimal {
+		return decimal.Decimal{}, fmt.Errorf("%s called on non-decimal type %s", name, typeName(v))
+	}
+	return v.Decimal(), nil
+}
@@ -37,14 +37,14 @@ func init() {
 			}
 
 			// Validate byte range [0, 255]
-			if byteVal.Int < 0 || byteVal.Int > 255 {
-				return value.Value{}, fmt.Errorf("bytes.append: byte value must be in range [0, 255], got %d", byteVal.Int)
+			if byteVal.Int() < 0 || byteVal.Int() > 255 {
+				return value.Value{}, fmt.Errorf("bytes.append: byte value must be in range [0, 255], got %d", byteVal.Int())
 			}
 
 			// Create new bytes with appended byte
-			newBytes := make([]byte, len(receiver.Bytes)+1)
-			copy(newBytes, receiver.Bytes)
-			newBytes[len(receiver.Bytes)] = byte(byteVal.Int)
+			newBytes := make([]byte, len(receiver.Bytes())+1)
+			copy(newBytes, receiver.Bytes())
+			newBytes[len(receiver.Bytes())] = byte(byteVal.Int())
 
 			return value.Bytes(newBytes), nil
 		},
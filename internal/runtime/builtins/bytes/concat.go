@@ -37,9 +37,9 @@ func init() {
 			}
 
 			// Create new bytes by concatenating receiver and other
-			newBytes := make([]byte, len(receiver.Bytes)+len(other.Bytes))
-			copy(newBytes, receiver.Bytes)
-			copy(newBytes[len(receiver.Bytes):], other.Bytes)
+			newBytes := make([]byte, len(receiver.Bytes())+len(other.Bytes()))
+			copy(newBytes, receiver.Bytes())
+			copy(newBytes[len(receiver.Bytes()):], other.Bytes())
 
 			return value.Bytes(newBytes), nil
 		},
@@ -32,7 +32,7 @@ func init() {
 			}
 
 			// Convert string to bytes (UTF-8 encode)
-			resultBytes := []byte(strVal.Str)
+			resultBytes := []byte(strVal.Str())
 			return value.Bytes(resultBytes), nil
 		},
 	})
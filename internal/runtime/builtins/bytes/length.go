@@ -31,7 +31,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("bytes.length called on non-bytes type %v", receiver.Kind)
 			}
 
-			return value.Int(int64(len(receiver.Bytes))), nil
+			return value.Int(int64(len(receiver.Bytes()))), nil
 		},
 	})
 }
@@ -41,9 +41,9 @@ func init() {
 				return value.Value{}, fmt.Errorf("bytes.slice: end argument must be int, got %v", endVal.Kind)
 			}
 
-			start := int(startVal.Int)
-			end := int(endVal.Int)
-			bytesLen := len(receiver.Bytes)
+			start := int(startVal.Int())
+			end := int(endVal.Int())
+			bytesLen := len(receiver.Bytes())
 
 			// Validate range bounds: 0 ≤ start ≤ end ≤ length
 			if start < 0 || start > bytesLen {
@@ -57,7 +57,7 @@ func init() {
 			}
 
 			// Create slice
-			slice := receiver.Bytes[start:end]
+			slice := receiver.Bytes()[start:end]
 			resultBytes := make([]byte, len(slice))
 			copy(resultBytes, slice)
 
@@ -33,12 +33,12 @@ func init() {
 			}
 
 			// Validate UTF-8 encoding
-			if !utf8.Valid(receiver.Bytes) {
+			if !utf8.Valid(receiver.Bytes()) {
 				return value.Value{}, fmt.Errorf("bytes.toString: bytes are not valid UTF-8")
 			}
 
 			// Convert bytes to string (UTF-8 decode)
-			result := string(receiver.Bytes)
+			result := string(receiver.Bytes())
 			return value.Str(result), nil
 		},
 	})
@@ -0,0 +1,62 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// anyList is the loose receiver and result type of list methods; the
+// checker specializes the element types where it can.
+var anyList = builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}}
+
+// requireList returns the elements of the receiver of list method name.
+func requireList(args []interface{}, arity int, name string) ([]value.Value, error) {
+	if len(args) != arity {
+		return nil, fmt.Errorf("list.%s expects %d arguments, got %d", name, arity, len(args))
+	}
+	receiver := args[0].(value.Value)
+	if receiver.Kind != value.KindList {
+		return nil, fmt.Errorf("list.%s called on non-list type %v", name, receiver.Kind)
+	}
+	return receiver.List(), nil
+}
+
+// requireFn checks that the argument param of list method name is a function.
+func requireFn(v value.Value, name, param string) error {
+	if v.Kind != value.KindClosure {
+		return fmt.Errorf("list.%s: %s argument must be a function, got %s", name, param, typeName(v))
+	}
+	return nil
+}
+
+// callFn calls fn for the element at index i of the receiver of list
+// method name.
+func callFn(env builtins.Env, fn value.Value, name string, i int, args ...value.Value) (value.Value, error) {
+	callArgs := make([]interface{}, len(args))
+	for j, a := range args {
+		callArgs[j] = a
+	}
+	result, err := env.CallClosure(fn.Closure(), callArgs)
+	if err != nil {
+		return value.Value{}, fmt.Errorf("list.%s: error calling function at index %d: %w", name, i, err)
+	}
+	resultVal, ok := result.(value.Value)
+	if !ok {
+		return value.Value{}, fmt.Errorf("list.%s: function returned non-Value type at index %d", name, i)
+	}
+	return resultVal, nil
+}
+
+// callPredicate is callFn for functions that must return a bool.
+func callPredicate(env builtins.Env, fn value.Value, name string, i int, elem value.Value) (bool, error) {
+	res, err := callFn(env, fn, name, i, elem)
+	if err != nil {
+		return false, err
+	}
+	if res.Kind != value.KindBool {
+		return false, fmt.Errorf("list.%s: predicate must return bool, got %s at index %d", name, typeName(res), i)
+	}
+	return res.Bool(), nil
+}
@@ -0,0 +1,44 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListChunk,
+			Name:         "chunk",
+			Arity:        2, // receiver + size
+			ParamNames:   []string{"self", "size"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{anyList}},
+			ReceiverType: builtins.TypeList,
+			MethodName:   "chunk",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "chunk")
+			if err != nil {
+				return value.Value{}, err
+			}
+			sizeVal := args[1].(value.Value)
+			if sizeVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("list.chunk: size must be int, got %s", typeName(sizeVal))
+			}
+			size := sizeVal.Int()
+			if size <= 0 {
+				return value.Value{}, fmt.Errorf("list.chunk: size must be positive, got %d", size)
+			}
+			// The last chunk holds the remaining elements and may be shorter.
+			chunks := make([]value.Value, 0, (int64(len(elems))+size-1)/size)
+			for start := 0; start < len(elems); start += int(size) {
+				end := min(start+int(size), len(elems))
+				chunks = append(chunks, value.List(append([]value.Value(nil), elems[start:end]...)))
+			}
+			return value.List(chunks), nil
+		},
+	})
+}
@@ -15,58 +15,62 @@ func equalValues(a, b value.Value) bool {
 	}
 	switch a.Kind {
 	case value.KindInt:
-		return a.Int == b.Int
+		return a.Int() == b.Int()
 	case value.KindFloat:
-		return a.Float == b.Float
+		return a.Float() == b.Float()
+	case value.KindBigInt:
+		return a.BigInt().Cmp(b.BigInt()) == 0
+	case value.KindDecimal:
+		return a.Decimal().Cmp(b.Decimal()) == 0
 	case value.KindString:
-		return a.Str == b.Str
+		return a.Str() == b.Str()
 	case value.KindBool:
-		return a.Bool == b.Bool
+		return a.Bool() == b.Bool()
 	case value.KindError:
-		return a.Str == b.Str
+		return a.Str() == b.Str()
 	case value.KindBytes:
-		if len(a.Bytes) != len(b.Bytes) {
+		if len(a.Bytes()) != len(b.Bytes()) {
 			return false
 		}
-		for i := range a.Bytes {
-			if a.Bytes[i] != b.Bytes[i] {
+		for i := range a.Bytes() {
+			if a.Bytes()[i] != b.Bytes()[i] {
 				return false
 			}
 		}
 		return true
 	case value.KindList:
-		if len(a.List) != len(b.List) {
+		if len(a.List()) != len(b.List()) {
 			return false
 		}
-		for i := range a.List {
-			if !equalValues(a.List[i], b.List[i]) {
+		for i := range a.List() {
+			if !equalValues(a.List()[i], b.List()[i]) {
 				return false
 			}
 		}
 		return true
 	case value.KindOptional:
-		if a.Optional == nil || b.Optional == nil {
-			return a.Optional == b.Optional
+		if a.Optional() == nil || b.Optional() == nil {
+			return a.Optional() == b.Optional()
 		}
-		if a.Optional.IsSome != b.Optional.IsSome {
+		if a.Optional().IsSome != b.Optional().IsSome {
 			return false
 		}
-		if a.Optional.IsSome {
-			return equalValues(a.Optional.Value, b.Optional.Value)
+		if a.Optional().IsSome {
+			return equalValues(a.Optional().Value, b.Optional().Value)
 		}
 		return true
 	case value.KindStruct:
-		if a.Struct == nil || b.Struct == nil {
-			return a.Struct == b.Struct
+		if a.Struct() == nil || b.Struct() == nil {
+			return a.Struct() == b.Struct()
 		}
-		if a.Struct.TypeIndex != b.Struct.TypeIndex {
+		if a.Struct().TypeIndex != b.Struct().TypeIndex {
 			return false
 		}
-		if len(a.Struct.Fields) != len(b.Struct.Fields) {
+		if len(a.Struct().Fields) != len(b.Struct().Fields) {
 			return false
 		}
-		for i := range a.Struct.Fields {
-			if !equalValues(a.Struct.Fields[i], b.Struct.Fields[i]) {
+		for i := range a.Struct().Fields {
+			if !equalValues(a.Struct().Fields[i], b.Struct().Fields[i]) {
 				return false
 			}
 		}
@@ -103,7 +107,7 @@ func init() {
 			}
 
 			// Search for the value using deep equality
-			for _, elem := range receiver.List {
+			for _, elem := range receiver.List() {
 				if equalValues(elem, searchVal) {
 					return value.Bool(true), nil
 				}
@@ -32,8 +32,8 @@ func init() {
 			}
 
 			// Create a shallow copy of the list
-			copied := make([]value.Value, len(receiver.List))
-			copy(copied, receiver.List)
+			copied := make([]value.Value, len(receiver.List()))
+			copy(copied, receiver.List())
 
 			return value.List(copied), nil
 		},
@@ -37,11 +37,11 @@ func init() {
 			}
 
 			// Filter elements where predicate returns true
-			resultList := make([]value.Value, 0, len(receiver.List))
-			for i, elem := range receiver.List {
+			resultList := make([]value.Value, 0, len(receiver.List()))
+			for i, elem := range receiver.List() {
 				// Call the predicate with the element as argument
 				callArgs := []interface{}{elem}
-				result, err := env.CallClosure(predVal.Closure, callArgs)
+				result, err := env.CallClosure(predVal.Closure(), callArgs)
 				if err != nil {
 					return value.Value{}, fmt.Errorf("list.filter: error calling predicate at index %d: %w", i, err)
 				}
@@ -52,7 +52,7 @@ func init() {
 				if resultVal.Kind != value.KindBool {
 					return value.Value{}, fmt.Errorf("list.filter: predicate must return bool, got %v at index %d", resultVal.Kind, i)
 				}
-				if resultVal.Bool {
+				if resultVal.Bool() {
 					resultList = append(resultList, elem)
 				}
 			}
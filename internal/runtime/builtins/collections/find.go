@@ -0,0 +1,74 @@
+package collections
+
+import (
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListFind,
+			Name:         "find",
+			Arity:        2, // receiver + predicate
+			ParamNames:   []string{"self", "predicate"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny}, // checker specializes to T?
+			ReceiverType: builtins.TypeList,
+			MethodName:   "find",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			i, err := findIndex(env, args, "find")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if i < 0 {
+				return value.None(), nil
+			}
+			return value.Some(args[0].(value.Value).List()[i]), nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListFindIndex,
+			Name:         "findIndex",
+			Arity:        2, // receiver + predicate
+			ParamNames:   []string{"self", "predicate"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeList,
+			MethodName:   "findIndex",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			i, err := findIndex(env, args, "findIndex")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(i)), nil
+		},
+	})
+}
+
+// findIndex returns the index of the first element matching the predicate,
+// or -1.
+func findIndex(env builtins.Env, args []interface{}, name string) (int, error) {
+	elems, err := requireList(args, 2, name)
+	if err != nil {
+		return 0, err
+	}
+	pred := args[1].(value.Value)
+	if err := requireFn(pred, name, "predicate"); err != nil {
+		return 0, err
+	}
+	for i, elem := range elems {
+		ok, err := callPredicate(env, pred, name, i, elem)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
@@ -0,0 +1,45 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListFlatMap,
+			Name:         "flatMap",
+			Arity:        2, // receiver + function
+			ParamNames:   []string{"self", "fn"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       anyList,
+			ReceiverType: builtins.TypeList,
+			MethodName:   "flatMap",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "flatMap")
+			if err != nil {
+				return value.Value{}, err
+			}
+			fn := args[1].(value.Value)
+			if err := requireFn(fn, "flatMap", "fn"); err != nil {
+				return value.Value{}, err
+			}
+			result := make([]value.Value, 0, len(elems))
+			for i, elem := range elems {
+				res, err := callFn(env, fn, "flatMap", i, elem)
+				if err != nil {
+					return value.Value{}, err
+				}
+				if res.Kind != value.KindList {
+					return value.Value{}, fmt.Errorf("list.flatMap: function must return a list, got %s at index %d", typeName(res), i)
+				}
+				result = append(result, res.List()...)
+			}
+			return value.List(result), nil
+		},
+	})
+}
@@ -36,15 +36,15 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.get: index argument must be int, got %v", indexVal.Kind)
 			}
 
-			index := int(indexVal.Int)
-			listLen := len(receiver.List)
+			index := int(indexVal.Int())
+			listLen := len(receiver.List())
 
 			// Validate index bounds
 			if index < 0 || index >= listLen {
 				return value.Value{}, fmt.Errorf("list.get: index %d out of bounds [0, %d)", index, listLen)
 			}
 
-			return receiver.List[index], nil
+			return receiver.List()[index], nil
 		},
 	})
 }
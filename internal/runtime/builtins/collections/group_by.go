@@ -0,0 +1,57 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListGroupBy,
+			Name:         "groupBy",
+			Arity:        2, // receiver + key function
+			ParamNames:   []string{"self", "key"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{anyList}}, // checker specializes to dict<any, list<T>>
+			ReceiverType: builtins.TypeList,
+			MethodName:   "groupBy",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "groupBy")
+			if err != nil {
+				return value.Value{}, err
+			}
+			keyFn := args[1].(value.Value)
+			if err := requireFn(keyFn, "groupBy", "key"); err != nil {
+				return value.Value{}, err
+			}
+			// Groups keep the order in which their keys first appear, and
+			// each group keeps the order of its elements. While grouping,
+			// the dict maps each key to its position in members.
+			groups := value.NewDict(0)
+			var members [][]value.Value
+			for i, elem := range elems {
+				key, err := callFn(env, keyFn, "groupBy", i, elem)
+				if err != nil {
+					return value.Value{}, err
+				}
+				pos, ok := groups.Get(key)
+				if !ok {
+					pos = value.Int(int64(len(members)))
+					if err := groups.Set(key, pos); err != nil {
+						return value.Value{}, fmt.Errorf("list.groupBy: %w", err)
+					}
+					members = append(members, nil)
+				}
+				members[pos.Int()] = append(members[pos.Int()], elem)
+			}
+			for key, pos := range groups.All() {
+				groups.Set(key, value.List(members[pos.Int()]))
+			}
+			return value.DictVal(groups), nil
+		},
+	})
+}
@@ -34,7 +34,7 @@ func init() {
 			}
 
 			// Search for the value using deep equality
-			for i, elem := range receiver.List {
+			for i, elem := range receiver.List() {
 				if equalValues(elem, searchVal) {
 					return value.Int(int64(i)), nil
 				}
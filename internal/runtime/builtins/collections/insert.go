@@ -38,8 +38,8 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.insert: index argument must be int, got %v", indexVal.Kind)
 			}
 
-			index := int(indexVal.Int)
-			listLen := len(receiver.List)
+			index := int(indexVal.Int())
+			listLen := len(receiver.List())
 
 			// Validate index bounds (allow insertion at end: index == listLen)
 			if index < 0 || index > listLen {
@@ -48,9 +48,9 @@ func init() {
 
 			// Create new list with element inserted
 			newList := make([]value.Value, listLen+1)
-			copy(newList[:index], receiver.List[:index])
+			copy(newList[:index], receiver.List()[:index])
 			newList[index] = element
-			copy(newList[index+1:], receiver.List[index:])
+			copy(newList[index+1:], receiver.List()[index:])
 
 			return value.List(newList), nil
 		},
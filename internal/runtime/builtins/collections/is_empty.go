@@ -31,7 +31,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.isEmpty called on non-list type %v", receiver.Kind)
 			}
 
-			return value.Bool(len(receiver.List) == 0), nil
+			return value.Bool(len(receiver.List()) == 0), nil
 		},
 	})
 }
@@ -0,0 +1,45 @@
+package collections
+
+import (
+	"fmt"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListJoin,
+			Name:         "join",
+			Arity:        2, // receiver + separator
+			ParamNames:   []string{"self", "sep"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeList,
+			MethodName:   "join",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "join")
+			if err != nil {
+				return value.Value{}, err
+			}
+			sep := args[1].(value.Value)
+			if sep.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("list.join: separator must be string, got %s", typeName(sep))
+			}
+			var sb strings.Builder
+			for i, elem := range elems {
+				if elem.Kind != value.KindString {
+					return value.Value{}, fmt.Errorf("list.join: element at index %d must be string, got %s", i, typeName(elem))
+				}
+				if i > 0 {
+					sb.WriteString(sep.Str())
+				}
+				sb.WriteString(elem.Str())
+			}
+			return value.Str(sb.String()), nil
+		},
+	})
+}
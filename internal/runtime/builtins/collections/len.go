@@ -15,7 +15,7 @@ func init() {
 			Arity:      1,
 			ParamNames: []string{"value"},
 			Params: []builtins.TypeRef{
-				{Kind: builtins.TypeAny}, // Accept list<any> or bytes
+				{Kind: builtins.TypeAny}, // Accept list<any>, set<any> or bytes
 			},
 			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
 			ReceiverType: builtins.TypeVoid,
@@ -27,12 +27,15 @@ func init() {
 			}
 			arg := args[0].(value.Value)
 			if arg.Kind == value.KindList {
-				return value.Int(int64(len(arg.List))), nil
+				return value.Int(int64(len(arg.List()))), nil
 			}
 			if arg.Kind == value.KindBytes {
-				return value.Int(int64(len(arg.Bytes))), nil
+				return value.Int(int64(len(arg.Bytes()))), nil
 			}
-			return value.Value{}, fmt.Errorf("len expects list<T> or bytes, got %v", arg.Kind)
+			if arg.Kind == value.KindSet {
+				return value.Int(int64(arg.Set().Len())), nil
+			}
+			return value.Value{}, fmt.Errorf("len expects list<T>, set<T> or bytes, got %v", arg.Kind)
 		},
 	})
 }
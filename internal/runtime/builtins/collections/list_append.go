@@ -34,9 +34,9 @@ func init() {
 			}
 
 			// Append element to the list
-			newList := make([]value.Value, len(receiver.List)+1)
-			copy(newList, receiver.List)
-			newList[len(receiver.List)] = element
+			newList := make([]value.Value, len(receiver.List())+1)
+			copy(newList, receiver.List())
+			newList[len(receiver.List())] = element
 
 			return value.List(newList), nil
 		},
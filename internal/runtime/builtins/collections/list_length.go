@@ -31,7 +31,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.length called on non-list type %v", receiver.Kind)
 			}
 
-			return value.Int(int64(len(receiver.List))), nil
+			return value.Int(int64(len(receiver.List()))), nil
 		},
 	})
 }
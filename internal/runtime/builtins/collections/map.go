@@ -37,11 +37,11 @@ func init() {
 			}
 
 			// Apply the function to each element
-			resultList := make([]value.Value, len(receiver.List))
-			for i, elem := range receiver.List {
+			resultList := make([]value.Value, len(receiver.List()))
+			for i, elem := range receiver.List() {
 				// Call the function with the element as argument
 				callArgs := []interface{}{elem}
-				result, err := env.CallClosure(fnVal.Closure, callArgs)
+				result, err := env.CallClosure(fnVal.Closure(), callArgs)
 				if err != nil {
 					return value.Value{}, fmt.Errorf("list.map: error calling function at index %d: %w", i, err)
 				}
@@ -0,0 +1,50 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerExtreme(builtins.ListMin, "min", -1)
+	registerExtreme(builtins.ListMax, "max", 1)
+}
+
+// registerExtreme registers min (want -1) or max (want 1). Of equal
+// elements, the first one wins.
+func registerExtreme(id builtins.ID, name string, want int) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         name,
+			Arity:        1, // receiver only
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{anyList},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny}, // checker specializes to T
+			ReceiverType: builtins.TypeList,
+			MethodName:   name,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 1, name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			if len(elems) == 0 {
+				return value.Value{}, fmt.Errorf("list.%s: empty list", name)
+			}
+			best := elems[0]
+			for _, elem := range elems[1:] {
+				c, err := compareValues(elem, best)
+				if err != nil {
+					return value.Value{}, fmt.Errorf("list.%s: %w", name, err)
+				}
+				if c == want {
+					best = elem
+				}
+			}
+			return best, nil
+		},
+	})
+}
@@ -31,12 +31,12 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.pop called on non-list type %v", receiver.Kind)
 			}
 
-			if len(receiver.List) == 0 {
+			if len(receiver.List()) == 0 {
 				return value.Value{}, fmt.Errorf("list.pop: cannot pop from empty list")
 			}
 
 			// Return the last element and create a new list without it
-			popped := receiver.List[len(receiver.List)-1]
+			popped := receiver.List()[len(receiver.List())-1]
 			return popped, nil
 		},
 	})
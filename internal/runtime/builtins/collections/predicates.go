@@ -0,0 +1,68 @@
+package collections
+
+import (
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerPredicate(builtins.ListAny, "any", builtins.TypeBool, func(env builtins.Env, args []interface{}) (value.Value, error) {
+		_, stopped, err := scanMatches(env, args, "any", func(ok bool) bool { return ok })
+		return value.Bool(stopped), err
+	})
+	registerPredicate(builtins.ListAll, "all", builtins.TypeBool, func(env builtins.Env, args []interface{}) (value.Value, error) {
+		_, stopped, err := scanMatches(env, args, "all", func(ok bool) bool { return !ok })
+		return value.Bool(!stopped), err
+	})
+	registerPredicate(builtins.ListCount, "count", builtins.TypeInt, func(env builtins.Env, args []interface{}) (value.Value, error) {
+		matched, _, err := scanMatches(env, args, "count", nil)
+		return value.Int(int64(matched)), err
+	})
+}
+
+// registerPredicate registers a list method taking a predicate.
+func registerPredicate(id builtins.ID, name string, result builtins.TypeKind, call func(env builtins.Env, args []interface{}) (value.Value, error)) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         name,
+			Arity:        2, // receiver + predicate
+			ParamNames:   []string{"self", "predicate"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: result},
+			ReceiverType: builtins.TypeList,
+			MethodName:   name,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			return call(env, args)
+		},
+	})
+}
+
+// scanMatches calls the predicate on the elements in order and counts the
+// matches. When stop is set, the scan ends at the first result it accepts
+// and reports that it stopped.
+func scanMatches(env builtins.Env, args []interface{}, name string, stop func(ok bool) bool) (int, bool, error) {
+	elems, err := requireList(args, 2, name)
+	if err != nil {
+		return 0, false, err
+	}
+	pred := args[1].(value.Value)
+	if err := requireFn(pred, name, "predicate"); err != nil {
+		return 0, false, err
+	}
+	matched := 0
+	for i, elem := range elems {
+		ok, err := callPredicate(env, pred, name, i, elem)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			matched++
+		}
+		if stop != nil && stop(ok) {
+			return matched, true, nil
+		}
+	}
+	return matched, false, nil
+}
@@ -0,0 +1,62 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.Range,
+			Name:       "range",
+			Arity:      3,
+			ParamNames: []string{"start", "end", "step"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeInt}}},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+			Optional:     1,
+		},
+		// A for-in loop over range(...) counts without calling this; other
+		// uses get the numbers as a list.
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 3 {
+				return value.Value{}, fmt.Errorf("range expects 3 arguments, got %d", len(args))
+			}
+			var bounds [3]int64
+			for i, a := range args {
+				v := a.(value.Value)
+				if i == 2 && v.Kind == value.KindOptional {
+					bounds[i] = 1
+					continue
+				}
+				if v.Kind != value.KindInt {
+					return value.Value{}, fmt.Errorf("range: %s must be int, got %s", []string{"start", "end", "step"}[i], typeName(v))
+				}
+				bounds[i] = v.Int()
+			}
+			start, end, step := bounds[0], bounds[1], bounds[2]
+			if step == 0 {
+				return value.Value{}, fmt.Errorf("range: step must not be 0")
+			}
+			var nums []value.Value
+			for n := start; (step > 0 && n < end) || (step < 0 && n > end); n += step {
+				nums = append(nums, value.Int(n))
+				if (step > 0 && n > end-step) || (step < 0 && n < end-step) {
+					break // the next step would pass end or overflow
+				}
+			}
+			if nums == nil {
+				nums = []value.Value{}
+			}
+			return value.List(nums), nil
+		},
+	})
+}
@@ -42,10 +42,10 @@ func init() {
 			acc := initial
 
 			// Apply reducer to each element
-			for i, elem := range receiver.List {
+			for i, elem := range receiver.List() {
 				// Call the reducer with (accumulator, element)
 				callArgs := []interface{}{acc, elem}
-				result, err := env.CallClosure(reducerVal.Closure, callArgs)
+				result, err := env.CallClosure(reducerVal.Closure(), callArgs)
 				if err != nil {
 					return value.Value{}, fmt.Errorf("list.reduce: error calling reducer at index %d: %w", i, err)
 				}
@@ -36,8 +36,8 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.removeAt: index argument must be int, got %v", indexVal.Kind)
 			}
 
-			index := int(indexVal.Int)
-			listLen := len(receiver.List)
+			index := int(indexVal.Int())
+			listLen := len(receiver.List())
 
 			// Validate index bounds
 			if index < 0 || index >= listLen {
@@ -46,8 +46,8 @@ func init() {
 
 			// Create new list without the element at index
 			newList := make([]value.Value, listLen-1)
-			copy(newList[:index], receiver.List[:index])
-			copy(newList[index:], receiver.List[index+1:])
+			copy(newList[:index], receiver.List()[:index])
+			copy(newList[index:], receiver.List()[index+1:])
 
 			return value.List(newList), nil
 		},
@@ -32,10 +32,10 @@ func init() {
 			}
 
 			// Create reversed list
-			listLen := len(receiver.List)
+			listLen := len(receiver.List())
 			reversed := make([]value.Value, listLen)
 			for i := 0; i < listLen; i++ {
-				reversed[i] = receiver.List[listLen-1-i]
+				reversed[i] = receiver.List()[listLen-1-i]
 			}
 
 			return value.List(reversed), nil
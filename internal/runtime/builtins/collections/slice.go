@@ -41,9 +41,9 @@ func init() {
 				return value.Value{}, fmt.Errorf("list.slice: end argument must be int, got %v", endVal.Kind)
 			}
 
-			start := int(startVal.Int)
-			end := int(endVal.Int)
-			listLen := len(receiver.List)
+			start := int(startVal.Int())
+			end := int(endVal.Int())
+			listLen := len(receiver.List())
 
 			// Validate range bounds
 			if start < 0 || start > listLen {
@@ -57,7 +57,7 @@ func init() {
 			}
 
 			// Create slice
-			slice := receiver.List[start:end]
+			slice := receiver.List()[start:end]
 			resultList := make([]value.Value, len(slice))
 			copy(resultList, slice)
 
@@ -0,0 +1,184 @@
+package collections
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListSort,
+			Name:         "sort",
+			Arity:        2, // receiver + optional comparator
+			ParamNames:   []string{"self", "cmp"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       anyList,
+			ReceiverType: builtins.TypeList,
+			MethodName:   "sort",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "sort")
+			if err != nil {
+				return value.Value{}, err
+			}
+			cmpVal := args[1].(value.Value)
+			if cmpVal.Kind == value.KindOptional {
+				// sort() without a comparator
+				sorted := slices.Clone(elems)
+				if err := sortStable(sorted, compareValues); err != nil {
+					return value.Value{}, fmt.Errorf("list.sort: %w", err)
+				}
+				return value.List(sorted), nil
+			}
+			if err := requireFn(cmpVal, "sort", "cmp"); err != nil {
+				return value.Value{}, err
+			}
+			sorted := slices.Clone(elems)
+			err = sortStable(sorted, func(a, b value.Value) (int, error) {
+				res, err := callFn(env, cmpVal, "sort", 0, a, b)
+				if err != nil {
+					return 0, err
+				}
+				if res.Kind != value.KindInt {
+					return 0, fmt.Errorf("list.sort: comparator must return int, got %s", typeName(res))
+				}
+				return cmp.Compare(res.Int(), 0), nil
+			})
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.List(sorted), nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListSortBy,
+			Name:         "sortBy",
+			Arity:        2, // receiver + key function
+			ParamNames:   []string{"self", "key"},
+			Params:       []builtins.TypeRef{anyList, {Kind: builtins.TypeAny}},
+			Result:       anyList,
+			ReceiverType: builtins.TypeList,
+			MethodName:   "sortBy",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "sortBy")
+			if err != nil {
+				return value.Value{}, err
+			}
+			keyFn := args[1].(value.Value)
+			if err := requireFn(keyFn, "sortBy", "key"); err != nil {
+				return value.Value{}, err
+			}
+			// Compute each key once, then sort the (key, element) pairs.
+			pairs := make([]value.Value, len(elems))
+			for i, elem := range elems {
+				key, err := callFn(env, keyFn, "sortBy", i, elem)
+				if err != nil {
+					return value.Value{}, err
+				}
+				pairs[i] = value.List([]value.Value{key, elem})
+			}
+			err = sortStable(pairs, func(a, b value.Value) (int, error) {
+				return compareValues(a.List()[0], b.List()[0])
+			})
+			if err != nil {
+				return value.Value{}, fmt.Errorf("list.sortBy: %w", err)
+			}
+			sorted := make([]value.Value, len(pairs))
+			for i, p := range pairs {
+				sorted[i] = p.List()[1]
+			}
+			return value.List(sorted), nil
+		},
+	})
+}
+
+// sortStable sorts elems in place with compare, keeping the order of equal
+// elements. It stops comparing after the first error.
+func sortStable(elems []value.Value, compare func(a, b value.Value) (int, error)) error {
+	var firstErr error
+	slices.SortStableFunc(elems, func(a, b value.Value) int {
+		if firstErr != nil {
+			return 0
+		}
+		c, err := compare(a, b)
+		if err != nil {
+			firstErr = err
+		}
+		return c
+	})
+	return firstErr
+}
+
+// compareValues orders two ints, floats, strings or bytes values; ints and
+// floats compare with each other by value.
+func compareValues(a, b value.Value) (int, error) {
+	switch {
+	case a.Kind == value.KindInt && b.Kind == value.KindInt:
+		return cmp.Compare(a.Int(), b.Int()), nil
+	case isNumber(a) && isNumber(b):
+		return cmp.Compare(toFloat(a), toFloat(b)), nil
+	case a.Kind == value.KindString && b.Kind == value.KindString:
+		return strings.Compare(a.Str(), b.Str()), nil
+	case a.Kind == value.KindBytes && b.Kind == value.KindBytes:
+		return bytes.Compare(a.Bytes(), b.Bytes()), nil
+	}
+	if c, ok := value.CompareBig(a, b); ok {
+		return c, nil
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(a), typeName(b))
+}
+
+// typeName names the type of v in comparison errors.
+func typeName(v value.Value) string {
+	switch v.Kind {
+	case value.KindInt:
+		return "int"
+	case value.KindFloat:
+		return "float"
+	case value.KindBigInt:
+		return "bigint"
+	case value.KindDecimal:
+		return "decimal"
+	case value.KindString:
+		return "string"
+	case value.KindBool:
+		return "bool"
+	case value.KindBytes:
+		return "bytes"
+	case value.KindList:
+		return "list"
+	case value.KindDict:
+		return "dict"
+	case value.KindSet:
+		return "set"
+	case value.KindOptional:
+		return "optional"
+	case value.KindStruct:
+		return "struct"
+	case value.KindError:
+		return "error"
+	}
+	return "function"
+}
+
+func isNumber(v value.Value) bool {
+	return v.Kind == value.KindInt || v.Kind == value.KindFloat
+}
+
+func toFloat(v value.Value) float64 {
+	if v.Kind == value.KindInt {
+		return float64(v.Int())
+	}
+	return v.Float()
+}
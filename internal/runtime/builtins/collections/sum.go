@@ -0,0 +1,53 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListSum,
+			Name:         "sum",
+			Arity:        1, // receiver only
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{anyList},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny}, // checker specializes to int or float
+			ReceiverType: builtins.TypeList,
+			MethodName:   "sum",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 1, "sum")
+			if err != nil {
+				return value.Value{}, err
+			}
+			// The sum is an int while all elements are ints, and a float
+			// once a float is seen. An empty list sums to 0.
+			var n int64
+			var f float64
+			isFloat := false
+			for i, elem := range elems {
+				switch {
+				case elem.Kind == value.KindInt && !isFloat:
+					n += elem.Int()
+				case elem.Kind == value.KindInt:
+					f += float64(elem.Int())
+				case elem.Kind == value.KindFloat:
+					if !isFloat {
+						f, isFloat = float64(n), true
+					}
+					f += elem.Float()
+				default:
+					return value.Value{}, fmt.Errorf("list.sum: element at index %d must be a number, got %s", i, typeName(elem))
+				}
+			}
+			if isFloat {
+				return value.Float(f), nil
+			}
+			return value.Int(n), nil
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package collections
+
+import (
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListUnique,
+			Name:         "unique",
+			Arity:        1, // receiver only
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{anyList},
+			Result:       anyList,
+			ReceiverType: builtins.TypeList,
+			MethodName:   "unique",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 1, "unique")
+			if err != nil {
+				return value.Value{}, err
+			}
+			// Keep the first occurrence of each element. Hashable elements
+			// are looked up in a set; the others, such as floats and lists,
+			// are compared with the kept ones.
+			seen := value.NewSet(len(elems))
+			var others []value.Value
+			result := make([]value.Value, 0, len(elems))
+			for _, elem := range elems {
+				if value.Hashable(elem) {
+					if seen.Has(elem) {
+						continue
+					}
+					seen.Add(elem)
+				} else {
+					if containsEqual(others, elem) {
+						continue
+					}
+					others = append(others, elem)
+				}
+				result = append(result, elem)
+			}
+			return value.List(result), nil
+		},
+	})
+}
+
+func containsEqual(elems []value.Value, v value.Value) bool {
+	for _, e := range elems {
+		if equalValues(e, v) {
+			return true
+		}
+	}
+	return false
+}
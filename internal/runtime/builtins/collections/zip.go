@@ -0,0 +1,40 @@
+package collections
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ListZip,
+			Name:         "zip",
+			Arity:        2, // receiver + other list
+			ParamNames:   []string{"self", "other"},
+			Params:       []builtins.TypeRef{anyList, anyList},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{anyList}},
+			ReceiverType: builtins.TypeList,
+			MethodName:   "zip",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			elems, err := requireList(args, 2, "zip")
+			if err != nil {
+				return value.Value{}, err
+			}
+			other := args[1].(value.Value)
+			if other.Kind != value.KindList {
+				return value.Value{}, fmt.Errorf("list.zip: other argument must be a list, got %s", typeName(other))
+			}
+			// The result is as long as the shorter list.
+			n := min(len(elems), len(other.List()))
+			pairs := make([]value.Value, n)
+			for i := range n {
+				pairs[i] = value.List([]value.Value{elems[i], other.List()[i]})
+			}
+			return value.List(pairs), nil
+		},
+	})
+}
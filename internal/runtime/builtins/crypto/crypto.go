@@ -265,7 +265,7 @@ func registerJWTVerifyHS256() {
 				return value.Dict(status), nil
 			}
 			_ = parts
-			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str != "HS256" {
+			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str() != "HS256" {
 				return value.Dict(jwtStatusInvalid("alg_mismatch", "token alg must be HS256")), nil
 			}
 			mac := hmac.New(sha256.New, secret)
@@ -353,7 +353,7 @@ func registerJWTVerifyRS256() {
 			if status != nil {
 				return value.Dict(status), nil
 			}
-			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str != "RS256" {
+			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str() != "RS256" {
 				return value.Dict(jwtStatusInvalid("alg_mismatch", "token alg must be RS256")), nil
 			}
 			h := sha256.Sum256([]byte(signingInput))
@@ -446,7 +446,7 @@ func registerJWTVerifyES256() {
 			if status != nil {
 				return value.Dict(status), nil
 			}
-			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str != "ES256" {
+			if alg, ok := header["alg"]; !ok || alg.Kind != value.KindString || alg.Str() != "ES256" {
 				return value.Dict(jwtStatusInvalid("alg_mismatch", "token alg must be ES256")), nil
 			}
 			r, s, ok := ecdsaRawSignatureParts(signature, 32)
@@ -532,7 +532,7 @@ func requireBytesArg(args []interface{}, idx int, name string) ([]byte, error) {
 	if v.Kind != value.KindBytes {
 		return nil, fmt.Errorf("%s expects argument %d as bytes", name, idx+1)
 	}
-	return v.Bytes, nil
+	return v.Bytes(), nil
 }
 
 func requireStringArg(args []interface{}, idx int, name string) (string, error) {
@@ -543,7 +543,7 @@ func requireStringArg(args []interface{}, idx int, name string) (string, error)
 	if v.Kind != value.KindString {
 		return "", fmt.Errorf("%s expects argument %d as string", name, idx+1)
 	}
-	return v.Str, nil
+	return v.Str(), nil
 }
 
 func requireIntArg(args []interface{}, idx int, name string) (int64, error) {
@@ -554,7 +554,7 @@ func requireIntArg(args []interface{}, idx int, name string) (int64, error) {
 	if v.Kind != value.KindInt {
 		return 0, fmt.Errorf("%s expects argument %d as int", name, idx+1)
 	}
-	return v.Int, nil
+	return v.Int(), nil
 }
 
 func requireDictArg(args []interface{}, idx int, name string) (map[string]value.Value, error) {
@@ -565,7 +565,7 @@ func requireDictArg(args []interface{}, idx int, name string) (map[string]value.
 	if v.Kind != value.KindDict {
 		return nil, fmt.Errorf("%s expects argument %d as dict<any>", name, idx+1)
 	}
-	return v.Dict, nil
+	return v.Dict().StrMap(), nil
 }
 
 func jwtPrepareHeaderPayload(args []interface{}, alg string, name string) (map[string]value.Value, map[string]value.Value, string, error) {
@@ -607,27 +607,27 @@ func encodeJWTObject(dict map[string]value.Value) ([]byte, error) {
 func valueToJSON(v value.Value) (interface{}, error) {
 	switch v.Kind {
 	case value.KindInt:
-		return v.Int, nil
+		return v.Int(), nil
 	case value.KindFloat:
-		if math.IsNaN(v.Float) || math.IsInf(v.Float, 0) {
+		if math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0) {
 			return nil, fmt.Errorf("non-finite float")
 		}
-		return v.Float, nil
+		return v.Float(), nil
 	case value.KindString:
-		return v.Str, nil
+		return v.Str(), nil
 	case value.KindBool:
-		return v.Bool, nil
+		return v.Bool(), nil
 	case value.KindBytes:
-		return jwtBase64.EncodeToString(v.Bytes), nil
+		return jwtBase64.EncodeToString(v.Bytes()), nil
 	case value.KindOptional:
-		if v.Optional == nil || !v.Optional.IsSome {
+		if v.Optional() == nil || !v.Optional().IsSome {
 			return nil, nil
 		}
-		return valueToJSON(v.Optional.Value)
+		return valueToJSON(v.Optional().Value)
 	case value.KindList:
-		items := make([]interface{}, len(v.List))
-		for i := range v.List {
-			j, err := valueToJSON(v.List[i])
+		items := make([]interface{}, len(v.List()))
+		for i := range v.List() {
+			j, err := valueToJSON(v.List()[i])
 			if err != nil {
 				return nil, err
 			}
@@ -635,8 +635,8 @@ func valueToJSON(v value.Value) (interface{}, error) {
 		}
 		return items, nil
 	case value.KindDict:
-		obj := make(map[string]interface{}, len(v.Dict))
-		for k, item := range v.Dict {
+		obj := make(map[string]interface{}, v.Dict().Len())
+		for k, item := range v.Dict().StrMap() {
 			j, err := valueToJSON(item)
 			if err != nil {
 				return nil, err
@@ -793,9 +793,9 @@ func validateJWTClaims(payload map[string]value.Value, nowUnix int64) map[string
 func claimNumeric(v value.Value) (int64, bool) {
 	switch v.Kind {
 	case value.KindInt:
-		return v.Int, true
+		return v.Int(), true
 	case value.KindFloat:
-		return int64(v.Float), true
+		return int64(v.Float()), true
 	default:
 		return 0, false
 	}
@@ -50,7 +50,7 @@ func TestSHA256AndSHA512(t *testing.T) {
 		t.Fatalf("sha256 expected bytes, got %v", s256.Kind)
 	}
 	want256 := sha256.Sum256(data)
-	if string(s256.Bytes) != string(want256[:]) {
+	if string(s256.Bytes()) != string(want256[:]) {
 		t.Fatalf("sha256 mismatch")
 	}
 
@@ -62,7 +62,7 @@ func TestSHA256AndSHA512(t *testing.T) {
 		t.Fatalf("sha512 expected bytes, got %v", s512.Kind)
 	}
 	want512 := sha512.Sum512(data)
-	if string(s512.Bytes) != string(want512[:]) {
+	if string(s512.Bytes()) != string(want512[:]) {
 		t.Fatalf("sha512 mismatch")
 	}
 }
@@ -84,7 +84,7 @@ func TestHMACSHA256(t *testing.T) {
 	if err != nil {
 		t.Fatalf("hmac verify error: %v", err)
 	}
-	if ok.Kind != value.KindBool || !ok.Bool {
+	if ok.Kind != value.KindBool || !ok.Bool() {
 		t.Fatalf("expected valid signature")
 	}
 
@@ -92,7 +92,7 @@ func TestHMACSHA256(t *testing.T) {
 	if err != nil {
 		t.Fatalf("hmac verify bad error: %v", err)
 	}
-	if bad.Kind != value.KindBool || bad.Bool {
+	if bad.Kind != value.KindBool || bad.Bool() {
 		t.Fatalf("expected invalid signature")
 	}
 }
@@ -111,7 +111,7 @@ func TestBase64URLRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("decode error: %v", err)
 	}
-	if dec.Kind != value.KindBytes || string(dec.Bytes) != string(data.Bytes) {
+	if dec.Kind != value.KindBytes || string(dec.Bytes()) != string(data.Bytes()) {
 		t.Fatalf("roundtrip mismatch")
 	}
 }
@@ -131,7 +131,7 @@ func TestJWTHS256SignVerify(t *testing.T) {
 	if err != nil {
 		t.Fatalf("jwt verify hs256 error: %v", err)
 	}
-	if status.Kind != value.KindDict || !status.Dict["valid"].Bool {
+	if status.Kind != value.KindDict || !status.Dict().StrMap()["valid"].Bool() {
 		t.Fatalf("expected valid JWT status")
 	}
 }
@@ -151,10 +151,10 @@ func TestJWTHS256Expired(t *testing.T) {
 	if err != nil {
 		t.Fatalf("jwt verify hs256 error: %v", err)
 	}
-	if status.Kind != value.KindDict || status.Dict["valid"].Bool {
+	if status.Kind != value.KindDict || status.Dict().StrMap()["valid"].Bool() {
 		t.Fatalf("expected invalid JWT status")
 	}
-	if status.Dict["reason"].Kind != value.KindString || status.Dict["reason"].Str != "expired" {
+	if status.Dict().StrMap()["reason"].Kind != value.KindString || status.Dict().StrMap()["reason"].Str() != "expired" {
 		t.Fatalf("expected expired reason")
 	}
 }
@@ -186,7 +186,7 @@ func TestJWTRS256AndES256(t *testing.T) {
 	if err != nil {
 		t.Fatalf("jwt verify rs256: %v", err)
 	}
-	if rsaStatus.Kind != value.KindDict || !rsaStatus.Dict["valid"].Bool {
+	if rsaStatus.Kind != value.KindDict || !rsaStatus.Dict().StrMap()["valid"].Bool() {
 		t.Fatalf("expected valid RS256 status")
 	}
 
@@ -213,7 +213,7 @@ func TestJWTRS256AndES256(t *testing.T) {
 	if err != nil {
 		t.Fatalf("jwt verify es256: %v", err)
 	}
-	if esStatus.Kind != value.KindDict || !esStatus.Dict["valid"].Bool {
+	if esStatus.Kind != value.KindDict || !esStatus.Dict().StrMap()["valid"].Bool() {
 		t.Fatalf("expected valid ES256 status")
 	}
 }
@@ -224,21 +224,21 @@ func TestPasswordHashVerify(t *testing.T) {
 	if err != nil {
 		t.Fatalf("password hash error: %v", err)
 	}
-	if hash.Kind != value.KindString || hash.Str == "" {
+	if hash.Kind != value.KindString || hash.Str() == "" {
 		t.Fatalf("expected non-empty hash")
 	}
 	ok, err := callBuiltin(t, env, "__builtin_crypto_password_verify", value.Str("pa$$w0rd"), hash)
 	if err != nil {
 		t.Fatalf("password verify error: %v", err)
 	}
-	if ok.Kind != value.KindBool || !ok.Bool {
+	if ok.Kind != value.KindBool || !ok.Bool() {
 		t.Fatalf("expected password to verify")
 	}
 	bad, err := callBuiltin(t, env, "__builtin_crypto_password_verify", value.Str("wrong"), hash)
 	if err != nil {
 		t.Fatalf("password verify wrong error: %v", err)
 	}
-	if bad.Kind != value.KindBool || bad.Bool {
+	if bad.Kind != value.KindBool || bad.Bool() {
 		t.Fatalf("expected wrong password to fail")
 	}
 }
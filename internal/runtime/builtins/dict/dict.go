@@ -15,6 +15,7 @@ func init() {
 	registerGet()
 	registerSet()
 	registerRemove()
+	registerEntries()
 }
 
 func registerLength() {
@@ -36,7 +37,7 @@ func registerLength() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			return value.Int(int64(len(dictVal.Dict))), nil
+			return value.Int(int64(dictVal.Dict().Len())), nil
 		},
 	})
 }
@@ -51,7 +52,7 @@ func registerKeys() {
 			Params: []builtins.TypeRef{
 				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
 			},
-			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}}, // checker specializes to list<K>
 			ReceiverType: builtins.TypeDict,
 			MethodName:   "keys",
 		},
@@ -60,11 +61,7 @@ func registerKeys() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			keys := make([]value.Value, 0, len(dictVal.Dict))
-			for k := range dictVal.Dict {
-				keys = append(keys, value.Str(k))
-			}
-			return value.List(keys), nil
+			return value.List(dictVal.Dict().Keys()), nil
 		},
 	})
 }
@@ -88,11 +85,7 @@ func registerValues() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			values := make([]value.Value, 0, len(dictVal.Dict))
-			for _, v := range dictVal.Dict {
-				values = append(values, v)
-			}
-			return value.List(values), nil
+			return value.List(dictVal.Dict().Values()), nil
 		},
 	})
 }
@@ -106,7 +99,7 @@ func registerHas() {
 			ParamNames: []string{"self", "key"},
 			Params: []builtins.TypeRef{
 				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
-				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeAny},
 			},
 			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
 			ReceiverType: builtins.TypeDict,
@@ -117,11 +110,7 @@ func registerHas() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			keyVal := args[1].(value.Value)
-			if keyVal.Kind != value.KindString {
-				return value.Value{}, fmt.Errorf("dict.has expects key as string")
-			}
-			_, ok := dictVal.Dict[keyVal.Str]
+			_, ok := dictVal.Dict().Get(args[1].(value.Value))
 			return value.Bool(ok), nil
 		},
 	})
@@ -136,7 +125,7 @@ func registerGet() {
 			ParamNames: []string{"self", "key"},
 			Params: []builtins.TypeRef{
 				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
-				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeAny},
 			},
 			Result:       builtins.TypeRef{Kind: builtins.TypeAny}, // checker specializes to T?
 			ReceiverType: builtins.TypeDict,
@@ -147,11 +136,7 @@ func registerGet() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			keyVal := args[1].(value.Value)
-			if keyVal.Kind != value.KindString {
-				return value.Value{}, fmt.Errorf("dict.get expects key as string")
-			}
-			val, ok := dictVal.Dict[keyVal.Str]
+			val, ok := dictVal.Dict().Get(args[1].(value.Value))
 			if !ok {
 				return value.None(), nil
 			}
@@ -169,7 +154,7 @@ func registerSet() {
 			ParamNames: []string{"self", "key", "value"},
 			Params: []builtins.TypeRef{
 				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
-				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeAny},
 				{Kind: builtins.TypeAny},
 			},
 			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
@@ -181,14 +166,12 @@ func registerSet() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			if dictVal.Dict == nil {
+			if dictVal.Dict() == nil {
 				return value.Value{}, fmt.Errorf("dict.set called on nil dict")
 			}
-			keyVal := args[1].(value.Value)
-			if keyVal.Kind != value.KindString {
-				return value.Value{}, fmt.Errorf("dict.set expects key as string")
+			if err := dictVal.Dict().Set(args[1].(value.Value), args[2].(value.Value)); err != nil {
+				return value.Value{}, fmt.Errorf("dict.set: %w", err)
 			}
-			dictVal.Dict[keyVal.Str] = args[2].(value.Value)
 			return value.Value{}, nil
 		},
 	})
@@ -203,7 +186,7 @@ func registerRemove() {
 			ParamNames: []string{"self", "key"},
 			Params: []builtins.TypeRef{
 				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
-				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeAny},
 			},
 			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
 			ReceiverType: builtins.TypeDict,
@@ -214,16 +197,37 @@ func registerRemove() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			keyVal := args[1].(value.Value)
-			if keyVal.Kind != value.KindString {
-				return value.Value{}, fmt.Errorf("dict.remove expects key as string")
+			return value.Bool(dictVal.Dict().Delete(args[1].(value.Value))), nil
+		},
+	})
+}
+
+func registerEntries() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.DictEntries,
+			Name:       "entries",
+			Arity:      1,
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+			},
+			Result: builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{
+				{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+			}}, // checker specializes to list<list<K, V>>
+			ReceiverType: builtins.TypeDict,
+			MethodName:   "entries",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			dictVal, err := requireDict(args, "dict.entries")
+			if err != nil {
+				return value.Value{}, err
 			}
-			if dictVal.Dict == nil {
-				return value.Bool(false), nil
+			entries := make([]value.Value, 0, dictVal.Dict().Len())
+			for k, v := range dictVal.Dict().All() {
+				entries = append(entries, value.List([]value.Value{k, v}))
 			}
-			_, ok := dictVal.Dict[keyVal.Str]
-			delete(dictVal.Dict, keyVal.Str)
-			return value.Bool(ok), nil
+			return value.List(entries), nil
 		},
 	})
 }
@@ -29,7 +29,7 @@ func init() {
 			if arg.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("error expects string, got %v", arg.Kind)
 			}
-			return value.ErrorValue(arg.Str), nil
+			return value.ErrorValue(arg.Str()), nil
 		},
 	})
 }
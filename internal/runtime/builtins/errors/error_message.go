@@ -26,12 +26,24 @@ func init() {
 				return value.Value{}, fmt.Errorf("errorMessage expects 1 argument, got %d", len(args))
 			}
 			arg := args[0].(value.Value)
+			if arg.Kind == value.KindStruct && arg.Struct() != nil {
+				// Typed errors such as std.os PermissionDenied carry their
+				// text in a message field.
+				if env != nil {
+					if i, ok := env.StructFieldIndex(arg.Struct().TypeIndex, "message"); ok && i < len(arg.Struct().Fields) {
+						if msg := arg.Struct().Fields[i]; msg.Kind == value.KindString {
+							return msg, nil
+						}
+					}
+				}
+				return value.Str(arg.String()), nil
+			}
 			if arg.Kind != value.KindError {
 				return value.Value{}, fmt.Errorf("errorMessage expects error, got %v", arg.Kind)
 			}
-			msg := arg.Str
-			if arg.Error != nil && arg.Error.Message != "" {
-				msg = arg.Error.Message
+			msg := arg.Str()
+			if arg.Error() != nil && arg.Error().Message != "" {
+				msg = arg.Error().Message
 			}
 			return value.Str(msg), nil
 		},
@@ -0,0 +1,14 @@
+package builtins
+
+import "fmt"
+
+// ExitError is returned by builtins that terminate the program with an exit
+// code. It is not catchable from Avenir code: the VM runs pending defers,
+// then hands it to the host, which exits the process with Code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
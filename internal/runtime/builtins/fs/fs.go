@@ -16,6 +16,19 @@ func init() {
 	registerRemove()
 	registerMkdir()
 	registerExecRoot()
+	registerStat()
+	registerLstat()
+	registerReadDir()
+	registerRename()
+	registerCopy()
+	registerMkdirAll()
+	registerRemoveAll()
+	registerChmod()
+	registerSymlink()
+	registerReadlink()
+	registerGlob()
+	registerSeek()
+	registerTruncate()
 }
 
 func registerOpen() {
@@ -48,7 +61,7 @@ func registerOpen() {
 			if modeVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_fs_open expects mode as string")
 			}
-			handle, err := env.FS().Open(pathVal.Str, modeVal.Str)
+			handle, err := env.FS().Open(pathVal.Str(), modeVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -87,7 +100,7 @@ func registerRead() {
 			if nVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_fs_read expects n as int")
 			}
-			data, err := env.FS().Read(handle, int(nVal.Int))
+			data, err := env.FS().Read(handle, int(nVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -126,7 +139,7 @@ func registerWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return value.Value{}, fmt.Errorf("__builtin_fs_write expects data as bytes")
 			}
-			n, err := env.FS().Write(handle, dataVal.Bytes)
+			n, err := env.FS().Write(handle, dataVal.Bytes())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -193,7 +206,7 @@ func registerExists() {
 			if pathVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_fs_exists expects path as string")
 			}
-			ok, err := env.FS().Exists(pathVal.Str)
+			ok, err := env.FS().Exists(pathVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -227,7 +240,7 @@ func registerRemove() {
 			if pathVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_fs_remove expects path as string")
 			}
-			if err := env.FS().Remove(pathVal.Str); err != nil {
+			if err := env.FS().Remove(pathVal.Str()); err != nil {
 				return value.Value{}, err
 			}
 			return value.Value{}, nil
@@ -260,7 +273,7 @@ func registerMkdir() {
 			if pathVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_fs_mkdir expects path as string")
 			}
-			if err := env.FS().Mkdir(pathVal.Str); err != nil {
+			if err := env.FS().Mkdir(pathVal.Str()); err != nil {
 				return value.Value{}, err
 			}
 			return value.Value{}, nil
@@ -272,8 +285,510 @@ func requireHandle(val value.Value) ([]byte, error) {
 	if val.Kind != value.KindBytes {
 		return nil, fmt.Errorf("file handle must be bytes")
 	}
-	if len(val.Bytes) == 0 {
+	if len(val.Bytes()) == 0 {
 		return nil, fmt.Errorf("file handle is empty")
 	}
-	return val.Bytes, nil
+	return val.Bytes(), nil
+}
+
+func registerStat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSStat,
+			Name:       "__builtin_fs_stat",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_stat")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_stat", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Stat(path)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return infoValue(res), nil
+		},
+	})
+}
+
+func registerLstat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSLstat,
+			Name:       "__builtin_fs_lstat",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_lstat")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_lstat", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Lstat(path)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return infoValue(res), nil
+		},
+	})
+}
+
+func registerReadDir() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSReadDir,
+			Name:       "__builtin_fs_read_dir",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_read_dir")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_read_dir", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.ReadDir(path)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return infoList(res), nil
+		},
+	})
+}
+
+func registerRename() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSRename,
+			Name:       "__builtin_fs_rename",
+			Arity:      2,
+			ParamNames: []string{"from", "to"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_rename")
+			if err != nil {
+				return value.Value{}, err
+			}
+			from, err := requireString(args[0], "__builtin_fs_rename", "from")
+			if err != nil {
+				return value.Value{}, err
+			}
+			to, err := requireString(args[1], "__builtin_fs_rename", "to")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Rename(from, to); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerCopy() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSCopy,
+			Name:       "__builtin_fs_copy",
+			Arity:      2,
+			ParamNames: []string{"from", "to"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_copy")
+			if err != nil {
+				return value.Value{}, err
+			}
+			from, err := requireString(args[0], "__builtin_fs_copy", "from")
+			if err != nil {
+				return value.Value{}, err
+			}
+			to, err := requireString(args[1], "__builtin_fs_copy", "to")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Copy(from, to); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerMkdirAll() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSMkdirAll,
+			Name:       "__builtin_fs_mkdir_all",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_mkdir_all")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_mkdir_all", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.MkdirAll(path); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerRemoveAll() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSRemoveAll,
+			Name:       "__builtin_fs_remove_all",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_remove_all")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_remove_all", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.RemoveAll(path); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerChmod() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSChmod,
+			Name:       "__builtin_fs_chmod",
+			Arity:      2,
+			ParamNames: []string{"path", "mode"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_chmod")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_chmod", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			mode, err := requireInt(args[1], "__builtin_fs_chmod", "mode")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Chmod(path, uint32(mode)); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerSymlink() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSSymlink,
+			Name:       "__builtin_fs_symlink",
+			Arity:      2,
+			ParamNames: []string{"target", "link"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_symlink")
+			if err != nil {
+				return value.Value{}, err
+			}
+			target, err := requireString(args[0], "__builtin_fs_symlink", "target")
+			if err != nil {
+				return value.Value{}, err
+			}
+			link, err := requireString(args[1], "__builtin_fs_symlink", "link")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Symlink(target, link); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerReadlink() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSReadlink,
+			Name:       "__builtin_fs_readlink",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_readlink")
+			if err != nil {
+				return value.Value{}, err
+			}
+			path, err := requireString(args[0], "__builtin_fs_readlink", "path")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Readlink(path)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(res), nil
+		},
+	})
+}
+
+func registerGlob() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSGlob,
+			Name:       "__builtin_fs_glob",
+			Arity:      2,
+			ParamNames: []string{"dir", "pattern"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_glob")
+			if err != nil {
+				return value.Value{}, err
+			}
+			dir, err := requireString(args[0], "__builtin_fs_glob", "dir")
+			if err != nil {
+				return value.Value{}, err
+			}
+			pattern, err := requireString(args[1], "__builtin_fs_glob", "pattern")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Glob(dir, pattern)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return stringList(res), nil
+		},
+	})
+}
+
+func registerSeek() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSSeek,
+			Name:       "__builtin_fs_seek",
+			Arity:      3,
+			ParamNames: []string{"file", "offset", "whence"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 3, "__builtin_fs_seek")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return value.Value{}, err
+			}
+			offset, err := requireInt(args[1], "__builtin_fs_seek", "offset")
+			if err != nil {
+				return value.Value{}, err
+			}
+			whence, err := requireInt(args[2], "__builtin_fs_seek", "whence")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Seek(handle, offset, int(whence))
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(res), nil
+		},
+	})
+}
+
+func registerTruncate() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSTruncate,
+			Name:       "__builtin_fs_truncate",
+			Arity:      2,
+			ParamNames: []string{"file", "size"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_truncate")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return value.Value{}, err
+			}
+			size, err := requireInt(args[1], "__builtin_fs_truncate", "size")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Truncate(handle, size); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func requireFS(env builtins.Env, args []interface{}, arity int, name string) (builtins.FS, error) {
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, arity, len(args))
+	}
+	if env == nil || env.FS() == nil {
+		return nil, fmt.Errorf("runtime env fs is nil")
+	}
+	return env.FS(), nil
+}
+
+func requireString(arg interface{}, name, param string) (string, error) {
+	v := arg.(value.Value)
+	if v.Kind != value.KindString {
+		return "", fmt.Errorf("%s expects %s as string", name, param)
+	}
+	return v.Str(), nil
+}
+
+func requireInt(arg interface{}, name, param string) (int64, error) {
+	v := arg.(value.Value)
+	if v.Kind != value.KindInt {
+		return 0, fmt.Errorf("%s expects %s as int", name, param)
+	}
+	return v.Int(), nil
+}
+
+func infoValue(info *builtins.FileInfo) value.Value {
+	return value.Dict(map[string]value.Value{
+		"name":      value.Str(info.Name),
+		"size":      value.Int(info.Size),
+		"mode":      value.Int(int64(info.Mode)),
+		"mtime":     value.Int(info.ModTime),
+		"isDir":     value.Bool(info.IsDir),
+		"isSymlink": value.Bool(info.IsSymlink),
+	})
+}
+
+func infoList(infos []builtins.FileInfo) value.Value {
+	items := make([]value.Value, len(infos))
+	for i := range infos {
+		items[i] = infoValue(&infos[i])
+	}
+	return value.List(items)
+}
+
+func stringList(strs []string) value.Value {
+	items := make([]value.Value, len(strs))
+	for i, s := range strs {
+		items[i] = value.Str(s)
+	}
+	return value.List(items)
 }
@@ -15,6 +15,19 @@ func init() {
 	registerAsyncExists()
 	registerAsyncRemove()
 	registerAsyncMkdir()
+	registerAsyncStat()
+	registerAsyncLstat()
+	registerAsyncReadDir()
+	registerAsyncRename()
+	registerAsyncCopy()
+	registerAsyncMkdirAll()
+	registerAsyncRemoveAll()
+	registerAsyncChmod()
+	registerAsyncSymlink()
+	registerAsyncReadlink()
+	registerAsyncGlob()
+	registerAsyncSeek()
+	registerAsyncTruncate()
 }
 
 func registerAsyncOpen() {
@@ -46,7 +59,7 @@ func registerAsyncOpen() {
 			if modeVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_fs_open expects mode as string")
 			}
-			p, m := pathVal.Str, modeVal.Str
+			p, m := pathVal.Str(), modeVal.Str()
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := fsService.Open(p, m)
@@ -88,7 +101,7 @@ func registerAsyncRead() {
 			if nVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_async_fs_read expects n as int")
 			}
-			n := int(nVal.Int)
+			n := int(nVal.Int())
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				data, err := fsService.Read(handle, n)
@@ -130,7 +143,7 @@ func registerAsyncWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return nil, fmt.Errorf("__builtin_async_fs_write expects data as bytes")
 			}
-			data := append([]byte(nil), dataVal.Bytes...)
+			data := append([]byte(nil), dataVal.Bytes()...)
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				n, err := fsService.Write(handle, data)
@@ -202,7 +215,7 @@ func registerAsyncExists() {
 			if pathVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_fs_exists expects path as string")
 			}
-			p := pathVal.Str
+			p := pathVal.Str()
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				ok, err := fsService.Exists(p)
@@ -239,7 +252,7 @@ func registerAsyncRemove() {
 			if pathVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_fs_remove expects path as string")
 			}
-			p := pathVal.Str
+			p := pathVal.Str()
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := fsService.Remove(p); err != nil {
@@ -275,7 +288,7 @@ func registerAsyncMkdir() {
 			if pathVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_fs_mkdir expects path as string")
 			}
-			p := pathVal.Str
+			p := pathVal.Str()
 			fsService := env.FS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := fsService.Mkdir(p); err != nil {
@@ -286,3 +299,465 @@ func registerAsyncMkdir() {
 		},
 	})
 }
+
+func registerAsyncStat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSStat,
+			Name:       "__builtin_async_fs_stat",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_stat")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_stat", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Stat(path)
+				if err != nil {
+					return nil, err
+				}
+				return infoValue(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncLstat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSLstat,
+			Name:       "__builtin_async_fs_lstat",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_lstat")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_lstat", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Lstat(path)
+				if err != nil {
+					return nil, err
+				}
+				return infoValue(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncReadDir() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSReadDir,
+			Name:       "__builtin_async_fs_read_dir",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_read_dir")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_read_dir", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.ReadDir(path)
+				if err != nil {
+					return nil, err
+				}
+				return infoList(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncRename() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSRename,
+			Name:       "__builtin_async_fs_rename",
+			Arity:      2,
+			ParamNames: []string{"from", "to"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_rename")
+			if err != nil {
+				return nil, err
+			}
+			from, err := requireString(args[0], "__builtin_async_fs_rename", "from")
+			if err != nil {
+				return nil, err
+			}
+			to, err := requireString(args[1], "__builtin_async_fs_rename", "to")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.Rename(from, to); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncCopy() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSCopy,
+			Name:       "__builtin_async_fs_copy",
+			Arity:      2,
+			ParamNames: []string{"from", "to"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_copy")
+			if err != nil {
+				return nil, err
+			}
+			from, err := requireString(args[0], "__builtin_async_fs_copy", "from")
+			if err != nil {
+				return nil, err
+			}
+			to, err := requireString(args[1], "__builtin_async_fs_copy", "to")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.Copy(from, to); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncMkdirAll() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSMkdirAll,
+			Name:       "__builtin_async_fs_mkdir_all",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_mkdir_all")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_mkdir_all", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.MkdirAll(path); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncRemoveAll() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSRemoveAll,
+			Name:       "__builtin_async_fs_remove_all",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_remove_all")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_remove_all", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.RemoveAll(path); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncChmod() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSChmod,
+			Name:       "__builtin_async_fs_chmod",
+			Arity:      2,
+			ParamNames: []string{"path", "mode"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_chmod")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_chmod", "path")
+			if err != nil {
+				return nil, err
+			}
+			mode, err := requireInt(args[1], "__builtin_async_fs_chmod", "mode")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.Chmod(path, uint32(mode)); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncSymlink() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSSymlink,
+			Name:       "__builtin_async_fs_symlink",
+			Arity:      2,
+			ParamNames: []string{"target", "link"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_symlink")
+			if err != nil {
+				return nil, err
+			}
+			target, err := requireString(args[0], "__builtin_async_fs_symlink", "target")
+			if err != nil {
+				return nil, err
+			}
+			link, err := requireString(args[1], "__builtin_async_fs_symlink", "link")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.Symlink(target, link); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncReadlink() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSReadlink,
+			Name:       "__builtin_async_fs_readlink",
+			Arity:      1,
+			ParamNames: []string{"path"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_readlink")
+			if err != nil {
+				return nil, err
+			}
+			path, err := requireString(args[0], "__builtin_async_fs_readlink", "path")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Readlink(path)
+				if err != nil {
+					return nil, err
+				}
+				return value.Str(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncGlob() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSGlob,
+			Name:       "__builtin_async_fs_glob",
+			Arity:      2,
+			ParamNames: []string{"dir", "pattern"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_glob")
+			if err != nil {
+				return nil, err
+			}
+			dir, err := requireString(args[0], "__builtin_async_fs_glob", "dir")
+			if err != nil {
+				return nil, err
+			}
+			pattern, err := requireString(args[1], "__builtin_async_fs_glob", "pattern")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Glob(dir, pattern)
+				if err != nil {
+					return nil, err
+				}
+				return stringList(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncSeek() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSSeek,
+			Name:       "__builtin_async_fs_seek",
+			Arity:      3,
+			ParamNames: []string{"file", "offset", "whence"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 3, "__builtin_async_fs_seek")
+			if err != nil {
+				return nil, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return nil, err
+			}
+			offset, err := requireInt(args[1], "__builtin_async_fs_seek", "offset")
+			if err != nil {
+				return nil, err
+			}
+			whence, err := requireInt(args[2], "__builtin_async_fs_seek", "whence")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Seek(handle, offset, int(whence))
+				if err != nil {
+					return nil, err
+				}
+				return value.Int(res), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncTruncate() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSTruncate,
+			Name:       "__builtin_async_fs_truncate",
+			Arity:      2,
+			ParamNames: []string{"file", "size"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_async_fs_truncate")
+			if err != nil {
+				return nil, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return nil, err
+			}
+			size, err := requireInt(args[1], "__builtin_async_fs_truncate", "size")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				if err := svc.Truncate(handle, size); err != nil {
+					return nil, err
+				}
+				return value.Value{}, nil
+			}), nil
+		},
+	})
+}
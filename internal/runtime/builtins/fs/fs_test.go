@@ -43,7 +43,7 @@ func TestFSOpenWriteReadClose(t *testing.T) {
 	data := callBuiltin(t, env, "__builtin_fs_read", readHandle, value.Int(5))
 	callBuiltin(t, env, "__builtin_fs_close", readHandle)
 
-	if data.Kind != value.KindBytes || string(data.Bytes) != "hello" {
+	if data.Kind != value.KindBytes || string(data.Bytes()) != "hello" {
 		t.Fatalf("expected %q, got %v", "hello", data.String())
 	}
 }
@@ -54,19 +54,69 @@ func TestFSExistsMkdirRemove(t *testing.T) {
 	env := runtime.DefaultEnv()
 
 	exists := callBuiltin(t, env, "__builtin_fs_exists", value.Str(path))
-	if exists.Kind != value.KindBool || exists.Bool {
+	if exists.Kind != value.KindBool || exists.Bool() {
 		t.Fatalf("expected exists=false, got %v", exists.String())
 	}
 
 	callBuiltin(t, env, "__builtin_fs_mkdir", value.Str(path))
 	exists = callBuiltin(t, env, "__builtin_fs_exists", value.Str(path))
-	if exists.Kind != value.KindBool || !exists.Bool {
+	if exists.Kind != value.KindBool || !exists.Bool() {
 		t.Fatalf("expected exists=true, got %v", exists.String())
 	}
 
 	callBuiltin(t, env, "__builtin_fs_remove", value.Str(path))
 	exists = callBuiltin(t, env, "__builtin_fs_exists", value.Str(path))
-	if exists.Kind != value.KindBool || exists.Bool {
+	if exists.Kind != value.KindBool || exists.Bool() {
 		t.Fatalf("expected exists=false after remove, got %v", exists.String())
 	}
 }
+
+func TestFSStatReadDirGlob(t *testing.T) {
+	dir := t.TempDir()
+	env := runtime.DefaultEnv()
+	callBuiltin(t, env, "__builtin_fs_mkdir_all", value.Str(filepath.Join(dir, "b", "c")))
+	handle := callBuiltin(t, env, "__builtin_fs_open", value.Str(filepath.Join(dir, "a.txt")), value.Str("w"))
+	callBuiltin(t, env, "__builtin_fs_write", handle, value.Bytes([]byte("abc")))
+	callBuiltin(t, env, "__builtin_fs_close", handle)
+
+	info := callBuiltin(t, env, "__builtin_fs_stat", value.Str(filepath.Join(dir, "a.txt")))
+	if info.Dict().StrMap()["name"].Str() != "a.txt" || info.Dict().StrMap()["size"].Int() != 3 || info.Dict().StrMap()["isDir"].Bool() {
+		t.Fatalf("unexpected stat result %v", info.String())
+	}
+
+	entries := callBuiltin(t, env, "__builtin_fs_read_dir", value.Str(dir))
+	if len(entries.List()) != 2 || entries.List()[0].Dict().StrMap()["name"].Str() != "a.txt" || !entries.List()[1].Dict().StrMap()["isDir"].Bool() {
+		t.Fatalf("unexpected entries %v", entries.String())
+	}
+
+	matches := callBuiltin(t, env, "__builtin_fs_glob", value.Str(dir), value.Str("*.txt"))
+	if len(matches.List()) != 1 || matches.List()[0].Str() != "a.txt" {
+		t.Fatalf("expected relative match a.txt, got %v", matches.String())
+	}
+	matches = callBuiltin(t, env, "__builtin_fs_glob", value.Str(dir), value.Str(filepath.Join(dir, "b", "*")))
+	if len(matches.List()) != 1 || matches.List()[0].Str() != filepath.Join(dir, "b", "c") {
+		t.Fatalf("expected absolute match, got %v", matches.String())
+	}
+}
+
+func TestFSSeekTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	env := runtime.DefaultEnv()
+	handle := callBuiltin(t, env, "__builtin_fs_open", value.Str(path), value.Str("w+"))
+	defer callBuiltin(t, env, "__builtin_fs_close", handle)
+	callBuiltin(t, env, "__builtin_fs_write", handle, value.Bytes([]byte("0123456789")))
+
+	pos := callBuiltin(t, env, "__builtin_fs_seek", handle, value.Int(-3), value.Int(2))
+	if pos.Int() != 7 {
+		t.Fatalf("expected offset 7, got %d", pos.Int())
+	}
+	data := callBuiltin(t, env, "__builtin_fs_read", handle, value.Int(10))
+	if string(data.Bytes()) != "789" {
+		t.Fatalf("expected %q, got %q", "789", data.Bytes())
+	}
+	callBuiltin(t, env, "__builtin_fs_truncate", handle, value.Int(4))
+	info := callBuiltin(t, env, "__builtin_fs_stat", value.Str(path))
+	if info.Dict().StrMap()["size"].Int() != 4 {
+		t.Fatalf("expected size 4 after truncate, got %d", info.Dict().StrMap()["size"].Int())
+	}
+}
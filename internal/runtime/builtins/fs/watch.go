@@ -0,0 +1,152 @@
+package fs
+
+import (
+	"fmt"
+	"time"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerWatch()
+	registerAsyncWatchNext()
+	registerWatchClose()
+}
+
+func registerWatch() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSWatch,
+			Name:       "__builtin_fs_watch",
+			Arity:      2,
+			ParamNames: []string{"paths", "opts"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+				{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 2, "__builtin_fs_watch")
+			if err != nil {
+				return value.Value{}, err
+			}
+			pathsVal := args[0].(value.Value)
+			if pathsVal.Kind != value.KindList {
+				return value.Value{}, fmt.Errorf("__builtin_fs_watch expects paths as list<string>")
+			}
+			paths := make([]string, len(pathsVal.List()))
+			for i, p := range pathsVal.List() {
+				if p.Kind != value.KindString {
+					return value.Value{}, fmt.Errorf("__builtin_fs_watch expects paths as list<string>")
+				}
+				paths[i] = p.Str()
+			}
+			opts, err := parseWatchOptions(args[1].(value.Value))
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := svc.Watch(paths, opts)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bytes(handle), nil
+		},
+	})
+}
+
+func registerAsyncWatchNext() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.AsyncFSWatchNext,
+			Name:       "__builtin_async_fs_watch_next",
+			Arity:      1,
+			ParamNames: []string{"watcher"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_async_fs_watch_next")
+			if err != nil {
+				return nil, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return nil, err
+			}
+			return svc.NextWatchEvents(handle), nil
+		},
+	})
+}
+
+func registerWatchClose() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.FSWatchClose,
+			Name:       "__builtin_fs_watch_close",
+			Arity:      1,
+			ParamNames: []string{"watcher"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireFS(env, args, 1, "__builtin_fs_watch_close")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value))
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.CloseWatch(handle); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+// parseWatchOptions reads recursive, debounceMs, poll and pollIntervalMs.
+// Directories are watched recursively unless recursive is false.
+func parseWatchOptions(optsVal value.Value) (builtins.WatchOptions, error) {
+	opts := builtins.WatchOptions{Recursive: true}
+	if optsVal.Kind != value.KindDict {
+		return opts, fmt.Errorf("__builtin_fs_watch expects opts as dict")
+	}
+	for key, opt := range optsVal.Dict().StrMap() {
+		switch key {
+		case "recursive", "poll":
+			if opt.Kind != value.KindBool {
+				return opts, fmt.Errorf("__builtin_fs_watch: opts.%s must be bool", key)
+			}
+			if key == "recursive" {
+				opts.Recursive = opt.Bool()
+			} else {
+				opts.Poll = opt.Bool()
+			}
+		case "debounceMs", "pollIntervalMs":
+			if opt.Kind != value.KindInt || opt.Int() < 0 {
+				return opts, fmt.Errorf("__builtin_fs_watch: opts.%s must be a non-negative int", key)
+			}
+			d := time.Duration(opt.Int()) * time.Millisecond
+			if key == "debounceMs" {
+				opts.Debounce = d
+			} else {
+				opts.PollInterval = d
+			}
+		default:
+			return opts, fmt.Errorf("__builtin_fs_watch: unknown option %q", key)
+		}
+	}
+	return opts, nil
+}
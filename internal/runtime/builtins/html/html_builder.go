@@ -42,7 +42,7 @@ func registerNewBuilder() {
 		},
 		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
 			h := &builderHandle{}
-			return value.Value{Kind: value.KindBytes, Bytes: encodeBuilderPtr(h)}, nil
+			return value.Bytes(encodeBuilderPtr(h)), nil
 		},
 	})
 }
@@ -72,11 +72,11 @@ func registerTag() {
 			first := args[2].(value.Value)
 			second := args[3].(value.Value)
 
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.tag: invalid builder handle")
 			}
-			tag := tagVal.Str
+			tag := tagVal.Str()
 
 			attrs, content := classifyArgs(first, second)
 
@@ -95,7 +95,7 @@ func registerTag() {
 			h.buf.WriteString(tag)
 			h.buf.WriteByte('>')
 
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			return value.None(), nil
 		},
 	})
 }
@@ -123,19 +123,19 @@ func registerVoidTag() {
 			tagVal := args[1].(value.Value)
 			attrsVal := args[2].(value.Value)
 
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.void_tag: invalid builder handle")
 			}
 
 			h.buf.WriteByte('<')
-			h.buf.WriteString(tagVal.Str)
-			if attrsVal.Kind == value.KindDict && len(attrsVal.Dict) > 0 {
-				writeAttrs(&h.buf, attrsVal.Dict)
+			h.buf.WriteString(tagVal.Str())
+			if attrsVal.Kind == value.KindDict && attrsVal.Dict().Len() > 0 {
+				writeAttrs(&h.buf, attrsVal.Dict().StrMap())
 			}
 			h.buf.WriteByte('>')
 
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			return value.None(), nil
 		},
 	})
 }
@@ -158,13 +158,13 @@ func registerText() {
 			handleVal := args[0].(value.Value)
 			contentVal := args[1].(value.Value)
 
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.text: invalid builder handle")
 			}
 
-			h.buf.WriteString(escapeHTML(contentVal.Str))
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			h.buf.WriteString(escapeHTML(contentVal.Str()))
+			return value.None(), nil
 		},
 	})
 }
@@ -187,13 +187,13 @@ func registerRawHTML() {
 			handleVal := args[0].(value.Value)
 			contentVal := args[1].(value.Value)
 
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.raw_html: invalid builder handle")
 			}
 
-			h.buf.WriteString(contentVal.Str)
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			h.buf.WriteString(contentVal.Str())
+			return value.None(), nil
 		},
 	})
 }
@@ -213,12 +213,12 @@ func registerDoctype() {
 		},
 		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
 			handleVal := args[0].(value.Value)
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.doctype: invalid builder handle")
 			}
 			h.buf.WriteString("<!DOCTYPE html>")
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			return value.None(), nil
 		},
 	})
 }
@@ -238,7 +238,7 @@ func registerBuilderResult() {
 		},
 		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
 			handleVal := args[0].(value.Value)
-			h := decodeBuilderPtr(handleVal.Bytes)
+			h := decodeBuilderPtr(handleVal.Bytes())
 			if h == nil {
 				return value.Value{}, fmt.Errorf("html.builder_result: invalid builder handle")
 			}
@@ -262,7 +262,7 @@ func registerEscape() {
 		},
 		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
 			s := args[0].(value.Value)
-			return value.Str(escapeHTML(s.Str)), nil
+			return value.Str(escapeHTML(s.Str())), nil
 		},
 	})
 }
@@ -284,7 +284,7 @@ func registerRaw() {
 			s := args[0].(value.Value)
 			dict := map[string]value.Value{
 				safeStringMarker: value.Bool(true),
-				"value":          value.Str(s.Str),
+				"value":          value.Str(s.Str()),
 			}
 			return value.Dict(dict), nil
 		},
@@ -328,7 +328,7 @@ func decodeBuilderPtr(b []byte) *builderHandle {
 
 func classifyArgs(first, second value.Value) (map[string]value.Value, value.Value) {
 	isNone := func(v value.Value) bool {
-		return v.Kind == value.KindOptional && (v.Optional == nil || !v.Optional.IsSome)
+		return v.Kind == value.KindOptional && (v.Optional() == nil || !v.Optional().IsSome)
 	}
 
 	if isNone(first) && isNone(second) {
@@ -337,9 +337,9 @@ func classifyArgs(first, second value.Value) (map[string]value.Value, value.Valu
 
 	if first.Kind == value.KindDict && !isSafeString(first) {
 		if isNone(second) {
-			return first.Dict, value.Value{}
+			return first.Dict().StrMap(), value.Value{}
 		}
-		return first.Dict, second
+		return first.Dict().StrMap(), second
 	}
 
 	return nil, first
@@ -349,8 +349,8 @@ func isSafeString(v value.Value) bool {
 	if v.Kind != value.KindDict {
 		return false
 	}
-	marker, ok := v.Dict[safeStringMarker]
-	return ok && marker.Kind == value.KindBool && marker.Bool
+	marker, ok := v.Dict().GetStr(safeStringMarker)
+	return ok && marker.Kind == value.KindBool && marker.Bool()
 }
 
 func writeAttrs(b *strings.Builder, attrs map[string]value.Value) {
@@ -365,7 +365,7 @@ func writeAttrs(b *strings.Builder, attrs map[string]value.Value) {
 		b.WriteString(k)
 		b.WriteString(`="`)
 		if v.Kind == value.KindString {
-			b.WriteString(escapeAttrValue(v.Str))
+			b.WriteString(escapeAttrValue(v.Str()))
 		} else {
 			b.WriteString(escapeAttrValue(v.String()))
 		}
@@ -376,16 +376,16 @@ func writeAttrs(b *strings.Builder, attrs map[string]value.Value) {
 func writeContent(env builtins.Env, h *builderHandle, content value.Value) error {
 	switch content.Kind {
 	case value.KindString:
-		h.buf.WriteString(escapeHTML(content.Str))
+		h.buf.WriteString(escapeHTML(content.Str()))
 	case value.KindClosure:
-		_, err := env.CallClosure(content.Closure, []interface{}{})
+		_, err := env.CallClosure(content.Closure(), []interface{}{})
 		if err != nil {
 			return fmt.Errorf("html.tag: error calling content closure: %w", err)
 		}
 	case value.KindDict:
 		if isSafeString(content) {
-			if raw, ok := content.Dict["value"]; ok && raw.Kind == value.KindString {
-				h.buf.WriteString(raw.Str)
+			if raw, ok := content.Dict().GetStr("value"); ok && raw.Kind == value.KindString {
+				h.buf.WriteString(raw.Str())
 			}
 		}
 	case value.KindOptional:
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"avenir/internal/fswatch"
 	"avenir/internal/runtime/builtins"
 	"avenir/internal/value"
 )
@@ -61,6 +62,10 @@ type engineHandle struct {
 	dir     string
 	cache   map[string]*compiledTemplate
 	devMode bool
+	watcher *fswatch.Watcher // set in dev mode when the template dir can be watched
+	// checkRead applies the Env's permissions to template paths, which may
+	// reach outside dir through "..".
+	checkRead func(path string) error
 }
 
 func init() {
@@ -153,7 +158,7 @@ func registerNewEngine() {
 			dirVal := args[0].(value.Value)
 			optsVal := args[1].(value.Value)
 
-			dir := dirVal.Str
+			dir := dirVal.Str()
 			if !filepath.IsAbs(dir) {
 				root := env.ExecRoot()
 				if root == "" {
@@ -163,21 +168,25 @@ func registerNewEngine() {
 					dir = filepath.Join(root, dir)
 				}
 			}
+			if err := env.CheckRead(dir); err != nil {
+				return value.Value{}, err
+			}
 
 			devMode := false
 			if optsVal.Kind == value.KindDict {
-				if dm, ok := optsVal.Dict["devMode"]; ok && dm.Kind == value.KindBool {
-					devMode = dm.Bool
+				if dm, ok := optsVal.Dict().GetStr("devMode"); ok && dm.Kind == value.KindBool {
+					devMode = dm.Bool()
 				}
 			}
 
 			eng := &engineHandle{
-				dir:     dir,
-				cache:   make(map[string]*compiledTemplate),
-				devMode: devMode,
+				dir:       dir,
+				cache:     make(map[string]*compiledTemplate),
+				checkRead: env.CheckRead,
 			}
+			eng.setDevMode(devMode)
 
-			return value.Value{Kind: value.KindBytes, Bytes: encodeEnginePtr(eng)}, nil
+			return value.Bytes(encodeEnginePtr(eng)), nil
 		},
 	})
 }
@@ -202,12 +211,12 @@ func registerEngineRender() {
 			nameVal := args[1].(value.Value)
 			dataVal := args[2].(value.Value)
 
-			eng := decodeEnginePtr(handleVal.Bytes)
+			eng := decodeEnginePtr(handleVal.Bytes())
 			if eng == nil {
 				return value.Value{}, fmt.Errorf("html.engine.render: invalid engine handle")
 			}
 
-			tpl, err := eng.getTemplate(nameVal.Str)
+			tpl, err := eng.getTemplate(nameVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -240,17 +249,17 @@ func registerEngineCompile() {
 			handleVal := args[0].(value.Value)
 			nameVal := args[1].(value.Value)
 
-			eng := decodeEnginePtr(handleVal.Bytes)
+			eng := decodeEnginePtr(handleVal.Bytes())
 			if eng == nil {
 				return value.Value{}, fmt.Errorf("html.engine.compile: invalid engine handle")
 			}
 
-			tpl, err := eng.getTemplate(nameVal.Str)
+			tpl, err := eng.getTemplate(nameVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
 
-			return value.Value{Kind: value.KindBytes, Bytes: encodeTplPtr(tpl)}, nil
+			return value.Bytes(encodeTplPtr(tpl)), nil
 		},
 	})
 }
@@ -273,16 +282,14 @@ func registerEngineSetDevMode() {
 			handleVal := args[0].(value.Value)
 			enabledVal := args[1].(value.Value)
 
-			eng := decodeEnginePtr(handleVal.Bytes)
+			eng := decodeEnginePtr(handleVal.Bytes())
 			if eng == nil {
 				return value.Value{}, fmt.Errorf("html.engine.set_dev_mode: invalid engine handle")
 			}
 
-			eng.mu.Lock()
-			eng.devMode = enabledVal.Bool
-			eng.mu.Unlock()
+			eng.setDevMode(enabledVal.Bool())
 
-			return value.Value{Kind: value.KindOptional, Optional: &value.OptionalValue{IsSome: false}}, nil
+			return value.None(), nil
 		},
 	})
 }
@@ -305,7 +312,7 @@ func registerTemplateRender() {
 			handleVal := args[0].(value.Value)
 			dataVal := args[1].(value.Value)
 
-			tpl := decodeTplPtr(handleVal.Bytes)
+			tpl := decodeTplPtr(handleVal.Bytes())
 			if tpl == nil {
 				return value.Value{}, fmt.Errorf("html.template.render: invalid template handle")
 			}
@@ -322,17 +329,56 @@ func registerTemplateRender() {
 
 // --- Engine methods ---
 
+// templateWatchDebounce is short so that a render right after saving a
+// template sees the change.
+const templateWatchDebounce = 10 * time.Millisecond
+
+// setDevMode toggles template reloading. In dev mode the engine watches its
+// directory and drops the cache when files change; if the directory cannot
+// be watched it checks template mtimes on every render instead.
+func (eng *engineHandle) setDevMode(enabled bool) {
+	eng.mu.Lock()
+	defer eng.mu.Unlock()
+	eng.devMode = enabled
+	if enabled && eng.watcher == nil {
+		w, err := fswatch.New([]string{eng.dir}, fswatch.Options{Recursive: true, Debounce: templateWatchDebounce})
+		if err == nil {
+			eng.watcher = w
+			go eng.invalidateOnChange(w)
+		}
+	} else if !enabled && eng.watcher != nil {
+		eng.watcher.Close()
+		eng.watcher = nil
+	}
+}
+
+// invalidateOnChange clears the whole cache on every batch, since a change
+// to a layout or include affects the templates that use it.
+func (eng *engineHandle) invalidateOnChange(w *fswatch.Watcher) {
+	for range w.Events() {
+		eng.mu.Lock()
+		eng.cache = make(map[string]*compiledTemplate)
+		eng.mu.Unlock()
+	}
+}
+
 func (eng *engineHandle) getTemplate(name string) (*compiledTemplate, error) {
 	eng.mu.RLock()
 	cached, ok := eng.cache[name]
 	devMode := eng.devMode
+	watching := eng.watcher != nil
 	eng.mu.RUnlock()
 
-	if ok && !devMode {
+	if ok && (!devMode || watching) {
 		return cached, nil
 	}
 
 	path := filepath.Join(eng.dir, name)
+	if eng.checkRead != nil {
+		if err := eng.checkRead(path); err != nil {
+			return nil, err
+		}
+	}
 
 	if ok && devMode {
 		info, err := os.Stat(path)
@@ -800,19 +846,24 @@ func renderNodes(buf *strings.Builder, nodes []*templateNode, ctx *renderContext
 			collection := evalExpr(n.expr, ctx)
 			childCtx := ctx.clone()
 			if collection.Kind == value.KindList {
-				for _, item := range collection.List {
+				for _, item := range collection.List() {
+					childCtx.scope[n.iterVar] = item
+					if err := renderNodes(buf, n.children, childCtx); err != nil {
+						return err
+					}
+				}
+			} else if collection.Kind == value.KindSet {
+				for item := range collection.Set().All() {
 					childCtx.scope[n.iterVar] = item
 					if err := renderNodes(buf, n.children, childCtx); err != nil {
 						return err
 					}
 				}
 			} else if collection.Kind == value.KindDict {
-				for k, v := range collection.Dict {
+				for k, v := range collection.Dict().All() {
+					childCtx.scope[n.iterVar] = k
 					if n.iterVar2 != "" {
-						childCtx.scope[n.iterVar] = value.Str(k)
 						childCtx.scope[n.iterVar2] = v
-					} else {
-						childCtx.scope[n.iterVar] = value.Str(k)
 					}
 					if err := renderNodes(buf, n.children, childCtx); err != nil {
 						return err
@@ -934,7 +985,7 @@ func evalExpr(expr string, ctx *renderContext) value.Value {
 			return value.Str(valueToString(left) + valueToString(right))
 		}
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Int(left.Int + right.Int)
+			return value.Int(left.Int() + right.Int())
 		}
 	}
 
@@ -943,7 +994,7 @@ func evalExpr(expr string, ctx *renderContext) value.Value {
 		left := evalExpr(expr[:idx], ctx)
 		right := evalExpr(expr[idx+1:], ctx)
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Int(left.Int - right.Int)
+			return value.Int(left.Int() - right.Int())
 		}
 	}
 
@@ -952,7 +1003,7 @@ func evalExpr(expr string, ctx *renderContext) value.Value {
 		left := evalExpr(expr[:idx], ctx)
 		right := evalExpr(expr[idx+1:], ctx)
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Int(left.Int * right.Int)
+			return value.Int(left.Int() * right.Int())
 		}
 	}
 
@@ -962,8 +1013,8 @@ func evalExpr(expr string, ctx *renderContext) value.Value {
 			base := evalExpr(expr[:bracketIdx], ctx)
 			keyStr := strings.TrimSpace(expr[bracketIdx+1 : len(expr)-1])
 			keyVal := evalExpr(keyStr, ctx)
-			if base.Kind == value.KindDict && keyVal.Kind == value.KindString {
-				if v, ok := base.Dict[keyVal.Str]; ok {
+			if base.Kind == value.KindDict {
+				if v, ok := base.Dict().Get(keyVal); ok {
 					return v
 				}
 			}
@@ -989,7 +1040,7 @@ func lookupDotPath(path string, ctx *renderContext) value.Value {
 	if v, ok := ctx.scope[root]; ok {
 		current = v
 	} else if ctx.data.Kind == value.KindDict {
-		if v, ok := ctx.data.Dict[root]; ok {
+		if v, ok := ctx.data.Dict().GetStr(root); ok {
 			current = v
 		} else {
 			return value.Str("")
@@ -1003,17 +1054,17 @@ func lookupDotPath(path string, ctx *renderContext) value.Value {
 		part = strings.TrimSpace(part)
 		switch current.Kind {
 		case value.KindDict:
-			if v, ok := current.Dict[part]; ok {
+			if v, ok := current.Dict().GetStr(part); ok {
 				current = v
 			} else {
 				return value.Str("")
 			}
 		case value.KindStruct:
-			if current.Struct == nil {
+			if current.Struct() == nil {
 				return value.Str("")
 			}
 			found := false
-			for i, f := range current.Struct.Fields {
+			for i, f := range current.Struct().Fields {
 				_ = i
 				// Struct field access by index requires type info which we don't have here.
 				// Fall back to treating struct as dict-like if fields are named.
@@ -1038,22 +1089,22 @@ func evalComparison(left, right value.Value, op string) value.Value {
 		return value.Bool(!valuesEqual(left, right))
 	case ">":
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Bool(left.Int > right.Int)
+			return value.Bool(left.Int() > right.Int())
 		}
 		return value.Bool(false)
 	case "<":
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Bool(left.Int < right.Int)
+			return value.Bool(left.Int() < right.Int())
 		}
 		return value.Bool(false)
 	case ">=":
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Bool(left.Int >= right.Int)
+			return value.Bool(left.Int() >= right.Int())
 		}
 		return value.Bool(false)
 	case "<=":
 		if left.Kind == value.KindInt && right.Kind == value.KindInt {
-			return value.Bool(left.Int <= right.Int)
+			return value.Bool(left.Int() <= right.Int())
 		}
 		return value.Bool(false)
 	}
@@ -1066,13 +1117,17 @@ func valuesEqual(a, b value.Value) bool {
 	}
 	switch a.Kind {
 	case value.KindInt:
-		return a.Int == b.Int
+		return a.Int() == b.Int()
 	case value.KindFloat:
-		return a.Float == b.Float
+		return a.Float() == b.Float()
+	case value.KindBigInt:
+		return a.BigInt().Cmp(b.BigInt()) == 0
+	case value.KindDecimal:
+		return a.Decimal().Cmp(b.Decimal()) == 0
 	case value.KindString:
-		return a.Str == b.Str
+		return a.Str() == b.Str()
 	case value.KindBool:
-		return a.Bool == b.Bool
+		return a.Bool() == b.Bool()
 	default:
 		return false
 	}
@@ -1081,17 +1136,23 @@ func valuesEqual(a, b value.Value) bool {
 func isTruthy(v value.Value) bool {
 	switch v.Kind {
 	case value.KindBool:
-		return v.Bool
+		return v.Bool()
 	case value.KindInt:
-		return v.Int != 0
+		return v.Int() != 0
+	case value.KindBigInt:
+		return v.BigInt().Sign() != 0
+	case value.KindDecimal:
+		return v.Decimal().Sign() != 0
 	case value.KindString:
-		return v.Str != ""
+		return v.Str() != ""
 	case value.KindList:
-		return len(v.List) > 0
+		return len(v.List()) > 0
 	case value.KindDict:
-		return len(v.Dict) > 0
+		return v.Dict().Len() > 0
+	case value.KindSet:
+		return v.Set().Len() > 0
 	case value.KindOptional:
-		return v.Optional != nil && v.Optional.IsSome
+		return v.Optional() != nil && v.Optional().IsSome
 	default:
 		return true
 	}
@@ -1100,21 +1161,23 @@ func isTruthy(v value.Value) bool {
 func valueToString(v value.Value) string {
 	switch v.Kind {
 	case value.KindString:
-		return v.Str
+		return v.Str()
 	case value.KindInt:
-		return fmt.Sprintf("%d", v.Int)
+		return fmt.Sprintf("%d", v.Int())
 	case value.KindFloat:
-		return fmt.Sprintf("%g", v.Float)
+		return fmt.Sprintf("%g", v.Float())
+	case value.KindBigInt, value.KindDecimal:
+		return v.String()
 	case value.KindBool:
-		if v.Bool {
+		if v.Bool() {
 			return "true"
 		}
 		return "false"
 	case value.KindOptional:
-		if v.Optional == nil || !v.Optional.IsSome {
+		if v.Optional() == nil || !v.Optional().IsSome {
 			return ""
 		}
-		return valueToString(v.Optional.Value)
+		return valueToString(v.Optional().Value)
 	default:
 		return v.String()
 	}
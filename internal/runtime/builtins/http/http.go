@@ -14,6 +14,7 @@ func init() {
 	registerListen()
 	registerAccept()
 	registerRespond()
+	registerClose()
 }
 
 func registerRequest() {
@@ -60,7 +61,7 @@ func registerRequest() {
 				return value.Value{}, err
 			}
 
-			resp, err := env.HTTP().Request(methodVal.Str, urlVal.Str, headers, body)
+			resp, err := env.HTTP().Request(methodVal.Str(), urlVal.Str(), headers, body)
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -107,7 +108,7 @@ func registerListen() {
 			if hostVal.Kind != value.KindString || portVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("http.listen expects host string and port int")
 			}
-			handle, err := env.HTTP().Listen(hostVal.Str, int(portVal.Int))
+			handle, err := env.HTTP().Listen(hostVal.Str(), int(portVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -208,7 +209,43 @@ func registerRespond() {
 			if bodyVal.Kind != value.KindBytes {
 				return value.Value{}, fmt.Errorf("http.respond expects body as bytes")
 			}
-			if err := env.HTTP().Respond(reqHandle, int(statusVal.Int), headers, bodyVal.Bytes); err != nil {
+			if err := env.HTTP().Respond(reqHandle, int(statusVal.Int()), headers, bodyVal.Bytes()); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerClose() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.HTTPClose,
+			Name:       "__builtin_http_close",
+			Arity:      1,
+			ParamNames: []string{"server"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeAny},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if env == nil {
+				return value.Value{}, fmt.Errorf("runtime env is nil")
+			}
+			if env.HTTP() == nil {
+				return value.Value{}, fmt.Errorf("http service is nil")
+			}
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("http.close expects 1 argument, got %d", len(args))
+			}
+			handle, err := extractHandle(args[0].(value.Value), "http.close")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := env.HTTP().Close(handle); err != nil {
 				return value.Value{}, err
 			}
 			return value.Value{}, nil
@@ -219,16 +256,16 @@ func registerRespond() {
 func extractHandle(v value.Value, name string) ([]byte, error) {
 	switch v.Kind {
 	case value.KindBytes:
-		return v.Bytes, nil
+		return v.Bytes(), nil
 	case value.KindDict:
-		if v.Dict == nil {
+		if v.Dict() == nil {
 			return nil, fmt.Errorf("%s expects request handle", name)
 		}
-		if handleVal, ok := v.Dict[requestHandleKey]; ok {
+		if handleVal, ok := v.Dict().GetStr(requestHandleKey); ok {
 			if handleVal.Kind != value.KindBytes {
 				return nil, fmt.Errorf("%s: handle must be bytes", name)
 			}
-			return handleVal.Bytes, nil
+			return handleVal.Bytes(), nil
 		}
 		return nil, fmt.Errorf("%s expects request handle", name)
 	default:
@@ -240,12 +277,12 @@ func requireStringHeaders(v value.Value, name string) (map[string]string, error)
 	if v.Kind != value.KindDict {
 		return nil, fmt.Errorf("%s expects headers as dict<string>", name)
 	}
-	headers := make(map[string]string, len(v.Dict))
-	for k, hv := range v.Dict {
+	headers := make(map[string]string, v.Dict().Len())
+	for k, hv := range v.Dict().StrMap() {
 		if hv.Kind != value.KindString {
 			return nil, fmt.Errorf("%s expects header values as string", name)
 		}
-		headers[k] = hv.Str
+		headers[k] = hv.Str()
 	}
 	return headers, nil
 }
@@ -253,15 +290,15 @@ func requireStringHeaders(v value.Value, name string) (map[string]string, error)
 func optionalBytes(v value.Value, name string) ([]byte, error) {
 	switch v.Kind {
 	case value.KindBytes:
-		return v.Bytes, nil
+		return v.Bytes(), nil
 	case value.KindOptional:
-		if v.Optional == nil || !v.Optional.IsSome {
+		if v.Optional() == nil || !v.Optional().IsSome {
 			return nil, nil
 		}
-		if v.Optional.Value.Kind != value.KindBytes {
+		if v.Optional().Value.Kind != value.KindBytes {
 			return nil, fmt.Errorf("%s expects optional bytes", name)
 		}
-		return v.Optional.Value.Bytes, nil
+		return v.Optional().Value.Bytes(), nil
 	default:
 		return nil, fmt.Errorf("%s expects bytes or bytes?", name)
 	}
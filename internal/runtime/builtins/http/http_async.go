@@ -56,7 +56,7 @@ func registerAsyncRequest() {
 				return nil, err
 			}
 
-			method, url := methodVal.Str, urlVal.Str
+			method, url := methodVal.Str(), urlVal.Str()
 			httpService := env.HTTP()
 			return builtins.RunAsync(func() (interface{}, error) {
 				resp, err := httpService.Request(method, url, headers, body)
@@ -171,8 +171,8 @@ func registerAsyncRespond() {
 				return nil, fmt.Errorf("__builtin_async_http_respond expects body as bytes")
 			}
 
-			status := int(statusVal.Int)
-			bodyData := append([]byte(nil), bodyVal.Bytes...)
+			status := int(statusVal.Int())
+			bodyData := append([]byte(nil), bodyVal.Bytes()...)
 			httpService := env.HTTP()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := httpService.Respond(reqHandle, status, headers, bodyData); err != nil {
@@ -67,17 +67,17 @@ func TestHTTPRequestBuiltin(t *testing.T) {
 	if resp.Kind != value.KindDict {
 		t.Fatalf("expected dict response, got %v", resp.Kind)
 	}
-	if resp.Dict["status"].Kind != value.KindInt || resp.Dict["status"].Int != 201 {
-		t.Fatalf("expected status 201, got %v", resp.Dict["status"].String())
+	if resp.Dict().StrMap()["status"].Kind != value.KindInt || resp.Dict().StrMap()["status"].Int() != 201 {
+		t.Fatalf("expected status 201, got %v", resp.Dict().StrMap()["status"].String())
 	}
-	if resp.Dict["body"].Kind != value.KindBytes || string(resp.Dict["body"].Bytes) != "done" {
-		t.Fatalf("expected body done, got %v", resp.Dict["body"].String())
+	if resp.Dict().StrMap()["body"].Kind != value.KindBytes || string(resp.Dict().StrMap()["body"].Bytes()) != "done" {
+		t.Fatalf("expected body done, got %v", resp.Dict().StrMap()["body"].String())
 	}
-	reply := resp.Dict["headers"]
+	reply := resp.Dict().StrMap()["headers"]
 	if reply.Kind != value.KindDict {
 		t.Fatalf("expected headers dict, got %v", reply.Kind)
 	}
-	if reply.Dict["X-Reply"].Str != "ok" {
+	if reply.Dict().StrMap()["X-Reply"].Str() != "ok" {
 		t.Fatalf("expected X-Reply ok")
 	}
 }
@@ -103,11 +103,11 @@ func TestHTTPServerBuiltins(t *testing.T) {
 			t.Errorf("expected dict request, got %v", req.Kind)
 			return
 		}
-		if req.Dict["path"].Str != "/ping" {
-			t.Errorf("expected path /ping, got %q", req.Dict["path"].Str)
+		if req.Dict().StrMap()["path"].Str() != "/ping" {
+			t.Errorf("expected path /ping, got %q", req.Dict().StrMap()["path"].Str())
 			return
 		}
-		if req.Dict["remote_addr"].Kind != value.KindString || req.Dict["remote_addr"].Str == "" {
+		if req.Dict().StrMap()["remote_addr"].Kind != value.KindString || req.Dict().StrMap()["remote_addr"].Str() == "" {
 			t.Errorf("expected non-empty remote_addr")
 			return
 		}
@@ -115,7 +115,7 @@ func TestHTTPServerBuiltins(t *testing.T) {
 			"Content-Type": value.Str("text/plain"),
 		})
 		_, err = callBuiltin(t, env, "__builtin_http_respond",
-			req.Dict["__handle"],
+			req.Dict().StrMap()["__handle"],
 			value.Int(200),
 			headers,
 			value.Bytes([]byte("pong")),
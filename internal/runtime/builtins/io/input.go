@@ -30,10 +30,7 @@ func init() {
 			if err != nil {
 				return value.Value{}, fmt.Errorf("input failed: %w", err)
 			}
-			return value.Value{
-				Kind: value.KindString,
-				Str:  line,
-			}, nil
+			return value.Str(line), nil
 		},
 	})
 }
@@ -5,24 +5,28 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"sort"
+	"math/big"
 	"strconv"
 	"strings"
 
+	"avenir/internal/decimal"
 	"avenir/internal/runtime/builtins"
 	"avenir/internal/value"
 )
 
 func init() {
-	registerParse()
+	registerParse(builtins.JSONParse, "__builtin_json_parse", "json.parse", false)
+	registerParse(builtins.JSONParseDecimal, "__builtin_json_parse_decimal", "json.parseDecimal", true)
 	registerStringify()
 }
 
-func registerParse() {
+// registerParse registers a JSON parser. With exact set, numbers with a
+// fraction or an exponent become decimals rather than floats.
+func registerParse(id builtins.ID, name, symbol string, exact bool) {
 	builtins.Register(builtins.Builtin{
 		Meta: builtins.Meta{
-			ID:         builtins.JSONParse,
-			Name:       "__builtin_json_parse",
+			ID:         id,
+			Name:       name,
 			Arity:      1,
 			ParamNames: []string{"text"},
 			Params: []builtins.TypeRef{
@@ -34,13 +38,13 @@ func registerParse() {
 		},
 		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
 			if len(args) != 1 {
-				return value.Value{}, fmt.Errorf("json.parse expects 1 argument, got %d", len(args))
+				return value.Value{}, fmt.Errorf("%s expects 1 argument, got %d", symbol, len(args))
 			}
 			textVal := args[0].(value.Value)
 			if textVal.Kind != value.KindString {
-				return value.Value{}, fmt.Errorf("json.parse expects a string")
+				return value.Value{}, fmt.Errorf("%s expects a string", symbol)
 			}
-			parsed, err := parseJSON(textVal.Str)
+			parsed, err := parseJSON(textVal.Str(), exact)
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -77,68 +81,98 @@ func registerStringify() {
 	})
 }
 
-func parseJSON(text string) (value.Value, error) {
+func parseJSON(text string, exact bool) (value.Value, error) {
 	dec := json.NewDecoder(strings.NewReader(text))
 	dec.UseNumber()
 
-	var data interface{}
-	if err := dec.Decode(&data); err != nil {
+	val, err := decodeValue(dec, exact)
+	if err != nil {
 		return value.Value{}, fmt.Errorf("json.parse: %w", err)
 	}
 	// Ensure there is no trailing data.
-	if err := dec.Decode(&struct{}{}); err != io.EOF {
+	if _, err := dec.Token(); err != io.EOF {
 		if err == nil {
 			return value.Value{}, fmt.Errorf("json.parse: extra data after JSON value")
 		}
 		return value.Value{}, fmt.Errorf("json.parse: %w", err)
 	}
-
-	return jsonToValue(data)
+	return val, nil
 }
 
-func jsonToValue(v interface{}) (value.Value, error) {
-	switch val := v.(type) {
+// decodeValue reads the next JSON value from dec token by token, so that
+// objects become dicts with their keys in document order.
+func decodeValue(dec *json.Decoder, exact bool) (value.Value, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return value.Value{}, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return value.Value{}, err
+	}
+	switch tok := tok.(type) {
 	case nil:
 		return value.None(), nil
 	case bool:
-		return value.Bool(val), nil
+		return value.Bool(tok), nil
 	case string:
-		return value.Str(val), nil
+		return value.Str(tok), nil
 	case json.Number:
-		return numberToValue(val)
-	case float64:
-		return value.Float(val), nil
-	case []interface{}:
-		items := make([]value.Value, len(val))
-		for i, item := range val {
-			converted, err := jsonToValue(item)
-			if err != nil {
+		return numberToValue(tok, exact)
+	case json.Delim:
+		if tok == '[' {
+			items := []value.Value{}
+			for dec.More() {
+				item, err := decodeValue(dec, exact)
+				if err != nil {
+					return value.Value{}, err
+				}
+				items = append(items, item)
+			}
+			if _, err := dec.Token(); err != nil {
 				return value.Value{}, err
 			}
-			items[i] = converted
+			return value.List(items), nil
 		}
-		return value.List(items), nil
-	case map[string]interface{}:
-		dict := make(map[string]value.Value, len(val))
-		for k, item := range val {
-			converted, err := jsonToValue(item)
+		dict := value.NewDict(0)
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return value.Value{}, err
+			}
+			item, err := decodeValue(dec, exact)
 			if err != nil {
 				return value.Value{}, err
 			}
-			dict[k] = converted
+			dict.SetStr(keyTok.(string), item)
 		}
-		return value.Dict(dict), nil
+		if _, err := dec.Token(); err != nil {
+			return value.Value{}, err
+		}
+		return value.DictVal(dict), nil
 	default:
-		return value.Value{}, fmt.Errorf("json.parse: unsupported JSON value %T", v)
+		return value.Value{}, fmt.Errorf("unsupported JSON value %T", tok)
 	}
 }
 
-func numberToValue(num json.Number) (value.Value, error) {
+// numberToValue converts a JSON number: integers to ints, or bigints when
+// they do not fit, and other numbers to floats, or decimals when exact is
+// set.
+func numberToValue(num json.Number, exact bool) (value.Value, error) {
 	raw := num.String()
 	if !strings.ContainsAny(raw, ".eE") {
 		if i, err := num.Int64(); err == nil {
 			return value.Int(i), nil
 		}
+		if b, ok := new(big.Int).SetString(raw, 10); ok {
+			return value.BigInt(b), nil
+		}
+	}
+	if exact {
+		d, err := decimal.Parse(raw)
+		if err != nil {
+			return value.Value{}, fmt.Errorf("json.parse: %w", err)
+		}
+		return value.Decimal(d), nil
 	}
 	f, err := num.Float64()
 	if err != nil {
@@ -158,32 +192,35 @@ func stringifyJSON(val value.Value) (string, error) {
 func writeJSONValue(b *strings.Builder, val value.Value) error {
 	switch val.Kind {
 	case value.KindInt:
-		b.WriteString(strconv.FormatInt(val.Int, 10))
+		b.WriteString(strconv.FormatInt(val.Int(), 10))
 		return nil
 	case value.KindFloat:
-		if math.IsNaN(val.Float) || math.IsInf(val.Float, 0) {
+		if math.IsNaN(val.Float()) || math.IsInf(val.Float(), 0) {
 			return fmt.Errorf("json.stringify: cannot encode non-finite float")
 		}
-		b.WriteString(strconv.FormatFloat(val.Float, 'g', -1, 64))
+		b.WriteString(strconv.FormatFloat(val.Float(), 'g', -1, 64))
+		return nil
+	case value.KindBigInt, value.KindDecimal:
+		b.WriteString(val.String())
 		return nil
 	case value.KindString:
-		return writeJSONString(b, val.Str)
+		return writeJSONString(b, val.Str())
 	case value.KindBool:
-		if val.Bool {
+		if val.Bool() {
 			b.WriteString("true")
 		} else {
 			b.WriteString("false")
 		}
 		return nil
 	case value.KindOptional:
-		if val.Optional == nil || !val.Optional.IsSome {
+		if val.Optional() == nil || !val.Optional().IsSome {
 			b.WriteString("null")
 			return nil
 		}
-		return writeJSONValue(b, val.Optional.Value)
+		return writeJSONValue(b, val.Optional().Value)
 	case value.KindList:
 		b.WriteByte('[')
-		for i, item := range val.List {
+		for i, item := range val.List() {
 			if i > 0 {
 				b.WriteByte(',')
 			}
@@ -193,25 +230,38 @@ func writeJSONValue(b *strings.Builder, val value.Value) error {
 		}
 		b.WriteByte(']')
 		return nil
+	case value.KindSet:
+		b.WriteByte('[')
+		i := 0
+		for item := range val.Set().All() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			i++
+			if err := writeJSONValue(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+		return nil
 	case value.KindDict:
 		b.WriteByte('{')
-		if len(val.Dict) > 0 {
-			keys := make([]string, 0, len(val.Dict))
-			for k := range val.Dict {
-				keys = append(keys, k)
-			}
-			sort.Strings(keys)
-			for i, k := range keys {
-				if i > 0 {
-					b.WriteByte(',')
-				}
-				if err := writeJSONString(b, k); err != nil {
-					return err
-				}
-				b.WriteByte(':')
-				if err := writeJSONValue(b, val.Dict[k]); err != nil {
-					return err
-				}
+		i := 0
+		for k, v := range val.Dict().All() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			i++
+			key, err := jsonKey(k)
+			if err != nil {
+				return err
+			}
+			if err := writeJSONString(b, key); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := writeJSONValue(b, v); err != nil {
+				return err
 			}
 		}
 		b.WriteByte('}')
@@ -221,6 +271,19 @@ func writeJSONValue(b *strings.Builder, val value.Value) error {
 	}
 }
 
+// jsonKey returns the object key for a dict key. Int and bool keys are
+// written as their decimal or literal text.
+func jsonKey(k value.Value) (string, error) {
+	switch k.Kind {
+	case value.KindString:
+		return k.Str(), nil
+	case value.KindInt, value.KindBool:
+		return k.String(), nil
+	default:
+		return "", fmt.Errorf("json.stringify: cannot encode dict key of type %v", k.Kind)
+	}
+}
+
 func writeJSONString(b *strings.Builder, s string) error {
 	encoded, err := json.Marshal(s)
 	if err != nil {
@@ -39,17 +39,17 @@ func TestJSONParseObject(t *testing.T) {
 	if val.Kind != value.KindDict {
 		t.Fatalf("expected dict, got %v", val.Kind)
 	}
-	if got := val.Dict["name"]; got.Kind != value.KindString || got.Str != "Alex" {
+	if got := val.Dict().StrMap()["name"]; got.Kind != value.KindString || got.Str() != "Alex" {
 		t.Fatalf("expected name=Alex, got %v", got.String())
 	}
-	if got := val.Dict["age"]; got.Kind != value.KindInt || got.Int != 30 {
+	if got := val.Dict().StrMap()["age"]; got.Kind != value.KindInt || got.Int() != 30 {
 		t.Fatalf("expected age=30, got %v", got.String())
 	}
-	tags := val.Dict["tags"]
-	if tags.Kind != value.KindList || len(tags.List) != 2 {
+	tags := val.Dict().StrMap()["tags"]
+	if tags.Kind != value.KindList || len(tags.List()) != 2 {
 		t.Fatalf("expected tags list, got %v", tags.String())
 	}
-	if meta := val.Dict["meta"]; meta.Kind != value.KindOptional || meta.Optional == nil || meta.Optional.IsSome {
+	if meta := val.Dict().StrMap()["meta"]; meta.Kind != value.KindOptional || meta.Optional() == nil || meta.Optional().IsSome {
 		t.Fatalf("expected meta=null, got %v", meta.String())
 	}
 }
@@ -76,8 +76,8 @@ func TestJSONStringifyBasics(t *testing.T) {
 		t.Fatalf("expected string result, got %v", val.Kind)
 	}
 	expected := `{"a":"x","b":2}`
-	if val.Str != expected {
-		t.Fatalf("expected %q, got %q", expected, val.Str)
+	if val.Str() != expected {
+		t.Fatalf("expected %q, got %q", expected, val.Str())
 	}
 }
 
@@ -88,3 +88,84 @@ func TestJSONStringifyUnsupported(t *testing.T) {
 		t.Fatalf("expected stringify error for unsupported type, got nil")
 	}
 }
+
+func TestJSONRoundTripKeepsKeyOrder(t *testing.T) {
+	env := runtime.DefaultEnv()
+	src := `{"zeta":1,"alpha":{"b":2,"a":[3,{"y":4,"x":5}]},"mid":null}`
+	val, err := callBuiltin(t, env, "__builtin_json_parse", value.Str(src))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	out, err := callBuiltin(t, env, "__builtin_json_stringify", val)
+	if err != nil {
+		t.Fatalf("stringify error: %v", err)
+	}
+	if out.Str() != src {
+		t.Fatalf("expected %q, got %q", src, out.Str())
+	}
+}
+
+func TestJSONStringifyDictKeys(t *testing.T) {
+	env := runtime.DefaultEnv()
+	d := value.NewDict(2)
+	d.Set(value.Int(2), value.Str("two"))
+	d.Set(value.Bool(true), value.Str("yes"))
+	out, err := callBuiltin(t, env, "__builtin_json_stringify", value.DictVal(d))
+	if err != nil {
+		t.Fatalf("stringify error: %v", err)
+	}
+	if expected := `{"2":"two","true":"yes"}`; out.Str() != expected {
+		t.Fatalf("expected %q, got %q", expected, out.Str())
+	}
+
+	d.Set(value.Bytes([]byte("k")), value.Int(1))
+	if _, err := callBuiltin(t, env, "__builtin_json_stringify", value.DictVal(d)); err == nil {
+		t.Fatalf("expected stringify error for bytes key, got nil")
+	}
+}
+
+func TestJSONStringifySet(t *testing.T) {
+	env := runtime.DefaultEnv()
+	s := value.NewSet(3)
+	for _, v := range []value.Value{value.Int(3), value.Str("a"), value.Int(3)} {
+		if err := s.Add(v); err != nil {
+			t.Fatalf("add error: %v", err)
+		}
+	}
+	out, err := callBuiltin(t, env, "__builtin_json_stringify", value.SetVal(s))
+	if err != nil {
+		t.Fatalf("stringify error: %v", err)
+	}
+	if expected := `[3,"a"]`; out.Str() != expected {
+		t.Fatalf("expected %q, got %q", expected, out.Str())
+	}
+}
+
+func TestJSONBigNumbers(t *testing.T) {
+	env := runtime.DefaultEnv()
+	out, err := callBuiltin(t, env, "__builtin_json_parse", value.Str(`[12345678901234567890, 1.25]`))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if items := out.List(); items[0].Kind != value.KindBigInt || items[1].Kind != value.KindFloat {
+		t.Fatalf("expected bigint and float, got %v and %v", items[0].Kind, items[1].Kind)
+	}
+
+	out, err = callBuiltin(t, env, "__builtin_json_parse_decimal", value.Str(`{"price":19.90,"qty":3}`))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	price, _ := out.Dict().GetStr("price")
+	qty, _ := out.Dict().GetStr("qty")
+	if price.Kind != value.KindDecimal || price.String() != "19.90" || qty.Kind != value.KindInt {
+		t.Fatalf("expected decimal 19.90 and int, got %v %s and %v", price.Kind, price, qty.Kind)
+	}
+
+	str, err := callBuiltin(t, env, "__builtin_json_stringify", out)
+	if err != nil {
+		t.Fatalf("stringify error: %v", err)
+	}
+	if expected := `{"price":19.90,"qty":3}`; str.Str() != expected {
+		t.Fatalf("expected %q, got %q", expected, str.Str())
+	}
+}
@@ -0,0 +1,113 @@
+package math
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerToInt()
+	registerParseFloat()
+}
+
+// roundings are the modes of toInt.
+var roundings = map[string]func(float64) float64{
+	"trunc":     math.Trunc,
+	"floor":     math.Floor,
+	"ceil":      math.Ceil,
+	"round":     math.Round,
+	"roundEven": math.RoundToEven,
+}
+
+func registerToInt() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathToInt,
+			Name:         "__builtin_math_to_int",
+			Arity:        2,
+			ParamNames:   []string{"x", "mode"},
+			Params:       []builtins.TypeRef{number, {Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("math.toInt expects 2 arguments, got %d", len(args))
+			}
+			x := args[0].(value.Value)
+			if x.Kind == value.KindInt {
+				return x, nil
+			}
+			xs, err := floatArgs(args[:1], 1, "toInt")
+			if err != nil {
+				return value.Value{}, err
+			}
+			mode := args[1].(value.Value)
+			round, ok := roundings[mode.Str()]
+			if mode.Kind != value.KindString || !ok {
+				return value.Value{}, fmt.Errorf("math.toInt: unknown rounding mode %q", mode.Str())
+			}
+			n, err := toInt(round(xs[0]))
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(n), nil
+		},
+	})
+}
+
+// toInt converts the integral float f to an int.
+func toInt(f float64) (int64, error) {
+	switch {
+	case math.IsNaN(f):
+		return 0, fmt.Errorf("math: cannot convert NaN to int")
+	case math.IsInf(f, 0):
+		return 0, fmt.Errorf("math: cannot convert %v to int", f)
+	// -2^63 is exact as a float64; 2^63 is the first value out of range.
+	case f < math.MinInt64 || f >= -math.MinInt64:
+		return 0, fmt.Errorf("math: %v is out of int range", f)
+	}
+	return int64(f), nil
+}
+
+func registerParseFloat() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathParseFloat,
+			Name:         "__builtin_math_parse_float",
+			Arity:        1,
+			ParamNames:   []string{"text"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       floatRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("math.parseFloat expects 1 argument, got %d", len(args))
+			}
+			text := args[0].(value.Value)
+			if text.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("math.parseFloat expects a string, got %v", text.Kind)
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(text.Str()), 64)
+			if err != nil && !isRangeErr(err) {
+				return value.Value{}, fmt.Errorf("math.parseFloat: invalid float %q", text.Str())
+			}
+			return value.Float(f), nil
+		},
+	})
+}
+
+// isRangeErr reports whether err is strconv's out of range error, for which
+// ParseFloat still returns ±Inf or 0.
+func isRangeErr(err error) bool {
+	ne, ok := err.(*strconv.NumError)
+	return ok && ne.Err == strconv.ErrRange
+}
@@ -0,0 +1,100 @@
+package math
+
+import (
+	"fmt"
+	"math"
+
+	"avenir/internal/intmath"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerIntOp(builtins.MathChecked, "checked", builtins.TypeRef{Kind: builtins.TypeAny}, checked)
+	registerIntOp(builtins.MathWrapping, "wrapping", builtins.TypeRef{Kind: builtins.TypeInt}, wrapping)
+	registerIntOp(builtins.MathSaturating, "saturating", builtins.TypeRef{Kind: builtins.TypeInt}, saturating)
+}
+
+// registerIntOp registers __builtin_math_<name>(op, a, b), which applies
+// the int operator op ("+", "-" or "*") to a and b, handling overflow as fn
+// does.
+func registerIntOp(id builtins.ID, name string, result builtins.TypeRef, fn func(op string, a, b int64) value.Value) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         id,
+			Name:       "__builtin_math_" + name,
+			Arity:      3,
+			ParamNames: []string{"op", "a", "b"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       result,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 3 {
+				return value.Value{}, fmt.Errorf("math.%s expects 3 arguments, got %d", name, len(args))
+			}
+			op, a, b := args[0].(value.Value), args[1].(value.Value), args[2].(value.Value)
+			if op.Kind != value.KindString || a.Kind != value.KindInt || b.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("math.%s expects an operator and two ints", name)
+			}
+			switch op.Str() {
+			case "+", "-", "*":
+			default:
+				return value.Value{}, fmt.Errorf("math.%s: unknown operator %q", name, op.Str())
+			}
+			return fn(op.Str(), a.Int(), b.Int()), nil
+		},
+	})
+}
+
+// exact applies op to a and b, reporting whether the result fit.
+func exact(op string, a, b int64) (int64, bool) {
+	switch op {
+	case "+":
+		return intmath.Add(a, b)
+	case "-":
+		return intmath.Sub(a, b)
+	}
+	return intmath.Mul(a, b)
+}
+
+// checked returns the result as an optional, none on overflow.
+func checked(op string, a, b int64) value.Value {
+	if n, ok := exact(op, a, b); ok {
+		return value.Some(value.Int(n))
+	}
+	return value.None()
+}
+
+// wrapping returns the result modulo 2^64, as Go computes it.
+func wrapping(op string, a, b int64) value.Value {
+	switch op {
+	case "+":
+		return value.Int(a + b)
+	case "-":
+		return value.Int(a - b)
+	}
+	return value.Int(a * b)
+}
+
+// saturating clamps the result to the int range.
+func saturating(op string, a, b int64) value.Value {
+	if n, ok := exact(op, a, b); ok {
+		return value.Int(n)
+	}
+	// a + b and a - b only overflow past the end of a's sign; a * b past
+	// the end of the product's sign.
+	negative := a < 0
+	if op == "*" {
+		negative = (a < 0) != (b < 0)
+	}
+	if negative {
+		return value.Int(math.MinInt64)
+	}
+	return value.Int(math.MaxInt64)
+}
@@ -0,0 +1,217 @@
+package math
+
+import (
+	"fmt"
+	"math"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	for _, f := range []struct {
+		id   builtins.ID
+		name string
+		fn   func(float64) float64
+	}{
+		{builtins.MathSqrt, "sqrt", math.Sqrt},
+		{builtins.MathCbrt, "cbrt", math.Cbrt},
+		{builtins.MathExp, "exp", math.Exp},
+		{builtins.MathLog, "log", math.Log},
+		{builtins.MathLog2, "log2", math.Log2},
+		{builtins.MathLog10, "log10", math.Log10},
+		{builtins.MathSin, "sin", math.Sin},
+		{builtins.MathCos, "cos", math.Cos},
+		{builtins.MathTan, "tan", math.Tan},
+		{builtins.MathAsin, "asin", math.Asin},
+		{builtins.MathAcos, "acos", math.Acos},
+		{builtins.MathAtan, "atan", math.Atan},
+		{builtins.MathFloor, "floor", math.Floor},
+		{builtins.MathCeil, "ceil", math.Ceil},
+		{builtins.MathRound, "round", math.Round},
+		{builtins.MathRoundEven, "round_even", math.RoundToEven},
+		{builtins.MathTrunc, "trunc", math.Trunc},
+		{builtins.MathAbs, "abs", math.Abs},
+	} {
+		registerUnary(f.id, f.name, f.fn)
+	}
+	for _, f := range []struct {
+		id   builtins.ID
+		name string
+		fn   func(float64, float64) float64
+	}{
+		{builtins.MathPow, "pow", math.Pow},
+		{builtins.MathAtan2, "atan2", math.Atan2},
+		{builtins.MathHypot, "hypot", math.Hypot},
+		{builtins.MathMin, "min", math.Min},
+		{builtins.MathMax, "max", math.Max},
+	} {
+		registerBinary(f.id, f.name, f.fn)
+	}
+	registerIsNaN()
+	registerIsInf()
+	registerInf()
+	registerNaN()
+}
+
+// number is the parameter type of float functions, which also take ints.
+var number = builtins.TypeRef{Kind: builtins.TypeUnion, Elem: []builtins.TypeRef{
+	{Kind: builtins.TypeInt},
+	{Kind: builtins.TypeFloat},
+}}
+
+var floatRef = builtins.TypeRef{Kind: builtins.TypeFloat}
+
+// registerUnary registers __builtin_math_<name>, computing fn of a number.
+func registerUnary(id builtins.ID, name string, fn func(float64) float64) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         "__builtin_math_" + name,
+			Arity:        1,
+			ParamNames:   []string{"x"},
+			Params:       []builtins.TypeRef{number},
+			Result:       floatRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			x, err := floatArgs(args, 1, name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Float(fn(x[0])), nil
+		},
+	})
+}
+
+// registerBinary registers __builtin_math_<name>, computing fn of two
+// numbers.
+func registerBinary(id builtins.ID, name string, fn func(float64, float64) float64) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         "__builtin_math_" + name,
+			Arity:        2,
+			ParamNames:   []string{"x", "y"},
+			Params:       []builtins.TypeRef{number, number},
+			Result:       floatRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			x, err := floatArgs(args, 2, name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Float(fn(x[0], x[1])), nil
+		},
+	})
+}
+
+func registerIsNaN() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathIsNaN,
+			Name:         "__builtin_math_is_nan",
+			Arity:        1,
+			ParamNames:   []string{"x"},
+			Params:       []builtins.TypeRef{number},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			x, err := floatArgs(args, 1, "isNaN")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(math.IsNaN(x[0])), nil
+		},
+	})
+}
+
+func registerIsInf() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathIsInf,
+			Name:         "__builtin_math_is_inf",
+			Arity:        1,
+			ParamNames:   []string{"x"},
+			Params:       []builtins.TypeRef{number},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			x, err := floatArgs(args, 1, "isInf")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(math.IsInf(x[0], 0)), nil
+		},
+	})
+}
+
+func registerInf() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathInf,
+			Name:         "__builtin_math_inf",
+			Arity:        1,
+			ParamNames:   []string{"sign"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       floatRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("math.inf expects 1 argument, got %d", len(args))
+			}
+			sign := args[0].(value.Value)
+			if sign.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("math.inf expects sign as int")
+			}
+			return value.Float(math.Inf(int(sign.Int()))), nil
+		},
+	})
+}
+
+func registerNaN() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.MathNaN,
+			Name:         "__builtin_math_nan",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       floatRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			return value.Float(math.NaN()), nil
+		},
+	})
+}
+
+// floatArgs converts the n number arguments of math.name to float64.
+func floatArgs(args []interface{}, n int, name string) ([]float64, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("math.%s expects %d arguments, got %d", name, n, len(args))
+	}
+	xs := make([]float64, n)
+	for i, a := range args {
+		v := a.(value.Value)
+		switch v.Kind {
+		case value.KindInt:
+			xs[i] = float64(v.Int())
+		case value.KindFloat:
+			xs[i] = v.Float()
+		default:
+			return nil, fmt.Errorf("math.%s expects numbers, got %v", name, v.Kind)
+		}
+	}
+	return xs, nil
+}
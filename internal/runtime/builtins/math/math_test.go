@@ -0,0 +1,146 @@
+package math_test
+
+import (
+	stdmath "math"
+	"strings"
+	"testing"
+
+	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func callBuiltin(t *testing.T, env *runtime.Env, name string, args ...value.Value) (value.Value, error) {
+	t.Helper()
+	b := builtins.LookupByName(name)
+	if b == nil {
+		t.Fatalf("builtin %q not found", name)
+	}
+	argsIface := make([]interface{}, len(args))
+	for i, arg := range args {
+		argsIface[i] = arg
+	}
+	res, err := b.Call(env, argsIface)
+	if err != nil {
+		return value.Value{}, err
+	}
+	val, ok := res.(value.Value)
+	if !ok {
+		t.Fatalf("builtin %q returned non-value %T", name, res)
+	}
+	return val, nil
+}
+
+func TestMathFloatFunctions(t *testing.T) {
+	env := runtime.DefaultEnv()
+	tests := []struct {
+		name string
+		args []value.Value
+		want float64
+	}{
+		{"__builtin_math_sqrt", []value.Value{value.Int(9)}, 3},
+		{"__builtin_math_floor", []value.Value{value.Float(-1.5)}, -2},
+		{"__builtin_math_round", []value.Value{value.Float(2.5)}, 3},
+		{"__builtin_math_round_even", []value.Value{value.Float(2.5)}, 2},
+		{"__builtin_math_abs", []value.Value{value.Int(-4)}, 4},
+		{"__builtin_math_pow", []value.Value{value.Int(2), value.Float(0.5)}, stdmath.Sqrt2},
+		{"__builtin_math_max", []value.Value{value.Int(1), value.Float(1.5)}, 1.5},
+	}
+	for _, tt := range tests {
+		got, err := callBuiltin(t, env, tt.name, tt.args...)
+		if err != nil {
+			t.Fatalf("%s error: %v", tt.name, err)
+		}
+		if got.Kind != value.KindFloat || got.Float() != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := callBuiltin(t, env, "__builtin_math_sqrt", value.Str("x")); err == nil {
+		t.Fatalf("expected error for string argument")
+	}
+}
+
+func TestMathToInt(t *testing.T) {
+	env := runtime.DefaultEnv()
+	tests := []struct {
+		x    float64
+		mode string
+		want int64
+	}{
+		{-2.5, "trunc", -2},
+		{-2.5, "floor", -3},
+		{-2.5, "ceil", -2},
+		{-2.5, "round", -3},
+		{-2.5, "roundEven", -2},
+		{-9223372036854775808, "trunc", stdmath.MinInt64},
+	}
+	for _, tt := range tests {
+		got, err := callBuiltin(t, env, "__builtin_math_to_int", value.Float(tt.x), value.Str(tt.mode))
+		if err != nil {
+			t.Fatalf("toInt(%v, %s) error: %v", tt.x, tt.mode, err)
+		}
+		if got.Int() != tt.want {
+			t.Errorf("toInt(%v, %s) = %d, want %d", tt.x, tt.mode, got.Int(), tt.want)
+		}
+	}
+	for _, x := range []float64{stdmath.NaN(), stdmath.Inf(1), 9223372036854775808} {
+		if _, err := callBuiltin(t, env, "__builtin_math_to_int", value.Float(x), value.Str("trunc")); err == nil {
+			t.Errorf("toInt(%v) expected error", x)
+		}
+	}
+	if _, err := callBuiltin(t, env, "__builtin_math_to_int", value.Float(1), value.Str("up")); err == nil {
+		t.Errorf("expected error for unknown mode")
+	}
+}
+
+func TestMathParseFloat(t *testing.T) {
+	env := runtime.DefaultEnv()
+	got, err := callBuiltin(t, env, "__builtin_math_parse_float", value.Str(" -1.25e2 "))
+	if err != nil {
+		t.Fatalf("parseFloat error: %v", err)
+	}
+	if got.Float() != -125 {
+		t.Errorf("parseFloat = %v, want -125", got.Float())
+	}
+	got, err = callBuiltin(t, env, "__builtin_math_parse_float", value.Str("1e400"))
+	if err != nil || !stdmath.IsInf(got.Float(), 1) {
+		t.Errorf("parseFloat(1e400) = %v, %v, want +Inf", got, err)
+	}
+	_, err = callBuiltin(t, env, "__builtin_math_parse_float", value.Str("12abc"))
+	if err == nil || !strings.Contains(err.Error(), `invalid float "12abc"`) {
+		t.Errorf("parseFloat(12abc) error = %v", err)
+	}
+}
+
+func TestMathIntOps(t *testing.T) {
+	env := runtime.DefaultEnv()
+	max, min := value.Int(stdmath.MaxInt64), value.Int(stdmath.MinInt64)
+	tests := []struct {
+		name string
+		op   string
+		a, b value.Value
+		want string
+	}{
+		{"__builtin_math_checked", "+", value.Int(1), value.Int(2), "some(3)"},
+		{"__builtin_math_checked", "+", max, value.Int(1), "none"},
+		{"__builtin_math_checked", "*", min, value.Int(-1), "none"},
+		{"__builtin_math_wrapping", "+", max, value.Int(1), "-9223372036854775808"},
+		{"__builtin_math_wrapping", "-", min, value.Int(1), "9223372036854775807"},
+		{"__builtin_math_saturating", "+", max, value.Int(1), "9223372036854775807"},
+		{"__builtin_math_saturating", "-", value.Int(0), min, "9223372036854775807"},
+		{"__builtin_math_saturating", "-", min, value.Int(1), "-9223372036854775808"},
+		{"__builtin_math_saturating", "*", max, value.Int(-2), "-9223372036854775808"},
+	}
+	for _, tt := range tests {
+		got, err := callBuiltin(t, env, tt.name, value.Str(tt.op), tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("%s(%s) error: %v", tt.name, tt.op, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s(%q, %v, %v) = %v, want %s", tt.name, tt.op, tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := callBuiltin(t, env, "__builtin_math_checked", value.Str("/"), value.Int(1), value.Int(1)); err == nil {
+		t.Errorf("expected error for unknown operator")
+	}
+}
@@ -42,6 +42,10 @@ func typeFromValue(val value.Value, env builtins.Env) (types.Type, error) {
 		return types.Int, nil
 	case value.KindFloat:
 		return types.Float, nil
+	case value.KindBigInt:
+		return types.BigInt, nil
+	case value.KindDecimal:
+		return types.Decimal, nil
 	case value.KindString:
 		return types.String, nil
 	case value.KindBool:
@@ -51,10 +55,10 @@ func typeFromValue(val value.Value, env builtins.Env) (types.Type, error) {
 	case value.KindError:
 		return types.ErrorType, nil
 	case value.KindOptional:
-		if val.Optional == nil || !val.Optional.IsSome {
+		if val.Optional() == nil || !val.Optional().IsSome {
 			return &types.Optional{Inner: types.Any}, nil
 		}
-		inner, err := typeFromValue(val.Optional.Value, env)
+		inner, err := typeFromValue(val.Optional().Value, env)
 		if err != nil {
 			return nil, err
 		}
@@ -64,7 +68,7 @@ func typeFromValue(val value.Value, env builtins.Env) (types.Type, error) {
 		return &types.Optional{Inner: inner}, nil
 	case value.KindList:
 		elemTypes := make([]types.Type, 0)
-		for _, el := range val.List {
+		for _, el := range val.List() {
 			et, err := typeFromValue(el, env)
 			if err != nil {
 				return nil, err
@@ -88,19 +92,19 @@ func typeFromValue(val value.Value, env builtins.Env) (types.Type, error) {
 		}
 		return &types.List{ElementTypes: elemTypes}, nil
 	case value.KindStruct:
-		if val.Struct == nil {
+		if val.Struct() == nil {
 			return nil, fmt.Errorf("typeOf: nil struct value")
 		}
 		if env == nil {
 			return nil, fmt.Errorf("typeOf: runtime env is nil")
 		}
-		if name, ok := env.StructTypeName(val.Struct.TypeIndex); ok {
+		if name, ok := env.StructTypeName(val.Struct().TypeIndex); ok {
 			return &types.Struct{Name: name}, nil
 		}
-		return nil, fmt.Errorf("typeOf: unknown struct type index %d", val.Struct.TypeIndex)
+		return nil, fmt.Errorf("typeOf: unknown struct type index %d", val.Struct().TypeIndex)
 	case value.KindDict:
 		valueTypes := make([]types.Type, 0)
-		for _, v := range val.Dict {
+		for _, v := range val.Dict().All() {
 			vt, err := typeFromValue(v, env)
 			if err != nil {
 				return nil, err
@@ -129,6 +133,17 @@ func typeFromValue(val value.Value, env builtins.Env) (types.Type, error) {
 			valueType = &types.Union{Variants: valueTypes}
 		}
 		return &types.Dict{ValueType: valueType}, nil
+	case value.KindSet:
+		// A set has the element types its elements would have in a list.
+		lt, err := typeFromValue(value.List(val.Set().Elems()), env)
+		if err != nil {
+			return nil, err
+		}
+		elemTypes := lt.(*types.List).ElementTypes
+		if len(elemTypes) == 1 {
+			return &types.Set{ElemType: elemTypes[0]}, nil
+		}
+		return &types.Set{ElemType: &types.Union{Variants: elemTypes}}, nil
 	case value.KindClosure:
 		return &types.Func{ParamTypes: []types.Type{}, Result: types.Any}, nil
 	case value.KindInvalid:
@@ -46,7 +46,7 @@ func registerConnect() {
 			if portVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_socket_connect expects port as int")
 			}
-			handle, err := env.Net().Connect(hostVal.Str, int(portVal.Int))
+			handle, err := env.Net().Connect(hostVal.Str(), int(portVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -85,7 +85,7 @@ func registerListen() {
 			if portVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_socket_listen expects port as int")
 			}
-			handle, err := env.Net().Listen(hostVal.Str, int(portVal.Int))
+			handle, err := env.Net().Listen(hostVal.Str(), int(portVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -158,7 +158,7 @@ func registerRead() {
 			if nVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_socket_read expects n as int")
 			}
-			data, err := env.Net().Read(handle, int(nVal.Int))
+			data, err := env.Net().Read(handle, int(nVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -197,7 +197,7 @@ func registerWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return value.Value{}, fmt.Errorf("__builtin_socket_write expects data as bytes")
 			}
-			n, err := env.Net().Write(handle, dataVal.Bytes)
+			n, err := env.Net().Write(handle, dataVal.Bytes())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -243,8 +243,8 @@ func requireHandle(val value.Value) ([]byte, error) {
 	if val.Kind != value.KindBytes {
 		return nil, fmt.Errorf("socket handle must be bytes")
 	}
-	if len(val.Bytes) == 0 {
+	if len(val.Bytes()) == 0 {
 		return nil, fmt.Errorf("socket handle is empty")
 	}
-	return val.Bytes, nil
+	return val.Bytes(), nil
 }
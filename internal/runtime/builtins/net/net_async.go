@@ -44,7 +44,7 @@ func registerAsyncConnect() {
 			if portVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_async_socket_connect expects port as int")
 			}
-			host, port := hostVal.Str, int(portVal.Int)
+			host, port := hostVal.Str(), int(portVal.Int())
 			netService := env.Net()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := netService.Connect(host, port)
@@ -122,7 +122,7 @@ func registerAsyncRead() {
 			if nVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_async_socket_read expects n as int")
 			}
-			n := int(nVal.Int)
+			n := int(nVal.Int())
 			netService := env.Net()
 			return builtins.RunAsync(func() (interface{}, error) {
 				data, err := netService.Read(handle, n)
@@ -164,7 +164,7 @@ func registerAsyncWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return nil, fmt.Errorf("__builtin_async_socket_write expects data as bytes")
 			}
-			data := append([]byte(nil), dataVal.Bytes...)
+			data := append([]byte(nil), dataVal.Bytes()...)
 			netService := env.Net()
 			return builtins.RunAsync(func() (interface{}, error) {
 				n, err := netService.Write(handle, data)
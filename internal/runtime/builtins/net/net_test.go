@@ -119,7 +119,7 @@ func TestSocketReadWrite(t *testing.T) {
 	resp := callBuiltin(t, env, "__builtin_socket_read", handle, value.Int(4))
 	callBuiltin(t, env, "__builtin_socket_close", handle)
 
-	if resp.Kind != value.KindBytes || string(resp.Bytes) != "pong" {
+	if resp.Kind != value.KindBytes || string(resp.Bytes()) != "pong" {
 		t.Fatalf("expected response 'pong', got %v", resp.String())
 	}
 
@@ -0,0 +1,353 @@
+package os
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerArgs()
+	registerGetenv()
+	registerHasEnv()
+	registerSetenv()
+	registerUnsetenv()
+	registerEnviron()
+	registerExit()
+	registerPid()
+	registerHostname()
+	registerGetwd()
+	registerChdir()
+}
+
+func registerArgs() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSArgs,
+			Name:         "__builtin_os_args",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 0, "__builtin_os_args")
+			if err != nil {
+				return value.Value{}, err
+			}
+			argv := osSvc.Args()
+			items := make([]value.Value, len(argv))
+			for i, a := range argv {
+				items[i] = value.Str(a)
+			}
+			return value.List(items), nil
+		},
+	})
+}
+
+func registerGetenv() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSGetenv,
+			Name:         "__builtin_os_getenv",
+			Arity:        1,
+			ParamNames:   []string{"key"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_getenv")
+			if err != nil {
+				return value.Value{}, err
+			}
+			key, err := requireString(args[0], "__builtin_os_getenv", "key")
+			if err != nil {
+				return value.Value{}, err
+			}
+			val, _, err := osSvc.Getenv(key)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(val), nil
+		},
+	})
+}
+
+func registerHasEnv() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSHasEnv,
+			Name:         "__builtin_os_has_env",
+			Arity:        1,
+			ParamNames:   []string{"key"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_has_env")
+			if err != nil {
+				return value.Value{}, err
+			}
+			key, err := requireString(args[0], "__builtin_os_has_env", "key")
+			if err != nil {
+				return value.Value{}, err
+			}
+			_, ok, err := osSvc.Getenv(key)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(ok), nil
+		},
+	})
+}
+
+func registerSetenv() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.OSSetenv,
+			Name:       "__builtin_os_setenv",
+			Arity:      2,
+			ParamNames: []string{"key", "value"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 2, "__builtin_os_setenv")
+			if err != nil {
+				return value.Value{}, err
+			}
+			key, err := requireString(args[0], "__builtin_os_setenv", "key")
+			if err != nil {
+				return value.Value{}, err
+			}
+			val, err := requireString(args[1], "__builtin_os_setenv", "value")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := osSvc.Setenv(key, val); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerUnsetenv() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSUnsetenv,
+			Name:         "__builtin_os_unsetenv",
+			Arity:        1,
+			ParamNames:   []string{"key"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_unsetenv")
+			if err != nil {
+				return value.Value{}, err
+			}
+			key, err := requireString(args[0], "__builtin_os_unsetenv", "key")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := osSvc.Unsetenv(key); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerEnviron() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSEnviron,
+			Name:         "__builtin_os_environ",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 0, "__builtin_os_environ")
+			if err != nil {
+				return value.Value{}, err
+			}
+			vars, err := osSvc.Environ()
+			if err != nil {
+				return value.Value{}, err
+			}
+			entries := make(map[string]value.Value, len(vars))
+			for k, v := range vars {
+				entries[k] = value.Str(v)
+			}
+			return value.Dict(entries), nil
+		},
+	})
+}
+
+func registerExit() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSExit,
+			Name:         "__builtin_os_exit",
+			Arity:        1,
+			ParamNames:   []string{"code"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("__builtin_os_exit expects 1 argument, got %d", len(args))
+			}
+			codeVal := args[0].(value.Value)
+			if codeVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("__builtin_os_exit expects code as int")
+			}
+			if codeVal.Int() < 0 || codeVal.Int() > 255 {
+				return value.Value{}, fmt.Errorf("__builtin_os_exit expects code in 0..255, got %d", codeVal.Int())
+			}
+			return value.Value{}, &builtins.ExitError{Code: int(codeVal.Int())}
+		},
+	})
+}
+
+func registerPid() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSPid,
+			Name:         "__builtin_os_pid",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 0, "__builtin_os_pid")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(osSvc.Pid())), nil
+		},
+	})
+}
+
+func registerHostname() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSHostname,
+			Name:         "__builtin_os_hostname",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 0, "__builtin_os_hostname")
+			if err != nil {
+				return value.Value{}, err
+			}
+			name, err := osSvc.Hostname()
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(name), nil
+		},
+	})
+}
+
+func registerGetwd() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSGetwd,
+			Name:         "__builtin_os_getwd",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 0, "__builtin_os_getwd")
+			if err != nil {
+				return value.Value{}, err
+			}
+			wd, err := osSvc.Getwd()
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(wd), nil
+		},
+	})
+}
+
+func registerChdir() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSChdir,
+			Name:         "__builtin_os_chdir",
+			Arity:        1,
+			ParamNames:   []string{"dir"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_chdir")
+			if err != nil {
+				return value.Value{}, err
+			}
+			dir, err := requireString(args[0], "__builtin_os_chdir", "dir")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := osSvc.Chdir(dir); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func requireOS(env builtins.Env, args []interface{}, arity int, name string) (builtins.OS, error) {
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, arity, len(args))
+	}
+	if env == nil || env.OS() == nil {
+		return nil, fmt.Errorf("runtime os service is nil")
+	}
+	return env.OS(), nil
+}
+
+func requireString(arg interface{}, name, param string) (string, error) {
+	v := arg.(value.Value)
+	if v.Kind != value.KindString {
+		return "", fmt.Errorf("%s expects %s as string", name, param)
+	}
+	return v.Str(), nil
+}
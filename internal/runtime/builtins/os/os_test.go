@@ -0,0 +1,74 @@
+package os_test
+
+import (
+	"errors"
+	"testing"
+
+	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func callBuiltin(t *testing.T, env *runtime.Env, name string, args ...value.Value) (value.Value, error) {
+	t.Helper()
+	b := builtins.LookupByName(name)
+	if b == nil {
+		t.Fatalf("builtin %q not found", name)
+	}
+	argsIface := make([]interface{}, len(args))
+	for i, arg := range args {
+		argsIface[i] = arg
+	}
+	res, err := b.Call(env, argsIface)
+	if err != nil {
+		return value.Value{}, err
+	}
+	return res.(value.Value), nil
+}
+
+func TestOSArgs(t *testing.T) {
+	env := runtime.DefaultEnv()
+	env.SetArgs([]string{"serve", "--port=8080"})
+	val, err := callBuiltin(t, env, "__builtin_os_args")
+	if err != nil {
+		t.Fatalf("args error: %v", err)
+	}
+	if len(val.List()) != 2 || val.List()[0].Str() != "serve" || val.List()[1].Str() != "--port=8080" {
+		t.Fatalf("unexpected args %s", val.String())
+	}
+}
+
+func TestOSEnv(t *testing.T) {
+	env := runtime.DefaultEnv()
+	t.Setenv("AVENIR_OS_TEST", "")
+	if _, err := callBuiltin(t, env, "__builtin_os_setenv", value.Str("AVENIR_OS_TEST"), value.Str("42")); err != nil {
+		t.Fatalf("setenv error: %v", err)
+	}
+	val, err := callBuiltin(t, env, "__builtin_os_getenv", value.Str("AVENIR_OS_TEST"))
+	if err != nil || val.Str() != "42" {
+		t.Fatalf("expected 42, got %q (%v)", val.Str(), err)
+	}
+	all, err := callBuiltin(t, env, "__builtin_os_environ")
+	if err != nil || all.Dict().StrMap()["AVENIR_OS_TEST"].Str() != "42" {
+		t.Fatalf("expected environ to contain AVENIR_OS_TEST, err %v", err)
+	}
+	if _, err := callBuiltin(t, env, "__builtin_os_unsetenv", value.Str("AVENIR_OS_TEST")); err != nil {
+		t.Fatalf("unsetenv error: %v", err)
+	}
+	has, err := callBuiltin(t, env, "__builtin_os_has_env", value.Str("AVENIR_OS_TEST"))
+	if err != nil || has.Bool() {
+		t.Fatalf("expected variable to be unset, err %v", err)
+	}
+}
+
+func TestOSExit(t *testing.T) {
+	env := runtime.DefaultEnv()
+	_, err := callBuiltin(t, env, "__builtin_os_exit", value.Int(2))
+	var exitErr *builtins.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("expected exit status 2, got %v", err)
+	}
+	if _, err := callBuiltin(t, env, "__builtin_os_exit", value.Int(256)); err == nil || errors.As(err, &exitErr) {
+		t.Fatalf("expected out-of-range code to be rejected, got %v", err)
+	}
+}
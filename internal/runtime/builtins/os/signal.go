@@ -0,0 +1,104 @@
+package os
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerSignalNotify()
+	registerAsyncSignalNext()
+	registerSignalStop()
+}
+
+func registerSignalNotify() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSSignalNotify,
+			Name:         "__builtin_os_signal_notify",
+			Arity:        1,
+			ParamNames:   []string{"signals"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_signal_notify")
+			if err != nil {
+				return value.Value{}, err
+			}
+			listVal := args[0].(value.Value)
+			if listVal.Kind != value.KindList {
+				return value.Value{}, fmt.Errorf("__builtin_os_signal_notify expects signals as list<string>")
+			}
+			names := make([]string, 0, len(listVal.List()))
+			for _, item := range listVal.List() {
+				if item.Kind != value.KindString {
+					return value.Value{}, fmt.Errorf("__builtin_os_signal_notify expects signals as list<string>")
+				}
+				names = append(names, item.Str())
+			}
+			id, err := osSvc.Notify(names)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(id)), nil
+		},
+	})
+}
+
+func registerAsyncSignalNext() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncOSSignalNext,
+			Name:         "__builtin_async_os_signal_next",
+			Arity:        1,
+			ParamNames:   []string{"id"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_async_os_signal_next")
+			if err != nil {
+				return nil, err
+			}
+			idVal := args[0].(value.Value)
+			if idVal.Kind != value.KindInt {
+				return nil, fmt.Errorf("__builtin_async_os_signal_next expects id as int")
+			}
+			return osSvc.NextSignal(int(idVal.Int())), nil
+		},
+	})
+}
+
+func registerSignalStop() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.OSSignalStop,
+			Name:         "__builtin_os_signal_stop",
+			Arity:        1,
+			ParamNames:   []string{"id"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			osSvc, err := requireOS(env, args, 1, "__builtin_os_signal_stop")
+			if err != nil {
+				return value.Value{}, err
+			}
+			idVal := args[0].(value.Value)
+			if idVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("__builtin_os_signal_stop expects id as int")
+			}
+			osSvc.StopSignals(int(idVal.Int()))
+			return value.Value{}, nil
+		},
+	})
+}
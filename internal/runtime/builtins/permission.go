@@ -0,0 +1,21 @@
+package builtins
+
+import "fmt"
+
+// PermissionError is returned by host services when the Env's permissions
+// deny an operation. The VM raises it as a std.os PermissionDenied value
+// when the program imports std.os, and as a plain error otherwise.
+type PermissionError struct {
+	// Op is one of "read", "write", "connect", "listen", "sql", "run" or
+	// "env".
+	Op string
+	// Target is the path, address, command or variable involved, if any.
+	Target string
+}
+
+func (e *PermissionError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("permission denied: %s", e.Op)
+	}
+	return fmt.Sprintf("permission denied: %s %s", e.Op, e.Target)
+}
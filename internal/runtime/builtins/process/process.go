@@ -0,0 +1,301 @@
+package process
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+var (
+	stringList = builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}}
+	anyDict    = builtins.TypeRef{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}}
+)
+
+func init() {
+	registerRun()
+	registerStart()
+	registerPid()
+	registerWrite()
+	registerCloseStdin()
+	registerRead()
+	registerWait()
+	registerKill()
+}
+
+func registerRun() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessRun,
+			Name:         "__builtin_process_run",
+			Arity:        3,
+			ParamNames:   []string{"cmd", "args", "opts"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}, stringList, anyDict},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 3, "__builtin_process_run")
+			if err != nil {
+				return value.Value{}, err
+			}
+			spec, err := parseSpec(env, args[0].(value.Value), args[1].(value.Value), args[2].(value.Value), "__builtin_process_run")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Run(spec)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return resultValue(res, true), nil
+		},
+	})
+}
+
+func registerStart() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessStart,
+			Name:         "__builtin_process_start",
+			Arity:        4,
+			ParamNames:   []string{"cmd", "args", "opts", "stdinFrom"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}, stringList, anyDict, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 4, "__builtin_process_start")
+			if err != nil {
+				return value.Value{}, err
+			}
+			spec, err := parseSpec(env, args[0].(value.Value), args[1].(value.Value), args[2].(value.Value), "__builtin_process_start")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if spec.Stdin != nil {
+				return value.Value{}, fmt.Errorf("__builtin_process_start: opts.stdin is only supported by run; write to the process instead")
+			}
+			from := args[3].(value.Value)
+			if from.Kind == value.KindOptional && from.Optional() != nil && from.Optional().IsSome {
+				from = from.Optional().Value
+			}
+			if from.Kind == value.KindBytes {
+				handle, err := requireHandle(from, "__builtin_process_start")
+				if err != nil {
+					return value.Value{}, err
+				}
+				spec.StdinFrom = handle
+			}
+			handle, err := svc.Start(spec)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bytes(handle), nil
+		},
+	})
+}
+
+func registerPid() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessPid,
+			Name:         "__builtin_process_pid",
+			Arity:        1,
+			ParamNames:   []string{"proc"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 1, "__builtin_process_pid")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value), "__builtin_process_pid")
+			if err != nil {
+				return value.Value{}, err
+			}
+			pid, err := svc.Pid(handle)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(pid)), nil
+		},
+	})
+}
+
+func registerCloseStdin() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessCloseStdin,
+			Name:         "__builtin_process_close_stdin",
+			Arity:        1,
+			ParamNames:   []string{"proc"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 1, "__builtin_process_close_stdin")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value), "__builtin_process_close_stdin")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.CloseStdin(handle); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerKill() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessKill,
+			Name:         "__builtin_process_kill",
+			Arity:        1,
+			ParamNames:   []string{"proc"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 1, "__builtin_process_kill")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value), "__builtin_process_kill")
+			if err != nil {
+				return value.Value{}, err
+			}
+			if err := svc.Kill(handle); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerWrite() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessWrite,
+			Name:         "__builtin_process_write",
+			Arity:        2,
+			ParamNames:   []string{"proc", "data"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}, {Kind: builtins.TypeBytes}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, handle, data, err := writeArgs(env, args, "__builtin_process_write")
+			if err != nil {
+				return value.Value{}, err
+			}
+			n, err := svc.Write(handle, data)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(n)), nil
+		},
+	})
+}
+
+func registerRead() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessRead,
+			Name:         "__builtin_process_read",
+			Arity:        3,
+			ParamNames:   []string{"proc", "stream", "n"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}, {Kind: builtins.TypeString}, {Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBytes},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, handle, stream, n, err := readArgs(env, args, "__builtin_process_read")
+			if err != nil {
+				return value.Value{}, err
+			}
+			data, err := svc.Read(handle, stream, n)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bytes(data), nil
+		},
+	})
+}
+
+func registerWait() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.ProcessWait,
+			Name:         "__builtin_process_wait",
+			Arity:        1,
+			ParamNames:   []string{"proc"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			svc, err := requireProcess(env, args, 1, "__builtin_process_wait")
+			if err != nil {
+				return value.Value{}, err
+			}
+			handle, err := requireHandle(args[0].(value.Value), "__builtin_process_wait")
+			if err != nil {
+				return value.Value{}, err
+			}
+			res, err := svc.Wait(handle)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return resultValue(res, false), nil
+		},
+	})
+}
+
+func writeArgs(env builtins.Env, args []interface{}, name string) (builtins.Process, []byte, []byte, error) {
+	svc, err := requireProcess(env, args, 2, name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	handle, err := requireHandle(args[0].(value.Value), name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dataVal := args[1].(value.Value)
+	if dataVal.Kind != value.KindBytes {
+		return nil, nil, nil, fmt.Errorf("%s expects data as bytes", name)
+	}
+	return svc, handle, dataVal.Bytes(), nil
+}
+
+func readArgs(env builtins.Env, args []interface{}, name string) (builtins.Process, []byte, string, int, error) {
+	svc, err := requireProcess(env, args, 3, name)
+	if err != nil {
+		return nil, nil, "", 0, err
+	}
+	handle, err := requireHandle(args[0].(value.Value), name)
+	if err != nil {
+		return nil, nil, "", 0, err
+	}
+	streamVal := args[1].(value.Value)
+	nVal := args[2].(value.Value)
+	if streamVal.Kind != value.KindString || nVal.Kind != value.KindInt {
+		return nil, nil, "", 0, fmt.Errorf("%s expects stream string and n int", name)
+	}
+	return svc, handle, streamVal.Str(), int(nVal.Int()), nil
+}
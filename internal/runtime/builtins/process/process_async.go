@@ -0,0 +1,129 @@
+package process
+
+import (
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerAsyncRun()
+	registerAsyncWrite()
+	registerAsyncRead()
+	registerAsyncWait()
+}
+
+func registerAsyncRun() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncProcessRun,
+			Name:         "__builtin_async_process_run",
+			Arity:        3,
+			ParamNames:   []string{"cmd", "args", "opts"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}, stringList, anyDict},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireProcess(env, args, 3, "__builtin_async_process_run")
+			if err != nil {
+				return nil, err
+			}
+			spec, err := parseSpec(env, args[0].(value.Value), args[1].(value.Value), args[2].(value.Value), "__builtin_async_process_run")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Run(spec)
+				if err != nil {
+					return nil, err
+				}
+				return resultValue(res, true), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncWrite() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncProcessWrite,
+			Name:         "__builtin_async_process_write",
+			Arity:        2,
+			ParamNames:   []string{"proc", "data"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}, {Kind: builtins.TypeBytes}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, handle, data, err := writeArgs(env, args, "__builtin_async_process_write")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				n, err := svc.Write(handle, data)
+				if err != nil {
+					return nil, err
+				}
+				return value.Int(int64(n)), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncRead() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncProcessRead,
+			Name:         "__builtin_async_process_read",
+			Arity:        3,
+			ParamNames:   []string{"proc", "stream", "n"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}, {Kind: builtins.TypeString}, {Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBytes},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, handle, stream, n, err := readArgs(env, args, "__builtin_async_process_read")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				data, err := svc.Read(handle, stream, n)
+				if err != nil {
+					return nil, err
+				}
+				return value.Bytes(data), nil
+			}), nil
+		},
+	})
+}
+
+func registerAsyncWait() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncProcessWait,
+			Name:         "__builtin_async_process_wait",
+			Arity:        1,
+			ParamNames:   []string{"proc"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+			ReceiverType: builtins.TypeVoid,
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			svc, err := requireProcess(env, args, 1, "__builtin_async_process_wait")
+			if err != nil {
+				return nil, err
+			}
+			handle, err := requireHandle(args[0].(value.Value), "__builtin_async_process_wait")
+			if err != nil {
+				return nil, err
+			}
+			return builtins.RunAsync(func() (interface{}, error) {
+				res, err := svc.Wait(handle)
+				if err != nil {
+					return nil, err
+				}
+				return resultValue(res, false), nil
+			}), nil
+		},
+	})
+}
@@ -0,0 +1,111 @@
+package process
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// requireProcess returns the process service, failing when the host has
+// disabled subprocess execution.
+func requireProcess(env builtins.Env, args []interface{}, arity int, name string) (builtins.Process, error) {
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, arity, len(args))
+	}
+	if env == nil {
+		return nil, fmt.Errorf("runtime env is nil")
+	}
+	svc := env.Process()
+	if svc == nil {
+		return nil, fmt.Errorf("process execution is disabled")
+	}
+	return svc, nil
+}
+
+// parseSpec builds a ProcessSpec from (cmd, args, opts). A relative or empty
+// working directory is resolved against the exec root, like std.fs paths.
+func parseSpec(env builtins.Env, cmdVal, argsVal, optsVal value.Value, name string) (*builtins.ProcessSpec, error) {
+	if cmdVal.Kind != value.KindString || cmdVal.Str() == "" {
+		return nil, fmt.Errorf("%s expects cmd as non-empty string", name)
+	}
+	spec := &builtins.ProcessSpec{Path: cmdVal.Str()}
+	if argsVal.Kind != value.KindList {
+		return nil, fmt.Errorf("%s expects args as list<string>", name)
+	}
+	for _, a := range argsVal.List() {
+		if a.Kind != value.KindString {
+			return nil, fmt.Errorf("%s expects args as list<string>", name)
+		}
+		spec.Args = append(spec.Args, a.Str())
+	}
+	if optsVal.Kind != value.KindDict {
+		return nil, fmt.Errorf("%s expects opts as dict", name)
+	}
+	for key, opt := range optsVal.Dict().StrMap() {
+		switch key {
+		case "env":
+			if opt.Kind != value.KindDict {
+				return nil, fmt.Errorf("%s: opts.env must be dict<string>", name)
+			}
+			spec.Env = make(map[string]string, opt.Dict().Len())
+			for k, v := range opt.Dict().StrMap() {
+				if v.Kind != value.KindString {
+					return nil, fmt.Errorf("%s: opts.env must be dict<string>", name)
+				}
+				spec.Env[k] = v.Str()
+			}
+		case "clearEnv":
+			if opt.Kind != value.KindBool {
+				return nil, fmt.Errorf("%s: opts.clearEnv must be bool", name)
+			}
+			spec.ClearEnv = opt.Bool()
+		case "dir":
+			if opt.Kind != value.KindString {
+				return nil, fmt.Errorf("%s: opts.dir must be string", name)
+			}
+			spec.Dir = opt.Str()
+		case "stdin":
+			switch opt.Kind {
+			case value.KindString:
+				spec.Stdin = []byte(opt.Str())
+			case value.KindBytes:
+				spec.Stdin = opt.Bytes()
+			default:
+				return nil, fmt.Errorf("%s: opts.stdin must be string or bytes", name)
+			}
+		case "timeoutMs":
+			if opt.Kind != value.KindInt || opt.Int() < 0 {
+				return nil, fmt.Errorf("%s: opts.timeoutMs must be a non-negative int", name)
+			}
+			spec.Timeout = time.Duration(opt.Int()) * time.Millisecond
+		default:
+			return nil, fmt.Errorf("%s: unknown option %q", name, key)
+		}
+	}
+	if root := env.ExecRoot(); root != "" && !filepath.IsAbs(spec.Dir) {
+		spec.Dir = filepath.Join(root, spec.Dir)
+	}
+	return spec, nil
+}
+
+func resultValue(res *builtins.ProcessResult, withOutput bool) value.Value {
+	entries := map[string]value.Value{
+		"code":     value.Int(int64(res.Code)),
+		"timedOut": value.Bool(res.TimedOut),
+	}
+	if withOutput {
+		entries["stdout"] = value.Bytes(res.Stdout)
+		entries["stderr"] = value.Bytes(res.Stderr)
+	}
+	return value.Dict(entries)
+}
+
+func requireHandle(v value.Value, name string) ([]byte, error) {
+	if v.Kind != value.KindBytes {
+		return nil, fmt.Errorf("%s expects process handle", name)
+	}
+	return v.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package regex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+)
+
+// maxCached bounds the compile cache. When it is full the cache is dropped
+// and refilled, which keeps the hot patterns of a program cached without
+// tracking recency.
+const maxCached = 256
+
+var cache = struct {
+	mu sync.RWMutex
+	re map[string]*regexp.Regexp
+}{re: make(map[string]*regexp.Regexp)}
+
+// compile returns the compiled form of pattern, compiling it on first use.
+// A compiled regexp is safe for concurrent use, so tasks share entries.
+func compile(pattern string) (*regexp.Regexp, error) {
+	cache.mu.RLock()
+	re, ok := cache.re[pattern]
+	cache.mu.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		var synErr *syntax.Error
+		if errors.As(err, &synErr) {
+			return nil, fmt.Errorf("regex: invalid pattern %q: %s", pattern, synErr.Code)
+		}
+		return nil, fmt.Errorf("regex: invalid pattern %q: %w", pattern, err)
+	}
+
+	cache.mu.Lock()
+	if len(cache.re) >= maxCached {
+		cache.re = make(map[string]*regexp.Regexp)
+	}
+	cache.re[pattern] = re
+	cache.mu.Unlock()
+	return re, nil
+}
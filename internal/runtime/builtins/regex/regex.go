@@ -0,0 +1,283 @@
+package regex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+var (
+	stringRef  = builtins.TypeRef{Kind: builtins.TypeString}
+	intRef     = builtins.TypeRef{Kind: builtins.TypeInt}
+	stringList = builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{stringRef}}
+	matchList  = builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{
+		{Kind: builtins.TypeDict, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+	}}
+)
+
+func init() {
+	registerCompile()
+	registerMatches()
+	registerFindAll()
+	registerReplace()
+	registerReplaceFunc()
+	registerSplit()
+	registerEscape()
+}
+
+func registerCompile() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexCompile,
+			Name:         "__builtin_regex_compile",
+			Arity:        1,
+			ParamNames:   []string{"pattern"},
+			Params:       []builtins.TypeRef{stringRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if _, _, err := regexArgs(args, 1, "compile"); err != nil {
+				return value.Value{}, err
+			}
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerMatches() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexMatches,
+			Name:         "__builtin_regex_matches",
+			Arity:        2,
+			ParamNames:   []string{"pattern", "text"},
+			Params:       []builtins.TypeRef{stringRef, stringRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			re, rest, err := regexArgs(args, 2, "matches")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(re.MatchString(rest[0])), nil
+		},
+	})
+}
+
+func registerFindAll() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexFindAll,
+			Name:         "__builtin_regex_find_all",
+			Arity:        3,
+			ParamNames:   []string{"pattern", "text", "limit"},
+			Params:       []builtins.TypeRef{stringRef, stringRef, intRef},
+			Result:       matchList,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			re, rest, err := regexArgs(args[:2], 2, "findAll")
+			if err != nil {
+				return value.Value{}, err
+			}
+			limit, err := intArg(args, 2, "findAll")
+			if err != nil {
+				return value.Value{}, err
+			}
+			text := rest[0]
+			locs := re.FindAllStringSubmatchIndex(text, limit)
+			matches := make([]value.Value, len(locs))
+			for i, loc := range locs {
+				matches[i] = matchValue(re, text, loc)
+			}
+			return value.List(matches), nil
+		},
+	})
+}
+
+func registerReplace() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexReplace,
+			Name:         "__builtin_regex_replace",
+			Arity:        3,
+			ParamNames:   []string{"pattern", "text", "template"},
+			Params:       []builtins.TypeRef{stringRef, stringRef, stringRef},
+			Result:       stringRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			re, rest, err := regexArgs(args, 3, "replace")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(re.ReplaceAllString(rest[0], rest[1])), nil
+		},
+	})
+}
+
+func registerReplaceFunc() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexReplaceFunc,
+			Name:         "__builtin_regex_replace_func",
+			Arity:        3,
+			ParamNames:   []string{"pattern", "text", "fn"},
+			Params:       []builtins.TypeRef{stringRef, stringRef, {Kind: builtins.TypeAny}},
+			Result:       stringRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			re, rest, err := regexArgs(args[:2], 2, "replaceFunc")
+			if err != nil {
+				return value.Value{}, err
+			}
+			fnVal := args[2].(value.Value)
+			if fnVal.Kind != value.KindClosure {
+				return value.Value{}, fmt.Errorf("regex.replaceFunc: fn argument must be a function, got %v", fnVal.Kind)
+			}
+			text := rest[0]
+			var b strings.Builder
+			last := 0
+			for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+				result, err := env.CallClosure(fnVal.Closure(), []interface{}{matchValue(re, text, loc)})
+				if err != nil {
+					return value.Value{}, fmt.Errorf("regex.replaceFunc: %w", err)
+				}
+				repl, ok := result.(value.Value)
+				if !ok || repl.Kind != value.KindString {
+					return value.Value{}, fmt.Errorf("regex.replaceFunc: fn must return a string")
+				}
+				b.WriteString(text[last:loc[0]])
+				b.WriteString(repl.Str())
+				last = loc[1]
+			}
+			b.WriteString(text[last:])
+			return value.Str(b.String()), nil
+		},
+	})
+}
+
+func registerSplit() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexSplit,
+			Name:         "__builtin_regex_split",
+			Arity:        3,
+			ParamNames:   []string{"pattern", "text", "limit"},
+			Params:       []builtins.TypeRef{stringRef, stringRef, intRef},
+			Result:       stringList,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			re, rest, err := regexArgs(args[:2], 2, "split")
+			if err != nil {
+				return value.Value{}, err
+			}
+			limit, err := intArg(args, 2, "split")
+			if err != nil {
+				return value.Value{}, err
+			}
+			parts := re.Split(rest[0], limit)
+			out := make([]value.Value, len(parts))
+			for i, part := range parts {
+				out[i] = value.Str(part)
+			}
+			return value.List(out), nil
+		},
+	})
+}
+
+func registerEscape() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.RegexEscape,
+			Name:         "__builtin_regex_escape",
+			Arity:        1,
+			ParamNames:   []string{"text"},
+			Params:       []builtins.TypeRef{stringRef},
+			Result:       stringRef,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("regex.escape expects 1 argument, got %d", len(args))
+			}
+			text := args[0].(value.Value)
+			if text.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("regex.escape expects a string, got %v", text.Kind)
+			}
+			return value.Str(regexp.QuoteMeta(text.Str())), nil
+		},
+	})
+}
+
+// regexArgs checks that args are n strings, the first being the pattern,
+// and returns the compiled pattern with the remaining strings.
+func regexArgs(args []interface{}, n int, name string) (*regexp.Regexp, []string, error) {
+	if len(args) != n {
+		return nil, nil, fmt.Errorf("regex.%s expects %d arguments, got %d", name, n, len(args))
+	}
+	strs := make([]string, n)
+	for i, arg := range args {
+		v := arg.(value.Value)
+		if v.Kind != value.KindString {
+			return nil, nil, fmt.Errorf("regex.%s expects string arguments, got %v", name, v.Kind)
+		}
+		strs[i] = v.Str()
+	}
+	re, err := compile(strs[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return re, strs[1:], nil
+}
+
+func intArg(args []interface{}, i int, name string) (int, error) {
+	if len(args) <= i {
+		return 0, fmt.Errorf("regex.%s: missing argument %d", name, i+1)
+	}
+	v := args[i].(value.Value)
+	if v.Kind != value.KindInt {
+		return 0, fmt.Errorf("regex.%s expects an int, got %v", name, v.Kind)
+	}
+	return int(v.Int()), nil
+}
+
+// matchValue describes one match as a dict: the matched text, its byte
+// offsets, every group (the whole match first) and the named groups.
+// Groups that did not take part in the match are empty strings.
+func matchValue(re *regexp.Regexp, text string, loc []int) value.Value {
+	names := re.SubexpNames()
+	groups := make([]value.Value, len(loc)/2)
+	named := value.NewDict(0)
+	for g := range groups {
+		s := ""
+		if loc[2*g] >= 0 {
+			s = text[loc[2*g]:loc[2*g+1]]
+		}
+		groups[g] = value.Str(s)
+		if names[g] != "" {
+			named.SetStr(names[g], value.Str(s))
+		}
+	}
+	m := value.NewDict(5)
+	m.SetStr("text", groups[0])
+	m.SetStr("start", value.Int(int64(loc[0])))
+	m.SetStr("end", value.Int(int64(loc[1])))
+	m.SetStr("groups", value.List(groups))
+	m.SetStr("named", value.DictVal(named))
+	return value.DictVal(m)
+}
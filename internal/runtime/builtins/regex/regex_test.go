@@ -0,0 +1,91 @@
+package regex_test
+
+import (
+	"strings"
+	"testing"
+
+	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func callBuiltin(t *testing.T, env *runtime.Env, name string, args ...value.Value) (value.Value, error) {
+	t.Helper()
+	b := builtins.LookupByName(name)
+	if b == nil {
+		t.Fatalf("builtin %q not found", name)
+	}
+	argsIface := make([]interface{}, len(args))
+	for i, arg := range args {
+		argsIface[i] = arg
+	}
+	res, err := b.Call(env, argsIface)
+	if err != nil {
+		return value.Value{}, err
+	}
+	val, ok := res.(value.Value)
+	if !ok {
+		t.Fatalf("builtin %q returned non-value %T", name, res)
+	}
+	return val, nil
+}
+
+func TestRegexFindAll(t *testing.T) {
+	env := runtime.DefaultEnv()
+	out, err := callBuiltin(t, env, "__builtin_regex_find_all",
+		value.Str(`(?P<key>\w+)=(\d+)?`), value.Str("a=1 b= c=3"), value.Int(-1))
+	if err != nil {
+		t.Fatalf("findAll error: %v", err)
+	}
+	if n := len(out.List()); n != 3 {
+		t.Fatalf("expected 3 matches, got %d", n)
+	}
+	m := out.List()[1].Dict()
+	text, _ := m.GetStr("text")
+	start, _ := m.GetStr("start")
+	groups, _ := m.GetStr("groups")
+	named, _ := m.GetStr("named")
+	key, _ := named.Dict().GetStr("key")
+	if text.Str() != "b=" || start.Int() != 4 || key.Str() != "b" {
+		t.Fatalf("unexpected match %v", out.List()[1])
+	}
+	if g := groups.List(); len(g) != 3 || g[2].Str() != "" {
+		t.Fatalf("expected an empty unmatched group, got %v", groups)
+	}
+
+	out, err = callBuiltin(t, env, "__builtin_regex_find_all", value.Str(`\d`), value.Str("1 2 3"), value.Int(2))
+	if err != nil || len(out.List()) != 2 {
+		t.Fatalf("findAll with limit = %v, %v", out, err)
+	}
+}
+
+func TestRegexReplaceAndSplit(t *testing.T) {
+	env := runtime.DefaultEnv()
+	out, err := callBuiltin(t, env, "__builtin_regex_replace",
+		value.Str(`(?P<y>\d{4})-(?P<m>\d{2})`), value.Str("on 2024-05"), value.Str("$m/$y"))
+	if err != nil || out.Str() != "on 05/2024" {
+		t.Fatalf("replace = %q, %v", out.Str(), err)
+	}
+	out, err = callBuiltin(t, env, "__builtin_regex_split", value.Str(`\s*;\s*`), value.Str("a ; b;c"), value.Int(-1))
+	if err != nil {
+		t.Fatalf("split error: %v", err)
+	}
+	if got := out.String(); got != "[a, b, c]" {
+		t.Fatalf("split = %s", got)
+	}
+	out, err = callBuiltin(t, env, "__builtin_regex_escape", value.Str("a.b*c"))
+	if err != nil || out.Str() != `a\.b\*c` {
+		t.Fatalf("escape = %q, %v", out.Str(), err)
+	}
+}
+
+func TestRegexInvalidPattern(t *testing.T) {
+	env := runtime.DefaultEnv()
+	_, err := callBuiltin(t, env, "__builtin_regex_compile", value.Str("a(b"))
+	if err == nil || !strings.Contains(err.Error(), `regex: invalid pattern "a(b": missing closing )`) {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+	if _, err := callBuiltin(t, env, "__builtin_regex_matches", value.Str("[a-"), value.Str("a")); err == nil {
+		t.Fatalf("expected error for invalid pattern in matches")
+	}
+}
@@ -2,7 +2,9 @@ package builtins
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Env provides host services to builtins (IO, FS, etc.).
@@ -10,6 +12,8 @@ import (
 type Env interface {
 	IO() IO
 	StructTypeName(index int) (string, bool)
+	// StructFieldIndex returns the index of the named field of a struct type.
+	StructFieldIndex(index int, field string) (int, bool)
 	Net() Net
 	FS() FS
 	HTTP() HTTP
@@ -17,12 +21,104 @@ type Env interface {
 	TLS() TLS
 	WS() WS
 	ExecRoot() string
+	// CheckRead returns a *PermissionError when reading path is not allowed,
+	// for builtins that access files outside the FS service.
+	CheckRead(path string) error
+	Timers() Timers
+	OS() OS
+	// Process returns nil when subprocess execution is disabled.
+	Process() Process
 	// CallClosure calls a closure with the given arguments.
 	// This enables builtins to call first-class functions (e.g., in map/filter/reduce).
 	// The closure and arguments are passed as interface{} to avoid import cycles.
 	CallClosure(clo interface{}, args []interface{}) (interface{}, error)
 }
 
+// Timers is the interface needed by timer builtins. When an event loop is
+// running, timers fire on it instead of on a goroutine per timer.
+type Timers interface {
+	// Sleep returns a handle that resolves after d.
+	Sleep(d time.Duration) AsyncHandle
+	// NewTimer creates a stoppable timer and returns its id.
+	NewTimer() int
+	// Wait returns a handle that resolves to true after d, or to false as soon
+	// as the timer is stopped.
+	Wait(id int, d time.Duration) AsyncHandle
+	// Stop stops the timer, releasing any pending Wait.
+	Stop(id int)
+	// Stopped reports whether the timer has been stopped.
+	Stopped(id int) bool
+}
+
+// OS is the interface needed by builtin os functions: process arguments,
+// environment, identity, working directory and signals.
+type OS interface {
+	// Args returns the arguments passed to the program after the script path.
+	Args() []string
+	Getenv(key string) (string, bool, error)
+	Setenv(key, value string) error
+	Unsetenv(key string) error
+	Environ() (map[string]string, error)
+	Pid() int
+	Hostname() (string, error)
+	// Getwd returns the working directory used to resolve relative paths.
+	Getwd() (string, error)
+	// Chdir changes the working directory used to resolve relative paths.
+	Chdir(dir string) error
+	// Notify starts relaying the named signals (e.g. "SIGTERM") and returns
+	// a subscription id.
+	Notify(signals []string) (int, error)
+	// NextSignal returns a handle that resolves to the name of the next
+	// signal delivered to the subscription, or to "" once it is stopped.
+	NextSignal(id int) AsyncHandle
+	// StopSignals ends the subscription, releasing any pending NextSignal.
+	StopSignals(id int)
+}
+
+// ProcessSpec describes a command to run.
+type ProcessSpec struct {
+	Path string
+	Args []string
+	// Env is added to the inherited environment, or replaces it when
+	// ClearEnv is set.
+	Env      map[string]string
+	ClearEnv bool
+	Dir      string
+	// Stdin is fed to the command by Run.
+	Stdin []byte
+	// StdinFrom is the handle of a started process whose stdout becomes the
+	// command's stdin (Start only).
+	StdinFrom []byte
+	// Timeout kills the command once it elapses; zero means no timeout.
+	Timeout time.Duration
+}
+
+// ProcessResult is the outcome of a finished command. Code is -1 when the
+// process was killed by a signal.
+type ProcessResult struct {
+	Code     int
+	Stdout   []byte
+	Stderr   []byte
+	TimedOut bool
+}
+
+// Process is the interface needed by builtin process functions.
+type Process interface {
+	// Run starts the command, waits for it and returns its captured output.
+	Run(spec *ProcessSpec) (*ProcessResult, error)
+	// Start launches the command with piped stdin, stdout and stderr.
+	Start(spec *ProcessSpec) ([]byte, error)
+	Pid(handle []byte) (int, error)
+	Write(handle []byte, data []byte) (int, error)
+	CloseStdin(handle []byte) error
+	// Read reads up to n bytes from "stdout" or "stderr". It returns no
+	// data once the stream is exhausted.
+	Read(handle []byte, stream string, n int) ([]byte, error)
+	// Wait blocks until the process exits. Stdout and Stderr are not set.
+	Wait(handle []byte) (*ProcessResult, error)
+	Kill(handle []byte) error
+}
+
 // IO is the minimal interface needed by builtin IO functions (e.g. print, input).
 // This matches the interface defined in builtins/io/io.go.
 type IO interface {
@@ -49,6 +145,49 @@ type FS interface {
 	Exists(path string) (bool, error)
 	Remove(path string) error
 	Mkdir(path string) error
+	Stat(path string) (*FileInfo, error)
+	Lstat(path string) (*FileInfo, error)
+	// ReadDir lists a directory sorted by name. Entries describe the
+	// entries themselves; symlinks are not followed.
+	ReadDir(path string) ([]FileInfo, error)
+	Rename(from, to string) error
+	// Copy copies a regular file, including its permission bits.
+	Copy(from, to string) error
+	MkdirAll(path string) error
+	RemoveAll(path string) error
+	Chmod(path string, mode uint32) error
+	Symlink(target, link string) error
+	Readlink(path string) (string, error)
+	// Glob matches pattern with filepath.Match syntax. Relative patterns are
+	// matched under dir and their matches are returned relative to it.
+	Glob(dir, pattern string) ([]string, error)
+	Seek(handle []byte, offset int64, whence int) (int64, error)
+	Truncate(handle []byte, size int64) error
+	// Watch starts watching paths for changes and returns a watcher handle.
+	Watch(paths []string, opts WatchOptions) ([]byte, error)
+	// NextWatchEvents resolves to the next debounced batch of events as a
+	// list of {op, path} dicts, or to an empty list once the watcher is closed.
+	NextWatchEvents(handle []byte) AsyncHandle
+	CloseWatch(handle []byte) error
+}
+
+// WatchOptions configures FS.Watch. Zero durations select the defaults.
+type WatchOptions struct {
+	Recursive    bool
+	Debounce     time.Duration
+	Poll         bool
+	PollInterval time.Duration
+}
+
+// FileInfo describes a file. Mode holds the permission bits; ModTime is in
+// Unix nanoseconds.
+type FileInfo struct {
+	Name      string
+	Size      int64
+	Mode      uint32
+	ModTime   int64
+	IsDir     bool
+	IsSymlink bool
 }
 
 // HTTP is the minimal interface needed by builtin HTTP functions.
@@ -57,6 +196,7 @@ type HTTP interface {
 	Listen(host string, port int) ([]byte, error)
 	Accept(serverHandle []byte) (*HTTPRequestData, error)
 	Respond(reqHandle []byte, status int, headers map[string]string, body []byte) error
+	Close(serverHandle []byte) error
 }
 
 // SQL is the minimal interface needed by builtin SQL functions.
@@ -372,6 +512,186 @@ const (
 	// WebSocket builtins (sync)
 	WSSetReadLimit
 	WSGetInfo
+
+	// Timer builtins
+	TimeTimerNew
+	TimeTimerStop
+	TimeTimerStopped
+	AsyncTimeTimerWait
+	TimeCronNext
+	TimeJitter
+
+	// Time zone and calendar builtins
+	TimeZoneLoad
+	TimeFields
+	TimeDate
+	TimeAddDate
+	TimeFormatInZone
+	TimeParseInZone
+	TimeMonotonic
+
+	// OS builtins
+	OSArgs
+	OSGetenv
+	OSHasEnv
+	OSSetenv
+	OSUnsetenv
+	OSEnviron
+	OSExit
+	OSPid
+	OSHostname
+	OSGetwd
+	OSChdir
+	OSSignalNotify
+	AsyncOSSignalNext
+	OSSignalStop
+
+	HTTPClose
+
+	// Process builtins
+	ProcessRun
+	AsyncProcessRun
+	ProcessStart
+	ProcessPid
+	ProcessWrite
+	AsyncProcessWrite
+	ProcessCloseStdin
+	ProcessRead
+	AsyncProcessRead
+	ProcessWait
+	AsyncProcessWait
+	ProcessKill
+	FSStat
+	AsyncFSStat
+	FSLstat
+	AsyncFSLstat
+	FSReadDir
+	AsyncFSReadDir
+	FSRename
+	AsyncFSRename
+	FSCopy
+	AsyncFSCopy
+	FSMkdirAll
+	AsyncFSMkdirAll
+	FSRemoveAll
+	AsyncFSRemoveAll
+	FSChmod
+	AsyncFSChmod
+	FSSymlink
+	AsyncFSSymlink
+	FSReadlink
+	AsyncFSReadlink
+	FSGlob
+	AsyncFSGlob
+	FSSeek
+	AsyncFSSeek
+	FSTruncate
+	AsyncFSTruncate
+	FSWatch
+	AsyncFSWatchNext
+	FSWatchClose
+	DictEntries
+	SetLength
+	SetAdd
+	SetRemove
+	SetHas
+	SetUnion
+	SetIntersection
+	SetDifference
+	SetIsSubsetOf
+	SetIsSupersetOf
+	SetToList
+	ListToSet
+	ListSort
+	ListSortBy
+	ListFind
+	ListFindIndex
+	ListAny
+	ListAll
+	ListCount
+	ListZip
+	ListFlatMap
+	ListGroupBy
+	ListChunk
+	ListUnique
+	ListJoin
+	ListMin
+	ListMax
+	ListSum
+	Range
+	MathSqrt
+	MathCbrt
+	MathExp
+	MathLog
+	MathLog2
+	MathLog10
+	MathSin
+	MathCos
+	MathTan
+	MathAsin
+	MathAcos
+	MathAtan
+	MathFloor
+	MathCeil
+	MathRound
+	MathRoundEven
+	MathTrunc
+	MathAbs
+	MathPow
+	MathAtan2
+	MathHypot
+	MathMin
+	MathMax
+	MathIsNaN
+	MathIsInf
+	MathInf
+	MathNaN
+	MathToInt
+	MathParseFloat
+	MathChecked
+	MathWrapping
+	MathSaturating
+	BigIntNew
+	BigIntToInt
+	BigIntToFloat
+	BigIntToString
+	BigIntAbs
+	BigIntSign
+	BigIntPow
+	DecimalNew
+	DecimalScale
+	DecimalSetScale
+	DecimalDiv
+	DecimalToFloat
+	DecimalToString
+	DecimalToBigInt
+	DecimalAbs
+	DecimalSign
+	JSONParseDecimal
+	RegexCompile
+	RegexMatches
+	RegexFindAll
+	RegexReplace
+	RegexReplaceFunc
+	RegexSplit
+	RegexEscape
+	ToFloat
+	ParseInt
+	StringPadLeft
+	StringPadRight
+	StringRepeat
+	StringChars
+	StringRunes
+	StringRuneCount
+	StringFoldCase
+	StringEqualFold
+	StringNormalize
+	StringsFormat
+	StringsBuilderNew
+	StringsBuilderWrite
+	StringsBuilderString
+	StringsBuilderLen
+	StringsBuilderReset
 )
 
 // TypeKind represents a type in the builtin type system.
@@ -389,6 +709,9 @@ const (
 	TypeError
 	TypeBytes
 	TypeUnion
+	TypeSet
+	TypeBigInt
+	TypeDecimal
 	// extend later if needed
 )
 
@@ -411,6 +734,7 @@ type Meta struct {
 	Result       TypeRef
 	ReceiverType TypeKind // TypeVoid for regular functions, non-Void for methods
 	MethodName   string   // Empty for regular functions, method name for methods
+	Optional     int      // Trailing parameters that calls may omit; they are passed as none
 }
 
 // AsyncHandle is an opaque interface for an asynchronous operation handle.
@@ -448,17 +772,29 @@ type Builtin struct {
 	CallAsync func(env Env, args []interface{}) (AsyncHandle, error)
 }
 
-// IsAsyncBuiltin returns true if the given builtin ID is an async builtin.
+// IsAsyncBuiltin returns true if the given builtin ID is an async builtin
+// of the default registry.
 func IsAsyncBuiltin(id ID) bool {
-	b := LookupByID(id)
-	if b == nil {
-		return false
+	return defaultRegistry.IsAsync(id)
+}
+
+// AutoID asks Registry.Register to assign an unused ID, for builtins added
+// at run time by programs embedding Avenir.
+const AutoID ID = -1
+
+// Symbol returns the name that bytecode uses to refer to the builtin: the
+// function name, or "type.method" for methods (e.g. "list.filter").
+func (m Meta) Symbol() string {
+	if m.ReceiverType != TypeVoid {
+		return m.ReceiverType.String() + "." + m.MethodName
 	}
-	return b.CallAsync != nil
+	return m.Name
 }
 
-// registry holds all registered builtins with fast lookup indexes.
-type registry struct {
+// Registry holds builtins with fast lookup indexes. The compiler, type
+// checker and VM each take a registry, so that programs can be given
+// builtins beyond the default ones.
+type Registry struct {
 	mu sync.RWMutex
 
 	// Index by ID for fast dispatch
@@ -469,108 +805,237 @@ type registry struct {
 
 	// Index by (receiver type, method name) for method lookup
 	byMethod map[TypeKind]map[string]*Builtin
+
+	nextID ID // lowest ID handed out for AutoID
 }
 
-var globalRegistry = &registry{
-	byID:     make(map[ID]*Builtin),
-	byName:   make(map[string]*Builtin),
-	byMethod: make(map[TypeKind]map[string]*Builtin),
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byID:     make(map[ID]*Builtin),
+		byName:   make(map[string]*Builtin),
+		byMethod: make(map[TypeKind]map[string]*Builtin),
+	}
 }
 
-// Register registers a builtin. This is called automatically by each builtin's init() function.
+// defaultRegistry holds the builtins registered by init functions.
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry of the builtins registered by init
+// functions. Use Default().Clone() to extend it without affecting other
+// programs.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register registers a builtin in the default registry. This is called automatically by each builtin's init() function.
 // Panics if the builtin ID is already registered or if metadata is invalid.
 func Register(b Builtin) {
-	globalRegistry.mu.Lock()
-	defer globalRegistry.mu.Unlock()
+	if err := defaultRegistry.Register(b); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Register adds a builtin to r. With AutoID, an unused ID is assigned.
+// It returns an error if the ID, name or method is already registered or if
+// the metadata is invalid.
+func (r *Registry) Register(b Builtin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	// Validate metadata
 	if len(b.Meta.ParamNames) != b.Meta.Arity {
-		panic(fmt.Sprintf("builtin %s (ID %d): ParamNames length (%d) != Arity (%d)",
-			b.Meta.Name, b.Meta.ID, len(b.Meta.ParamNames), b.Meta.Arity))
+		return fmt.Errorf("builtin %s (ID %d): ParamNames length (%d) != Arity (%d)",
+			b.Meta.Name, b.Meta.ID, len(b.Meta.ParamNames), b.Meta.Arity)
+	}
+	if b.Meta.Optional < 0 || b.Meta.Optional > b.Meta.Arity {
+		return fmt.Errorf("builtin %s (ID %d): Optional (%d) out of range for Arity (%d)",
+			b.Meta.Name, b.Meta.ID, b.Meta.Optional, b.Meta.Arity)
 	}
 
 	// For methods, ensure the first parameter is the receiver
 	if b.Meta.ReceiverType != TypeVoid {
 		if len(b.Meta.Params) == 0 {
-			panic(fmt.Sprintf("method %s on %v has no parameters (receiver missing)", b.Meta.MethodName, b.Meta.ReceiverType))
+			return fmt.Errorf("method %s on %v has no parameters (receiver missing)", b.Meta.MethodName, b.Meta.ReceiverType)
 		}
 		if b.Meta.Params[0].Kind != b.Meta.ReceiverType {
-			panic(fmt.Sprintf("method %s on %v has wrong receiver type in first parameter", b.Meta.MethodName, b.Meta.ReceiverType))
+			return fmt.Errorf("method %s on %v has wrong receiver type in first parameter", b.Meta.MethodName, b.Meta.ReceiverType)
 		}
 		if b.Meta.MethodName == "" {
-			panic(fmt.Sprintf("builtin %s (ID %d): ReceiverType is set but MethodName is empty", b.Meta.Name, b.Meta.ID))
+			return fmt.Errorf("builtin %s (ID %d): ReceiverType is set but MethodName is empty", b.Meta.Name, b.Meta.ID)
+		}
+		if _, exists := r.byMethod[b.Meta.ReceiverType][b.Meta.MethodName]; exists {
+			return fmt.Errorf("method %s on %v is already registered", b.Meta.MethodName, b.Meta.ReceiverType)
 		}
 	} else {
 		if b.Meta.MethodName != "" {
-			panic(fmt.Sprintf("builtin %s (ID %d): MethodName is set but ReceiverType is TypeVoid", b.Meta.Name, b.Meta.ID))
+			return fmt.Errorf("builtin %s (ID %d): MethodName is set but ReceiverType is TypeVoid", b.Meta.Name, b.Meta.ID)
+		}
+		if strings.Contains(b.Meta.Name, ".") {
+			return fmt.Errorf("builtin name %q must not contain dots", b.Meta.Name)
+		}
+		// Check for duplicate name (for regular functions)
+		if _, exists := r.byName[b.Meta.Name]; exists {
+			return fmt.Errorf("builtin name %q is already registered", b.Meta.Name)
 		}
 	}
 
-	// Check for duplicate ID
-	if _, exists := globalRegistry.byID[b.Meta.ID]; exists {
-		panic(fmt.Sprintf("builtin ID %d (%s) is already registered", b.Meta.ID, b.Meta.Name))
+	if b.Meta.ID == AutoID {
+		for r.byID[r.nextID] != nil {
+			r.nextID++
+		}
+		b.Meta.ID = r.nextID
+	} else if _, exists := r.byID[b.Meta.ID]; exists {
+		// Check for duplicate ID
+		return fmt.Errorf("builtin ID %d (%s) is already registered", b.Meta.ID, b.Meta.Name)
 	}
 
-	// Check for duplicate name (for regular functions)
+	r.byID[b.Meta.ID] = &b
 	if b.Meta.ReceiverType == TypeVoid {
-		if _, exists := globalRegistry.byName[b.Meta.Name]; exists {
-			panic(fmt.Sprintf("builtin name %q is already registered", b.Meta.Name))
+		r.byName[b.Meta.Name] = &b
+	} else {
+		if r.byMethod[b.Meta.ReceiverType] == nil {
+			r.byMethod[b.Meta.ReceiverType] = make(map[string]*Builtin)
 		}
-		globalRegistry.byName[b.Meta.Name] = &b
+		r.byMethod[b.Meta.ReceiverType][b.Meta.MethodName] = &b
 	}
+	return nil
+}
 
-	// Index by ID
-	globalRegistry.byID[b.Meta.ID] = &b
-
-	// Index by method if applicable
-	if b.Meta.ReceiverType != TypeVoid {
-		if globalRegistry.byMethod[b.Meta.ReceiverType] == nil {
-			globalRegistry.byMethod[b.Meta.ReceiverType] = make(map[string]*Builtin)
-		}
-		if _, exists := globalRegistry.byMethod[b.Meta.ReceiverType][b.Meta.MethodName]; exists {
-			panic(fmt.Sprintf("method %s on %v is already registered", b.Meta.MethodName, b.Meta.ReceiverType))
+// Clone returns a copy of r that can be extended independently.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := NewRegistry()
+	c.nextID = r.nextID
+	for id, b := range r.byID {
+		c.byID[id] = b
+	}
+	for name, b := range r.byName {
+		c.byName[name] = b
+	}
+	for kind, methods := range r.byMethod {
+		c.byMethod[kind] = make(map[string]*Builtin, len(methods))
+		for name, b := range methods {
+			c.byMethod[kind][name] = b
 		}
-		globalRegistry.byMethod[b.Meta.ReceiverType][b.Meta.MethodName] = &b
 	}
+	return c
 }
 
 // LookupByID finds a builtin by ID. Returns nil if not found.
-func LookupByID(id ID) *Builtin {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
-	return globalRegistry.byID[id]
+func (r *Registry) LookupByID(id ID) *Builtin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byID[id]
 }
 
 // LookupByName finds a builtin by name (for regular functions only).
 // Returns nil if not found.
-func LookupByName(name string) *Builtin {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
-	return globalRegistry.byName[name]
+func (r *Registry) LookupByName(name string) *Builtin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byName[name]
 }
 
 // LookupMethod finds a built-in method by receiver type and method name.
 // Returns nil if not found.
-func LookupMethod(receiverType TypeKind, methodName string) *Builtin {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
-	if methodMap, ok := globalRegistry.byMethod[receiverType]; ok {
+func (r *Registry) LookupMethod(receiverType TypeKind, methodName string) *Builtin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if methodMap, ok := r.byMethod[receiverType]; ok {
 		return methodMap[methodName]
 	}
 	return nil
 }
 
+// LookupSymbol finds a builtin by its Meta.Symbol. Returns nil if not found.
+func (r *Registry) LookupSymbol(symbol string) *Builtin {
+	if typeName, method, ok := strings.Cut(symbol, "."); ok {
+		for kind := TypeInt; kind <= TypeDecimal; kind++ {
+			if kind.String() == typeName {
+				return r.LookupMethod(kind, method)
+			}
+		}
+		return nil
+	}
+	return r.LookupByName(symbol)
+}
+
+// IsAsync reports whether the builtin with the given ID is async.
+func (r *Registry) IsAsync(id ID) bool {
+	b := r.LookupByID(id)
+	return b != nil && b.CallAsync != nil
+}
+
 // All returns all registered builtin metadata. Used for type checking and other introspection.
-func All() []Meta {
-	globalRegistry.mu.RLock()
-	defer globalRegistry.mu.RUnlock()
-	result := make([]Meta, 0, len(globalRegistry.byID))
-	for _, b := range globalRegistry.byID {
+func (r *Registry) All() []Meta {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]Meta, 0, len(r.byID))
+	for _, b := range r.byID {
 		result = append(result, b.Meta)
 	}
 	return result
 }
 
+// LookupByID finds a builtin of the default registry by ID.
+func LookupByID(id ID) *Builtin {
+	return defaultRegistry.LookupByID(id)
+}
+
+// LookupByName finds a regular builtin function of the default registry by
+// name.
+func LookupByName(name string) *Builtin {
+	return defaultRegistry.LookupByName(name)
+}
+
+// LookupMethod finds a built-in method of the default registry.
+func LookupMethod(receiverType TypeKind, methodName string) *Builtin {
+	return defaultRegistry.LookupMethod(receiverType, methodName)
+}
+
+// All returns the metadata of all builtins of the default registry.
+func All() []Meta {
+	return defaultRegistry.All()
+}
+
+// String returns the type's name as written in Avenir, e.g. "list".
+func (k TypeKind) String() string {
+	switch k {
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeString:
+		return "string"
+	case TypeBool:
+		return "bool"
+	case TypeVoid:
+		return "void"
+	case TypeAny:
+		return "any"
+	case TypeList:
+		return "list"
+	case TypeDict:
+		return "dict"
+	case TypeError:
+		return "error"
+	case TypeBytes:
+		return "bytes"
+	case TypeUnion:
+		return "union"
+	case TypeSet:
+		return "set"
+	case TypeBigInt:
+		return "bigint"
+	case TypeDecimal:
+		return "decimal"
+	default:
+		return fmt.Sprintf("TypeKind(%d)", int(k))
+	}
+}
+
 // TypeKindFromString converts a type name string to a TypeKind.
 // Returns the TypeKind and true if found, TypeVoid and false otherwise.
 func TypeKindFromString(name string) (TypeKind, bool) {
@@ -593,6 +1058,10 @@ func TypeKindFromString(name string) (TypeKind, bool) {
 		return TypeError, true
 	case "bytes":
 		return TypeBytes, true
+	case "bigint":
+		return TypeBigInt, true
+	case "decimal":
+		return TypeDecimal, true
 	default:
 		return TypeVoid, false
 	}
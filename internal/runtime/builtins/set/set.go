@@ -0,0 +1,287 @@
+package set
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerLength()
+	registerAdd()
+	registerRemove()
+	registerHas()
+	registerAlgebra(builtins.SetUnion, "union", union)
+	registerAlgebra(builtins.SetIntersection, "intersection", intersection)
+	registerAlgebra(builtins.SetDifference, "difference", difference)
+	registerSubset(builtins.SetIsSubsetOf, "isSubsetOf", false)
+	registerSubset(builtins.SetIsSupersetOf, "isSupersetOf", true)
+	registerToList()
+	registerListToSet()
+}
+
+// setRef is the receiver type of set methods; the checker specializes the
+// element types.
+var setRef = builtins.TypeRef{Kind: builtins.TypeSet, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}}
+
+func registerLength() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.SetLength,
+			Name:         "length",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{setRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeSet,
+			MethodName:   "length",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, err := requireSet(args, 0, "set.length")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(int64(s.Len())), nil
+		},
+	})
+}
+
+func registerAdd() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.SetAdd,
+			Name:         "add",
+			Arity:        2,
+			ParamNames:   []string{"self", "value"},
+			Params:       []builtins.TypeRef{setRef, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeSet,
+			MethodName:   "add",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, err := requireSet(args, 0, "set.add")
+			if err != nil {
+				return value.Value{}, err
+			}
+			v := args[1].(value.Value)
+			if s.Has(v) {
+				return value.Bool(false), nil
+			}
+			if err := s.Add(v); err != nil {
+				return value.Value{}, fmt.Errorf("set.add: %w", err)
+			}
+			return value.Bool(true), nil
+		},
+	})
+}
+
+func registerRemove() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.SetRemove,
+			Name:         "remove",
+			Arity:        2,
+			ParamNames:   []string{"self", "value"},
+			Params:       []builtins.TypeRef{setRef, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeSet,
+			MethodName:   "remove",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, err := requireSet(args, 0, "set.remove")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(s.Remove(args[1].(value.Value))), nil
+		},
+	})
+}
+
+func registerHas() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.SetHas,
+			Name:         "has",
+			Arity:        2,
+			ParamNames:   []string{"self", "value"},
+			Params:       []builtins.TypeRef{setRef, {Kind: builtins.TypeAny}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeSet,
+			MethodName:   "has",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, err := requireSet(args, 0, "set.has")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(s.Has(args[1].(value.Value))), nil
+		},
+	})
+}
+
+// registerAlgebra registers a method that combines two sets into a new one.
+func registerAlgebra(id builtins.ID, name string, op func(a, b *value.SetValue) *value.SetValue) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         name,
+			Arity:        2,
+			ParamNames:   []string{"self", "other"},
+			Params:       []builtins.TypeRef{setRef, setRef},
+			Result:       setRef,
+			ReceiverType: builtins.TypeSet,
+			MethodName:   name,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			a, err := requireSet(args, 0, "set."+name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			b, err := requireSet(args, 1, "set."+name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.SetVal(op(a, b)), nil
+		},
+	})
+}
+
+// union keeps the order of a, followed by the new elements of b.
+func union(a, b *value.SetValue) *value.SetValue {
+	out := value.NewSet(a.Len() + b.Len())
+	for _, s := range []*value.SetValue{a, b} {
+		for v := range s.All() {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+func intersection(a, b *value.SetValue) *value.SetValue {
+	out := value.NewSet(min(a.Len(), b.Len()))
+	for v := range a.All() {
+		if b.Has(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+func difference(a, b *value.SetValue) *value.SetValue {
+	out := value.NewSet(a.Len())
+	for v := range a.All() {
+		if !b.Has(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+// registerSubset registers isSubsetOf, or isSupersetOf when reverse is set.
+func registerSubset(id builtins.ID, name string, reverse bool) {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           id,
+			Name:         name,
+			Arity:        2,
+			ParamNames:   []string{"self", "other"},
+			Params:       []builtins.TypeRef{setRef, setRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeSet,
+			MethodName:   name,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			a, err := requireSet(args, 0, "set."+name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			b, err := requireSet(args, 1, "set."+name)
+			if err != nil {
+				return value.Value{}, err
+			}
+			if reverse {
+				a, b = b, a
+			}
+			return value.Bool(isSubset(a, b)), nil
+		},
+	})
+}
+
+func isSubset(a, b *value.SetValue) bool {
+	if a.Len() > b.Len() {
+		return false
+	}
+	for v := range a.All() {
+		if !b.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func registerToList() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.SetToList,
+			Name:         "toList",
+			Arity:        1,
+			ParamNames:   []string{"self"},
+			Params:       []builtins.TypeRef{setRef},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}}, // checker specializes to list<T>
+			ReceiverType: builtins.TypeSet,
+			MethodName:   "toList",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, err := requireSet(args, 0, "set.toList")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.List(s.Elems()), nil
+		},
+	})
+}
+
+func registerListToSet() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.ListToSet,
+			Name:       "toSet",
+			Arity:      1,
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+			},
+			Result:       setRef, // checker specializes to set<T>
+			ReceiverType: builtins.TypeList,
+			MethodName:   "toSet",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			receiver := args[0].(value.Value)
+			if receiver.Kind != value.KindList {
+				return value.Value{}, fmt.Errorf("list.toSet called on non-list type %v", receiver.Kind)
+			}
+			s := value.NewSet(len(receiver.List()))
+			for _, v := range receiver.List() {
+				if err := s.Add(v); err != nil {
+					return value.Value{}, fmt.Errorf("list.toSet: %w", err)
+				}
+			}
+			return value.SetVal(s), nil
+		},
+	})
+}
+
+func requireSet(args []interface{}, idx int, name string) (*value.SetValue, error) {
+	if len(args) <= idx {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, idx+1, len(args))
+	}
+	v := args[idx].(value.Value)
+	if v.Kind != value.KindSet {
+		if idx > 0 {
+			return nil, fmt.Errorf("%s expects a set, got %v", name, v.Kind)
+		}
+		return nil, fmt.Errorf("%s called on non-set type %v", name, v.Kind)
+	}
+	return v.Set(), nil
+}
@@ -3,6 +3,7 @@ package sql
 import (
 	"fmt"
 
+	"avenir/internal/decimal"
 	"avenir/internal/runtime/builtins"
 	"avenir/internal/value"
 )
@@ -26,7 +27,7 @@ func init() {
 
 func requireHandle(v value.Value) ([]byte, error) {
 	if v.Kind == value.KindBytes {
-		return v.Bytes, nil
+		return v.Bytes(), nil
 	}
 	return nil, fmt.Errorf("expected handle (bytes), got %v", v.Kind)
 }
@@ -35,33 +36,38 @@ func extractParams(v value.Value) ([]interface{}, error) {
 	if v.Kind != value.KindList {
 		return nil, fmt.Errorf("expected list for params, got %v", v.Kind)
 	}
-	params := make([]interface{}, len(v.List))
-	for i, item := range v.List {
+	params := make([]interface{}, len(v.List()))
+	for i, item := range v.List() {
 		switch item.Kind {
 		case value.KindInt:
-			params[i] = item.Int
+			params[i] = item.Int()
 		case value.KindFloat:
-			params[i] = item.Float
+			params[i] = item.Float()
 		case value.KindString:
-			params[i] = item.Str
+			params[i] = item.Str()
 		case value.KindBool:
-			params[i] = item.Bool
+			params[i] = item.Bool()
 		case value.KindBytes:
-			params[i] = item.Bytes
+			params[i] = item.Bytes()
+		case value.KindBigInt, value.KindDecimal:
+			// Drivers take exact numbers as text.
+			params[i] = item.String()
 		case value.KindOptional:
-			if item.Optional == nil || !item.Optional.IsSome {
+			if item.Optional() == nil || !item.Optional().IsSome {
 				params[i] = nil
 			} else {
-				inner := item.Optional.Value
+				inner := item.Optional().Value
 				switch inner.Kind {
 				case value.KindInt:
-					params[i] = inner.Int
+					params[i] = inner.Int()
 				case value.KindFloat:
-					params[i] = inner.Float
+					params[i] = inner.Float()
 				case value.KindString:
-					params[i] = inner.Str
+					params[i] = inner.Str()
 				case value.KindBool:
-					params[i] = inner.Bool
+					params[i] = inner.Bool()
+				case value.KindBigInt, value.KindDecimal:
+					params[i] = inner.String()
 				default:
 					params[i] = nil
 				}
@@ -121,6 +127,8 @@ func goToValue(v interface{}) value.Value {
 		return value.Str(val)
 	case []byte:
 		return value.Bytes(val)
+	case decimal.Decimal:
+		return value.Decimal(val)
 	default:
 		return value.Str(fmt.Sprintf("%v", val))
 	}
@@ -160,7 +168,7 @@ func registerAsyncPgConnect() {
 				dbVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_sql_pg_connect expects all string arguments")
 			}
-			host, port, user, pass, database := hostVal.Str, portVal.Str, userVal.Str, passVal.Str, dbVal.Str
+			host, port, user, pass, database := hostVal.Str(), portVal.Str(), userVal.Str(), passVal.Str(), dbVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := sqlSvc.PgConnect(host, port, user, pass, database)
@@ -243,7 +251,7 @@ func registerAsyncPgQuery() {
 			if err != nil {
 				return nil, err
 			}
-			query := queryVal.Str
+			query := queryVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				result, err := sqlSvc.PgQuery(handle, query, params)
@@ -291,7 +299,7 @@ func registerAsyncPgExec() {
 			if err != nil {
 				return nil, err
 			}
-			query := queryVal.Str
+			query := queryVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				result, err := sqlSvc.PgExec(handle, query, params)
@@ -31,7 +31,7 @@ func registerAsyncSqliteConnect() {
 			if pathVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_sql_sqlite_connect expects string argument")
 			}
-			path := pathVal.Str
+			path := pathVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := sqlSvc.SqliteConnect(path)
@@ -114,7 +114,7 @@ func registerAsyncSqliteQuery() {
 			if err != nil {
 				return nil, err
 			}
-			query := queryVal.Str
+			query := queryVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				result, err := sqlSvc.SqliteQuery(handle, query, params)
@@ -162,7 +162,7 @@ func registerAsyncSqliteExec() {
 			if err != nil {
 				return nil, err
 			}
-			query := queryVal.Str
+			query := queryVal.Str()
 			sqlSvc := env.SQL()
 			return builtins.RunAsync(func() (interface{}, error) {
 				result, err := sqlSvc.SqliteExec(handle, query, params)
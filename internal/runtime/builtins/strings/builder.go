@@ -0,0 +1,148 @@
+package strings
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// builder backs a std.strings StringBuilder. Tasks may share a builder, so
+// it is guarded by a mutex.
+type builder struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+var builderHandle = builtins.TypeRef{Kind: builtins.TypeAny}
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.StringsBuilderNew,
+			Name:         "__builtin_strings_builder_new",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builderHandle,
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 0 {
+				return value.Value{}, fmt.Errorf("strings.newBuilder expects no arguments, got %d", len(args))
+			}
+			return value.HandleVal(&builder{}), nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.StringsBuilderWrite,
+			Name:         "__builtin_strings_builder_write",
+			Arity:        2,
+			ParamNames:   []string{"handle", "text"},
+			Params:       []builtins.TypeRef{builderHandle, {Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBuilder(args, 2, "write")
+			if err != nil {
+				return value.Value{}, err
+			}
+			text := args[1].(value.Value)
+			if text.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("StringBuilder.write expects a string, got %v", text.Kind)
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.buf.Len()+len(text.Str()) > maxStringLen {
+				return value.Value{}, fmt.Errorf("StringBuilder.write: result too large")
+			}
+			b.buf.WriteString(text.Str())
+			return value.Value{}, nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.StringsBuilderString,
+			Name:         "__builtin_strings_builder_string",
+			Arity:        1,
+			ParamNames:   []string{"handle"},
+			Params:       []builtins.TypeRef{builderHandle},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBuilder(args, 1, "toString")
+			if err != nil {
+				return value.Value{}, err
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return value.Str(b.buf.String()), nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.StringsBuilderLen,
+			Name:         "__builtin_strings_builder_len",
+			Arity:        1,
+			ParamNames:   []string{"handle"},
+			Params:       []builtins.TypeRef{builderHandle},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBuilder(args, 1, "length")
+			if err != nil {
+				return value.Value{}, err
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return value.Int(int64(b.buf.Len())), nil
+		},
+	})
+
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.StringsBuilderReset,
+			Name:         "__builtin_strings_builder_reset",
+			Arity:        1,
+			ParamNames:   []string{"handle"},
+			Params:       []builtins.TypeRef{builderHandle},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			b, err := requireBuilder(args, 1, "reset")
+			if err != nil {
+				return value.Value{}, err
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.buf.Reset()
+			return value.Value{}, nil
+		},
+	})
+}
+
+func requireBuilder(args []interface{}, n int, method string) (*builder, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("StringBuilder.%s expects %d arguments, got %d", method, n, len(args))
+	}
+	b, ok := args[0].(value.Value).Handle().(*builder)
+	if !ok {
+		return nil, fmt.Errorf("StringBuilder.%s: invalid builder handle", method)
+	}
+	return b, nil
+}
@@ -0,0 +1,42 @@
+package strings
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringChars,
+			Name:       "chars",
+			Arity:      1, // receiver only
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeString}}},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "chars",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("string.chars expects 1 argument (receiver), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.chars called on non-string type %v", receiver.Kind)
+			}
+
+			// Each code point becomes a string; invalid UTF-8 bytes become U+FFFD.
+			chars := []value.Value{}
+			for _, r := range receiver.Str() {
+				chars = append(chars, value.Str(string(r)))
+			}
+			return value.List(chars), nil
+		},
+	})
+}
@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.contains: substr argument must be string, got %v", substr.Kind)
 			}
 
-			result := strings.Contains(receiver.Str, substr.Str)
+			result := strings.Contains(receiver.Str(), substr.Str())
 			return value.Bool(result), nil
 		},
 	})
@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.endsWith: suffix argument must be string, got %v", suffix.Kind)
 			}
 
-			result := strings.HasSuffix(receiver.Str, suffix.Str)
+			result := strings.HasSuffix(receiver.Str(), suffix.Str())
 			return value.Bool(result), nil
 		},
 	})
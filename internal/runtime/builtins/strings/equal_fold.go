@@ -0,0 +1,43 @@
+package strings
+
+import (
+	"fmt"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringEqualFold,
+			Name:       "equalFold",
+			Arity:      2, // receiver + other
+			ParamNames: []string{"self", "other"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+				{Kind: builtins.TypeString}, // other: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "equalFold",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("string.equalFold expects 2 arguments (receiver + other), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+			other := args[1].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.equalFold called on non-string type %v", receiver.Kind)
+			}
+			if other.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.equalFold: other argument must be string, got %v", other.Kind)
+			}
+
+			return value.Bool(strings.EqualFold(receiver.Str(), other.Str())), nil
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package strings
+
+import (
+	"fmt"
+
+	"golang.org/x/text/cases"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringFoldCase,
+			Name:       "foldCase",
+			Arity:      1, // receiver only
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "foldCase",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("string.foldCase expects 1 argument (receiver), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.foldCase called on non-string type %v", receiver.Kind)
+			}
+
+			return value.Str(cases.Fold().String(receiver.Str())), nil
+		},
+	})
+}
@@ -0,0 +1,390 @@
+package strings
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"avenir/internal/decimal"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// maxFormatWidth bounds the width and precision of a format spec.
+const maxFormatWidth = 10000
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringsFormat,
+			Name:       "__builtin_strings_format",
+			Arity:      2,
+			ParamNames: []string{"format", "args"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeAny}}},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("strings.format expects 2 arguments, got %d", len(args))
+			}
+			format := args[0].(value.Value)
+			list := args[1].(value.Value)
+			if format.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("strings.format expects a string format, got %v", format.Kind)
+			}
+			if list.Kind != value.KindList {
+				return value.Value{}, fmt.Errorf("strings.format expects a list of arguments, got %v", list.Kind)
+			}
+			out, err := formatString(format.Str(), list.List())
+			if err != nil {
+				return value.Value{}, fmt.Errorf("strings.format: %w", err)
+			}
+			return value.Str(out), nil
+		},
+	})
+}
+
+// formatString replaces each placeholder of format with an argument. A
+// placeholder is {} for the next argument or {i} for argument i, optionally
+// followed by :spec; {{ and }} stand for literal braces.
+func formatString(format string, args []value.Value) (string, error) {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '}' {
+			if i+1 < len(format) && format[i+1] == '}' {
+				b.WriteByte('}')
+				i++
+				continue
+			}
+			return "", fmt.Errorf("single } at offset %d; write }} for a brace", i)
+		}
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '{' {
+			b.WriteByte('{')
+			i++
+			continue
+		}
+		end := strings.IndexByte(format[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed { at offset %d", i)
+		}
+		field := format[i+1 : i+end]
+		i += end
+
+		ref, specText, _ := strings.Cut(field, ":")
+		index := next
+		if ref == "" {
+			next++
+		} else {
+			n, err := strconv.Atoi(ref)
+			if err != nil || n < 0 {
+				return "", fmt.Errorf("invalid placeholder {%s}", field)
+			}
+			index = n
+		}
+		if index >= len(args) {
+			return "", fmt.Errorf("missing argument %d for {%s}", index, field)
+		}
+		sp, err := parseSpec(specText)
+		if err != nil {
+			return "", err
+		}
+		s, err := sp.apply(args[index])
+		if err != nil {
+			return "", fmt.Errorf("{%s}: %w", field, err)
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+// spec is a parsed format spec: [[fill]align][sign][0][width][,][.precision][type].
+type spec struct {
+	fill      rune
+	align     byte // '<', '>', '^', or 0 for the default of the value
+	sign      byte // '+', ' ', or 0 to show only minus signs
+	zero      bool
+	width     int
+	comma     bool
+	precision int // -1 when not given
+	verb      byte
+}
+
+func parseSpec(text string) (spec, error) {
+	sp := spec{fill: ' ', precision: -1}
+	s := text
+	if r, size := utf8.DecodeRuneInString(s); size > 0 && size < len(s) && isAlign(s[size]) {
+		sp.fill, sp.align = r, s[size]
+		s = s[size+1:]
+	} else if s != "" && isAlign(s[0]) {
+		sp.align = s[0]
+		s = s[1:]
+	}
+	if s != "" && (s[0] == '+' || s[0] == ' ') {
+		sp.sign = s[0]
+		s = s[1:]
+	}
+	if s != "" && s[0] == '0' {
+		sp.zero = true
+		s = s[1:]
+	}
+	var ok bool
+	if sp.width, s, ok = parseNumber(s); !ok {
+		return spec{}, fmt.Errorf("width too large in spec %q", text)
+	}
+	if s != "" && s[0] == ',' {
+		sp.comma = true
+		s = s[1:]
+	}
+	if s != "" && s[0] == '.' {
+		if sp.precision, s, ok = parseNumber(s[1:]); !ok {
+			return spec{}, fmt.Errorf("precision too large in spec %q", text)
+		}
+		if sp.precision < 0 {
+			return spec{}, fmt.Errorf("missing precision in spec %q", text)
+		}
+	}
+	if len(s) == 1 && strings.IndexByte("sdxXobfeEgG%", s[0]) >= 0 {
+		sp.verb = s[0]
+		s = ""
+	}
+	if s != "" {
+		return spec{}, fmt.Errorf("invalid spec %q", text)
+	}
+	return sp, nil
+}
+
+func isAlign(c byte) bool {
+	return c == '<' || c == '>' || c == '^'
+}
+
+// parseNumber reads the leading digits of s, returning -1 as the number
+// when there are none, and false when it exceeds maxFormatWidth.
+func parseNumber(s string) (int, string, bool) {
+	n, i := -1, 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		n = max(n, 0)*10 + int(s[i]-'0')
+		if n > maxFormatWidth {
+			return 0, s, false
+		}
+	}
+	return n, s[i:], true
+}
+
+// apply formats v according to the spec.
+func (sp spec) apply(v value.Value) (string, error) {
+	var body string
+	numeric := false
+	switch sp.verb {
+	case 0:
+		switch v.Kind {
+		case value.KindInt, value.KindBigInt:
+			body, numeric = sp.integer(v, 10), true
+		case value.KindDecimal:
+			if sp.precision < 0 {
+				body, numeric = v.String(), true
+				break
+			}
+			s, err := sp.float(v, 'f')
+			if err != nil {
+				return "", err
+			}
+			body, numeric = s, true
+		case value.KindFloat:
+			verb := byte('g')
+			if sp.precision >= 0 {
+				verb = 'f'
+			}
+			s, err := sp.float(v, verb)
+			if err != nil {
+				return "", err
+			}
+			body, numeric = s, true
+		default:
+			body = sp.truncate(v.String())
+		}
+	case 's':
+		body = sp.truncate(v.String())
+	case 'd', 'x', 'X', 'o', 'b':
+		if v.Kind != value.KindInt && v.Kind != value.KindBigInt {
+			return "", fmt.Errorf("'%c' needs an int, got %s", sp.verb, kindName(v))
+		}
+		base := map[byte]int{'d': 10, 'x': 16, 'X': 16, 'o': 8, 'b': 2}[sp.verb]
+		body, numeric = sp.integer(v, base), true
+		if sp.verb == 'X' {
+			body = strings.ToUpper(body)
+		}
+	default:
+		s, err := sp.float(v, sp.verb)
+		if err != nil {
+			return "", err
+		}
+		body, numeric = s, true
+	}
+
+	if !numeric {
+		if sp.zero || sp.sign != 0 || sp.comma {
+			return "", fmt.Errorf("sign, 0 and , need a number, got %s", kindName(v))
+		}
+		return sp.pad(body, '<'), nil
+	}
+
+	sign := ""
+	if strings.HasPrefix(body, "-") {
+		sign, body = "-", body[1:]
+	} else if sp.sign != 0 {
+		sign = string(sp.sign)
+	}
+	if sp.comma {
+		body = groupThousands(body)
+	}
+	if sp.zero && sp.align == 0 {
+		if missing := sp.width - len(sign) - utf8.RuneCountInString(body); missing > 0 {
+			body = strings.Repeat("0", missing) + body
+		}
+	}
+	return sp.pad(sign+body, '>'), nil
+}
+
+// integer formats an int or bigint in base.
+func (sp spec) integer(v value.Value, base int) string {
+	if v.Kind == value.KindBigInt {
+		return v.BigInt().Text(base)
+	}
+	return strconv.FormatInt(v.Int(), base)
+}
+
+// float formats a number with a float verb. Under 'f' and '%', decimals
+// and bigints stay exact: they keep their scale unless a precision is
+// given, and round half to even.
+func (sp spec) float(v value.Value, verb byte) (string, error) {
+	if v.Kind == value.KindDecimal || v.Kind == value.KindBigInt {
+		d, _ := value.ToDecimal(v)
+		if verb == 'f' || verb == '%' {
+			prec := sp.precision
+			if verb == '%' {
+				d = d.Mul(decimal.FromInt(100))
+			}
+			if prec < 0 {
+				prec = d.Scale()
+			}
+			r, err := d.Rescale(prec, decimal.HalfEven)
+			if err != nil {
+				return "", err
+			}
+			if verb == '%' {
+				return r.String() + "%", nil
+			}
+			return r.String(), nil
+		}
+		return strconv.FormatFloat(d.Float64(), verb, sp.floatPrecision(verb), 64), nil
+	}
+
+	var f float64
+	switch v.Kind {
+	case value.KindFloat:
+		f = v.Float()
+	case value.KindInt:
+		f = float64(v.Int())
+	default:
+		return "", fmt.Errorf("'%c' needs a number, got %s", verb, kindName(v))
+	}
+	prec := sp.floatPrecision(verb)
+	if verb == '%' {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return strconv.FormatFloat(f, 'f', prec, 64), nil
+		}
+		return strconv.FormatFloat(f*100, 'f', prec, 64) + "%", nil
+	}
+	return strconv.FormatFloat(f, verb, prec, 64), nil
+}
+
+// floatPrecision is the precision for a float verb: 6 digits unless given,
+// or the fewest digits that round-trip for 'g' and 'G'.
+func (sp spec) floatPrecision(verb byte) int {
+	if sp.precision < 0 && verb != 'g' && verb != 'G' {
+		return 6
+	}
+	return sp.precision
+}
+
+// truncate cuts s to the precision, counted in code points.
+func (sp spec) truncate(s string) string {
+	if sp.precision < 0 || utf8.RuneCountInString(s) <= sp.precision {
+		return s
+	}
+	return string([]rune(s)[:sp.precision])
+}
+
+// pad fills s to the width, aligning it as the spec says or by def.
+func (sp spec) pad(s string, def byte) string {
+	missing := sp.width - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s
+	}
+	align := sp.align
+	if align == 0 {
+		align = def
+	}
+	fill := string(sp.fill)
+	switch align {
+	case '<':
+		return s + strings.Repeat(fill, missing)
+	case '^':
+		return strings.Repeat(fill, missing/2) + s + strings.Repeat(fill, missing-missing/2)
+	default:
+		return strings.Repeat(fill, missing) + s
+	}
+}
+
+// groupThousands puts commas between groups of three digits in the integer
+// part of a formatted number.
+func groupThousands(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(s)
+	}
+	digits := s[:end]
+	if len(digits) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String() + s[end:]
+}
+
+// kindName names the type of v for error messages.
+func kindName(v value.Value) string {
+	switch v.Kind {
+	case value.KindString:
+		return "string"
+	case value.KindBool:
+		return "bool"
+	case value.KindFloat:
+		return "float"
+	case value.KindDecimal:
+		return "decimal"
+	case value.KindList:
+		return "list"
+	case value.KindDict:
+		return "dict"
+	}
+	return "value"
+}
@@ -0,0 +1,73 @@
+package strings
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"avenir/internal/decimal"
+	"avenir/internal/value"
+)
+
+func TestFormatString(t *testing.T) {
+	price, err := decimal.Parse("19.995")
+	if err != nil {
+		t.Fatalf("decimal.Parse: %v", err)
+	}
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		format string
+		args   []value.Value
+		want   string
+	}{
+		{"{:>8.2f}", []value.Value{value.Float(3.14159)}, "    3.14"},
+		{"{} and {}", []value.Value{value.Int(1), value.Str("two")}, "1 and two"},
+		{"{1}{0}{1}", []value.Value{value.Str("a"), value.Str("b")}, "bab"},
+		{"{{}} {{{}}}", []value.Value{value.Int(5)}, "{} {5}"},
+		{"|{:<5}|{:^6}|{:*>4}|", []value.Value{value.Str("ab"), value.Str("mid"), value.Int(7)}, "|ab   | mid  |***7|"},
+		{"{:05d} {:+d} {: d}", []value.Value{value.Int(-42), value.Int(3), value.Int(3)}, "-0042 +3  3"},
+		{"{:x} {:X} {:o} {:b}", []value.Value{value.Int(255), value.Int(255), value.Int(8), value.Int(5)}, "ff FF 10 101"},
+		{"{:,} {:,.2f}", []value.Value{value.Int(-1234567), value.Float(1234.5)}, "-1,234,567 1,234.50"},
+		{"{:.1%} {:e}", []value.Value{value.Float(0.256), value.Float(12345.678)}, "25.6% 1.234568e+04"},
+		{"{:.3}|{:>6.2}|", []value.Value{value.Str("héllo"), value.Str("héllo")}, "hél|    hé|"},
+		{"{:.2f} {} {:f}", []value.Value{value.Decimal(price), value.Decimal(price), value.Decimal(price)}, "20.00 19.995 19.995"},
+		{"{:,d}", []value.Value{value.BigInt(huge)}, "123,456,789,012,345,678,901,234,567,890"},
+		{"{:f} {}", []value.Value{value.Int(2), value.Float(0.5)}, "2.000000 0.5"},
+		{"{:é>4}", []value.Value{value.Int(1)}, "ééé1"},
+	}
+	for _, tt := range tests {
+		got, err := formatString(tt.format, tt.args)
+		if err != nil {
+			t.Errorf("formatString(%q) error: %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("formatString(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormatStringErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		args   []value.Value
+		want   string
+	}{
+		{"{} {}", []value.Value{value.Int(1)}, "missing argument 1 for {}"},
+		{"{:d}", []value.Value{value.Str("x")}, "{:d}: 'd' needs an int, got string"},
+		{"{:f}", []value.Value{value.Bool(true)}, "'f' needs a number, got bool"},
+		{"{:05}", []value.Value{value.Str("x")}, "sign, 0 and , need a number"},
+		{"{:q}", []value.Value{value.Int(1)}, `invalid spec "q"`},
+		{"{:.f}", []value.Value{value.Int(1)}, "missing precision"},
+		{"{:99999}", []value.Value{value.Int(1)}, "width too large"},
+		{"{x}", []value.Value{value.Int(1)}, "invalid placeholder {x}"},
+		{"{", nil, "unclosed {"},
+		{"}", nil, "single }"},
+	}
+	for _, tt := range tests {
+		_, err := formatString(tt.format, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("formatString(%q) error = %v, want %q", tt.format, err, tt.want)
+		}
+	}
+}
@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.indexOf: substr argument must be string, got %v", substr.Kind)
 			}
 
-			index := strings.Index(receiver.Str, substr.Str)
+			index := strings.Index(receiver.Str(), substr.Str())
 			return value.Int(int64(index)), nil
 		},
 	})
@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.lastIndexOf: substr argument must be string, got %v", substr.Kind)
 			}
 
-			index := strings.LastIndex(receiver.Str, substr.Str)
+			index := strings.LastIndex(receiver.Str(), substr.Str())
 			return value.Int(int64(index)), nil
 		},
 	})
@@ -31,7 +31,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.length called on non-string type %v", receiver.Kind)
 			}
 
-			return value.Int(int64(len(receiver.Str))), nil
+			return value.Int(int64(len(receiver.Str()))), nil
 		},
 	})
 }
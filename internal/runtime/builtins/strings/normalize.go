@@ -0,0 +1,60 @@
+package strings
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// normForms are the Unicode normalization forms accepted by normalize.
+var normForms = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringNormalize,
+			Name:       "normalize",
+			Arity:      2, // receiver + form
+			ParamNames: []string{"self", "form"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+				{Kind: builtins.TypeString}, // form: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "normalize",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("string.normalize expects 2 arguments (receiver + form), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+			formVal := args[1].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.normalize called on non-string type %v", receiver.Kind)
+			}
+			form := norm.NFC
+			if formVal.Kind == value.KindString {
+				f, ok := normForms[formVal.Str()]
+				if !ok {
+					return value.Value{}, fmt.Errorf("string.normalize: unknown form %q", formVal.Str())
+				}
+				form = f
+			} else if formVal.Kind != value.KindOptional {
+				return value.Value{}, fmt.Errorf("string.normalize: form argument must be string, got %v", formVal.Kind)
+			}
+
+			return value.Str(form.String(receiver.Str())), nil
+		},
+	})
+}
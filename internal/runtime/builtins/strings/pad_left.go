@@ -0,0 +1,75 @@
+package strings
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringPadLeft,
+			Name:       "padLeft",
+			Arity:      3, // receiver + width + fill
+			ParamNames: []string{"self", "width", "fill"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+				{Kind: builtins.TypeInt},    // width: int
+				{Kind: builtins.TypeString}, // fill: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "padLeft",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, pad, err := padding(args, "padLeft")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(pad + s), nil
+		},
+	})
+}
+
+// padding checks the arguments of padLeft and padRight and returns the
+// receiver and the fill to add. Widths count code points; a fill of several
+// code points is repeated and cut to size.
+func padding(args []interface{}, name string) (string, string, error) {
+	if len(args) != 3 {
+		return "", "", fmt.Errorf("string.%s expects 3 arguments (receiver + width + fill), got %d", name, len(args))
+	}
+	receiver := args[0].(value.Value)
+	width := args[1].(value.Value)
+	fillVal := args[2].(value.Value)
+	if receiver.Kind != value.KindString {
+		return "", "", fmt.Errorf("string.%s called on non-string type %v", name, receiver.Kind)
+	}
+	if width.Kind != value.KindInt {
+		return "", "", fmt.Errorf("string.%s: width argument must be int, got %v", name, width.Kind)
+	}
+	fill := " "
+	if fillVal.Kind == value.KindString {
+		fill = fillVal.Str()
+	} else if fillVal.Kind != value.KindOptional {
+		return "", "", fmt.Errorf("string.%s: fill argument must be string, got %v", name, fillVal.Kind)
+	}
+	if fill == "" {
+		return "", "", fmt.Errorf("string.%s: fill must not be empty", name)
+	}
+	if width.Int() > maxStringLen {
+		return "", "", fmt.Errorf("string.%s: width %d is too large", name, width.Int())
+	}
+	s := receiver.Str()
+	missing := int(width.Int()) - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s, "", nil
+	}
+	fillRunes := []rune(fill)
+	pad := []rune(strings.Repeat(fill, (missing+len(fillRunes)-1)/len(fillRunes)))
+	return s, string(pad[:missing]), nil
+}
@@ -0,0 +1,33 @@
+package strings
+
+import (
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringPadRight,
+			Name:       "padRight",
+			Arity:      3, // receiver + width + fill
+			ParamNames: []string{"self", "width", "fill"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+				{Kind: builtins.TypeInt},    // width: int
+				{Kind: builtins.TypeString}, // fill: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "padRight",
+			Optional:     1,
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			s, pad, err := padding(args, "padRight")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(s + pad), nil
+		},
+	})
+}
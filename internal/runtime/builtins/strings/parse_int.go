@@ -0,0 +1,49 @@
+package strings
+
+import (
+	"fmt"
+	"strconv"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.ParseInt,
+			Name:       "parseInt",
+			Arity:      2,
+			ParamNames: []string{"value", "base"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("parseInt expects 2 arguments, got %d", len(args))
+			}
+			arg := args[0].(value.Value)
+			baseVal := args[1].(value.Value)
+			if arg.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("parseInt expects string, got %v", arg.Kind)
+			}
+			if baseVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("parseInt: base must be int, got %v", baseVal.Kind)
+			}
+			base := baseVal.Int()
+			if base != 0 && (base < 2 || base > 36) {
+				return value.Value{}, fmt.Errorf("parseInt: base must be 0 or between 2 and 36, got %d", base)
+			}
+			parsed, err := strconv.ParseInt(arg.Str(), int(base), 64)
+			if err != nil {
+				return value.Value{}, fmt.Errorf("parseInt: invalid base %d integer %q", base, arg.Str())
+			}
+			return value.Int(parsed), nil
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package strings
+
+import (
+	"fmt"
+	"strings"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// maxStringLen bounds the strings that repeat and padding build, so that a
+// bad count fails with an error instead of exhausting memory.
+const maxStringLen = 1 << 30
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringRepeat,
+			Name:       "repeat",
+			Arity:      2, // receiver + count
+			ParamNames: []string{"self", "count"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+				{Kind: builtins.TypeInt},    // count: int
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "repeat",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("string.repeat expects 2 arguments (receiver + count), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+			count := args[1].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.repeat called on non-string type %v", receiver.Kind)
+			}
+			if count.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("string.repeat: count argument must be int, got %v", count.Kind)
+			}
+			n := count.Int()
+			if n < 0 {
+				return value.Value{}, fmt.Errorf("string.repeat: negative count %d", n)
+			}
+			s := receiver.Str()
+			if len(s) > 0 && n > maxStringLen/int64(len(s)) {
+				return value.Value{}, fmt.Errorf("string.repeat: result too large")
+			}
+			return value.Str(strings.Repeat(s, int(n))), nil
+		},
+	})
+}
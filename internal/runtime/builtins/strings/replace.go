@@ -42,7 +42,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.replace: new argument must be string, got %v", newStr.Kind)
 			}
 
-			result := strings.ReplaceAll(receiver.Str, oldStr.Str, newStr.Str)
+			result := strings.ReplaceAll(receiver.Str(), oldStr.Str(), newStr.Str())
 			return value.Str(result), nil
 		},
 	})
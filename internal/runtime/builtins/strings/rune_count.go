@@ -0,0 +1,38 @@
+package strings
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringRuneCount,
+			Name:       "runeCount",
+			Arity:      1, // receiver only
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "runeCount",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("string.runeCount expects 1 argument (receiver), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.runeCount called on non-string type %v", receiver.Kind)
+			}
+
+			return value.Int(int64(utf8.RuneCountInString(receiver.Str()))), nil
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package strings
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.StringRunes,
+			Name:       "runes",
+			Arity:      1, // receiver only
+			ParamNames: []string{"self"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString}, // receiver: string
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeInt}}},
+			ReceiverType: builtins.TypeString,
+			MethodName:   "runes",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("string.runes expects 1 argument (receiver), got %d", len(args))
+			}
+			receiver := args[0].(value.Value)
+
+			if receiver.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("string.runes called on non-string type %v", receiver.Kind)
+			}
+
+			// Invalid UTF-8 bytes become U+FFFD (65533).
+			runes := []value.Value{}
+			for _, r := range receiver.Str() {
+				runes = append(runes, value.Int(int64(r)))
+			}
+			return value.List(runes), nil
+		},
+	})
+}
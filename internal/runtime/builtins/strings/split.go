@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.split: sep argument must be string, got %v", sep.Kind)
 			}
 
-			parts := strings.Split(receiver.Str, sep.Str)
+			parts := strings.Split(receiver.Str(), sep.Str())
 			resultList := make([]value.Value, len(parts))
 			for i, part := range parts {
 				resultList[i] = value.Str(part)
@@ -37,7 +37,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.startsWith: prefix argument must be string, got %v", prefix.Kind)
 			}
 
-			result := strings.HasPrefix(receiver.Str, prefix.Str)
+			result := strings.HasPrefix(receiver.Str(), prefix.Str())
 			return value.Bool(result), nil
 		},
 	})
@@ -0,0 +1,44 @@
+package strings
+
+import (
+	"fmt"
+	"strconv"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.ToFloat,
+			Name:       "toFloat",
+			Arity:      1,
+			ParamNames: []string{"value"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeUnion, Elem: []builtins.TypeRef{{Kind: builtins.TypeInt}, {Kind: builtins.TypeString}}},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeFloat},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("toFloat expects 1 argument, got %d", len(args))
+			}
+			arg := args[0].(value.Value)
+			if arg.Kind == value.KindInt {
+				// Ints beyond 2^53 round to the nearest float.
+				return value.Float(float64(arg.Int())), nil
+			}
+			if arg.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("toFloat expects int or string, got %v", arg.Kind)
+			}
+			parsed, err := strconv.ParseFloat(arg.Str(), 64)
+			if err != nil {
+				return value.Value{}, fmt.Errorf("toFloat: invalid float %q", arg.Str())
+			}
+			return value.Float(parsed), nil
+		},
+	})
+}
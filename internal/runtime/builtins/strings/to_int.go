@@ -30,9 +30,9 @@ func init() {
 			if arg.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("toInt expects string, got %v", arg.Kind)
 			}
-			parsed, err := strconv.Atoi(arg.Str)
+			parsed, err := strconv.Atoi(arg.Str())
 			if err != nil {
-				return value.Value{}, fmt.Errorf("toInt: invalid integer %q", arg.Str)
+				return value.Value{}, fmt.Errorf("toInt: invalid integer %q", arg.Str())
 			}
 			return value.Int(int64(parsed)), nil
 		},
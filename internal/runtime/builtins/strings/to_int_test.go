@@ -78,8 +78,8 @@ func TestToIntBuiltin(t *testing.T) {
 			if val.Kind != tc.wantKind {
 				t.Fatalf("expected kind %v, got %v", tc.wantKind, val.Kind)
 			}
-			if val.Int != tc.wantInt {
-				t.Fatalf("expected int %d, got %d", tc.wantInt, val.Int)
+			if val.Int() != tc.wantInt {
+				t.Fatalf("expected int %d, got %d", tc.wantInt, val.Int())
 			}
 		})
 	}
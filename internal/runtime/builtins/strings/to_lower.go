@@ -32,7 +32,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.toLowerCase called on non-string type %v", receiver.Kind)
 			}
 
-			result := strings.ToLower(receiver.Str)
+			result := strings.ToLower(receiver.Str())
 			return value.Str(result), nil
 		},
 	})
@@ -32,7 +32,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.toUpperCase called on non-string type %v", receiver.Kind)
 			}
 
-			result := strings.ToUpper(receiver.Str)
+			result := strings.ToUpper(receiver.Str())
 			return value.Str(result), nil
 		},
 	})
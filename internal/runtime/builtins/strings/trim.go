@@ -32,7 +32,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.trim called on non-string type %v", receiver.Kind)
 			}
 
-			result := strings.TrimSpace(receiver.Str)
+			result := strings.TrimSpace(receiver.Str())
 			return value.Str(result), nil
 		},
 	})
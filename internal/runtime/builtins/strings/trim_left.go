@@ -32,7 +32,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.trimLeft called on non-string type %v", receiver.Kind)
 			}
 
-			result := strings.TrimLeft(receiver.Str, " \t\n\r")
+			result := strings.TrimLeft(receiver.Str(), " \t\n\r")
 			return value.Str(result), nil
 		},
 	})
@@ -32,7 +32,7 @@ func init() {
 				return value.Value{}, fmt.Errorf("string.trimRight called on non-string type %v", receiver.Kind)
 			}
 
-			result := strings.TrimRight(receiver.Str, " \t\n\r")
+			result := strings.TrimRight(receiver.Str(), " \t\n\r")
 			return value.Str(result), nil
 		},
 	})
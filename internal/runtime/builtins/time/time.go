@@ -65,10 +65,10 @@ func registerSleep() {
 			if nanosVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_time_sleep expects nanos as int")
 			}
-			if nanosVal.Int < 0 {
-				return value.Value{}, fmt.Errorf("__builtin_time_sleep expects non-negative nanos, got %d", nanosVal.Int)
+			if nanosVal.Int() < 0 {
+				return value.Value{}, fmt.Errorf("__builtin_time_sleep expects non-negative nanos, got %d", nanosVal.Int())
 			}
-			stdtime.Sleep(stdtime.Duration(nanosVal.Int))
+			stdtime.Sleep(stdtime.Duration(nanosVal.Int()))
 			return value.Value{}, nil
 		},
 	})
@@ -98,7 +98,7 @@ func registerParseDateTime() {
 			if textVal.Kind != value.KindString || layoutVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_time_parse_datetime expects text and layout as strings")
 			}
-			t, err := stdtime.Parse(layoutVal.Str, textVal.Str)
+			t, err := stdtime.Parse(layoutVal.Str(), textVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -131,8 +131,8 @@ func registerFormatDateTime() {
 			if tsVal.Kind != value.KindInt || layoutVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_time_format_datetime expects timestamp int and layout string")
 			}
-			t := stdtime.Unix(0, tsVal.Int).UTC()
-			return value.Str(t.Format(layoutVal.Str)), nil
+			t := stdtime.Unix(0, tsVal.Int()).UTC()
+			return value.Str(t.Format(layoutVal.Str())), nil
 		},
 	})
 }
@@ -159,7 +159,7 @@ func registerParseDuration() {
 			if textVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_time_parse_duration expects text as string")
 			}
-			d, err := stdtime.ParseDuration(textVal.Str)
+			d, err := stdtime.ParseDuration(textVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -320,5 +320,5 @@ func requireTimestamp(args []interface{}, name string) (int64, error) {
 	if val.Kind != value.KindInt {
 		return 0, fmt.Errorf("%s expects timestamp as int", name)
 	}
-	return val.Int, nil
+	return val.Int(), nil
 }
@@ -51,15 +51,11 @@ func registerAsyncSleep() {
 			if nanosVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_async_time_sleep expects nanos as int")
 			}
-			if nanosVal.Int < 0 {
-				return nil, fmt.Errorf("__builtin_async_time_sleep expects non-negative nanos, got %d", nanosVal.Int)
+			if nanosVal.Int() < 0 {
+				return nil, fmt.Errorf("__builtin_async_time_sleep expects non-negative nanos, got %d", nanosVal.Int())
 			}
 
-			dur := stdtime.Duration(nanosVal.Int)
-			return builtins.RunAsync(func() (interface{}, error) {
-				stdtime.Sleep(dur)
-				return value.Value{}, nil
-			}), nil
+			return env.Timers().Sleep(stdtime.Duration(nanosVal.Int())), nil
 		},
 	})
 }
@@ -39,8 +39,8 @@ func TestTimeNow(t *testing.T) {
 	if val.Kind != value.KindInt {
 		t.Fatalf("expected int, got %v", val.Kind)
 	}
-	if val.Int <= 0 {
-		t.Fatalf("expected positive timestamp, got %d", val.Int)
+	if val.Int() <= 0 {
+		t.Fatalf("expected positive timestamp, got %d", val.Int())
 	}
 }
 
@@ -57,19 +57,19 @@ func TestTimeFormatParse(t *testing.T) {
 	if formatted.Kind != value.KindString {
 		t.Fatalf("expected string, got %v", formatted.Kind)
 	}
-	if formatted.Str != "2024-02-03 04:05:06" {
-		t.Fatalf("unexpected formatted value: %q", formatted.Str)
+	if formatted.Str() != "2024-02-03 04:05:06" {
+		t.Fatalf("unexpected formatted value: %q", formatted.Str())
 	}
 
-	parsed, err := callBuiltin(t, env, "__builtin_time_parse_datetime", value.Str(formatted.Str), value.Str(layout))
+	parsed, err := callBuiltin(t, env, "__builtin_time_parse_datetime", value.Str(formatted.Str()), value.Str(layout))
 	if err != nil {
 		t.Fatalf("parse error: %v", err)
 	}
 	if parsed.Kind != value.KindInt {
 		t.Fatalf("expected int, got %v", parsed.Kind)
 	}
-	if parsed.Int != ts {
-		t.Fatalf("expected %d, got %d", ts, parsed.Int)
+	if parsed.Int() != ts {
+		t.Fatalf("expected %d, got %d", ts, parsed.Int())
 	}
 }
 
@@ -80,7 +80,7 @@ func TestTimeParseDuration(t *testing.T) {
 		t.Fatalf("parse duration error: %v", err)
 	}
 	want := int64(stdtime.Hour + 30*stdtime.Minute)
-	if val.Kind != value.KindInt || val.Int != want {
+	if val.Kind != value.KindInt || val.Int() != want {
 		t.Fatalf("expected %d, got %v", want, val.String())
 	}
 }
@@ -97,11 +97,11 @@ func TestTimeComponents(t *testing.T) {
 	minute, _ := callBuiltin(t, env, "__builtin_time_minute", ts)
 	second, _ := callBuiltin(t, env, "__builtin_time_second", ts)
 
-	if year.Int != 2023 || month.Int != 12 || day.Int != 31 {
-		t.Fatalf("unexpected date parts: %d-%d-%d", year.Int, month.Int, day.Int)
+	if year.Int() != 2023 || month.Int() != 12 || day.Int() != 31 {
+		t.Fatalf("unexpected date parts: %d-%d-%d", year.Int(), month.Int(), day.Int())
 	}
-	if hour.Int != 23 || minute.Int != 59 || second.Int != 1 {
-		t.Fatalf("unexpected time parts: %d:%d:%d", hour.Int, minute.Int, second.Int)
+	if hour.Int() != 23 || minute.Int() != 59 || second.Int() != 1 {
+		t.Fatalf("unexpected time parts: %d:%d:%d", hour.Int(), minute.Int(), second.Int())
 	}
 }
 
@@ -112,3 +112,112 @@ func TestTimeSleepZero(t *testing.T) {
 		t.Fatalf("sleep error: %v", err)
 	}
 }
+
+func TestTimeCronNext(t *testing.T) {
+	env := runtime.DefaultEnv()
+	cases := []struct {
+		expr  string
+		after stdtime.Time
+		want  stdtime.Time
+	}{
+		{"*/5 * * * *", stdtime.Date(2024, 1, 1, 10, 3, 0, 0, stdtime.UTC), stdtime.Date(2024, 1, 1, 10, 5, 0, 0, stdtime.UTC)},
+		{"*/5 * * * *", stdtime.Date(2024, 1, 1, 10, 5, 0, 0, stdtime.UTC), stdtime.Date(2024, 1, 1, 10, 10, 0, 0, stdtime.UTC)},
+		{"0 9 * * mon-fri", stdtime.Date(2024, 1, 6, 10, 0, 0, 0, stdtime.UTC), stdtime.Date(2024, 1, 8, 9, 0, 0, 0, stdtime.UTC)},
+		{"30 2 29 2 *", stdtime.Date(2024, 3, 1, 0, 0, 0, 0, stdtime.UTC), stdtime.Date(2028, 2, 29, 2, 30, 0, 0, stdtime.UTC)},
+		{"0 0 1 * 7", stdtime.Date(2024, 1, 2, 0, 0, 0, 0, stdtime.UTC), stdtime.Date(2024, 1, 7, 0, 0, 0, 0, stdtime.UTC)},
+		{"@daily", stdtime.Date(2024, 12, 31, 23, 59, 30, 0, stdtime.UTC), stdtime.Date(2025, 1, 1, 0, 0, 0, 0, stdtime.UTC)},
+	}
+	for _, tc := range cases {
+		val, err := callBuiltin(t, env, "__builtin_time_cron_next", value.Str(tc.expr), value.Int(tc.after.UnixNano()))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.expr, err)
+		}
+		got := stdtime.Unix(0, val.Int()).UTC()
+		if !got.Equal(tc.want) {
+			t.Fatalf("%s after %s: expected %s, got %s", tc.expr, tc.after, tc.want, got)
+		}
+	}
+}
+
+func TestTimeCronInvalid(t *testing.T) {
+	env := runtime.DefaultEnv()
+	for _, expr := range []string{"* * * *", "61 * * * *", "*/0 * * * *", "5-1 * * * *", "0 0 31 2 *"} {
+		if _, err := callBuiltin(t, env, "__builtin_time_cron_next", value.Str(expr), value.Int(0)); err == nil {
+			t.Fatalf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestTimeTimerStop(t *testing.T) {
+	env := runtime.DefaultEnv()
+	idVal, err := callBuiltin(t, env, "__builtin_time_timer_new")
+	if err != nil {
+		t.Fatalf("timer_new error: %v", err)
+	}
+	stopped, _ := callBuiltin(t, env, "__builtin_time_timer_stopped", idVal)
+	if stopped.Bool() {
+		t.Fatalf("expected new timer to be running")
+	}
+
+	ah := env.Timers().Wait(int(idVal.Int()), stdtime.Hour).(*runtime.AsyncHandle)
+	if _, err := callBuiltin(t, env, "__builtin_time_timer_stop", idVal); err != nil {
+		t.Fatalf("timer_stop error: %v", err)
+	}
+	res, _, ready := ah.Poll()
+	if !ready || res.Kind != value.KindBool || res.Bool() {
+		t.Fatalf("expected pending wait to resolve false after stop, got ready=%v %s", ready, res.String())
+	}
+	stopped, _ = callBuiltin(t, env, "__builtin_time_timer_stopped", idVal)
+	if !stopped.Bool() {
+		t.Fatalf("expected timer to report stopped")
+	}
+}
+
+func TestTimeFieldsInZone(t *testing.T) {
+	env := runtime.DefaultEnv()
+	ts := stdtime.Date(2024, 3, 10, 6, 30, 0, 0, stdtime.UTC).UnixNano()
+	val, err := callBuiltin(t, env, "__builtin_time_fields", value.Int(ts), value.Str("America/New_York"))
+	if err != nil {
+		t.Fatalf("fields error: %v", err)
+	}
+	want := []int64{2024, 3, 10, 1, 30, 0, 0, 7, 70, 2024, 10, -5 * 3600}
+	if len(val.List()) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(val.List()))
+	}
+	for i, w := range want {
+		if val.List()[i].Int() != w {
+			t.Fatalf("field %d: expected %d, got %d", i, w, val.List()[i].Int())
+		}
+	}
+
+	if _, err := callBuiltin(t, env, "__builtin_time_zone_load", value.Str("Mars/Olympus")); err == nil {
+		t.Fatalf("expected unknown zone error")
+	}
+}
+
+func TestTimeAddDateClampsAndKeepsWallClock(t *testing.T) {
+	env := runtime.DefaultEnv()
+	ny, err := stdtime.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+	cases := []struct {
+		start        stdtime.Time
+		months, days int64
+		want         stdtime.Time
+	}{
+		{stdtime.Date(2024, 1, 31, 12, 0, 0, 0, stdtime.UTC), 1, 0, stdtime.Date(2024, 2, 29, 12, 0, 0, 0, stdtime.UTC)},
+		{stdtime.Date(2024, 3, 31, 12, 0, 0, 0, stdtime.UTC), -13, 0, stdtime.Date(2023, 2, 28, 12, 0, 0, 0, stdtime.UTC)},
+		{stdtime.Date(2024, 3, 9, 9, 0, 0, 0, ny), 0, 1, stdtime.Date(2024, 3, 10, 9, 0, 0, 0, ny)},
+	}
+	for _, tc := range cases {
+		zone := tc.start.Location().String()
+		val, err := callBuiltin(t, env, "__builtin_time_add_date", value.Int(tc.start.UnixNano()), value.Str(zone), value.Int(tc.months), value.Int(tc.days))
+		if err != nil {
+			t.Fatalf("add date error: %v", err)
+		}
+		if got := stdtime.Unix(0, val.Int()); !got.Equal(tc.want) {
+			t.Fatalf("%v %+dm %+dd: expected %v, got %v", tc.start, tc.months, tc.days, tc.want, got.In(tc.want.Location()))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package time
+
+import (
+	"fmt"
+	"math/rand/v2"
+	stdtime "time"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+func init() {
+	registerTimerNew()
+	registerTimerStop()
+	registerTimerStopped()
+	registerAsyncTimerWait()
+	registerCronNext()
+	registerJitter()
+}
+
+func registerTimerNew() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeTimerNew,
+			Name:         "__builtin_time_timer_new",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 0 {
+				return value.Value{}, fmt.Errorf("__builtin_time_timer_new expects 0 arguments, got %d", len(args))
+			}
+			return value.Int(int64(env.Timers().NewTimer())), nil
+		},
+	})
+}
+
+func registerTimerStop() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeTimerStop,
+			Name:         "__builtin_time_timer_stop",
+			Arity:        1,
+			ParamNames:   []string{"id"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeVoid},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			id, err := requireTimerID(args, "__builtin_time_timer_stop")
+			if err != nil {
+				return value.Value{}, err
+			}
+			env.Timers().Stop(id)
+			return value.Value{}, nil
+		},
+	})
+}
+
+func registerTimerStopped() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeTimerStopped,
+			Name:         "__builtin_time_timer_stopped",
+			Arity:        1,
+			ParamNames:   []string{"id"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			id, err := requireTimerID(args, "__builtin_time_timer_stopped")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Bool(env.Timers().Stopped(id)), nil
+		},
+	})
+}
+
+func registerAsyncTimerWait() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.AsyncTimeTimerWait,
+			Name:         "__builtin_async_time_timer_wait",
+			Arity:        2,
+			ParamNames:   []string{"id", "nanos"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}, {Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeBool},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		CallAsync: func(env builtins.Env, args []interface{}) (builtins.AsyncHandle, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("__builtin_async_time_timer_wait expects 2 arguments, got %d", len(args))
+			}
+			id, err := requireTimerID(args[:1], "__builtin_async_time_timer_wait")
+			if err != nil {
+				return nil, err
+			}
+			nanosVal := args[1].(value.Value)
+			if nanosVal.Kind != value.KindInt {
+				return nil, fmt.Errorf("__builtin_async_time_timer_wait expects nanos as int")
+			}
+			nanos := nanosVal.Int()
+			if nanos < 0 {
+				nanos = 0
+			}
+			return env.Timers().Wait(id, stdtime.Duration(nanos)), nil
+		},
+	})
+}
+
+func registerCronNext() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeCronNext,
+			Name:         "__builtin_time_cron_next",
+			Arity:        2,
+			ParamNames:   []string{"expr", "after"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}, {Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 2 {
+				return value.Value{}, fmt.Errorf("__builtin_time_cron_next expects 2 arguments, got %d", len(args))
+			}
+			exprVal := args[0].(value.Value)
+			afterVal := args[1].(value.Value)
+			if exprVal.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("__builtin_time_cron_next expects expr as string")
+			}
+			if afterVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("__builtin_time_cron_next expects after as int")
+			}
+			sched, err := parseCron(exprVal.Str())
+			if err != nil {
+				return value.Value{}, err
+			}
+			next, err := sched.next(stdtime.Unix(0, afterVal.Int()))
+			if err != nil {
+				return value.Value{}, fmt.Errorf("%s: %v", exprVal.Str(), err)
+			}
+			return value.Int(next.UnixNano()), nil
+		},
+	})
+}
+
+func registerJitter() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeJitter,
+			Name:         "__builtin_time_jitter",
+			Arity:        1,
+			ParamNames:   []string{"max"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeInt}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("__builtin_time_jitter expects 1 argument, got %d", len(args))
+			}
+			maxVal := args[0].(value.Value)
+			if maxVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("__builtin_time_jitter expects max as int")
+			}
+			if maxVal.Int() <= 0 {
+				return value.Int(0), nil
+			}
+			return value.Int(rand.Int64N(maxVal.Int())), nil
+		},
+	})
+}
+
+func requireTimerID(args []interface{}, name string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s expects 1 argument, got %d", name, len(args))
+	}
+	idVal := args[0].(value.Value)
+	if idVal.Kind != value.KindInt {
+		return 0, fmt.Errorf("%s expects timer id as int", name)
+	}
+	return int(idVal.Int()), nil
+}
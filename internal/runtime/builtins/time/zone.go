@@ -0,0 +1,350 @@
+package time
+
+import (
+	"fmt"
+	"sync"
+	stdtime "time"
+	_ "time/tzdata" // zone rules must not depend on the host system
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// processStart anchors monotonic readings. time.Since uses the monotonic
+// clock, so Instant values are immune to wall clock adjustments.
+var processStart = stdtime.Now()
+
+var zoneCache sync.Map // name -> *stdtime.Location
+
+func init() {
+	registerZoneLoad()
+	registerFields()
+	registerDate()
+	registerAddDate()
+	registerFormatInZone()
+	registerParseInZone()
+	registerMonotonic()
+}
+
+// loadZone resolves an IANA zone name. The empty name means UTC.
+func loadZone(name string) (*stdtime.Location, error) {
+	if name == "" || name == "UTC" {
+		return stdtime.UTC, nil
+	}
+	if loc, ok := zoneCache.Load(name); ok {
+		return loc.(*stdtime.Location), nil
+	}
+	loc, err := stdtime.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	zoneCache.Store(name, loc)
+	return loc, nil
+}
+
+func registerZoneLoad() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeZoneLoad,
+			Name:         "__builtin_time_zone_load",
+			Arity:        1,
+			ParamNames:   []string{"name"},
+			Params:       []builtins.TypeRef{{Kind: builtins.TypeString}},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return value.Value{}, fmt.Errorf("__builtin_time_zone_load expects 1 argument, got %d", len(args))
+			}
+			nameVal := args[0].(value.Value)
+			if nameVal.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("__builtin_time_zone_load expects name as string")
+			}
+			loc, err := loadZone(nameVal.Str())
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Str(loc.String()), nil
+		},
+	})
+}
+
+// Field order of __builtin_time_fields. std/time/datetime.av indexes the
+// result with the same positions.
+const (
+	fieldYear = iota
+	fieldMonth
+	fieldDay
+	fieldHour
+	fieldMinute
+	fieldSecond
+	fieldNanosecond
+	fieldWeekday
+	fieldYearDay
+	fieldISOYear
+	fieldISOWeek
+	fieldOffset
+	fieldCount
+)
+
+func timeFields(t stdtime.Time) []value.Value {
+	fields := make([]value.Value, fieldCount)
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	isoYear, isoWeek := t.ISOWeek()
+	_, offset := t.Zone()
+	fields[fieldYear] = value.Int(int64(t.Year()))
+	fields[fieldMonth] = value.Int(int64(t.Month()))
+	fields[fieldDay] = value.Int(int64(t.Day()))
+	fields[fieldHour] = value.Int(int64(t.Hour()))
+	fields[fieldMinute] = value.Int(int64(t.Minute()))
+	fields[fieldSecond] = value.Int(int64(t.Second()))
+	fields[fieldNanosecond] = value.Int(int64(t.Nanosecond()))
+	fields[fieldWeekday] = value.Int(int64(weekday))
+	fields[fieldYearDay] = value.Int(int64(t.YearDay()))
+	fields[fieldISOYear] = value.Int(int64(isoYear))
+	fields[fieldISOWeek] = value.Int(int64(isoWeek))
+	fields[fieldOffset] = value.Int(int64(offset))
+	return fields
+}
+
+func registerFields() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.TimeFields,
+			Name:       "__builtin_time_fields",
+			Arity:      2,
+			ParamNames: []string{"timestamp", "zone"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeList, Elem: []builtins.TypeRef{{Kind: builtins.TypeInt}}},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			t, err := requireZonedTime(args, 2, "__builtin_time_fields")
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.List(timeFields(t)), nil
+		},
+	})
+}
+
+func registerDate() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.TimeDate,
+			Name:       "__builtin_time_date",
+			Arity:      8,
+			ParamNames: []string{"year", "month", "day", "hour", "minute", "second", "nanos", "zone"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 8 {
+				return value.Value{}, fmt.Errorf("__builtin_time_date expects 8 arguments, got %d", len(args))
+			}
+			parts := make([]int, 7)
+			for i := 0; i < 7; i++ {
+				v := args[i].(value.Value)
+				if v.Kind != value.KindInt {
+					return value.Value{}, fmt.Errorf("__builtin_time_date expects date and time components as int")
+				}
+				parts[i] = int(v.Int())
+			}
+			zoneVal := args[7].(value.Value)
+			if zoneVal.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("__builtin_time_date expects zone as string")
+			}
+			loc, err := loadZone(zoneVal.Str())
+			if err != nil {
+				return value.Value{}, err
+			}
+			t := stdtime.Date(parts[0], stdtime.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], parts[6], loc)
+			return value.Int(t.UnixNano()), nil
+		},
+	})
+}
+
+// addDate moves t by calendar months and days, keeping the wall clock time.
+// When the target month is shorter than the current day, the day is clamped
+// to the last day of that month (Jan 31 + 1 month = Feb 28/29).
+func addDate(t stdtime.Time, months, days int) stdtime.Time {
+	year, month, day := t.Date()
+	if months != 0 {
+		total := int(month) - 1 + months
+		year += total / 12
+		total %= 12
+		if total < 0 {
+			total += 12
+			year--
+		}
+		month = stdtime.Month(total + 1)
+		if last := daysIn(year, month); day > last {
+			day = last
+		}
+	}
+	return stdtime.Date(year, month, day+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month stdtime.Month) int {
+	return stdtime.Date(year, month+1, 0, 0, 0, 0, 0, stdtime.UTC).Day()
+}
+
+func registerAddDate() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.TimeAddDate,
+			Name:       "__builtin_time_add_date",
+			Arity:      4,
+			ParamNames: []string{"timestamp", "zone", "months", "days"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeInt},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			t, err := requireZonedTime(args, 4, "__builtin_time_add_date")
+			if err != nil {
+				return value.Value{}, err
+			}
+			monthsVal := args[2].(value.Value)
+			daysVal := args[3].(value.Value)
+			if monthsVal.Kind != value.KindInt || daysVal.Kind != value.KindInt {
+				return value.Value{}, fmt.Errorf("__builtin_time_add_date expects months and days as int")
+			}
+			return value.Int(addDate(t, int(monthsVal.Int()), int(daysVal.Int())).UnixNano()), nil
+		},
+	})
+}
+
+func registerFormatInZone() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.TimeFormatInZone,
+			Name:       "__builtin_time_format_in_zone",
+			Arity:      3,
+			ParamNames: []string{"timestamp", "zone", "layout"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeInt},
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeString},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			t, err := requireZonedTime(args, 3, "__builtin_time_format_in_zone")
+			if err != nil {
+				return value.Value{}, err
+			}
+			layoutVal := args[2].(value.Value)
+			if layoutVal.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("__builtin_time_format_in_zone expects layout as string")
+			}
+			return value.Str(t.Format(layoutVal.Str())), nil
+		},
+	})
+}
+
+func registerParseInZone() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:         builtins.TimeParseInZone,
+			Name:       "__builtin_time_parse_in_zone",
+			Arity:      3,
+			ParamNames: []string{"text", "layout", "zone"},
+			Params: []builtins.TypeRef{
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+				{Kind: builtins.TypeString},
+			},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 3 {
+				return value.Value{}, fmt.Errorf("__builtin_time_parse_in_zone expects 3 arguments, got %d", len(args))
+			}
+			textVal := args[0].(value.Value)
+			layoutVal := args[1].(value.Value)
+			zoneVal := args[2].(value.Value)
+			if textVal.Kind != value.KindString || layoutVal.Kind != value.KindString || zoneVal.Kind != value.KindString {
+				return value.Value{}, fmt.Errorf("__builtin_time_parse_in_zone expects text, layout and zone as strings")
+			}
+			loc, err := loadZone(zoneVal.Str())
+			if err != nil {
+				return value.Value{}, err
+			}
+			t, err := stdtime.ParseInLocation(layoutVal.Str(), textVal.Str(), loc)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.Int(t.UnixNano()), nil
+		},
+	})
+}
+
+func registerMonotonic() {
+	builtins.Register(builtins.Builtin{
+		Meta: builtins.Meta{
+			ID:           builtins.TimeMonotonic,
+			Name:         "__builtin_time_monotonic",
+			Arity:        0,
+			ParamNames:   []string{},
+			Params:       []builtins.TypeRef{},
+			Result:       builtins.TypeRef{Kind: builtins.TypeInt},
+			ReceiverType: builtins.TypeVoid,
+			MethodName:   "",
+		},
+		Call: func(env builtins.Env, args []interface{}) (interface{}, error) {
+			if len(args) != 0 {
+				return value.Value{}, fmt.Errorf("__builtin_time_monotonic expects 0 arguments, got %d", len(args))
+			}
+			return value.Int(int64(stdtime.Since(processStart))), nil
+		},
+	})
+}
+
+// requireZonedTime reads the leading (timestamp, zone) pair shared by the
+// zone-aware builtins.
+func requireZonedTime(args []interface{}, arity int, name string) (stdtime.Time, error) {
+	if len(args) != arity {
+		return stdtime.Time{}, fmt.Errorf("%s expects %d arguments, got %d", name, arity, len(args))
+	}
+	tsVal := args[0].(value.Value)
+	zoneVal := args[1].(value.Value)
+	if tsVal.Kind != value.KindInt || zoneVal.Kind != value.KindString {
+		return stdtime.Time{}, fmt.Errorf("%s expects timestamp int and zone string", name)
+	}
+	loc, err := loadZone(zoneVal.Str())
+	if err != nil {
+		return stdtime.Time{}, err
+	}
+	return stdtime.Unix(0, tsVal.Int()).In(loc), nil
+}
@@ -46,7 +46,7 @@ func registerAsyncConnect() {
 			if hostVal.Kind != value.KindString || portVal.Kind != value.KindInt || snVal.Kind != value.KindString {
 				return nil, fmt.Errorf("__builtin_async_tls_connect: invalid argument types")
 			}
-			host, port, sn := hostVal.Str, int(portVal.Int), snVal.Str
+			host, port, sn := hostVal.Str(), int(portVal.Int()), snVal.Str()
 			tlsSvc := env.TLS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := tlsSvc.Connect(host, port, sn)
@@ -91,7 +91,7 @@ func registerAsyncConnectConfig() {
 			if err != nil {
 				return nil, err
 			}
-			host, port := hostVal.Str, int(portVal.Int)
+			host, port := hostVal.Str(), int(portVal.Int())
 			tlsSvc := env.TLS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				handle, err := tlsSvc.ConnectConfig(host, port, cfg)
@@ -169,7 +169,7 @@ func registerAsyncRead() {
 			if nVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_async_tls_read expects n as int")
 			}
-			n := int(nVal.Int)
+			n := int(nVal.Int())
 			tlsSvc := env.TLS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				data, err := tlsSvc.Read(handle, n)
@@ -211,7 +211,7 @@ func registerAsyncWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return nil, fmt.Errorf("__builtin_async_tls_write expects data as bytes")
 			}
-			data := append([]byte(nil), dataVal.Bytes...)
+			data := append([]byte(nil), dataVal.Bytes()...)
 			tlsSvc := env.TLS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				n, err := tlsSvc.Write(handle, data)
@@ -328,23 +328,23 @@ func registerAsyncHTTPSRequest() {
 				return nil, fmt.Errorf("__builtin_async_https_request: method and url must be strings")
 			}
 			headers := make(map[string]string)
-			if headersVal.Kind == value.KindDict && headersVal.Dict != nil {
-				for k, v := range headersVal.Dict {
+			if headersVal.Kind == value.KindDict && headersVal.Dict() != nil {
+				for k, v := range headersVal.Dict().StrMap() {
 					if v.Kind == value.KindString {
-						headers[k] = v.Str
+						headers[k] = v.Str()
 					}
 				}
 			}
 			var body []byte
 			if bodyVal.Kind == value.KindBytes {
-				body = append([]byte(nil), bodyVal.Bytes...)
+				body = append([]byte(nil), bodyVal.Bytes()...)
 			}
 			cfg, err := extractTLSConfig(cfgVal)
 			if err != nil {
 				return nil, err
 			}
 
-			method, url := methodVal.Str, urlVal.Str
+			method, url := methodVal.Str(), urlVal.Str()
 			tlsSvc := env.TLS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				resp, err := tlsSvc.HTTPSRequest(method, url, headers, body, cfg)
@@ -33,51 +33,51 @@ func requireHandle(val value.Value) ([]byte, error) {
 	if val.Kind != value.KindBytes {
 		return nil, fmt.Errorf("tls handle must be bytes")
 	}
-	if len(val.Bytes) == 0 {
+	if len(val.Bytes()) == 0 {
 		return nil, fmt.Errorf("tls handle is empty")
 	}
-	return val.Bytes, nil
+	return val.Bytes(), nil
 }
 
 func extractTLSConfig(val value.Value) (*builtins.TLSConfigData, error) {
 	cfg := &builtins.TLSConfigData{}
 	if val.Kind == value.KindDict {
-		d := val.Dict
+		d := val.Dict()
 		if d == nil {
 			return cfg, nil
 		}
-		if v, ok := d["certFile"]; ok && v.Kind == value.KindString {
-			cfg.CertFile = v.Str
+		if v, ok := d.GetStr("certFile"); ok && v.Kind == value.KindString {
+			cfg.CertFile = v.Str()
 		}
-		if v, ok := d["keyFile"]; ok && v.Kind == value.KindString {
-			cfg.KeyFile = v.Str
+		if v, ok := d.GetStr("keyFile"); ok && v.Kind == value.KindString {
+			cfg.KeyFile = v.Str()
 		}
-		if v, ok := d["minVersion"]; ok && v.Kind == value.KindString {
-			cfg.MinVersion = v.Str
+		if v, ok := d.GetStr("minVersion"); ok && v.Kind == value.KindString {
+			cfg.MinVersion = v.Str()
 		}
-		if v, ok := d["maxVersion"]; ok && v.Kind == value.KindString {
-			cfg.MaxVersion = v.Str
+		if v, ok := d.GetStr("maxVersion"); ok && v.Kind == value.KindString {
+			cfg.MaxVersion = v.Str()
 		}
-		if v, ok := d["clientAuth"]; ok && v.Kind == value.KindString {
-			cfg.ClientAuth = v.Str
+		if v, ok := d.GetStr("clientAuth"); ok && v.Kind == value.KindString {
+			cfg.ClientAuth = v.Str()
 		}
-		if v, ok := d["serverName"]; ok && v.Kind == value.KindString {
-			cfg.ServerName = v.Str
+		if v, ok := d.GetStr("serverName"); ok && v.Kind == value.KindString {
+			cfg.ServerName = v.Str()
 		}
-		if v, ok := d["insecureSkipVerify"]; ok && v.Kind == value.KindBool {
-			cfg.InsecureSkipVerify = v.Bool
+		if v, ok := d.GetStr("insecureSkipVerify"); ok && v.Kind == value.KindBool {
+			cfg.InsecureSkipVerify = v.Bool()
 		}
-		if v, ok := d["alpnProtocols"]; ok && v.Kind == value.KindList {
-			for _, item := range v.List {
+		if v, ok := d.GetStr("alpnProtocols"); ok && v.Kind == value.KindList {
+			for _, item := range v.List() {
 				if item.Kind == value.KindString {
-					cfg.ALPNProtocols = append(cfg.ALPNProtocols, item.Str)
+					cfg.ALPNProtocols = append(cfg.ALPNProtocols, item.Str())
 				}
 			}
 		}
-		if v, ok := d["clientCAs"]; ok && v.Kind == value.KindList {
-			for _, item := range v.List {
+		if v, ok := d.GetStr("clientCAs"); ok && v.Kind == value.KindList {
+			for _, item := range v.List() {
 				if item.Kind == value.KindString {
-					cfg.ClientCAs = append(cfg.ClientCAs, item.Str)
+					cfg.ClientCAs = append(cfg.ClientCAs, item.Str())
 				}
 			}
 		}
@@ -114,7 +114,7 @@ func registerConnect() {
 			if hostVal.Kind != value.KindString || portVal.Kind != value.KindInt || snVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_tls_connect: invalid argument types")
 			}
-			handle, err := env.TLS().Connect(hostVal.Str, int(portVal.Int), snVal.Str)
+			handle, err := env.TLS().Connect(hostVal.Str(), int(portVal.Int()), snVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -155,7 +155,7 @@ func registerConnectConfig() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			handle, err := env.TLS().ConnectConfig(hostVal.Str, int(portVal.Int), cfg)
+			handle, err := env.TLS().ConnectConfig(hostVal.Str(), int(portVal.Int()), cfg)
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -195,7 +195,7 @@ func registerListen() {
 				certVal.Kind != value.KindString || keyVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_tls_listen: invalid argument types")
 			}
-			handle, err := env.TLS().Listen(hostVal.Str, int(portVal.Int), certVal.Str, keyVal.Str)
+			handle, err := env.TLS().Listen(hostVal.Str(), int(portVal.Int()), certVal.Str(), keyVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -236,7 +236,7 @@ func registerListenConfig() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			handle, err := env.TLS().ListenConfig(hostVal.Str, int(portVal.Int), cfg)
+			handle, err := env.TLS().ListenConfig(hostVal.Str(), int(portVal.Int()), cfg)
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -307,7 +307,7 @@ func registerRead() {
 			if nVal.Kind != value.KindInt {
 				return value.Value{}, fmt.Errorf("__builtin_tls_read expects n as int")
 			}
-			data, err := env.TLS().Read(handle, int(nVal.Int))
+			data, err := env.TLS().Read(handle, int(nVal.Int()))
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -345,7 +345,7 @@ func registerWrite() {
 			if dataVal.Kind != value.KindBytes {
 				return value.Value{}, fmt.Errorf("__builtin_tls_write expects data as bytes")
 			}
-			n, err := env.TLS().Write(handle, dataVal.Bytes)
+			n, err := env.TLS().Write(handle, dataVal.Bytes())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -444,7 +444,7 @@ func registerLoadCert() {
 			if certVal.Kind != value.KindString || keyVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_tls_load_cert: expects string arguments")
 			}
-			handle, err := env.TLS().LoadCert(certVal.Str, keyVal.Str)
+			handle, err := env.TLS().LoadCert(certVal.Str(), keyVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -478,11 +478,11 @@ func registerLoadCertChain() {
 				return value.Value{}, fmt.Errorf("__builtin_tls_load_cert_chain: expects list argument")
 			}
 			var files []string
-			for _, elem := range listVal.List {
+			for _, elem := range listVal.List() {
 				if elem.Kind != value.KindString {
 					return value.Value{}, fmt.Errorf("__builtin_tls_load_cert_chain: list elements must be strings")
 				}
-				files = append(files, elem.Str)
+				files = append(files, elem.Str())
 			}
 			handle, err := env.TLS().LoadCertChain(files)
 			if err != nil {
@@ -519,7 +519,7 @@ func registerLoadCertPEM() {
 			if certVal.Kind != value.KindBytes || keyVal.Kind != value.KindBytes {
 				return value.Value{}, fmt.Errorf("__builtin_tls_load_cert_pem: expects bytes arguments")
 			}
-			handle, err := env.TLS().LoadCertPEM(certVal.Bytes, keyVal.Bytes)
+			handle, err := env.TLS().LoadCertPEM(certVal.Bytes(), keyVal.Bytes())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -695,7 +695,7 @@ func registerHTTPSListen() {
 				certVal.Kind != value.KindString || keyVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_https_listen: invalid argument types")
 			}
-			handle, err := env.TLS().Listen(hostVal.Str, int(portVal.Int), certVal.Str, keyVal.Str)
+			handle, err := env.TLS().Listen(hostVal.Str(), int(portVal.Int()), certVal.Str(), keyVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -736,7 +736,7 @@ func registerHTTPSListenConfig() {
 			if err != nil {
 				return value.Value{}, err
 			}
-			handle, err := env.TLS().ListenConfig(hostVal.Str, int(portVal.Int), cfg)
+			handle, err := env.TLS().ListenConfig(hostVal.Str(), int(portVal.Int()), cfg)
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -776,7 +776,7 @@ func registerHTTPSListenAuto() {
 				domainVal.Kind != value.KindString || emailVal.Kind != value.KindString {
 				return value.Value{}, fmt.Errorf("__builtin_https_listen_auto: invalid argument types")
 			}
-			handle, err := env.TLS().ListenAutoTLS(hostVal.Str, int(portVal.Int), domainVal.Str, emailVal.Str)
+			handle, err := env.TLS().ListenAutoTLS(hostVal.Str(), int(portVal.Int()), domainVal.Str(), emailVal.Str())
 			if err != nil {
 				return value.Value{}, err
 			}
@@ -22,7 +22,7 @@ func requireHandle(v value.Value) ([]byte, error) {
 	if v.Kind != value.KindBytes {
 		return nil, fmt.Errorf("ws: expected bytes handle, got %v", v.Kind)
 	}
-	return v.Bytes, nil
+	return v.Bytes(), nil
 }
 
 func registerAsyncUpgrade() {
@@ -58,18 +58,18 @@ func registerAsyncUpgrade() {
 
 			var protocols []string
 			if protocolsVal.Kind == value.KindList {
-				for _, item := range protocolsVal.List {
+				for _, item := range protocolsVal.List() {
 					if item.Kind == value.KindString {
-						protocols = append(protocols, item.Str)
+						protocols = append(protocols, item.Str())
 					}
 				}
 			}
 
 			extraHeaders := make(map[string]string)
 			if headersVal.Kind == value.KindDict {
-				for k, v := range headersVal.Dict {
+				for k, v := range headersVal.Dict().StrMap() {
 					if v.Kind == value.KindString {
-						extraHeaders[k] = v.Str
+						extraHeaders[k] = v.Str()
 					}
 				}
 			}
@@ -121,7 +121,7 @@ func registerAsyncSendText() {
 			}
 
 			wsHandle := append([]byte(nil), handle...)
-			text := textVal.Str
+			text := textVal.Str()
 			wsSvc := env.WS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := wsSvc.SendText(wsHandle, text); err != nil {
@@ -164,7 +164,7 @@ func registerAsyncSendBytes() {
 			}
 
 			wsHandle := append([]byte(nil), handle...)
-			data := append([]byte(nil), dataVal.Bytes...)
+			data := append([]byte(nil), dataVal.Bytes()...)
 			wsSvc := env.WS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := wsSvc.SendBytes(wsHandle, data); err != nil {
@@ -207,7 +207,7 @@ func registerAsyncSendPing() {
 			}
 
 			wsHandle := append([]byte(nil), handle...)
-			data := append([]byte(nil), dataVal.Bytes...)
+			data := append([]byte(nil), dataVal.Bytes()...)
 			wsSvc := env.WS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := wsSvc.SendPing(wsHandle, data); err != nil {
@@ -302,8 +302,8 @@ func registerAsyncClose() {
 			}
 
 			wsHandle := append([]byte(nil), handle...)
-			code := int(codeVal.Int)
-			reason := reasonVal.Str
+			code := int(codeVal.Int())
+			reason := reasonVal.Str()
 			wsSvc := env.WS()
 			return builtins.RunAsync(func() (interface{}, error) {
 				if err := wsSvc.Close(wsHandle, code, reason); err != nil {
@@ -344,7 +344,7 @@ func registerSetReadLimit() {
 			if limitVal.Kind != value.KindInt {
 				return nil, fmt.Errorf("__builtin_ws_set_read_limit expects limit as int")
 			}
-			if err := env.WS().SetReadLimit(handle, limitVal.Int); err != nil {
+			if err := env.WS().SetReadLimit(handle, limitVal.Int()); err != nil {
 				return nil, err
 			}
 			return value.Value{}, nil
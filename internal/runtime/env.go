@@ -23,13 +23,18 @@ type Env struct {
 	ioService       builtinsio.IO
 	closureCaller   ClosureCaller // Function to call closures (set by VM)
 	structTypeNames []string
-	netService      *netService
-	fsService       *fsService
+	structFields    [][]string
+	netService      builtins.Net
+	fsService       builtins.FS
 	httpService     *httpService
 	sqlService      *sqlService
 	tlsService      *tlsService
 	wsService       *wsService
+	timerService    *timerService
+	osService       *osService
+	processService  *processService
 	execRoot        string
+	sandbox         *sandbox
 }
 
 // IO returns the IO service. Implements builtins.Env interface.
@@ -67,6 +72,82 @@ func (e *Env) WS() builtins.WS {
 	return e.wsService
 }
 
+// Timers returns the timer service. Implements builtins.Env interface.
+func (e *Env) Timers() builtins.Timers {
+	return e.timerService
+}
+
+// OS returns the OS service. Implements builtins.Env interface.
+func (e *Env) OS() builtins.OS {
+	return e.osService
+}
+
+// Process returns the subprocess service, or nil when it is disabled.
+// Implements builtins.Env interface.
+func (e *Env) Process() builtins.Process {
+	if e.processService == nil {
+		return nil
+	}
+	return e.processService
+}
+
+// SetIO replaces the IO service, e.g. to capture a program's output.
+func (e *Env) SetIO(io builtinsio.IO) {
+	e.ioService = io
+}
+
+// SetNet replaces the networking service. Custom implementations are not
+// subject to the Env's permissions and must do their own checks.
+func (e *Env) SetNet(n builtins.Net) {
+	e.netService = n
+}
+
+// SetFS replaces the filesystem service. Custom implementations are not
+// subject to the Env's permissions and must do their own checks.
+func (e *Env) SetFS(fs builtins.FS) {
+	e.fsService = fs
+}
+
+// DisableProcess turns off subprocess execution for sandboxed runs.
+func (e *Env) DisableProcess() {
+	e.processService = nil
+}
+
+// SetPermissions restricts the host services to p; nil lifts every
+// restriction. Relative read and write roots are resolved against the
+// current exec root, so set the exec root first.
+func (e *Env) SetPermissions(p *Permissions) error {
+	return e.sandbox.set(p, e.execRoot)
+}
+
+// Permissions returns the active permissions, or nil when unrestricted.
+func (e *Env) Permissions() *Permissions {
+	return e.sandbox.get()
+}
+
+// CheckRead returns a *builtins.PermissionError when reading path is not
+// allowed. Implements builtins.Env interface.
+func (e *Env) CheckRead(path string) error {
+	return e.sandbox.checkRead(path)
+}
+
+// SetArgs sets the program arguments reported by std.os.
+func (e *Env) SetArgs(args []string) {
+	e.osService.args = append([]string(nil), args...)
+}
+
+// SetScheduler attaches the event loop's scheduler so timers fire on it.
+func (e *Env) SetScheduler(sched *Scheduler) {
+	e.timerService.attach(sched)
+}
+
+// Scheduler returns the scheduler attached by SetScheduler, or nil.
+func (e *Env) Scheduler() *Scheduler {
+	e.timerService.mu.Lock()
+	defer e.timerService.mu.Unlock()
+	return e.timerService.sched
+}
+
 // ExecRoot returns the execution root directory for relative file paths.
 func (e *Env) ExecRoot() string {
 	if e == nil {
@@ -88,6 +169,20 @@ func (e *Env) StructTypeName(index int) (string, bool) {
 	return name, true
 }
 
+// StructFieldIndex returns the index of the named field of a struct type.
+// Implements builtins.Env interface.
+func (e *Env) StructFieldIndex(index int, field string) (int, bool) {
+	if e == nil || index < 0 || index >= len(e.structFields) {
+		return 0, false
+	}
+	for i, name := range e.structFields[index] {
+		if name == field {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 // CallClosure calls a closure with the given arguments.
 // Implements builtins.Env interface.
 func (e *Env) CallClosure(clo interface{}, args []interface{}) (interface{}, error) {
@@ -125,6 +220,12 @@ func (e *Env) SetStructTypeNames(names []string) {
 	e.structTypeNames = names
 }
 
+// SetStructFieldNames sets the field names of each struct type, indexed
+// like the struct type names.
+func (e *Env) SetStructFieldNames(fields [][]string) {
+	e.structFields = fields
+}
+
 // SetExecRoot sets the execution root directory for relative file paths.
 func (e *Env) SetExecRoot(root string) {
 	e.execRoot = root
@@ -165,29 +266,38 @@ func (s *stdIO) ReadLine() (string, error) {
 // DefaultEnv returns an Env with standard implementations
 // (printing to stdout, real filesystem, etc.).
 func DefaultEnv() *Env {
-	httpSvc := newHTTPService()
-	return &Env{
-		ioService:   newStdIO(),
-		netService:  newNetService(),
-		fsService:   newFSService(),
-		httpService: httpSvc,
-		sqlService:  newSQLService(),
-		tlsService:  newTLSService(),
-		wsService:   newWSService(httpSvc),
-	}
+	return newEnv(newStdIO())
 }
 
 // NewEnv creates a new Env with the given IO service.
 // This is useful for tests that need to provide a custom IO implementation.
 func NewEnv(io builtinsio.IO) *Env {
+	return newEnv(io)
+}
+
+func newEnv(io builtinsio.IO) *Env {
+	sb := &sandbox{}
+	netSvc := newNetService()
+	fsSvc := newFSService()
 	httpSvc := newHTTPService()
-	return &Env{
-		ioService:   io,
-		netService:  newNetService(),
-		fsService:   newFSService(),
-		httpService: httpSvc,
-		sqlService:  newSQLService(),
-		tlsService:  newTLSService(),
-		wsService:   newWSService(httpSvc),
+	env := &Env{
+		ioService:      io,
+		netService:     netSvc,
+		fsService:      fsSvc,
+		httpService:    httpSvc,
+		sqlService:     newSQLService(),
+		tlsService:     newTLSService(),
+		wsService:      newWSService(httpSvc),
+		timerService:   newTimerService(),
+		processService: newProcessService(),
+		sandbox:        sb,
 	}
+	netSvc.sandbox = sb
+	fsSvc.sandbox = sb
+	httpSvc.sandbox = sb
+	env.sqlService.sandbox = sb
+	env.tlsService.sandbox = sb
+	env.processService.sandbox = sb
+	env.osService = newOSService(env)
+	return env
 }
@@ -1,16 +1,39 @@
 package runtime
 
+import (
+	"errors"
+
+	"avenir/internal/runtime/builtins"
+)
+
+// fatalError is implemented by errors that must stop the event loop, such as
+// the VM's resource limit errors.
+type fatalError interface {
+	error
+	Fatal() bool
+}
+
 // RunEventLoop runs all scheduled tasks until completion.
 // When no ready tasks exist but suspended tasks remain (waiting for async I/O),
 // the loop blocks on the scheduler's wakeup channel until a goroutine signals
-// that a future has been resolved/rejected.
+// that a future has been resolved/rejected or the next timer is due.
+// Timer callbacks run on the loop between task steps. The loop stops with the
+// context's error once the scheduler's context is done.
 func RunEventLoop(sched *Scheduler) error {
 	for {
+		if err := sched.Err(); err != nil {
+			return err
+		}
+		sched.RunTimers()
 		for !sched.HasTasks() {
 			if sched.IsIdle() {
 				return nil
 			}
 			sched.WaitForWakeup()
+			if err := sched.Err(); err != nil {
+				return err
+			}
+			sched.RunTimers()
 		}
 
 		task := sched.Next()
@@ -24,6 +47,17 @@ func RunEventLoop(sched *Scheduler) error {
 		if err != nil {
 			task.Status = TaskFailed
 			task.Future.Reject(err)
+			// os.exit and exceeded VM limits in any task end the whole
+			// program. On exit, the other tasks still run their defers.
+			var exitErr *builtins.ExitError
+			if errors.As(err, &exitErr) {
+				sched.UnwindTasks()
+				return err
+			}
+			var fatal fatalError
+			if errors.As(err, &fatal) && fatal.Fatal() {
+				return err
+			}
 			continue
 		}
 
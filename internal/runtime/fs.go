@@ -4,20 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
+
+	"avenir/internal/fswatch"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
 )
 
 type fsService struct {
-	nextID uint64
-	mu     sync.Mutex
-	files  map[uint64]*os.File
+	nextID   uint64
+	mu       sync.Mutex
+	files    map[uint64]*os.File
+	watchers map[uint64]*fswatch.Watcher
+
+	sandbox *sandbox
 }
 
 func newFSService() *fsService {
 	return &fsService{
-		files: make(map[uint64]*os.File),
+		files:    make(map[uint64]*os.File),
+		watchers: make(map[uint64]*fswatch.Watcher),
 	}
 }
 
@@ -26,6 +36,16 @@ func (f *fsService) Open(path string, mode string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if flags&(os.O_WRONLY|os.O_RDWR) == 0 || flags&os.O_RDWR != 0 {
+		if err := f.sandbox.checkRead(path); err != nil {
+			return nil, err
+		}
+	}
+	if flags&(os.O_WRONLY|os.O_RDWR) != 0 {
+		if err := f.sandbox.checkWrite(path); err != nil {
+			return nil, err
+		}
+	}
 	file, err := os.OpenFile(path, flags, 0o666)
 	if err != nil {
 		return nil, err
@@ -94,6 +114,9 @@ func (f *fsService) Close(handle []byte) error {
 }
 
 func (f *fsService) Exists(path string) (bool, error) {
+	if err := f.sandbox.checkRead(path); err != nil {
+		return false, err
+	}
 	_, err := os.Stat(path)
 	if err == nil {
 		return true, nil
@@ -105,13 +128,319 @@ func (f *fsService) Exists(path string) (bool, error) {
 }
 
 func (f *fsService) Remove(path string) error {
+	if err := f.sandbox.checkWrite(path); err != nil {
+		return err
+	}
 	return os.Remove(path)
 }
 
 func (f *fsService) Mkdir(path string) error {
+	if err := f.sandbox.checkWrite(path); err != nil {
+		return err
+	}
 	return os.Mkdir(path, 0o755)
 }
 
+func (f *fsService) Stat(path string) (*builtins.FileInfo, error) {
+	if err := f.sandbox.checkRead(path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo(info), nil
+}
+
+func (f *fsService) Lstat(path string) (*builtins.FileInfo, error) {
+	if err := f.sandbox.checkRead(path); err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo(info), nil
+}
+
+func (f *fsService) ReadDir(path string) ([]builtins.FileInfo, error) {
+	if err := f.sandbox.checkRead(path); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]builtins.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// Removed between listing and stat.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *fileInfo(info))
+	}
+	return infos, nil
+}
+
+func (f *fsService) Rename(from, to string) error {
+	if err := f.sandbox.checkWrite(from, to); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+func (f *fsService) Copy(from, to string) error {
+	if err := f.sandbox.checkRead(from); err != nil {
+		return err
+	}
+	if err := f.sandbox.checkWrite(to); err != nil {
+		return err
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("copy %s: not a regular file", from)
+	}
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	// OpenFile only applies the mode to new files.
+	return os.Chmod(to, info.Mode().Perm())
+}
+
+func (f *fsService) MkdirAll(path string) error {
+	if err := f.sandbox.checkWrite(path); err != nil {
+		return err
+	}
+	return os.MkdirAll(path, 0o755)
+}
+
+func (f *fsService) RemoveAll(path string) error {
+	if err := f.sandbox.checkWrite(path); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+func (f *fsService) Chmod(path string, mode uint32) error {
+	if err := f.sandbox.checkWrite(path); err != nil {
+		return err
+	}
+	if mode > 0o7777 {
+		return fmt.Errorf("invalid file mode %o", mode)
+	}
+	return os.Chmod(path, fileMode(mode))
+}
+
+func (f *fsService) Symlink(target, link string) error {
+	if err := f.sandbox.checkWrite(link); err != nil {
+		return err
+	}
+	return os.Symlink(target, link)
+}
+
+func (f *fsService) Readlink(path string) (string, error) {
+	if err := f.sandbox.checkRead(path); err != nil {
+		return "", err
+	}
+	return os.Readlink(path)
+}
+
+// Glob matches pattern, relative to dir unless it is absolute. Matches the
+// sandbox does not allow reading are left out.
+func (f *fsService) Glob(dir, pattern string) ([]string, error) {
+	relative := !filepath.IsAbs(pattern) && dir != ""
+	if relative {
+		pattern = filepath.Join(dir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	allowed := matches[:0]
+	for _, m := range matches {
+		if f.sandbox.checkRead(m) != nil {
+			continue
+		}
+		if relative {
+			if rel, err := filepath.Rel(dir, m); err == nil {
+				m = rel
+			}
+		}
+		allowed = append(allowed, m)
+	}
+	return nonNil(allowed), nil
+}
+
+func (f *fsService) Seek(handle []byte, offset int64, whence int) (int64, error) {
+	if whence < io.SeekStart || whence > io.SeekEnd {
+		return 0, fmt.Errorf("invalid seek whence %d", whence)
+	}
+	file, err := f.file(handle)
+	if err != nil {
+		return 0, err
+	}
+	return file.Seek(offset, whence)
+}
+
+func (f *fsService) Truncate(handle []byte, size int64) error {
+	if size < 0 {
+		return fmt.Errorf("invalid truncate size %d", size)
+	}
+	file, err := f.file(handle)
+	if err != nil {
+		return err
+	}
+	return file.Truncate(size)
+}
+
+func (f *fsService) Watch(paths []string, opts builtins.WatchOptions) ([]byte, error) {
+	if err := f.sandbox.checkRead(paths...); err != nil {
+		return nil, err
+	}
+	w, err := fswatch.New(paths, fswatch.Options{
+		Recursive:    opts.Recursive,
+		Debounce:     opts.Debounce,
+		Poll:         opts.Poll,
+		PollInterval: opts.PollInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	id := f.nextHandle()
+	f.mu.Lock()
+	f.watchers[id] = w
+	f.mu.Unlock()
+	return encodeHandle(id), nil
+}
+
+func (f *fsService) NextWatchEvents(handle []byte) builtins.AsyncHandle {
+	ah := NewAsyncHandle()
+	w, err := f.watcher(handle)
+	if err != nil {
+		ah.Reject(err)
+		return ah
+	}
+	go func() {
+		batch, ok := <-w.Events()
+		if !ok {
+			ah.Resolve(value.List([]value.Value{}))
+			return
+		}
+		items := make([]value.Value, len(batch))
+		for i, ev := range batch {
+			items[i] = value.Dict(map[string]value.Value{
+				"op":   value.Str(ev.Op),
+				"path": value.Str(ev.Path),
+			})
+		}
+		ah.Resolve(value.List(items))
+	}()
+	return ah
+}
+
+// CloseWatch stops the watcher. The handle stays valid so that pending and
+// later NextWatchEvents calls resolve to an empty batch.
+func (f *fsService) CloseWatch(handle []byte) error {
+	w, err := f.watcher(handle)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (f *fsService) watcher(handle []byte) (*fswatch.Watcher, error) {
+	id, err := decodeHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	w := f.watchers[id]
+	f.mu.Unlock()
+	if w == nil {
+		return nil, fmt.Errorf("invalid watcher handle")
+	}
+	return w, nil
+}
+
+func (f *fsService) file(handle []byte) (*os.File, error) {
+	id, err := decodeHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	file := f.files[id]
+	f.mu.Unlock()
+	if file == nil {
+		return nil, fmt.Errorf("invalid file handle")
+	}
+	return file, nil
+}
+
+func fileInfo(info fs.FileInfo) *builtins.FileInfo {
+	mode := info.Mode()
+	perm := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+	return &builtins.FileInfo{
+		Name:      info.Name(),
+		Size:      info.Size(),
+		Mode:      perm,
+		ModTime:   info.ModTime().UnixNano(),
+		IsDir:     info.IsDir(),
+		IsSymlink: mode&fs.ModeSymlink != 0,
+	}
+}
+
+// fileMode converts Unix permission bits, including setuid, setgid and
+// sticky, to an fs.FileMode.
+func fileMode(mode uint32) fs.FileMode {
+	m := fs.FileMode(mode & 0o777)
+	if mode&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if mode&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m
+}
+
+func nonNil(paths []string) []string {
+	if paths == nil {
+		return []string{}
+	}
+	return paths
+}
+
 func (f *fsService) nextHandle() uint64 {
 	return atomic.AddUint64(&f.nextID, 1)
 }
@@ -118,3 +118,20 @@ func (f *Future) AddWaiter(t *Task) bool {
 
 	return true
 }
+
+// Forward settles f with the outcome of inner once inner completes.
+// Used when a spawned task itself returns a future, so awaiting the task's
+// future yields the inner result instead of a nested Future.
+func (f *Future) Forward(inner *Future) {
+	go func() {
+		<-inner.done
+		inner.mu.Lock()
+		res, err := inner.Result, inner.Err
+		inner.mu.Unlock()
+		if err != nil {
+			f.Reject(err)
+		} else {
+			f.Resolve(res)
+		}
+	}()
+}
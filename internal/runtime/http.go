@@ -19,6 +19,8 @@ type httpService struct {
 	mu       sync.Mutex
 	servers  map[uint64]net.Listener
 	requests map[uint64]*httpRequest
+
+	sandbox *sandbox
 }
 
 type httpRequest struct {
@@ -40,6 +42,9 @@ func newHTTPService() *httpService {
 }
 
 func (h *httpService) Request(method string, url string, headers map[string]string, body []byte) (*builtins.HTTPResponseData, error) {
+	if err := h.sandbox.checkURL(url); err != nil {
+		return nil, err
+	}
 	var bodyReader io.Reader
 	if len(body) > 0 {
 		bodyReader = bytes.NewReader(body)
@@ -51,7 +56,7 @@ func (h *httpService) Request(method string, url string, headers map[string]stri
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
-	client := &http.Client{}
+	client := &http.Client{CheckRedirect: h.sandbox.checkRedirect}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -76,6 +81,9 @@ func (h *httpService) Listen(host string, port int) ([]byte, error) {
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("invalid port %d", port)
 	}
+	if err := h.sandbox.checkListen(host, port); err != nil {
+		return nil, err
+	}
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -181,6 +189,22 @@ func (h *httpService) Respond(reqHandle []byte, status int, headers map[string]s
 	return req.conn.Close()
 }
 
+// Close stops listening. A pending Accept on the server fails.
+func (h *httpService) Close(serverHandle []byte) error {
+	id, err := decodeHandle(serverHandle)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	ln := h.servers[id]
+	delete(h.servers, id)
+	h.mu.Unlock()
+	if ln == nil {
+		return fmt.Errorf("invalid server handle")
+	}
+	return ln.Close()
+}
+
 func (h *httpService) nextHandle() uint64 {
 	return atomic.AddUint64(&h.nextID, 1)
 }
@@ -14,6 +14,8 @@ type netService struct {
 	mu        sync.Mutex
 	conns     map[uint64]net.Conn
 	listeners map[uint64]net.Listener
+
+	sandbox *sandbox
 }
 
 func newNetService() *netService {
@@ -27,6 +29,9 @@ func (n *netService) Connect(host string, port int) ([]byte, error) {
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("invalid port %d", port)
 	}
+	if err := n.sandbox.checkConnect(host, port); err != nil {
+		return nil, err
+	}
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 	conn, err := net.Dial("tcp", addr)
 	if err != nil {
@@ -43,6 +48,9 @@ func (n *netService) Listen(host string, port int) ([]byte, error) {
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("invalid port %d", port)
 	}
+	if err := n.sandbox.checkListen(host, port); err != nil {
+		return nil, err
+	}
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
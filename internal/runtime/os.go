@@ -0,0 +1,186 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// signalsByName lists the signals programs may subscribe to.
+var signalsByName = map[string]os.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+}
+
+type osService struct {
+	env  *Env
+	args []string
+
+	mu      sync.Mutex
+	nextSub int
+	subs    map[int]*signalSub
+}
+
+type signalSub struct {
+	ch   chan os.Signal
+	done chan struct{}
+}
+
+func newOSService(env *Env) *osService {
+	return &osService{env: env, subs: make(map[int]*signalSub)}
+}
+
+func (s *osService) Args() []string {
+	return append([]string(nil), s.args...)
+}
+
+func (s *osService) Getenv(key string) (string, bool, error) {
+	if err := s.env.sandbox.checkEnv(key); err != nil {
+		return "", false, err
+	}
+	val, ok := os.LookupEnv(key)
+	return val, ok, nil
+}
+
+func (s *osService) Setenv(key, value string) error {
+	if err := s.env.sandbox.checkEnv(key); err != nil {
+		return err
+	}
+	return os.Setenv(key, value)
+}
+
+func (s *osService) Unsetenv(key string) error {
+	if err := s.env.sandbox.checkEnv(key); err != nil {
+		return err
+	}
+	return os.Unsetenv(key)
+}
+
+func (s *osService) Environ() (map[string]string, error) {
+	if err := s.env.sandbox.checkEnv(""); err != nil {
+		return nil, err
+	}
+	env := os.Environ()
+	result := make(map[string]string, len(env))
+	for _, kv := range env {
+		key, val, _ := strings.Cut(kv, "=")
+		if key == "" {
+			continue
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+func (s *osService) Pid() int {
+	return os.Getpid()
+}
+
+func (s *osService) Hostname() (string, error) {
+	return os.Hostname()
+}
+
+// Getwd reports the exec root when one is set, so that it agrees with how
+// std.fs resolves relative paths.
+func (s *osService) Getwd() (string, error) {
+	if root := s.env.ExecRoot(); root != "" {
+		return root, nil
+	}
+	return os.Getwd()
+}
+
+// Chdir moves the exec root rather than the process working directory, so
+// VMs embedded in the same process do not affect each other.
+func (s *osService) Chdir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		wd, err := s.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(wd, dir)
+	}
+	if err := s.env.sandbox.checkRead(dir); err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("chdir %s: not a directory", dir)
+	}
+	s.env.SetExecRoot(filepath.Clean(dir))
+	return nil
+}
+
+func (s *osService) Notify(names []string) (int, error) {
+	if len(names) == 0 {
+		return 0, fmt.Errorf("no signals given")
+	}
+	sigs := make([]os.Signal, 0, len(names))
+	for _, name := range names {
+		sig, ok := signalsByName[strings.ToUpper(name)]
+		if !ok {
+			return 0, fmt.Errorf("unsupported signal %q", name)
+		}
+		sigs = append(sigs, sig)
+	}
+	sub := &signalSub{ch: make(chan os.Signal, 8), done: make(chan struct{})}
+	signal.Notify(sub.ch, sigs...)
+	s.mu.Lock()
+	s.nextSub++
+	id := s.nextSub
+	s.subs[id] = sub
+	s.mu.Unlock()
+	return id, nil
+}
+
+func (s *osService) NextSignal(id int) builtins.AsyncHandle {
+	ah := NewAsyncHandle()
+	s.mu.Lock()
+	sub, ok := s.subs[id]
+	s.mu.Unlock()
+	if !ok {
+		ah.Resolve(value.Str(""))
+		return ah
+	}
+	go func() {
+		select {
+		case sig := <-sub.ch:
+			ah.Resolve(value.Str(signalName(sig)))
+		case <-sub.done:
+			ah.Resolve(value.Str(""))
+		}
+	}()
+	return ah
+}
+
+func (s *osService) StopSignals(id int) {
+	s.mu.Lock()
+	sub, ok := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	signal.Stop(sub.ch)
+	close(sub.done)
+}
+
+func signalName(sig os.Signal) string {
+	for name, s := range signalsByName {
+		if s == sig {
+			return name
+		}
+	}
+	return sig.String()
+}
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"avenir/internal/value"
+)
+
+func TestOSChdirMovesExecRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	env := DefaultEnv()
+	env.SetExecRoot(root)
+
+	if err := env.OS().Chdir("sub"); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	wd, err := env.OS().Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if want := filepath.Join(root, "sub"); wd != want || env.ExecRoot() != want {
+		t.Fatalf("expected working directory %s, got %s (exec root %s)", want, wd, env.ExecRoot())
+	}
+	if err := env.OS().Chdir("missing"); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+}
+
+func TestOSSignalSubscription(t *testing.T) {
+	svc := DefaultEnv().OS()
+	if _, err := svc.Notify([]string{"SIGKILL"}); err == nil {
+		t.Fatal("expected error for unsupported signal")
+	}
+	id, err := svc.Notify([]string{"SIGHUP"})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	ah := svc.NextSignal(id).(*AsyncHandle)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+	select {
+	case <-ah.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal was not delivered")
+	}
+	if res, _, _ := ah.Poll(); res.Kind != value.KindString || res.Str() != "SIGHUP" {
+		t.Fatalf("expected SIGHUP, got %v", res)
+	}
+
+	pending := svc.NextSignal(id).(*AsyncHandle)
+	svc.StopSignals(id)
+	pending.Wait()
+	if res, _, _ := pending.Poll(); res.Str() != "" {
+		t.Fatalf("expected stopped subscription to resolve to \"\", got %q", res.Str())
+	}
+}
@@ -0,0 +1,285 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"avenir/internal/runtime/builtins"
+)
+
+// Permissions restricts the host services available to a program. An Env
+// without permissions grants everything; once SetPermissions is called,
+// anything not listed here is denied with a *builtins.PermissionError.
+type Permissions struct {
+	// Read and Write list the files and directories (with everything below
+	// them) that may be read or modified. Relative entries are resolved
+	// against the exec root when the permissions are set.
+	Read  []string
+	Write []string
+	// Connect and Listen list allowed addresses as "host:port", "host" (any
+	// port) or "*:port" (any host).
+	Connect []string
+	Listen  []string
+	SQL     bool
+	// Process allows running subprocesses; Env allows reading and changing
+	// environment variables.
+	Process bool
+	Env     bool
+}
+
+// AllowAll returns permissions that grant every host service, for callers
+// that only want to narrow a single capability.
+func AllowAll() *Permissions {
+	return &Permissions{
+		Read:    []string{string(filepath.Separator)},
+		Write:   []string{string(filepath.Separator)},
+		Connect: []string{"*"},
+		Listen:  []string{"*"},
+		SQL:     true,
+		Process: true,
+		Env:     true,
+	}
+}
+
+// sandbox is shared by the services of one Env and checks operations against
+// its current permissions.
+type sandbox struct {
+	mu    sync.RWMutex
+	perms *Permissions
+}
+
+func (s *sandbox) set(p *Permissions, root string) error {
+	var resolved *Permissions
+	if p != nil {
+		copied := *p
+		var err error
+		if copied.Read, err = resolveRoots(p.Read, root); err != nil {
+			return err
+		}
+		if copied.Write, err = resolveRoots(p.Write, root); err != nil {
+			return err
+		}
+		for _, list := range [][]string{p.Connect, p.Listen} {
+			for _, pattern := range list {
+				if _, _, err := splitAddrPattern(pattern); err != nil {
+					return err
+				}
+			}
+		}
+		copied.Connect = append([]string(nil), p.Connect...)
+		copied.Listen = append([]string(nil), p.Listen...)
+		resolved = &copied
+	}
+	s.mu.Lock()
+	s.perms = resolved
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *sandbox) get() *Permissions {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.perms
+}
+
+func (s *sandbox) checkRead(paths ...string) error {
+	return s.checkPaths("read", func(p *Permissions) []string { return p.Read }, paths)
+}
+
+func (s *sandbox) checkWrite(paths ...string) error {
+	return s.checkPaths("write", func(p *Permissions) []string { return p.Write }, paths)
+}
+
+func (s *sandbox) checkPaths(op string, roots func(*Permissions) []string, paths []string) error {
+	p := s.get()
+	if p == nil {
+		return nil
+	}
+	for _, path := range paths {
+		if !pathAllowed(roots(p), path) {
+			return &builtins.PermissionError{Op: op, Target: path}
+		}
+	}
+	return nil
+}
+
+func (s *sandbox) checkConnect(host string, port int) error {
+	return s.checkAddr("connect", func(p *Permissions) []string { return p.Connect }, host, port)
+}
+
+func (s *sandbox) checkListen(host string, port int) error {
+	return s.checkAddr("listen", func(p *Permissions) []string { return p.Listen }, host, port)
+}
+
+// checkURL checks a connection to the host and port of an http(s) URL.
+func (s *sandbox) checkURL(rawURL string) error {
+	if s.get() == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	port := 80
+	if u.Scheme == "https" {
+		port = 443
+	}
+	if p := u.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return fmt.Errorf("invalid port in %s", rawURL)
+		}
+	}
+	return s.checkConnect(u.Hostname(), port)
+}
+
+// checkRedirect is an http.Client CheckRedirect hook that keeps redirects
+// within the allowed hosts.
+func (s *sandbox) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return s.checkURL(req.URL.String())
+}
+
+func (s *sandbox) checkAddr(op string, patterns func(*Permissions) []string, host string, port int) error {
+	p := s.get()
+	if p == nil {
+		return nil
+	}
+	for _, pattern := range patterns(p) {
+		if addrAllowed(pattern, host, port) {
+			return nil
+		}
+	}
+	return &builtins.PermissionError{Op: op, Target: net.JoinHostPort(host, strconv.Itoa(port))}
+}
+
+func (s *sandbox) checkSQL() error {
+	if p := s.get(); p != nil && !p.SQL {
+		return &builtins.PermissionError{Op: "sql"}
+	}
+	return nil
+}
+
+func (s *sandbox) checkProcess(path string) error {
+	if p := s.get(); p != nil && !p.Process {
+		return &builtins.PermissionError{Op: "run", Target: path}
+	}
+	return nil
+}
+
+func (s *sandbox) checkEnv(key string) error {
+	if p := s.get(); p != nil && !p.Env {
+		return &builtins.PermissionError{Op: "env", Target: key}
+	}
+	return nil
+}
+
+// resolveRoots makes roots absolute against root and resolves symlinks, so
+// that a link inside an allowed directory cannot point outside of it.
+func resolveRoots(roots []string, root string) ([]string, error) {
+	resolved := make([]string, 0, len(roots))
+	for _, r := range roots {
+		if r == "" {
+			return nil, fmt.Errorf("empty path in permissions")
+		}
+		if !filepath.IsAbs(r) && root != "" {
+			r = filepath.Join(root, r)
+		}
+		abs, err := realPath(r)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, abs)
+	}
+	return resolved, nil
+}
+
+func pathAllowed(roots []string, path string) bool {
+	abs, err := realPath(path)
+	if err != nil {
+		return false
+	}
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath returns the absolute path with symlinks resolved for the part of
+// it that exists.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for dir := abs; ; {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs, nil
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+		dir = parent
+	}
+}
+
+// splitAddrPattern splits "host:port", "host" or "*:port"; an empty host or
+// a port of -1 match anything.
+func splitAddrPattern(pattern string) (string, int, error) {
+	if pattern == "" {
+		return "", 0, fmt.Errorf("empty address in permissions")
+	}
+	if pattern == "*" {
+		return "", -1, nil
+	}
+	host, portStr, err := net.SplitHostPort(pattern)
+	if err != nil {
+		// No port: the whole pattern is a host, possibly a bare IPv6 address.
+		return strings.Trim(pattern, "[]"), -1, nil
+	}
+	if host == "*" {
+		host = ""
+	}
+	if portStr == "*" {
+		return host, -1, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in permission %q", pattern)
+	}
+	return host, port, nil
+}
+
+func addrAllowed(pattern, host string, port int) bool {
+	allowHost, allowPort, err := splitAddrPattern(pattern)
+	if err != nil {
+		return false
+	}
+	if allowPort != -1 && allowPort != port {
+		return false
+	}
+	return allowHost == "" || strings.EqualFold(allowHost, host)
+}
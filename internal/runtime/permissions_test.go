@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"avenir/internal/runtime/builtins"
+)
+
+func expectDenied(t *testing.T, err error, op string) {
+	t.Helper()
+	var permErr *builtins.PermissionError
+	if !errors.As(err, &permErr) {
+		t.Fatalf("expected permission error for %s, got %v", op, err)
+	}
+	if permErr.Op != op {
+		t.Fatalf("expected %s to be denied, got %v", op, permErr)
+	}
+}
+
+func TestPermissionsFS(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{"data", "out"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "data", "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	env := DefaultEnv()
+	env.SetExecRoot(root)
+	if err := env.SetPermissions(&Permissions{Read: []string{"data", "out"}, Write: []string{"out"}}); err != nil {
+		t.Fatalf("set permissions: %v", err)
+	}
+	fs := env.FS()
+
+	if _, err := fs.ReadDir(filepath.Join(root, "data")); err != nil {
+		t.Fatalf("read allowed dir: %v", err)
+	}
+	_, err := fs.Open(filepath.Join(root, "data", "escape", "secret"), "r")
+	expectDenied(t, err, "read")
+	_, err = fs.Open(filepath.Join(root, "data", "new.txt"), "w")
+	expectDenied(t, err, "write")
+
+	h, err := fs.Open(filepath.Join(root, "out", "new.txt"), "w")
+	if err != nil {
+		t.Fatalf("write allowed file: %v", err)
+	}
+	fs.Close(h)
+	expectDenied(t, fs.Copy(filepath.Join(root, "out", "new.txt"), filepath.Join(root, "data", "copy")), "write")
+	expectDenied(t, fs.Rename(filepath.Join(root, "out", "new.txt"), filepath.Join(outside, "moved")), "write")
+
+	matches, err := fs.Glob(root, "*/*")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	for _, m := range matches {
+		if m == filepath.Join("data", "escape") {
+			t.Fatalf("glob returned a path outside the read roots: %v", matches)
+		}
+	}
+
+	expectDenied(t, env.OS().Chdir(outside), "read")
+
+	if err := env.SetPermissions(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(filepath.Join(outside, "secret")); err != nil {
+		t.Fatalf("expected no restrictions after clearing permissions: %v", err)
+	}
+}
+
+func TestPermissionsNet(t *testing.T) {
+	env := DefaultEnv()
+	if err := env.SetPermissions(&Permissions{
+		Connect: []string{"api.local:443", "db.local"},
+		Listen:  []string{"*:0"},
+	}); err != nil {
+		t.Fatalf("set permissions: %v", err)
+	}
+	sb := env.sandbox
+
+	allowed := []struct {
+		host string
+		port int
+	}{{"api.local", 443}, {"API.local", 443}, {"db.local", 5432}}
+	for _, a := range allowed {
+		if err := sb.checkConnect(a.host, a.port); err != nil {
+			t.Errorf("connect %s:%d: %v", a.host, a.port, err)
+		}
+	}
+	expectDenied(t, sb.checkConnect("api.local", 80), "connect")
+	expectDenied(t, sb.checkURL("http://api.local/"), "connect")
+	if err := sb.checkURL("https://api.local/v1"); err != nil {
+		t.Errorf("https url: %v", err)
+	}
+
+	_, err := env.Net().Connect("127.0.0.1", 1)
+	expectDenied(t, err, "connect")
+	_, err = env.HTTP().Request("GET", "http://127.0.0.1:1/", nil, nil)
+	expectDenied(t, err, "connect")
+	_, err = env.HTTP().Listen("127.0.0.1", 8080)
+	expectDenied(t, err, "listen")
+	ln, err := env.Net().Listen("127.0.0.1", 0)
+	if err != nil {
+		t.Fatalf("listen on allowed port: %v", err)
+	}
+	env.Net().Close(ln)
+
+	if err := env.SetPermissions(&Permissions{Connect: []string{"host:http"}}); err == nil {
+		t.Fatal("expected error for invalid port")
+	}
+}
+
+func TestPermissionsServices(t *testing.T) {
+	env := DefaultEnv()
+	if err := env.SetPermissions(&Permissions{}); err != nil {
+		t.Fatalf("set permissions: %v", err)
+	}
+	_, err := env.SQL().SqliteConnect(":memory:")
+	expectDenied(t, err, "sql")
+	_, err = env.Process().Run(&builtins.ProcessSpec{Path: "true"})
+	expectDenied(t, err, "run")
+	_, _, err = env.OS().Getenv("HOME")
+	expectDenied(t, err, "env")
+	_, err = env.OS().Environ()
+	expectDenied(t, err, "env")
+
+	if err := env.SetPermissions(&Permissions{SQL: true, Env: true}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := env.SQL().SqliteConnect(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite with sql allowed: %v", err)
+	}
+	env.SQL().SqliteClose(h)
+	_, err = env.SQL().SqliteConnect(filepath.Join(t.TempDir(), "app.db"))
+	expectDenied(t, err, "write")
+	if _, _, err := env.OS().Getenv("HOME"); err != nil {
+		t.Fatalf("getenv with env allowed: %v", err)
+	}
+}
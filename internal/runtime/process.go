@@ -0,0 +1,350 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"avenir/internal/runtime/builtins"
+)
+
+// processWaitDelay bounds how long Wait keeps copying output after a killed
+// command exits, in case a grandchild still holds its pipes open.
+const processWaitDelay = time.Second
+
+type processService struct {
+	nextID uint64
+	mu     sync.Mutex
+	procs  map[uint64]*processEntry
+
+	sandbox *sandbox
+}
+
+// processEntry is a started command. Its stdio are OS pipes; the parent
+// ends are closed as each stream reaches EOF, and the entry is dropped once
+// the process has been waited for and both output streams are closed.
+type processEntry struct {
+	cmd    *exec.Cmd
+	stdin  *os.File
+	stdout *os.File
+	stderr *os.File
+
+	mu        sync.Mutex
+	piped     bool // stdout was handed to another process
+	outClosed bool
+	errClosed bool
+	waited    bool
+
+	done     chan struct{}
+	code     int
+	waitErr  error
+	timedOut atomic.Bool
+}
+
+func newProcessService() *processService {
+	return &processService{procs: make(map[uint64]*processEntry)}
+}
+
+func (p *processService) command(ctx context.Context, spec *builtins.ProcessSpec) *exec.Cmd {
+	var cmd *exec.Cmd
+	if ctx != nil {
+		cmd = exec.CommandContext(ctx, spec.Path, spec.Args...)
+		cmd.WaitDelay = processWaitDelay
+	} else {
+		cmd = exec.Command(spec.Path, spec.Args...)
+	}
+	cmd.Dir = spec.Dir
+	if spec.ClearEnv || len(spec.Env) > 0 {
+		cmd.Env = processEnv(spec)
+	}
+	return cmd
+}
+
+func processEnv(spec *builtins.ProcessSpec) []string {
+	vars := make(map[string]string)
+	if !spec.ClearEnv {
+		for _, kv := range os.Environ() {
+			if key, val, ok := strings.Cut(kv, "="); ok && key != "" {
+				vars[key] = val
+			}
+		}
+	}
+	for k, v := range spec.Env {
+		vars[k] = v
+	}
+	env := make([]string, 0, len(vars))
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func (p *processService) Run(spec *builtins.ProcessSpec) (*builtins.ProcessResult, error) {
+	if err := p.sandbox.checkProcess(spec.Path); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+	cmd := p.command(ctx, spec)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(spec.Stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result := &builtins.ProcessResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	if cmd.ProcessState == nil {
+		return nil, err
+	}
+	result.Code = cmd.ProcessState.ExitCode()
+	result.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	return result, nil
+}
+
+func (p *processService) Start(spec *builtins.ProcessSpec) ([]byte, error) {
+	if err := p.sandbox.checkProcess(spec.Path); err != nil {
+		return nil, err
+	}
+	cmd := p.command(nil, spec)
+	entry := &processEntry{cmd: cmd, done: make(chan struct{})}
+
+	var childFiles []*os.File
+	if spec.StdinFrom != nil {
+		src, err := p.lookup(spec.StdinFrom)
+		if err != nil {
+			return nil, err
+		}
+		src.mu.Lock()
+		if src.piped || src.outClosed {
+			src.mu.Unlock()
+			return nil, fmt.Errorf("stdout of process %d is no longer available", src.cmd.Process.Pid)
+		}
+		src.piped = true
+		src.mu.Unlock()
+		cmd.Stdin = src.stdout
+		childFiles = append(childFiles, src.stdout)
+	} else {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdin = r
+		entry.stdin = w
+		childFiles = append(childFiles, r)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		closeFiles(childFiles...)
+		closeFiles(entry.stdin)
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		closeFiles(childFiles...)
+		closeFiles(entry.stdin, outR, outW)
+		return nil, err
+	}
+	cmd.Stdout = outW
+	cmd.Stderr = errW
+	entry.stdout = outR
+	entry.stderr = errR
+	childFiles = append(childFiles, outW, errW)
+
+	startErr := cmd.Start()
+	// The child holds its own copies; closing ours lets EOF propagate.
+	closeFiles(childFiles...)
+	if startErr != nil {
+		closeFiles(entry.stdin, outR, errR)
+		return nil, startErr
+	}
+
+	if spec.Timeout > 0 {
+		timer := time.AfterFunc(spec.Timeout, func() {
+			entry.timedOut.Store(true)
+			cmd.Process.Kill()
+		})
+		go func() {
+			<-entry.done
+			timer.Stop()
+		}()
+	}
+	go func() {
+		entry.waitErr = cmd.Wait()
+		entry.code = cmd.ProcessState.ExitCode()
+		var exitErr *exec.ExitError
+		if errors.As(entry.waitErr, &exitErr) {
+			entry.waitErr = nil
+		}
+		close(entry.done)
+	}()
+
+	id := atomic.AddUint64(&p.nextID, 1)
+	p.mu.Lock()
+	p.procs[id] = entry
+	p.mu.Unlock()
+	return encodeHandle(id), nil
+}
+
+func (p *processService) lookup(handle []byte) (*processEntry, error) {
+	id, err := decodeHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	entry := p.procs[id]
+	p.mu.Unlock()
+	if entry == nil {
+		return nil, fmt.Errorf("invalid process handle")
+	}
+	return entry, nil
+}
+
+// release drops the entry once nothing more can be read from it.
+func (p *processService) release(handle []byte, entry *processEntry) {
+	entry.mu.Lock()
+	finished := entry.waited && (entry.outClosed || entry.piped) && entry.errClosed
+	entry.mu.Unlock()
+	if !finished {
+		return
+	}
+	if id, err := decodeHandle(handle); err == nil {
+		p.mu.Lock()
+		delete(p.procs, id)
+		p.mu.Unlock()
+	}
+}
+
+func (p *processService) Pid(handle []byte) (int, error) {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return 0, err
+	}
+	return entry.cmd.Process.Pid, nil
+}
+
+func (p *processService) Write(handle []byte, data []byte) (int, error) {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return 0, err
+	}
+	if entry.stdin == nil {
+		return 0, fmt.Errorf("stdin is not writable")
+	}
+	return entry.stdin.Write(data)
+}
+
+func (p *processService) CloseStdin(handle []byte) error {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return err
+	}
+	if entry.stdin == nil {
+		return nil
+	}
+	err = entry.stdin.Close()
+	if errors.Is(err, os.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (p *processService) Read(handle []byte, stream string, n int) ([]byte, error) {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("read size must be positive")
+	}
+	var f *os.File
+	var closed *bool
+	entry.mu.Lock()
+	switch stream {
+	case "stdout":
+		if entry.piped {
+			entry.mu.Unlock()
+			return nil, fmt.Errorf("stdout is piped to another process")
+		}
+		f, closed = entry.stdout, &entry.outClosed
+	case "stderr":
+		f, closed = entry.stderr, &entry.errClosed
+	default:
+		entry.mu.Unlock()
+		return nil, fmt.Errorf("unknown stream %q", stream)
+	}
+	if *closed {
+		entry.mu.Unlock()
+		return []byte{}, nil
+	}
+	entry.mu.Unlock()
+
+	buf := make([]byte, n)
+	read, err := f.Read(buf)
+	if read > 0 {
+		return buf[:read], nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	entry.mu.Lock()
+	*closed = true
+	entry.mu.Unlock()
+	f.Close()
+	p.release(handle, entry)
+	return []byte{}, nil
+}
+
+func (p *processService) Wait(handle []byte) (*builtins.ProcessResult, error) {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+	<-entry.done
+	closeFiles(entry.stdin)
+	entry.mu.Lock()
+	entry.waited = true
+	entry.mu.Unlock()
+	p.release(handle, entry)
+	if entry.waitErr != nil {
+		return nil, entry.waitErr
+	}
+	return &builtins.ProcessResult{Code: entry.code, TimedOut: entry.timedOut.Load()}, nil
+}
+
+func (p *processService) Kill(handle []byte) error {
+	entry, err := p.lookup(handle)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-entry.done:
+		return nil
+	default:
+	}
+	if err := entry.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+func closeFiles(files ...*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
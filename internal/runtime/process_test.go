@@ -0,0 +1,112 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"avenir/internal/runtime/builtins"
+)
+
+func TestProcessRunCapturesOutput(t *testing.T) {
+	svc := DefaultEnv().Process()
+	res, err := svc.Run(&builtins.ProcessSpec{
+		Path:  "sh",
+		Args:  []string{"-c", "read line; echo \"$line $GREETING\"; echo oops >&2; exit 3"},
+		Env:   map[string]string{"GREETING": "world"},
+		Stdin: []byte("hello\n"),
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if res.Code != 3 || string(res.Stdout) != "hello world\n" || string(res.Stderr) != "oops\n" || res.TimedOut {
+		t.Fatalf("unexpected result: code=%d stdout=%q stderr=%q timedOut=%v", res.Code, res.Stdout, res.Stderr, res.TimedOut)
+	}
+
+	if _, err := svc.Run(&builtins.ProcessSpec{Path: "avenir-no-such-command"}); err == nil {
+		t.Fatal("expected error for missing command")
+	}
+}
+
+func TestProcessRunTimeout(t *testing.T) {
+	svc := DefaultEnv().Process()
+	start := time.Now()
+	res, err := svc.Run(&builtins.ProcessSpec{Path: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !res.TimedOut || res.Code == 0 {
+		t.Fatalf("expected timed out result, got code=%d timedOut=%v", res.Code, res.TimedOut)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("timeout was not enforced, took %v", elapsed)
+	}
+}
+
+func TestProcessStartPipe(t *testing.T) {
+	svc := DefaultEnv().Process()
+	src, err := svc.Start(&builtins.ProcessSpec{Path: "cat"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	dst, err := svc.Start(&builtins.ProcessSpec{Path: "tr", Args: []string{"a-z", "A-Z"}, StdinFrom: src})
+	if err != nil {
+		t.Fatalf("start pipe: %v", err)
+	}
+	if _, err := svc.Read(src, "stdout", 16); err == nil {
+		t.Fatal("expected error reading piped stdout")
+	}
+	if _, err := svc.Write(src, []byte("piped text\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := svc.CloseStdin(src); err != nil {
+		t.Fatalf("close stdin: %v", err)
+	}
+
+	var out strings.Builder
+	for {
+		chunk, err := svc.Read(dst, "stdout", 4)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		out.Write(chunk)
+	}
+	if out.String() != "PIPED TEXT\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	for _, h := range [][]byte{src, dst} {
+		res, err := svc.Wait(h)
+		if err != nil || res.Code != 0 {
+			t.Fatalf("wait: code=%v err=%v", res, err)
+		}
+	}
+}
+
+func TestProcessKill(t *testing.T) {
+	svc := DefaultEnv().Process()
+	h, err := svc.Start(&builtins.ProcessSpec{Path: "sleep", Args: []string{"10"}})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := svc.Kill(h); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+	res, err := svc.Wait(h)
+	if err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if res.Code != -1 {
+		t.Fatalf("expected code -1 after kill, got %d", res.Code)
+	}
+}
+
+func TestDisableProcess(t *testing.T) {
+	env := DefaultEnv()
+	env.DisableProcess()
+	if env.Process() != nil {
+		t.Fatal("expected process service to be disabled")
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"avenir/internal/runtime/builtins"
 	// Import all builtin packages to trigger their init() functions for self-registration
+	_ "avenir/internal/runtime/builtins/bignum"
 	_ "avenir/internal/runtime/builtins/bytes"
 	_ "avenir/internal/runtime/builtins/collections"
 	_ "avenir/internal/runtime/builtins/crypto"
@@ -15,8 +16,13 @@ import (
 	_ "avenir/internal/runtime/builtins/http"
 	_ "avenir/internal/runtime/builtins/io"
 	_ "avenir/internal/runtime/builtins/json"
+	_ "avenir/internal/runtime/builtins/math"
 	_ "avenir/internal/runtime/builtins/meta"
 	_ "avenir/internal/runtime/builtins/net"
+	_ "avenir/internal/runtime/builtins/os"
+	_ "avenir/internal/runtime/builtins/process"
+	_ "avenir/internal/runtime/builtins/regex"
+	_ "avenir/internal/runtime/builtins/set"
 	_ "avenir/internal/runtime/builtins/sql"
 	_ "avenir/internal/runtime/builtins/strings"
 	_ "avenir/internal/runtime/builtins/time"
@@ -41,13 +47,12 @@ func init() {
 	}
 }
 
-// CallBuiltin executes a builtin identified by builtins.ID with given args.
+// CallBuiltin executes a builtin with given args.
 // It uses services from Env (IO, FS, etc.).
 // Returns the result value and an error if the call failed.
-func CallBuiltin(env *Env, id builtins.ID, args []value.Value) (value.Value, bool, error) {
-	builtin := builtins.LookupByID(id)
-	if builtin == nil {
-		return value.Value{}, false, fmt.Errorf("unknown builtin id %d", id)
+func CallBuiltin(env *Env, builtin *builtins.Builtin, args []value.Value) (value.Value, bool, error) {
+	if builtin.Call == nil {
+		return value.Value{}, false, fmt.Errorf("builtin %s is async", builtin.Meta.Name)
 	}
 
 	// Convert []value.Value to []interface{} for the Call function
@@ -71,13 +76,9 @@ func CallBuiltin(env *Env, id builtins.ID, args []value.Value) (value.Value, boo
 	return result, true, nil
 }
 
-// CallBuiltinAsync executes an async builtin identified by builtins.ID.
+// CallBuiltinAsync executes an async builtin.
 // Returns an *AsyncHandle that will be resolved/rejected when the I/O completes.
-func CallBuiltinAsync(env *Env, id builtins.ID, args []value.Value) (*AsyncHandle, error) {
-	builtin := builtins.LookupByID(id)
-	if builtin == nil {
-		return nil, fmt.Errorf("unknown async builtin id %d", id)
-	}
+func CallBuiltinAsync(env *Env, builtin *builtins.Builtin, args []value.Value) (*AsyncHandle, error) {
 	if builtin.CallAsync == nil {
 		return nil, fmt.Errorf("builtin %s is not async", builtin.Meta.Name)
 	}
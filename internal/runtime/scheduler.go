@@ -1,6 +1,11 @@
 package runtime
 
-import "sync"
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
 
 // Scheduler manages the ready queue and suspended tasks for the async event loop.
 type Scheduler struct {
@@ -9,6 +14,8 @@ type Scheduler struct {
 	suspended  map[int]*Task
 	nextID     int
 	wakeup     chan struct{}
+	timers     timerHeap
+	ctx        context.Context // stops the event loop once done; may be nil
 }
 
 // NewScheduler creates a new empty Scheduler.
@@ -43,6 +50,26 @@ func (s *Scheduler) Schedule(t *Task) {
 	s.Signal()
 }
 
+// UnwindTasks drops every ready and suspended task and calls their Unwind
+// functions, newest task first.
+func (s *Scheduler) UnwindTasks() {
+	s.mu.Lock()
+	tasks := s.readyQueue
+	for _, t := range s.suspended {
+		tasks = append(tasks, t)
+	}
+	s.readyQueue = nil
+	s.suspended = make(map[int]*Task)
+	s.mu.Unlock()
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID > tasks[j].ID })
+	for _, t := range tasks {
+		if t.Unwind != nil {
+			t.Unwind()
+		}
+	}
+}
+
 // Signal sends a non-blocking signal on the wakeup channel.
 // This wakes the event loop when it is waiting for I/O completion.
 func (s *Scheduler) Signal() {
@@ -52,9 +79,41 @@ func (s *Scheduler) Signal() {
 	}
 }
 
-// WaitForWakeup blocks until a signal arrives on the wakeup channel.
+// SetContext makes the event loop stop with ctx's error once ctx is done.
+func (s *Scheduler) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// Err returns the error of the scheduler's context once it is done.
+func (s *Scheduler) Err() error {
+	if s.ctx == nil {
+		return nil
+	}
+	return s.ctx.Err()
+}
+
+// WaitForWakeup blocks until a signal arrives on the wakeup channel, the
+// earliest pending timer is due or the scheduler's context is done.
 func (s *Scheduler) WaitForWakeup() {
-	<-s.wakeup
+	var done <-chan struct{}
+	if s.ctx != nil {
+		done = s.ctx.Done()
+	}
+	when, ok := s.nextTimer()
+	if !ok {
+		select {
+		case <-s.wakeup:
+		case <-done:
+		}
+		return
+	}
+	wait := time.NewTimer(time.Until(when))
+	defer wait.Stop()
+	select {
+	case <-s.wakeup:
+	case <-wait.C:
+	case <-done:
+	}
 }
 
 // Next dequeues and returns the first ready task, or nil if the queue is empty.
@@ -91,9 +150,10 @@ func (s *Scheduler) HasSuspended() bool {
 	return len(s.suspended) > 0
 }
 
-// IsIdle atomically checks whether the scheduler has no ready and no suspended tasks.
+// IsIdle atomically checks whether the scheduler has no ready tasks, no
+// suspended tasks and no pending timers.
 func (s *Scheduler) IsIdle() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return len(s.readyQueue) == 0 && len(s.suspended) == 0
+	return len(s.readyQueue) == 0 && len(s.suspended) == 0 && len(s.timers) == 0
 }
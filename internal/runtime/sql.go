@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 
+	"avenir/internal/decimal"
 	"avenir/internal/runtime/builtins"
 
 	_ "github.com/lib/pq"
@@ -18,6 +21,8 @@ type sqlService struct {
 	mu     sync.Mutex
 	conns  map[uint64]*sql.DB
 	txs    map[uint64]*sql.Tx
+
+	sandbox *sandbox
 }
 
 func newSQLService() *sqlService {
@@ -32,6 +37,18 @@ func (s *sqlService) nextHandle() uint64 {
 }
 
 func (s *sqlService) PgConnect(host, port, user, password, database string) ([]byte, error) {
+	if err := s.sandbox.checkSQL(); err != nil {
+		return nil, err
+	}
+	if s.sandbox.get() != nil {
+		portNum, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("sql: invalid port %q", port)
+		}
+		if err := s.sandbox.checkConnect(host, portNum); err != nil {
+			return nil, err
+		}
+	}
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, database)
 	db, err := sql.Open("postgres", dsn)
@@ -194,6 +211,14 @@ func (s *sqlService) PgRollback(txHandle []byte) error {
 }
 
 func (s *sqlService) SqliteConnect(path string) ([]byte, error) {
+	if err := s.sandbox.checkSQL(); err != nil {
+		return nil, err
+	}
+	if path != ":memory:" && !strings.HasPrefix(path, "file::memory:") {
+		if err := s.sandbox.checkWrite(path); err != nil {
+			return nil, err
+		}
+	}
 	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("sql: connection error: %w", err)
@@ -238,6 +263,10 @@ func scanRows(rows *sql.Rows) (*builtins.SQLResultData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("sql: query error: %w", err)
 	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("sql: query error: %w", err)
+	}
 	var resultRows []map[string]interface{}
 	for rows.Next() {
 		values := make([]interface{}, len(cols))
@@ -250,7 +279,11 @@ func scanRows(rows *sql.Rows) (*builtins.SQLResultData, error) {
 		}
 		row := make(map[string]interface{}, len(cols))
 		for i, col := range cols {
-			row[col] = normalizeValue(values[i])
+			if isDecimalColumn(colTypes[i]) {
+				row[col] = normalizeDecimal(values[i])
+			} else {
+				row[col] = normalizeValue(values[i])
+			}
 		}
 		resultRows = append(resultRows, row)
 	}
@@ -282,3 +315,34 @@ func normalizeValue(v interface{}) interface{} {
 		return fmt.Sprintf("%v", val)
 	}
 }
+
+// isDecimalColumn reports whether ct is an exact decimal column, such as
+// Postgres NUMERIC or a column declared DECIMAL(10, 2) in SQLite.
+func isDecimalColumn(ct *sql.ColumnType) bool {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	return strings.HasPrefix(name, "NUMERIC") || strings.HasPrefix(name, "DECIMAL")
+}
+
+// normalizeDecimal converts the value of a decimal column to a
+// decimal.Decimal. Drivers return the digits as text, or numbers for
+// SQLite; values that are not numbers are normalized as usual.
+func normalizeDecimal(v interface{}) interface{} {
+	var text string
+	switch val := v.(type) {
+	case int64:
+		return decimal.FromInt(val)
+	case float64:
+		text = strconv.FormatFloat(val, 'f', -1, 64)
+	case []byte:
+		text = string(val)
+	case string:
+		text = val
+	default:
+		return normalizeValue(v)
+	}
+	d, err := decimal.Parse(text)
+	if err != nil {
+		return normalizeValue(v)
+	}
+	return d
+}
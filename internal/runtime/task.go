@@ -18,4 +18,7 @@ type Task struct {
 	Future    *Future
 	Scheduler *Scheduler
 	StepFn    func() (TaskStatus, error)
+	// Unwind, when set, runs the pending defers of a task that will never
+	// finish because another task ended the program.
+	Unwind func()
 }
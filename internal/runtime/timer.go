@@ -0,0 +1,197 @@
+package runtime
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// Timer is a callback scheduled on the event loop by Scheduler.AfterFunc.
+type Timer struct {
+	when  time.Time
+	fn    func()
+	index int // position in the scheduler's timer heap, -1 when not pending
+	sched *Scheduler
+}
+
+// Stop cancels the timer. It reports whether the timer was still pending.
+func (t *Timer) Stop() bool {
+	s := t.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&s.timers, t.index)
+	return true
+}
+
+// timerHeap is a min-heap of timers ordered by deadline.
+type timerHeap []*Timer
+
+func (h timerHeap) Len() int           { return len(h) }
+func (h timerHeap) Less(i, j int) bool { return h[i].when.Before(h[j].when) }
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	t := x.(*Timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
+
+// AfterFunc schedules fn to run on the event loop once d has elapsed.
+// All timers share the loop's single wait, so pending timers cost no goroutines.
+func (s *Scheduler) AfterFunc(d time.Duration, fn func()) *Timer {
+	t := &Timer{
+		when:  time.Now().Add(d),
+		fn:    fn,
+		sched: s,
+	}
+	s.mu.Lock()
+	heap.Push(&s.timers, t)
+	s.mu.Unlock()
+	// Wake the loop so it recomputes its deadline.
+	s.Signal()
+	return t
+}
+
+// RunTimers runs the callbacks of all timers whose deadline has passed.
+func (s *Scheduler) RunTimers() {
+	now := time.Now()
+	var due []*Timer
+	s.mu.Lock()
+	for len(s.timers) > 0 && !s.timers[0].when.After(now) {
+		due = append(due, heap.Pop(&s.timers).(*Timer))
+	}
+	s.mu.Unlock()
+	for _, t := range due {
+		t.fn()
+	}
+}
+
+// nextTimer returns the deadline of the earliest pending timer.
+func (s *Scheduler) nextTimer() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.timers) == 0 {
+		return time.Time{}, false
+	}
+	return s.timers[0].when, true
+}
+
+// timerService implements builtins.Timers. Timers run on the scheduler's heap
+// once an event loop is attached, and fall back to Go timers otherwise.
+type timerService struct {
+	mu     sync.Mutex
+	sched  *Scheduler
+	nextID int
+	timers map[int]*timerEntry
+}
+
+// timerEntry tracks the pending waits of a stoppable timer.
+type timerEntry struct {
+	waits map[*AsyncHandle]func() bool
+}
+
+func newTimerService() *timerService {
+	return &timerService{timers: make(map[int]*timerEntry)}
+}
+
+// afterFunc runs fn after d and returns a function that cancels it.
+func (s *timerService) afterFunc(d time.Duration, fn func()) func() bool {
+	s.mu.Lock()
+	sched := s.sched
+	s.mu.Unlock()
+	if sched != nil {
+		return sched.AfterFunc(d, fn).Stop
+	}
+	return time.AfterFunc(d, fn).Stop
+}
+
+func (s *timerService) Sleep(d time.Duration) builtins.AsyncHandle {
+	ah := NewAsyncHandle()
+	s.afterFunc(d, func() { ah.Resolve(value.Value{}) })
+	return ah
+}
+
+func (s *timerService) NewTimer() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.timers[s.nextID] = &timerEntry{waits: make(map[*AsyncHandle]func() bool)}
+	return s.nextID
+}
+
+func (s *timerService) Wait(id int, d time.Duration) builtins.AsyncHandle {
+	ah := NewAsyncHandle()
+	s.mu.Lock()
+	entry, ok := s.timers[id]
+	s.mu.Unlock()
+	if !ok {
+		// Stopped (or unknown) timers release waiters immediately.
+		ah.Resolve(value.Bool(false))
+		return ah
+	}
+	stop := s.afterFunc(d, func() {
+		s.mu.Lock()
+		delete(entry.waits, ah)
+		s.mu.Unlock()
+		ah.Resolve(value.Bool(true))
+	})
+	s.mu.Lock()
+	if _, live := s.timers[id]; live {
+		entry.waits[ah] = stop
+		s.mu.Unlock()
+		return ah
+	}
+	s.mu.Unlock()
+	// Stopped while the wait was being armed.
+	stop()
+	ah.Resolve(value.Bool(false))
+	return ah
+}
+
+func (s *timerService) Stop(id int) {
+	s.mu.Lock()
+	entry, ok := s.timers[id]
+	delete(s.timers, id)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	for ah, stop := range entry.waits {
+		stop()
+		ah.Resolve(value.Bool(false))
+	}
+}
+
+func (s *timerService) Stopped(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.timers[id]
+	return !ok
+}
+
+// attach routes new timers to the scheduler's heap.
+func (s *timerService) attach(sched *Scheduler) {
+	s.mu.Lock()
+	s.sched = sched
+	s.mu.Unlock()
+}
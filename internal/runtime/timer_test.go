@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerTimersFireInOrder(t *testing.T) {
+	sched := NewScheduler()
+	var order []int
+	for _, ms := range []int{30, 10, 20} {
+		ms := ms
+		sched.AfterFunc(time.Duration(ms)*time.Millisecond, func() {
+			order = append(order, ms)
+		})
+	}
+	cancelled := sched.AfterFunc(15*time.Millisecond, func() {
+		order = append(order, 15)
+	})
+	if !cancelled.Stop() {
+		t.Fatal("expected Stop to report a pending timer")
+	}
+	if cancelled.Stop() {
+		t.Fatal("expected second Stop to report false")
+	}
+
+	if err := RunEventLoop(sched); err != nil {
+		t.Fatalf("event loop error: %v", err)
+	}
+	if len(order) != 3 || order[0] != 10 || order[1] != 20 || order[2] != 30 {
+		t.Fatalf("expected timers to fire as [10 20 30], got %v", order)
+	}
+}
+
+func TestSchedulerManyTimers(t *testing.T) {
+	sched := NewScheduler()
+	fired := 0
+	for i := 0; i < 5000; i++ {
+		sched.AfterFunc(time.Duration(i%20)*time.Millisecond, func() {
+			fired++
+		})
+	}
+	if err := RunEventLoop(sched); err != nil {
+		t.Fatalf("event loop error: %v", err)
+	}
+	if fired != 5000 {
+		t.Fatalf("expected 5000 timers to fire, got %d", fired)
+	}
+	if !sched.IsIdle() {
+		t.Fatal("expected scheduler to be idle")
+	}
+}
@@ -23,6 +23,8 @@ type tlsService struct {
 	conns     map[uint64]*tls.Conn
 	listeners map[uint64]net.Listener
 	certs     map[uint64]*tls.Certificate
+
+	sandbox *sandbox
 }
 
 func newTLSService() *tlsService {
@@ -44,6 +46,9 @@ func (t *tlsService) buildTLSConfig(cfg *builtins.TLSConfigData) (*tls.Config, e
 	tlsCfg := t.secureDefaults()
 
 	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		if err := t.sandbox.checkRead(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, err
+		}
 		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 		if err != nil {
 			return nil, fmt.Errorf("tls: failed to load certificate: %w", err)
@@ -88,6 +93,9 @@ func (t *tlsService) buildTLSConfig(cfg *builtins.TLSConfigData) (*tls.Config, e
 
 	if len(cfg.ClientCAs) > 0 {
 		pool := x509.NewCertPool()
+		if err := t.sandbox.checkRead(cfg.ClientCAs...); err != nil {
+			return nil, err
+		}
 		for _, caFile := range cfg.ClientCAs {
 			pem, err := os.ReadFile(caFile)
 			if err != nil {
@@ -107,6 +115,9 @@ func (t *tlsService) Connect(host string, port int, serverName string) ([]byte,
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("tls: invalid port %d", port)
 	}
+	if err := t.sandbox.checkConnect(host, port); err != nil {
+		return nil, err
+	}
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
 	cfg := t.secureDefaults()
 	if serverName != "" {
@@ -129,6 +140,9 @@ func (t *tlsService) ConnectConfig(host string, port int, cfg *builtins.TLSConfi
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("tls: invalid port %d", port)
 	}
+	if err := t.sandbox.checkConnect(host, port); err != nil {
+		return nil, err
+	}
 	tlsCfg, err := t.buildTLSConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -152,6 +166,12 @@ func (t *tlsService) Listen(host string, port int, certFile, keyFile string) ([]
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("tls: invalid port %d", port)
 	}
+	if err := t.sandbox.checkListen(host, port); err != nil {
+		return nil, err
+	}
+	if err := t.sandbox.checkRead(certFile, keyFile); err != nil {
+		return nil, err
+	}
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return nil, fmt.Errorf("tls: failed to load certificate: %w", err)
@@ -174,6 +194,9 @@ func (t *tlsService) ListenConfig(host string, port int, cfg *builtins.TLSConfig
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("tls: invalid port %d", port)
 	}
+	if err := t.sandbox.checkListen(host, port); err != nil {
+		return nil, err
+	}
 	tlsCfg, err := t.buildTLSConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -194,6 +217,15 @@ func (t *tlsService) ListenAutoTLS(host string, port int, domain, email string)
 	if port < 0 || port > 65535 {
 		return nil, fmt.Errorf("tls: invalid port %d", port)
 	}
+	if err := t.sandbox.checkListen(host, port); err != nil {
+		return nil, err
+	}
+	if err := t.sandbox.checkListen(host, 80); err != nil {
+		return nil, err
+	}
+	if err := t.sandbox.checkWrite("avenir-certs"); err != nil {
+		return nil, err
+	}
 	m := &autocert.Manager{
 		Prompt:     autocert.AcceptTOS,
 		HostPolicy: autocert.HostWhitelist(domain),
@@ -322,6 +354,9 @@ func (t *tlsService) CloseListener(handle []byte) error {
 }
 
 func (t *tlsService) LoadCert(certFile, keyFile string) ([]byte, error) {
+	if err := t.sandbox.checkRead(certFile, keyFile); err != nil {
+		return nil, err
+	}
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return nil, fmt.Errorf("tls: failed to load certificate: %w", err)
@@ -334,6 +369,9 @@ func (t *tlsService) LoadCert(certFile, keyFile string) ([]byte, error) {
 }
 
 func (t *tlsService) LoadCertChain(files []string) ([]byte, error) {
+	if err := t.sandbox.checkRead(files...); err != nil {
+		return nil, err
+	}
 	if len(files) < 2 {
 		return nil, fmt.Errorf("tls: loadCertChain requires at least 2 files (cert + key)")
 	}
@@ -450,6 +488,9 @@ func (t *tlsService) TLSVersion(connHandle []byte) (string, error) {
 }
 
 func (t *tlsService) HTTPSRequest(method, url string, headers map[string]string, body []byte, cfg *builtins.TLSConfigData) (*builtins.HTTPResponseData, error) {
+	if err := t.sandbox.checkURL(url); err != nil {
+		return nil, err
+	}
 	tlsCfg, err := t.buildTLSConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -457,7 +498,7 @@ func (t *tlsService) HTTPSRequest(method, url string, headers map[string]string,
 	transport := &http.Transport{
 		TLSClientConfig: tlsCfg,
 	}
-	client := &http.Client{Transport: transport}
+	client := &http.Client{Transport: transport, CheckRedirect: t.sandbox.checkRedirect}
 	return doHTTPRequest(client, method, url, headers, body)
 }
 
@@ -48,6 +48,7 @@ const (
 	Interface // interface
 	Async     // async
 	Await     // await
+	Spawn     // spawn
 
 	// Type keywords
 	IntType    // int
@@ -193,6 +194,8 @@ func (k Kind) String() string {
 		return "Async"
 	case Await:
 		return "Await"
+	case Spawn:
+		return "Spawn"
 	case IntType:
 		return "IntType"
 	case FloatType:
@@ -308,6 +311,7 @@ var keywords = map[string]Kind{
 	"interface": Interface,
 	"async":     Async,
 	"await":     Await,
+	"spawn":     Spawn,
 
 	"int":    IntType,
 	"float":  FloatType,
@@ -321,6 +325,16 @@ var keywords = map[string]Kind{
 	"dict":   DictType,
 }
 
+// IsKeyword reports whether k is the kind of a keyword or a type name.
+func IsKeyword(k Kind) bool {
+	for _, kind := range keywords {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
 func LookupIdent(lit string) Kind {
 	if kind, ok := keywords[lit]; ok {
 		return kind
@@ -46,12 +46,24 @@ type ModuleInfo struct {
 	Name  string // e.g. "std.io"
 	Prog  *ast.Program
 	Scope *Scope // top-level symbols (functions, etc.)
+	File  string // source file path, recorded in debug info; may be empty
 }
 
 // World represents all modules in a program.
 type World struct {
 	Modules map[string]*ModuleInfo
 	Entry   string // Entry module name
+	// Builtins are the builtin functions and methods available to the
+	// world's modules; nil means builtins.Default().
+	Builtins *builtins.Registry
+}
+
+// BuiltinRegistry returns the world's builtin registry.
+func (w *World) BuiltinRegistry() *builtins.Registry {
+	if w.Builtins == nil {
+		return builtins.Default()
+	}
+	return w.Builtins
 }
 
 // Bindings stores static resolution info for expressions:
@@ -128,6 +140,7 @@ type Checker struct {
 	currentThrows []Type // error types the current function can throw
 
 	bindings *Bindings // optional binding info sink
+	registry *builtins.Registry
 
 	loopDepth      int                   // tracks nesting level of loops for break validation
 	structTypes    map[string]*Struct    // struct name -> Struct type
@@ -142,6 +155,14 @@ type Checker struct {
 	genericFuncs   map[string]*GenericFunc   // generic func name -> definition
 	monomorphized  map[string]bool           // monomorph key -> already generated
 	typeParamScope map[string]Type           // current type parameter bindings (T -> int)
+
+	builtinMethods map[*ast.MemberExpr]builtinMethod // builtin method members -> their builtin
+}
+
+// builtinMethod is a builtin method that a member expression refers to.
+type builtinMethod struct {
+	meta     builtins.Meta
+	receiver Type
 }
 
 // CheckProgram type-checks a program and returns a list of errors (if any).
@@ -179,6 +200,7 @@ func CheckWorld(world *World) []error {
 // CheckWorldWithBindings type-checks all modules in a world and returns bindings.
 func CheckWorldWithBindings(world *World) (*Bindings, []error) {
 	bindings := NewBindings()
+	registry := world.BuiltinRegistry()
 	var allErrors []error
 
 	// Phase 1a: Create scopes and register builtins for each module
@@ -187,6 +209,7 @@ func CheckWorldWithBindings(world *World) (*Bindings, []error) {
 		c := &Checker{
 			global:        modInfo.Scope,
 			bindings:      bindings,
+			registry:      registry,
 			currentModule: modInfo.Name,
 		}
 		c.declareBuiltins()
@@ -204,6 +227,7 @@ func CheckWorldWithBindings(world *World) (*Bindings, []error) {
 		c := &Checker{
 			global:        modInfo.Scope,
 			bindings:      bindings,
+			registry:      registry,
 			currentModule: modInfo.Name,
 		}
 		c.scope = c.global
@@ -246,6 +270,7 @@ func CheckWorldWithBindings(world *World) (*Bindings, []error) {
 		c := &Checker{
 			global:        modInfo.Scope,
 			bindings:      bindings,
+			registry:      registry,
 			currentModule: modInfo.Name,
 		}
 		c.scope = c.global
@@ -286,6 +311,7 @@ func CheckWorldWithBindings(world *World) (*Bindings, []error) {
 		c := &Checker{
 			global:        modInfo.Scope,
 			bindings:      bindings,
+			registry:      registry,
 			currentModule: modInfo.Name,
 		}
 		c.scope = c.global
@@ -361,7 +387,7 @@ func (c *Checker) addError(pos token.Position, msg string, args ...interface{})
 }
 
 func (c *Checker) declareBuiltins() {
-	for _, meta := range builtins.All() {
+	for _, meta := range c.registry.All() {
 		if meta.ReceiverType != builtins.TypeVoid {
 			continue
 		}
@@ -383,7 +409,7 @@ func (c *Checker) builtinFuncTypeFromMeta(meta builtins.Meta) *Func {
 		paramTypes = append(paramTypes, c.typeFromBuiltinTypeRef(p))
 	}
 	res := c.typeFromBuiltinTypeRef(meta.Result)
-	if builtins.IsAsyncBuiltin(meta.ID) {
+	if c.registry.IsAsync(meta.ID) {
 		res = &Future{Inner: res}
 	}
 	return &Func{
@@ -422,10 +448,20 @@ func (c *Checker) typeFromBuiltinTypeRef(tr builtins.TypeRef) Type {
 			valueType = c.typeFromBuiltinTypeRef(tr.Elem[0])
 		}
 		return &Dict{KeyType: keyType, ValueType: valueType}
+	case builtins.TypeSet:
+		var elemType Type = Any
+		if len(tr.Elem) == 1 {
+			elemType = c.typeFromBuiltinTypeRef(tr.Elem[0])
+		}
+		return &Set{ElemType: elemType}
 	case builtins.TypeError:
 		return ErrorType
 	case builtins.TypeBytes:
 		return Bytes
+	case builtins.TypeBigInt:
+		return BigInt
+	case builtins.TypeDecimal:
+		return Decimal
 	case builtins.TypeUnion:
 		variants := make([]Type, 0, len(tr.Elem))
 		for _, v := range tr.Elem {
@@ -1006,20 +1042,49 @@ func (c *Checker) validateDecoratorFunc(dec *ast.Decorator, decFunc *Func, fnTyp
 	}
 }
 
+// asyncFuncCompatible reports whether a function of type actual can stand in for
+// expected when the two differ only in Future wrapping of the result:
+//   - expected returns T, actual returns Future<T> (an async function passed to a
+//     decorator that treats it as a plain callable);
+//   - expected returns Future<T>, actual returns T (a plain callable used where an
+//     async one is expected; call sites spawn it as a task).
+//
+// Parameters must match, recursing into function-typed parameters.
 func (c *Checker) asyncFuncCompatible(expected *Func, actual *Func) bool {
 	if len(expected.ParamTypes) != len(actual.ParamTypes) {
 		return false
 	}
 	for i, p := range expected.ParamTypes {
-		if !Equal(p, actual.ParamTypes[i]) {
+		if Equal(p, actual.ParamTypes[i]) {
+			continue
+		}
+		pf, ok1 := p.(*Func)
+		af, ok2 := actual.ParamTypes[i].(*Func)
+		if !ok1 || !ok2 || !c.asyncFuncCompatible(pf, af) {
 			return false
 		}
 	}
-	fut, ok := actual.Result.(*Future)
-	if !ok {
+	if fut, ok := actual.Result.(*Future); ok && Equal(expected.Result, fut.Inner) {
+		return true
+	}
+	if fut, ok := expected.Result.(*Future); ok && Equal(fut.Inner, actual.Result) {
+		return true
+	}
+	return Equal(expected.Result, actual.Result)
+}
+
+// asyncLiftable reports whether a function value of type src may be used where a
+// function of type dst is expected because dst only adds Future wrapping to the
+// result (fun(A) | T used as fun(A) | Future<T>). Calls through dst spawn a task,
+// so the result is a Future at runtime.
+func (c *Checker) asyncLiftable(dst *Func, src *Func) bool {
+	if _, ok := dst.Result.(*Future); !ok {
 		return false
 	}
-	return Equal(expected.Result, fut.Inner)
+	if _, ok := src.Result.(*Future); ok {
+		return false
+	}
+	return c.asyncFuncCompatible(dst, src)
 }
 
 func (c *Checker) checkGenericDecorator(dec *ast.Decorator, gf *GenericFunc, fnType *Func) *DecoratorInfo {
@@ -1148,9 +1213,15 @@ func (c *Checker) checkFuncLiteral(lit *ast.FuncLiteral) Type {
 		paramTypes = append(paramTypes, pt)
 	}
 	res := c.typeOfTypeNode(lit.Return)
+	// Async literals are typed like async functions: the caller receives Future<T>,
+	// while return statements in the body use T.
+	var fnResult Type = res
+	if lit.IsAsync {
+		fnResult = &Future{Inner: res}
+	}
 	fnType := &Func{
 		ParamTypes: paramTypes,
-		Result:     res,
+		Result:     fnResult,
 	}
 
 	// Create a new scope with current scope as parent
@@ -1216,6 +1287,10 @@ func (c *Checker) typeOfTypeNode(tn ast.TypeNode) Type {
 			return ErrorType
 		case "bytes":
 			return Bytes
+		case "bigint":
+			return BigInt
+		case "decimal":
+			return Decimal
 		default:
 			// Check if it's a type parameter in scope
 			if c.typeParamScope != nil {
@@ -1281,9 +1356,18 @@ func (c *Checker) typeOfTypeNode(tn ast.TypeNode) Type {
 		var keyType Type
 		if t.KeyType != nil {
 			keyType = c.typeOfTypeNode(t.KeyType)
+			if !hashable(keyType, nil) {
+				c.addError(t.KeyType.Pos(), "%s cannot be a dict key type", keyType.String())
+			}
 		}
 		valueType := c.typeOfTypeNode(t.ValueType)
 		return &Dict{KeyType: keyType, ValueType: valueType}
+	case *ast.SetType:
+		elemType := c.typeOfTypeNode(t.ElemType)
+		if !hashable(elemType, nil) {
+			c.addError(t.ElemType.Pos(), "%s cannot be a set element type", elemType.String())
+		}
+		return &Set{ElemType: elemType}
 	case *ast.OptionalType:
 		innerType := c.typeOfTypeNode(t.Inner)
 		return &Optional{Inner: innerType}
@@ -1489,6 +1573,15 @@ func (c *Checker) inferTypeArgs(gf *GenericFunc, argTypes []Type) ([]Type, bool)
 				return unify(t.ValueType, dt.ValueType)
 			}
 			return true
+		case *ast.SetType:
+			st, ok := concrete.(*Set)
+			if !ok {
+				return false
+			}
+			if st.ElemType != nil {
+				return unify(t.ElemType, st.ElemType)
+			}
+			return true
 		case *ast.OptionalType:
 			ot, ok := concrete.(*Optional)
 			if !ok {
@@ -1509,6 +1602,12 @@ func (c *Checker) inferTypeArgs(gf *GenericFunc, argTypes []Type) ([]Type, bool)
 				}
 			}
 			if t.Result != nil && ft.Result != nil {
+				// fun(A) | T passed where fun(A) | Future<T> is expected is lifted to a task.
+				if git, ok := t.Result.(*ast.GenericInstanceType); ok && git.Name == "Future" && len(git.TypeArgs) == 1 {
+					if _, isFut := ft.Result.(*Future); !isFut {
+						return unify(git.TypeArgs[0], ft.Result)
+					}
+				}
 				return unify(t.Result, ft.Result)
 			}
 			return true
@@ -1585,10 +1684,14 @@ func (c *Checker) instantiateGenericFunc(gf *GenericFunc, typeArgs []Type) (*Fun
 		paramTypes = append(paramTypes, pt)
 	}
 	retType := c.typeOfTypeNode(fn.Return)
+	var fnResult Type = retType
+	if fn.IsAsync {
+		fnResult = &Future{Inner: retType}
+	}
 
 	fnType := &Func{
 		ParamTypes: paramTypes,
-		Result:     retType,
+		Result:     fnResult,
 	}
 
 	// Register the monomorphized function in scope if not already done
@@ -1606,6 +1709,7 @@ func (c *Checker) instantiateGenericFunc(gf *GenericFunc, typeArgs []Type) (*Fun
 			Return:   fn.Return,
 			Body:     fn.Body,
 			IsPublic: fn.IsPublic,
+			IsAsync:  fn.IsAsync,
 		}
 
 		_ = c.global.Insert(&Symbol{
@@ -1981,9 +2085,36 @@ func (c *Checker) checkFor(s *ast.ForStmt) {
 
 func (c *Checker) checkForEach(s *ast.ForEachStmt) {
 	listType := c.checkExpr(s.ListExpr)
-	list, ok := listType.(*List)
-	if !ok {
-		c.addError(s.ListExpr.Pos(), "foreach requires a list type, got %s", listType.String())
+
+	// Determine the types of the loop variables
+	var keyType, varType Type
+	switch t := listType.(type) {
+	case *List:
+		keyType = Int
+		if len(t.ElementTypes) == 1 {
+			varType = t.ElementTypes[0]
+		} else {
+			// Multiple element types - use any
+			varType = Any
+		}
+	case *Set:
+		keyType = Int
+		varType = t.ElemType
+		if varType == nil {
+			varType = Any
+		}
+	case *Dict:
+		keyType = t.keyType()
+		switch {
+		case s.KeyName == "":
+			varType = keyType
+		case t.ValueType == nil:
+			varType = Any
+		default:
+			varType = t.ValueType
+		}
+	default:
+		c.addError(s.ListExpr.Pos(), "foreach requires a list, dict or set type, got %s", listType.String())
 		return
 	}
 
@@ -1992,16 +2123,17 @@ func (c *Checker) checkForEach(s *ast.ForEachStmt) {
 	c.scope = NewScope(prevScope)
 	defer func() { c.scope = prevScope }()
 
-	// Determine the type of the loop variable
-	var varType Type
-	if len(list.ElementTypes) == 1 {
-		varType = list.ElementTypes[0]
-	} else {
-		// Multiple element types - use any
-		varType = Any
+	// Bind the loop variables
+	if s.KeyName != "" {
+		if err := c.scope.Insert(&Symbol{
+			Name: s.KeyName,
+			Kind: SymVar,
+			Type: keyType,
+			Node: s,
+		}); err != nil {
+			c.addError(s.KeyPos, "variable %q: %v", s.KeyName, err)
+		}
 	}
-
-	// Bind the loop variable
 	if err := c.scope.Insert(&Symbol{
 		Name: s.VarName,
 		Kind: SymVar,
@@ -2123,6 +2255,8 @@ func (c *Checker) checkExpr(e ast.Expr) Type {
 		resultType = c.checkListLiteral(ex)
 	case *ast.DictLiteral:
 		resultType = c.checkDictLiteral(ex)
+	case *ast.SetLiteral:
+		resultType = c.checkSetLiteral(ex)
 
 	case *ast.CallExpr:
 		resultType = c.checkCall(ex)
@@ -2151,6 +2285,9 @@ func (c *Checker) checkExpr(e ast.Expr) Type {
 	case *ast.AwaitExpr:
 		resultType = c.checkAwait(ex)
 
+	case *ast.SpawnExpr:
+		resultType = c.checkSpawn(ex)
+
 	case *ast.ValuePackExpansion:
 		sym := c.scope.Lookup(ex.Name)
 		if sym == nil {
@@ -2410,10 +2547,13 @@ func (c *Checker) checkMember(m *ast.MemberExpr) Type {
 
 	// Dict key access (after built-in methods)
 	if dictType, ok := xType.(*Dict); ok {
+		if kt := dictType.keyType(); !Equal(kt, String) && !Equal(kt, Any) {
+			c.addError(m.Pos(), "%s has no field %q; use an index for non-string keys", dictType.String(), m.Name)
+		}
 		if lit, ok2 := m.X.(*ast.DictLiteral); ok2 {
 			found := false
 			for _, entry := range lit.Entries {
-				if entry.Key == m.Name {
+				if entry.KeyExpr == nil && entry.Key == m.Name {
 					found = true
 					break
 				}
@@ -2524,6 +2664,7 @@ func (c *Checker) checkBuiltinMethod(m *ast.MemberExpr, receiverType Type) Type
 	var typeKind builtins.TypeKind
 	var found bool
 	var dictType *Dict
+	var setType *Set
 
 	switch t := receiverType.(type) {
 	case *Basic:
@@ -2533,6 +2674,9 @@ func (c *Checker) checkBuiltinMethod(m *ast.MemberExpr, receiverType Type) Type
 	case *Dict:
 		typeKind, found = builtins.TypeDict, true
 		dictType = t
+	case *Set:
+		typeKind, found = builtins.TypeSet, true
+		setType = t
 	default:
 		return nil
 	}
@@ -2542,11 +2686,15 @@ func (c *Checker) checkBuiltinMethod(m *ast.MemberExpr, receiverType Type) Type
 	}
 
 	// Look up built-in method
-	methodBuiltin := builtins.LookupMethod(typeKind, m.Name)
+	methodBuiltin := c.registry.LookupMethod(typeKind, m.Name)
 	if methodBuiltin == nil {
 		return nil
 	}
 	methodMeta := methodBuiltin.Meta
+	if c.builtinMethods == nil {
+		c.builtinMethods = make(map[*ast.MemberExpr]builtinMethod)
+	}
+	c.builtinMethods[m] = builtinMethod{meta: methodMeta, receiver: receiverType}
 
 	// Build function type for the method
 	// For built-in methods, the receiver is the first parameter
@@ -2568,6 +2716,9 @@ func (c *Checker) checkBuiltinMethod(m *ast.MemberExpr, receiverType Type) Type
 		case "values":
 			paramTypes = []Type{dictType}
 			resultType = &List{ElementTypes: []Type{valueType}}
+		case "entries":
+			paramTypes = []Type{dictType}
+			resultType = &List{ElementTypes: []Type{&List{ElementTypes: []Type{keyType, valueType}}}}
 		case "has":
 			paramTypes = []Type{dictType, keyType}
 			resultType = Bool
@@ -2583,12 +2734,21 @@ func (c *Checker) checkBuiltinMethod(m *ast.MemberExpr, receiverType Type) Type
 		default:
 			return nil
 		}
+	} else if setType != nil {
+		var ok bool
+		paramTypes, resultType, ok = setMethodSignature(setType, m.Name)
+		if !ok {
+			return nil
+		}
 	} else {
 		paramTypes = make([]Type, len(methodMeta.Params))
 		for i, p := range methodMeta.Params {
 			paramTypes[i] = c.typeRefToType(p)
 		}
 		resultType = c.typeRefToType(methodMeta.Result)
+		if list, ok := receiverType.(*List); ok {
+			resultType = c.listMethodResult(m, list, resultType)
+		}
 	}
 
 	// Record binding for IR compiler
@@ -2632,6 +2792,10 @@ func (c *Checker) typeRefToType(ref builtins.TypeRef) Type {
 		return ErrorType
 	case builtins.TypeBytes:
 		return Bytes
+	case builtins.TypeBigInt:
+		return BigInt
+	case builtins.TypeDecimal:
+		return Decimal
 	case builtins.TypeList:
 		// Convert element types
 		elemTypes := make([]Type, len(ref.Elem))
@@ -2645,6 +2809,12 @@ func (c *Checker) typeRefToType(ref builtins.TypeRef) Type {
 			valueType = c.typeRefToType(ref.Elem[0])
 		}
 		return &Dict{ValueType: valueType}
+	case builtins.TypeSet:
+		var elemType Type = Any
+		if len(ref.Elem) > 0 {
+			elemType = c.typeRefToType(ref.Elem[0])
+		}
+		return &Set{ElemType: elemType}
 	case builtins.TypeUnion:
 		variants := make([]Type, 0, len(ref.Elem))
 		for _, elem := range ref.Elem {
@@ -2686,8 +2856,21 @@ func (c *Checker) checkListLiteral(lit *ast.ListLiteral) Type {
 }
 
 func (c *Checker) checkDictLiteral(lit *ast.DictLiteral) Type {
-	var valueTypes []Type
+	var keyTypes, valueTypes []Type
+	exprKeys := false
 	for _, entry := range lit.Entries {
+		var kt Type = String
+		if entry.KeyExpr != nil {
+			exprKeys = true
+			kt = c.checkExpr(entry.KeyExpr)
+			if !IsInvalid(kt) && !hashable(kt, nil) {
+				c.addError(entry.KeyExpr.Pos(), "%s cannot be a dict key", kt.String())
+			}
+		}
+		if !IsInvalid(kt) && !containsType(keyTypes, kt) {
+			keyTypes = append(keyTypes, kt)
+		}
+
 		t := c.checkExpr(entry.Value)
 		if IsInvalid(t) {
 			continue
@@ -2714,7 +2897,110 @@ func (c *Checker) checkDictLiteral(lit *ast.DictLiteral) Type {
 		valueType = &Union{Variants: valueTypes}
 	}
 
-	return &Dict{ValueType: valueType}
+	// Literals with only name and string keys keep the string key type; an
+	// empty literal fits any dict.
+	var keyType Type
+	switch {
+	case len(lit.Entries) == 0:
+		keyType = Any
+	case !exprKeys:
+	case len(keyTypes) == 1:
+		keyType = keyTypes[0]
+	case len(keyTypes) > 1:
+		keyType = &Union{Variants: keyTypes}
+	}
+
+	return &Dict{KeyType: keyType, ValueType: valueType}
+}
+
+// checkSetLiteral infers the element type like a list literal does, unless
+// the literal names it; an empty literal without one is a set<any>.
+func (c *Checker) checkSetLiteral(lit *ast.SetLiteral) Type {
+	var elemType Type
+	if lit.ElemType != nil {
+		elemType = c.typeOfTypeNode(lit.ElemType)
+		if !hashable(elemType, nil) {
+			c.addError(lit.ElemType.Pos(), "%s cannot be a set element type", elemType.String())
+		}
+	}
+	var elemTypes []Type
+	for _, e := range lit.Elements {
+		t := c.checkExpr(e)
+		if IsInvalid(t) {
+			continue
+		}
+		if elemType != nil {
+			if !c.assignable(elemType, t) {
+				c.addError(e.Pos(), "set element must be %s, got %s", elemType.String(), t.String())
+			}
+			continue
+		}
+		if !hashable(t, nil) {
+			c.addError(e.Pos(), "%s cannot be a set element", t.String())
+		}
+		if !containsType(elemTypes, t) {
+			elemTypes = append(elemTypes, t)
+		}
+	}
+	if elemType != nil {
+		return &Set{ElemType: elemType}
+	}
+	switch len(elemTypes) {
+	case 0:
+		elemType = Any
+	case 1:
+		elemType = elemTypes[0]
+	default:
+		elemType = &Union{Variants: elemTypes}
+	}
+	return &Set{ElemType: elemType}
+}
+
+func containsType(ts []Type, t Type) bool {
+	for _, u := range ts {
+		if Equal(u, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashable reports whether values of type t can be dict keys: ints, bools,
+// strings, bytes, and structs whose fields are all hashable. Values of type
+// any are checked when the key is used.
+func hashable(t Type, seen map[*Struct]bool) bool {
+	switch t := t.(type) {
+	case *Basic:
+		switch t.Kind {
+		case BasicInt, BasicBool, BasicString, BasicBytes, BasicAny, BasicInvalid:
+			return true
+		}
+		return false
+	case *Struct:
+		if seen[t] {
+			return true
+		}
+		if seen == nil {
+			seen = map[*Struct]bool{}
+		}
+		seen[t] = true
+		for _, f := range t.Fields {
+			if !hashable(f.Type, seen) {
+				return false
+			}
+		}
+		return true
+	case *Union:
+		for _, v := range t.Variants {
+			if !hashable(v, seen) {
+				return false
+			}
+		}
+		return true
+	case *TypeVar:
+		return true
+	}
+	return false
 }
 
 func (c *Checker) checkStructLiteral(lit *ast.StructLiteral) Type {
@@ -2834,8 +3120,11 @@ func (c *Checker) isCompileTimeConstant(e ast.Expr) bool {
 		}
 		return true
 	case *ast.DictLiteral:
-		// Dict literals are compile-time constants if all values are compile-time constants
+		// Dict literals are compile-time constants if all keys and values are compile-time constants
 		for _, entry := range expr.Entries {
+			if entry.KeyExpr != nil && !c.isCompileTimeConstant(entry.KeyExpr) {
+				return false
+			}
 			if !c.isCompileTimeConstant(entry.Value) {
 				return false
 			}
@@ -2937,17 +3226,22 @@ func (c *Checker) checkInferredGenericCall(call *ast.CallExpr, gf *GenericFunc)
 	}
 
 	if c.bindings != nil {
-		if ident, ok := call.Callee.(*ast.IdentExpr); ok {
-			var monoNode *ast.FunDecl
-			if decl, ok2 := c.bindings.MonomorphizedFuncs[monoName]; ok2 {
-				monoNode = decl
-			}
-			c.bindings.Idents[ident] = &Symbol{
-				Name: monoName,
-				Kind: SymFunc,
-				Type: fnType,
-				Node: monoNode,
-			}
+		var monoNode *ast.FunDecl
+		if decl, ok := c.bindings.MonomorphizedFuncs[monoName]; ok {
+			monoNode = decl
+		}
+		monoSym := &Symbol{
+			Name: monoName,
+			Kind: SymFunc,
+			Type: fnType,
+			Node: monoNode,
+		}
+		switch callee := call.Callee.(type) {
+		case *ast.IdentExpr:
+			c.bindings.Idents[callee] = monoSym
+		case *ast.MemberExpr:
+			// Module-qualified generic call, e.g. task.map(items, fn, 4)
+			c.bindings.Members[callee] = monoSym
 		}
 	}
 
@@ -3014,10 +3308,12 @@ func (c *Checker) checkCall(call *ast.CallExpr) Type {
 		// Check if this is a builtin call (by identifier name)
 		var builtinParamNames []string
 		var builtinName string
+		var builtinOptional int
 		if ident, ok := call.Callee.(*ast.IdentExpr); ok {
-			if builtin := builtins.LookupByName(ident.Name); builtin != nil {
+			if builtin := c.registry.LookupByName(ident.Name); builtin != nil {
 				builtinParamNames = builtin.Meta.ParamNames
 				builtinName = ident.Name
+				builtinOptional = builtin.Meta.Optional
 			}
 		}
 
@@ -3067,8 +3363,9 @@ func (c *Checker) checkCall(call *ast.CallExpr) Type {
 				}
 			}
 
-			// Check for missing required parameters (builtins don't have defaults)
-			for i := 0; i < nParams; i++ {
+			// Check for missing required parameters (builtins don't have
+			// defaults; omitted optional parameters are passed as none)
+			for i := 0; i < nParams-builtinOptional; i++ {
 				if !provided[i] {
 					c.addError(call.Pos(), "missing argument for required parameter %q", paramNames[i])
 				}
@@ -3091,10 +3388,15 @@ func (c *Checker) checkCall(call *ast.CallExpr) Type {
 		// Not a builtin - check if this is a method call (MemberExpr with receiver parameter)
 		// For method calls (both instance methods and built-in methods), the receiver is implicitly the first argument
 		var effectiveArgs []ast.Expr
+		var method builtinMethod
+		isBuiltinMethod := false
 		if member, ok := call.Callee.(*ast.MemberExpr); ok {
+			method, isBuiltinMethod = c.builtinMethods[member]
 			// Check if this looks like a method call
-			// (function type has one more parameter than call.Args)
-			if len(fnType.ParamTypes) == len(call.Args)+1 {
+			// (function type has one more parameter than call.Args, or
+			// than the arguments up to the optional ones of a builtin)
+			optional := method.meta.Optional
+			if n := len(call.Args) + 1; n <= len(fnType.ParamTypes) && n >= len(fnType.ParamTypes)-optional {
 				// This is likely a method call (instance method or built-in method) - prepend receiver
 				effectiveArgs = append([]ast.Expr{member.X}, call.Args...)
 			} else {
@@ -3112,19 +3414,24 @@ func (c *Checker) checkCall(call *ast.CallExpr) Type {
 			}
 		}
 		// Positional-only logic with effective arguments
-		if len(effectiveArgs) != len(fnType.ParamTypes) {
+		if len(effectiveArgs) > len(fnType.ParamTypes) || len(effectiveArgs) < len(fnType.ParamTypes)-method.meta.Optional {
 			c.addError(call.Pos(), "function expects %d arguments, got %d",
 				len(fnType.ParamTypes), len(effectiveArgs))
 			return fnType.Result
 		}
+		argTypes := make([]Type, len(effectiveArgs))
 		for i, arg := range effectiveArgs {
 			argType := c.checkExpr(arg)
+			argTypes[i] = argType
 			paramType := fnType.ParamTypes[i]
 			if !c.assignable(paramType, argType) {
 				c.addError(arg.Pos(), "cannot use expression of type %s as argument %d of type %s",
 					argType.String(), i+1, paramType.String())
 			}
 		}
+		if list, ok := method.receiver.(*List); ok && isBuiltinMethod {
+			return c.listMethodCallResult(call, list, method.meta.Name, argTypes, fnType.Result)
+		}
 		return fnType.Result
 	}
 
@@ -3264,8 +3571,8 @@ func (c *Checker) checkIndex(idx *ast.IndexExpr) Type {
 	// Support indexing for dict (returns value type)
 	if dictType, ok := xType.(*Dict); ok {
 		indexType := c.checkExpr(idx.Index)
-		if !Equal(indexType, String) {
-			c.addError(idx.Index.Pos(), "dict index must be string, got %s", indexType.String())
+		if !c.assignable(dictType.keyType(), indexType) {
+			c.addError(idx.Index.Pos(), "dict index must be %s, got %s", dictType.keyType().String(), indexType.String())
 		}
 		if dictType.ValueType == nil {
 			return Any
@@ -3318,10 +3625,10 @@ func (c *Checker) checkUnary(u *ast.UnaryExpr) Type {
 		if Equal(xType, Int) {
 			return Int
 		}
-		if Equal(xType, Float) {
-			return Float
+		if Equal(xType, Float) || Equal(xType, BigInt) || Equal(xType, Decimal) {
+			return xType
 		}
-		c.addError(u.Pos(), "unary - expects int or float, got %s", xType.String())
+		c.addError(u.Pos(), "unary - expects a number, got %s", xType.String())
 		return Invalid
 	default:
 		c.addError(u.Pos(), "unsupported unary operator %s", u.Op)
@@ -3338,6 +3645,9 @@ func (c *Checker) checkBinary(b *ast.BinaryExpr) Type {
 		if Equal(left, String) && Equal(right, String) {
 			return String
 		}
+		if t, ok := c.bigArithType(b, left, right); ok {
+			return t
+		}
 		leftIsInt := Equal(left, Int)
 		leftIsFloat := Equal(left, Float)
 		rightIsInt := Equal(right, Int)
@@ -3364,6 +3674,9 @@ func (c *Checker) checkBinary(b *ast.BinaryExpr) Type {
 				b.Op, left.String(), right.String())
 			return Invalid
 		}
+		if t, ok := c.bigArithType(b, left, right); ok {
+			return t
+		}
 		// Support int/int -> int, float/float -> float, int/float or float/int -> float
 		leftIsInt := Equal(left, Int)
 		leftIsFloat := Equal(left, Float)
@@ -3387,6 +3700,12 @@ func (c *Checker) checkBinary(b *ast.BinaryExpr) Type {
 				b.Op, left.String(), right.String())
 			return Invalid
 		}
+		if t, ok := c.bigArithType(b, left, right); ok {
+			if IsInvalid(t) {
+				return Invalid
+			}
+			return Bool
+		}
 		// Support comparisons between int and float
 		leftIsInt := Equal(left, Int)
 		leftIsFloat := Equal(left, Float)
@@ -3445,6 +3764,36 @@ func (c *Checker) checkBinary(b *ast.BinaryExpr) Type {
 	}
 }
 
+// bigArithType types arithmetic or comparison operator b when left or
+// right is a bigint or a decimal. The other operand may be an int, which
+// is converted, a bigint or a decimal; a decimal operand makes the result a
+// decimal, and only integers have a modulo. It reports false when neither
+// operand is a bigint or a decimal.
+func (c *Checker) bigArithType(b *ast.BinaryExpr, left, right Type) (Type, bool) {
+	isBig := func(t Type) bool { return Equal(t, BigInt) || Equal(t, Decimal) }
+	if !isBig(left) && !isBig(right) {
+		return nil, false
+	}
+	for _, t := range []Type{left, right} {
+		if !isBig(t) && !Equal(t, Int) {
+			if !IsInvalid(t) {
+				c.addError(b.Pos(), "operator %s is not defined for types %s and %s",
+					b.Op, left.String(), right.String())
+			}
+			return Invalid, true
+		}
+	}
+	if Equal(left, Decimal) || Equal(right, Decimal) {
+		if b.Op == token.Percent {
+			c.addError(b.Pos(), "operator %% is not defined for decimals, got (%s, %s)",
+				left.String(), right.String())
+			return Invalid, true
+		}
+		return Decimal, true
+	}
+	return BigInt, true
+}
+
 // ----- Assignability Rule -----
 
 func (c *Checker) assignable(dst, src Type) bool {
@@ -3544,6 +3893,23 @@ func (c *Checker) assignable(dst, src Type) bool {
 		return c.assignable(dstValue, srcValue)
 	}
 
+	// set<T> := set<U>
+	dstSet, dstIsSet := dst.(*Set)
+	srcSet, srcIsSet := src.(*Set)
+	if dstIsSet && srcIsSet {
+		if dstSet.ElemType == nil || srcSet.ElemType == nil {
+			return true
+		}
+		return c.assignable(dstSet.ElemType, srcSet.ElemType)
+	}
+
+	// fun(A) | Future<T> := fun(A) | T (plain callable spawned as a task)
+	dstFunc, dstIsFunc := dst.(*Func)
+	srcFunc, srcIsFunc := src.(*Func)
+	if dstIsFunc && srcIsFunc {
+		return c.asyncLiftable(dstFunc, srcFunc)
+	}
+
 	// Interface satisfaction: if dst is an interface, check if src satisfies it
 	if dstInterface, ok := dst.(*Interface); ok {
 		return c.satisfiesInterface(src, dstInterface)
@@ -3666,6 +4032,9 @@ func (c *Checker) findMethod(typ Type, methodName string) *Method {
 	case *Dict:
 		// Check built-in methods for dicts
 		return c.findBuiltinMethodForDict(t, methodName)
+	case *Set:
+		// Check built-in methods for sets
+		return c.findBuiltinMethodForSet(t, methodName)
 
 	default:
 		return nil
@@ -3679,7 +4048,7 @@ func (c *Checker) findBuiltinMethod(typ *Basic, methodName string) *Method {
 		return nil
 	}
 
-	methodBuiltin := builtins.LookupMethod(typeKind, methodName)
+	methodBuiltin := c.registry.LookupMethod(typeKind, methodName)
 	if methodBuiltin == nil {
 		return nil
 	}
@@ -3702,7 +4071,7 @@ func (c *Checker) findBuiltinMethod(typ *Basic, methodName string) *Method {
 
 // findBuiltinMethodForList finds a built-in method on a list type.
 func (c *Checker) findBuiltinMethodForList(typ *List, methodName string) *Method {
-	methodBuiltin := builtins.LookupMethod(builtins.TypeList, methodName)
+	methodBuiltin := c.registry.LookupMethod(builtins.TypeList, methodName)
 	if methodBuiltin == nil {
 		return nil
 	}
@@ -3725,7 +4094,7 @@ func (c *Checker) findBuiltinMethodForList(typ *List, methodName string) *Method
 
 // findBuiltinMethodForDict finds a built-in method on a dict type.
 func (c *Checker) findBuiltinMethodForDict(typ *Dict, methodName string) *Method {
-	methodBuiltin := builtins.LookupMethod(builtins.TypeDict, methodName)
+	methodBuiltin := c.registry.LookupMethod(builtins.TypeDict, methodName)
 	if methodBuiltin == nil {
 		return nil
 	}
@@ -3746,6 +4115,9 @@ func (c *Checker) findBuiltinMethodForDict(typ *Dict, methodName string) *Method
 	case "values":
 		paramTypes = []Type{typ}
 		resultType = &List{ElementTypes: []Type{valueType}}
+	case "entries":
+		paramTypes = []Type{typ}
+		resultType = &List{ElementTypes: []Type{&List{ElementTypes: []Type{keyType, valueType}}}}
 	case "has":
 		paramTypes = []Type{typ, keyType}
 		resultType = Bool
@@ -3770,6 +4142,145 @@ func (c *Checker) findBuiltinMethodForDict(typ *Dict, methodName string) *Method
 	}
 }
 
+// findBuiltinMethodForSet finds a built-in method on a set type.
+func (c *Checker) findBuiltinMethodForSet(typ *Set, methodName string) *Method {
+	if c.registry.LookupMethod(builtins.TypeSet, methodName) == nil {
+		return nil
+	}
+	paramTypes, resultType, ok := setMethodSignature(typ, methodName)
+	if !ok {
+		return nil
+	}
+	return &Method{
+		Name:       methodName,
+		Receiver:   typ,
+		ParamTypes: paramTypes,
+		Result:     resultType,
+		IsStatic:   false,
+	}
+}
+
+// setMethodSignature returns the parameter types, receiver first, and the
+// result type of set method name specialized to the element type of typ.
+func setMethodSignature(typ *Set, name string) ([]Type, Type, bool) {
+	elemType := typ.ElemType
+	if elemType == nil {
+		elemType = Any
+	}
+	switch name {
+	case "length":
+		return []Type{typ}, Int, true
+	case "add", "remove", "has":
+		return []Type{typ, elemType}, Bool, true
+	case "union", "intersection", "difference":
+		return []Type{typ, typ}, typ, true
+	case "isSubsetOf", "isSupersetOf":
+		return []Type{typ, typ}, Bool, true
+	case "toList":
+		return []Type{typ}, &List{ElementTypes: []Type{elemType}}, true
+	}
+	return nil, nil, false
+}
+
+// listElemType returns the element type of a list: its single element type,
+// a union of several, or any for an unknown one.
+func listElemType(l *List) Type {
+	switch len(l.ElementTypes) {
+	case 0:
+		return Any
+	case 1:
+		return l.ElementTypes[0]
+	}
+	return &Union{Variants: l.ElementTypes}
+}
+
+// listMethodResult specializes the result type of list method m, which the
+// registry declares with loose element types, to the element type of list.
+func (c *Checker) listMethodResult(m *ast.MemberExpr, list *List, result Type) Type {
+	elemType := listElemType(list)
+	switch m.Name {
+	case "toSet":
+		if !hashable(elemType, nil) {
+			c.addError(m.Pos(), "%s cannot be a set element", elemType.String())
+		}
+		return &Set{ElemType: elemType}
+	case "sort", "sortBy", "unique":
+		return list
+	case "chunk":
+		return &List{ElementTypes: []Type{list}}
+	case "find":
+		return &Optional{Inner: elemType}
+	case "groupBy":
+		return &Dict{KeyType: Any, ValueType: list}
+	case "min", "max":
+		if !orderable(elemType) {
+			c.addError(m.Pos(), "list.%s requires ordered elements (int, float, string or bytes), got %s", m.Name, elemType.String())
+		}
+		return elemType
+	case "sum":
+		switch {
+		case Equal(elemType, Int):
+			return Int
+		case Equal(elemType, Any):
+			return Any
+		case numeric(elemType):
+			return Float
+		}
+		c.addError(m.Pos(), "list.sum requires int or float elements, got %s", elemType.String())
+		return Invalid
+	case "join":
+		if !Equal(elemType, String) && !Equal(elemType, Any) {
+			c.addError(m.Pos(), "list.join requires string elements, got %s", elemType.String())
+		}
+	}
+	return result
+}
+
+// listMethodCallResult refines the result of a list method call using its
+// arguments, whose types argTypes start with the receiver.
+func (c *Checker) listMethodCallResult(call *ast.CallExpr, list *List, name string, argTypes []Type, result Type) Type {
+	switch name {
+	case "sort":
+		// Without a comparator the elements are compared with each other.
+		if elemType := listElemType(list); len(argTypes) == 1 && !orderable(elemType) {
+			c.addError(call.Pos(), "list.sort requires ordered elements (int, float, string or bytes) or a comparator, got %s", elemType.String())
+		}
+	case "groupBy":
+		// The groups are keyed by the results of the key function.
+		if fn, ok := argTypes[1].(*Func); ok && fn.Result != nil && !Equal(fn.Result, Void) {
+			if !hashable(fn.Result, nil) {
+				c.addError(call.Args[0].Pos(), "%s cannot be a dict key", fn.Result.String())
+				return result
+			}
+			return &Dict{KeyType: fn.Result, ValueType: list}
+		}
+	}
+	return result
+}
+
+// orderable reports whether list.sort, min and max can compare values of
+// type t: ints and floats with each other, bigints, decimals, strings, or
+// bytes.
+func orderable(t Type) bool {
+	if numeric(t) {
+		return true
+	}
+	return Equal(t, BigInt) || Equal(t, Decimal) || Equal(t, String) || Equal(t, Bytes) || Equal(t, Any)
+}
+
+// numeric reports whether t is int, float, or a union of them.
+func numeric(t Type) bool {
+	if u, ok := t.(*Union); ok {
+		for _, v := range u.Variants {
+			if !numeric(v) {
+				return false
+			}
+		}
+		return true
+	}
+	return Equal(t, Int) || Equal(t, Float)
+}
+
 // methodSignaturesMatch checks if a method signature matches an interface requirement.
 // It also checks visibility rules: public interfaces can only require public methods.
 func (c *Checker) methodSignaturesMatch(required InterfaceMethod, actual *Method, typ Type, iface *Interface) bool {
@@ -3809,6 +4320,37 @@ func (c *Checker) methodSignaturesMatch(required InterfaceMethod, actual *Method
 	return true
 }
 
+// checkSpawn type-checks spawn f(args). The call is checked as usual; the result
+// is Future<T> where T is the call's result. Calls that already produce a future
+// (async functions) keep their Future<T> type.
+func (c *Checker) checkSpawn(s *ast.SpawnExpr) Type {
+	if ident, ok := s.Call.Callee.(*ast.IdentExpr); ok {
+		if sym := c.scope.Lookup(ident.Name); sym == nil || sym.Node == nil {
+			if builtin := c.registry.LookupByName(ident.Name); builtin != nil {
+				c.addError(s.Pos(), "cannot spawn builtin function %q", ident.Name)
+				return Invalid
+			}
+		}
+	}
+	callType := c.checkCall(s.Call)
+	if c.bindings != nil && callType != nil {
+		c.bindings.ExprTypes[s.Call] = callType
+	}
+	if IsInvalid(callType) {
+		return Invalid
+	}
+	if member, ok := s.Call.Callee.(*ast.MemberExpr); ok && c.bindings != nil {
+		if sym, ok2 := c.bindings.Members[member]; ok2 && sym.Kind == SymFunc && sym.Node == nil {
+			c.addError(s.Pos(), "cannot spawn built-in method %q", member.Name)
+			return Invalid
+		}
+	}
+	if fut, ok := callType.(*Future); ok {
+		return fut
+	}
+	return &Future{Inner: callType}
+}
+
 func (c *Checker) checkAwait(a *ast.AwaitExpr) Type {
 	exprType := c.checkExpr(a.Expr)
 	if IsInvalid(exprType) {
@@ -269,6 +269,222 @@ fun main() | void {
 	}
 }
 
+func TestCheckProgram_DictKeyTypes(t *testing.T) {
+	input := `
+pckg main;
+
+struct Point {
+    x | int
+    y | int
+}
+
+fun main() | void {
+    var ids | dict<int, string> = {1: "one", 2: "two"};
+    var s | string = ids[1];
+    var grid | dict<Point, int> = {Point{x = 1, y = 2}: 3};
+    var n | int = grid[Point{x = 1, y = 2}];
+    var empty | dict<bool, int> = {};
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	if errs := types.CheckProgram(prog); len(errs) > 0 {
+		t.Fatalf("expected no type errors, got %v", errs)
+	}
+}
+
+func TestCheckProgram_DictKeyTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"float key type", `var d | dict<float, int> = {};`, "float cannot be a dict key type"},
+		{"list key", `var d = {[1]: 2};`, "list<int> cannot be a dict key"},
+		{"wrong index", `var d | dict<int, string> = {1: "x"}; print(d["x"]);`, "dict index must be int, got string"},
+		{"member on int keys", `var d | dict<int, string> = {1: "x"}; print(d.x);`, "use an index for non-string keys"},
+		{"wrong literal keys", `var d | dict<int, string> = {a: "x"};`, "cannot assign"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(lexer.New("pckg main;\n\nfun main() | void {\n    " + tt.body + "\n}\n"))
+			prog := p.ParseProgram()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected parser errors: %v", errs)
+			}
+			errs := types.CheckProgram(prog)
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.want) {
+					return
+				}
+			}
+			t.Fatalf("expected error containing %q, got %v", tt.want, errs)
+		})
+	}
+}
+
+func TestCheckProgram_SetTypes(t *testing.T) {
+	input := `
+pckg main;
+
+fun main() | void {
+    var s | set<int> = set{1, 2};
+    var ok | bool = s.add(3);
+    var u | set<int> = s.union(set<int>{4});
+    var xs | list<int> = u.toList();
+    var back | set<int> = xs.toSet();
+    var mixed | set<<int|string>> = set{1, "a"};
+    var empty | set<string> = set{};
+    for (x in s) {
+        var n | int = x;
+    }
+    for (i, x in s) {
+        var n | int = i + x;
+    }
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	if errs := types.CheckProgram(prog); len(errs) > 0 {
+		t.Fatalf("expected no type errors, got %v", errs)
+	}
+}
+
+func TestCheckProgram_SetTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"float element type", `var s | set<float> = set{};`, "float cannot be a set element type"},
+		{"list element", `var s = set{[1]};`, "list<int> cannot be a set element"},
+		{"float list toSet", `var s = [1.5].toSet();`, "float cannot be a set element"},
+		{"explicit element type", `var s = set<int>{1, "a"};`, "set element must be int, got string"},
+		{"wrong add", `var s | set<int> = set{1}; s.add("a");`, "argument 2 of type int"},
+		{"wrong assignment", `var s | set<int> = set{"a"};`, "cannot assign"},
+		{"index", `var s = set{1}; print(s[0]);`, "indexing is only supported"},
+		{"set dict key", `var d | dict<set<int>, int> = {};`, "cannot be a dict key type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(lexer.New("pckg main;\n\nfun main() | void {\n    " + tt.body + "\n}\n"))
+			prog := p.ParseProgram()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected parser errors: %v", errs)
+			}
+			errs := types.CheckProgram(prog)
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.want) {
+					return
+				}
+			}
+			t.Fatalf("expected error containing %q, got %v", tt.want, errs)
+		})
+	}
+}
+
+func TestCheckProgram_ListMethodTypes(t *testing.T) {
+	input := `
+pckg main;
+
+fun main() | void {
+    var xs | list<int> = [3, 1, 2];
+    var sorted | list<int> = xs.sort();
+    var desc | list<int> = xs.sort(fun(a | int, b | int) | int { return b - a; });
+    var first | int? = xs.find(fun(x | int) | bool { return x > 1; });
+    var chunks | list<list<int>> = xs.chunk(2);
+    var groups | dict<bool, list<int>> = xs.groupBy(fun(x | int) | bool { return x > 1; });
+    var lo | int = xs.min();
+    var total | int = xs.sum();
+    var ftotal | float = [1.5, 2].sum();
+    var s | string = ["a", "b"].join("-");
+    var ok | bool = xs.any(fun(x | int) | bool { return x > 2; });
+    var nums | list<int> = range(0, 10);
+    var evens | list<int> = range(0, 10, 2);
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+	if errs := types.CheckProgram(prog); len(errs) > 0 {
+		t.Fatalf("unexpected type errors: %v", errs)
+	}
+}
+
+func TestCheckProgram_ListMethodErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"sort unordered", `var xs | list<bool> = [true]; print(xs.sort());`, "list.sort requires ordered elements"},
+		{"min unordered", `var xs | list<list<int>> = [[1]]; print(xs.min());`, "list.min requires ordered elements"},
+		{"sum strings", `print(["a"].sum());`, "list.sum requires int or float elements, got string"},
+		{"join ints", `print([1, 2].join(","));`, "list.join requires string elements, got int"},
+		{"find result", `var x | string? = [1].find(fun(x | int) | bool { return true; });`, "cannot assign"},
+		{"groupBy key", `var g = [1].groupBy(fun(x | int) | float { return 1.5; });`, "float cannot be a dict key"},
+		{"range end", `var r = range(1);`, "missing argument for required parameter \"end\""},
+		{"range step type", `var r = range(0, 1, "a");`, "argument 3 (\"step\") of type int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(lexer.New("pckg main;\n\nfun main() | void {\n    " + tt.body + "\n}\n"))
+			prog := p.ParseProgram()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected parser errors: %v", errs)
+			}
+			errs := types.CheckProgram(prog)
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.want) {
+					return
+				}
+			}
+			t.Fatalf("expected error containing %q, got %v", tt.want, errs)
+		})
+	}
+}
+
+func TestCheckProgram_BigNumErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"decimal plus float", `var d = decimal("1.5") + 1.5;`, "operator Plus is not defined for types decimal and float"},
+		{"decimal modulo", `var d = decimal("1.5") % 2;`, "operator % is not defined for decimals"},
+		{"bigint less float", `var b = bigint(1) < 1.5;`, "is not defined for types bigint and float"},
+		{"bigint to int", `var n | int = bigint(1) + 1;`, "cannot assign"},
+		{"decimal to bigint", `var b | bigint = decimal("1") * 2;`, "cannot assign"},
+		{"negate string", `var s = -"a";`, "unary - expects a number, got string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parser.New(lexer.New("pckg main;\n\nfun main() | void {\n    " + tt.body + "\n}\n"))
+			prog := p.ParseProgram()
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("unexpected parser errors: %v", errs)
+			}
+			errs := types.CheckProgram(prog)
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.want) {
+					return
+				}
+			}
+			t.Fatalf("expected error containing %q, got %v", tt.want, errs)
+		})
+	}
+}
+
 func TestCheckProgram_ForLoop(t *testing.T) {
 	input := `
 pckg main;
@@ -322,6 +538,36 @@ fun main() | void {
 	}
 }
 
+func TestCheckProgram_ForEachDict(t *testing.T) {
+	input := `
+pckg main;
+
+fun main() | void {
+    var ages | dict<string, int> = {alex: 30};
+    for (name in ages) {
+        var s | string = name;
+    }
+    for (name, age in ages) {
+        var s | string = name;
+        var n | int = age;
+    }
+    for (i, item in ["a"]) {
+        var n | int = i;
+        var s | string = item;
+    }
+}
+`
+	p := parser.New(lexer.New(input))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	if errs := types.CheckProgram(prog); len(errs) > 0 {
+		t.Fatalf("expected no type errors, got %v", errs)
+	}
+}
+
 func TestCheckProgram_ForEachLoopInvalidType(t *testing.T) {
 	input := `
 pckg main;
@@ -2176,3 +2422,85 @@ fun main() | void {
 		t.Fatalf("expected no type errors, got %d", len(errs))
 	}
 }
+
+func TestCheckProgram_SpawnAndAsyncLiterals(t *testing.T) {
+	input := `
+pckg main;
+
+fun square(x | int) | int {
+    return x * x;
+}
+
+async fun fetch() | string {
+    return "ok";
+}
+
+async fun main() | void {
+    var a | Future<int> = spawn square(3);
+    var b | Future<string> = spawn fetch();
+    var lit | fun(int) | Future<int> = async fun(x | int) | int {
+        return x + 1;
+    };
+    var lifted | fun(int) | Future<int> = square;
+    var n | int = await a + await lit(1) + await lifted(2);
+    var s | string = await b;
+}
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	errs := types.CheckProgram(prog)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			t.Logf("type error: %s", e)
+		}
+		t.Fatalf("expected no type errors, got %d", len(errs))
+	}
+}
+
+func TestCheckProgram_SpawnInvalid(t *testing.T) {
+	cases := map[string]string{
+		"builtin": `
+pckg main;
+
+async fun main() | void {
+    var f | Future<void> = spawn print("x");
+}
+`,
+		"wrong result": `
+pckg main;
+
+fun square(x | int) | int {
+    return x * x;
+}
+
+async fun main() | void {
+    var f | Future<string> = spawn square(2);
+}
+`,
+		"async literal return": `
+pckg main;
+
+async fun main() | void {
+    var f | fun() | int = async fun() | int {
+        return 1;
+    };
+}
+`,
+	}
+	for name, input := range cases {
+		l := lexer.New(input)
+		p := parser.New(l)
+		prog := p.ParseProgram()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("%s: unexpected parser errors: %v", name, errs)
+		}
+		if errs := types.CheckProgram(prog); len(errs) == 0 {
+			t.Fatalf("%s: expected type error, got none", name)
+		}
+	}
+}
@@ -25,6 +25,8 @@ const (
 	BasicAny
 	BasicError
 	BasicBytes
+	BasicBigInt
+	BasicDecimal
 )
 
 type Basic struct {
@@ -52,6 +54,8 @@ var (
 	Any       = &Basic{Kind: BasicAny, Name: "any"}
 	ErrorType = &Basic{Kind: BasicError, Name: "error"}
 	Bytes     = &Basic{Kind: BasicBytes, Name: "bytes"}
+	BigInt    = &Basic{Kind: BasicBigInt, Name: "bigint"}
+	Decimal   = &Basic{Kind: BasicDecimal, Name: "decimal"}
 )
 
 func IsInvalid(t Type) bool {
@@ -150,6 +154,29 @@ func (d *Dict) equal(other Type) bool {
 	return d.ValueType.equal(o.ValueType)
 }
 
+// Set represents a set type: set<T>
+type Set struct {
+	ElemType Type
+}
+
+func (s *Set) String() string {
+	if s.ElemType == nil {
+		return "set<?>"
+	}
+	return "set<" + s.ElemType.String() + ">"
+}
+
+func (s *Set) equal(other Type) bool {
+	o, ok := other.(*Set)
+	if !ok {
+		return false
+	}
+	if s.ElemType == nil || o.ElemType == nil {
+		return s.ElemType == o.ElemType
+	}
+	return s.ElemType.equal(o.ElemType)
+}
+
 // Functions
 
 // Func - function type: (T1, T2, ...) -> R
@@ -476,6 +503,11 @@ func SubstituteType(t Type, mapping map[string]Type) Type {
 			vt = SubstituteType(ty.ValueType, mapping)
 		}
 		return &Dict{KeyType: kt, ValueType: vt}
+	case *Set:
+		if ty.ElemType == nil {
+			return t
+		}
+		return &Set{ElemType: SubstituteType(ty.ElemType, mapping)}
 	case *TypePack:
 		expanded := make([]Type, len(ty.Types))
 		for i, t := range ty.Types {
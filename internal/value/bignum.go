@@ -0,0 +1,83 @@
+package value
+
+import (
+	"math/big"
+	"unsafe"
+
+	"avenir/internal/decimal"
+)
+
+// BigInt creates a bigint value for b. The value keeps b, which must not
+// be changed afterwards.
+func BigInt(b *big.Int) Value {
+	return Value{Kind: KindBigInt, ptr: unsafe.Pointer(b)}
+}
+
+// BigInt returns the integer of a KindBigInt value. Callers must not
+// change it.
+func (v Value) BigInt() *big.Int {
+	if v.Kind != KindBigInt {
+		return nil
+	}
+	return (*big.Int)(v.ptr)
+}
+
+// Decimal creates a decimal value for d.
+func Decimal(d decimal.Decimal) Value {
+	return Value{Kind: KindDecimal, ptr: unsafe.Pointer(&d)}
+}
+
+// Decimal returns the number of a KindDecimal value.
+func (v Value) Decimal() decimal.Decimal {
+	if v.Kind != KindDecimal {
+		return decimal.Decimal{}
+	}
+	return *(*decimal.Decimal)(v.ptr)
+}
+
+// ToBigInt converts an int or a bigint to a big.Int, which callers must not
+// change.
+func ToBigInt(v Value) (*big.Int, bool) {
+	switch v.Kind {
+	case KindInt:
+		return big.NewInt(v.Int()), true
+	case KindBigInt:
+		return v.BigInt(), true
+	}
+	return nil, false
+}
+
+// ToDecimal converts an int, a bigint or a decimal to a decimal; integers
+// get scale 0.
+func ToDecimal(v Value) (decimal.Decimal, bool) {
+	if v.Kind == KindDecimal {
+		return v.Decimal(), true
+	}
+	if b, ok := ToBigInt(v); ok {
+		return decimal.New(b, 0), true
+	}
+	return decimal.Decimal{}, false
+}
+
+// CompareBig compares a and b by value when one is a bigint or a decimal
+// and the other an int, a bigint or a decimal. It returns -1, 0 or 1, and
+// false for other operands.
+func CompareBig(a, b Value) (int, bool) {
+	if a.Kind != KindBigInt && a.Kind != KindDecimal && b.Kind != KindBigInt && b.Kind != KindDecimal {
+		return 0, false
+	}
+	if a.Kind == KindDecimal || b.Kind == KindDecimal {
+		x, ok1 := ToDecimal(a)
+		y, ok2 := ToDecimal(b)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	}
+	x, ok1 := ToBigInt(a)
+	y, ok2 := ToBigInt(b)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
@@ -0,0 +1,308 @@
+package value
+
+import (
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"sort"
+	"unsafe"
+)
+
+// DictValue is the map of a dict value. It keeps its entries in insertion
+// order; setting an existing key keeps its position.
+//
+// Keys are ints, bools, strings, bytes, and structs whose fields are all
+// keys. Two keys are equal when they have the same kind and equal contents;
+// struct keys must also have the same type. Structs are shared and may have
+// mutable fields, so the dict stores its own copy of a struct key and hands
+// out copies of it, which keeps a stored key equal to its hash.
+type DictValue struct {
+	entries []dictEntry
+	index   map[dictKey]int // key -> position in entries
+	removed int             // deleted entries left in entries
+}
+
+type dictEntry struct {
+	key, val Value
+	deleted  bool
+}
+
+// dictKey is the comparable form of a key: ints and bools keep their
+// payload, strings and bytes their contents, and structs their type index
+// and an encoding of their fields.
+type dictKey struct {
+	kind Kind
+	n    uint64
+	s    string
+}
+
+// NewDict returns an empty dict with room for size entries.
+func NewDict(size int) *DictValue {
+	return &DictValue{
+		entries: make([]dictEntry, 0, size),
+		index:   make(map[dictKey]int, size),
+	}
+}
+
+// DictVal creates a dict value for d.
+func DictVal(d *DictValue) Value {
+	return Value{Kind: KindDict, ptr: unsafe.Pointer(d)}
+}
+
+// Hashable reports whether v can be a dict key.
+func Hashable(v Value) bool {
+	_, ok := keyOf(v)
+	return ok
+}
+
+func keyOf(v Value) (dictKey, bool) {
+	switch v.Kind {
+	case KindInt, KindBool:
+		return dictKey{kind: v.Kind, n: v.n}, true
+	case KindString:
+		return dictKey{kind: KindString, s: v.Str()}, true
+	case KindBytes:
+		return dictKey{kind: KindBytes, s: string(v.Bytes())}, true
+	case KindStruct:
+		st := v.Struct()
+		var buf []byte
+		for _, f := range st.Fields {
+			var ok bool
+			if buf, ok = appendKey(buf, f); !ok {
+				return dictKey{}, false
+			}
+		}
+		return dictKey{kind: KindStruct, n: uint64(st.TypeIndex), s: string(buf)}, true
+	}
+	return dictKey{}, false
+}
+
+// appendKey appends an unambiguous encoding of the key v to buf.
+func appendKey(buf []byte, v Value) ([]byte, bool) {
+	k, ok := keyOf(v)
+	if !ok {
+		return buf, false
+	}
+	buf = append(buf, byte(k.kind))
+	buf = binary.LittleEndian.AppendUint64(buf, k.n)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(k.s)))
+	return append(buf, k.s...), true
+}
+
+// unhashable is the error for a value that cannot be a dict key.
+func unhashable(v Value) error {
+	return fmt.Errorf("a %s cannot be a dict key", unhashableKind(v))
+}
+
+// unhashableKind describes a value that cannot be a key.
+func unhashableKind(v Value) string {
+	what := "function"
+	switch v.Kind {
+	case KindFloat:
+		what = "float"
+	case KindList:
+		what = "list"
+	case KindDict:
+		what = "dict"
+	case KindOptional:
+		what = "optional"
+	case KindError:
+		what = "error"
+	case KindFuture:
+		what = "future"
+	case KindStruct:
+		what = "struct with a field that is not a valid key"
+	case KindSet:
+		what = "set"
+	case KindBigInt:
+		what = "bigint"
+	case KindDecimal:
+		what = "decimal"
+	case KindHandle:
+		what = "handle"
+	}
+	return what
+}
+
+// Len returns the number of entries.
+func (d *DictValue) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.index)
+}
+
+// Get returns the value of key.
+func (d *DictValue) Get(key Value) (Value, bool) {
+	if d == nil {
+		return Value{}, false
+	}
+	k, ok := keyOf(key)
+	if !ok {
+		return Value{}, false
+	}
+	i, ok := d.index[k]
+	if !ok {
+		return Value{}, false
+	}
+	return d.entries[i].val, true
+}
+
+// GetStr returns the value of the string key key.
+func (d *DictValue) GetStr(key string) (Value, bool) {
+	if d == nil {
+		return Value{}, false
+	}
+	i, ok := d.index[dictKey{kind: KindString, s: key}]
+	if !ok {
+		return Value{}, false
+	}
+	return d.entries[i].val, true
+}
+
+// Set sets the value of key, adding it at the end if it is new. It fails
+// if key cannot be a dict key.
+func (d *DictValue) Set(key, val Value) error {
+	k, ok := keyOf(key)
+	if !ok {
+		return unhashable(key)
+	}
+	if i, ok := d.index[k]; ok {
+		d.entries[i].val = val
+		return nil
+	}
+	d.index[k] = len(d.entries)
+	d.entries = append(d.entries, dictEntry{key: copyKey(key), val: val})
+	return nil
+}
+
+// copyKey returns a copy of the key v that shares no mutable state with it.
+// Only structs need copying; the other key kinds are immutable.
+func copyKey(v Value) Value {
+	st := v.Struct()
+	if st == nil {
+		return v
+	}
+	fields := make([]Value, len(st.Fields))
+	for i, f := range st.Fields {
+		fields[i] = copyKey(f)
+	}
+	return Struct(st.TypeIndex, fields)
+}
+
+// SetStr sets the value of the string key key.
+func (d *DictValue) SetStr(key string, val Value) {
+	d.Set(Str(key), val)
+}
+
+// Delete removes key and reports whether it was present.
+func (d *DictValue) Delete(key Value) bool {
+	if d == nil {
+		return false
+	}
+	k, ok := keyOf(key)
+	if !ok {
+		return false
+	}
+	i, ok := d.index[k]
+	if !ok {
+		return false
+	}
+	delete(d.index, k)
+	d.entries[i] = dictEntry{deleted: true}
+	d.removed++
+	if d.removed > len(d.entries)/2 {
+		d.compact()
+	}
+	return true
+}
+
+// compact drops deleted entries and renumbers the index.
+func (d *DictValue) compact() {
+	live := d.entries[:0]
+	for _, e := range d.entries {
+		if !e.deleted {
+			k, _ := keyOf(e.key)
+			d.index[k] = len(live)
+			live = append(live, e)
+		}
+	}
+	clear(d.entries[len(live):])
+	d.entries = live
+	d.removed = 0
+}
+
+// All iterates over the entries in insertion order. The dict must not
+// change during the iteration.
+func (d *DictValue) All() iter.Seq2[Value, Value] {
+	return func(yield func(Value, Value) bool) {
+		if d == nil {
+			return
+		}
+		for i := 0; i < len(d.entries); i++ {
+			if e := d.entries[i]; !e.deleted && !yield(copyKey(e.key), e.val) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys in insertion order.
+func (d *DictValue) Keys() []Value {
+	keys := make([]Value, 0, d.Len())
+	for k := range d.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns the values in insertion order.
+func (d *DictValue) Values() []Value {
+	vals := make([]Value, 0, d.Len())
+	for _, v := range d.All() {
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+// StrMap copies the entries into a Go map, for builtins that take string
+// keys. Other keys are formatted as by Value.String.
+func (d *DictValue) StrMap() map[string]Value {
+	m := make(map[string]Value, d.Len())
+	for k, v := range d.All() {
+		m[keyString(k)] = v
+	}
+	return m
+}
+
+// Dict creates a dict value with the given string keys, inserted in sorted
+// order so that dicts built from Go maps iterate deterministically.
+func Dict(entries map[string]Value) Value {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	d := NewDict(len(keys))
+	for _, k := range keys {
+		d.SetStr(k, entries[k])
+	}
+	return DictVal(d)
+}
+
+// Dict returns the map of a KindDict value.
+func (v Value) Dict() *DictValue {
+	if v.Kind != KindDict {
+		return nil
+	}
+	return (*DictValue)(v.ptr)
+}
+
+// keyString formats a dict key for Value.String: strings as they are, other
+// keys like values.
+func keyString(k Value) string {
+	if k.Kind == KindString {
+		return k.Str()
+	}
+	return k.String()
+}
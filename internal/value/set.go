@@ -0,0 +1,92 @@
+package value
+
+import (
+	"fmt"
+	"iter"
+	"unsafe"
+)
+
+// SetValue is the element set of a set value. Like a dict it keeps its
+// elements in insertion order, and its elements are the values that can be
+// dict keys, compared the same way.
+type SetValue struct {
+	d DictValue
+}
+
+// NewSet returns an empty set with room for size elements.
+func NewSet(size int) *SetValue {
+	return &SetValue{d: *NewDict(size)}
+}
+
+// SetVal creates a set value for s.
+func SetVal(s *SetValue) Value {
+	return Value{Kind: KindSet, ptr: unsafe.Pointer(s)}
+}
+
+// Set returns the element set of a KindSet value.
+func (v Value) Set() *SetValue {
+	if v.Kind != KindSet {
+		return nil
+	}
+	return (*SetValue)(v.ptr)
+}
+
+// Len returns the number of elements.
+func (s *SetValue) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.d.Len()
+}
+
+// Has reports whether v is an element.
+func (s *SetValue) Has(v Value) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.d.Get(v)
+	return ok
+}
+
+// Add adds v at the end if it is not an element yet. It fails if v cannot
+// be an element.
+func (s *SetValue) Add(v Value) error {
+	if !Hashable(v) {
+		return fmt.Errorf("a %s cannot be a set element", unhashableKind(v))
+	}
+	if s.Has(v) {
+		return nil
+	}
+	return s.d.Set(v, Value{})
+}
+
+// Remove removes v and reports whether it was an element.
+func (s *SetValue) Remove(v Value) bool {
+	if s == nil {
+		return false
+	}
+	return s.d.Delete(v)
+}
+
+// All iterates over the elements in insertion order. The set must not
+// change during the iteration.
+func (s *SetValue) All() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		if s == nil {
+			return
+		}
+		for k := range s.d.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Elems returns the elements in insertion order.
+func (s *SetValue) Elems() []Value {
+	if s == nil {
+		return []Value{}
+	}
+	return s.d.Keys()
+}
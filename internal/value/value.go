@@ -2,7 +2,9 @@ package value
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"unsafe"
 
 	"avenir/internal/ir"
 )
@@ -24,6 +26,10 @@ const (
 	KindStruct
 	KindDict
 	KindFuture
+	KindSet
+	KindBigInt
+	KindDecimal
+	KindHandle
 )
 
 // Upvalue represents a captured variable.
@@ -31,8 +37,9 @@ const (
 // If closed, holds a copied Value.
 type Upvalue struct {
 	IsClosed bool
-	Index    int   // stack index when open
-	Closed   Value // captured value when closed
+	Index    int      // stack index when open
+	Stack    *[]Value // stack of the VM that owns the slot when open
+	Closed   Value    // captured value when closed
 }
 
 // Closure represents a function closure with captured variables.
@@ -59,40 +66,55 @@ type ErrorInfo struct {
 	Meta    map[string]string
 }
 
-// Value is a universal value for the VM/runtime.
+// Value is a universal value for the VM/runtime. It is three words: the
+// kind, an immediate payload and a pointer. Ints, floats and bools live in
+// the payload; strings, bytes and lists keep their data pointer in ptr and
+// their length (and capacity) in the payload, unless they are too large to
+// pack; the other heap kinds are a single pointer. Read the payload with the accessor of the value's kind;
+// accessors of other kinds return the zero value.
 type Value struct {
-	Kind     Kind
-	Int      int64
-	Float    float64
-	Str      string
-	Bool     bool
-	List     []Value
-	Dict     map[string]Value
-	Closure  *Closure       // for KindClosure
-	Bytes    []byte         // for KindBytes
-	Optional *OptionalValue // for KindOptional
-	Struct   *StructValue   // for KindStruct
-	Error    *ErrorInfo
-	Future   interface{}
+	Kind Kind
+	n    uint64
+	ptr  unsafe.Pointer
+}
+
+// Bytes and lists pack their length and capacity into the payload. A
+// slice too large for that is kept behind a pointer to its header instead,
+// with a payload of boxedLen.
+const (
+	lenBits  = 32
+	boxedLen = math.MaxUint64
+)
+
+// maxPackedCap is the largest capacity packed into the payload; it stays
+// below 1<<lenBits-1 so no packed payload equals boxedLen. Tests lower it to
+// reach the boxed form without allocating 4 GiB.
+var maxPackedCap = 1<<lenBits - 2
+
+func packLen(length, capacity int) (uint64, bool) {
+	if capacity > maxPackedCap {
+		return 0, false
+	}
+	return uint64(length) | uint64(capacity)<<lenBits, true
 }
 
 func (v Value) String() string {
 	switch v.Kind {
 	case KindInt:
-		return fmt.Sprintf("%d", v.Int)
+		return fmt.Sprintf("%d", v.Int())
 	case KindFloat:
-		return fmt.Sprintf("%g", v.Float)
+		return fmt.Sprintf("%g", v.Float())
 	case KindString:
-		return v.Str
+		return v.Str()
 	case KindBool:
-		if v.Bool {
+		if v.Bool() {
 			return "true"
 		}
 		return "false"
 	case KindList:
 		var b strings.Builder
 		b.WriteByte('[')
-		for i, el := range v.List {
+		for i, el := range v.List() {
 			if i > 0 {
 				b.WriteString(", ")
 			}
@@ -101,30 +123,30 @@ func (v Value) String() string {
 		b.WriteByte(']')
 		return b.String()
 	case KindClosure:
-		if v.Closure != nil {
-			return fmt.Sprintf("<closure %s>", v.Closure.Fn.Name)
+		if v.Closure() != nil {
+			return fmt.Sprintf("<closure %s>", v.Closure().Fn.Name)
 		}
 		return "<closure nil>"
 	case KindError:
-		msg := v.Str
-		if v.Error != nil && v.Error.Message != "" {
-			msg = v.Error.Message
+		msg := ""
+		if v.Error() != nil {
+			msg = v.Error().Message
 		}
 		return "error(" + msg + ")"
 	case KindBytes:
-		return fmt.Sprintf("bytes(%d)", len(v.Bytes))
+		return fmt.Sprintf("bytes(%d)", len(v.Bytes()))
 	case KindOptional:
-		if v.Optional != nil && v.Optional.IsSome {
-			return fmt.Sprintf("some(%s)", v.Optional.Value.String())
+		if v.Optional() != nil && v.Optional().IsSome {
+			return fmt.Sprintf("some(%s)", v.Optional().Value.String())
 		}
 		return "none"
 	case KindStruct:
-		if v.Struct == nil {
+		if v.Struct() == nil {
 			return "<nil struct>"
 		}
 		var b strings.Builder
 		b.WriteString("{")
-		for i, f := range v.Struct.Fields {
+		for i, f := range v.Struct().Fields {
 			if i > 0 {
 				b.WriteString(", ")
 			}
@@ -134,21 +156,40 @@ func (v Value) String() string {
 		return b.String()
 	case KindFuture:
 		return "<future>"
+	case KindHandle:
+		return "<handle>"
 	case KindDict:
 		var b strings.Builder
 		b.WriteString("{")
 		first := true
-		for k, val := range v.Dict {
+		for k, val := range v.Dict().All() {
 			if !first {
 				b.WriteString(", ")
 			}
 			first = false
-			b.WriteString(k)
+			b.WriteString(keyString(k))
 			b.WriteString(": ")
 			b.WriteString(val.String())
 		}
 		b.WriteString("}")
 		return b.String()
+	case KindSet:
+		var b strings.Builder
+		b.WriteString("set{")
+		first := true
+		for el := range v.Set().All() {
+			if !first {
+				b.WriteString(", ")
+			}
+			first = false
+			b.WriteString(el.String())
+		}
+		b.WriteString("}")
+		return b.String()
+	case KindBigInt:
+		return v.BigInt().String()
+	case KindDecimal:
+		return v.Decimal().String()
 	default:
 		return "<invalid>"
 	}
@@ -157,80 +198,178 @@ func (v Value) String() string {
 // Helpers
 
 func Int(v int64) Value {
-	return Value{Kind: KindInt, Int: v}
+	return Value{Kind: KindInt, n: uint64(v)}
 }
 
 func Float(v float64) Value {
-	return Value{Kind: KindFloat, Float: v}
+	return Value{Kind: KindFloat, n: math.Float64bits(v)}
 }
 
 func Str(s string) Value {
-	return Value{Kind: KindString, Str: s}
+	return Value{Kind: KindString, n: uint64(len(s)), ptr: unsafe.Pointer(unsafe.StringData(s))}
 }
 
 func Bool(v bool) Value {
-	return Value{Kind: KindBool, Bool: v}
+	if v {
+		return Value{Kind: KindBool, n: 1}
+	}
+	return Value{Kind: KindBool}
 }
 
 func Bytes(b []byte) Value {
-	return Value{Kind: KindBytes, Bytes: b}
+	if n, ok := packLen(len(b), cap(b)); ok {
+		return Value{Kind: KindBytes, n: n, ptr: unsafe.Pointer(unsafe.SliceData(b))}
+	}
+	return Value{Kind: KindBytes, n: boxedLen, ptr: unsafe.Pointer(&b)}
 }
 
 func List(vals []Value) Value {
-	return Value{Kind: KindList, List: vals}
+	if n, ok := packLen(len(vals), cap(vals)); ok {
+		return Value{Kind: KindList, n: n, ptr: unsafe.Pointer(unsafe.SliceData(vals))}
+	}
+	return Value{Kind: KindList, n: boxedLen, ptr: unsafe.Pointer(&vals)}
 }
 
 func NewClosure(fn *ir.Function, ups []*Upvalue) Value {
-	return Value{Kind: KindClosure, Closure: &Closure{Fn: fn, Upvalues: ups}}
+	return ClosureVal(&Closure{Fn: fn, Upvalues: ups})
+}
+
+// ClosureVal wraps an existing closure.
+func ClosureVal(c *Closure) Value {
+	return Value{Kind: KindClosure, ptr: unsafe.Pointer(c)}
 }
 
 func ErrorValue(msg string) Value {
-	return Value{
-		Kind:  KindError,
-		Str:   msg,
-		Error: &ErrorInfo{Message: msg},
-	}
+	return Value{Kind: KindError, ptr: unsafe.Pointer(&ErrorInfo{Message: msg})}
 }
 
 func Some(v Value) Value {
-	return Value{
-		Kind: KindOptional,
-		Optional: &OptionalValue{
-			IsSome: true,
-			Value:  v,
-		},
-	}
+	return Value{Kind: KindOptional, ptr: unsafe.Pointer(&OptionalValue{IsSome: true, Value: v})}
 }
 
 func None() Value {
-	return Value{
-		Kind: KindOptional,
-		Optional: &OptionalValue{
-			IsSome: false,
-		},
-	}
+	return Value{Kind: KindOptional, ptr: unsafe.Pointer(&OptionalValue{IsSome: false})}
 }
 
 // Struct creates a struct value with the given type index and fields.
 func Struct(typeIndex int, fields []Value) Value {
-	return Value{
-		Kind: KindStruct,
-		Struct: &StructValue{
-			TypeIndex: typeIndex,
-			Fields:    fields,
-		},
+	return Value{Kind: KindStruct, ptr: unsafe.Pointer(&StructValue{TypeIndex: typeIndex, Fields: fields})}
+}
+
+// FutureVal creates a future value wrapping a *runtime.Future (stored as interface{} to avoid circular import).
+func FutureVal(f interface{}) Value {
+	return Value{Kind: KindFuture, ptr: unsafe.Pointer(&f)}
+}
+
+// HandleVal wraps a host object owned by builtins, such as a string
+// builder. Avenir code can only pass it back to the builtins that made it.
+func HandleVal(h interface{}) Value {
+	return Value{Kind: KindHandle, ptr: unsafe.Pointer(&h)}
+}
+
+// Accessors
+
+// Int returns the integer of a KindInt value.
+func (v Value) Int() int64 {
+	if v.Kind != KindInt {
+		return 0
 	}
+	return int64(v.n)
 }
 
-// Dict creates a dict value with the given key/value map.
-func Dict(entries map[string]Value) Value {
-	return Value{
-		Kind: KindDict,
-		Dict: entries,
+// Float returns the number of a KindFloat value.
+func (v Value) Float() float64 {
+	if v.Kind != KindFloat {
+		return 0
 	}
+	return math.Float64frombits(v.n)
 }
 
-// FutureVal creates a future value wrapping a *runtime.Future (stored as interface{} to avoid circular import).
-func FutureVal(f interface{}) Value {
-	return Value{Kind: KindFuture, Future: f}
+// Str returns the text of a KindString value, or the message of a
+// KindError value.
+func (v Value) Str() string {
+	switch v.Kind {
+	case KindString:
+		return unsafe.String((*byte)(v.ptr), int(v.n))
+	case KindError:
+		if info := v.Error(); info != nil {
+			return info.Message
+		}
+	}
+	return ""
+}
+
+// Bool returns the boolean of a KindBool value.
+func (v Value) Bool() bool {
+	return v.Kind == KindBool && v.n != 0
+}
+
+// Bytes returns the bytes of a KindBytes value.
+func (v Value) Bytes() []byte {
+	if v.Kind != KindBytes || v.ptr == nil {
+		return nil
+	}
+	if v.n == boxedLen {
+		return *(*[]byte)(v.ptr)
+	}
+	return unsafe.Slice((*byte)(v.ptr), v.n>>lenBits)[:uint32(v.n)]
+}
+
+// List returns the elements of a KindList value.
+func (v Value) List() []Value {
+	if v.Kind != KindList || v.ptr == nil {
+		return nil
+	}
+	if v.n == boxedLen {
+		return *(*[]Value)(v.ptr)
+	}
+	return unsafe.Slice((*Value)(v.ptr), v.n>>lenBits)[:uint32(v.n)]
+}
+
+// Closure returns the closure of a KindClosure value.
+func (v Value) Closure() *Closure {
+	if v.Kind != KindClosure {
+		return nil
+	}
+	return (*Closure)(v.ptr)
+}
+
+// Optional returns the optional of a KindOptional value.
+func (v Value) Optional() *OptionalValue {
+	if v.Kind != KindOptional {
+		return nil
+	}
+	return (*OptionalValue)(v.ptr)
+}
+
+// Struct returns the struct of a KindStruct value.
+func (v Value) Struct() *StructValue {
+	if v.Kind != KindStruct {
+		return nil
+	}
+	return (*StructValue)(v.ptr)
+}
+
+// Error returns the error info of a KindError value.
+func (v Value) Error() *ErrorInfo {
+	if v.Kind != KindError {
+		return nil
+	}
+	return (*ErrorInfo)(v.ptr)
+}
+
+// Handle returns the host object of a KindHandle value.
+func (v Value) Handle() interface{} {
+	if v.Kind != KindHandle || v.ptr == nil {
+		return nil
+	}
+	return *(*interface{})(v.ptr)
+}
+
+// Future returns the *runtime.Future of a KindFuture value.
+func (v Value) Future() interface{} {
+	if v.Kind != KindFuture || v.ptr == nil {
+		return nil
+	}
+	return *(*interface{})(v.ptr)
 }
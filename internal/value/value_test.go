@@ -0,0 +1,63 @@
+package value
+
+import "testing"
+
+func TestPackLenBoundary(t *testing.T) {
+	tests := []struct {
+		length, capacity int
+		packed           bool
+	}{
+		{0, 0, true},
+		{3, 8, true},
+		{1<<lenBits - 2, 1<<lenBits - 2, true},
+		{0, 1<<lenBits - 1, false},
+		{1 << lenBits, 1 << lenBits, false},
+	}
+	for _, tt := range tests {
+		n, ok := packLen(tt.length, tt.capacity)
+		if ok != tt.packed {
+			t.Fatalf("packLen(%d, %d) packed = %v, want %v", tt.length, tt.capacity, ok, tt.packed)
+		}
+		if ok && n == boxedLen {
+			t.Fatalf("packLen(%d, %d) = boxedLen", tt.length, tt.capacity)
+		}
+	}
+}
+
+func TestLargeSlicesAreBoxed(t *testing.T) {
+	saved := maxPackedCap
+	maxPackedCap = 4
+	defer func() { maxPackedCap = saved }()
+
+	// Grow past the packed capacity, as appends do.
+	var vals []Value
+	var data []byte
+	for i := 0; i < 10; i++ {
+		vals = List(append(List(vals).List(), Int(int64(i)))).List()
+		data = Bytes(append(Bytes(data).Bytes(), byte('a'+i))).Bytes()
+	}
+
+	list := List(vals)
+	if list.n != boxedLen {
+		t.Fatalf("list of cap %d was packed", cap(vals))
+	}
+	if got := list.String(); got != "[0, 1, 2, 3, 4, 5, 6, 7, 8, 9]" {
+		t.Fatalf("list = %s", got)
+	}
+	if got := list.List()[2:5]; cap(got) != cap(vals)-2 {
+		t.Fatalf("cap of boxed list slice = %d, want %d", cap(got), cap(vals)-2)
+	}
+
+	b := Bytes(data)
+	if b.n != boxedLen {
+		t.Fatalf("bytes of cap %d were packed", cap(data))
+	}
+	if got := string(b.Bytes()); got != "abcdefghij" {
+		t.Fatalf("bytes = %q", got)
+	}
+
+	small := List(vals[:2:2])
+	if small.n == boxedLen || small.String() != "[0, 1]" {
+		t.Fatalf("small list = %s, boxed %v", small, small.n == boxedLen)
+	}
+}
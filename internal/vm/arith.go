@@ -0,0 +1,206 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"avenir/internal/decimal"
+	"avenir/internal/intmath"
+	"avenir/internal/ir"
+	"avenir/internal/value"
+)
+
+// binaryArith pops b and a and pushes a op b for an arithmetic opcode.
+func (vm *VM) binaryArith(op ir.OpCode) error {
+	b, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	a, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	r, err := vm.arith(op, a, b)
+	if err != nil {
+		return err
+	}
+	// Bigints and decimals grow without bound, so they count toward the
+	// memory limit like other allocations.
+	if isBig(r) {
+		if err := vm.allocated(r); err != nil {
+			return err
+		}
+	}
+	vm.push(r)
+	return nil
+}
+
+// arith computes a op b. Two ints give an int (see binaryIntOp); an int and
+// a float, or two floats, compute in float64. A bigint or decimal operand
+// makes the ints among the operands bigints or decimals (see bigArith).
+// Only integers have a modulo.
+func (vm *VM) arith(op ir.OpCode, a, b value.Value) (value.Value, error) {
+	if a.Kind == value.KindInt && b.Kind == value.KindInt {
+		r, err := binaryIntOp(op, a.Int(), b.Int())
+		if err != nil {
+			return value.Value{}, err
+		}
+		return value.Int(r), nil
+	}
+	if isBig(a) || isBig(b) {
+		if r, ok, err := bigArith(op, a, b); ok || err != nil {
+			return r, err
+		}
+	}
+	x, ok1 := toFloat(a)
+	y, ok2 := toFloat(b)
+	if !ok1 || !ok2 || op == ir.OpMod {
+		want := "numbers"
+		if op == ir.OpMod {
+			want = "ints"
+		}
+		return value.Value{}, fmt.Errorf("operator %s expects %s, got %s and %s", arithSymbol(op), want, vm.typeName(a), vm.typeName(b))
+	}
+	switch op {
+	case ir.OpAdd:
+		return value.Float(x + y), nil
+	case ir.OpSub:
+		return value.Float(x - y), nil
+	case ir.OpMul:
+		return value.Float(x * y), nil
+	case ir.OpDiv:
+		if y == 0 {
+			return value.Value{}, fmt.Errorf("division by zero")
+		}
+		return value.Float(x / y), nil
+	}
+	return value.Value{}, fmt.Errorf("invalid arithmetic opcode %d", op)
+}
+
+// binaryIntOp computes a op b on ints. Division truncates toward zero, and
+// the remainder has the sign of a. A result that does not fit in an int is
+// an error rather than wrapping around, as is dividing by zero.
+func binaryIntOp(op ir.OpCode, a, b int64) (int64, error) {
+	var r int64
+	ok := true
+	switch op {
+	case ir.OpAdd:
+		r, ok = intmath.Add(a, b)
+	case ir.OpSub:
+		r, ok = intmath.Sub(a, b)
+	case ir.OpMul:
+		r, ok = intmath.Mul(a, b)
+	case ir.OpDiv:
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		r, ok = intmath.Div(a, b)
+	case ir.OpMod:
+		if b == 0 {
+			return 0, fmt.Errorf("modulo by zero")
+		}
+		r = a % b
+	default:
+		return 0, fmt.Errorf("invalid arithmetic opcode %d", op)
+	}
+	if !ok {
+		return 0, fmt.Errorf("integer overflow: %d %s %d", a, arithSymbol(op), b)
+	}
+	return r, nil
+}
+
+// isBig reports whether v is a bigint or a decimal.
+func isBig(v value.Value) bool {
+	return v.Kind == value.KindBigInt || v.Kind == value.KindDecimal
+}
+
+// bigArith computes a op b when an operand is a bigint or a decimal and the
+// other is an integer or a decimal. It reports false for other operands.
+// Bigint division truncates like int division; decimal division rounds
+// half to even at the larger scale of the operands.
+func bigArith(op ir.OpCode, a, b value.Value) (value.Value, bool, error) {
+	if a.Kind == value.KindDecimal || b.Kind == value.KindDecimal {
+		x, ok1 := value.ToDecimal(a)
+		y, ok2 := value.ToDecimal(b)
+		if !ok1 || !ok2 || op == ir.OpMod {
+			return value.Value{}, false, nil
+		}
+		switch op {
+		case ir.OpAdd:
+			return value.Decimal(x.Add(y)), true, nil
+		case ir.OpSub:
+			return value.Decimal(x.Sub(y)), true, nil
+		case ir.OpMul:
+			return value.Decimal(x.Mul(y)), true, nil
+		case ir.OpDiv:
+			r, err := x.Quo(y, max(x.Scale(), y.Scale()), decimal.HalfEven)
+			if err != nil {
+				return value.Value{}, true, err
+			}
+			return value.Decimal(r), true, nil
+		}
+		return value.Value{}, false, nil
+	}
+	x, ok1 := value.ToBigInt(a)
+	y, ok2 := value.ToBigInt(b)
+	if !ok1 || !ok2 {
+		return value.Value{}, false, nil
+	}
+	r := new(big.Int)
+	switch op {
+	case ir.OpAdd:
+		r.Add(x, y)
+	case ir.OpSub:
+		r.Sub(x, y)
+	case ir.OpMul:
+		r.Mul(x, y)
+	case ir.OpDiv:
+		if y.Sign() == 0 {
+			return value.Value{}, true, fmt.Errorf("division by zero")
+		}
+		r.Quo(x, y)
+	case ir.OpMod:
+		if y.Sign() == 0 {
+			return value.Value{}, true, fmt.Errorf("modulo by zero")
+		}
+		r.Rem(x, y)
+	default:
+		return value.Value{}, false, nil
+	}
+	return value.BigInt(r), true, nil
+}
+
+// negate returns -v for a number.
+func (vm *VM) negate(v value.Value) (value.Value, error) {
+	switch v.Kind {
+	case value.KindBigInt:
+		return value.BigInt(new(big.Int).Neg(v.BigInt())), nil
+	case value.KindDecimal:
+		return value.Decimal(v.Decimal().Neg()), nil
+	case value.KindInt:
+		r, ok := intmath.Neg(v.Int())
+		if !ok {
+			return value.Value{}, fmt.Errorf("integer overflow: -(%d)", v.Int())
+		}
+		return value.Int(r), nil
+	case value.KindFloat:
+		return value.Float(-v.Float()), nil
+	}
+	return value.Value{}, fmt.Errorf("operator - expects a number, got %s", vm.typeName(v))
+}
+
+func arithSymbol(op ir.OpCode) string {
+	switch op {
+	case ir.OpAdd:
+		return "+"
+	case ir.OpSub:
+		return "-"
+	case ir.OpMul:
+		return "*"
+	case ir.OpDiv:
+		return "/"
+	case ir.OpMod:
+		return "%"
+	}
+	return op.String()
+}
@@ -0,0 +1,47 @@
+package vm
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+)
+
+// builtinTable resolves the module's builtin symbols against a registry on
+// first use, and caches the targets of its OpCallMethod sites. It is shared
+// by a VM and the child VMs running its tasks.
+type builtinTable struct {
+	registry *builtins.Registry
+	resolved []*builtins.Builtin
+	sites    []methodSite
+}
+
+// SetBuiltins makes vm resolve the builtins called by the module in registry
+// instead of builtins.Default(). Call it before RunMain.
+func (vm *VM) SetBuiltins(registry *builtins.Registry) {
+	*vm.builtinTable = builtinTable{registry: registry}
+}
+
+// lookupBuiltin returns the builtin at index idx of the module's symbol table.
+func (vm *VM) lookupBuiltin(idx int) (*builtins.Builtin, error) {
+	t := vm.builtinTable
+	symbols := vm.mod.Builtins
+	if idx < 0 || idx >= len(symbols) {
+		return nil, fmt.Errorf("invalid builtin index %d", idx)
+	}
+	if t.resolved == nil {
+		t.resolved = make([]*builtins.Builtin, len(symbols))
+	}
+	if b := t.resolved[idx]; b != nil {
+		return b, nil
+	}
+	registry := t.registry
+	if registry == nil {
+		registry = builtins.Default()
+	}
+	b := registry.LookupSymbol(symbols[idx])
+	if b == nil {
+		return nil, fmt.Errorf("unknown builtin %q", symbols[idx])
+	}
+	t.resolved[idx] = b
+	return b, nil
+}
@@ -0,0 +1,729 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"avenir/internal/ir"
+	"avenir/internal/value"
+)
+
+// ErrTerminated is returned by RunMain when a Debugger ended the program.
+var ErrTerminated error = terminatedError{}
+
+type terminatedError struct{}
+
+func (terminatedError) Error() string { return "program terminated by debugger" }
+
+// Fatal makes the event loop stop, like the fatal limit errors.
+func (terminatedError) Fatal() bool { return true }
+
+// ErrNotStopped is returned by the inspection methods of a Debugger while
+// the program is running.
+var ErrNotStopped = errors.New("program is not stopped")
+
+// StopReason tells why a debugged program stopped.
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"
+	StopBreakpoint StopReason = "breakpoint"
+	StopStep       StopReason = "step"
+	StopPause      StopReason = "pause"
+)
+
+// StopEvent describes where a debugged program stopped.
+type StopEvent struct {
+	Reason StopReason
+	Thread int // ID of the task that stopped, see Debugger.Threads
+	File   string
+	Line   int
+}
+
+// Thread is a task of a debugged program. The main task has ID 1; each
+// spawned task gets the next ID and is listed until it finishes.
+type Thread struct {
+	ID   int
+	Name string
+}
+
+// StackFrame is a call frame of a stopped task.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Scope is a group of variables visible in a frame. Pass Ref to Variables.
+type Scope struct {
+	Name string // "Locals", "Upvalues" or "Globals"
+	Ref  int
+}
+
+// Variable is a named value of a stopped program. Lists, dicts and
+// structs have a Ref to pass to Variables for their elements.
+type Variable struct {
+	Name  string
+	Value string // Avenir literal syntax where there is one
+	Type  string
+	Ref   int
+}
+
+// Debugger stops a program at breakpoints and steps through it, one source
+// line at a time, and inspects it while stopped. Attach it with
+// VM.SetDebugger before RunMain.
+//
+// A stopped program blocks the goroutine running it. The other methods may
+// be called from any goroutine; the inspection methods (StackTrace, Scopes,
+// Variables, Evaluate) return ErrNotStopped while the program runs, and
+// their Refs are valid until it resumes.
+type Debugger struct {
+	// OnStop is called on the program's goroutine each time it stops,
+	// before it waits for a command.
+	OnStop func(StopEvent)
+
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool // by cleaned absolute path
+	files       map[string]string       // Function.File -> cleaned absolute path
+	threads     []*debugThread
+	nextThread  int
+
+	// The program stops at the next line that stepVM reaches with at most
+	// stepDepth frames, or at the next line of any task if stepVM is nil.
+	stepping   bool
+	stepVM     *VM
+	stepDepth  int
+	stepReason StopReason
+	terminate  bool
+
+	stopped  *VM           // task the program stopped in, nil while running
+	resumed  chan struct{} // closed when the stopped program resumes
+	requests chan func()
+	resume   chan bool // false terminates the program
+	handles  []func() []Variable
+}
+
+type debugThread struct {
+	id   int
+	name string
+	vm   *VM
+}
+
+// NewDebugger returns a Debugger without breakpoints. With stopOnEntry,
+// the program stops at its first line.
+func NewDebugger(stopOnEntry bool) *Debugger {
+	d := &Debugger{
+		breakpoints: make(map[string]map[int]bool),
+		files:       make(map[string]string),
+		requests:    make(chan func()),
+		resume:      make(chan bool),
+	}
+	if stopOnEntry {
+		d.stepping = true
+		d.stepReason = StopEntry
+	}
+	return d
+}
+
+// SetDebugger attaches d to the program run by vm, including the tasks it
+// spawns. Call it before RunMain.
+func (vm *VM) SetDebugger(d *Debugger) {
+	vm.debug = d
+	d.addThread(vm, "main")
+}
+
+// SetBreakpoints replaces the breakpoints of a source file. A line without
+// code moves to the next line of the file that has some; it returns the
+// lines used, 0 where none was found.
+func (d *Debugger) SetBreakpoints(mod *ir.Module, file string, lines []int) []int {
+	file = cleanPath(file)
+	var code []int // lines of file with code, sorted
+	for _, fn := range mod.Functions {
+		if fn == nil || cleanPath(fn.File) != file {
+			continue
+		}
+		for _, line := range fn.Chunk.Lines {
+			if line > 0 {
+				code = append(code, line)
+			}
+		}
+	}
+	sort.Ints(code)
+
+	set := make(map[int]bool, len(lines))
+	used := make([]int, len(lines))
+	for i, line := range lines {
+		j := sort.SearchInts(code, line)
+		if j < len(code) {
+			used[i] = code[j]
+			set[code[j]] = true
+		}
+	}
+	d.mu.Lock()
+	d.breakpoints[file] = set
+	d.mu.Unlock()
+	return used
+}
+
+func cleanPath(file string) string {
+	if file == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return filepath.Clean(file)
+}
+
+// Continue resumes the stopped program.
+func (d *Debugger) Continue() error {
+	return d.resumeWith(func() { d.stepping = false })
+}
+
+// StepIn resumes the program until the task that stopped reaches a new
+// source line, in the current function or one it calls.
+func (d *Debugger) StepIn() error {
+	return d.resumeWith(func() { d.step(math.MaxInt) })
+}
+
+// StepOver resumes the program until the task that stopped reaches a new
+// source line outside the functions called from the current one.
+func (d *Debugger) StepOver() error {
+	return d.resumeWith(func() { d.step(len(d.stopped.frames)) })
+}
+
+// StepOut resumes the program until the task that stopped reaches a new
+// source line after the current function returns.
+func (d *Debugger) StepOut() error {
+	return d.resumeWith(func() { d.step(len(d.stopped.frames) - 1) })
+}
+
+func (d *Debugger) step(depth int) {
+	d.stepping = true
+	d.stepVM = d.stopped
+	d.stepDepth = depth
+	d.stepReason = StopStep
+}
+
+// Pause stops the running program at the next source line of any task. A
+// program waiting for timers or I/O stops once it runs again.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped == nil {
+		d.stepping = true
+		d.stepVM = nil
+		d.stepReason = StopPause
+	}
+}
+
+// Terminate ends the program: RunMain returns ErrTerminated. A running
+// program ends at its next source line.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	d.terminate = true
+	d.mu.Unlock()
+	d.resumeWith(func() {})
+}
+
+func (d *Debugger) resumeWith(set func()) error {
+	d.mu.Lock()
+	if d.stopped == nil {
+		d.mu.Unlock()
+		return ErrNotStopped
+	}
+	set()
+	run := !d.terminate
+	resumed := d.resumed
+	d.mu.Unlock()
+	select {
+	case d.resume <- run:
+	case <-resumed:
+	}
+	return nil
+}
+
+// Threads lists the tasks of the program.
+func (d *Debugger) Threads() []Thread {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	threads := make([]Thread, len(d.threads))
+	for i, t := range d.threads {
+		threads[i] = Thread{ID: t.id, Name: t.name}
+	}
+	return threads
+}
+
+func (d *Debugger) addThread(vm *VM, name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextThread++
+	if d.nextThread > 1 {
+		name = fmt.Sprintf("task %d: %s", d.nextThread, name)
+	}
+	d.threads = append(d.threads, &debugThread{id: d.nextThread, name: name, vm: vm})
+}
+
+func (d *Debugger) removeThread(vm *VM) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, t := range d.threads {
+		if t.vm == vm {
+			d.threads = append(d.threads[:i], d.threads[i+1:]...)
+			break
+		}
+	}
+	// A step out of a finished task stops wherever the program goes next.
+	if d.stepVM == vm {
+		d.stepVM = nil
+	}
+}
+
+// thread returns the task with the given ID. d.mu must be held.
+func (d *Debugger) thread(id int) (*VM, error) {
+	for _, t := range d.threads {
+		if t.id == id {
+			return t.vm, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown thread %d", id)
+}
+
+// atLine is called by the dispatch loop each time a frame of vm reaches a
+// new source line, and blocks while the program is stopped there.
+func (d *Debugger) atLine(vm *VM, fr *Frame) error {
+	d.mu.Lock()
+	if d.terminate {
+		d.mu.Unlock()
+		vm.budget.err = ErrTerminated
+		return ErrTerminated
+	}
+	reason := d.stopReason(vm, fr)
+	if reason == "" {
+		d.mu.Unlock()
+		return nil
+	}
+	d.stepping = false
+	d.stepVM = nil
+	d.stopped = vm
+	d.resumed = make(chan struct{})
+	ev := StopEvent{Reason: reason, File: fr.Fn.File, Line: fr.line}
+	for _, t := range d.threads {
+		if t.vm == vm {
+			ev.Thread = t.id
+		}
+	}
+	d.mu.Unlock()
+
+	if d.OnStop != nil {
+		d.OnStop(ev)
+	}
+	for {
+		select {
+		case req := <-d.requests:
+			req()
+		case run := <-d.resume:
+			d.mu.Lock()
+			d.stopped = nil
+			d.handles = nil
+			close(d.resumed)
+			d.mu.Unlock()
+			if !run {
+				vm.budget.err = ErrTerminated
+				return ErrTerminated
+			}
+			return nil
+		}
+	}
+}
+
+// stopReason decides whether vm stops at the line fr just reached. d.mu
+// must be held.
+func (d *Debugger) stopReason(vm *VM, fr *Frame) StopReason {
+	file, ok := d.files[fr.Fn.File]
+	if !ok {
+		file = cleanPath(fr.Fn.File)
+		d.files[fr.Fn.File] = file
+	}
+	if d.breakpoints[file][fr.line] {
+		return StopBreakpoint
+	}
+	if d.stepping && (d.stepVM == nil || d.stepVM == vm && len(vm.frames) <= d.stepDepth) {
+		return d.stepReason
+	}
+	return ""
+}
+
+// do runs fn on the goroutine of the stopped program.
+func (d *Debugger) do(fn func() error) error {
+	d.mu.Lock()
+	stopped, resumed := d.stopped, d.resumed
+	d.mu.Unlock()
+	if stopped == nil {
+		return ErrNotStopped
+	}
+	done := make(chan error, 1)
+	select {
+	case d.requests <- func() { done <- fn() }:
+		return <-done
+	case <-resumed:
+		return ErrNotStopped
+	}
+}
+
+// frame returns frame n of a task, counting from the innermost one.
+func (d *Debugger) frame(thread, n int) (*VM, *Frame, error) {
+	d.mu.Lock()
+	vm, err := d.thread(thread)
+	d.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 0 || n >= len(vm.frames) {
+		return nil, nil, fmt.Errorf("thread %d has no frame %d", thread, n)
+	}
+	return vm, &vm.frames[len(vm.frames)-1-n], nil
+}
+
+// StackTrace returns the frames of a task, innermost first.
+func (d *Debugger) StackTrace(thread int) ([]StackFrame, error) {
+	var frames []StackFrame
+	err := d.do(func() error {
+		d.mu.Lock()
+		vm, err := d.thread(thread)
+		d.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		for i := len(vm.frames) - 1; i >= 0; i-- {
+			fr := &vm.frames[i]
+			frames = append(frames, StackFrame{Function: fr.Fn.Name, File: fr.Fn.File, Line: fr.line})
+		}
+		return nil
+	})
+	return frames, err
+}
+
+// Scopes returns the scopes of frame n of a task, counting from the
+// innermost one.
+func (d *Debugger) Scopes(thread, n int) ([]Scope, error) {
+	var scopes []Scope
+	err := d.do(func() error {
+		vm, fr, err := d.frame(thread, n)
+		if err != nil {
+			return err
+		}
+		scopes = append(scopes, Scope{Name: "Locals", Ref: d.handle(func() []Variable {
+			return vm.variables(localsAt(vm, fr))
+		})})
+		if len(fr.Fn.Upvalues) > 0 {
+			scopes = append(scopes, Scope{Name: "Upvalues", Ref: d.handle(func() []Variable {
+				return vm.variables(upvaluesOf(fr))
+			})})
+		}
+		if len(vm.globals) > 0 {
+			scopes = append(scopes, Scope{Name: "Globals", Ref: d.handle(func() []Variable {
+				return vm.variables(globalsOf(vm))
+			})})
+		}
+		return nil
+	})
+	return scopes, err
+}
+
+// Variables returns the variables of a scope, or the elements of a list,
+// dict or struct.
+func (d *Debugger) Variables(ref int) ([]Variable, error) {
+	var vars []Variable
+	err := d.do(func() error {
+		if ref < 1 || ref > len(d.handles) {
+			return fmt.Errorf("unknown variables reference %d", ref)
+		}
+		vars = d.handles[ref-1]()
+		return nil
+	})
+	return vars, err
+}
+
+// Evaluate evaluates an expression in frame n of a task. It may read
+// locals, upvalues, globals, fields and elements, and use operators; it
+// cannot call functions or change the program.
+func (d *Debugger) Evaluate(thread, n int, expr string) (Variable, error) {
+	var result Variable
+	err := d.do(func() error {
+		vm, fr, err := d.frame(thread, n)
+		if err != nil {
+			return err
+		}
+		v, err := vm.evaluate(fr, expr)
+		if err != nil {
+			return err
+		}
+		result = vm.variable(expr, v)
+		return nil
+	})
+	return result, err
+}
+
+// handle registers a source of variables until the program resumes. It
+// runs on the program's goroutine, which owns d.handles while stopped.
+func (d *Debugger) handle(vars func() []Variable) int {
+	d.handles = append(d.handles, vars)
+	return len(d.handles)
+}
+
+type namedValue struct {
+	name string
+	val  value.Value
+}
+
+// localsAt returns the locals in scope at the current instruction of fr;
+// an inner local hides an outer one of the same name.
+func localsAt(vm *VM, fr *Frame) []namedValue {
+	var locals []namedValue
+	pos := make(map[string]int)
+	for _, l := range fr.Fn.Locals {
+		if fr.IP < l.Start || fr.IP >= l.End || fr.Base+l.Slot >= vm.sp {
+			continue
+		}
+		nv := namedValue{l.Name, vm.stack[fr.Base+l.Slot]}
+		if i, ok := pos[l.Name]; ok {
+			locals[i] = nv
+			continue
+		}
+		pos[l.Name] = len(locals)
+		locals = append(locals, nv)
+	}
+	return locals
+}
+
+func upvaluesOf(fr *Frame) []namedValue {
+	var upvalues []namedValue
+	if fr.Clo == nil {
+		return nil
+	}
+	for i, upv := range fr.Clo.Upvalues {
+		if i >= len(fr.Fn.Upvalues) || upv == nil {
+			break
+		}
+		name := fr.Fn.Upvalues[i].Name
+		if name == "" {
+			name = "upvalue " + strconv.Itoa(i)
+		}
+		val := upv.Closed
+		if !upv.IsClosed && upv.Stack != nil && upv.Index < len(*upv.Stack) {
+			val = (*upv.Stack)[upv.Index]
+		}
+		upvalues = append(upvalues, namedValue{name, val})
+	}
+	return upvalues
+}
+
+func globalsOf(vm *VM) []namedValue {
+	var globals []namedValue
+	for i, g := range vm.mod.Globals {
+		if i < len(vm.globals) {
+			globals = append(globals, namedValue{g.Name, vm.globals[i]})
+		}
+	}
+	return globals
+}
+
+// variables describes values, leaving out those not assigned yet.
+func (vm *VM) variables(values []namedValue) []Variable {
+	vars := make([]Variable, 0, len(values))
+	for _, nv := range values {
+		if nv.val.Kind != value.KindInvalid {
+			vars = append(vars, vm.variable(nv.name, nv.val))
+		}
+	}
+	return vars
+}
+
+func (vm *VM) variable(name string, v value.Value) Variable {
+	d := vm.debug
+	vr := Variable{Name: name, Value: vm.format(v, 2), Type: vm.typeName(v)}
+	switch v.Kind {
+	case value.KindList:
+		if len(v.List()) > 0 {
+			vr.Ref = d.handle(func() []Variable {
+				vars := make([]Variable, len(v.List()))
+				for i, el := range v.List() {
+					vars[i] = vm.variable(strconv.Itoa(i), el)
+				}
+				return vars
+			})
+		}
+	case value.KindSet:
+		if v.Set().Len() > 0 {
+			vr.Ref = d.handle(func() []Variable {
+				elems := v.Set().Elems()
+				vars := make([]Variable, len(elems))
+				for i, el := range elems {
+					vars[i] = vm.variable(strconv.Itoa(i), el)
+				}
+				return vars
+			})
+		}
+	case value.KindDict:
+		if v.Dict().Len() > 0 {
+			vr.Ref = d.handle(func() []Variable {
+				vars := make([]Variable, 0, v.Dict().Len())
+				for k, el := range v.Dict().All() {
+					vars = append(vars, vm.variable(vm.formatKey(k), el))
+				}
+				return vars
+			})
+		}
+	case value.KindStruct:
+		if v.Struct() != nil && len(v.Struct().Fields) > 0 {
+			vr.Ref = d.handle(func() []Variable {
+				names := vm.fieldNames(v.Struct().TypeIndex)
+				vars := make([]Variable, len(v.Struct().Fields))
+				for i, f := range v.Struct().Fields {
+					name := strconv.Itoa(i)
+					if i < len(names) {
+						name = names[i]
+					}
+					vars[i] = vm.variable(name, f)
+				}
+				return vars
+			})
+		}
+	}
+	return vr
+}
+
+// formatKey formats a dict key: strings quoted, other keys as values.
+func (vm *VM) formatKey(k value.Value) string {
+	if k.Kind == value.KindString {
+		return strconv.Quote(k.Str())
+	}
+	return vm.format(k, 1)
+}
+
+func (vm *VM) fieldNames(typeIndex int) []string {
+	if typeIndex < 0 || typeIndex >= len(vm.mod.StructTypes) {
+		return nil
+	}
+	var names []string
+	for _, f := range vm.mod.StructTypes[typeIndex].Fields {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func (vm *VM) typeName(v value.Value) string {
+	switch v.Kind {
+	case value.KindInt:
+		return "int"
+	case value.KindFloat:
+		return "float"
+	case value.KindBigInt:
+		return "bigint"
+	case value.KindDecimal:
+		return "decimal"
+	case value.KindString:
+		return "string"
+	case value.KindBool:
+		return "bool"
+	case value.KindList:
+		return "list"
+	case value.KindClosure:
+		return "function"
+	case value.KindError:
+		return "error"
+	case value.KindBytes:
+		return "bytes"
+	case value.KindOptional:
+		return "optional"
+	case value.KindStruct:
+		if v.Struct() != nil && v.Struct().TypeIndex >= 0 && v.Struct().TypeIndex < len(vm.mod.StructTypes) {
+			return vm.mod.StructTypes[v.Struct().TypeIndex].Name
+		}
+		return "struct"
+	case value.KindDict:
+		return "dict"
+	case value.KindSet:
+		return "set"
+	case value.KindFuture:
+		return "future"
+	case value.KindHandle:
+		return "handle"
+	}
+	return "invalid"
+}
+
+// maxFormatElements bounds the elements of a list, dict or set that format
+// shows inline.
+const maxFormatElements = 20
+
+// format renders v in Avenir literal syntax, nesting containers depth
+// levels deep.
+func (vm *VM) format(v value.Value, depth int) string {
+	var b strings.Builder
+	elements := func(n int, each func(i int)) {
+		if depth <= 0 && n > 0 {
+			b.WriteString("...")
+			return
+		}
+		for i := 0; i < n && i < maxFormatElements; i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			each(i)
+		}
+		if n > maxFormatElements {
+			b.WriteString(", ...")
+		}
+	}
+	switch v.Kind {
+	case value.KindString:
+		return strconv.Quote(v.Str())
+	case value.KindBytes:
+		return "b" + strconv.Quote(string(v.Bytes()))
+	case value.KindOptional:
+		if v.Optional() != nil && v.Optional().IsSome {
+			return "some(" + vm.format(v.Optional().Value, depth) + ")"
+		}
+		return "none"
+	case value.KindList:
+		b.WriteByte('[')
+		elements(len(v.List()), func(i int) { b.WriteString(vm.format(v.List()[i], depth-1)) })
+		b.WriteByte(']')
+	case value.KindSet:
+		elems := v.Set().Elems()
+		b.WriteString("set{")
+		elements(len(elems), func(i int) { b.WriteString(vm.format(elems[i], depth-1)) })
+		b.WriteByte('}')
+	case value.KindDict:
+		keys, vals := v.Dict().Keys(), v.Dict().Values()
+		b.WriteByte('{')
+		elements(len(keys), func(i int) {
+			b.WriteString(vm.formatKey(keys[i]) + ": " + vm.format(vals[i], depth-1))
+		})
+		b.WriteByte('}')
+	case value.KindStruct:
+		if v.Struct() == nil {
+			return v.String()
+		}
+		names := vm.fieldNames(v.Struct().TypeIndex)
+		b.WriteString(vm.typeName(v) + "{")
+		elements(len(v.Struct().Fields), func(i int) {
+			if i < len(names) {
+				b.WriteString(names[i] + " = ")
+			}
+			b.WriteString(vm.format(v.Struct().Fields[i], depth-1))
+		})
+		b.WriteByte('}')
+	default:
+		return v.String()
+	}
+	return b.String()
+}
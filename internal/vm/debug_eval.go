@@ -0,0 +1,242 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"avenir/internal/ast"
+	"avenir/internal/ir"
+	"avenir/internal/lexer"
+	"avenir/internal/parser"
+	"avenir/internal/token"
+	"avenir/internal/value"
+)
+
+// evaluate evaluates a debugger expression in frame fr of vm. It runs the
+// operators on a scratch VM so the program's stack is left alone.
+func (vm *VM) evaluate(fr *Frame, src string) (value.Value, error) {
+	// The lexer drops the last rune of an identifier or number that ends
+	// the input, so end it with a newline like a source file.
+	p := parser.New(lexer.New(src + "\n"))
+	expr := p.ParseExpression()
+	if errs := p.Errors(); len(errs) > 0 {
+		return value.Value{}, errors.New(strings.Join(errs, "; "))
+	}
+	ev := &evaluator{vm: vm, fr: fr, scratch: &VM{}}
+	return ev.eval(expr)
+}
+
+type evaluator struct {
+	vm      *VM
+	fr      *Frame
+	scratch *VM
+}
+
+func (ev *evaluator) eval(expr ast.Expr) (value.Value, error) {
+	switch e := expr.(type) {
+	case *ast.IntLiteral:
+		return value.Int(e.Value), nil
+	case *ast.FloatLiteral:
+		return value.Float(e.Value), nil
+	case *ast.StringLiteral:
+		return value.Str(e.Value), nil
+	case *ast.BytesLiteral:
+		return value.Bytes(e.Value), nil
+	case *ast.BoolLiteral:
+		return value.Bool(e.Value), nil
+	case *ast.NoneLiteral:
+		return value.None(), nil
+	case *ast.SomeLiteral:
+		v, err := ev.eval(e.Value)
+		if err != nil {
+			return value.Value{}, err
+		}
+		return value.Some(v), nil
+	case *ast.InterpolatedString:
+		var b strings.Builder
+		for _, part := range e.Parts {
+			switch part := part.(type) {
+			case *ast.StringTextPart:
+				b.WriteString(part.Value)
+			case *ast.StringExprPart:
+				v, err := ev.eval(part.Expr)
+				if err != nil {
+					return value.Value{}, err
+				}
+				b.WriteString(v.String())
+			}
+		}
+		return value.Str(b.String()), nil
+	case *ast.ListLiteral:
+		elems := make([]value.Value, len(e.Elements))
+		for i, el := range e.Elements {
+			v, err := ev.eval(el)
+			if err != nil {
+				return value.Value{}, err
+			}
+			elems[i] = v
+		}
+		return value.List(elems), nil
+	case *ast.IdentExpr:
+		return ev.lookup(e.Name)
+	case *ast.MemberExpr:
+		x, err := ev.eval(e.X)
+		if err != nil {
+			return value.Value{}, err
+		}
+		return ev.member(x, e.Name)
+	case *ast.IndexExpr:
+		x, err := ev.eval(e.X)
+		if err != nil {
+			return value.Value{}, err
+		}
+		idx, err := ev.eval(e.Index)
+		if err != nil {
+			return value.Value{}, err
+		}
+		return ev.index(x, idx)
+	case *ast.UnaryExpr:
+		x, err := ev.eval(e.X)
+		if err != nil {
+			return value.Value{}, err
+		}
+		return ev.unary(e.Op, x)
+	case *ast.BinaryExpr:
+		return ev.binary(e)
+	case *ast.CallExpr, *ast.OptionalCallExpr, *ast.AwaitExpr, *ast.SpawnExpr:
+		return value.Value{}, errors.New("calls are not supported in debugger expressions")
+	}
+	return value.Value{}, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// lookup finds a name among the locals, upvalues and globals of the frame.
+func (ev *evaluator) lookup(name string) (value.Value, error) {
+	for _, scope := range [][]namedValue{localsAt(ev.vm, ev.fr), upvaluesOf(ev.fr), globalsOf(ev.vm)} {
+		for _, nv := range scope {
+			if nv.name != name {
+				continue
+			}
+			if nv.val.Kind == value.KindInvalid {
+				return value.Value{}, fmt.Errorf("%s is not assigned yet", name)
+			}
+			return nv.val, nil
+		}
+	}
+	return value.Value{}, fmt.Errorf("unknown name %q", name)
+}
+
+func (ev *evaluator) member(x value.Value, name string) (value.Value, error) {
+	switch x.Kind {
+	case value.KindStruct:
+		if x.Struct() != nil {
+			for i, field := range ev.vm.fieldNames(x.Struct().TypeIndex) {
+				if field == name && i < len(x.Struct().Fields) {
+					return x.Struct().Fields[i], nil
+				}
+			}
+		}
+		return value.Value{}, fmt.Errorf("%s has no field %q", ev.vm.typeName(x), name)
+	case value.KindDict:
+		if v, ok := x.Dict().GetStr(name); ok {
+			return v, nil
+		}
+		return value.Value{}, fmt.Errorf("key %q not found", name)
+	}
+	return value.Value{}, fmt.Errorf("%s has no field %q", ev.vm.typeName(x), name)
+}
+
+func (ev *evaluator) index(x, idx value.Value) (value.Value, error) {
+	switch {
+	case x.Kind == value.KindList && idx.Kind == value.KindInt:
+		if idx.Int() < 0 || idx.Int() >= int64(len(x.List())) {
+			return value.Value{}, fmt.Errorf("index out of range %d (len=%d)", idx.Int(), len(x.List()))
+		}
+		return x.List()[idx.Int()], nil
+	case x.Kind == value.KindBytes && idx.Kind == value.KindInt:
+		if idx.Int() < 0 || idx.Int() >= int64(len(x.Bytes())) {
+			return value.Value{}, fmt.Errorf("index out of range %d (len=%d)", idx.Int(), len(x.Bytes()))
+		}
+		return value.Int(int64(x.Bytes()[idx.Int()])), nil
+	case x.Kind == value.KindDict && value.Hashable(idx):
+		if v, ok := x.Dict().Get(idx); ok {
+			return v, nil
+		}
+		return value.Value{}, fmt.Errorf("key %s not found", ev.vm.formatKey(idx))
+	}
+	return value.Value{}, fmt.Errorf("cannot index %s with %s", ev.vm.typeName(x), ev.vm.typeName(idx))
+}
+
+func (ev *evaluator) unary(op token.Kind, x value.Value) (value.Value, error) {
+	switch {
+	case op == token.Minus && (x.Kind == value.KindInt || x.Kind == value.KindFloat):
+		return ev.vm.negate(x)
+	case op == token.Bang && x.Kind == value.KindBool:
+		return value.Bool(!x.Bool()), nil
+	}
+	return value.Value{}, fmt.Errorf("invalid operand %s for %s", ev.vm.typeName(x), op)
+}
+
+func (ev *evaluator) binary(e *ast.BinaryExpr) (value.Value, error) {
+	a, err := ev.eval(e.Left)
+	if err != nil {
+		return value.Value{}, err
+	}
+	// && and || short-circuit as in compiled code.
+	if e.Op == token.AndAnd || e.Op == token.OrOr {
+		if a.Kind != value.KindBool {
+			return value.Value{}, fmt.Errorf("invalid operand %s for %s", ev.vm.typeName(a), e.Op)
+		}
+		if a.Bool() == (e.Op == token.OrOr) {
+			return a, nil
+		}
+	}
+	b, err := ev.eval(e.Right)
+	if err != nil {
+		return value.Value{}, err
+	}
+	if e.Op == token.Plus && a.Kind == value.KindString && b.Kind == value.KindString {
+		return value.Str(a.Str() + b.Str()), nil
+	}
+
+	// Arithmetic and comparisons reuse the VM's operators.
+	vm := ev.scratch
+	vm.sp = 0
+	vm.push(a)
+	vm.push(b)
+	switch e.Op {
+	case token.AndAnd, token.OrOr:
+		if b.Kind != value.KindBool {
+			return value.Value{}, fmt.Errorf("invalid operand %s for %s", ev.vm.typeName(b), e.Op)
+		}
+		return b, nil
+	case token.Plus:
+		err = vm.binaryArith(ir.OpAdd)
+	case token.Minus:
+		err = vm.binaryArith(ir.OpSub)
+	case token.Star:
+		err = vm.binaryArith(ir.OpMul)
+	case token.Slash:
+		err = vm.binaryArith(ir.OpDiv)
+	case token.Percent:
+		err = vm.binaryArith(ir.OpMod)
+	case token.Lt:
+		err = vm.binaryCmp(ir.OpLt)
+	case token.LtEq:
+		err = vm.binaryCmp(ir.OpLte)
+	case token.Gt:
+		err = vm.binaryCmp(ir.OpGt)
+	case token.GtEq:
+		err = vm.binaryCmp(ir.OpGte)
+	case token.Eq:
+		err = vm.binaryEq()
+	case token.NotEq:
+		err = vm.binaryNeq()
+	default:
+		return value.Value{}, fmt.Errorf("unsupported operator %s", e.Op)
+	}
+	if err != nil {
+		return value.Value{}, err
+	}
+	return vm.pop()
+}
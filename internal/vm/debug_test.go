@@ -0,0 +1,275 @@
+package vm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"avenir/internal/ir"
+	"avenir/internal/runtime"
+	"avenir/internal/value"
+)
+
+// debugSession runs a module under a debugger on another goroutine.
+type debugSession struct {
+	t     *testing.T
+	d     *Debugger
+	stops chan StopEvent
+	done  chan error
+	res   value.Value
+}
+
+func startDebugSession(t *testing.T, mod *ir.Module, d *Debugger) *debugSession {
+	s := &debugSession{t: t, d: d, stops: make(chan StopEvent, 1), done: make(chan error, 1)}
+	d.OnStop = func(ev StopEvent) { s.stops <- ev }
+	machine := NewVM(mod, runtime.DefaultEnv())
+	machine.SetDebugger(d)
+	go func() {
+		var err error
+		s.res, err = machine.RunMain()
+		s.done <- err
+	}()
+	return s
+}
+
+// stop waits for the program to stop at line.
+func (s *debugSession) stop(reason StopReason, line int) StopEvent {
+	s.t.Helper()
+	select {
+	case ev := <-s.stops:
+		if ev.Reason != reason || ev.Line != line {
+			s.t.Fatalf("stopped for %s at line %d, want %s at line %d", ev.Reason, ev.Line, reason, line)
+		}
+		return ev
+	case err := <-s.done:
+		s.t.Fatalf("program ended (%v), want a stop at line %d", err, line)
+	case <-time.After(5 * time.Second):
+		s.t.Fatalf("timed out waiting for a stop at line %d", line)
+	}
+	return StopEvent{}
+}
+
+func (s *debugSession) end() error {
+	s.t.Helper()
+	select {
+	case err := <-s.done:
+		return err
+	case ev := <-s.stops:
+		s.t.Fatalf("unexpected stop at line %d", ev.Line)
+	case <-time.After(5 * time.Second):
+		s.t.Fatalf("timed out waiting for the program to end")
+	}
+	return nil
+}
+
+// values lists variables as name=value pairs.
+func values(vars []Variable) []string {
+	var out []string
+	for _, v := range vars {
+		out = append(out, v.Name+"="+v.Value)
+	}
+	return out
+}
+
+const debugLoopSrc = `pckg main;
+
+fun add(a | int, b | int) | int {
+    var sum | int = a + b;
+    return sum;
+}
+
+fun main() | int {
+    var xs | list<int> = [1, 2, 3];
+    var total | int = 0;
+    for (x in xs) {
+        total = add(total, x);
+    }
+    return total;
+}
+`
+
+func TestDebugger_Breakpoints(t *testing.T) {
+	mod, path := compileFile(t, debugLoopSrc)
+	d := NewDebugger(false)
+	if got := d.SetBreakpoints(mod, path, []int{4, 7}); !reflect.DeepEqual(got, []int{4, 9}) {
+		t.Fatalf("breakpoint lines = %v, want [4 9]", got)
+	}
+	d.SetBreakpoints(mod, path, []int{4})
+	s := startDebugSession(t, mod, d)
+
+	for _, want := range [][]string{{"a=0", "b=1"}, {"a=1", "b=2"}, {"a=3", "b=3"}} {
+		ev := s.stop(StopBreakpoint, 4)
+		if ev.Thread != 1 || ev.File != path {
+			t.Fatalf("stop event = %+v", ev)
+		}
+		trace, err := d.StackTrace(ev.Thread)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trace) != 2 || trace[0] != (StackFrame{"main.add", path, 4}) || trace[1] != (StackFrame{"main.main", path, 12}) {
+			t.Fatalf("stack trace = %+v", trace)
+		}
+		scopes, err := d.Scopes(ev.Thread, 0)
+		if err != nil || len(scopes) != 1 || scopes[0].Name != "Locals" {
+			t.Fatalf("scopes = %+v, %v", scopes, err)
+		}
+		locals, err := d.Variables(scopes[0].Ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := values(locals); !reflect.DeepEqual(got, want) {
+			t.Fatalf("locals = %v, want %v", got, want)
+		}
+		if err := d.Continue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.end(); err != nil {
+		t.Fatalf("RunMain: %v", err)
+	}
+	if s.res.Int() != 6 {
+		t.Fatalf("result = %v, want 6", s.res)
+	}
+}
+
+func TestDebugger_InspectCallerFrame(t *testing.T) {
+	mod, path := compileFile(t, debugLoopSrc)
+	d := NewDebugger(false)
+	d.SetBreakpoints(mod, path, []int{5})
+	s := startDebugSession(t, mod, d)
+
+	s.stop(StopBreakpoint, 5)
+	scopes, err := d.Scopes(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locals, err := d.Variables(scopes[0].Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values(locals); !reflect.DeepEqual(got, []string{"xs=[1, 2, 3]", "total=0", "x=1"}) {
+		t.Fatalf("caller locals = %v", got)
+	}
+	if locals[0].Type != "list" || locals[0].Ref == 0 {
+		t.Fatalf("xs = %+v, want an expandable list", locals[0])
+	}
+	elems, err := d.Variables(locals[0].Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values(elems); !reflect.DeepEqual(got, []string{"0=1", "1=2", "2=3"}) {
+		t.Fatalf("elements = %v", got)
+	}
+
+	for expr, want := range map[string]string{
+		"sum":                "1",
+		"a + b * 10":         "10",
+		`"n=${sum}" + "!"`:   `"n=1!"`,
+		"sum == 1 && !false": "true",
+	} {
+		v, err := d.Evaluate(1, 0, expr)
+		if err != nil || v.Value != want {
+			t.Errorf("Evaluate(%q) = %q, %v; want %q", expr, v.Value, err, want)
+		}
+	}
+	if v, err := d.Evaluate(1, 1, "xs[2] - total"); err != nil || v.Value != "3" {
+		t.Errorf("Evaluate in caller = %q, %v; want 3", v.Value, err)
+	}
+	for expr, want := range map[string]string{
+		"missing":   `unknown name "missing"`,
+		"add(1, 2)": "calls are not supported",
+		"xs[5]":     "index out of range",
+		"a +":       "expected",
+	} {
+		frame := 0
+		if strings.HasPrefix(expr, "xs") {
+			frame = 1
+		}
+		if _, err := d.Evaluate(1, frame, expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Evaluate(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+
+	d.SetBreakpoints(mod, path, nil)
+	if err := d.Continue(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.end(); err != nil {
+		t.Fatalf("RunMain: %v", err)
+	}
+	if _, err := d.StackTrace(1); !errors.Is(err, ErrNotStopped) {
+		t.Fatalf("StackTrace after the end = %v, want ErrNotStopped", err)
+	}
+}
+
+func TestDebugger_Stepping(t *testing.T) {
+	mod, _ := compileFile(t, debugLoopSrc)
+	d := NewDebugger(true)
+	s := startDebugSession(t, mod, d)
+
+	s.stop(StopEntry, 9)
+	steps := []struct {
+		step func() error
+		line int
+	}{
+		{d.StepOver, 10},
+		{d.StepOver, 11},
+		{d.StepOver, 12},
+		{d.StepIn, 4},
+		{d.StepOver, 5},
+		{d.StepOut, 11},
+		{d.StepOver, 12},
+		{d.StepOver, 11},
+	}
+	for _, st := range steps {
+		if err := st.step(); err != nil {
+			t.Fatal(err)
+		}
+		s.stop(StopStep, st.line)
+	}
+	if err := d.Continue(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.end(); err != nil {
+		t.Fatalf("RunMain: %v", err)
+	}
+}
+
+func TestDebugger_TasksAndTerminate(t *testing.T) {
+	mod, path := compileFile(t, `pckg main;
+
+fun square(x | int) | int {
+    return x * x;
+}
+
+async fun main() | int {
+    var a | Future<int> = spawn square(7);
+    return await a;
+}
+`)
+	d := NewDebugger(false)
+	d.SetBreakpoints(mod, path, []int{4})
+	s := startDebugSession(t, mod, d)
+
+	ev := s.stop(StopBreakpoint, 4)
+	if ev.Thread != 2 {
+		t.Fatalf("stopped in thread %d, want the spawned task", ev.Thread)
+	}
+	if got := d.Threads(); !reflect.DeepEqual(got, []Thread{{1, "main"}, {2, "task 2: main.square"}}) {
+		t.Fatalf("threads = %+v", got)
+	}
+	trace, err := d.StackTrace(1)
+	if err != nil || len(trace) != 1 || trace[0].Line != 9 {
+		t.Fatalf("main task trace = %+v, %v", trace, err)
+	}
+	if v, err := d.Evaluate(2, 0, "x"); err != nil || v.Value != "7" {
+		t.Fatalf("Evaluate(x) = %q, %v", v.Value, err)
+	}
+
+	d.Terminate()
+	if err := s.end(); !errors.Is(err, ErrTerminated) {
+		t.Fatalf("RunMain error = %v, want ErrTerminated", err)
+	}
+}
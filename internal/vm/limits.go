@@ -0,0 +1,169 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"avenir/internal/value"
+)
+
+// Limits bounds the resources a program may use, for hosts that run
+// untrusted code. Zero fields mean no limit.
+type Limits struct {
+	// MaxInstructions caps the number of executed instructions, summed over
+	// all tasks.
+	MaxInstructions int64
+	// MaxCallDepth caps the number of nested calls of a task. Exceeding it
+	// throws an error that Avenir code can catch.
+	MaxCallDepth int
+	// MaxMemory caps the approximate number of bytes allocated for strings,
+	// bytes, lists, dicts and structs over the whole run. Memory freed by the
+	// garbage collector is not credited back.
+	MaxMemory int64
+	// Context aborts the program once it is done, e.g. at a deadline. It is
+	// checked between instructions and while the event loop waits, but does
+	// not interrupt a builtin that is blocked in a host call.
+	Context context.Context
+}
+
+// Errors wrapped by LimitError; test for them with errors.Is.
+var (
+	ErrInstructionLimit = errors.New("instruction limit exceeded")
+	ErrCallDepthLimit   = errors.New("call depth limit exceeded")
+	ErrMemoryLimit      = errors.New("memory limit exceeded")
+)
+
+// LimitError is returned by RunMain when the program exceeded one of its
+// Limits. Err is one of the Err*Limit values, or the context's error when
+// Limits.Context is done.
+type LimitError struct {
+	Err error
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("%v (max %d)", e.Err, e.Max)
+	}
+	return fmt.Sprintf("execution aborted: %v", e.Err)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Fatal reports whether the error ends the whole program. Only call depth
+// errors can be caught by Avenir code; the event loop stops on the others.
+func (e *LimitError) Fatal() bool {
+	return !errors.Is(e.Err, ErrCallDepthLimit)
+}
+
+// contextCheckInterval is how many instructions run between context checks.
+const contextCheckInterval = 1024
+
+// budget tracks usage against Limits. It is shared by a VM and the child VMs
+// running its tasks, which all run on the event loop goroutine.
+type budget struct {
+	limits       Limits
+	instructions int64
+	memory       int64
+	// err is the fatal limit error once one was hit; the program cannot
+	// continue after it.
+	err error
+}
+
+// SetLimits applies limits to the program run by vm and resets the usage
+// counted so far. Call it before RunMain, or before each Call to give every
+// call a fresh budget.
+func (vm *VM) SetLimits(limits Limits) {
+	*vm.budget = budget{limits: limits}
+}
+
+// step counts one instruction and checks the instruction and context limits.
+func (b *budget) step() error {
+	if b.err != nil {
+		return b.err
+	}
+	b.instructions++
+	if max := b.limits.MaxInstructions; max > 0 && b.instructions > max {
+		b.err = &LimitError{Err: ErrInstructionLimit, Max: max}
+		return b.err
+	}
+	if ctx := b.limits.Context; ctx != nil && b.instructions%contextCheckInterval == 0 {
+		if err := ctx.Err(); err != nil {
+			b.err = &LimitError{Err: err}
+			return b.err
+		}
+	}
+	return nil
+}
+
+// charge accounts for a newly allocated value.
+func (b *budget) charge(v value.Value) error {
+	max := b.limits.MaxMemory
+	if max <= 0 {
+		return nil
+	}
+	b.memory += valueSize(v)
+	if b.memory > max {
+		b.err = &LimitError{Err: ErrMemoryLimit, Max: max}
+		return b.err
+	}
+	return nil
+}
+
+// checkDepth returns a catchable error when a call would exceed the call
+// depth limit.
+func (b *budget) checkDepth(depth int) error {
+	if max := b.limits.MaxCallDepth; max > 0 && depth >= max {
+		return &LimitError{Err: ErrCallDepthLimit, Max: int64(max)}
+	}
+	return nil
+}
+
+// valueSlotSize is the size of a value.Value, which each list element,
+// struct field and set or dict entry takes.
+const valueSlotSize = int64(unsafe.Sizeof(value.Value{}))
+
+// valueSize approximates the bytes allocated for v itself. Nested values are
+// counted when they are created, so only their slots are counted here, and
+// scalars live on the stack.
+func valueSize(v value.Value) int64 {
+	switch v.Kind {
+	case value.KindString:
+		return valueSlotSize + int64(len(v.Str()))
+	case value.KindBytes:
+		return valueSlotSize + int64(len(v.Bytes()))
+	case value.KindList:
+		return valueSlotSize * int64(1+len(v.List()))
+	case value.KindBigInt:
+		return valueSlotSize + int64(v.BigInt().BitLen()+7)/8
+	case value.KindDecimal:
+		return valueSlotSize + int64(v.Decimal().BitLen()+7)/8
+	case value.KindSet:
+		size := valueSlotSize
+		for e := range v.Set().All() {
+			size += valueSlotSize
+			if e.Kind == value.KindString {
+				size += int64(len(e.Str()))
+			}
+		}
+		return size
+	case value.KindDict:
+		size := valueSlotSize
+		for k := range v.Dict().All() {
+			size += valueSlotSize
+			if k.Kind == value.KindString {
+				size += int64(len(k.Str()))
+			}
+		}
+		return size
+	case value.KindStruct:
+		if v.Struct() != nil {
+			return valueSlotSize * int64(1+len(v.Struct().Fields))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"fmt"
+
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// methodCacheSize bounds the receiver types cached by one OpCallMethod site.
+// Sites that see more types resolve the method again on each call.
+const methodCacheSize = 4
+
+// methodTarget is what an OpCallMethod site calls for one receiver type:
+// a function of the module, or a builtin method when builtin is set.
+type methodTarget struct {
+	kind    value.Kind
+	typeIdx int // struct type index of KindStruct receivers, -1 otherwise
+	fn      int
+	builtin *builtins.Builtin
+}
+
+// methodSite is the inline cache of one OpCallMethod site, holding the
+// targets of the receiver types it has seen in the order it saw them.
+type methodSite struct {
+	targets [methodCacheSize]methodTarget
+	n       int
+}
+
+// lookupMethod returns the target of call site site for receiver recv,
+// which is called with numArgs arguments including the receiver.
+func (vm *VM) lookupMethod(site int, recv value.Value, numArgs int) (methodTarget, error) {
+	t := vm.builtinTable
+	if site < 0 || site >= len(vm.mod.MethodCalls) {
+		return methodTarget{}, fmt.Errorf("invalid method call index %d", site)
+	}
+	if t.sites == nil {
+		t.sites = make([]methodSite, len(vm.mod.MethodCalls))
+	}
+	typeIdx := -1
+	if recv.Kind == value.KindStruct {
+		typeIdx = recv.Struct().TypeIndex
+	}
+	s := &t.sites[site]
+	for i := 0; i < s.n; i++ {
+		if c := s.targets[i]; c.kind == recv.Kind && c.typeIdx == typeIdx {
+			return c, nil
+		}
+	}
+	target, err := vm.resolveMethod(vm.mod.MethodCalls[site], recv.Kind, typeIdx, numArgs)
+	if err != nil {
+		return methodTarget{}, err
+	}
+	if s.n < methodCacheSize {
+		s.targets[s.n] = target
+		s.n++
+	}
+	return target, nil
+}
+
+// resolveMethod finds method name of a receiver type: an instance method
+// for structs, a builtin method for other values.
+func (vm *VM) resolveMethod(name string, kind value.Kind, typeIdx, numArgs int) (methodTarget, error) {
+	target := methodTarget{kind: kind, typeIdx: typeIdx, fn: -1}
+	if kind == value.KindStruct {
+		if typeIdx < 0 || typeIdx >= len(vm.mod.StructTypes) {
+			return methodTarget{}, fmt.Errorf("invalid struct type %d", typeIdx)
+		}
+		st := vm.mod.StructTypes[typeIdx]
+		idx, ok := st.Methods[name]
+		if !ok {
+			return methodTarget{}, fmt.Errorf("%s has no method %q", st.Name, name)
+		}
+		if fn := vm.mod.Functions[idx]; fn.NumParams != numArgs {
+			return methodTarget{}, fmt.Errorf("%s expects %d arguments, got %d", fn.Name, fn.NumParams, numArgs)
+		}
+		target.fn = idx
+		return target, nil
+	}
+	typeKind, ok := builtinTypeKind(kind)
+	if !ok {
+		return methodTarget{}, fmt.Errorf("value kind %d has no method %q", kind, name)
+	}
+	registry := vm.builtinTable.registry
+	if registry == nil {
+		registry = builtins.Default()
+	}
+	b := registry.LookupMethod(typeKind, name)
+	if b == nil {
+		return methodTarget{}, fmt.Errorf("%s has no method %q", typeKind, name)
+	}
+	if numArgs > b.Meta.Arity || numArgs < b.Meta.Arity-b.Meta.Optional {
+		return methodTarget{}, fmt.Errorf("%s expects %d arguments, got %d", b.Meta.Symbol(), b.Meta.Arity, numArgs)
+	}
+	target.builtin = b
+	return target, nil
+}
+
+// builtinTypeKind maps the kind of a runtime value to the receiver type of
+// its builtin methods.
+func builtinTypeKind(kind value.Kind) (builtins.TypeKind, bool) {
+	switch kind {
+	case value.KindInt:
+		return builtins.TypeInt, true
+	case value.KindFloat:
+		return builtins.TypeFloat, true
+	case value.KindString:
+		return builtins.TypeString, true
+	case value.KindBool:
+		return builtins.TypeBool, true
+	case value.KindList:
+		return builtins.TypeList, true
+	case value.KindDict:
+		return builtins.TypeDict, true
+	case value.KindError:
+		return builtins.TypeError, true
+	case value.KindBytes:
+		return builtins.TypeBytes, true
+	case value.KindSet:
+		return builtins.TypeSet, true
+	case value.KindBigInt:
+		return builtins.TypeBigInt, true
+	case value.KindDecimal:
+		return builtins.TypeDecimal, true
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"avenir/internal/ir"
+	"avenir/internal/lexer"
+	"avenir/internal/parser"
+	"avenir/internal/runtime"
+)
+
+// compileSource compiles a single-file program, which unlike compileFile
+// may declare structs under any name.
+func compileSource(t testing.TB, src string) *ir.Module {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parser errors: %v", errs)
+	}
+	mod, errs := ir.Compile(prog)
+	if len(errs) > 0 {
+		t.Fatalf("compile errors: %v", errs)
+	}
+	return mod
+}
+
+// shapesProgram declares n struct types satisfying interface Shape and
+// returns the sum of their areas over rounds passes through a list<Shape>.
+func shapesProgram(n, rounds int) string {
+	var b strings.Builder
+	b.WriteString("pckg main;\n\ninterface Shape {\n    fun area() | int\n}\n")
+	var items []string
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\nstruct S%d {\n    k | int\n}\n\nfun (s | S%d).area() | int {\n    return s.k * %d;\n}\n", i, i, i+1)
+		items = append(items, fmt.Sprintf("S%d{k = 1}", i))
+	}
+	fmt.Fprintf(&b, `
+fun main() | int {
+    var shapes | list<Shape> = [%s];
+    var total | int = 0;
+    for (var i | int = 0; i < %d; i = i + 1) {
+        for (s in shapes) {
+            total = total + s.area();
+        }
+    }
+    return total;
+}
+`, strings.Join(items, ", "), rounds)
+	return b.String()
+}
+
+func TestVM_MethodCacheTypes(t *testing.T) {
+	for _, n := range []int{1, 3, methodCacheSize + 2} {
+		mod := compileSource(t, shapesProgram(n, 3))
+		m := NewVM(mod, runtime.DefaultEnv())
+		v, err := m.RunMain()
+		if err != nil {
+			t.Fatalf("%d types: %v", n, err)
+		}
+		if want := int64(3 * n * (n + 1) / 2); v.Int() != want {
+			t.Fatalf("%d types: result %d, want %d", n, v.Int(), want)
+		}
+		site := m.builtinTable.sites[0]
+		if site.n != min(n, methodCacheSize) {
+			t.Fatalf("%d types: %d cached targets", n, site.n)
+		}
+		for i, target := range site.targets[:site.n] {
+			if target.typeIdx != i || mod.Functions[target.fn].NumParams != 1 {
+				t.Fatalf("%d types: target %d = %+v", n, i, target)
+			}
+		}
+	}
+}
+
+func TestVM_MethodCacheMissingMethod(t *testing.T) {
+	mod := compileSource(t, shapesProgram(2, 1))
+	// A module whose struct lacks the method fails at the call, not before.
+	delete(mod.StructTypes[1].Methods, "area")
+	_, err := NewVM(mod, runtime.DefaultEnv()).RunMain()
+	if err == nil || !strings.Contains(err.Error(), `S1 has no method "area"`) {
+		t.Fatalf("RunMain error = %v", err)
+	}
+}
+
+func BenchmarkVM_InterfaceCalls(b *testing.B) {
+	mod := compileSource(b, shapesProgram(3, 200))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, err := NewVM(mod, runtime.DefaultEnv()).RunMain()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if v.Int() != 1200 {
+			b.Fatalf("result = %d, want 1200", v.Int())
+		}
+	}
+}
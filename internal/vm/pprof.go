@@ -0,0 +1,233 @@
+package vm
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"sort"
+	"time"
+)
+
+// WriteCPUProfile writes the CPU samples in pprof format.
+func (p *Profiler) WriteCPUProfile(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(w, p.cpu, []valueType{{"samples", "count"}, {"cpu", "nanoseconds"}}, "", p.period)
+}
+
+// WriteAllocProfile writes the values created per call site in pprof
+// format, labeled with their kind.
+func (p *Profiler) WriteAllocProfile(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(w, p.allocs, []valueType{{"alloc_objects", "count"}, {"alloc_space", "bytes"}}, "kind", 0)
+}
+
+// WriteBuiltinProfile writes the calls and time spent per builtin in pprof
+// format. Each builtin is the leaf function of its samples.
+func (p *Profiler) WriteBuiltinProfile(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.write(w, p.builtins, []valueType{{"calls", "count"}, {"time", "nanoseconds"}}, "", 0)
+}
+
+type valueType struct {
+	typ, unit string
+}
+
+// Field numbers of the messages in pprof's profile.proto.
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+	sampleLabel      = 3
+
+	labelKey = 1
+	labelStr = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID       = 1
+	functionName     = 2
+	functionFilename = 4
+)
+
+// write encodes samples as a gzipped profile.proto message. label names
+// the label of the samples, if they have one. period is the sampling period
+// of the last value type, or 0 for profiles that are not sampled.
+func (p *Profiler) write(w io.Writer, smps samples, types []valueType, label string, period time.Duration) error {
+	strs := stringTable{index: map[string]int64{"": 0}, list: []string{""}}
+	var b protoBuffer
+
+	for _, t := range types {
+		b.message(profileSampleType, func(b *protoBuffer) {
+			b.varint(valueTypeType, strs.id(t.typ))
+			b.varint(valueTypeUnit, strs.id(t.unit))
+		})
+	}
+
+	// Samples are sorted so that the same run writes the same profile.
+	keys := make([]string, 0, len(smps))
+	for k := range smps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	used := make(map[uint64]bool)
+	for _, k := range keys {
+		smp := smps[k]
+		for _, id := range smp.stack {
+			used[id] = true
+		}
+		b.message(profileSample, func(b *protoBuffer) {
+			b.uints(sampleLocationID, smp.stack)
+			b.ints(sampleValue, smp.values)
+			if label != "" {
+				b.message(sampleLabel, func(b *protoBuffer) {
+					b.varint(labelKey, strs.id(label))
+					b.varint(labelStr, strs.id(smp.label))
+				})
+			}
+		})
+	}
+
+	type function struct{ name, file string }
+	functions := make(map[function]uint64)
+	var funcList []function
+	for i, loc := range p.locList {
+		id := uint64(i + 1)
+		if !used[id] {
+			continue
+		}
+		f := function{name: loc.builtin}
+		if loc.fn != nil {
+			f = function{name: loc.fn.Name, file: loc.fn.File}
+		}
+		fid, ok := functions[f]
+		if !ok {
+			funcList = append(funcList, f)
+			fid = uint64(len(funcList))
+			functions[f] = fid
+		}
+		b.message(profileLocation, func(b *protoBuffer) {
+			b.uvarint(locationID, id)
+			b.message(locationLine, func(b *protoBuffer) {
+				b.uvarint(lineFunctionID, fid)
+				b.varint(lineLine, int64(loc.line))
+			})
+		})
+	}
+	for i, f := range funcList {
+		b.message(profileFunction, func(b *protoBuffer) {
+			b.uvarint(functionID, uint64(i+1))
+			b.varint(functionName, strs.id(f.name))
+			b.varint(functionFilename, strs.id(f.file))
+		})
+	}
+
+	if !p.start.IsZero() {
+		b.varint(profileTimeNanos, p.start.UnixNano())
+		if !p.end.IsZero() {
+			b.varint(profileDurationNanos, int64(p.end.Sub(p.start)))
+		}
+	}
+	if period > 0 {
+		last := types[len(types)-1]
+		b.message(profilePeriodType, func(b *protoBuffer) {
+			b.varint(valueTypeType, strs.id(last.typ))
+			b.varint(valueTypeUnit, strs.id(last.unit))
+		})
+		b.varint(profilePeriod, int64(period))
+	}
+	// The string table comes last, once every string is known.
+	for _, s := range strs.list {
+		b.bytes(profileStringTable, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.buf); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type stringTable struct {
+	index map[string]int64
+	list  []string
+}
+
+func (t *stringTable) id(s string) int64 {
+	id, ok := t.index[s]
+	if !ok {
+		id = int64(len(t.list))
+		t.list = append(t.list, s)
+		t.index[s] = id
+	}
+	return id
+}
+
+// protoBuffer encodes protocol buffer fields.
+type protoBuffer struct {
+	buf []byte
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.buf = binary.AppendUvarint(b.buf, uint64(field<<3|wireType))
+}
+
+// uvarint writes a varint field. Zero is the default and left out.
+func (b *protoBuffer) uvarint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.buf = binary.AppendUvarint(b.buf, v)
+}
+
+func (b *protoBuffer) varint(field int, v int64) {
+	b.uvarint(field, uint64(v))
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.key(field, 2)
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(data)))
+	b.buf = append(b.buf, data...)
+}
+
+// uints and ints write packed repeated fields.
+func (b *protoBuffer) uints(field int, vs []uint64) {
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.buf = binary.AppendUvarint(packed.buf, v)
+	}
+	b.bytes(field, packed.buf)
+}
+
+func (b *protoBuffer) ints(field int, vs []int64) {
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.buf = binary.AppendUvarint(packed.buf, uint64(v))
+	}
+	b.bytes(field, packed.buf)
+}
+
+func (b *protoBuffer) message(field int, encode func(b *protoBuffer)) {
+	var inner protoBuffer
+	encode(&inner)
+	b.bytes(field, inner.buf)
+}
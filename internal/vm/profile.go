@@ -0,0 +1,246 @@
+package vm
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"avenir/internal/ir"
+	"avenir/internal/runtime"
+	"avenir/internal/value"
+)
+
+// defaultProfilePeriod is the CPU sampling period, 100 samples a second as
+// in Go's own profiler.
+const defaultProfilePeriod = 10 * time.Millisecond
+
+// Profiler records where an Avenir program spends its time. Stacks are the
+// Avenir call stacks of the VM frames, so the profiles show Avenir functions
+// and source lines rather than the interpreter's Go code. It records:
+//
+//   - CPU samples of the running task's stack, taken between Start and Stop
+//   - values created per call site: lists, dicts, structs, strings, bytes
+//     and closures, including those returned by builtins
+//   - calls and time spent per builtin, such as SQL queries or HTTP requests
+//
+// Attach a profiler with VM.SetProfiler before RunMain and write the
+// profiles in pprof format once the program is done.
+type Profiler struct {
+	period time.Duration
+	tick   atomic.Bool // a CPU sample is due
+	stop   chan struct{}
+	start  time.Time
+	end    time.Time
+
+	mu        sync.Mutex
+	locations map[location]uint64
+	locList   []location
+	cpu       samples
+	allocs    samples
+	builtins  samples
+}
+
+// location is a source line of a function in a profile. Builtins appear
+// as leaf locations with fn nil and their symbol name.
+type location struct {
+	fn      *ir.Function
+	builtin string
+	line    int
+}
+
+// samples aggregates values by stack and label.
+type samples map[string]*sample
+
+type sample struct {
+	stack  []uint64 // location IDs, innermost first
+	label  string
+	values []int64
+}
+
+func (s samples) add(stack []uint64, label string, values ...int64) {
+	key := label + "/" + stackKey(stack)
+	smp := s[key]
+	if smp == nil {
+		smp = &sample{stack: stack, label: label, values: make([]int64, len(values))}
+		s[key] = smp
+	}
+	for i, v := range values {
+		smp.values[i] += v
+	}
+}
+
+func stackKey(stack []uint64) string {
+	buf := make([]byte, 0, len(stack)*2)
+	for _, id := range stack {
+		buf = binary.AppendUvarint(buf, id)
+	}
+	return string(buf)
+}
+
+// NewProfiler returns a profiler with nothing recorded.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		period:    defaultProfilePeriod,
+		locations: make(map[location]uint64),
+		cpu:       make(samples),
+		allocs:    make(samples),
+		builtins:  make(samples),
+	}
+}
+
+// SetProfiler makes vm and the tasks it spawns record into p.
+func (vm *VM) SetProfiler(p *Profiler) {
+	vm.prof = p
+}
+
+// Start starts taking CPU samples.
+func (p *Profiler) Start() {
+	if p.stop != nil {
+		return
+	}
+	p.start = time.Now()
+	p.stop = make(chan struct{})
+	ticker := time.NewTicker(p.period)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.tick.Store(true)
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops taking CPU samples.
+func (p *Profiler) Stop() {
+	if p.stop == nil || !p.end.IsZero() {
+		return
+	}
+	close(p.stop)
+	p.end = time.Now()
+}
+
+// allocated accounts for a value created by the current instruction
+// against the memory limit and in the profile.
+func (vm *VM) allocated(v value.Value) error {
+	if vm.prof != nil {
+		vm.prof.allocation(vm, v)
+	}
+	return vm.budget.charge(v)
+}
+
+// sampleCPU takes a CPU sample of vm's stack when one is due. The sample
+// stands for one period of the program running, so time spent waiting
+// between ticks, e.g. for timers, is not counted more than once.
+func (p *Profiler) sampleCPU(vm *VM) {
+	if !p.tick.Load() || !p.tick.CompareAndSwap(true, false) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cpu.add(p.stack(vm, ""), "", 1, int64(p.period))
+}
+
+// allocation records a value created by the current instruction of vm.
+func (p *Profiler) allocation(vm *VM, v value.Value) {
+	var size int64
+	switch v.Kind {
+	case value.KindString, value.KindBytes, value.KindList, value.KindDict, value.KindSet, value.KindStruct, value.KindBigInt, value.KindDecimal:
+		size = valueSize(v)
+	case value.KindClosure:
+		size = valueSlotSize
+		if v.Closure() != nil {
+			size *= int64(1 + len(v.Closure().Upvalues))
+		}
+	default:
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.allocs.add(p.stack(vm, ""), kindName(v.Kind), 1, size)
+}
+
+func kindName(k value.Kind) string {
+	switch k {
+	case value.KindString:
+		return "string"
+	case value.KindBytes:
+		return "bytes"
+	case value.KindList:
+		return "list"
+	case value.KindDict:
+		return "dict"
+	case value.KindSet:
+		return "set"
+	case value.KindBigInt:
+		return "bigint"
+	case value.KindDecimal:
+		return "decimal"
+	case value.KindStruct:
+		return "struct"
+	case value.KindClosure:
+		return "closure"
+	}
+	return "value"
+}
+
+// builtinCall records a call of builtin name made by the current
+// instruction of vm that took d.
+func (p *Profiler) builtinCall(vm *VM, name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.builtins.add(p.stack(vm, name), "", 1, int64(d))
+}
+
+// asyncBuiltinCall records a call of async builtin name made by the current
+// instruction of vm once the host work behind ah completes. A call that
+// never completes is never recorded.
+func (p *Profiler) asyncBuiltinCall(vm *VM, name string, ah *runtime.AsyncHandle) {
+	start := time.Now()
+	p.mu.Lock()
+	stack := p.stack(vm, name)
+	p.mu.Unlock()
+	ah.OnComplete(func() {
+		d := time.Since(start)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.builtins.add(stack, "", 1, int64(d))
+	})
+}
+
+// stack returns the location IDs of vm's call stack, innermost first,
+// under a leaf for the builtin being called, if any. p.mu must be held.
+func (p *Profiler) stack(vm *VM, builtin string) []uint64 {
+	stack := make([]uint64, 0, len(vm.frames)+1)
+	if builtin != "" {
+		stack = append(stack, p.locationID(location{builtin: builtin}))
+	}
+	for i := len(vm.frames) - 1; i >= 0; i-- {
+		fr := &vm.frames[i]
+		// Callers have moved past the call instruction.
+		ip := fr.IP
+		if i < len(vm.frames)-1 {
+			ip--
+		}
+		line := 0
+		if ip >= 0 && ip < len(fr.Fn.Chunk.Lines) {
+			line = fr.Fn.Chunk.Lines[ip]
+		}
+		stack = append(stack, p.locationID(location{fn: fr.Fn, line: line}))
+	}
+	return stack
+}
+
+func (p *Profiler) locationID(loc location) uint64 {
+	id, ok := p.locations[loc]
+	if !ok {
+		p.locList = append(p.locList, loc)
+		id = uint64(len(p.locList))
+		p.locations[loc] = id
+	}
+	return id
+}
@@ -0,0 +1,196 @@
+package vm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	goruntime "runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"avenir/internal/runtime"
+	"avenir/internal/value"
+)
+
+// profileStrings decodes a written profile far enough to return its string
+// table.
+func profileStrings(t *testing.T, data []byte) []string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var strs []string
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		msg = msg[n:]
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(msg)
+			msg = msg[n:]
+		case 2:
+			size, n := binary.Uvarint(msg)
+			if key>>3 == profileStringTable {
+				strs = append(strs, string(msg[n:n+int(size)]))
+			}
+			msg = msg[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type in key %d", key)
+		}
+	}
+	return strs
+}
+
+// leaf describes the innermost location of a sample.
+func (p *Profiler) leaf(smp *sample) (string, int) {
+	loc := p.locList[smp.stack[0]-1]
+	if loc.fn == nil {
+		return loc.builtin, 0
+	}
+	return loc.fn.Name, loc.line
+}
+
+func TestProfiler_AllocationsAndBuiltins(t *testing.T) {
+	mod, _ := compileFile(t, `pckg main;
+
+fun pair(x | int) | list<int> {
+    return [x, x];
+}
+
+fun main() | void {
+    var n | int = 0;
+    for (var i | int = 0; i < 3; i = i + 1) {
+        n = n + pair(i)[1];
+    }
+    var f | fun() | int = fun() | int {
+        return n;
+    };
+    print("n=${f()}");
+}
+`)
+	p := NewProfiler()
+	machine := NewVM(mod, runtime.DefaultEnv())
+	machine.SetProfiler(p)
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int64)
+	for _, smp := range p.allocs {
+		fn, line := p.leaf(smp)
+		counts[fmt.Sprintf("%s %s:%d", smp.label, fn, line)] += smp.values[0]
+		if len(smp.stack) != 2 && fn == "main.pair" {
+			t.Errorf("stack of pair = %v, want pair and main", smp.stack)
+		}
+	}
+	for site, want := range map[string]int64{"list main.pair:4": 3, "closure main.main:12": 1} {
+		if counts[site] != want {
+			t.Errorf("allocations at %s = %d, want %d (all: %v)", site, counts[site], want, counts)
+		}
+	}
+
+	var calls int64
+	for _, smp := range p.builtins {
+		if fn, _ := p.leaf(smp); fn == "print" {
+			calls += smp.values[0]
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("print calls = %d, want 1", calls)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteAllocProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	strs := profileStrings(t, buf.Bytes())
+	for _, want := range []string{"", "alloc_objects", "alloc_space", "kind", "list", "closure", "main.pair"} {
+		if !slices.Contains(strs, want) {
+			t.Errorf("string table %q lacks %q", strs, want)
+		}
+	}
+	if strs[0] != "" {
+		t.Errorf("string table starts with %q, want the empty string", strs[0])
+	}
+}
+
+func TestProfiler_AsyncBuiltinPending(t *testing.T) {
+	// Pending calls are recorded when they complete, without a goroutine
+	// parked on each one in the meantime.
+	p := NewProfiler()
+	machine := &VM{}
+	before := goruntime.NumGoroutine()
+	pending := make([]*runtime.AsyncHandle, 100)
+	for i := range pending {
+		pending[i] = runtime.NewAsyncHandle()
+		p.asyncBuiltinCall(machine, "time.asyncSleep", pending[i])
+	}
+	if after := goruntime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines grew from %d to %d with pending calls", before, after)
+	}
+
+	pending[0].Resolve(value.Int(0))
+	pending[1].Reject(errors.New("cancelled"))
+	var calls int64
+	for _, smp := range p.builtins {
+		calls += smp.values[0]
+	}
+	if calls != 2 {
+		t.Fatalf("recorded %d async calls, want 2", calls)
+	}
+}
+
+func TestProfiler_CPU(t *testing.T) {
+	mod, _ := compileFile(t, `pckg main;
+
+fun spin(n | int) | int {
+    var s | int = 0;
+    for (var i | int = 0; i < n; i = i + 1) {
+        s = s + i % 7;
+    }
+    return s;
+}
+
+fun main() | int {
+    return spin(300000);
+}
+`)
+	p := NewProfiler()
+	p.period = time.Millisecond
+	machine := NewVM(mod, runtime.DefaultEnv())
+	machine.SetProfiler(p)
+	p.Start()
+	if _, err := machine.RunMain(); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+
+	var spin int64
+	for _, smp := range p.cpu {
+		if fn, _ := p.leaf(smp); fn == "main.spin" {
+			spin += smp.values[0]
+		}
+	}
+	if spin == 0 {
+		t.Fatalf("no samples in main.spin: %d samples", len(p.cpu))
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteCPUProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	strs := profileStrings(t, buf.Bytes())
+	for _, want := range []string{"samples", "cpu", "nanoseconds", "main.spin", "main.main"} {
+		if !slices.Contains(strs, want) {
+			t.Errorf("string table %q lacks %q", strs, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"avenir/internal/ir"
 	"avenir/internal/runtime"
@@ -18,6 +19,7 @@ type Frame struct {
 	IP         int // Instruction pointer: index into Fn.Chunk.Code
 	Base       int // Stack index where local variables start
 	DeferStack []DeferCall
+	line       int // source line last reached, maintained while debugging
 }
 
 type DeferCall struct {
@@ -62,11 +64,18 @@ type VM struct {
 
 	closureOverrides []*value.Closure // decorator overrides indexed by function index
 	globals          []value.Value    // module-level variables
+	openUpvalues     []*value.Upvalue // open upvalues pointing into this VM's stack
 
 	scheduler   *runtime.Scheduler
 	currentTask *taskContext
 	suspended   bool
 	resuming    bool
+
+	budget       *budget       // resource usage against Limits, shared with child VMs
+	builtinTable *builtinTable // resolved builtins, shared with child VMs
+	initialized  bool          // module-level variables have been initialized
+	debug        *Debugger     // attached debugger, shared with child VMs
+	prof         *Profiler     // attached profiler, shared with child VMs
 }
 
 func (vm *VM) throwValue(exc value.Value) bool {
@@ -83,6 +92,7 @@ func (vm *VM) throwValue(exc value.Value) bool {
 		}
 
 		vm.frames = vm.frames[:h.FrameIndex+1]
+		vm.closeUpvalues(h.StackSP)
 		vm.sp = h.StackSP
 		vm.push(exc)
 		vm.frames[h.FrameIndex].IP = h.TargetIP
@@ -96,17 +106,113 @@ func (vm *VM) raiseError(err error) bool {
 	if err == nil {
 		return false
 	}
-	return vm.throwValue(value.ErrorValue(err.Error()))
+	var exitErr *builtins.ExitError
+	if errors.As(err, &exitErr) {
+		vm.exitFrames()
+		return false
+	}
+	// Once a fatal limit is hit, errors only unwind to the host; builtins
+	// that called back into Avenir may have wrapped the limit error.
+	if vm.budget.err != nil {
+		return false
+	}
+	return vm.throwValue(vm.errorValue(err))
+}
+
+// thrownError is returned for a throw that no handler caught. It keeps the
+// thrown value, so awaiting the failed task rethrows the original error.
+type thrownError struct {
+	exc value.Value
+}
+
+func (e *thrownError) Error() string {
+	return "unhandled error: " + errorMessage(e.exc)
+}
+
+// errorValue converts an error from a builtin into the value thrown to
+// Avenir code. An uncaught throw from another task is rethrown as is, and
+// permission errors become std.os PermissionDenied structs when the program
+// declares that type.
+func (vm *VM) errorValue(err error) value.Value {
+	var thrown *thrownError
+	if errors.As(err, &thrown) {
+		return thrown.exc
+	}
+	var permErr *builtins.PermissionError
+	if errors.As(err, &permErr) {
+		if exc, ok := vm.permissionDenied(permErr); ok {
+			return exc
+		}
+	}
+	return value.ErrorValue(err.Error())
+}
+
+func (vm *VM) permissionDenied(err *builtins.PermissionError) (value.Value, bool) {
+	if vm.mod == nil {
+		return value.Value{}, false
+	}
+	for i, st := range vm.mod.StructTypes {
+		if st.Name != "PermissionDenied" {
+			continue
+		}
+		if len(st.Fields) == 0 {
+			// Bytecode files keep only struct names; assume the std.os layout.
+			return value.Struct(i, []value.Value{value.Str(err.Op), value.Str(err.Target), value.Str(err.Error())}), true
+		}
+		fields := make([]value.Value, len(st.Fields))
+		for j, f := range st.Fields {
+			switch f.Name {
+			case "operation":
+				fields[j] = value.Str(err.Op)
+			case "target":
+				fields[j] = value.Str(err.Target)
+			case "message":
+				fields[j] = value.Str(err.Error())
+			default:
+				return value.Value{}, false
+			}
+		}
+		return value.Struct(i, fields), true
+	}
+	return value.Value{}, false
+}
+
+// exitFrames unwinds every active frame for os.exit, running pending defers
+// innermost first. Handlers are dropped, so the exit cannot be caught, and
+// errors raised by the defers are ignored.
+func (vm *VM) exitFrames() {
+	vm.handlers = vm.handlers[:0]
+	for len(vm.frames) > 0 {
+		f := vm.frames[len(vm.frames)-1]
+		vm.frames = vm.frames[:len(vm.frames)-1]
+		for i := len(f.DeferStack) - 1; i >= 0; i-- {
+			deferred := f.DeferStack[i]
+			if deferred.Callee.Kind != value.KindClosure || deferred.Callee.Closure() == nil {
+				continue
+			}
+			sp := vm.sp
+			for _, arg := range deferred.Args {
+				vm.push(arg)
+			}
+			depth := len(vm.frames)
+			if _, err := vm.callClosure(deferred.Callee.Closure(), len(deferred.Args)); err != nil && len(vm.frames) > depth {
+				vm.frames = vm.frames[:depth]
+			}
+			vm.sp = sp
+		}
+		vm.closeUpvalues(f.Base)
+		vm.sp = f.Base
+	}
 }
 
 func errorMessage(val value.Value) string {
 	if val.Kind != value.KindError {
 		return val.String()
 	}
-	if val.Error != nil && val.Error.Message != "" {
-		return val.Error.Message
+	if val.Error() != nil && val.Error().Message != "" {
+		return val.Error().Message
 	}
-	return val.Str
+	return val.Str()
 }
 
 // NewVM creates a VM for the given module.
@@ -116,10 +222,15 @@ func NewVM(m *ir.Module, env *runtime.Env) *VM {
 	}
 	if m != nil && len(m.StructTypes) > 0 {
 		names := make([]string, len(m.StructTypes))
+		fields := make([][]string, len(m.StructTypes))
 		for i, st := range m.StructTypes {
 			names[i] = st.Name
+			for _, f := range st.Fields {
+				fields[i] = append(fields[i], f.Name)
+			}
 		}
 		env.SetStructTypeNames(names)
+		env.SetStructFieldNames(fields)
 	}
 	var overrides []*value.Closure
 	var globals []value.Value
@@ -135,18 +246,33 @@ func NewVM(m *ir.Module, env *runtime.Env) *VM {
 		handlers:         make([]exceptionHandler, 0, 16),
 		closureOverrides: overrides,
 		globals:          globals,
+		budget:           &budget{},
+		builtinTable:     &builtinTable{},
 	}
-	// Enable builtins to call closures by setting the closure caller
-	// We need to wrap callClosure to match the ClosureCaller signature
-	env.SetClosureCaller(func(clo *value.Closure, args []value.Value) (value.Value, error) {
+	vm.bindClosureCaller()
+	return vm
+}
+
+// bindClosureCaller lets builtins call closures on this VM's stack.
+// Tasks rebind it each time they run, so callbacks execute on the VM of the
+// task that invoked the builtin.
+func (vm *VM) bindClosureCaller() {
+	vm.env.SetClosureCaller(func(clo *value.Closure, args []value.Value) (value.Value, error) {
 		// Push arguments onto the stack
 		for _, arg := range args {
 			vm.push(arg)
 		}
 		// Call the closure with the number of arguments
-		return vm.callClosure(clo, len(args))
+		result, err := vm.callClosure(clo, len(args))
+		if err != nil {
+			return value.Value{}, err
+		}
+		// callClosure leaves the result on the stack; the builtin receives it directly.
+		if _, err := vm.pop(); err != nil {
+			return value.Value{}, err
+		}
+		return result, nil
 	})
-	return vm
 }
 
 // push/pop
@@ -176,14 +302,19 @@ func (vm *VM) peek(offset int) (value.Value, error) {
 	return vm.stack[idx], nil
 }
 
-// RunMain runs the main function of the module.
+// RunMain runs the main function of the module. When the program exceeds
+// its Limits, the error is a *LimitError.
 func (vm *VM) RunMain() (value.Value, error) {
-	if vm.mod.InitIndex >= 0 && vm.mod.InitIndex < len(vm.mod.Functions) {
-		initFn := vm.mod.Functions[vm.mod.InitIndex]
-		initClo := value.NewClosure(initFn, nil)
-		if _, err := vm.callClosure(initClo.Closure, 0); err != nil {
-			return value.Value{}, fmt.Errorf("module init error: %w", err)
-		}
+	result, err := vm.runMain()
+	if err != nil && vm.budget.err != nil {
+		return value.Value{}, vm.budget.err
+	}
+	return result, err
+}
+
+func (vm *VM) runMain() (value.Value, error) {
+	if err := vm.runInit(); err != nil {
+		return value.Value{}, err
 	}
 
 	if vm.mod.MainIndex < 0 || vm.mod.MainIndex >= len(vm.mod.Functions) {
@@ -192,35 +323,234 @@ func (vm *VM) RunMain() (value.Value, error) {
 	fn := vm.mod.Functions[vm.mod.MainIndex]
 
 	if fn.IsAsync {
-		return vm.runAsyncMain(fn)
+		return vm.runAsyncMain(value.NewClosure(fn, nil).Closure(), nil)
 	}
 
 	cloVal := value.NewClosure(fn, nil)
-	return vm.callClosure(cloVal.Closure, 0)
+	return vm.callClosure(cloVal.Closure(), 0)
+}
+
+// runInit runs the module's __init__ function once.
+func (vm *VM) runInit() error {
+	if vm.initialized {
+		return nil
+	}
+	vm.initialized = true
+	if vm.mod.InitIndex >= 0 && vm.mod.InitIndex < len(vm.mod.Functions) {
+		initFn := vm.mod.Functions[vm.mod.InitIndex]
+		initClo := value.NewClosure(initFn, nil)
+		if _, err := vm.callClosure(initClo.Closure(), 0); err != nil {
+			return fmt.Errorf("module init error: %w", err)
+		}
+		if _, err := vm.pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Call calls the function with the given qualified name, e.g. "main.add",
+// and returns its result. Module-level variables are initialized before the
+// first call unless RunMain already did so, and keep their values between
+// calls. Async functions run on an event loop until they complete.
+func (vm *VM) Call(name string, args ...value.Value) (value.Value, error) {
+	idx, ok := vm.mod.FunctionIndex(name)
+	if !ok {
+		return value.Value{}, fmt.Errorf("unknown function %q", name)
+	}
+	result, err := vm.call(idx, args)
+	if err != nil {
+		vm.reset()
+		if vm.budget.err != nil {
+			return value.Value{}, vm.budget.err
+		}
+		return value.Value{}, err
+	}
+	return result, nil
+}
+
+func (vm *VM) call(idx int, args []value.Value) (value.Value, error) {
+	if err := vm.runInit(); err != nil {
+		return value.Value{}, err
+	}
+	clo := vm.closureOverrides[idx]
+	if clo == nil {
+		clo = value.NewClosure(vm.mod.Functions[idx], nil).Closure()
+	}
+	if len(args) != clo.Fn.NumParams {
+		return value.Value{}, fmt.Errorf("function %s expects %d args, got %d", clo.Fn.Name, clo.Fn.NumParams, len(args))
+	}
+	if clo.Fn.IsAsync {
+		return vm.runAsyncMain(clo, args)
+	}
+	for _, arg := range args {
+		vm.push(arg)
+	}
+	result, err := vm.callClosure(clo, len(args))
+	if err != nil {
+		return value.Value{}, err
+	}
+	if _, err := vm.pop(); err != nil {
+		return value.Value{}, err
+	}
+	return result, nil
+}
+
+// reset drops the frames and handlers left behind by a failed call.
+func (vm *VM) reset() {
+	vm.frames = vm.frames[:0]
+	vm.handlers = vm.handlers[:0]
+	vm.openUpvalues = nil
+	vm.sp = 0
 }
 
 // spawnChild creates a child VM that shares the module, environment, and scheduler
 // but has its own stack and frames for concurrent task execution.
 func (vm *VM) spawnChild() *VM {
 	child := &VM{
-		mod:       vm.mod,
-		stack:     make([]value.Value, 0, 1024),
-		frames:    make([]Frame, 0, 16),
-		env:       vm.env,
-		handlers:  make([]exceptionHandler, 0, 16),
-		globals:   vm.globals,
-		scheduler: vm.scheduler,
+		mod:          vm.mod,
+		stack:        make([]value.Value, 0, 1024),
+		frames:       make([]Frame, 0, 16),
+		env:          vm.env,
+		handlers:     make([]exceptionHandler, 0, 16),
+		globals:      vm.globals,
+		scheduler:    vm.scheduler,
+		budget:       vm.budget,
+		builtinTable: vm.builtinTable,
+		debug:        vm.debug,
+		prof:         vm.prof,
 	}
 	return child
 }
 
-// runAsyncMain runs an async main function using the scheduler and event loop.
-func (vm *VM) runAsyncMain(fn *ir.Function) (value.Value, error) {
+// restoreTask reloads the state saved when a task suspended so that the next
+// callClosure resumes the innermost frame.
+func (vm *VM) restoreTask(tc *taskContext) {
+	vm.stack = tc.stack
+	vm.sp = tc.sp
+	vm.frames = tc.frames
+	vm.handlers = tc.handlers
+	vm.resuming = true
+}
+
+// unwindTask runs the pending defers of a task that suspended and will not
+// be resumed, because another task called os.exit.
+func (vm *VM) unwindTask(tc *taskContext) {
+	vm.restoreTask(tc)
+	vm.resuming = false
+	vm.currentTask = tc
+	vm.suspended = false
+	vm.bindClosureCaller()
+	vm.exitFrames()
+}
+
+// spawnTask runs clo with args as a separate task and returns the future for
+// its result. The task gets a child VM with its own stack; without a scheduler
+// the call runs to completion before spawnTask returns.
+func (vm *VM) spawnTask(clo *value.Closure, args []value.Value) *runtime.Future {
+	fut := runtime.NewFuture()
+	numArgs := len(args)
+
+	if vm.scheduler == nil {
+		sp, frames, handlers := vm.sp, len(vm.frames), len(vm.handlers)
+		for _, arg := range args {
+			vm.push(arg)
+		}
+		result, err := vm.callClosure(clo, numArgs)
+		if err != nil {
+			vm.frames = vm.frames[:frames]
+			vm.handlers = vm.handlers[:handlers]
+			vm.closeUpvalues(sp)
+			vm.sp = sp
+			fut.Reject(err)
+			return fut
+		}
+		vm.sp = sp
+		settleTaskFuture(fut, result)
+		return fut
+	}
+
+	childVM := vm.spawnChild()
+	for _, arg := range args {
+		childVM.push(arg)
+	}
+	if vm.debug != nil {
+		vm.debug.addThread(childVM, clo.Fn.Name)
+	}
+
+	childTC := &taskContext{future: fut}
+	childResumed := false
+
+	var childTask *runtime.Task
+	childTask = vm.scheduler.NewTask(fut, func() (status runtime.TaskStatus, retErr error) {
+		defer func() {
+			if r := recover(); r != nil {
+				retErr = fmt.Errorf("panic in spawned task: %v", r)
+				status = runtime.TaskFailed
+			}
+		}()
+
+		childTC.task = childTask
+		childVM.currentTask = childTC
+		childVM.suspended = false
+		childVM.bindClosureCaller()
+
+		if childResumed {
+			childVM.restoreTask(childTC)
+		}
+
+		result, err := childVM.callClosure(clo, numArgs)
+		if err != nil && errors.Is(err, errSuspended) {
+			childResumed = true
+			return runtime.TaskSuspended, nil
+		}
+		if childVM.debug != nil {
+			childVM.debug.removeThread(childVM)
+		}
+		if err != nil {
+			return runtime.TaskFailed, err
+		}
+		settleTaskFuture(fut, result)
+		return runtime.TaskDone, nil
+	})
+	childTask.Unwind = func() {
+		if childResumed {
+			childVM.unwindTask(childTC)
+		}
+	}
+	vm.scheduler.Schedule(childTask)
+	return fut
+}
+
+// settleTaskFuture resolves fut with a task's result. A task that itself
+// returns a future (a sync function returning Future<T>) is flattened so that
+// awaiting fut yields T.
+func settleTaskFuture(fut *runtime.Future, result value.Value) {
+	if result.Kind == value.KindFuture {
+		if inner, ok := result.Future().(*runtime.Future); ok && inner != nil {
+			fut.Forward(inner)
+			return
+		}
+	}
+	fut.Resolve(result)
+}
+
+// runAsyncMain runs an async function, main or one called through Call, to
+// completion using a new scheduler and event loop.
+func (vm *VM) runAsyncMain(clo *value.Closure, args []value.Value) (value.Value, error) {
 	sched := runtime.NewScheduler()
+	sched.SetContext(vm.budget.limits.Context)
+	// The loop stops when this call returns; later calls must not spawn
+	// tasks or arm timers on it.
+	prevSched, prevEnvSched := vm.scheduler, vm.env.Scheduler()
 	vm.scheduler = sched
+	vm.env.SetScheduler(sched)
+	defer func() {
+		vm.scheduler = prevSched
+		vm.env.SetScheduler(prevEnvSched)
+	}()
 
 	mainFut := runtime.NewFuture()
-	cloVal := value.NewClosure(fn, nil)
 
 	tc := &taskContext{
 		future: mainFut,
@@ -239,17 +569,17 @@ func (vm *VM) runAsyncMain(fn *ir.Function) (value.Value, error) {
 		tc.task = task
 		vm.currentTask = tc
 		vm.suspended = false
+		vm.bindClosureCaller()
 
 		if resumed {
-			vm.stack = make([]value.Value, 4096)
-			copy(vm.stack, tc.stack)
-			vm.sp = tc.sp
-			vm.frames = tc.frames
-			vm.handlers = tc.handlers
-			vm.resuming = true
+			vm.restoreTask(tc)
+		} else {
+			for _, arg := range args {
+				vm.push(arg)
+			}
 		}
 
-		result, err := vm.callClosure(cloVal.Closure, 0)
+		result, err := vm.callClosure(clo, len(args))
 		if err != nil {
 			if errors.Is(err, errSuspended) {
 				resumed = true
@@ -260,10 +590,18 @@ func (vm *VM) runAsyncMain(fn *ir.Function) (value.Value, error) {
 		mainFut.Resolve(result)
 		return runtime.TaskDone, nil
 	})
+	task.Unwind = func() {
+		if resumed {
+			vm.unwindTask(tc)
+		}
+	}
 
 	sched.Schedule(task)
 
 	if err := runtime.RunEventLoop(sched); err != nil {
+		if ctx := vm.budget.limits.Context; vm.budget.err == nil && ctx != nil && errors.Is(err, ctx.Err()) {
+			vm.budget.err = &LimitError{Err: err}
+		}
 		return value.Value{}, err
 	}
 
@@ -302,6 +640,9 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			return value.Value{}, fmt.Errorf("function %s expects %d args, got %d",
 				fn.Name, fn.NumParams, numArgs)
 		}
+		if err := vm.budget.checkDepth(len(vm.frames)); err != nil {
+			return value.Value{}, err
+		}
 
 		// Arguments are already on the stack
 		// base = sp - numArgs (start of this function's stack frame)
@@ -330,7 +671,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 	}
 
 	var lastRet value.Value
-	var skipIncrement bool
 	for {
 		if vm.suspended {
 			return value.Value{}, errSuspended
@@ -343,15 +683,29 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 
 		if fr.IP < 0 || fr.IP >= len(fr.Fn.Chunk.Code) {
 			if vm.raiseError(fmt.Errorf("instruction pointer out of range in %s: %d", fr.Fn.Name, fr.IP)) {
-				skipIncrement = true
 				continue
 			}
 			return value.Value{}, fmt.Errorf("instruction pointer out of range in %s: %d", fr.Fn.Name, fr.IP)
 		}
 
+		if vm.debug != nil && fr.IP < len(fr.Fn.Chunk.Lines) {
+			if line := fr.Fn.Chunk.Lines[fr.IP]; line > 0 && line != fr.line {
+				fr.line = line
+				if err := vm.debug.atLine(vm, fr); err != nil {
+					return value.Value{}, err
+				}
+			}
+		}
+		if vm.prof != nil {
+			vm.prof.sampleCPU(vm)
+		}
 		inst := fr.Fn.Chunk.Code[fr.IP]
-		shouldIncrementIP := !skipIncrement
-		skipIncrement = false
+		if err := vm.budget.step(); err != nil {
+			return value.Value{}, err
+		}
+		// Instructions that jump (including to an exception handler) either
+		// continue or clear this, so the target instruction runs exactly once.
+		shouldIncrementIP := true
 
 		switch inst.Op {
 		case ir.OpHalt:
@@ -362,38 +716,24 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			constIdx := inst.A
 			if constIdx < 0 || constIdx >= len(fr.Fn.Chunk.Consts) {
 				if vm.raiseError(fmt.Errorf("const index out of range: %d", constIdx)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("const index out of range: %d", constIdx)
 			}
 			c := fr.Fn.Chunk.Consts[constIdx]
-			switch c.Kind {
-			case ir.ConstInt:
-				vm.push(value.Int(c.Int))
-			case ir.ConstFloat:
-				vm.push(value.Float(c.Float))
-			case ir.ConstString:
-				vm.push(value.Str(c.String))
-			case ir.ConstBool:
-				vm.push(value.Bool(c.Bool))
-			case ir.ConstBytes:
-				vm.push(value.Bytes(c.Bytes))
-			case ir.ConstNone:
-				vm.push(value.None())
-			default:
+			v, ok := constValue(c)
+			if !ok {
 				if vm.raiseError(fmt.Errorf("unsupported const kind %d", c.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("unsupported const kind %d", c.Kind)
 			}
+			vm.push(v)
 
 		case ir.OpLoadLocal:
 			slot := fr.Base + inst.A
 			if slot < 0 || slot >= vm.sp {
 				if vm.raiseError(fmt.Errorf("OpLoadLocal: invalid slot %d", slot)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpLoadLocal: invalid slot %d", slot)
@@ -404,7 +744,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			slot := fr.Base + inst.A
 			if slot < 0 || slot >= vm.sp {
 				if vm.raiseError(fmt.Errorf("OpStoreLocal: invalid slot %d", slot)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpStoreLocal: invalid slot %d", slot)
@@ -412,7 +751,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			v, err := vm.peek(0)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -422,153 +760,61 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 		case ir.OpPop:
 			if _, err := vm.pop(); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 
 		// Arithmetic operations
-		case ir.OpAdd:
-			if err := vm.binaryNumericOp(func(a, b float64) float64 { return a + b }); err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-		case ir.OpSub:
-			if err := vm.binaryNumericOp(func(a, b float64) float64 { return a - b }); err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-		case ir.OpMul:
-			if err := vm.binaryNumericOp(func(a, b float64) float64 { return a * b }); err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-		case ir.OpDiv:
-			b, err := vm.pop()
-			if err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-			a, err := vm.pop()
-			if err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-			if (b.Kind == value.KindInt && b.Int == 0) || (b.Kind == value.KindFloat && b.Float == 0) {
-				if vm.raiseError(fmt.Errorf("division by zero")) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, fmt.Errorf("division by zero")
-			}
-			vm.push(a)
-			vm.push(b)
-			if err := vm.binaryNumericOp(func(x, y float64) float64 { return x / y }); err != nil {
-				if vm.raiseError(err) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, err
-			}
-		case ir.OpMod:
-			// Modulo only works on integers
-			b, err := vm.pop()
-			if err != nil {
+		case ir.OpAdd, ir.OpSub, ir.OpMul, ir.OpDiv, ir.OpMod:
+			if err := vm.binaryArith(inst.Op); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			a, err := vm.pop()
+		case ir.OpNegate:
+			v, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			if a.Kind != value.KindInt || b.Kind != value.KindInt {
-				if vm.raiseError(fmt.Errorf("binary int op expects (int, int), got (%v, %v)", a.Kind, b.Kind)) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, fmt.Errorf("binary int op expects (int, int), got (%v, %v)", a.Kind, b.Kind)
-			}
-			if b.Int == 0 {
-				if vm.raiseError(fmt.Errorf("modulo by zero")) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, fmt.Errorf("modulo by zero")
-			}
-			vm.push(value.Int(a.Int % b.Int))
-		case ir.OpNegate:
-			v, err := vm.pop()
+			r, err := vm.negate(v)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			if v.Kind == value.KindInt {
-				vm.push(value.Int(-v.Int))
-			} else if v.Kind == value.KindFloat {
-				vm.push(value.Float(-v.Float))
-			} else {
-				if vm.raiseError(fmt.Errorf("OpNegate: expected int or float, got %v", v.Kind)) {
-					skipIncrement = true
-					continue
-				}
-				return value.Value{}, fmt.Errorf("OpNegate: expected int or float, got %v", v.Kind)
-			}
+			vm.push(r)
 
 		// Comparisons / logic
 		case ir.OpLt:
-			if err := vm.binaryNumericCmp(func(a, b float64) bool { return a < b }); err != nil {
+			if err := vm.binaryCmp(ir.OpLt); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 		case ir.OpLte:
-			if err := vm.binaryNumericCmp(func(a, b float64) bool { return a <= b }); err != nil {
+			if err := vm.binaryCmp(ir.OpLte); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 		case ir.OpGt:
-			if err := vm.binaryNumericCmp(func(a, b float64) bool { return a > b }); err != nil {
+			if err := vm.binaryCmp(ir.OpGt); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 		case ir.OpGte:
-			if err := vm.binaryNumericCmp(func(a, b float64) bool { return a >= b }); err != nil {
+			if err := vm.binaryCmp(ir.OpGte); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -576,7 +822,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 		case ir.OpEq:
 			if err := vm.binaryEq(); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -584,7 +829,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 		case ir.OpNeq:
 			if err := vm.binaryNeq(); err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -599,19 +843,17 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			cond, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if cond.Kind != value.KindBool {
 				if vm.raiseError(fmt.Errorf("OpJumpIfFalse: expected bool, got %v", cond.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpJumpIfFalse: expected bool, got %v", cond.Kind)
 			}
-			if !cond.Bool {
+			if !cond.Bool() {
 				fr.IP = inst.A
 				shouldIncrementIP = false
 			}
@@ -620,26 +862,69 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			top, err := vm.peek(0)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			if top.Kind == value.KindOptional && top.Optional != nil {
-				if !top.Optional.IsSome {
+			if top.Kind == value.KindOptional && top.Optional() != nil {
+				if !top.Optional().IsSome {
 					fr.IP = inst.A
 					shouldIncrementIP = false
 				} else {
-					vm.stack[vm.sp-1] = top.Optional.Value
+					vm.stack[vm.sp-1] = top.Optional().Value
 				}
 			}
 
+		case ir.OpAddLocalConst:
+			slot := fr.Base + inst.A
+			if slot < 0 || slot >= vm.sp || inst.B < 0 || inst.B >= len(fr.Fn.Chunk.Consts) {
+				if vm.raiseError(fmt.Errorf("OpAddLocalConst: invalid operands %d, %d", inst.A, inst.B)) {
+					continue
+				}
+				return value.Value{}, fmt.Errorf("OpAddLocalConst: invalid operands %d, %d", inst.A, inst.B)
+			}
+			b, _ := constValue(fr.Fn.Chunk.Consts[inst.B])
+			r, err := vm.arith(ir.OpAdd, vm.stack[slot], b)
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			vm.push(r)
+
+		case ir.OpCmpJumpIfFalse:
+			b, err := vm.pop()
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			a, err := vm.pop()
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			result, err := compare(ir.OpCode(inst.B), a, b)
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			if !result {
+				fr.IP = inst.A
+				shouldIncrementIP = false
+			}
+
 		case ir.OpPushDefer:
 			argCount := inst.A
 			if argCount < 0 || argCount+1 > vm.sp {
 				err := fmt.Errorf("OpPushDefer: invalid argument count %d", argCount)
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -647,7 +932,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			callee, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -657,7 +941,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				argVal, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -674,7 +957,7 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			} else {
 				fn := vm.mod.Functions[inst.A]
 				cloVal := value.NewClosure(fn, nil)
-				clo = cloVal.Closure
+				clo = cloVal.Closure()
 			}
 			// Pre-advance caller's IP past OpCall before entering callClosure.
 			// If the callee suspends (async await), the saved frame must have
@@ -687,7 +970,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 					return value.Value{}, errSuspended
 				}
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -698,87 +980,43 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			callee, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if callee.Kind != value.KindClosure {
 				if vm.raiseError(fmt.Errorf("OpCallValue: expected closure, got %v", callee.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpCallValue: expected closure, got %v", callee.Kind)
 			}
 			numArgs := inst.A
 			// inst.B=1: compiler signals this closure returns Future and must be spawned.
-			if inst.B == 1 && vm.scheduler != nil {
-				spawnArgs := make([]value.Value, numArgs)
-				for i := numArgs - 1; i >= 0; i-- {
-					arg, popErr := vm.pop()
-					if popErr != nil {
-						if vm.raiseError(popErr) {
-							skipIncrement = true
-							continue
-						}
-						return value.Value{}, popErr
-					}
-					spawnArgs[i] = arg
-				}
-				fut := runtime.NewFuture()
-				childVM := vm.spawnChild()
-				spawnClo := callee.Closure
-				for _, arg := range spawnArgs {
-					childVM.push(arg)
-				}
-				childTC := &taskContext{future: fut}
-				childResumed := false
-				var childTask *runtime.Task
-				childTask = vm.scheduler.NewTask(fut, func() (status runtime.TaskStatus, retErr error) {
-					defer func() {
-						if r := recover(); r != nil {
-							retErr = fmt.Errorf("panic in spawned task: %v", r)
-							status = runtime.TaskFailed
-						}
-					}()
-					childTC.task = childTask
-					childVM.currentTask = childTC
-					childVM.suspended = false
-					if childResumed {
-						childVM.stack = make([]value.Value, 4096)
-						copy(childVM.stack, childTC.stack)
-						childVM.sp = childTC.sp
-						childVM.frames = childTC.frames
-						childVM.handlers = childTC.handlers
-						childVM.resuming = true
-					}
-					result, callErr := childVM.callClosure(spawnClo, numArgs)
-					if callErr != nil {
-						if errors.Is(callErr, errSuspended) {
-							childResumed = true
-							return runtime.TaskSuspended, nil
-						}
-						return runtime.TaskFailed, callErr
+			if inst.B == 1 {
+				if numArgs < 0 || numArgs > vm.sp {
+					err := fmt.Errorf("OpCallValue: invalid arg count %d", numArgs)
+					if vm.raiseError(err) {
+						continue
 					}
-					fut.Resolve(result)
-					return runtime.TaskDone, nil
-				})
-				vm.scheduler.Schedule(childTask)
-				vm.push(value.FutureVal(fut))
+					return value.Value{}, err
+				}
+				spawnArgs := make([]value.Value, numArgs)
+				copy(spawnArgs, vm.stack[vm.sp-numArgs:vm.sp])
+				vm.sp -= numArgs
+				vm.push(value.FutureVal(vm.spawnTask(callee.Closure(), spawnArgs)))
 			} else {
-				// Synchronous closure call (or no scheduler).
+				// Synchronous closure call.
 				// Pre-advance caller's IP past OpCallValue before entering callClosure.
 				// If the callee suspends (async await), the saved frame state must have
 				// IP past this instruction so we don't re-execute OpCallValue on resume.
 				vm.frames[len(vm.frames)-1].IP++
 				shouldIncrementIP = false
-				retVal, err := vm.callClosure(callee.Closure, numArgs)
+				retVal, err := vm.callClosure(callee.Closure(), numArgs)
 				if err != nil {
 					if err == errSuspended {
 						return value.Value{}, errSuspended
 					}
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -787,11 +1025,16 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			}
 
 		case ir.OpCallBuiltin:
-			builtinID := builtins.ID(inst.A)
+			builtin, err := vm.lookupBuiltin(inst.A)
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
 			n := inst.B
 			if n < 0 || n > vm.sp {
 				if vm.raiseError(fmt.Errorf("OpCallBuiltin: invalid arg count %d", n)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpCallBuiltin: invalid arg count %d", n)
@@ -801,25 +1044,105 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				v, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
 				}
 				args[i] = v
 			}
-			res, hasRes, err := runtime.CallBuiltin(vm.env, builtinID, args)
+			var start time.Time
+			if vm.prof != nil {
+				start = time.Now()
+			}
+			res, hasRes, err := runtime.CallBuiltin(vm.env, builtin, args)
+			if vm.prof != nil {
+				vm.prof.builtinCall(vm, vm.mod.Builtins[inst.A], time.Since(start))
+			}
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if hasRes {
+				if err := vm.allocated(res); err != nil {
+					return value.Value{}, err
+				}
 				vm.push(res)
 			}
 
+		case ir.OpCallMethod:
+			numArgs := inst.B
+			if numArgs < 1 || numArgs > vm.sp {
+				err := fmt.Errorf("OpCallMethod: invalid arg count %d", numArgs)
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			target, err := vm.lookupMethod(inst.A, vm.stack[vm.sp-numArgs], numArgs)
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			if target.builtin != nil {
+				// Omitted optional parameters are passed as none.
+				args := make([]value.Value, max(numArgs, target.builtin.Meta.Arity))
+				copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+				for i := numArgs; i < len(args); i++ {
+					args[i] = value.None()
+				}
+				vm.sp -= numArgs
+				var start time.Time
+				if vm.prof != nil {
+					start = time.Now()
+				}
+				res, _, err := runtime.CallBuiltin(vm.env, target.builtin, args)
+				if vm.prof != nil {
+					vm.prof.builtinCall(vm, target.builtin.Meta.Symbol(), time.Since(start))
+				}
+				if err != nil {
+					if vm.raiseError(err) {
+						continue
+					}
+					return value.Value{}, err
+				}
+				if err := vm.allocated(res); err != nil {
+					return value.Value{}, err
+				}
+				vm.push(res)
+				break
+			}
+			var clo *value.Closure
+			if target.fn < len(vm.closureOverrides) && vm.closureOverrides[target.fn] != nil {
+				clo = vm.closureOverrides[target.fn]
+			} else {
+				clo = value.NewClosure(vm.mod.Functions[target.fn], nil).Closure()
+			}
+			if clo.Fn.IsAsync {
+				spawnArgs := make([]value.Value, numArgs)
+				copy(spawnArgs, vm.stack[vm.sp-numArgs:vm.sp])
+				vm.sp -= numArgs
+				vm.push(value.FutureVal(vm.spawnTask(clo, spawnArgs)))
+				break
+			}
+			// Pre-advance the caller's IP as for OpCall.
+			vm.frames[len(vm.frames)-1].IP++
+			shouldIncrementIP = false
+			retVal, err := vm.callClosure(clo, numArgs)
+			if err != nil {
+				if err == errSuspended {
+					return value.Value{}, errSuspended
+				}
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
+			lastRet = retVal
+
 		case ir.OpClosure:
 			// Create a closure: A = function index, B = number of upvalues
 			//
@@ -832,7 +1155,7 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			//
 			// Open upvalues are closed when the function that owns the stack slot returns.
 			if inst.B == 0 && inst.A < len(vm.closureOverrides) && vm.closureOverrides[inst.A] != nil {
-				vm.push(value.Value{Kind: value.KindClosure, Closure: vm.closureOverrides[inst.A]})
+				vm.push(value.ClosureVal(vm.closureOverrides[inst.A]))
 				break
 			}
 			fn := vm.mod.Functions[inst.A]
@@ -851,7 +1174,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 					if slotIndex < 0 || slotIndex >= vm.sp {
 						if vm.raiseError(fmt.Errorf("OpClosure: invalid local slot %d (base=%d, index=%d, sp=%d)",
 							slotIndex, currentFrame.Base, upvalueInfo.Index, vm.sp)) {
-							skipIncrement = true
 							shouldIncrementIP = false
 							goto nextInstruction
 						}
@@ -859,19 +1181,15 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 							slotIndex, currentFrame.Base, upvalueInfo.Index, vm.sp)
 					}
 
-					// Create open upvalue pointing to stack slot
-					// Multiple closures can point to the same slot (they share the variable)
-					upvalues[i] = &value.Upvalue{
-						IsClosed: false,
-						Index:    slotIndex,
-					}
+					// Reuse the open upvalue for this slot if one exists, so closures
+					// capturing the same variable keep sharing it after it is closed.
+					upvalues[i] = vm.captureUpvalue(slotIndex)
 				} else {
 					// This references a parent function's upvalue
 					// The compiler has already pushed the value via OpLoadUpvalue
 					val, err := vm.pop()
 					if err != nil {
 						if vm.raiseError(err) {
-							skipIncrement = true
 							shouldIncrementIP = false
 							goto nextInstruction
 						}
@@ -895,6 +1213,9 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			}
 
 			clo := value.NewClosure(fn, upvalues)
+			if vm.prof != nil {
+				vm.prof.allocation(vm, clo)
+			}
 			vm.push(clo)
 
 		case ir.OpLoadUpvalue:
@@ -902,7 +1223,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			idx := inst.A
 			if fr.Clo == nil || idx >= len(fr.Clo.Upvalues) {
 				if vm.raiseError(fmt.Errorf("OpLoadUpvalue: invalid upvalue index %d", idx)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpLoadUpvalue: invalid upvalue index %d", idx)
@@ -911,15 +1231,15 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if upv.IsClosed {
 				vm.push(upv.Closed)
 			} else {
-				// Open upvalue: read from stack
-				if upv.Index < 0 || upv.Index >= vm.sp {
+				// Open upvalue: read from the owning VM's stack
+				stack := *upv.Stack
+				if upv.Index < 0 || upv.Index >= len(stack) {
 					if vm.raiseError(fmt.Errorf("OpLoadUpvalue: invalid stack index %d", upv.Index)) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, fmt.Errorf("OpLoadUpvalue: invalid stack index %d", upv.Index)
 				}
-				vm.push(vm.stack[upv.Index])
+				vm.push(stack[upv.Index])
 			}
 
 		case ir.OpStoreUpvalue:
@@ -928,14 +1248,12 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			v, err := vm.peek(0)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if fr.Clo == nil || idx >= len(fr.Clo.Upvalues) {
 				if vm.raiseError(fmt.Errorf("OpStoreUpvalue: invalid upvalue index %d", idx)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpStoreUpvalue: invalid upvalue index %d", idx)
@@ -944,15 +1262,15 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if upv.IsClosed {
 				upv.Closed = v
 			} else {
-				// Open upvalue: write to stack
-				if upv.Index < 0 || upv.Index >= vm.sp {
+				// Open upvalue: write to the owning VM's stack
+				stack := *upv.Stack
+				if upv.Index < 0 || upv.Index >= len(stack) {
 					if vm.raiseError(fmt.Errorf("OpStoreUpvalue: invalid stack index %d", upv.Index)) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, fmt.Errorf("OpStoreUpvalue: invalid stack index %d", upv.Index)
 				}
-				vm.stack[upv.Index] = v
+				stack[upv.Index] = v
 			}
 
 		case ir.OpSetFunc:
@@ -960,7 +1278,7 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if err != nil {
 				return value.Value{}, err
 			}
-			if cloVal.Kind != value.KindClosure || cloVal.Closure == nil {
+			if cloVal.Kind != value.KindClosure || cloVal.Closure() == nil {
 				return value.Value{}, fmt.Errorf("OpSetFunc: expected closure, got %v", cloVal.Kind)
 			}
 			if inst.A >= len(vm.closureOverrides) {
@@ -968,7 +1286,7 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				copy(newOverrides, vm.closureOverrides)
 				vm.closureOverrides = newOverrides
 			}
-			vm.closureOverrides[inst.A] = cloVal.Closure
+			vm.closureOverrides[inst.A] = cloVal.Closure()
 
 		case ir.OpLoadGlobal:
 			if inst.A < 0 || inst.A >= len(vm.globals) {
@@ -1000,7 +1318,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				ret, err = vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -1012,10 +1329,9 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 
 			for i := len(f.DeferStack) - 1; i >= 0; i-- {
 				deferred := f.DeferStack[i]
-				if deferred.Callee.Kind != value.KindClosure || deferred.Callee.Closure == nil {
+				if deferred.Callee.Kind != value.KindClosure || deferred.Callee.Closure() == nil {
 					err := fmt.Errorf("defer expects callable closure, got %v", deferred.Callee.Kind)
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -1023,16 +1339,14 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				for _, arg := range deferred.Args {
 					vm.push(arg)
 				}
-				if _, err = vm.callClosure(deferred.Callee.Closure, len(deferred.Args)); err != nil {
+				if _, err = vm.callClosure(deferred.Callee.Closure(), len(deferred.Args)); err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
 				}
 				if _, err = vm.pop(); err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -1043,7 +1357,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 
 			if f.Base < 0 || f.Base > vm.sp {
 				if vm.raiseError(fmt.Errorf("OpReturn: invalid base %d", f.Base)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpReturn: invalid base %d", f.Base)
@@ -1069,7 +1382,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			n := inst.A
 			if n < 0 || n > vm.sp {
 				if vm.raiseError(fmt.Errorf("OpMakeList: invalid count %d", n)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpMakeList: invalid count %d", n)
@@ -1079,7 +1391,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				v, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						shouldIncrementIP = false
 						goto nextInstruction
 					}
@@ -1087,26 +1398,24 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				}
 				list[i] = v
 			}
+			if err := vm.allocated(value.List(list)); err != nil {
+				return value.Value{}, err
+			}
 			vm.push(value.List(list))
 
 		case ir.OpMakeDict:
 			n := inst.A
 			if n < 0 || n*2 > vm.sp {
 				if vm.raiseError(fmt.Errorf("OpMakeDict: invalid count %d", n)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpMakeDict: invalid count %d", n)
 			}
-			entries := make([]struct {
-				key   string
-				value value.Value
-			}, n)
+			entries := make([]struct{ key, value value.Value }, n)
 			for i := n - 1; i >= 0; i-- {
 				val, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						shouldIncrementIP = false
 						goto nextInstruction
 					}
@@ -1115,36 +1424,69 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				keyVal, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						shouldIncrementIP = false
 						goto nextInstruction
 					}
 					return value.Value{}, err
 				}
-				if keyVal.Kind != value.KindString {
-					if vm.raiseError(fmt.Errorf("OpMakeDict: expected string key, got %v", keyVal.Kind)) {
-						skipIncrement = true
+				entries[i] = struct{ key, value value.Value }{key: keyVal, value: val}
+			}
+			dict := value.NewDict(n)
+			for _, entry := range entries {
+				if err := dict.Set(entry.key, entry.value); err != nil {
+					if vm.raiseError(fmt.Errorf("OpMakeDict: %w", err)) {
 						shouldIncrementIP = false
 						goto nextInstruction
 					}
-					return value.Value{}, fmt.Errorf("OpMakeDict: expected string key, got %v", keyVal.Kind)
+					return value.Value{}, fmt.Errorf("OpMakeDict: %w", err)
 				}
-				entries[i] = struct {
-					key   string
-					value value.Value
-				}{key: keyVal.Str, value: val}
 			}
-			dict := make(map[string]value.Value, n)
-			for _, entry := range entries {
-				dict[entry.key] = entry.value
+			allocated := value.DictVal(dict)
+			if err := vm.allocated(allocated); err != nil {
+				return value.Value{}, err
 			}
-			vm.push(value.Dict(dict))
+			vm.push(allocated)
+
+		case ir.OpMakeSet:
+			n := inst.A
+			if n < 0 || n > vm.sp {
+				if vm.raiseError(fmt.Errorf("OpMakeSet: invalid count %d", n)) {
+					continue
+				}
+				return value.Value{}, fmt.Errorf("OpMakeSet: invalid count %d", n)
+			}
+			elems := make([]value.Value, n)
+			for i := n - 1; i >= 0; i-- {
+				v, err := vm.pop()
+				if err != nil {
+					if vm.raiseError(err) {
+						shouldIncrementIP = false
+						goto nextInstruction
+					}
+					return value.Value{}, err
+				}
+				elems[i] = v
+			}
+			set := value.NewSet(n)
+			for _, v := range elems {
+				if err := set.Add(v); err != nil {
+					if vm.raiseError(fmt.Errorf("OpMakeSet: %w", err)) {
+						shouldIncrementIP = false
+						goto nextInstruction
+					}
+					return value.Value{}, fmt.Errorf("OpMakeSet: %w", err)
+				}
+			}
+			allocated := value.SetVal(set)
+			if err := vm.allocated(allocated); err != nil {
+				return value.Value{}, err
+			}
+			vm.push(allocated)
 
 		case ir.OpIndex:
 			idxVal, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1152,7 +1494,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			listVal, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1161,57 +1502,50 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			case value.KindList:
 				if idxVal.Kind != value.KindInt {
 					if vm.raiseError(fmt.Errorf("OpIndex: expected int index, got %v", idxVal.Kind)) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, fmt.Errorf("OpIndex: expected int index, got %v", idxVal.Kind)
 				}
-				idx := idxVal.Int
-				if idx < 0 || int(idx) >= len(listVal.List) {
-					if vm.raiseError(fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.List))) {
-						skipIncrement = true
+				idx := idxVal.Int()
+				if idx < 0 || int(idx) >= len(listVal.List()) {
+					if vm.raiseError(fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.List()))) {
 						continue
 					}
-					return value.Value{}, fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.List))
+					return value.Value{}, fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.List()))
 				}
-				vm.push(listVal.List[idx])
+				vm.push(listVal.List()[idx])
 			case value.KindBytes:
 				if idxVal.Kind != value.KindInt {
 					if vm.raiseError(fmt.Errorf("OpIndex: expected int index, got %v", idxVal.Kind)) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, fmt.Errorf("OpIndex: expected int index, got %v", idxVal.Kind)
 				}
-				idx := idxVal.Int
-				if idx < 0 || int(idx) >= len(listVal.Bytes) {
-					if vm.raiseError(fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.Bytes))) {
-						skipIncrement = true
+				idx := idxVal.Int()
+				if idx < 0 || int(idx) >= len(listVal.Bytes()) {
+					if vm.raiseError(fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.Bytes()))) {
 						continue
 					}
-					return value.Value{}, fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.Bytes))
+					return value.Value{}, fmt.Errorf("OpIndex: index out of range %d (len=%d)", idx, len(listVal.Bytes()))
 				}
-				vm.push(value.Int(int64(listVal.Bytes[idx])))
+				vm.push(value.Int(int64(listVal.Bytes()[idx])))
 			case value.KindDict:
-				if idxVal.Kind != value.KindString {
-					if vm.raiseError(fmt.Errorf("OpIndex: expected string key, got %v", idxVal.Kind)) {
-						skipIncrement = true
+				if !value.Hashable(idxVal) {
+					if vm.raiseError(fmt.Errorf("OpIndex: invalid dict key of kind %v", idxVal.Kind)) {
 						continue
 					}
-					return value.Value{}, fmt.Errorf("OpIndex: expected string key, got %v", idxVal.Kind)
+					return value.Value{}, fmt.Errorf("OpIndex: invalid dict key of kind %v", idxVal.Kind)
 				}
-				val, ok := listVal.Dict[idxVal.Str]
+				val, ok := listVal.Dict().Get(idxVal)
 				if !ok {
-					if vm.raiseError(fmt.Errorf("OpIndex: key %q not found", idxVal.Str)) {
-						skipIncrement = true
+					if vm.raiseError(fmt.Errorf("OpIndex: key %s not found", vm.formatKey(idxVal))) {
 						continue
 					}
-					return value.Value{}, fmt.Errorf("OpIndex: key %q not found", idxVal.Str)
+					return value.Value{}, fmt.Errorf("OpIndex: key %s not found", vm.formatKey(idxVal))
 				}
 				vm.push(val)
 			default:
 				if vm.raiseError(fmt.Errorf("OpIndex: expected list, bytes, or dict, got %v", listVal.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpIndex: expected list, bytes, or dict, got %v", listVal.Kind)
@@ -1221,7 +1555,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			v, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1239,7 +1572,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				v, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						shouldIncrementIP = false
 						goto nextInstruction
 					}
@@ -1248,7 +1580,11 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				fields[i] = v
 			}
 
-			vm.push(value.Struct(structTypeIdx, fields))
+			allocated := value.Struct(structTypeIdx, fields)
+			if err := vm.allocated(allocated); err != nil {
+				return value.Value{}, err
+			}
+			vm.push(allocated)
 
 		case ir.OpLoadField:
 			// A = field index
@@ -1258,7 +1594,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			structVal, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1266,29 +1601,26 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 
 			if structVal.Kind != value.KindStruct {
 				if vm.raiseError(fmt.Errorf("OpLoadField: expected struct, got %v", structVal.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpLoadField: expected struct, got %v", structVal.Kind)
 			}
 
-			if structVal.Struct == nil {
+			if structVal.Struct() == nil {
 				if vm.raiseError(fmt.Errorf("OpLoadField: nil struct")) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpLoadField: nil struct")
 			}
 
-			if fieldIdx < 0 || fieldIdx >= len(structVal.Struct.Fields) {
-				if vm.raiseError(fmt.Errorf("OpLoadField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct.Fields))) {
-					skipIncrement = true
+			if fieldIdx < 0 || fieldIdx >= len(structVal.Struct().Fields) {
+				if vm.raiseError(fmt.Errorf("OpLoadField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct().Fields))) {
 					continue
 				}
-				return value.Value{}, fmt.Errorf("OpLoadField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct.Fields))
+				return value.Value{}, fmt.Errorf("OpLoadField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct().Fields))
 			}
 
-			vm.push(structVal.Struct.Fields[fieldIdx])
+			vm.push(structVal.Struct().Fields[fieldIdx])
 
 		case ir.OpStoreField:
 			// A = field index
@@ -1299,7 +1631,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			fieldVal, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1309,7 +1640,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			structVal, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1317,30 +1647,27 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 
 			if structVal.Kind != value.KindStruct {
 				if vm.raiseError(fmt.Errorf("OpStoreField: expected struct, got %v", structVal.Kind)) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpStoreField: expected struct, got %v", structVal.Kind)
 			}
 
-			if structVal.Struct == nil {
+			if structVal.Struct() == nil {
 				if vm.raiseError(fmt.Errorf("OpStoreField: nil struct")) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpStoreField: nil struct")
 			}
 
-			if fieldIdx < 0 || fieldIdx >= len(structVal.Struct.Fields) {
-				if vm.raiseError(fmt.Errorf("OpStoreField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct.Fields))) {
-					skipIncrement = true
+			if fieldIdx < 0 || fieldIdx >= len(structVal.Struct().Fields) {
+				if vm.raiseError(fmt.Errorf("OpStoreField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct().Fields))) {
 					continue
 				}
-				return value.Value{}, fmt.Errorf("OpStoreField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct.Fields))
+				return value.Value{}, fmt.Errorf("OpStoreField: field index %d out of range (struct has %d fields)", fieldIdx, len(structVal.Struct().Fields))
 			}
 
 			// Mutate the struct in-place (no copying)
-			structVal.Struct.Fields[fieldIdx] = fieldVal
+			structVal.Struct().Fields[fieldIdx] = fieldVal
 
 			// Push the mutated struct back (same struct value, mutated in-place)
 			vm.push(structVal)
@@ -1349,18 +1676,20 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			val, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			vm.push(value.Str(val.String()))
+			str := value.Str(val.String())
+			if err := vm.allocated(str); err != nil {
+				return value.Value{}, err
+			}
+			vm.push(str)
 
 		case ir.OpConcatString:
 			right, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1368,23 +1697,25 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			left, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if left.Kind != value.KindString || right.Kind != value.KindString {
 				if vm.raiseError(fmt.Errorf("OpConcatString expects strings")) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, fmt.Errorf("OpConcatString expects strings")
 			}
 			var builder strings.Builder
-			builder.Grow(len(left.Str) + len(right.Str))
-			builder.WriteString(left.Str)
-			builder.WriteString(right.Str)
-			vm.push(value.Str(builder.String()))
+			builder.Grow(len(left.Str()) + len(right.Str()))
+			builder.WriteString(left.Str())
+			builder.WriteString(right.Str())
+			allocated := value.Str(builder.String())
+			if err := vm.allocated(allocated); err != nil {
+				return value.Value{}, err
+			}
+			vm.push(allocated)
 
 		case ir.OpBeginTry:
 			vm.handlers = append(vm.handlers, exceptionHandler{
@@ -1408,19 +1739,17 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			top, err := vm.peek(0)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			matched := top.Kind == value.KindStruct && top.Struct != nil && top.Struct.TypeIndex == typeIdx
+			matched := top.Kind == value.KindStruct && top.Struct() != nil && top.Struct().TypeIndex == typeIdx
 			vm.push(value.Bool(matched))
 
 		case ir.OpThrow:
 			exc, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1428,7 +1757,7 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if vm.throwValue(exc) {
 				continue
 			}
-			return value.Value{}, fmt.Errorf("unhandled error: %s", errorMessage(exc))
+			return value.Value{}, &thrownError{exc: exc}
 
 		case ir.OpSpawn:
 			fnIdx := inst.A
@@ -1437,7 +1766,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if fnIdx < 0 || fnIdx >= len(vm.mod.Functions) {
 				err := fmt.Errorf("OpSpawn: invalid function index %d", fnIdx)
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1448,88 +1776,26 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				spawnClo = vm.closureOverrides[fnIdx]
 			} else {
 				fn := vm.mod.Functions[fnIdx]
-				spawnClo = value.NewClosure(fn, nil).Closure
-			}
-
-			spawnArgs := make([]value.Value, numArgs)
-			for i := numArgs - 1; i >= 0; i-- {
-				arg, err := vm.pop()
-				if err != nil {
-					if vm.raiseError(err) {
-						skipIncrement = true
-						continue
-					}
-					return value.Value{}, err
-				}
-				spawnArgs[i] = arg
+				spawnClo = value.NewClosure(fn, nil).Closure()
 			}
 
-			fut := runtime.NewFuture()
-
-			if vm.scheduler != nil {
-				childVM := vm.spawnChild()
-				clo := value.Value{Kind: value.KindClosure, Closure: spawnClo}
-
-				for _, arg := range spawnArgs {
-					childVM.push(arg)
-				}
-
-				childTC := &taskContext{future: fut}
-				childResumed := false
-
-				var childTask *runtime.Task
-				childTask = vm.scheduler.NewTask(fut, func() (status runtime.TaskStatus, retErr error) {
-					defer func() {
-						if r := recover(); r != nil {
-							retErr = fmt.Errorf("panic in spawned task: %v", r)
-							status = runtime.TaskFailed
-						}
-					}()
-
-					childTC.task = childTask
-					childVM.currentTask = childTC
-					childVM.suspended = false
-
-					if childResumed {
-						childVM.stack = make([]value.Value, 4096)
-						copy(childVM.stack, childTC.stack)
-						childVM.sp = childTC.sp
-						childVM.frames = childTC.frames
-						childVM.handlers = childTC.handlers
-						childVM.resuming = true
-					}
-
-					result, err := childVM.callClosure(clo.Closure, numArgs)
-					if err != nil {
-						if errors.Is(err, errSuspended) {
-							childResumed = true
-							return runtime.TaskSuspended, nil
-						}
-						return runtime.TaskFailed, err
-					}
-					fut.Resolve(result)
-					return runtime.TaskDone, nil
-				})
-				vm.scheduler.Schedule(childTask)
-			} else {
-				for _, arg := range spawnArgs {
-					vm.push(arg)
-				}
-				result, err := vm.callClosure(spawnClo, numArgs)
-				if err != nil {
-					fut.Reject(err)
-				} else {
-					fut.Resolve(result)
+			if numArgs < 0 || numArgs > vm.sp {
+				err := fmt.Errorf("OpSpawn: invalid arg count %d", numArgs)
+				if vm.raiseError(err) {
+					continue
 				}
+				return value.Value{}, err
 			}
+			spawnArgs := make([]value.Value, numArgs)
+			copy(spawnArgs, vm.stack[vm.sp-numArgs:vm.sp])
+			vm.sp -= numArgs
 
-			vm.push(value.FutureVal(fut))
+			vm.push(value.FutureVal(vm.spawnTask(spawnClo, spawnArgs)))
 
 		case ir.OpAwait:
 			val, err := vm.pop()
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1537,16 +1803,14 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 			if val.Kind != value.KindFuture {
 				err := fmt.Errorf("OpAwait: expected future, got %v", val.Kind)
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
-			fut, ok := val.Future.(*runtime.Future)
+			fut, ok := val.Future().(*runtime.Future)
 			if !ok || fut == nil {
 				err := fmt.Errorf("OpAwait: invalid future value")
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1555,7 +1819,8 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				if vm.currentTask != nil {
 					vm.push(val)
 					fut.AddWaiter(vm.currentTask.task)
-					vm.currentTask.stack = compactStack(vm.stack, vm.sp)
+					vm.stack = compactStack(vm.stack, vm.sp)
+					vm.currentTask.stack = vm.stack
 					vm.currentTask.sp = vm.sp
 					vm.currentTask.frames = vm.frames
 					vm.currentTask.handlers = vm.handlers
@@ -1564,27 +1829,33 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				}
 				err := fmt.Errorf("OpAwait: future not ready in non-async context")
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
 			if fut.Err != nil {
-				if vm.throwValue(value.ErrorValue(fut.Err.Error())) {
-					skipIncrement = true
+				if vm.throwValue(vm.errorValue(fut.Err)) {
 					continue
 				}
 				return value.Value{}, fut.Err
 			}
+			if err := vm.budget.charge(fut.Result); err != nil {
+				return value.Value{}, err
+			}
 			vm.push(fut.Result)
 
 		case ir.OpCallBuiltinAsync:
-			builtinID := builtins.ID(inst.A)
+			builtin, err := vm.lookupBuiltin(inst.A)
+			if err != nil {
+				if vm.raiseError(err) {
+					continue
+				}
+				return value.Value{}, err
+			}
 			n := inst.B
 			if n < 0 || n > vm.sp {
 				err := fmt.Errorf("OpCallBuiltinAsync: invalid arg count %d", n)
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
@@ -1594,7 +1865,6 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				v, err := vm.pop()
 				if err != nil {
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
@@ -1602,45 +1872,44 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 				args[i] = v
 			}
 
-			if builtinID == builtins.AsyncWithTimeout {
+			if builtin.Meta.ID == builtins.AsyncWithTimeout {
 				if n != 2 {
 					err := fmt.Errorf("withTimeout expects 2 args, got %d", n)
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
 				}
-				innerFut, ok := args[0].Future.(*runtime.Future)
+				innerFut, ok := args[0].Future().(*runtime.Future)
 				if !ok || innerFut == nil {
 					err := fmt.Errorf("withTimeout: first arg must be a future")
 					if vm.raiseError(err) {
-						skipIncrement = true
 						continue
 					}
 					return value.Value{}, err
 				}
-				durationNs := args[1].Int
+				durationNs := args[1].Int()
 				timeoutFut := runtime.WithTimeout(innerFut, durationNs)
 				vm.push(value.FutureVal(timeoutFut))
 				goto nextInstruction
 			}
 
-			ah, err := runtime.CallBuiltinAsync(vm.env, builtinID, args)
+			ah, err := runtime.CallBuiltinAsync(vm.env, builtin, args)
 			if err != nil {
 				if vm.raiseError(err) {
-					skipIncrement = true
 					continue
 				}
 				return value.Value{}, err
 			}
+			if vm.prof != nil {
+				vm.prof.asyncBuiltinCall(vm, vm.mod.Builtins[inst.A], ah)
+			}
 			fut := runtime.NewFuture()
 			ah.WireToFuture(fut)
 			vm.push(value.FutureVal(fut))
 
 		default:
 			if vm.raiseError(fmt.Errorf("unknown opcode %d", inst.Op)) {
-				skipIncrement = true
 				continue
 			}
 			return value.Value{}, fmt.Errorf("unknown opcode %d", inst.Op)
@@ -1657,12 +1926,12 @@ func (vm *VM) callClosure(clo *value.Closure, numArgs int) (value.Value, error)
 func (vm *VM) callFunction(fnIndex int, numArgs int) (value.Value, error) {
 	fn := vm.mod.Functions[fnIndex]
 	cloVal := value.NewClosure(fn, nil)
-	return vm.callClosure(cloVal.Closure, numArgs)
+	return vm.callClosure(cloVal.Closure(), numArgs)
 }
 
 // ---- Helpers for binary operations ----
 
-func (vm *VM) binaryIntOp(op func(a, b int64) int64) error {
+func (vm *VM) binaryIntCmp(op func(a, b int64) bool) error {
 	b, err := vm.pop()
 	if err != nil {
 		return err
@@ -1672,14 +1941,15 @@ func (vm *VM) binaryIntOp(op func(a, b int64) int64) error {
 		return err
 	}
 	if a.Kind != value.KindInt || b.Kind != value.KindInt {
-		return fmt.Errorf("binary int op expects (int, int), got (%v, %v)", a.Kind, b.Kind)
+		return fmt.Errorf("binary int cmp expects (int, int), got (%v, %v)", a.Kind, b.Kind)
 	}
-	vm.push(value.Int(op(a.Int, b.Int)))
+	vm.push(value.Bool(op(a.Int(), b.Int())))
 	return nil
 }
 
-// binaryNumericOp handles arithmetic operations for int and float, promoting to float if needed
-func (vm *VM) binaryNumericOp(op func(a, b float64) float64) error {
+// binaryCmp pops b and a and pushes the result of the ordering comparison
+// op, which compares ints exactly and other numbers as float64.
+func (vm *VM) binaryCmp(op ir.OpCode) error {
 	b, err := vm.pop()
 	if err != nil {
 		return err
@@ -1688,86 +1958,94 @@ func (vm *VM) binaryNumericOp(op func(a, b float64) float64) error {
 	if err != nil {
 		return err
 	}
-
-	// Convert to float64
-	var aFloat, bFloat float64
-	if a.Kind == value.KindInt {
-		aFloat = float64(a.Int)
-	} else if a.Kind == value.KindFloat {
-		aFloat = a.Float
-	} else {
-		return fmt.Errorf("binary numeric op expects numeric types, got (%v, %v)", a.Kind, b.Kind)
-	}
-
-	if b.Kind == value.KindInt {
-		bFloat = float64(b.Int)
-	} else if b.Kind == value.KindFloat {
-		bFloat = b.Float
-	} else {
-		return fmt.Errorf("binary numeric op expects numeric types, got (%v, %v)", a.Kind, b.Kind)
-	}
-
-	result := op(aFloat, bFloat)
-	// If both operands were int and result is whole number, return int; otherwise float
-	if a.Kind == value.KindInt && b.Kind == value.KindInt {
-		if result == float64(int64(result)) {
-			vm.push(value.Int(int64(result)))
-		} else {
-			vm.push(value.Float(result))
-		}
-	} else {
-		vm.push(value.Float(result))
+	r, err := compare(op, a, b)
+	if err != nil {
+		return err
 	}
+	vm.push(value.Bool(r))
 	return nil
 }
 
-func (vm *VM) binaryIntCmp(op func(a, b int64) bool) error {
-	b, err := vm.pop()
-	if err != nil {
-		return err
+// constValue returns the value of a constant.
+func constValue(c ir.Constant) (value.Value, bool) {
+	switch c.Kind {
+	case ir.ConstInt:
+		return value.Int(c.Int), true
+	case ir.ConstFloat:
+		return value.Float(c.Float), true
+	case ir.ConstString:
+		return value.Str(c.String), true
+	case ir.ConstBool:
+		return value.Bool(c.Bool), true
+	case ir.ConstBytes:
+		return value.Bytes(c.Bytes), true
+	case ir.ConstNone:
+		return value.None(), true
 	}
-	a, err := vm.pop()
-	if err != nil {
-		return err
-	}
-	if a.Kind != value.KindInt || b.Kind != value.KindInt {
-		return fmt.Errorf("binary int cmp expects (int, int), got (%v, %v)", a.Kind, b.Kind)
+	return value.Value{}, false
+}
+
+func toFloat(v value.Value) (float64, bool) {
+	switch v.Kind {
+	case value.KindInt:
+		return float64(v.Int()), true
+	case value.KindFloat:
+		return v.Float(), true
 	}
-	vm.push(value.Bool(op(a.Int, b.Int)))
-	return nil
+	return 0, false
 }
 
-// binaryNumericCmp handles comparison operations for int and float
-func (vm *VM) binaryNumericCmp(op func(a, b float64) bool) error {
-	b, err := vm.pop()
-	if err != nil {
-		return err
+// compare evaluates the comparison op of OpCmpJumpIfFalse like the
+// comparison instructions do.
+func compare(op ir.OpCode, a, b value.Value) (bool, error) {
+	switch op {
+	case ir.OpEq:
+		return equalValues(a, b), nil
+	case ir.OpNeq:
+		return !equalValues(a, b), nil
 	}
-	a, err := vm.pop()
-	if err != nil {
-		return err
+	if a.Kind == value.KindInt && b.Kind == value.KindInt {
+		switch op {
+		case ir.OpLt:
+			return a.Int() < b.Int(), nil
+		case ir.OpLte:
+			return a.Int() <= b.Int(), nil
+		case ir.OpGt:
+			return a.Int() > b.Int(), nil
+		case ir.OpGte:
+			return a.Int() >= b.Int(), nil
+		}
 	}
-
-	// Convert to float64
-	var aFloat, bFloat float64
-	if a.Kind == value.KindInt {
-		aFloat = float64(a.Int)
-	} else if a.Kind == value.KindFloat {
-		aFloat = a.Float
-	} else {
-		return fmt.Errorf("binary numeric cmp expects numeric types, got (%v, %v)", a.Kind, b.Kind)
+	if isBig(a) || isBig(b) {
+		if c, ok := value.CompareBig(a, b); ok {
+			switch op {
+			case ir.OpLt:
+				return c < 0, nil
+			case ir.OpLte:
+				return c <= 0, nil
+			case ir.OpGt:
+				return c > 0, nil
+			case ir.OpGte:
+				return c >= 0, nil
+			}
+		}
 	}
-
-	if b.Kind == value.KindInt {
-		bFloat = float64(b.Int)
-	} else if b.Kind == value.KindFloat {
-		bFloat = b.Float
-	} else {
-		return fmt.Errorf("binary numeric cmp expects numeric types, got (%v, %v)", a.Kind, b.Kind)
+	x, ok1 := toFloat(a)
+	y, ok2 := toFloat(b)
+	if !ok1 || !ok2 {
+		return false, fmt.Errorf("binary numeric cmp expects numeric types, got (%v, %v)", a.Kind, b.Kind)
 	}
-
-	vm.push(value.Bool(op(aFloat, bFloat)))
-	return nil
+	switch op {
+	case ir.OpLt:
+		return x < y, nil
+	case ir.OpLte:
+		return x <= y, nil
+	case ir.OpGt:
+		return x > y, nil
+	case ir.OpGte:
+		return x >= y, nil
+	}
+	return false, fmt.Errorf("OpCmpJumpIfFalse: invalid comparison %v", op)
 }
 
 // Deep comparison of values (including lists and functions)
@@ -1777,41 +2055,47 @@ func equalValues(a, b value.Value) bool {
 	}
 	switch a.Kind {
 	case value.KindInt:
-		return a.Int == b.Int
+		return a.Int() == b.Int()
 	case value.KindFloat:
-		return a.Float == b.Float
+		return a.Float() == b.Float()
+	case value.KindBigInt:
+		return a.BigInt().Cmp(b.BigInt()) == 0
+	case value.KindDecimal:
+		return a.Decimal().Cmp(b.Decimal()) == 0
+	case value.KindHandle:
+		return a.Handle() == b.Handle()
 	case value.KindString:
-		return a.Str == b.Str
+		return a.Str() == b.Str()
 	case value.KindBool:
-		return a.Bool == b.Bool
+		return a.Bool() == b.Bool()
 	case value.KindError:
-		return a.Str == b.Str
+		return a.Str() == b.Str()
 	case value.KindBytes:
-		if len(a.Bytes) != len(b.Bytes) {
+		if len(a.Bytes()) != len(b.Bytes()) {
 			return false
 		}
-		for i := range a.Bytes {
-			if a.Bytes[i] != b.Bytes[i] {
+		for i := range a.Bytes() {
+			if a.Bytes()[i] != b.Bytes()[i] {
 				return false
 			}
 		}
 		return true
 	case value.KindList:
-		if len(a.List) != len(b.List) {
+		if len(a.List()) != len(b.List()) {
 			return false
 		}
-		for i := range a.List {
-			if !equalValues(a.List[i], b.List[i]) {
+		for i := range a.List() {
+			if !equalValues(a.List()[i], b.List()[i]) {
 				return false
 			}
 		}
 		return true
 	case value.KindDict:
-		if len(a.Dict) != len(b.Dict) {
+		if a.Dict().Len() != b.Dict().Len() {
 			return false
 		}
-		for k, av := range a.Dict {
-			bv, ok := b.Dict[k]
+		for k, av := range a.Dict().All() {
+			bv, ok := b.Dict().Get(k)
 			if !ok {
 				return false
 			}
@@ -1820,35 +2104,45 @@ func equalValues(a, b value.Value) bool {
 			}
 		}
 		return true
+	case value.KindSet:
+		if a.Set().Len() != b.Set().Len() {
+			return false
+		}
+		for e := range a.Set().All() {
+			if !b.Set().Has(e) {
+				return false
+			}
+		}
+		return true
 	case value.KindOptional:
-		if a.Optional == nil && b.Optional == nil {
+		if a.Optional() == nil && b.Optional() == nil {
 			return true
 		}
-		if a.Optional == nil || b.Optional == nil {
+		if a.Optional() == nil || b.Optional() == nil {
 			return false
 		}
-		if !a.Optional.IsSome && !b.Optional.IsSome {
+		if !a.Optional().IsSome && !b.Optional().IsSome {
 			return true
 		}
-		if a.Optional.IsSome != b.Optional.IsSome {
+		if a.Optional().IsSome != b.Optional().IsSome {
 			return false
 		}
-		return equalValues(a.Optional.Value, b.Optional.Value)
+		return equalValues(a.Optional().Value, b.Optional().Value)
 	case value.KindStruct:
-		if a.Struct == b.Struct {
+		if a.Struct() == b.Struct() {
 			return true
 		}
-		if a.Struct == nil || b.Struct == nil {
+		if a.Struct() == nil || b.Struct() == nil {
 			return false
 		}
-		if a.Struct.TypeIndex != b.Struct.TypeIndex {
+		if a.Struct().TypeIndex != b.Struct().TypeIndex {
 			return false
 		}
-		if len(a.Struct.Fields) != len(b.Struct.Fields) {
+		if len(a.Struct().Fields) != len(b.Struct().Fields) {
 			return false
 		}
-		for i := range a.Struct.Fields {
-			if !equalValues(a.Struct.Fields[i], b.Struct.Fields[i]) {
+		for i := range a.Struct().Fields {
+			if !equalValues(a.Struct().Fields[i], b.Struct().Fields[i]) {
 				return false
 			}
 		}
@@ -1856,10 +2150,10 @@ func equalValues(a, b value.Value) bool {
 	case value.KindClosure:
 		// Closures are equal if they reference the same function and have same upvalues
 		// For simplicity, we compare function pointers
-		if a.Closure == nil || b.Closure == nil {
-			return a.Closure == b.Closure
+		if a.Closure() == nil || b.Closure() == nil {
+			return a.Closure() == b.Closure()
 		}
-		return a.Closure.Fn == b.Closure.Fn
+		return a.Closure().Fn == b.Closure().Fn
 	default:
 		return false
 	}
@@ -1889,47 +2183,49 @@ func (vm *VM) binaryNeq() error {
 	if v.Kind != value.KindBool {
 		return fmt.Errorf("binaryNeq: internal error, expected bool")
 	}
-	vm.push(value.Bool(!v.Bool))
+	vm.push(value.Bool(!v.Bool()))
 	return nil
 }
 
+// captureUpvalue returns the open upvalue for a stack slot, creating it if needed.
+func (vm *VM) captureUpvalue(slot int) *value.Upvalue {
+	for _, upv := range vm.openUpvalues {
+		if upv.Index == slot {
+			return upv
+		}
+	}
+	upv := &value.Upvalue{
+		IsClosed: false,
+		Index:    slot,
+		Stack:    &vm.stack,
+	}
+	vm.openUpvalues = append(vm.openUpvalues, upv)
+	return upv
+}
+
 // closeUpvalues closes all open upvalues that point to stack slots >= base.
 //
 // When a function returns, we need to "close" any open upvalues that point
 // into its stack frame. This copies the value from the stack into the upvalue
 // object, so closures can still access the variable after the function returns.
 //
-// We scan:
-//  1. All frames and their closures (for closures created during execution)
-//  2. All closures on the stack (for closures passed as arguments or stored)
+// Every open upvalue is registered with the VM that owns its stack slot, so
+// closures that escaped into other tasks, lists or dicts are closed as well.
 func (vm *VM) closeUpvalues(base int) {
-	// Scan all frames and their closures for open upvalues pointing to this frame
-	for i := len(vm.frames) - 1; i >= 0; i-- {
-		frame := vm.frames[i]
-		if frame.Clo != nil {
-			for _, upv := range frame.Clo.Upvalues {
-				if !upv.IsClosed && upv.Index >= base {
-					// Close this upvalue: copy value from stack
-					if upv.Index < vm.sp {
-						upv.Closed = vm.stack[upv.Index]
-						upv.IsClosed = true
-					}
-				}
-			}
+	kept := vm.openUpvalues[:0]
+	for _, upv := range vm.openUpvalues {
+		if upv.Index < base {
+			kept = append(kept, upv)
+			continue
 		}
-	}
-
-	// Also check any closures on the stack (they might have been passed as arguments)
-	for i := 0; i < vm.sp; i++ {
-		if vm.stack[i].Kind == value.KindClosure && vm.stack[i].Closure != nil {
-			for _, upv := range vm.stack[i].Closure.Upvalues {
-				if !upv.IsClosed && upv.Index >= base {
-					if upv.Index < vm.sp {
-						upv.Closed = vm.stack[upv.Index]
-						upv.IsClosed = true
-					}
-				}
-			}
+		if upv.Index < vm.sp {
+			upv.Closed = vm.stack[upv.Index]
 		}
+		upv.IsClosed = true
+		upv.Stack = nil
+	}
+	for i := len(kept); i < len(vm.openUpvalues); i++ {
+		vm.openUpvalues[i] = nil
 	}
+	vm.openUpvalues = kept
 }
@@ -1,13 +1,44 @@
 package vm
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"avenir/internal/ir"
+	"avenir/internal/modules"
 	"avenir/internal/runtime"
+	"avenir/internal/types"
 	"avenir/internal/value"
 )
 
+// compileFile compiles src as main.av in a temporary directory and
+// returns the module and the file's path.
+func compileFile(t testing.TB, src string) (*ir.Module, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.av")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	world, errs := modules.LoadWorld(path)
+	if len(errs) > 0 {
+		t.Fatalf("load errors: %v", errs)
+	}
+	typeWorld := &types.World{Modules: make(map[string]*types.ModuleInfo), Entry: world.Entry}
+	for name, m := range world.Modules {
+		typeWorld.Modules[name] = &types.ModuleInfo{Name: name, Prog: m.Prog, File: m.FilePath}
+	}
+	bindings, typeErrs := types.CheckWorldWithBindings(typeWorld)
+	if len(typeErrs) > 0 {
+		t.Fatalf("type errors: %v", typeErrs)
+	}
+	mod, compileErrs := ir.CompileWorld(typeWorld, typeWorld.Modules[world.Entry], bindings)
+	if len(compileErrs) > 0 {
+		t.Fatalf("compile errors: %v", compileErrs)
+	}
+	return mod, path
+}
+
 // Simple test: 1 + 2 = 3 using "manual" bytecode.
 func TestVM_SimpleAdd(t *testing.T) {
 	var chunk ir.Chunk
@@ -35,7 +66,81 @@ func TestVM_SimpleAdd(t *testing.T) {
 		t.Fatalf("RunMain error: %v", err)
 	}
 
-	if v.Kind != value.KindInt || v.Int != 3 {
-		t.Fatalf("expected 3, got %v (%s)", v.Int, v.String())
+	if v.Kind != value.KindInt || v.Int() != 3 {
+		t.Fatalf("expected 3, got %v (%s)", v.Int(), v.String())
+	}
+}
+
+// benchmarkProgram runs the main function of src, which must return want.
+func benchmarkProgram(b *testing.B, src, want string) {
+	mod, _ := compileFile(b, src)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, err := NewVM(mod, runtime.DefaultEnv()).RunMain()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if v.String() != want {
+			b.Fatalf("result = %s, want %s", v.String(), want)
+		}
 	}
 }
+
+func BenchmarkVM_Fib(b *testing.B) {
+	benchmarkProgram(b, `pckg main;
+
+fun fib(n | int) | int {
+    if (n < 2) {
+        return n;
+    }
+    return fib(n - 1) + fib(n - 2);
+}
+
+fun main() | int {
+    return fib(20);
+}
+`, "6765")
+}
+
+func BenchmarkVM_Lists(b *testing.B) {
+	benchmarkProgram(b, `pckg main;
+
+fun main() | int {
+    var xs | list<int> = [];
+    for (var i | int = 0; i < 1000; i = i + 1) {
+        xs = xs.append(i);
+    }
+    var total | int = 0;
+    for (x in xs) {
+        total = total + x;
+    }
+    for (var j | int = 0; j < xs.length(); j = j + 1) {
+        total = total + xs[j];
+    }
+    return total;
+}
+`, "999000")
+}
+
+func BenchmarkVM_Dicts(b *testing.B) {
+	benchmarkProgram(b, `pckg main;
+
+fun main() | int {
+    var counts | dict<string, int> = {};
+    for (var i | int = 0; i < 1000; i = i + 1) {
+        var key | string = "k${i % 50}";
+        if (counts.has(key)) {
+            counts.set(key, counts[key] + i);
+        } else {
+            counts.set(key, i);
+        }
+    }
+    var total | int = 0;
+    for (key in counts.keys()) {
+        total = total + counts[key];
+    }
+    return total;
+}
+`, "499500")
+}
@@ -0,0 +1,307 @@
+// Package avenir embeds the Avenir language in Go programs. It compiles
+// sources or loads .avc bytecode, runs programs and calls their functions,
+// and lets the host provide modules implemented in Go.
+//
+// Each Interpreter has its own runtime environment and host modules, so
+// several interpreters can run side by side in one process.
+package avenir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	"avenir/internal/ir"
+	"avenir/internal/modules"
+	"avenir/internal/runtime"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/types"
+	"avenir/internal/vm"
+	"avenir/std"
+)
+
+type (
+	// Env provides the host services (IO, filesystem, network, ...) used by
+	// programs, along with their permissions.
+	Env = runtime.Env
+	// Permissions restricts the host services of an Env.
+	Permissions = runtime.Permissions
+	// Limits bounds the instructions, call depth, memory and time of a run.
+	Limits = vm.Limits
+	// LimitError is returned when a program exceeds its Limits.
+	LimitError = vm.LimitError
+)
+
+// Errors wrapped by LimitError.
+var (
+	ErrInstructionLimit = vm.ErrInstructionLimit
+	ErrCallDepthLimit   = vm.ErrCallDepthLimit
+	ErrMemoryLimit      = vm.ErrMemoryLimit
+)
+
+// NewEnv returns an Env with the standard host services.
+func NewEnv() *Env {
+	return runtime.DefaultEnv()
+}
+
+// Interpreter compiles programs and runs them in its Env. Host modules are
+// added to the interpreter's own builtin registry, so they are not visible
+// to other interpreters.
+type Interpreter struct {
+	env      *runtime.Env
+	std      fs.FS
+	sources  fstest.MapFS // generated sources of host modules
+	registry *builtins.Registry
+	limits   vm.Limits
+}
+
+// New creates an interpreter with a default Env and the standard library
+// embedded in package std.
+func New() *Interpreter {
+	return NewWithEnv(runtime.DefaultEnv())
+}
+
+// NewWithEnv creates an interpreter that runs programs in env.
+func NewWithEnv(env *Env) *Interpreter {
+	if env == nil {
+		env = runtime.DefaultEnv()
+	}
+	return &Interpreter{
+		env:      env,
+		std:      std.FS,
+		sources:  fstest.MapFS{},
+		registry: builtins.Default().Clone(),
+	}
+}
+
+// Env returns the interpreter's Env, e.g. to set permissions or the exec
+// root before running a program.
+func (in *Interpreter) Env() *Env {
+	return in.env
+}
+
+// SetStd sets where std modules are compiled from, laid out like the std
+// directory ("io/io.av" for std.io). With nil, they must be in the std
+// directory of the sources being compiled.
+func (in *Interpreter) SetStd(stdlib fs.FS) {
+	in.std = stdlib
+}
+
+// SetLimits sets the limits of instances created afterwards.
+func (in *Interpreter) SetLimits(limits Limits) {
+	in.limits = limits
+}
+
+// AddModule makes the host module m importable by programs compiled with
+// this interpreter. A module may be named std.something as long as the
+// standard library has no module of that name.
+func (in *Interpreter) AddModule(m *Module) error {
+	src, natives, err := m.compile()
+	if err != nil {
+		return err
+	}
+	modPath := m.path()
+	if _, dup := in.sources[modPath]; dup {
+		return fmt.Errorf("host module %s is already added", m.name)
+	}
+	if stdPath, ok := strings.CutPrefix(modPath, "std/"); ok && in.std != nil {
+		flat := strings.ReplaceAll(strings.TrimPrefix(m.name, "std."), ".", "/") + ".av"
+		for _, p := range []string{stdPath, flat} {
+			if _, err := fs.Stat(in.std, p); err == nil {
+				return fmt.Errorf("host module %s conflicts with the standard library", m.name)
+			}
+		}
+	}
+	// Register into a copy so that a failure leaves the interpreter as it was.
+	registry := in.registry.Clone()
+	for _, b := range natives {
+		if err := registry.Register(b); err != nil {
+			return fmt.Errorf("module %s: %w", m.name, err)
+		}
+	}
+	in.registry = registry
+	in.sources[modPath] = &fstest.MapFile{Data: []byte(src), Mode: 0o444}
+	return nil
+}
+
+// Program is a compiled Avenir program.
+type Program struct {
+	mod *ir.Module
+}
+
+// CompileString compiles a single source file. name is its file name, e.g.
+// "main.av"; like any Avenir file, it must match the struct it declares, if
+// any.
+func (in *Interpreter) CompileString(name, src string) (*Program, error) {
+	if !strings.HasSuffix(name, ".av") || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid source file name %q", name)
+	}
+	return in.CompileFS(fstest.MapFS{name: &fstest.MapFile{Data: []byte(src), Mode: 0o444}}, name)
+}
+
+// CompileFile compiles the source file at path and the modules it imports
+// from its directory.
+func (in *Interpreter) CompileFile(path string) (*Program, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return in.CompileFS(os.DirFS(filepath.Dir(abs)), filepath.Base(abs))
+}
+
+// CompileFS compiles the entry file of fsys and the modules it imports.
+// Paths are slash-separated and relative to the root of fsys.
+func (in *Interpreter) CompileFS(fsys fs.FS, entry string) (*Program, error) {
+	sources := layers{in.sources}
+	if in.std != nil {
+		sources = append(sources, mount{dir: "std", fsys: in.std})
+	}
+	sources = append(sources, fsys)
+
+	world, errs := modules.LoadWorldFS(sources, entry)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	mod, err := compileWorld(world, in.registry)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{mod: mod}, nil
+}
+
+// Load reads a program compiled to .avc bytecode.
+func (in *Interpreter) Load(r io.Reader) (*Program, error) {
+	mod, err := ir.ReadModule(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{mod: mod}, nil
+}
+
+// Write writes the program as .avc bytecode.
+func (p *Program) Write(w io.Writer) error {
+	return ir.WriteModule(w, p.mod)
+}
+
+// exported returns the qualified name of function name, checking that it
+// is a pub function of the entry module: hosts see the same functions that
+// importing modules do.
+func (p *Program) exported(name string) (string, error) {
+	entry := p.entryModule()
+	qualified := name
+	if !strings.Contains(name, ".") {
+		qualified = entry + "." + name
+	}
+	idx, ok := p.mod.FunctionIndex(qualified)
+	if !ok {
+		return "", fmt.Errorf("unknown function %q", name)
+	}
+	local, inEntry := strings.CutPrefix(qualified, entry+".")
+	if !inEntry || strings.Contains(local, ".") || !p.mod.Functions[idx].IsPublic {
+		return "", fmt.Errorf("function %q is not exported: only pub functions of module %s can be called", name, entry)
+	}
+	return qualified, nil
+}
+
+// entryModule returns the name of the module that declares main.
+func (p *Program) entryModule() string {
+	if p.mod.MainIndex < 0 || p.mod.MainIndex >= len(p.mod.Functions) {
+		return ""
+	}
+	name := p.mod.Functions[p.mod.MainIndex].Name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// Instance is a program loaded into a VM, with its own module-level
+// variables. Instances of one interpreter share its Env, so run them one at
+// a time.
+type Instance struct {
+	prog *Program
+	vm   *vm.VM
+}
+
+// Instantiate prepares p to run in the interpreter's Env.
+func (in *Interpreter) Instantiate(p *Program) *Instance {
+	machine := vm.NewVM(p.mod, in.env)
+	machine.SetBuiltins(in.registry)
+	machine.SetLimits(in.limits)
+	return &Instance{prog: p, vm: machine}
+}
+
+// Run runs the program's main function in a new instance and returns its
+// result converted with FromValue.
+func (in *Interpreter) Run(p *Program) (any, error) {
+	return in.Instantiate(p).Run()
+}
+
+// Run runs main and returns its result converted with FromValue.
+func (i *Instance) Run() (any, error) {
+	result, err := i.vm.RunMain()
+	if err != nil {
+		return nil, err
+	}
+	return FromValue(result), nil
+}
+
+// Call calls a pub function of the entry module with arguments converted by
+// ToValue and returns its result converted by FromValue. name is the bare
+// function name, optionally qualified by the entry module, e.g. "main.add".
+func (i *Instance) Call(name string, args ...any) (any, error) {
+	vals := make([]Value, len(args))
+	for j, arg := range args {
+		v, err := ToValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", j, err)
+		}
+		vals[j] = v
+	}
+	result, err := i.CallValue(name, vals...)
+	if err != nil {
+		return nil, err
+	}
+	return FromValue(result), nil
+}
+
+// CallValue is Call without conversions.
+func (i *Instance) CallValue(name string, args ...Value) (Value, error) {
+	qualified, err := i.prog.exported(name)
+	if err != nil {
+		return Value{}, err
+	}
+	return i.vm.Call(qualified, args...)
+}
+
+// compileWorld type-checks and compiles a loaded world.
+func compileWorld(world *modules.World, registry *builtins.Registry) (*ir.Module, error) {
+	typeWorld := &types.World{
+		Modules:  make(map[string]*types.ModuleInfo),
+		Entry:    world.Entry,
+		Builtins: registry,
+	}
+	for modName, modAST := range world.Modules {
+		typeWorld.Modules[modName] = &types.ModuleInfo{
+			Name: modName,
+			Prog: modAST.Prog,
+			File: modAST.FilePath,
+		}
+	}
+
+	bindings, typeErrs := types.CheckWorldWithBindings(typeWorld)
+	if len(typeErrs) > 0 {
+		return nil, errors.Join(typeErrs...)
+	}
+
+	mod, compileErrs := ir.CompileWorld(typeWorld, typeWorld.Modules[world.Entry], bindings)
+	if len(compileErrs) > 0 {
+		return nil, errors.Join(compileErrs...)
+	}
+	return mod, nil
+}
@@ -0,0 +1,348 @@
+package avenir
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"avenir/internal/runtime"
+)
+
+type captureIO struct {
+	lines []string
+}
+
+func (c *captureIO) Println(s string)          { c.lines = append(c.lines, s) }
+func (c *captureIO) ReadLine() (string, error) { return "", nil }
+
+func TestCallFunctions(t *testing.T) {
+	in := New()
+	prog, err := in.CompileString("main.av", `pckg main;
+
+var calls | int = 0;
+
+pub fun add(a | int, b | int) | int {
+    calls = calls + 1;
+    return a + b;
+}
+
+pub fun count() | int {
+    return calls;
+}
+
+pub fun total(xs | list<int>) | dict<any> {
+    var sum | int = 0;
+    for (x in xs) {
+        sum = sum + x;
+    }
+    return {sum: sum, n: xs.length()};
+}
+
+pub fun fail() | void {
+    throw error("boom");
+}
+
+fun reset() | void {
+    calls = 0;
+}
+
+fun main() | void {}
+`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	inst := in.Instantiate(prog)
+	got, err := inst.Call("add", 2, 3)
+	if err != nil || got != int64(5) {
+		t.Fatalf("add(2, 3) = %v, %v", got, err)
+	}
+	if _, err := inst.Call("main.add", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := inst.Call("count"); got != int64(2) {
+		t.Fatalf("count() = %v, want globals kept between calls", got)
+	}
+	got, err = inst.Call("total", []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"sum": int64(6), "n": int64(3)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("total = %#v, want %#v", got, want)
+	}
+	if _, err := inst.Call("fail"); err == nil {
+		t.Fatal("expected error from fail()")
+	}
+	if got, err := inst.Call("add", 4, 5); err != nil || got != int64(9) {
+		t.Fatalf("add after a failed call = %v, %v", got, err)
+	}
+	if _, err := inst.Call("missing"); err == nil {
+		t.Fatal("expected error for unknown function")
+	}
+	if _, err := inst.Call("add", 1); err == nil {
+		t.Fatal("expected error for wrong argument count")
+	}
+	for _, name := range []string{"reset", "main.reset", "main"} {
+		if _, err := inst.Call(name); err == nil || !strings.Contains(err.Error(), "not exported") {
+			t.Fatalf("Call(%q) error = %v, want not exported", name, err)
+		}
+	}
+	if got, _ := inst.Call("count"); got != int64(3) {
+		t.Fatalf("count() = %v after calling a private function", got)
+	}
+}
+
+func TestCallAfterAsyncRun(t *testing.T) {
+	// An async main stops its event loop when it returns; sync calls made
+	// afterwards run spawned tasks and timers without it.
+	out := &captureIO{}
+	in := NewWithEnv(runtime.NewEnv(out))
+	prog, err := in.CompileString("main.av", `pckg main;
+
+import std.time;
+
+async fun noisy() | void {
+    print("noisy ran");
+}
+
+pub fun kick() | void {
+    spawn noisy();
+}
+
+async fun main() | void {
+    await time.asyncSleep(time.fromMillis(1));
+    print("main ran");
+}
+`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	inst := in.Instantiate(prog)
+	if _, err := inst.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := inst.Call("kick"); err != nil {
+		t.Fatalf("kick: %v", err)
+	}
+	if want := []string{"main ran", "noisy ran"}; !reflect.DeepEqual(out.lines, want) {
+		t.Fatalf("output = %q, want %q", out.lines, want)
+	}
+}
+
+func TestHostModules(t *testing.T) {
+	newInterp := func(greeting string) (*Interpreter, *captureIO) {
+		out := &captureIO{}
+		env := runtime.NewEnv(out)
+		in := NewWithEnv(env)
+		store := map[string]any{}
+		mod := NewModule("host.kv").
+			Func("put(key | string, v | int) | void", func(args []any) (any, error) {
+				store[args[0].(string)] = args[1]
+				return nil, nil
+			}).
+			Func("get(key | string) | int", func(args []any) (any, error) {
+				v, ok := store[args[0].(string)]
+				if !ok {
+					return nil, errors.New("no key " + args[0].(string))
+				}
+				return v, nil
+			}).
+			Func("greeting() | string", func(args []any) (any, error) {
+				return greeting, nil
+			})
+		if err := in.AddModule(mod); err != nil {
+			t.Fatalf("add module: %v", err)
+		}
+		return in, out
+	}
+	src := `pckg main;
+
+import host.kv;
+
+fun main() | void {
+    kv.put("a", 41);
+    print(kv.greeting() + " ${kv.get("a") + 1}");
+    try {
+        kv.get("b");
+    } catch (e | error) {
+        print(errorMessage(e));
+    }
+}
+`
+	first, firstOut := newInterp("hello")
+	second, secondOut := newInterp("bonjour")
+	for _, in := range []*Interpreter{first, second} {
+		prog, err := in.CompileString("main.av", src)
+		if err != nil {
+			t.Fatalf("compile: %v", err)
+		}
+		if _, err := in.Run(prog); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	if want := []string{"hello 42", "no key b"}; !reflect.DeepEqual(firstOut.lines, want) {
+		t.Fatalf("first output = %q, want %q", firstOut.lines, want)
+	}
+	if want := []string{"bonjour 42", "no key b"}; !reflect.DeepEqual(secondOut.lines, want) {
+		t.Fatalf("second output = %q, want %q", secondOut.lines, want)
+	}
+
+	if err := first.AddModule(NewModule("host.kv")); err == nil {
+		t.Fatal("expected error for duplicate module")
+	}
+	if err := first.AddModule(NewModule("bad").Func("f(x) {", nil)); err == nil {
+		t.Fatal("expected error for invalid signature")
+	}
+	if err := first.AddModule(NewModule("std.json")); err == nil {
+		t.Fatal("expected error for module shadowing std")
+	}
+}
+
+func TestStdStyleModule(t *testing.T) {
+	in := New()
+	mod := NewModule("std.stats").
+		Func("sum(xs | list<int>) | int", func(args []any) (any, error) {
+			total := int64(0)
+			for _, x := range args[0].([]any) {
+				total += x.(int64)
+			}
+			return total, nil
+		})
+	mod.Source(`struct stats {}
+
+pub struct Summary {
+    pub total | int
+    pub count | int
+}
+
+pub fun summarize(xs | list<int>) | Summary {
+    return Summary{total = ` + mod.NativeName("sum") + `(xs), count = len(xs)};
+}`)
+	if err := in.AddModule(mod); err != nil {
+		t.Fatalf("add module: %v", err)
+	}
+	prog, err := in.CompileString("main.av", `pckg main;
+
+import std.stats;
+
+fun main() | string {
+    var s | stats.Summary = stats.summarize([1, 2, 3]);
+    return "${s.total}/${s.count}/${stats.sum([4])}";
+}
+`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if got, err := in.Run(prog); err != nil || got != "6/3/4" {
+		t.Fatalf("run = %v, %v", got, err)
+	}
+
+	// Another interpreter does not see the module or its builtins.
+	if _, err := New().CompileString("main.av", "pckg main;\nimport std.stats;\nfun main() | void {}\n"); err == nil {
+		t.Fatal("expected import error in a separate interpreter")
+	}
+}
+
+func TestCompileFSAndBytecode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/main.av": {Data: []byte(`pckg main;
+
+import util;
+import std.json;
+
+fun main() | string {
+    return util.shout(json.stringify([1, 2]));
+}
+
+pub fun greet(s | string) | string {
+    return util.shout(s);
+}
+`)},
+		"app/util.av": {Data: []byte(`pckg util;
+
+pub fun shout(s | string) | string {
+    return s + "!";
+}
+`)},
+	}
+	in := New()
+	sub, err := fs.Sub(fsys, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := in.CompileFS(sub, "main.av")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if got, err := in.Run(prog); err != nil || got != "[1,2]!" {
+		t.Fatalf("run = %v, %v", got, err)
+	}
+
+	var buf bytes.Buffer
+	if err := prog.Write(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded, err := New().Load(&buf)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	inst := New().Instantiate(loaded)
+	if got, err := inst.Call("greet", "hi"); err != nil || got != "hi!" {
+		t.Fatalf("call on loaded program = %v, %v", got, err)
+	}
+	// Only the entry module is exposed, even for pub functions of others.
+	if _, err := inst.Call("util.shout", "hi"); err == nil || !strings.Contains(err.Error(), "not exported") {
+		t.Fatalf("call of util.shout error = %v, want not exported", err)
+	}
+
+	if _, err := in.CompileString("main.av", "pckg main;\nfun main() | void { undefinedFn(); }\n"); err == nil {
+		t.Fatal("expected compile error")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := New()
+	in.SetLimits(Limits{MaxInstructions: 1000})
+	prog, err := in.CompileString("main.av", "pckg main;\nfun main() | void { while (true) {} }\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Run(prog); !errors.Is(err, ErrInstructionLimit) {
+		t.Fatalf("expected instruction limit, got %v", err)
+	}
+}
+
+func TestValueConversion(t *testing.T) {
+	n := 7
+	cases := []struct {
+		in   any
+		want any
+	}{
+		{nil, nil},
+		{true, true},
+		{uint8(3), int64(3)},
+		{float32(1.5), 1.5},
+		{&n, int64(7)},
+		{[2]string{"a", "b"}, []any{"a", "b"}},
+		{map[string][]int{"x": {1}}, map[string]any{"x": []any{int64(1)}}},
+		{[]byte("hi"), []byte("hi")},
+	}
+	for _, c := range cases {
+		v, err := ToValue(c.in)
+		if err != nil {
+			t.Fatalf("ToValue(%#v): %v", c.in, err)
+		}
+		if got := FromValue(v); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("round trip of %#v = %#v, want %#v", c.in, got, c.want)
+		}
+	}
+	if _, err := ToValue(map[int]string{}); err == nil {
+		t.Error("expected error for non-string map keys")
+	}
+	if _, err := ToValue(struct{}{}); err == nil {
+		t.Error("expected error for Go structs")
+	}
+}
@@ -0,0 +1,39 @@
+package avenir
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// layers is an fs.FS that opens each name from the first layer that has it.
+type layers []fs.FS
+
+func (l layers) Open(name string) (fs.File, error) {
+	for _, fsys := range l {
+		f, err := fsys.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// mount serves fsys under the directory dir.
+type mount struct {
+	dir  string
+	fsys fs.FS
+}
+
+func (m mount) Open(name string) (fs.File, error) {
+	if name == m.dir {
+		return m.fsys.Open(".")
+	}
+	if rest, ok := strings.CutPrefix(name, m.dir+"/"); ok {
+		return m.fsys.Open(rest)
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
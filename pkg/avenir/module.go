@@ -0,0 +1,167 @@
+package avenir
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"avenir/internal/ast"
+	"avenir/internal/lexer"
+	"avenir/internal/parser"
+	"avenir/internal/runtime/builtins"
+	"avenir/internal/value"
+)
+
+// Func implements a host module function in Go. Arguments are converted
+// with FromValue and the result with ToValue; a returned error is thrown as
+// an Avenir error.
+type Func func(args []any) (any, error)
+
+// Module is a host module: Avenir code imports it like any other module, and
+// its functions are implemented in Go. Go packages can use modules to ship
+// std-style libraries: Go functions plus Avenir code built on them.
+type Module struct {
+	name   string
+	funcs  []moduleFunc
+	source []string
+}
+
+type moduleFunc struct {
+	sig string
+	fn  Func
+}
+
+// NewModule creates an empty host module with a dotted name such as
+// "host.kv", imported by Avenir code as `import host.kv;`.
+func NewModule(name string) *Module {
+	return &Module{name: name}
+}
+
+// Name returns the module's name.
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Func adds a public function given its Avenir signature without the fun
+// keyword, e.g. "get(key | string) | string". The signature is checked when
+// the module is added to an Interpreter. The module's own Avenir code can
+// also call the Go function directly as a builtin named NativeName(name).
+func (m *Module) Func(sig string, fn Func) *Module {
+	m.funcs = append(m.funcs, moduleFunc{sig: sig, fn: fn})
+	return m
+}
+
+// Source adds Avenir declarations to the module, without the pckg line.
+// Like any Avenir file, a module that declares structs must declare one
+// named after the last part of the module name.
+func (m *Module) Source(src string) *Module {
+	m.source = append(m.source, src)
+	return m
+}
+
+// NativeName returns the name of the builtin that implements function fn of
+// the module, e.g. "__builtin_host_kv_get" for get in host.kv.
+func (m *Module) NativeName(fn string) string {
+	return "__builtin_" + strings.ReplaceAll(m.name, ".", "_") + "_" + fn
+}
+
+// path returns the slash-separated path of the module's source file. It
+// uses the folder layout, which the loader prefers over flat files.
+func (m *Module) path() string {
+	parts := strings.Split(m.name, ".")
+	return path.Join(append(parts, parts[len(parts)-1]+".av")...)
+}
+
+// compile generates the module's source, with a wrapper for each function,
+// and the builtins that implement the functions.
+func (m *Module) compile() (string, []builtins.Builtin, error) {
+	for _, part := range strings.Split(m.name, ".") {
+		if part == "" {
+			return "", nil, fmt.Errorf("invalid host module name %q", m.name)
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "pckg %s;\n", m.name)
+	var natives []builtins.Builtin
+	for _, f := range m.funcs {
+		decl, err := parseSignature(f.sig)
+		if err != nil {
+			return "", nil, fmt.Errorf("module %s: %w", m.name, err)
+		}
+		names := make([]string, len(decl.Params))
+		params := make([]builtins.TypeRef, len(decl.Params))
+		for i, p := range decl.Params {
+			names[i] = p.Name
+			params[i] = builtins.TypeRef{Kind: builtins.TypeAny}
+		}
+		native := m.NativeName(decl.Name)
+		call := fmt.Sprintf("%s(%s)", native, strings.Join(names, ", "))
+		if isVoid(decl.Return) {
+			fmt.Fprintf(&b, "\npub fun %s {\n    %s;\n}\n", f.sig, call)
+		} else {
+			fmt.Fprintf(&b, "\npub fun %s {\n    return %s;\n}\n", f.sig, call)
+		}
+		natives = append(natives, builtins.Builtin{
+			Meta: builtins.Meta{
+				ID:           builtins.AutoID,
+				Name:         native,
+				Arity:        len(names),
+				ParamNames:   names,
+				Params:       params,
+				Result:       builtins.TypeRef{Kind: builtins.TypeAny},
+				ReceiverType: builtins.TypeVoid,
+			},
+			Call: nativeCall(f.fn),
+		})
+	}
+	for _, src := range m.source {
+		b.WriteString("\n")
+		b.WriteString(src)
+		b.WriteString("\n")
+	}
+	return b.String(), natives, nil
+}
+
+// parseSignature parses sig as the header of a plain function declaration.
+func parseSignature(sig string) (*ast.FunDecl, error) {
+	p := parser.New(lexer.New("pckg host;\nfun " + sig + " {}\n"))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid signature %q: %s", sig, errs[0])
+	}
+	if len(prog.Funcs) != 1 {
+		return nil, fmt.Errorf("invalid signature %q", sig)
+	}
+	decl := prog.Funcs[0]
+	if decl.Receiver != nil || len(decl.TypeParams) > 0 || decl.VariadicParam != nil || decl.IsAsync {
+		return nil, fmt.Errorf("signature %q: host functions cannot be methods, generic, variadic or async", sig)
+	}
+	for _, param := range decl.Params {
+		if param.Default != nil {
+			return nil, fmt.Errorf("signature %q: host function parameters cannot have defaults", sig)
+		}
+	}
+	return decl, nil
+}
+
+func isVoid(t ast.TypeNode) bool {
+	if t == nil {
+		return true
+	}
+	simple, ok := t.(*ast.SimpleType)
+	return ok && simple.Name == "void"
+}
+
+func nativeCall(fn Func) func(env builtins.Env, args []interface{}) (interface{}, error) {
+	return func(env builtins.Env, args []interface{}) (interface{}, error) {
+		goArgs := make([]any, len(args))
+		for i, arg := range args {
+			goArgs[i] = FromValue(arg.(value.Value))
+		}
+		result, err := fn(goArgs)
+		if err != nil {
+			return value.Value{}, err
+		}
+		return ToValue(result)
+	}
+}
@@ -0,0 +1,161 @@
+package avenir
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"avenir/internal/value"
+)
+
+// Value is a value of the Avenir VM.
+type Value = value.Value
+
+// ToValue converts a Go value to an Avenir value:
+//   - nil becomes none, and a non-nil pointer becomes some(*p)
+//   - bools, strings, []byte, integers and floats map to their Avenir types,
+//     and a *big.Int becomes a bigint
+//   - an error becomes an Avenir error with its message
+//   - slices and arrays become lists, and maps with string keys dicts
+//   - a Value is returned unchanged
+func ToValue(v any) (Value, error) {
+	switch x := v.(type) {
+	case nil:
+		return value.None(), nil
+	case Value:
+		return x, nil
+	case bool:
+		return value.Bool(x), nil
+	case string:
+		return value.Str(x), nil
+	case []byte:
+		return value.Bytes(x), nil
+	case int:
+		return value.Int(int64(x)), nil
+	case int64:
+		return value.Int(x), nil
+	case float64:
+		return value.Float(x), nil
+	case *big.Int:
+		if x == nil {
+			return value.None(), nil
+		}
+		return value.BigInt(new(big.Int).Set(x)), nil
+	case error:
+		return value.ErrorValue(x.Error()), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > 1<<63-1 {
+			return Value{}, fmt.Errorf("cannot convert %d to int: out of range", u)
+		}
+		return value.Int(int64(u)), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(rv.Float()), nil
+	case reflect.Bool:
+		return value.Bool(rv.Bool()), nil
+	case reflect.String:
+		return value.Str(rv.String()), nil
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return value.None(), nil
+		}
+		inner, err := ToValue(rv.Elem().Interface())
+		if err != nil {
+			return Value{}, err
+		}
+		return value.Some(inner), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return value.List(nil), nil
+		}
+		elems := make([]Value, rv.Len())
+		for i := range elems {
+			elem, err := ToValue(rv.Index(i).Interface())
+			if err != nil {
+				return Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			elems[i] = elem
+		}
+		return value.List(elems), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return Value{}, fmt.Errorf("cannot convert %T: dict keys must be strings", v)
+		}
+		entries := make(map[string]Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elem, err := ToValue(iter.Value().Interface())
+			if err != nil {
+				return Value{}, fmt.Errorf("key %q: %w", key, err)
+			}
+			entries[key] = elem
+		}
+		return value.Dict(entries), nil
+	}
+	return Value{}, fmt.Errorf("cannot convert %T to an Avenir value", v)
+}
+
+// FromValue converts an Avenir value to Go: int to int64, float to float64,
+// bigint to *big.Int, decimal to its exact string, string, bool, bytes to
+// []byte, lists and sets to []any, dicts to map[string]any (other keys
+// formatted as strings), none to nil, some(x) to x and errors to error.
+// Structs, closures and futures are returned as a Value, so they can be
+// passed back to Avenir.
+func FromValue(v Value) any {
+	switch v.Kind {
+	case value.KindInt:
+		return v.Int()
+	case value.KindFloat:
+		return v.Float()
+	case value.KindBigInt:
+		return new(big.Int).Set(v.BigInt())
+	case value.KindDecimal:
+		return v.Decimal().String()
+	case value.KindString:
+		return v.Str()
+	case value.KindBool:
+		return v.Bool()
+	case value.KindBytes:
+		return v.Bytes()
+	case value.KindList:
+		list := make([]any, len(v.List()))
+		for i, elem := range v.List() {
+			list[i] = FromValue(elem)
+		}
+		return list
+	case value.KindSet:
+		list := make([]any, 0, v.Set().Len())
+		for elem := range v.Set().All() {
+			list = append(list, FromValue(elem))
+		}
+		return list
+	case value.KindDict:
+		dict := make(map[string]any, v.Dict().Len())
+		for k, elem := range v.Dict().StrMap() {
+			dict[k] = FromValue(elem)
+		}
+		return dict
+	case value.KindOptional:
+		if v.Optional() == nil || !v.Optional().IsSome {
+			return nil
+		}
+		return FromValue(v.Optional().Value)
+	case value.KindError:
+		msg := v.Str()
+		if v.Error() != nil && v.Error().Message != "" {
+			msg = v.Error().Message
+		}
+		return errors.New(msg)
+	case value.KindInvalid:
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,11 @@
+// Package std embeds the sources of the Avenir standard library, so that Go
+// programs embedding Avenir do not need the std directory on disk.
+package std
+
+import "embed"
+
+// FS holds the standard library modules laid out like this directory, e.g.
+// "io/io.av" for std.io.
+//
+//go:embed */*.av */*/*.av
+var FS embed.FS